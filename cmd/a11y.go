@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// a11yFinding is one accessibility problem in a post. Fixed findings were
+// repaired in the content auditAccessibility returns.
+type a11yFinding struct {
+	Line    int
+	Message string
+	Fixed   bool
+}
+
+var (
+	htmlImgRegex        = regexp.MustCompile(`(?i)<img\b[^>]*>`)
+	htmlAltRegex        = regexp.MustCompile(`(?i)\balt\s*=\s*["']\s*[^"'\s]`)
+	markdownImageRegex  = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]+)(?:\s+"([^"]*)")?\)`)
+	tableSeparatorRegex = regexp.MustCompile(`^\s*\|?\s*:?-{3,}:?\s*(\|\s*:?-{3,}:?\s*)*\|?\s*$`)
+	htmlTableRegex      = regexp.MustCompile(`(?is)<table\b.*?</table>`)
+	colorNames          = `red|green|blue|yellow|orange|purple|pink|gr[ae]y`
+	colorPhraseRegex    = regexp.MustCompile(`(?i)\b(?:(?:in|marked|shown|highlighted|colou?red)\s+(?:in\s+)?(?:` + colorNames + `)\b|(?:the\s+)?(?:` + colorNames + `)\s+(?:button|text|line|box|dot|circle|arrow|icon|items?|rows?|cells?|bars?|parts?|sections?|highlights?))`)
+)
+
+// vagueLinkText is link text that says nothing about the destination out of context
+var vagueLinkText = map[string]bool{
+	"here": true, "click here": true, "this": true, "this link": true, "link": true, "this page": true,
+	"read more": true, "more": true, "learn more": true, "click": true, "go": true,
+}
+
+// auditAccessibility checks a post's body for missing alt text, skipped
+// heading levels, vague link text, headerless tables, and color-only
+// phrasing. Skipped headings and alt text that a caption or title already
+// supplies are fixed; the rest is reported.
+func auditAccessibility(content string) (string, []a11yFinding) {
+	var findings []a11yFinding
+	report := func(line int, fixed bool, format string, args ...interface{}) {
+		findings = append(findings, a11yFinding{Line: line, Message: fmt.Sprintf(format, args...), Fixed: fixed})
+	}
+
+	lines := strings.Split(content, "\n")
+	bodyStart := 0
+	if fm := frontMatterBlock(content); fm != "" {
+		bodyStart = strings.Count(fm, "\n") + 1
+	}
+
+	// Heading levels are renumbered against their nearest enclosing heading;
+	// the page title counts as the H1
+	type heading struct{ level, fixed int }
+	var stack []heading
+
+	fence := ""
+	for i := bodyStart; i < len(lines); i++ {
+		n := i + 1
+		line := lines[i]
+		stripped := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(stripped, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(stripped, "```") || strings.HasPrefix(stripped, "~~~") {
+			fence = stripped[:3]
+			continue
+		}
+
+		if m := headingRegex.FindStringSubmatch(line); m != nil {
+			level := len(m[1])
+			for len(stack) > 0 && stack[len(stack)-1].level >= level {
+				stack = stack[:len(stack)-1]
+			}
+			parent := 1
+			if len(stack) > 0 {
+				parent = stack[len(stack)-1].fixed
+			}
+			fixed := level
+			if level > 1 && level > parent+1 {
+				fixed = parent + 1
+				lines[i] = strings.Repeat("#", fixed) + line[level:]
+				report(n, true, "heading skips from H%d to H%d (H%d fits)", parent, level, fixed)
+			}
+			stack = append(stack, heading{level, fixed})
+		}
+
+		lines[i] = markdownImageRegex.ReplaceAllStringFunc(lines[i], func(img string) string {
+			m := markdownImageRegex.FindStringSubmatch(img)
+			if strings.TrimSpace(m[1]) != "" {
+				return img
+			}
+			if title := strings.TrimSpace(m[3]); title != "" {
+				report(n, true, "image %s has no alt text; its title can stand in", m[2])
+				return "![" + title + "](" + m[2] + ` "` + m[3] + `")`
+			}
+			report(n, false, "image %s has no alt text", m[2])
+			return img
+		})
+		lines[i] = figureShortcodeRegex.ReplaceAllStringFunc(lines[i], func(sc string) string {
+			args := shortcodeArgs(sc)
+			if strings.TrimSpace(args["alt"]) != "" {
+				return sc
+			}
+			if caption := strings.TrimSpace(firstNonEmpty(args["caption"], args["title"])); caption != "" && !strings.Contains(caption, `"`) {
+				report(n, true, "figure %s has no alt text; its caption can stand in", args["src"])
+				return strings.TrimSpace(strings.TrimSuffix(sc, ">}}")) + ` alt="` + caption + `" >}}`
+			}
+			report(n, false, "figure %s has no alt text", args["src"])
+			return sc
+		})
+		for _, img := range htmlImgRegex.FindAllString(line, -1) {
+			if !htmlAltRegex.MatchString(img) {
+				report(n, false, "<img> has no alt text")
+			}
+		}
+
+		for _, m := range markdownLinkOrImageRegex.FindAllStringSubmatch(line, -1) {
+			if m[1] == "" && vagueLinkText[strings.ToLower(strings.Trim(strings.TrimSpace(m[2]), ".:!"))] {
+				report(n, false, "link text %q doesn't describe %s; say where the link goes", m[2], m[3])
+			}
+		}
+
+		if i > bodyStart && strings.Contains(line, "|") && tableSeparatorRegex.MatchString(line) {
+			header := strings.Trim(strings.TrimSpace(lines[i-1]), "|")
+			if strings.Contains(lines[i-1], "|") && strings.TrimSpace(strings.ReplaceAll(header, "|", "")) == "" {
+				report(n-1, false, "table has an empty header row")
+			}
+		}
+
+		for _, m := range colorPhraseRegex.FindAllString(line, -1) {
+			report(n, false, "%q relies on color; also describe it by label, position, or shape", m)
+		}
+	}
+
+	body := strings.Join(lines[bodyStart:], "\n")
+	for _, loc := range htmlTableRegex.FindAllStringIndex(body, -1) {
+		if !strings.Contains(strings.ToLower(body[loc[0]:loc[1]]), "<th") {
+			report(bodyStart+strings.Count(body[:loc[0]], "\n")+1, false, "HTML table has no header cells (<th>)")
+		}
+	}
+
+	return strings.Join(lines, "\n"), findings
+}
+
+// applyAccessibility fixes what auditAccessibility can in a generated post and
+// logs the rest for review
+func applyAccessibility(content string) string {
+	fixed, findings := auditAccessibility(content)
+	for _, f := range findings {
+		if f.Fixed {
+			logInfo("♿ Fixed: %s (line %d)", f.Message, f.Line)
+		} else {
+			logInfo("♿ Accessibility: %s (line %d)", f.Message, f.Line)
+		}
+	}
+	return fixed
+}
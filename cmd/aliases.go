@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// hugoPostsPermalinkRegex finds the posts entry of the Hugo permalinks config
+var hugoPostsPermalinkRegex = regexp.MustCompile(`(?s)permalinks.*?\bposts["']?\s*[:=]\s*["']([^"']+)["']`)
+
+// addAliases adds urls to the post's aliases front matter so Hugo redirects
+// them to the post. The post's own URL and URLs already listed are skipped.
+func addAliases(content, ownURL string, urls ...string) string {
+	aliases := frontMatterList(content, "aliases")
+	seen := map[string]bool{strings.Trim(ownURL, "/"): true}
+	for _, a := range aliases {
+		seen[strings.Trim(a, "/")] = true
+	}
+	added := false
+	for _, u := range urls {
+		if u == "" || seen[strings.Trim(u, "/")] {
+			continue
+		}
+		seen[strings.Trim(u, "/")] = true
+		aliases = append(aliases, u)
+		added = true
+	}
+	if !added {
+		return content
+	}
+	return setFrontMatterList(content, "aliases", aliases)
+}
+
+// carryOverURLs gives a post that replaces oldContent the old post's URL and
+// aliases, so links to either keep working
+func carryOverURLs(content, newPath, oldPath, oldContent string) string {
+	urls := append([]string{defaultPostURL(oldPath, oldContent)}, frontMatterList(oldContent, "aliases")...)
+	return addAliases(content, defaultPostURL(newPath, content), urls...)
+}
+
+// previousPostFor returns the existing post megafone last generated from
+// source, according to the site's journal, or "" if there is none
+func previousPostFor(basePath, source string) string {
+	if source == "" {
+		return ""
+	}
+	f, err := os.Open(journalPath(basePath))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	latest := ""
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry journalEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) != nil || filepath.Ext(entry.Path) != ".md" {
+			continue
+		}
+		switch {
+		case entry.Action == "created" && entry.Source == source:
+			latest = entry.Path
+		case entry.Action == "deleted" && entry.Path == latest:
+			latest = ""
+		}
+	}
+	if latest == "" {
+		return ""
+	}
+	path := filepath.Join(basePath, filepath.FromSlash(latest))
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// permalinkFor expands a Hugo-style permalink pattern (:slug, :year, :month,
+// :day, :filename, :section) for a post
+func permalinkFor(pattern, postPath, slug string, date time.Time) string {
+	filename := strings.TrimSuffix(filepath.Base(postPath), filepath.Ext(postPath))
+	if filename == "index" {
+		filename = filepath.Base(filepath.Dir(postPath))
+	}
+	r := strings.NewReplacer(
+		":slug", slug,
+		":filename", filename,
+		":section", "posts",
+		":year", date.Format("2006"),
+		":month", date.Format("01"),
+		":day", date.Format("02"),
+	)
+	return r.Replace(pattern)
+}
+
+// sitePermalink returns the permalink pattern the site serves posts under
+func sitePermalink(basePath string) string {
+	return firstNonEmpty(hugoConfigValue(basePath, hugoPostsPermalinkRegex), "/posts/:slug/")
+}
+
+// postURL returns the URL a post is served at: its url field if it has one,
+// otherwise the site's permalink pattern
+func postURL(basePath, postPath, content string) string {
+	if u := frontMatterString(content, "url"); u != "" {
+		return u
+	}
+	post := parseSitePost(postPath, content)
+	return permalinkFor(sitePermalink(basePath), postPath, post.Slug, post.Time())
+}
@@ -0,0 +1,305 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	archiveSources    bool
+	archiveMaxSources int
+)
+
+var (
+	archiveClient = &http.Client{Timeout: 3 * time.Minute}
+	// waybackSnapshotRegex matches a snapshot URL, capturing the archived URL
+	waybackSnapshotRegex = regexp.MustCompile(`^https?://web\.archive\.org/web/\d{14}/(.+)$`)
+)
+
+// archiveConfig is the archive block of megafone.yaml
+type archiveConfig struct {
+	OnPing bool `yaml:"on_ping"` // archive the posts megafone ping announces
+}
+
+var archiveCmd = &cobra.Command{
+	Use:   "archive <post>...",
+	Short: "Save published posts and their sources to the Wayback Machine",
+	Long: `Submits a published post's URL, and the external pages it links to, to the
+Internet Archive's Wayback Machine, and records the snapshots in front matter:
+archive_url for the post, source_archives for its sources. Sources of news
+commentary often move or disappear; the snapshots keep what the post discussed.
+
+Sources that already have a snapshot in source_archives are skipped. With
+ARCHIVE_ORG_ACCESS_KEY and ARCHIVE_ORG_SECRET_KEY (archive.org/account/s3.php),
+the authenticated Save Page Now API is used, which allows more captures. When a
+capture fails, the latest existing snapshot is recorded instead. With
+archive.on_ping set, 'megafone ping' archives the posts it announces.
+
+Examples:
+  megafone archive content/posts/en/my-post.md
+  megafone archive content/posts/en/my-post.md --sources=false`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runArchive(args); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(archiveCmd)
+
+	archiveCmd.Flags().BoolVar(&archiveSources, "sources", true, "Archive the external pages the post links to as well")
+	archiveCmd.Flags().IntVar(&archiveMaxSources, "max-sources", 20, "Most sources to archive per post")
+	archiveCmd.Flags().StringVarP(&siteSource, "site-source", "s", "", "Path to local Hugo site repository (default: found from the post path)")
+	archiveCmd.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "Show the URLs without archiving them")
+}
+
+func runArchive(postPaths []string) error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	basePath, err := scheduleSitePath(postPaths[0])
+	if err != nil {
+		return err
+	}
+	baseURL := siteBaseURL(basePath)
+	if baseURL == "" {
+		return fmt.Errorf("the Hugo config has no baseURL, so post URLs can't be built")
+	}
+
+	failed := 0
+	for _, postPath := range postPaths {
+		if err := archivePost(context.Background(), basePath, baseURL, postPath); err != nil {
+			logError("Failed to archive %s: %v", postPath, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d posts failed", failed, len(postPaths))
+	}
+	return nil
+}
+
+// archivePost snapshots a post and its sources, recording the snapshots in
+// its front matter. A source that can't be archived is logged and skipped.
+func archivePost(ctx context.Context, basePath, baseURL, postPath string) error {
+	data, err := os.ReadFile(postPath)
+	if err != nil {
+		return fmt.Errorf("failed to read post: %w", err)
+	}
+	content := string(data)
+	if frontMatterString(content, "draft") == "true" {
+		return fmt.Errorf("drafts aren't published, so there's nothing to archive")
+	}
+	link := baseURL + postURL(basePath, postPath, content)
+
+	existing := frontMatterList(content, "source_archives")
+	archived := make(map[string]bool)
+	for _, snapshot := range existing {
+		if m := waybackSnapshotRegex.FindStringSubmatch(snapshot); m != nil {
+			archived[m[1]] = true
+		}
+	}
+	var sources []string
+	if archiveSources {
+		for _, u := range postSourceURLs(content, archiveMaxSources) {
+			if !onSite(u, baseURL) && !archived[u] && !waybackSnapshotRegex.MatchString(u) {
+				sources = append(sources, u)
+			}
+		}
+	}
+
+	logInfo("🏛️  %s: the post and %d sources", postPath, len(sources))
+	if dryRun {
+		for _, u := range append([]string{link}, sources...) {
+			logInfo("  %s", u)
+		}
+		logInfo("Dry run mode - not archiving")
+		return nil
+	}
+
+	postSnapshot, err := archiveURL(ctx, link)
+	if err != nil {
+		logError("Failed to archive %s: %v", link, err)
+	} else {
+		content = upsertFrontMatterField(content, "archive_url", yamlQuote(postSnapshot))
+	}
+	snapshots := existing
+	for _, u := range sources {
+		snapshot, err := archiveURL(ctx, u)
+		if err != nil {
+			logError("Failed to archive %s: %v", u, err)
+			continue
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	if len(snapshots) > len(existing) {
+		content = setFrontMatterList(content, "source_archives", snapshots)
+	}
+	if content == string(data) {
+		return fmt.Errorf("nothing could be archived")
+	}
+
+	if err := os.WriteFile(postPath, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write post: %w", err)
+	}
+	recordSiteChange("modified", postPath, "", false)
+	logSuccess("✅ Archived %s: %d of %d sources", postPath, len(snapshots)-len(existing), len(sources))
+	return nil
+}
+
+// archiveURL captures a page with Save Page Now and returns the snapshot URL.
+// If the capture fails, the page's latest existing snapshot is returned.
+func archiveURL(ctx context.Context, target string) (string, error) {
+	var snapshot string
+	var err error
+	if os.Getenv("ARCHIVE_ORG_ACCESS_KEY") != "" && os.Getenv("ARCHIVE_ORG_SECRET_KEY") != "" {
+		snapshot, err = saveWaybackAuthenticated(ctx, target)
+	} else {
+		snapshot, err = saveWayback(ctx, target)
+	}
+	if err == nil {
+		logVerbose("Archived %s: %s", target, snapshot)
+		return snapshot, nil
+	}
+
+	latest, lookupErr := latestWaybackSnapshot(ctx, target)
+	if lookupErr != nil || latest == "" {
+		return "", err
+	}
+	logInfo("⚠️  Capturing %s failed (%v); using its latest snapshot", target, err)
+	return latest, nil
+}
+
+// saveWayback captures a page anonymously. Save Page Now answers once the
+// capture is done by redirecting to the snapshot.
+func saveWayback(ctx context.Context, target string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://web.archive.org/save/"+target, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := archiveClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("Save Page Now request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return "", classify(ErrRateLimit, fmt.Errorf("Save Page Now is rate limiting captures; try again later or set ARCHIVE_ORG_ACCESS_KEY"))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Save Page Now error: %s", resp.Status)
+	}
+	for _, candidate := range []string{resp.Request.URL.String(), resp.Header.Get("Content-Location")} {
+		if strings.HasPrefix(candidate, "/web/") {
+			candidate = "https://web.archive.org" + candidate
+		}
+		if waybackSnapshotRegex.MatchString(candidate) {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("Save Page Now returned no snapshot")
+}
+
+// saveWaybackAuthenticated starts a capture through the Save Page Now 2 API
+// and waits for it to finish
+func saveWaybackAuthenticated(ctx context.Context, target string) (string, error) {
+	auth := "LOW " + os.Getenv("ARCHIVE_ORG_ACCESS_KEY") + ":" + os.Getenv("ARCHIVE_ORG_SECRET_KEY")
+	form := url.Values{"url": {target}, "if_not_archived_within": {"7d"}}
+	var job struct {
+		JobID   string `json:"job_id"`
+		Message string `json:"message"`
+	}
+	if err := waybackRequest(ctx, http.MethodPost, "https://web.archive.org/save", auth, strings.NewReader(form.Encode()), &job); err != nil {
+		return "", err
+	}
+	if job.JobID == "" {
+		return "", fmt.Errorf("Save Page Now didn't start a capture: %s", firstNonEmpty(job.Message, "no job id"))
+	}
+
+	for i := 0; i < 36; i++ {
+		select {
+		case <-time.After(5 * time.Second):
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+		var status struct {
+			Status      string `json:"status"`
+			Timestamp   string `json:"timestamp"`
+			OriginalURL string `json:"original_url"`
+			Message     string `json:"message"`
+		}
+		if err := waybackRequest(ctx, http.MethodGet, "https://web.archive.org/save/status/"+url.PathEscape(job.JobID), auth, nil, &status); err != nil {
+			return "", err
+		}
+		switch status.Status {
+		case "success":
+			return "https://web.archive.org/web/" + status.Timestamp + "/" + firstNonEmpty(status.OriginalURL, target), nil
+		case "error":
+			return "", fmt.Errorf("capture failed: %s", status.Message)
+		}
+	}
+	return "", fmt.Errorf("capture didn't finish within 3 minutes")
+}
+
+func waybackRequest(ctx context.Context, method, endpoint, auth string, body io.Reader, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", auth)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	resp, err := archiveClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("Save Page Now request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return classify(ErrAuth, fmt.Errorf("archive.org rejected the keys: %s", resp.Status))
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return classify(ErrRateLimit, fmt.Errorf("Save Page Now is rate limiting captures"))
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("Save Page Now error: %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("invalid Save Page Now response: %w", err)
+	}
+	return nil
+}
+
+// latestWaybackSnapshot returns the page's most recent snapshot, or "" if it has none
+func latestWaybackSnapshot(ctx context.Context, target string) (string, error) {
+	var reply struct {
+		ArchivedSnapshots struct {
+			Closest struct {
+				Available bool   `json:"available"`
+				URL       string `json:"url"`
+			} `json:"closest"`
+		} `json:"archived_snapshots"`
+	}
+	if err := getSocialJSON(ctx, "Wayback Machine", "https://archive.org/wayback/available?url="+url.QueryEscape(target), nil, &reply); err != nil {
+		return "", err
+	}
+	closest := reply.ArchivedSnapshots.Closest
+	if !closest.Available {
+		return "", nil
+	}
+	return strings.Replace(closest.URL, "http://", "https://", 1), nil
+}
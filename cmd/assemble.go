@@ -0,0 +1,538 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/spf13/cobra"
+)
+
+const (
+	sectionAI    = "ai"
+	sectionHuman = "human"
+)
+
+var (
+	assembleOutline  bool
+	assembleForce    bool
+	assembleNoPolish bool
+	assembleOutput   string
+)
+
+var (
+	// sectionMarkerRegex matches a section's owner marker: <!-- ai: what to write --> or <!-- human -->
+	sectionMarkerRegex = regexp.MustCompile(`(?s)^<!--\s*(ai|human)\b\s*:?\s*(.*?)\s*-->$`)
+	notesBlockRegex    = regexp.MustCompile(`(?s)^\s*<!--\s*notes:\s*(.*?)\s*-->\s*`)
+)
+
+var assembleCmd = &cobra.Command{
+	Use:   "assemble <workflow.md>",
+	Short: "Write the AI sections of a partly hand-written post, then polish the whole",
+	Long: `Builds a post from a workflow file whose sections are each marked as written
+by the model or by you:
+
+  ---
+  title: "Why I Left Kubernetes for Nomad"
+  tags: ["nomad", "kubernetes"]
+  sources: ["https://developer.hashicorp.com/nomad/docs"]
+  tone: "candid"
+  ---
+
+  <!-- ai: open with the outage that started it -->
+
+  ## What Went Wrong
+  <!-- human -->
+  My own account, which megafone never changes.
+
+  ## How Nomad Differs
+  <!-- ai: compare the scheduling models, citing the sources -->
+
+The model writes only the ai sections, in order, seeing your sections for
+context. A final pass then revises the ai sections so the post reads as one
+piece, with transitions into and out of yours. Unmarked sections count as
+yours. The workflow file is left as it is, so it can be edited and assembled
+again; the post is written to the site (or --output).
+
+With --outline, a workflow file holding only front matter and notes gets an
+outline: the model proposes the sections and marks which need your first-hand
+account. Your notes are kept at the top, in a <!-- notes: --> block.
+
+Examples:
+  megafone assemble nomad.md --outline
+  megafone assemble nomad.md -s ~/hugo
+  megafone assemble nomad.md -o /tmp/nomad.md --no-polish`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runAssemble(cmd, args[0]); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(assembleCmd)
+
+	assembleCmd.Flags().BoolVar(&assembleOutline, "outline", false, "Write an outline of ai and human sections into the workflow file")
+	assembleCmd.Flags().BoolVarP(&assembleForce, "force", "f", false, "With --outline, replace sections the workflow file already has")
+	assembleCmd.Flags().BoolVar(&assembleNoPolish, "no-polish", false, "Skip the final consistency and transitions pass")
+	assembleCmd.Flags().StringVarP(&assembleOutput, "output", "o", "", "Write the post here instead of the site's posts directory")
+	assembleCmd.Flags().StringVarP(&promptFile, "prompt", "p", "prompts/technical-article.txt", "Path to prompt template file")
+	assembleCmd.Flags().StringVarP(&model, "model", "m", "gpt-4o", "OpenAI model to use")
+	assembleCmd.Flags().StringVarP(&siteSource, "site-source", "s", "", "Path to local Hugo site repository (default: the site containing the workflow file)")
+	assembleCmd.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "Print the post (or outline) without writing files")
+}
+
+// workflowSection is a ## section of a workflow file; the intro before the
+// first heading has no heading
+type workflowSection struct {
+	Heading      string
+	Owner        string
+	Instructions string
+	Body         string
+}
+
+// workflowDoc is a parsed workflow file
+type workflowDoc struct {
+	FrontMatter string
+	Notes       string
+	Sections    []*workflowSection
+}
+
+func runAssemble(cmd *cobra.Command, path string) error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read workflow file: %w", err)
+	}
+	doc, err := parseWorkflow(string(data))
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	title := frontMatterString(doc.FrontMatter, "title")
+	if title == "" {
+		return fmt.Errorf("%s needs a title in its front matter", path)
+	}
+	apiKey, err := getOpenAIKey(cmd)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	client := openai.NewClient(apiKey)
+
+	styleGuide, err := loadPrompt(promptFile, "assemble", path)
+	if err != nil {
+		return err
+	}
+	reference := workflowSources(doc.FrontMatter)
+
+	if assembleOutline {
+		return outlineWorkflow(ctx, client, path, doc, styleGuide, reference)
+	}
+
+	if siteSource == "" && assembleOutput == "" {
+		if abs, err := filepath.Abs(path); err == nil {
+			siteSource = findSiteRoot(filepath.Dir(abs))
+		}
+	}
+	// With --output the site is optional; it's still used for dates and shortcodes
+	basePath := ""
+	if assembleOutput == "" || siteSource != "" || siteRepo != "" {
+		if basePath, err = resolveSitePath(); err != nil {
+			return err
+		}
+	}
+
+	var aiSections int
+	for i, s := range doc.Sections {
+		if s.Owner != sectionAI {
+			if strings.TrimSpace(s.Body) == "" {
+				logInfo("⚠️  Your section %q is empty", firstNonEmpty(s.Heading, "intro"))
+			}
+			continue
+		}
+		aiSections++
+		logInfo("✍️  Writing section %q...", firstNonEmpty(s.Heading, "intro"))
+		text, err := writeWorkflowSection(ctx, client, doc, i, title, styleGuide, reference)
+		if err != nil {
+			return classify(ErrGeneration, fmt.Errorf("failed to write section %q: %w", firstNonEmpty(s.Heading, "intro"), err))
+		}
+		s.Body = text
+	}
+	if aiSections == 0 {
+		logInfo("No ai sections to write")
+	}
+
+	description := frontMatterString(doc.FrontMatter, "description")
+	if !assembleNoPolish && aiSections > 0 {
+		logInfo("🪄 Polishing the whole post...")
+		desc, err := polishWorkflow(ctx, client, doc, title, styleGuide)
+		if err != nil {
+			logError("Polish pass failed, keeping the sections as written: %v", err)
+		} else if description == "" {
+			description = desc
+		}
+	}
+
+	content := doc.post()
+	if description != "" {
+		content = upsertFrontMatterField(content, "description", yamlQuote(description))
+	}
+	content = applyDatePolicy(content, postDateString(), basePath)
+	content = applyHighlights(ctx, client, content)
+	if content, err = applySummarySections(ctx, client, content, "assemble"); err != nil {
+		return err
+	}
+	content = applyAccessibility(content)
+	if content, err = applyDisclosure(content, basePath); err != nil {
+		return err
+	}
+	if content, err = applyFrontMatterDefaults(content, "assemble", ""); err != nil {
+		return err
+	}
+	if content, err = applySecretScan(content); err != nil {
+		return err
+	}
+	filename := sanitizeFilename(title)
+	if content, filename, err = applySlugStrategy(content, filename, ""); err != nil {
+		return err
+	}
+
+	if dryRun {
+		logInfo("Dry run mode - not writing files")
+		fmt.Println("\n" + strings.Repeat("=", 80))
+		fmt.Println("DRY RUN - Assembled Post:")
+		fmt.Println(strings.Repeat("=", 80))
+		fmt.Println(content)
+		fmt.Println(strings.Repeat("=", 80))
+		return nil
+	}
+
+	postPath := assembleOutput
+	if postPath == "" {
+		postPath = filepath.Join(postsDir(basePath), filename+".md")
+		if err := os.MkdirAll(filepath.Dir(postPath), 0755); err != nil {
+			return fmt.Errorf("failed to create posts directory: %w", err)
+		}
+	}
+	if err := os.WriteFile(postPath, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write post: %w", err)
+	}
+	recordSiteChange("created", postPath, "", aiSections > 0)
+	logSuccess("✅ Post assembled: %s (%d of %d sections written by the model)", postPath, aiSections, len(doc.Sections))
+	logGeneration(path, postPath, "", frontMatterList(content, "tags"))
+	if basePath == "" {
+		return nil
+	}
+	return runHooks("post_generate", appConfig.Hooks.PostGenerate, hookRun{Post: postPath, Source: path, Site: basePath})
+}
+
+// parseWorkflow splits a workflow file into its front matter, notes, and ##
+// sections. Headings inside code fences don't start sections.
+func parseWorkflow(content string) (*workflowDoc, error) {
+	fm := frontMatterBlock(content)
+	if fm == "" {
+		return nil, fmt.Errorf("workflow file has no front matter")
+	}
+	doc := &workflowDoc{FrontMatter: fm + "\n---\n"}
+	body := content[len(fm)+len("\n---"):]
+	if m := notesBlockRegex.FindStringSubmatch(body); m != nil {
+		doc.Notes = m[1]
+		body = body[len(m[0]):]
+	}
+
+	current := &workflowSection{}
+	inFence := false
+	var lines []string
+	flush := func() {
+		current.Body = strings.Trim(strings.Join(lines, "\n"), "\n")
+		if current.Heading != "" || strings.TrimSpace(current.Body) != "" {
+			doc.Sections = append(doc.Sections, current)
+		}
+		lines = nil
+	}
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+		}
+		if !inFence && strings.HasPrefix(line, "## ") {
+			flush()
+			current = &workflowSection{Heading: strings.TrimSpace(strings.TrimPrefix(line, "## "))}
+			continue
+		}
+		lines = append(lines, line)
+	}
+	flush()
+
+	for _, s := range doc.Sections {
+		s.Owner = sectionHuman
+		trimmed := strings.TrimSpace(s.Body)
+		if !strings.HasPrefix(trimmed, "<!--") {
+			continue
+		}
+		end := strings.Index(trimmed, "-->")
+		if end == -1 {
+			continue
+		}
+		if m := sectionMarkerRegex.FindStringSubmatch(trimmed[:end+3]); m != nil {
+			s.Owner, s.Instructions = m[1], m[2]
+			s.Body = strings.TrimSpace(trimmed[end+3:])
+		}
+	}
+	return doc, nil
+}
+
+// workflowSources fetches the sources listed in the workflow's front matter
+// as reference material for the ai sections
+func workflowSources(frontMatter string) string {
+	var b strings.Builder
+	for _, u := range frontMatterList(frontMatter, "sources") {
+		logInfo("🌐 Reading source %s", u)
+		text, meta, _, err := fetchWebsiteContent(u)
+		if err != nil {
+			logError("Skipping source %s: %v", u, err)
+			continue
+		}
+		fmt.Fprintf(&b, "\n### %s (%s)\n%s\n", firstNonEmpty(meta.Title, u), u, summarizeTokens(3000, text))
+	}
+	return b.String()
+}
+
+// draft renders the document for the model, with each section numbered and
+// labelled; unwritten ai sections show their instructions
+func (d *workflowDoc) draft() string {
+	var b strings.Builder
+	for i, s := range d.Sections {
+		fmt.Fprintf(&b, "\n[SECTION %d, %s]\n", i, strings.ToUpper(s.Owner))
+		if s.Heading != "" {
+			fmt.Fprintf(&b, "## %s\n", s.Heading)
+		}
+		if s.Body != "" {
+			fmt.Fprintf(&b, "%s\n", s.Body)
+		} else {
+			fmt.Fprintf(&b, "(not written yet: %s)\n", firstNonEmpty(s.Instructions, "no instructions"))
+		}
+	}
+	return b.String()
+}
+
+// post is the assembled post, without the workflow's markers and fields
+func (d *workflowDoc) post() string {
+	content := d.FrontMatter
+	for _, key := range []string{"sources", "tone", "length"} {
+		content = removeFrontMatterField(content, key)
+	}
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(content, "\n"))
+	b.WriteString("\n")
+	for _, s := range d.Sections {
+		b.WriteString("\n")
+		if s.Heading != "" {
+			fmt.Fprintf(&b, "## %s\n\n", s.Heading)
+		}
+		if s.Body != "" {
+			fmt.Fprintf(&b, "%s\n", s.Body)
+		}
+	}
+	return b.String()
+}
+
+func (d *workflowDoc) directives() string {
+	var b strings.Builder
+	if tone := frontMatterString(d.FrontMatter, "tone"); tone != "" {
+		fmt.Fprintf(&b, "Tone: %s\n", tone)
+	}
+	if length := frontMatterString(d.FrontMatter, "length"); length != "" {
+		fmt.Fprintf(&b, "Target length of the whole post: %s\n", length)
+	}
+	if d.Notes != "" {
+		fmt.Fprintf(&b, "\nAuthor's notes:\n%s\n", d.Notes)
+	}
+	return b.String()
+}
+
+// writeWorkflowSection writes the body of ai section i, seeing the rest of
+// the document as it stands
+func writeWorkflowSection(ctx context.Context, client *openai.Client, doc *workflowDoc, i int, title, styleGuide, reference string) (string, error) {
+	s := doc.Sections[i]
+	prompt := fmt.Sprintf(`%s
+
+You are co-writing the blog post "%s" with its author. The author writes the
+HUMAN sections; you write the AI sections. Write SECTION %d now.
+
+Instructions for this section: %s
+
+Write only the body of the section: no heading, no front matter. Match the
+author's voice in their sections, don't repeat what other sections say, and
+never put words in the author's mouth about their own experiences.
+%s
+Reference material:
+%s
+
+The post so far:
+%s`, styleGuide, title, i, firstNonEmpty(s.Instructions, "follow from the heading and the surrounding sections"), doc.directives(), firstNonEmpty(reference, "(none)"), doc.draft())
+
+	resp, err := createChatCompletion(ctx, client, openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: "You are a technical writer filling in sections of someone else's post. Output ONLY the section's markdown."},
+			{Role: openai.ChatMessageRoleUser, Content: prompt},
+		},
+		Temperature: generationTemperature,
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("no response from model")
+	}
+	text := strings.TrimSpace(resp.Choices[0].Message.Content)
+	// A heading the model repeats anyway would be doubled
+	if s.Heading != "" {
+		text = strings.TrimSpace(strings.TrimPrefix(text, "## "+s.Heading))
+	}
+	return text, nil
+}
+
+// polishWorkflow revises the ai sections so the post reads as one piece:
+// consistent terms and claims, no repetition, and transitions around the
+// author's sections, which are never changed. It returns a description for
+// the post.
+func polishWorkflow(ctx context.Context, client *openai.Client, doc *workflowDoc, title, styleGuide string) (string, error) {
+	prompt := fmt.Sprintf(`%s
+
+Edit the blog post "%s" below so it reads as one coherent piece. HUMAN sections
+are the author's and are locked: don't return them. Revise only AI sections:
+make terms, names, and claims consistent with the author's sections, remove
+repetition, and smooth the transitions into and out of the author's sections
+(the opening or closing sentences of an AI section are the place for them).
+Keep each AI section's substance and length; don't add headings.
+
+Also write a one-sentence description of the post for its front matter.
+
+Respond with only JSON: {"description": "...", "sections": [{"index": 0, "text": "revised body"}]}
+
+%s`, styleGuide, title, doc.draft())
+
+	resp, err := createChatCompletion(ctx, client, openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleUser, Content: prompt},
+		},
+		Temperature: 0.3,
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("no response from model")
+	}
+	var polished struct {
+		Description string `json:"description"`
+		Sections    []struct {
+			Index int    `json:"index"`
+			Text  string `json:"text"`
+		} `json:"sections"`
+	}
+	if err := decodeModelJSON(resp.Choices[0].Message.Content, &polished); err != nil {
+		return "", err
+	}
+	for _, p := range polished.Sections {
+		// Only ai sections may change, whatever the model returns
+		if p.Index >= 0 && p.Index < len(doc.Sections) && doc.Sections[p.Index].Owner == sectionAI && strings.TrimSpace(p.Text) != "" {
+			doc.Sections[p.Index].Body = strings.TrimSpace(p.Text)
+		}
+	}
+	return polished.Description, nil
+}
+
+// outlineWorkflow has the model propose the post's sections, marking the
+// ones that need the author's first-hand account as human, and writes them
+// into the workflow file
+func outlineWorkflow(ctx context.Context, client *openai.Client, path string, doc *workflowDoc, styleGuide, reference string) error {
+	// Text without a notes block is taken as the notes to outline from
+	if doc.Notes == "" && len(doc.Sections) == 1 && doc.Sections[0].Heading == "" && doc.Sections[0].Instructions == "" {
+		doc.Notes, doc.Sections = doc.Sections[0].Body, nil
+	}
+	if len(doc.Sections) > 0 && !assembleForce {
+		return fmt.Errorf("%s already has sections (use --force to replace them)", path)
+	}
+	title := frontMatterString(doc.FrontMatter, "title")
+
+	prompt := fmt.Sprintf(`%s
+
+Outline the blog post "%s". List its sections in order, starting with an
+untitled intro. Mark each section "human" if it needs the author's own
+experience, opinions, or results (what happened to them, what they decided and
+why, what they measured), and "ai" if it can be written from the notes and
+reference material (background, explanations, comparisons, how-tos). For each,
+give a one-sentence brief of what it should cover.
+%s
+Reference material:
+%s
+
+Respond with only JSON: {"sections": [{"heading": "" for the intro, "owner": "ai" or "human", "brief": "..."}]}`, styleGuide, title, doc.directives(), firstNonEmpty(reference, "(none)"))
+
+	resp, err := createChatCompletion(ctx, client, openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleUser, Content: prompt},
+		},
+		Temperature: 0.5,
+	})
+	if err != nil {
+		return classify(ErrGeneration, err)
+	}
+	if len(resp.Choices) == 0 {
+		return classify(ErrGeneration, fmt.Errorf("no response from model"))
+	}
+	var outline struct {
+		Sections []struct {
+			Heading string `json:"heading"`
+			Owner   string `json:"owner"`
+			Brief   string `json:"brief"`
+		} `json:"sections"`
+	}
+	if err := decodeModelJSON(resp.Choices[0].Message.Content, &outline); err != nil {
+		return classify(ErrGeneration, fmt.Errorf("failed to parse outline: %w", err))
+	}
+	if len(outline.Sections) == 0 {
+		return classify(ErrGeneration, fmt.Errorf("no sections in the outline"))
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(doc.FrontMatter, "\n"))
+	b.WriteString("\n")
+	if doc.Notes != "" {
+		fmt.Fprintf(&b, "\n<!-- notes:\n%s\n-->\n", doc.Notes)
+	}
+	human := 0
+	for _, s := range outline.Sections {
+		b.WriteString("\n")
+		if s.Heading != "" {
+			fmt.Fprintf(&b, "## %s\n", strings.TrimPrefix(s.Heading, "## "))
+		}
+		if s.Owner == sectionHuman {
+			human++
+			fmt.Fprintf(&b, "<!-- human: %s -->\n\n", s.Brief)
+		} else {
+			fmt.Fprintf(&b, "<!-- ai: %s -->\n", s.Brief)
+		}
+	}
+
+	if dryRun {
+		fmt.Println(b.String())
+		return nil
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write outline: %w", err)
+	}
+	logSuccess("✅ Outlined %d sections in %s; %d are yours to write, then run: megafone assemble %s", len(outline.Sections), path, human, path)
+	return nil
+}
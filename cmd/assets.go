@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// siteImageDir returns the Hugo assets directory that hero and body images live in
+func siteImageDir(basePath string) string {
+	return filepath.Join(basePath, "assets", "images", "site")
+}
+
+// writeSiteImage writes image data into the site's image directory. If an
+// identical image already exists under any name it is reused instead, and
+// leftovers from earlier runs with the same slug but another extension are
+// removed unless a post still uses them.
+func writeSiteImage(basePath, imageName string, data []byte) (string, error) {
+	dir := siteImageDir(basePath)
+
+	// Ensure destination directory exists
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	// Strip EXIF/location data before hashing so re-runs dedupe against the cleaned file
+	if stripImageMetadata {
+		data = sanitizeImage(data)
+	}
+	// The copyright stamp is added with or without stripping; it carries the
+	// year, so duplicates are matched on the image without it
+	data = stampImage(data, imageStamp)
+
+	// Concurrent runs could both miss a duplicate, or clear each other's image
+	// as a leftover, so the check and the write happen under the site lock
+	err := withSiteLock(basePath, "writing image "+imageName, func() error {
+		existing, err := findDuplicateImage(dir, data)
+		if err != nil {
+			return err
+		}
+		if existing != "" {
+			logInfo("♻️  Reusing identical image already in assets: %s", existing)
+			imageName = existing
+			return nil
+		}
+
+		removeSlugLeftovers(basePath, imageName)
+
+		if err := os.WriteFile(filepath.Join(dir, imageName), data, 0644); err != nil {
+			return err
+		}
+		recordSiteChange("created", filepath.Join(dir, imageName), "", false)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return imageName, nil
+}
+
+// imageStampSlack is how much two copies of an image may differ in size from
+// their copyright stamps alone
+const imageStampSlack = 4096
+
+// findDuplicateImage returns the name of an image in dir with the same content
+// and credit, ignoring the year of the copyright stamp, comparing sizes first
+// so only plausible matches are hashed. An image credited to another source
+// isn't reused, so a post never ships with the wrong credit.
+func findDuplicateImage(dir string, data []byte) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read image directory: %w", err)
+	}
+
+	var sum [sha256.Size]byte
+	hashed := false
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.Size() < int64(len(data))-imageStampSlack || info.Size() > int64(len(data))+imageStampSlack {
+			continue
+		}
+
+		other, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		if !hashed {
+			sum = sha256.Sum256(withoutImageStamp(data))
+			hashed = true
+		}
+		otherSum := sha256.Sum256(withoutImageStamp(other))
+		if bytes.Equal(sum[:], otherSum[:]) && imageCredit(other) == imageCredit(data) {
+			return entry.Name(), nil
+		}
+	}
+	return "", nil
+}
+
+// removeSlugLeftovers deletes images that share imageName's slug but have a
+// different extension, e.g. foo.jpg left behind when a regeneration produces
+// foo.png. Imports, evergreen rewrites, and stubs reuse slugs, so an image any
+// post still references is kept.
+func removeSlugLeftovers(basePath, imageName string) {
+	ext := filepath.Ext(imageName)
+	slug := strings.TrimSuffix(imageName, ext)
+
+	var usage map[string]int
+	for _, leftoverExt := range []string{".png", ".jpg", ".jpeg", ".webp", ".gif"} {
+		if strings.EqualFold(leftoverExt, ext) {
+			continue
+		}
+		leftover := filepath.Join(siteImageDir(basePath), slug+leftoverExt)
+		if _, err := os.Stat(leftover); err == nil {
+			if usage == nil {
+				usage = countImageUsage(basePath)
+			}
+			if usage[filepath.Base(leftover)] > 0 {
+				logInfo("Keeping %s from a previous run: %d post(s) still use it (assets gc removes it once unused)", filepath.Base(leftover), usage[filepath.Base(leftover)])
+				continue
+			}
+			if err := os.Remove(leftover); err == nil {
+				recordSiteChange("deleted", leftover, "", false)
+				logInfo("🧹 Removed leftover image from a previous run: %s", filepath.Base(leftover))
+			}
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	gcDryRun bool
+	gcYes    bool
+)
+
+var assetsCmd = &cobra.Command{
+	Use:   "assets",
+	Short: "Manage images in the Hugo site's asset store",
+}
+
+var assetsGCCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Delete images that no post references",
+	Long: `Scans assets/images/site for images that are not referenced anywhere in the
+site's content, data, layouts, or config, lists them, and offers to delete them.
+
+Examples:
+  # List unreferenced images without deleting anything
+  megafone assets gc -s ~/hugo --dry-run
+
+  # Delete without prompting
+  megafone assets gc -s ~/hugo --yes`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runAssetsGC(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(assetsCmd)
+	assetsCmd.AddCommand(assetsGCCmd)
+
+	assetsGCCmd.Flags().StringVarP(&siteSource, "site-source", "s", "", "Path to local Hugo site repository")
+	assetsGCCmd.Flags().BoolVarP(&gcDryRun, "dry-run", "d", false, "List unreferenced images without deleting them")
+	assetsGCCmd.Flags().BoolVarP(&gcYes, "yes", "y", false, "Delete without asking for confirmation")
+}
+
+func runAssetsGC() error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+
+	basePath, err := resolveSitePath()
+	if err != nil {
+		return err
+	}
+
+	unreferenced, totalSize, err := findUnreferencedAssets(basePath)
+	if err != nil {
+		return err
+	}
+
+	if len(unreferenced) == 0 {
+		fmt.Println("✨ No unreferenced images found.")
+		return nil
+	}
+
+	fmt.Printf("Found %d unreferenced images (%.1f MB):\n", len(unreferenced), float64(totalSize)/(1024*1024))
+	for _, name := range unreferenced {
+		fmt.Printf("  %s\n", filepath.Join("assets", "images", "site", name))
+	}
+
+	if gcDryRun {
+		fmt.Println("\nDry run - nothing deleted.")
+		return nil
+	}
+
+	if !gcYes && !confirm(fmt.Sprintf("\nDelete %d images?", len(unreferenced))) {
+		fmt.Println("Aborted.")
+		return nil
+	}
+
+	dir := siteImageDir(basePath)
+	for _, name := range unreferenced {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			logError("Failed to delete %s: %v", name, err)
+			continue
+		}
+		recordSiteChange("deleted", filepath.Join(dir, name), "", false)
+		logInfo("🗑️  Deleted unreferenced image: %s", name)
+	}
+	logSuccess("✅ Asset garbage collection complete")
+
+	return nil
+}
+
+// findUnreferencedAssets lists files in the image store whose names do not
+// appear in any content, data, layout, or config file
+func findUnreferencedAssets(basePath string) ([]string, int64, error) {
+	entries, err := os.ReadDir(siteImageDir(basePath))
+	if os.IsNotExist(err) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read image directory: %w", err)
+	}
+
+	candidates := make(map[string]int64)
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		candidates[entry.Name()] = info.Size()
+	}
+
+	searchRoots := []string{"content", "data", "layouts", "config"}
+	for _, root := range searchRoots {
+		err := filepath.WalkDir(filepath.Join(basePath, root), func(path string, d os.DirEntry, err error) error {
+			if err != nil || d.IsDir() || len(candidates) == 0 {
+				return nil
+			}
+			markReferenced(path, candidates)
+			return nil
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	// Top-level site config files can reference images too (e.g. default hero)
+	for _, name := range []string{"hugo.toml", "hugo.yaml", "hugo.json", "config.toml", "config.yaml", "config.json"} {
+		markReferenced(filepath.Join(basePath, name), candidates)
+	}
+
+	var unreferenced []string
+	var totalSize int64
+	for name, size := range candidates {
+		unreferenced = append(unreferenced, name)
+		totalSize += size
+	}
+	sort.Strings(unreferenced)
+
+	return unreferenced, totalSize, nil
+}
+
+// markReferenced removes every candidate whose file name appears in the file at path
+func markReferenced(path string, candidates map[string]int64) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	text := string(data)
+	for name := range candidates {
+		if strings.Contains(text, name) {
+			delete(candidates, name)
+		}
+	}
+}
+
+// confirm asks a yes/no question on stdin, defaulting to no
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N]: ", question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
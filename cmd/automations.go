@@ -0,0 +1,606 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"text/template"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	automationsFile  string
+	automationsWatch bool
+	automationsOnly  string
+	automationsDry   bool
+	automationsJobs  int
+)
+
+var automationsCmd = &cobra.Command{
+	Use:   "automations",
+	Short: "Run the content pipeline declared in an automations file",
+}
+
+var automationsRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Evaluate automation triggers and run the actions of those that fired",
+	Long: `Reads a YAML automations file where each automation has a trigger (a cron
+schedule, a new GitHub release, or a new feed item) and a list of actions
+(generate, notify, or any other megafone subcommand). Trigger state is kept
+next to the file in <file>.state.json so each event fires once.
+
+Example automations.yaml:
+
+  automations:
+    - name: weekly-roundup
+      trigger:
+        schedule: "0 9 * * MON"
+      actions:
+        - generate:
+            topic: "This week in Go tooling"
+            tags: [go, weekly]
+        - notify:
+            webhook: https://hooks.slack.com/services/...
+            message: "Weekly roundup drafted"
+    - name: hugo-releases
+      trigger:
+        release: gohugoio/hugo
+      actions:
+        - generate:
+            topic: "{{.URL}}"
+    - name: upstream-blog
+      trigger:
+        feed: https://go.dev/blog/feed.atom
+      actions:
+        - run: [gap, --competitor, go.dev/blog]
+
+Examples:
+  megafone automations run
+  megafone automations run --file ops/automations.yaml --watch`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runAutomations(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(automationsCmd)
+	automationsCmd.AddCommand(automationsRunCmd)
+
+	automationsRunCmd.Flags().StringVarP(&automationsFile, "file", "f", "automations.yaml", "Path to the automations file")
+	automationsRunCmd.Flags().BoolVarP(&automationsWatch, "watch", "w", false, "Keep running and evaluate triggers every minute")
+	automationsRunCmd.Flags().StringVar(&automationsOnly, "only", "", "Run only the named automation")
+	automationsRunCmd.Flags().BoolVarP(&automationsDry, "dry-run", "d", false, "Report which automations would fire without running actions or saving state")
+	automationsRunCmd.Flags().IntVarP(&automationsJobs, "jobs", "j", 1, "Automations to run at once; provider requests are paced by --tpm-budget and --provider-concurrency")
+}
+
+// automationsConfig is the top-level shape of the automations file
+type automationsConfig struct {
+	Automations []automation `yaml:"automations"`
+}
+
+type automation struct {
+	Name    string             `yaml:"name"`
+	Trigger automationTrigger  `yaml:"trigger"`
+	Actions []automationAction `yaml:"actions"`
+}
+
+// automationTrigger sets exactly one of its fields
+type automationTrigger struct {
+	Schedule string `yaml:"schedule"`
+	Release  string `yaml:"release"`
+	Feed     string `yaml:"feed"`
+}
+
+// automationAction sets exactly one action type
+type automationAction struct {
+	Generate *generateAction `yaml:"generate"`
+	Notify   *notifyAction   `yaml:"notify"`
+	Run      []string        `yaml:"run"`
+}
+
+type generateAction struct {
+	Topic       string   `yaml:"topic"`
+	Tags        []string `yaml:"tags"`
+	Prompt      string   `yaml:"prompt"`
+	Model       string   `yaml:"model"`
+	ImageSource string   `yaml:"image_source"`
+	SiteSource  string   `yaml:"site_source"`
+	Brief       string   `yaml:"brief"`
+	SourceType  string   `yaml:"source_type"`
+	DryRun      bool     `yaml:"dry_run"`
+}
+
+type notifyAction struct {
+	Webhook string `yaml:"webhook"`
+	Message string `yaml:"message"`
+}
+
+// automationEvent describes what fired a trigger; action templates can use its fields
+type automationEvent struct {
+	Name    string
+	Trigger string // schedule, release, or feed
+	Title   string
+	URL     string
+	Time    time.Time
+	// FeedID identifies the feed item that fired the event
+	FeedID string
+}
+
+// automationState records what each automation has already seen
+type automationState struct {
+	LastRun  time.Time `json:"last_run"`
+	Release  string    `json:"release,omitempty"`
+	FeedSeen []string  `json:"feed_seen,omitempty"`
+	// Failures counts the runs in a row whose actions failed; the automation
+	// isn't checked again before RetryAfter
+	Failures   int       `json:"failures,omitempty"`
+	RetryAfter time.Time `json:"retry_after,omitempty"`
+}
+
+const (
+	// automationRetryDelay is the wait after the first failure, doubling with
+	// each failure after that
+	automationRetryDelay = 5 * time.Minute
+	// automationMaxAttempts is how often failed events are tried before they
+	// are given up on and marked seen
+	automationMaxAttempts = 5
+)
+
+func runAutomations() error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+
+	cfg, err := loadAutomations(automationsFile)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return automationsLoop(ctx, cfg, automationsFile+".state.json", automationsWatch)
+}
+
+// automationsLoop evaluates triggers once, or every minute when watch is set
+// until ctx is cancelled. A pass in progress always finishes.
+func automationsLoop(ctx context.Context, cfg *automationsConfig, statePath string, watch bool) error {
+	for {
+		if err := runAutomationsOnce(cfg, statePath, time.Now()); err != nil {
+			if !watch {
+				return err
+			}
+			logError("Automations pass failed: %v", err)
+		}
+		if !watch {
+			return nil
+		}
+		// Wake at the top of the next minute so schedules line up with cron
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(time.Until(time.Now().Truncate(time.Minute).Add(time.Minute))):
+		}
+	}
+}
+
+func loadAutomations(path string) (*automationsConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read automations file: %w", err)
+	}
+
+	var cfg automationsConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse automations file: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for i, a := range cfg.Automations {
+		if a.Name == "" {
+			return nil, fmt.Errorf("automation #%d has no name", i+1)
+		}
+		if seen[a.Name] {
+			return nil, fmt.Errorf("duplicate automation name %q", a.Name)
+		}
+		seen[a.Name] = true
+
+		set := 0
+		for _, v := range []string{a.Trigger.Schedule, a.Trigger.Release, a.Trigger.Feed} {
+			if v != "" {
+				set++
+			}
+		}
+		if set != 1 {
+			return nil, fmt.Errorf("automation %q must have exactly one trigger (schedule, release, or feed)", a.Name)
+		}
+		if a.Trigger.Schedule != "" {
+			if _, err := parseCron(a.Trigger.Schedule); err != nil {
+				return nil, fmt.Errorf("automation %q: %w", a.Name, err)
+			}
+		}
+		if len(a.Actions) == 0 {
+			return nil, fmt.Errorf("automation %q has no actions", a.Name)
+		}
+	}
+	return &cfg, nil
+}
+
+func runAutomationsOnce(cfg *automationsConfig, statePath string, now time.Time) error {
+	state, err := loadAutomationState(statePath)
+	if err != nil {
+		return err
+	}
+
+	var (
+		failed []string
+		// advanced holds the new state of each automation, only past the
+		// events that ran, so failed ones fire again on the next run
+		advanced = make(map[string]automationState)
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+	)
+	fail := func(name string) {
+		mu.Lock()
+		failed = append(failed, name)
+		mu.Unlock()
+	}
+	// Each automation's events run in order; separate automations interleave up to --jobs
+	slots := make(chan struct{}, max(automationsJobs, 1))
+
+	for _, a := range cfg.Automations {
+		if automationsOnly != "" && a.Name != automationsOnly {
+			continue
+		}
+
+		prev := state[a.Name]
+		if now.Before(prev.RetryAfter) {
+			logVerbose("Automation %s failed %d time(s); next try after %s", a.Name, prev.Failures, prev.RetryAfter.Format(time.RFC3339))
+			continue
+		}
+		events, next, err := evaluateTrigger(a, prev, now)
+		if err != nil {
+			logError("Automation %s: trigger check failed: %v", a.Name, err)
+			fail(a.Name)
+			continue
+		}
+
+		for _, ev := range events {
+			logInfo("⚡ Automation %s fired: %s", a.Name, firstNonEmpty(ev.Title, ev.URL, "schedule"))
+		}
+		if automationsDry {
+			continue
+		}
+		next.Failures, next.RetryAfter = 0, time.Time{}
+		if len(events) == 0 {
+			advanced[a.Name] = next
+			continue
+		}
+
+		wg.Add(1)
+		go func(a automation, events []automationEvent, prev, next automationState) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			for i, ev := range events {
+				if err := runAutomationActions(a, ev); err != nil {
+					logError("Automation %s: %v", a.Name, err)
+					fail(a.Name)
+					next = retryAutomationState(a.Name, prev, next, events[i:], now)
+					break
+				}
+			}
+			mu.Lock()
+			advanced[a.Name] = next
+			mu.Unlock()
+		}(a, events, prev, next)
+	}
+	wg.Wait()
+	sort.Strings(failed)
+	for name, next := range advanced {
+		state[name] = next
+	}
+
+	if !automationsDry {
+		if err := saveAutomationState(statePath, state); err != nil {
+			return err
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("automations failed: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// retryAutomationState returns the state to save when the events in pending
+// failed: they stay pending and are tried again after a growing delay, until
+// automationMaxAttempts failures in a row, when they're given up on
+func retryAutomationState(name string, prev, next automationState, pending []automationEvent, now time.Time) automationState {
+	failures := prev.Failures + 1
+	if failures >= automationMaxAttempts {
+		logError("Automation %s failed %d times in a row; skipping %d event(s)", name, failures, len(pending))
+		return next
+	}
+	retry := pendingAutomationState(prev, next, pending)
+	retry.Failures = failures
+	retry.RetryAfter = now.Add(automationRetryDelay << (failures - 1))
+	logInfo("Automation %s will retry after %s", name, retry.RetryAfter.Format(time.RFC3339))
+	return retry
+}
+
+// pendingAutomationState returns the state to save when the events in pending
+// didn't run: feed items stay unseen so they fire again, and a schedule or
+// release keeps its previous state
+func pendingAutomationState(prev, next automationState, pending []automationEvent) automationState {
+	if len(pending) == 0 || pending[0].Trigger != "feed" {
+		return prev
+	}
+	unrun := make(map[string]bool, len(pending))
+	for _, ev := range pending {
+		unrun[ev.FeedID] = true
+	}
+	seen := next.FeedSeen
+	next.FeedSeen = nil
+	for _, id := range seen {
+		if !unrun[id] {
+			next.FeedSeen = append(next.FeedSeen, id)
+		}
+	}
+	return next
+}
+
+// evaluateTrigger returns the events that fired since prev and the updated state.
+// A trigger seen for the first time only records a baseline so existing
+// releases and feed items don't all fire at once.
+func evaluateTrigger(a automation, prev automationState, now time.Time) ([]automationEvent, automationState, error) {
+	next := prev
+	next.LastRun = now
+	first := prev.LastRun.IsZero()
+
+	switch {
+	case a.Trigger.Schedule != "":
+		schedule, err := parseCron(a.Trigger.Schedule)
+		if err != nil {
+			return nil, prev, err
+		}
+		if first || !schedule.firedBetween(prev.LastRun, now) {
+			return nil, next, nil
+		}
+		return []automationEvent{{Name: a.Name, Trigger: "schedule", Time: now}}, next, nil
+
+	case a.Trigger.Release != "":
+		owner, repo, ok := strings.Cut(a.Trigger.Release, "/")
+		if !ok {
+			return nil, prev, fmt.Errorf("release trigger must be owner/repo, got %q", a.Trigger.Release)
+		}
+		release, _, err := newGitHubClient().Repositories.GetLatestRelease(context.Background(), owner, repo)
+		if err != nil {
+			return nil, prev, fmt.Errorf("failed to fetch latest release: %w", err)
+		}
+		next.Release = release.GetTagName()
+		if first || next.Release == prev.Release {
+			return nil, next, nil
+		}
+		return []automationEvent{{
+			Name:    a.Name,
+			Trigger: "release",
+			Title:   firstNonEmpty(release.GetName(), release.GetTagName()),
+			URL:     release.GetHTMLURL(),
+			Time:    now,
+		}}, next, nil
+
+	default:
+		items, err := fetchFeed(a.Trigger.Feed)
+		if err != nil {
+			return nil, prev, err
+		}
+		seen := make(map[string]bool, len(prev.FeedSeen))
+		for _, id := range prev.FeedSeen {
+			seen[id] = true
+		}
+
+		var events []automationEvent
+		next.FeedSeen = nil
+		for _, item := range items {
+			next.FeedSeen = append(next.FeedSeen, item.id())
+			if !first && !seen[item.id()] {
+				events = append(events, automationEvent{Name: a.Name, Trigger: "feed", Title: item.Title, URL: item.Link, Time: now, FeedID: item.id()})
+			}
+		}
+		return events, next, nil
+	}
+}
+
+func runAutomationActions(a automation, ev automationEvent) error {
+	for i, action := range a.Actions {
+		var err error
+		switch {
+		case action.Generate != nil:
+			err = runGenerateAction(*action.Generate, ev)
+		case action.Notify != nil:
+			err = runNotifyAction(*action.Notify, ev)
+		case len(action.Run) > 0:
+			err = runSubcommandAction(action.Run, ev)
+		default:
+			err = fmt.Errorf("unknown action type (supported: generate, notify, run)")
+		}
+		if err != nil {
+			return fmt.Errorf("action %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+func runGenerateAction(g generateAction, ev automationEvent) error {
+	topic, err := expandAutomationTemplate(g.Topic, ev)
+	if err != nil {
+		return err
+	}
+
+	args := []string{"generate"}
+	if topic != "" {
+		args = append(args, "--topic", topic)
+	}
+	if len(g.Tags) > 0 {
+		args = append(args, "--tags", strings.Join(g.Tags, ","))
+	}
+	if g.Prompt != "" {
+		args = append(args, "--prompt", g.Prompt)
+	}
+	if g.Model != "" {
+		args = append(args, "--model", g.Model)
+	}
+	if g.ImageSource != "" {
+		args = append(args, "--image-source", g.ImageSource)
+	}
+	if g.SiteSource != "" {
+		args = append(args, "--site-source", g.SiteSource)
+	}
+	if g.Brief != "" {
+		args = append(args, "--brief", g.Brief)
+	}
+	// Feed items get the feed settings block unless the action says otherwise
+	kind := g.SourceType
+	if kind == "" && ev.Trigger == "feed" {
+		kind = "feed"
+	}
+	if kind != "" {
+		args = append(args, "--source-type", kind)
+	}
+	if g.DryRun {
+		args = append(args, "--dry-run")
+	}
+	return runSelf(args)
+}
+
+// runSubcommandAction runs any megafone subcommand, so actions like promote or
+// digest work as soon as the subcommand exists
+func runSubcommandAction(args []string, ev automationEvent) error {
+	expanded := make([]string, len(args))
+	for i, arg := range args {
+		s, err := expandAutomationTemplate(arg, ev)
+		if err != nil {
+			return err
+		}
+		expanded[i] = s
+	}
+	return runSelf(expanded)
+}
+
+// runSelf runs this binary with args in a child process, keeping each action's
+// flag state isolated. Global flags given to this run (--tpm-budget, --config,
+// --lock-timeout, ...) reach the child through the environment, so pacing and
+// locking span every action.
+func runSelf(args []string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate megafone binary: %w", err)
+	}
+	logInfo("▶️  megafone %s", strings.Join(args, " "))
+
+	// Posts streamed by parallel actions would interleave on one terminal
+	at := len(args)
+	if i := slices.Index(args, "--"); i >= 0 {
+		at = i
+	}
+	args = slices.Insert(slices.Clone(args), at, "--quiet")
+
+	c := exec.Command(exe, args...)
+	c.Env = append(os.Environ(), changedRootFlagEnv()...)
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("megafone %s failed: %w", args[0], err)
+	}
+	return nil
+}
+
+// runNotifyAction posts a Slack-compatible {"text": ...} payload to a webhook
+func runNotifyAction(n notifyAction, ev automationEvent) error {
+	if n.Webhook == "" {
+		return fmt.Errorf("notify action requires a webhook")
+	}
+	message, err := expandAutomationTemplate(firstNonEmpty(n.Message, "Automation {{.Name}} ran"), ev)
+	if err != nil {
+		return err
+	}
+	if err := sendWebhookText(n.Webhook, message); err != nil {
+		return err
+	}
+	logSuccess("Sent notification for %s", ev.Name)
+	return nil
+}
+
+// sendWebhookText posts a Slack-compatible {"text": ...} payload. Environment
+// variables in the webhook URL are expanded, so secrets can stay out of config.
+func sendWebhookText(webhook, message string) error {
+	payload, err := json.Marshal(map[string]string{"text": message})
+	if err != nil {
+		return err
+	}
+	resp, err := http.Post(os.ExpandEnv(webhook), "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("notification webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+func expandAutomationTemplate(text string, ev automationEvent) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	tmpl, err := template.New("action").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid template %q: %w", text, err)
+	}
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, ev); err != nil {
+		return "", fmt.Errorf("failed to expand template %q: %w", text, err)
+	}
+	return buf.String(), nil
+}
+
+func loadAutomationState(path string) (map[string]automationState, error) {
+	state := make(map[string]automationState)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read automation state: %w", err)
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse automation state: %w", err)
+	}
+	return state, nil
+}
+
+func saveAutomationState(path string, state map[string]automationState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to save automation state: %w", err)
+	}
+	return nil
+}
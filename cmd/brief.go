@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var briefPath string
+
+// contentBrief is a structured editorial brief that steers research and generation
+type contentBrief struct {
+	Topic             string   `yaml:"topic"`
+	TargetKeyword     string   `yaml:"target_keyword"`
+	SecondaryKeywords []string `yaml:"secondary_keywords"`
+	Audience          string   `yaml:"audience"`
+	KeyPoints         []string `yaml:"key_points"`
+	Competitors       []string `yaml:"competitors"`
+	Notes             string   `yaml:"notes"`
+}
+
+// loadBrief reads a brief from a YAML file, or from a Notion database when
+// the argument has the form notion:<database-id>
+func loadBrief(source string) (*contentBrief, error) {
+	if strings.HasPrefix(source, "notion:") {
+		return loadNotionBrief(strings.TrimPrefix(source, "notion:"))
+	}
+
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read brief: %w", err)
+	}
+
+	var brief contentBrief
+	if err := yaml.Unmarshal(data, &brief); err != nil {
+		return nil, fmt.Errorf("failed to parse brief: %w", err)
+	}
+	return &brief, nil
+}
+
+// topic returns what the brief is about, for runs without --topic
+func (b *contentBrief) topic() string {
+	return firstNonEmpty(b.Topic, b.TargetKeyword)
+}
+
+// researchGuidance steers the research call toward the brief's audience and points
+func (b *contentBrief) researchGuidance() string {
+	var s strings.Builder
+	if b.Audience != "" {
+		s.WriteString(fmt.Sprintf("\nThe audience is: %s. Pitch the depth accordingly.", b.Audience))
+	}
+	if len(b.KeyPoints) > 0 {
+		s.WriteString("\nMake sure the research covers these points in depth:\n")
+		for _, point := range b.KeyPoints {
+			s.WriteString("- " + point + "\n")
+		}
+	}
+	return s.String()
+}
+
+// promptSection renders the brief for the generation prompt, including an
+// outline of each competing article so the post can go beyond them
+func (b *contentBrief) promptSection() string {
+	var s strings.Builder
+	s.WriteString("\n\n## Content Brief (follow this brief)\n")
+	if b.TargetKeyword != "" {
+		s.WriteString(fmt.Sprintf("- Target keyword: %s (use it in the title, description, and first paragraph naturally)\n", b.TargetKeyword))
+	}
+	if len(b.SecondaryKeywords) > 0 {
+		s.WriteString(fmt.Sprintf("- Secondary keywords: %s\n", strings.Join(b.SecondaryKeywords, ", ")))
+	}
+	if b.Audience != "" {
+		s.WriteString(fmt.Sprintf("- Audience: %s\n", b.Audience))
+	}
+	if len(b.KeyPoints) > 0 {
+		s.WriteString("- Key points that MUST be covered:\n")
+		for _, point := range b.KeyPoints {
+			s.WriteString("  - " + point + "\n")
+		}
+	}
+	if b.Notes != "" {
+		s.WriteString("\n### Editor's notes\n" + b.Notes + "\n")
+	}
+
+	for _, competitor := range b.Competitors {
+		logInfo("🥊 Fetching competing article: %s", competitor)
+		_, meta, html, err := fetchWebsiteContent(competitor)
+		if err != nil {
+			logError("Failed to fetch competitor %s: %v", competitor, err)
+			continue
+		}
+		s.WriteString(fmt.Sprintf("\n### Competing article to outdo: %s (%s)\n", meta.Title, competitor))
+		for _, heading := range extractHeadings(html) {
+			s.WriteString("- " + heading + "\n")
+		}
+		s.WriteString("Cover everything this article covers, then go further: fill its gaps, add concrete examples, and be more accurate.\n")
+	}
+
+	return s.String()
+}
+
+// extractHeadings returns the h2/h3 outline of an HTML page
+func extractHeadings(html string) []string {
+	headingRegex := regexp.MustCompile(`(?is)<h([23])[^>]*>(.*?)</h[23]>`)
+	var headings []string
+	for _, matches := range headingRegex.FindAllStringSubmatch(html, -1) {
+		text := cleanCaptionText(matches[2])
+		if text == "" {
+			continue
+		}
+		if matches[1] == "3" {
+			text = "  " + text
+		}
+		headings = append(headings, text)
+		if len(headings) >= 40 {
+			break
+		}
+	}
+	return headings
+}
+
+// loadNotionBrief pulls the first row of a Notion database whose Status is
+// "Ready" (or the first row if the database has no Status property).
+// Expected properties: Name/Title, Target Keyword, Audience, Key Points,
+// Competitors, Notes. Multi-value text properties are split on newlines.
+func loadNotionBrief(databaseID string) (*contentBrief, error) {
+	token := os.Getenv("NOTION_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("NOTION_TOKEN env var required for Notion briefs")
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{"page_size": 25})
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("https://api.notion.com/v1/databases/%s/query", databaseID), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Notion-Version", "2022-06-28")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Notion: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("Notion API error: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	var result struct {
+		Results []struct {
+			Properties map[string]notionProperty `json:"properties"`
+		} `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode Notion response: %w", err)
+	}
+
+	for _, row := range result.Results {
+		props := row.Properties
+		if status, ok := props["Status"]; ok && !strings.EqualFold(status.text(), "ready") {
+			continue
+		}
+
+		brief := &contentBrief{
+			Topic:         firstNonEmpty(props["Name"].text(), props["Title"].text()),
+			TargetKeyword: props["Target Keyword"].text(),
+			Audience:      props["Audience"].text(),
+			KeyPoints:     splitLines(props["Key Points"].text()),
+			Competitors:   splitLines(props["Competitors"].text()),
+			Notes:         props["Notes"].text(),
+		}
+		logInfo("📓 Loaded brief from Notion: %s", brief.topic())
+		return brief, nil
+	}
+
+	return nil, fmt.Errorf("no ready briefs found in Notion database %s", databaseID)
+}
+
+// notionProperty covers the property types a brief database is likely to use
+type notionProperty struct {
+	Type     string           `json:"type"`
+	Title    []notionRichText `json:"title"`
+	RichText []notionRichText `json:"rich_text"`
+	URL      string           `json:"url"`
+	Select   *struct {
+		Name string `json:"name"`
+	} `json:"select"`
+	Status *struct {
+		Name string `json:"name"`
+	} `json:"status"`
+	MultiSelect []struct {
+		Name string `json:"name"`
+	} `json:"multi_select"`
+}
+
+type notionRichText struct {
+	PlainText string `json:"plain_text"`
+}
+
+func (p notionProperty) text() string {
+	switch p.Type {
+	case "title":
+		return joinRichText(p.Title)
+	case "rich_text":
+		return joinRichText(p.RichText)
+	case "url":
+		return p.URL
+	case "select":
+		if p.Select != nil {
+			return p.Select.Name
+		}
+	case "status":
+		if p.Status != nil {
+			return p.Status.Name
+		}
+	case "multi_select":
+		var names []string
+		for _, o := range p.MultiSelect {
+			names = append(names, o.Name)
+		}
+		return strings.Join(names, "\n")
+	}
+	return ""
+}
+
+func joinRichText(parts []notionRichText) string {
+	var s strings.Builder
+	for _, part := range parts {
+		s.WriteString(part.PlainText)
+	}
+	return strings.TrimSpace(s.String())
+}
+
+func splitLines(s string) []string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "-*•"))
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
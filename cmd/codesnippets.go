@@ -0,0 +1,273 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/sashabaranov/go-openai"
+)
+
+// codeSnippets is how many source excerpts a repo post may quote (0: none)
+var codeSnippets int
+
+const (
+	// snippetMaxLines keeps quotes short enough to be fair use and readable
+	snippetMaxLines = 20
+	// snippetMaxFiles is how many source files are read to find snippets
+	snippetMaxFiles = 8
+)
+
+// snippetLanguages maps source extensions to code fence languages
+var snippetLanguages = map[string]string{
+	".go": "go", ".rs": "rust", ".py": "python", ".js": "javascript", ".mjs": "javascript",
+	".ts": "typescript", ".tsx": "tsx", ".jsx": "jsx", ".rb": "ruby", ".java": "java",
+	".kt": "kotlin", ".swift": "swift", ".c": "c", ".h": "c", ".cc": "cpp", ".cpp": "cpp",
+	".hpp": "cpp", ".cs": "csharp", ".php": "php", ".ex": "elixir", ".exs": "elixir",
+	".zig": "zig", ".scala": "scala", ".hs": "haskell", ".lua": "lua", ".sh": "bash",
+	".ml": "ocaml", ".clj": "clojure", ".dart": "dart", ".nim": "nim", ".jl": "julia",
+}
+
+// snippetSkipRegex excludes paths unlikely to show the interesting parts of a project
+var snippetSkipRegex = regexp.MustCompile(`(?i)(^|/)(vendor|node_modules|third_party|testdata|dist|build|examples?/.*/gen|\.github)/|_test\.|\.test\.|\.spec\.|_pb2?\.|\.pb\.go$|generated|\.min\.`)
+
+// entryPointRegex matches the usual entry points of a project
+var entryPointRegex = regexp.MustCompile(`(?i)(^|/)(main\.(go|rs|py|c|cc|cpp|ts|js)|lib\.rs|__main__\.py|cli\.(py|ts|js)|index\.(ts|js)|app\.(py|ts|js)|mod\.rs)$`)
+
+// codeSnippet is an excerpt of a repository file pinned to a commit
+type codeSnippet struct {
+	Path      string
+	Start     int
+	End       int
+	Code      string
+	Language  string
+	Permalink string
+	Why       string
+}
+
+// findCodeSnippets picks short excerpts of a repository's source worth
+// quoting in a post about it. The model chooses files from the tree, the
+// README, and code search, then chooses line ranges; the code itself is
+// always copied from the file, never from the model.
+func findCodeSnippets(ctx context.Context, apiKey string, gh *github.Client, repoData *github.Repository, readme string, limit int) ([]codeSnippet, error) {
+	owner, repo := repoData.GetOwner().GetLogin(), repoData.GetName()
+	branch, _, err := gh.Repositories.GetBranch(ctx, owner, repo, repoData.GetDefaultBranch(), 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch the default branch: %w", err)
+	}
+	sha := branch.GetCommit().GetSHA()
+
+	tree, _, err := gh.Git.GetTree(ctx, owner, repo, sha, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repository files: %w", err)
+	}
+	var sources []string
+	for _, e := range tree.Entries {
+		p := e.GetPath()
+		if e.GetType() == "blob" && snippetLanguages[strings.ToLower(path.Ext(p))] != "" && !snippetSkipRegex.MatchString(p) && e.GetSize() < 200<<10 {
+			sources = append(sources, p)
+		}
+	}
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no source files found")
+	}
+	// Entry points and shallow files first, so a big tree's listing keeps them
+	sort.SliceStable(sources, func(i, j int) bool {
+		ei, ej := entryPointRegex.MatchString(sources[i]), entryPointRegex.MatchString(sources[j])
+		if ei != ej {
+			return ei
+		}
+		return strings.Count(sources[i], "/") < strings.Count(sources[j], "/")
+	})
+	if len(sources) > 400 {
+		sources = sources[:400]
+	}
+
+	client := openai.NewClient(apiKey)
+	var pick struct {
+		Files    []string `json:"files"`
+		Searches []string `json:"searches"`
+	}
+	if err := snippetModelJSON(ctx, client, fmt.Sprintf(`A blog post will spotlight the GitHub project %s: %s
+
+Pick up to %d source files worth reading to find short, concrete code excerpts
+that show how the project works: entry points, the core algorithm or data
+structure, the public API a user calls. Also suggest up to 3 identifiers
+(function or type names) to look up with code search.
+
+Respond with only JSON: {"files": ["path", ...], "searches": ["identifier", ...]}
+
+README:
+%s
+
+Source files:
+%s`, repoData.GetFullName(), repoData.GetDescription(), snippetMaxFiles, summarizeTokens(4000, readme), strings.Join(sources, "\n")), &pick); err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]bool)
+	for _, p := range sources {
+		known[p] = true
+	}
+	var files []string
+	add := func(p string) {
+		if known[p] && len(files) < snippetMaxFiles {
+			known[p] = false
+			files = append(files, p)
+		}
+	}
+	for _, p := range pick.Files {
+		add(strings.TrimPrefix(p, "/"))
+	}
+	for _, q := range pick.Searches {
+		// Code search needs a token and is rate limited; it only adds candidates
+		result, _, err := gh.Search.Code(ctx, fmt.Sprintf("%s repo:%s", q, repoData.GetFullName()), &github.SearchOptions{ListOptions: github.ListOptions{PerPage: 3}})
+		if err != nil {
+			logVerbose("Code search for %q failed: %v", q, err)
+			continue
+		}
+		for _, r := range result.CodeResults {
+			add(r.GetPath())
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no files chosen")
+	}
+
+	raw := func(p string) string {
+		return fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s/%s", owner, repo, sha, p)
+	}
+	fileLines := make(map[string][]string)
+	var listing strings.Builder
+	for _, p := range files {
+		data, err := fetchRepoFile(raw(p))
+		if err != nil {
+			logVerbose("Failed to fetch %s: %v", p, err)
+			continue
+		}
+		lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+		fileLines[p] = lines
+		fmt.Fprintf(&listing, "=== %s\n", p)
+		for i, line := range lines {
+			if i >= 600 {
+				listing.WriteString("... [file truncated]\n")
+				break
+			}
+			fmt.Fprintf(&listing, "%d: %s\n", i+1, line)
+		}
+	}
+	logInfo("🧩 Reading %d source files for code snippets", len(fileLines))
+
+	var chosen struct {
+		Snippets []struct {
+			Path  string `json:"path"`
+			Start int    `json:"start"`
+			End   int    `json:"end"`
+			Why   string `json:"why"`
+		} `json:"snippets"`
+	}
+	if err := snippetModelJSON(ctx, client, fmt.Sprintf(`Choose up to %d excerpts from these files of %s to quote in a blog post
+about the project. Each must be at most %d lines, self-contained (a whole small
+function, type, or block), and show something a reader learns from: how the
+core works, or what using the API looks like. Prefer variety across files.
+
+Respond with only JSON: {"snippets": [{"path": "...", "start": 12, "end": 28, "why": "what it shows, one sentence"}]}
+
+%s`, limit, repoData.GetFullName(), snippetMaxLines, summarizeTokens(24000, listing.String())), &chosen); err != nil {
+		return nil, err
+	}
+
+	var snippets []codeSnippet
+	for _, c := range chosen.Snippets {
+		lines, ok := fileLines[c.Path]
+		if !ok || c.Start < 1 || c.End < c.Start || c.Start > len(lines) {
+			continue
+		}
+		if c.End > len(lines) {
+			c.End = len(lines)
+		}
+		if c.End-c.Start+1 > snippetMaxLines {
+			c.End = c.Start + snippetMaxLines - 1
+		}
+		snippets = append(snippets, codeSnippet{
+			Path:      c.Path,
+			Start:     c.Start,
+			End:       c.End,
+			Code:      dedent(strings.Join(lines[c.Start-1:c.End], "\n")),
+			Language:  snippetLanguages[strings.ToLower(path.Ext(c.Path))],
+			Permalink: fmt.Sprintf("https://github.com/%s/%s/blob/%s/%s#L%d-L%d", owner, repo, sha, c.Path, c.Start, c.End),
+			Why:       c.Why,
+		})
+		if len(snippets) == limit {
+			break
+		}
+	}
+	if len(snippets) == 0 {
+		return nil, fmt.Errorf("no usable snippets chosen")
+	}
+	return snippets, nil
+}
+
+func snippetModelJSON(ctx context.Context, client *openai.Client, prompt string, v interface{}) error {
+	resp, err := createChatCompletion(ctx, client, openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleUser, Content: prompt},
+		},
+		Temperature: 0.2,
+	})
+	if err != nil {
+		return err
+	}
+	if len(resp.Choices) == 0 {
+		return fmt.Errorf("no response from model")
+	}
+	return decodeModelJSON(resp.Choices[0].Message.Content, v)
+}
+
+// dedent removes the indentation the lines of a snippet share
+func dedent(code string) string {
+	lines := strings.Split(code, "\n")
+	common := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if common < 0 || indent < common {
+			common = indent
+		}
+	}
+	if common <= 0 {
+		return code
+	}
+	for i, line := range lines {
+		if len(line) >= common {
+			lines[i] = line[common:]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// snippetPromptSection gives the writer the snippets to quote, each with the
+// attribution line that must follow it
+func snippetPromptSection(snippets []codeSnippet) string {
+	var b strings.Builder
+	b.WriteString(`
+
+## Code From the Repository
+These excerpts are copied from the project's source at a fixed commit. Quote
+the ones that help explain the project, where they fit in the post, and
+explain what each shows. Copy the code exactly (trimming lines from the start or
+end is fine; never change code or invent code presented as the project's), and
+put its attribution line, unchanged, directly under the code block.
+`)
+	for _, s := range snippets {
+		fmt.Fprintf(&b, "\n### %s (%s)\n```%s\n%s\n```\n*Source: [`%s`, lines %d–%d](%s)*\n", s.Path, s.Why, s.Language, s.Code, s.Path, s.Start, s.End, s.Permalink)
+	}
+	return b.String()
+}
@@ -0,0 +1,372 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/spf13/cobra"
+)
+
+const (
+	commentsGiscus     = "giscus"
+	commentsUtterances = "utterances"
+	commentsMastodon   = "mastodon"
+)
+
+var commentsSystem string
+
+// commentsConfig is the comments block of megafone.yaml, describing the
+// site's comment system so megafone can start each post's thread
+type commentsConfig struct {
+	System   string `yaml:"system"`   // giscus, utterances, or mastodon
+	Repo     string `yaml:"repo"`     // giscus and utterances: owner/name
+	Category string `yaml:"category"` // giscus: discussion category (default Announcements)
+	Label    string `yaml:"label"`    // utterances: label for new issues
+	Mapping  string `yaml:"mapping"`  // giscus and utterances: pathname (default), url, or number
+	OnPing   bool   `yaml:"on_ping"`  // seed threads for the posts megafone ping announces
+}
+
+var commentsCmd = &cobra.Command{
+	Use:   "comments",
+	Short: "Manage the comment threads of posts",
+}
+
+var commentsSeedCmd = &cobra.Command{
+	Use:   "seed <post>...",
+	Short: "Create the comment thread for published posts",
+	Long: `Creates the discussion a post's comments live in, so they work from the
+first visitor on, and records it in the post's front matter:
+
+  giscus      a GitHub Discussion in comments.repo, titled the way giscus finds
+              it (comments.mapping); front matter discussion: {number, url}
+  utterances  a GitHub issue in comments.repo, titled the way utterances finds
+              it; front matter discussion: {number, url}
+  mastodon    a status announcing the post (or the announcement 'megafone
+              schedule run' already sent); front matter comments: {host,
+              username, id}, the format of Mastodon comment themes
+
+Threads that already exist are reused. GitHub needs GITHUB_TOKEN, Mastodon
+MASTODON_SERVER and MASTODON_ACCESS_TOKEN. With comments.on_ping set,
+'megafone ping' seeds the posts it announces.
+
+Examples:
+  megafone comments seed content/posts/en/my-post.md
+  megafone comments seed content/posts/en/my-post.md --system mastodon`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runCommentsSeed(args); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(commentsCmd)
+	commentsCmd.AddCommand(commentsSeedCmd)
+
+	commentsSeedCmd.Flags().StringVar(&commentsSystem, "system", "", "Comment system: giscus, utterances, or mastodon (default: comments.system in config)")
+	commentsSeedCmd.Flags().StringVarP(&siteSource, "site-source", "s", "", "Path to local Hugo site repository (default: found from the post path)")
+	commentsSeedCmd.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "Show the threads without creating them")
+}
+
+func runCommentsSeed(postPaths []string) error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	basePath, err := scheduleSitePath(postPaths[0])
+	if err != nil {
+		return err
+	}
+	baseURL := siteBaseURL(basePath)
+	if baseURL == "" {
+		return fmt.Errorf("the Hugo config has no baseURL, so post URLs can't be built")
+	}
+	system := firstNonEmpty(commentsSystem, appConfig.Comments.System)
+
+	failed := 0
+	for _, postPath := range postPaths {
+		if err := seedComments(context.Background(), basePath, baseURL, postPath, system); err != nil {
+			logError("Failed to seed comments for %s: %v", postPath, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d posts failed", failed, len(postPaths))
+	}
+	return nil
+}
+
+// seedComments creates (or finds) a post's comment thread and records it in
+// the post's front matter. Posts that already have one are left alone.
+func seedComments(ctx context.Context, basePath, baseURL, postPath, system string) error {
+	data, err := os.ReadFile(postPath)
+	if err != nil {
+		return fmt.Errorf("failed to read post: %w", err)
+	}
+	content := string(data)
+	link := baseURL + postURL(basePath, postPath, content)
+
+	var key, value string
+	switch system {
+	case commentsGiscus, commentsUtterances:
+		if hasFrontMatterField(content, "discussion") {
+			logVerbose("%s already has a discussion", postPath)
+			return nil
+		}
+		key = "discussion"
+		value, err = seedGitHubThread(ctx, system, content, link)
+	case commentsMastodon:
+		if hasFrontMatterField(content, "comments") {
+			logVerbose("%s already has a comments thread", postPath)
+			return nil
+		}
+		key = "comments"
+		value, err = seedMastodonThread(ctx, basePath, postPath, content, link)
+	case "":
+		return fmt.Errorf("no comment system set (use --system or comments.system in %s)", configPath)
+	default:
+		return fmt.Errorf("unknown comment system %q (use giscus, utterances, or mastodon)", system)
+	}
+	if err != nil || value == "" {
+		return err
+	}
+
+	if err := os.WriteFile(postPath, []byte(upsertFrontMatterField(content, key, value)), 0644); err != nil {
+		return fmt.Errorf("failed to write post: %w", err)
+	}
+	recordSiteChange("modified", postPath, "", false)
+	logSuccess("💬 %s: %s: %s", postPath, key, value)
+	return nil
+}
+
+// commentsTerm is the title giscus and utterances look a page's thread up by.
+// Both take the pathname without its leading slash, or "index" for the home page.
+func commentsTerm(content, link string) (string, error) {
+	switch firstNonEmpty(appConfig.Comments.Mapping, "pathname") {
+	case "pathname":
+		u, err := url.Parse(link)
+		if err != nil {
+			return "", err
+		}
+		if len(u.Path) < 2 {
+			return "index", nil
+		}
+		return strings.TrimPrefix(u.Path, "/"), nil
+	case "url":
+		return link, nil
+	case "number":
+		// The theme passes the number from front matter, so the title is free
+		return frontMatterString(content, "title"), nil
+	default:
+		return "", fmt.Errorf("invalid comments.mapping %q (use pathname, url, or number)", appConfig.Comments.Mapping)
+	}
+}
+
+// seedGitHubThread creates the discussion or issue for a post and returns
+// its front matter value
+func seedGitHubThread(ctx context.Context, system, content, link string) (string, error) {
+	if appConfig.Comments.Repo == "" {
+		return "", fmt.Errorf("comments.repo is not set in %s", configPath)
+	}
+	owner, repo, err := parseGitHubURL(appConfig.Comments.Repo)
+	if err != nil {
+		return "", err
+	}
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		return "", classify(ErrAuth, fmt.Errorf("GITHUB_TOKEN is required to create comment threads"))
+	}
+	title, err := commentsTerm(content, link)
+	if err != nil {
+		return "", err
+	}
+	body := fmt.Sprintf("# %s\n\n%s\n\n%s", frontMatterString(content, "title"), frontMatterString(content, "description"), link)
+	if dryRun {
+		logInfo("Dry run mode - would create %q in %s/%s", title, owner, repo)
+		return "", nil
+	}
+
+	var number int
+	var threadURL string
+	if system == commentsUtterances {
+		number, threadURL, err = createCommentsIssue(ctx, owner, repo, title, body)
+	} else {
+		number, threadURL, err = createCommentsDiscussion(ctx, token, owner, repo, title, body)
+	}
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("{number: %d, url: %s}", number, yamlQuote(threadURL)), nil
+}
+
+// createCommentsIssue opens the utterances issue for a post, or finds the one
+// a visitor's comment already opened
+func createCommentsIssue(ctx context.Context, owner, repo, title, body string) (int, string, error) {
+	client := newGitHubClient()
+	query := fmt.Sprintf("repo:%s/%s is:issue in:title %q", owner, repo, title)
+	found, _, err := client.Search.Issues(ctx, query, nil)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to search issues: %w", err)
+	}
+	for _, issue := range found.Issues {
+		if issue.GetTitle() == title {
+			logInfo("Found existing issue #%d", issue.GetNumber())
+			return issue.GetNumber(), issue.GetHTMLURL(), nil
+		}
+	}
+
+	req := &github.IssueRequest{Title: github.String(title), Body: github.String(body)}
+	if appConfig.Comments.Label != "" {
+		req.Labels = &[]string{appConfig.Comments.Label}
+	}
+	issue, _, err := client.Issues.Create(ctx, owner, repo, req)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to create issue: %w", err)
+	}
+	return issue.GetNumber(), issue.GetHTMLURL(), nil
+}
+
+// createCommentsDiscussion opens the giscus discussion for a post, or finds
+// the one a visitor's comment already opened. Discussions are only in
+// GitHub's GraphQL API.
+func createCommentsDiscussion(ctx context.Context, token, owner, repo, title, body string) (int, string, error) {
+	headers := map[string]string{"Authorization": "Bearer " + token}
+	graphql := func(query string, variables map[string]interface{}, v interface{}) error {
+		var reply struct {
+			Data   interface{} `json:"data"`
+			Errors []struct {
+				Message string `json:"message"`
+			} `json:"errors"`
+		}
+		reply.Data = v
+		if err := postSocialJSON(ctx, "GitHub", "https://api.github.com/graphql", headers, map[string]interface{}{"query": query, "variables": variables}, &reply); err != nil {
+			return err
+		}
+		if len(reply.Errors) > 0 {
+			return fmt.Errorf("GitHub API error: %s", reply.Errors[0].Message)
+		}
+		return nil
+	}
+
+	var found struct {
+		Search struct {
+			Nodes []struct {
+				Title  string `json:"title"`
+				Number int    `json:"number"`
+				URL    string `json:"url"`
+			} `json:"nodes"`
+		} `json:"search"`
+	}
+	err := graphql(`query($q: String!) {
+  search(query: $q, type: DISCUSSION, first: 10) { nodes { ... on Discussion { title number url } } }
+}`, map[string]interface{}{"q": fmt.Sprintf("repo:%s/%s in:title %q", owner, repo, title)}, &found)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to search discussions: %w", err)
+	}
+	for _, d := range found.Search.Nodes {
+		if d.Title == title {
+			logInfo("Found existing discussion #%d", d.Number)
+			return d.Number, d.URL, nil
+		}
+	}
+
+	var repository struct {
+		Repository *struct {
+			ID                   string `json:"id"`
+			DiscussionCategories struct {
+				Nodes []struct {
+					ID   string `json:"id"`
+					Name string `json:"name"`
+				} `json:"nodes"`
+			} `json:"discussionCategories"`
+		} `json:"repository"`
+	}
+	err = graphql(`query($owner: String!, $name: String!) {
+  repository(owner: $owner, name: $name) { id discussionCategories(first: 50) { nodes { id name } } }
+}`, map[string]interface{}{"owner": owner, "name": repo}, &repository)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to read repository: %w", err)
+	}
+	if repository.Repository == nil {
+		return 0, "", fmt.Errorf("repository %s/%s not found", owner, repo)
+	}
+	category := firstNonEmpty(appConfig.Comments.Category, "Announcements")
+	categoryID := ""
+	for _, c := range repository.Repository.DiscussionCategories.Nodes {
+		if strings.EqualFold(c.Name, category) {
+			categoryID = c.ID
+		}
+	}
+	if categoryID == "" {
+		return 0, "", fmt.Errorf("%s/%s has no discussion category %q (are Discussions enabled?)", owner, repo, category)
+	}
+
+	var created struct {
+		CreateDiscussion struct {
+			Discussion struct {
+				Number int    `json:"number"`
+				URL    string `json:"url"`
+			} `json:"discussion"`
+		} `json:"createDiscussion"`
+	}
+	err = graphql(`mutation($repo: ID!, $category: ID!, $title: String!, $body: String!) {
+  createDiscussion(input: {repositoryId: $repo, categoryId: $category, title: $title, body: $body}) { discussion { number url } }
+}`, map[string]interface{}{"repo": repository.Repository.ID, "category": categoryID, "title": title, "body": body}, &created)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to create discussion: %w", err)
+	}
+	d := created.CreateDiscussion.Discussion
+	return d.Number, d.URL, nil
+}
+
+// seedMastodonThread returns the front matter value for the Mastodon status
+// a post's comments hang off: the announcement the schedule already sent, or
+// a new one
+func seedMastodonThread(ctx context.Context, basePath, postPath, content, link string) (string, error) {
+	statusURL := ""
+	if q, err := loadScheduleQueue(basePath); err == nil {
+		absBase, _ := filepath.Abs(basePath)
+		absPost, _ := filepath.Abs(postPath)
+		rel := filepath.ToSlash(mustRel(absBase, absPost))
+		for _, item := range q.Items {
+			if item.Post == rel && item.Kind == dripAnnouncement && item.Sent[platformMastodon] != "" {
+				statusURL = item.Sent[platformMastodon]
+				logInfo("Using the announcement already sent: %s", statusURL)
+				break
+			}
+		}
+	}
+
+	if statusURL == "" {
+		blurb := firstNonEmpty(frontMatterString(content, "social_blurb"), frontMatterString(content, "description"), frontMatterString(content, "title"))
+		text := fitSocialPost(blurb+"\n\nComments welcome here 👇", link, socialPostLimits[platformMastodon])
+		if dryRun {
+			logInfo("Dry run mode - would post: %s", text)
+			return "", nil
+		}
+		var err error
+		if statusURL, err = postToMastodon(ctx, text); err != nil {
+			return "", err
+		}
+	}
+
+	// Status URLs look like https://host/@username/<id>
+	u, err := url.Parse(statusURL)
+	if err != nil {
+		return "", err
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) != 2 || !strings.HasPrefix(parts[0], "@") {
+		return "", fmt.Errorf("unexpected status URL %s", statusURL)
+	}
+	if _, err := strconv.ParseUint(parts[1], 10, 64); err != nil {
+		return "", fmt.Errorf("unexpected status URL %s", statusURL)
+	}
+	return fmt.Sprintf("{host: %s, username: %s, id: %s}", yamlQuote(u.Host), yamlQuote(strings.TrimPrefix(parts[0], "@")), yamlQuote(parts[1])), nil
+}
@@ -0,0 +1,225 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/spf13/cobra"
+)
+
+var (
+	compileTags   []string
+	compileFormat string
+	compileTitle  string
+	compileAuthor string
+	compileOutput string
+	compileNoAI   bool
+)
+
+var compileCmd = &cobra.Command{
+	Use:   "compile",
+	Short: "Bundle posts into an EPUB or PDF ebook",
+	Long: `Collects published posts with any of the given tags, oldest first, and builds
+an ebook with a table of contents, one chapter per post, and the posts' images.
+The AI writes an introduction and a closing chapter that tie the posts together
+(skip with --no-ai).
+
+PDF output is printed by headless Chromium/Chrome, or wkhtmltopdf if no browser is
+installed.
+
+Examples:
+  megafone compile --tag kubernetes --format epub --title "Kubernetes in Practice"
+  megafone compile --tag go --tag testing --format pdf --author "Jane Doe" -o go-testing.pdf`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runCompile(cmd); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(compileCmd)
+
+	compileCmd.Flags().StringArrayVar(&compileTags, "tag", nil, "Include posts with this tag (repeatable) (required)")
+	compileCmd.Flags().StringVarP(&compileFormat, "format", "f", "epub", "Output format: epub or pdf")
+	compileCmd.Flags().StringVar(&compileTitle, "title", "", "Book title (default: generated from the tags)")
+	compileCmd.Flags().StringVar(&compileAuthor, "author", "", "Author name")
+	compileCmd.Flags().StringVarP(&compileOutput, "output", "o", "", "Output file (default: <title>.<format>)")
+	compileCmd.Flags().BoolVar(&compileNoAI, "no-ai", false, "Skip the AI-written introduction and conclusion")
+	compileCmd.Flags().StringVarP(&model, "model", "m", "gpt-4o", "OpenAI model to use")
+	compileCmd.Flags().StringVarP(&siteSource, "site-source", "s", "", "Path to local Hugo site repository")
+
+	compileCmd.MarkFlagRequired("tag")
+}
+
+func runCompile(cmd *cobra.Command) error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	ctx := context.Background()
+
+	if compileFormat != "epub" && compileFormat != "pdf" {
+		return fmt.Errorf("invalid --format value %q (use epub or pdf)", compileFormat)
+	}
+
+	basePath, err := resolveSitePath()
+	if err != nil {
+		return err
+	}
+
+	all, err := loadSitePosts(basePath)
+	if err != nil {
+		return fmt.Errorf("failed to load posts: %w", err)
+	}
+	posts := postsWithTags(all, compileTags)
+	if len(posts) == 0 {
+		return fmt.Errorf("no published posts tagged %s", strings.Join(compileTags, ", "))
+	}
+	logInfo("📚 Compiling %d posts tagged %s", len(posts), strings.Join(compileTags, ", "))
+
+	title := compileTitle
+	if title == "" {
+		title = "Collected Posts: " + strings.Join(compileTags, ", ")
+	}
+	book := &ebook{Title: title, Author: compileAuthor, Language: "en", Images: make(map[string][]byte)}
+
+	var intro, outro string
+	if !compileNoAI {
+		apiKey, err := getOpenAIKey(cmd)
+		if err != nil {
+			return err
+		}
+		logInfo("✍️  Writing introduction and conclusion...")
+		intro, outro, err = generateBookFraming(ctx, openai.NewClient(apiKey), title, posts, model)
+		if err != nil {
+			return err
+		}
+	}
+
+	if intro != "" {
+		if err := book.addChapter("Introduction", intro, basePath); err != nil {
+			return err
+		}
+	}
+	for _, p := range posts {
+		if err := book.addChapter(p.Title, p.Body, basePath); err != nil {
+			return fmt.Errorf("failed to render %s: %w", p.Path, err)
+		}
+	}
+	if outro != "" {
+		if err := book.addChapter("Conclusion", outro, basePath); err != nil {
+			return err
+		}
+	}
+
+	outPath := compileOutput
+	if outPath == "" {
+		outPath = sanitizeFilename(title) + "." + compileFormat
+	}
+
+	if compileFormat == "pdf" {
+		logInfo("🖨️  Printing PDF...")
+		if err := book.writePDF(outPath); err != nil {
+			return fmt.Errorf("failed to build PDF: %w", err)
+		}
+	} else {
+		var buf bytes.Buffer
+		if err := book.writeEPUB(&buf); err != nil {
+			return fmt.Errorf("failed to build EPUB: %w", err)
+		}
+		if err := os.WriteFile(outPath, buf.Bytes(), 0644); err != nil {
+			return fmt.Errorf("failed to write EPUB: %w", err)
+		}
+	}
+
+	logSuccess("✅ Compiled %d chapters into %s", len(book.Chapters), outPath)
+	return nil
+}
+
+// postsWithTags returns the non-draft posts carrying any of the tags, oldest first
+func postsWithTags(posts []sitePost, tagNames []string) []sitePost {
+	matched := filterPosts(posts, postFilter{Tags: tagNames})
+	slices.Reverse(matched) // loadSitePosts is newest first
+	return matched
+}
+
+// addChapter renders a post body and copies its site images into the book
+func (b *ebook) addChapter(title, markdown, basePath string) error {
+	body := expandShortcodesMarkdown(markdown)
+	body = siteImageRefRegex.ReplaceAllStringFunc(body, func(ref string) string {
+		name := strings.TrimPrefix(ref, "/images/site/")
+		if _, ok := b.Images[name]; !ok {
+			data, err := os.ReadFile(filepath.Join(siteImageDir(basePath), name))
+			if err != nil {
+				logInfo("⚠️  Skipping missing image %s", ref)
+				return ref
+			}
+			b.Images[name] = data
+		}
+		return "images/" + name
+	})
+
+	rendered, err := markdownToXHTML(body)
+	if err != nil {
+		return err
+	}
+	b.Chapters = append(b.Chapters, ebookChapter{Title: title, Body: rendered})
+	return nil
+}
+
+// generateBookFraming writes the introduction and conclusion that connect the chapters
+func generateBookFraming(ctx context.Context, client *openai.Client, title string, posts []sitePost, model string) (intro, outro string, err error) {
+	var chapters strings.Builder
+	for i, p := range posts {
+		fmt.Fprintf(&chapters, "%d. %s", i+1, p.Title)
+		if p.Description != "" {
+			fmt.Fprintf(&chapters, " - %s", p.Description)
+		}
+		chapters.WriteString("\n")
+	}
+
+	userPrompt := fmt.Sprintf(`These blog posts are being compiled, in this order, into an ebook titled %q:
+
+%s
+Write two sections in markdown:
+1. An introduction (300-500 words): who the book is for, what the reader will learn, and how the
+   chapters build on each other, referring to chapters by title.
+2. A conclusion (200-400 words): the through-line of the chapters and where the reader could go next.
+
+Don't include headings for the sections themselves; the book adds them. Never invent content the
+chapters don't cover.
+
+Separate the two sections with a line containing only "===CONCLUSION===".
+
+IMPORTANT: Your response must be ONLY the two markdown sections and the separator.`, title, chapters.String())
+
+	resp, err := createChatCompletion(ctx, client, openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: "You are an editor turning a series of blog posts into a cohesive short book. Output ONLY the requested markdown.",
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: userPrompt,
+			},
+		},
+		Temperature: 0.7,
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("OpenAI API error: %w", err)
+	}
+	if len(resp.Choices) == 0 || resp.Choices[0].Message.Content == "" {
+		return "", "", fmt.Errorf("no response from OpenAI")
+	}
+
+	intro, outro, _ = strings.Cut(resp.Choices[0].Message.Content, "===CONCLUSION===")
+	return strings.TrimSpace(intro), strings.TrimSpace(outro), nil
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// megafoneConfig is the optional megafone.yaml read at startup
+type megafoneConfig struct {
+	Hooks           hooksConfig               `yaml:"hooks"`
+	Sources         map[string]sourceSettings `yaml:"sources"`
+	Routes          []routeRule               `yaml:"routes"`
+	FallbackModels  []string                  `yaml:"fallback_models"`
+	Providers       map[string]providerLimits `yaml:"providers"`
+	FrontMatter     yaml.Node                 `yaml:"front_matter"`
+	Dates           datesConfig               `yaml:"dates"`
+	Slugs           slugsConfig               `yaml:"slugs"`
+	Translation     translationConfig         `yaml:"translation"`
+	SummarySections summarySectionsConfig     `yaml:"summary_sections"`
+	Campaigns       map[string]campaignConfig `yaml:"campaigns"`
+	Tracking        trackingConfig            `yaml:"tracking"`
+	Experiments     experimentsConfig         `yaml:"experiments"`
+	Ping            pingConfig                `yaml:"ping"`
+	Comments        commentsConfig            `yaml:"comments"`
+	Archive         archiveConfig             `yaml:"archive"`
+	Monitor         monitorConfig             `yaml:"monitor"`
+	HostPolicy      hostPolicyConfig          `yaml:"host_policy"`
+	SecretScan      secretScanConfig          `yaml:"secret_scan"`
+	Tags            tagsConfig                `yaml:"tags"`
+}
+
+var (
+	configPath string
+	appConfig  megafoneConfig
+)
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "megafone.yaml", "Config file (ignored if it doesn't exist)")
+}
+
+// loadConfig reads configPath into appConfig. A missing file is not an error.
+func loadConfig() error {
+	data, err := os.ReadFile(configPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &appConfig); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", configPath, err)
+	}
+	return nil
+}
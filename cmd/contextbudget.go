@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// contextReport prints the context budget breakdown after generation
+var contextReport bool
+
+// budgetPart is one piece of the generation prompt. Original is the length
+// before it was cut to fit, when it was; included parts (readFile in
+// templates) are already counted in the template.
+type budgetPart struct {
+	Name     string `json:"name"`
+	Chars    int    `json:"chars"`
+	Tokens   int    `json:"tokens"`
+	Original int    `json:"original_chars,omitempty"`
+	Included bool   `json:"included,omitempty"`
+}
+
+// contextBudget is one line of .megafone/history/context.jsonl: what went into the
+// generation prompt of a run. Token counts are estimates (four characters a
+// token) except PromptTokens, which the API reported.
+type contextBudget struct {
+	Time         string       `json:"time"`
+	RunID        string       `json:"run_id"`
+	Source       string       `json:"source"`
+	Model        string       `json:"model"`
+	Parts        []budgetPart `json:"parts"`
+	PromptChars  int          `json:"prompt_chars"`
+	PromptTokens int          `json:"prompt_tokens,omitempty"`
+
+	// pending holds original lengths of parts cut before they were recorded
+	pending map[string]int
+	// awaiting is set once the prompt is built, so the next completion's usage is the prompt's
+	awaiting bool
+}
+
+var runBudget = &contextBudget{pending: make(map[string]int)}
+
+func textTokens(s string) int {
+	return (len(s) + 3) / 4
+}
+
+// budgetRecord records (or replaces) a part of the prompt
+func budgetRecord(name, text string) {
+	if text == "" {
+		return
+	}
+	part := budgetPart{Name: name, Chars: len(text), Tokens: textTokens(text), Original: runBudget.pending[name]}
+	delete(runBudget.pending, name)
+	for i, p := range runBudget.Parts {
+		if p.Name == name {
+			if part.Original == 0 {
+				part.Original = p.Original
+			}
+			runBudget.Parts[i] = part
+			return
+		}
+	}
+	runBudget.Parts = append(runBudget.Parts, part)
+}
+
+// budgetInclude records a file a prompt template pulled in with readFile,
+// such as few-shot example posts
+func budgetInclude(name, text string) {
+	runBudget.Parts = append(runBudget.Parts, budgetPart{Name: "template readFile " + name, Chars: len(text), Tokens: textTokens(text), Included: true})
+}
+
+// budgetTruncated notes that a part was cut from original characters to
+// kept; the part may be recorded before or after
+func budgetTruncated(name string, original, kept int) {
+	for i, p := range runBudget.Parts {
+		if p.Name == name {
+			runBudget.Parts[i].Original = original
+			runBudget.Parts[i].Chars = kept
+			runBudget.Parts[i].Tokens = (kept + 3) / 4
+			return
+		}
+	}
+	runBudget.pending[name] = original
+}
+
+// budgetPrompt records the final generation prompt; the usage of the next
+// completion is taken as its real size
+func budgetPrompt(prompt string) {
+	runBudget.PromptChars = len(prompt)
+	runBudget.awaiting = true
+}
+
+// budgetUsage records the API's prompt token count for the generation call
+func budgetUsage(promptTokens int) {
+	if runBudget.awaiting {
+		runBudget.PromptTokens = promptTokens
+		runBudget.awaiting = false
+	}
+}
+
+// finishContextBudget stores the run's budget in the site and prints it with
+// --context-report. Truncated parts are always called out, since they're the
+// usual reason a post misses details of a long source.
+func finishContextBudget(basePath, source string) {
+	b := runBudget
+	if b.PromptChars == 0 {
+		return
+	}
+	b.Time = time.Now().Format(time.RFC3339)
+	b.RunID = journalRunID
+	b.Source = source
+	b.Model = effectiveModel()
+
+	counted := 0
+	for _, p := range b.Parts {
+		if !p.Included {
+			counted += p.Chars
+		}
+	}
+	if rest := b.PromptChars - counted; rest > 0 {
+		b.Parts = append(b.Parts, budgetPart{Name: "instructions and metadata", Chars: rest, Tokens: (rest + 3) / 4})
+	}
+
+	if contextReport {
+		actual := ""
+		if b.PromptTokens > 0 {
+			actual = fmt.Sprintf("; the API counted %s", formatCount(b.PromptTokens))
+		}
+		logInfo("📐 Context budget for %s: %s chars, ~%s tokens%s", b.Model, formatCount(b.PromptChars), formatCount((b.PromptChars+3)/4), actual)
+		for _, p := range b.Parts {
+			name := p.Name
+			if p.Included {
+				name = "  " + name
+			}
+			line := fmt.Sprintf("   %-34s %9s chars %8s tokens", name, formatCount(p.Chars), "~"+formatCount(p.Tokens))
+			if p.Original > 0 {
+				line += fmt.Sprintf("  (cut from %s chars, %d%% kept)", formatCount(p.Original), p.Chars*100/p.Original)
+			}
+			logInfo("%s", line)
+		}
+	} else {
+		for _, p := range b.Parts {
+			if p.Original > 0 {
+				logInfo("✂️  The %s was cut to %s of its %s chars (%d%% kept); see --context-report", p.Name, formatCount(p.Chars), formatCount(p.Original), p.Chars*100/p.Original)
+			}
+		}
+	}
+
+	if dryRun || basePath == "" {
+		return
+	}
+	if err := appendContextBudget(workspacePath(basePath, "history", "context.jsonl"), b); err != nil {
+		logError("Failed to record the context budget: %v", err)
+	}
+}
+
+func appendContextBudget(path string, b *contextBudget) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	line, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
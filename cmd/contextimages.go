@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+var contextImages []string
+
+// visionFallbackModel reads screenshots when the generation model can't see images
+const visionFallbackModel = "gpt-4o"
+
+// contextImage is a --context-image screenshot: copied into the site as a body
+// image, and described by a vision model so the post can discuss what it shows
+type contextImage struct {
+	Name        string // file name in assets/images/site
+	Description string
+	Alt         string
+	Caption     string
+}
+
+// supportsVision reports whether a model accepts image inputs
+func supportsVision(name string) bool {
+	if modelProvider(name) == "anthropic" {
+		return true
+	}
+	for _, prefix := range []string{"gpt-4o", "gpt-4.1", "gpt-4-turbo", "gpt-5", "o1", "o3", "o4"} {
+		if strings.HasPrefix(name, prefix) && name != "o1-mini" {
+			return true
+		}
+	}
+	return false
+}
+
+// loadContextImages copies each screenshot into the site and has a vision
+// model describe it. A screenshot the model can't describe is still embedded,
+// with its file name as the alt text.
+func loadContextImages(ctx context.Context, apiKey string, paths []string, topic, basePath, model string) ([]contextImage, error) {
+	visionModel := model
+	if !supportsVision(model) {
+		visionModel = visionFallbackModel
+		logInfo("⚠️  %s can't read images; describing screenshots with %s", model, visionModel)
+	}
+	client := openai.NewClient(apiKey)
+
+	var images []contextImage
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read context image: %w", err)
+		}
+		mimeType := http.DetectContentType(data)
+		if !strings.HasPrefix(mimeType, "image/") {
+			return nil, fmt.Errorf("context image %s is not an image (%s)", p, mimeType)
+		}
+
+		base := sanitizeFilename(strings.TrimSuffix(filepath.Base(p), filepath.Ext(p)))
+		name, err := writeSiteImage(basePath, firstNonEmpty(base, "screenshot")+strings.ToLower(filepath.Ext(p)), data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy context image: %w", err)
+		}
+		logSuccess("✅ Image copied: assets/images/site/%s", name)
+
+		img := contextImage{Name: name, Alt: strings.ReplaceAll(base, "-", " ")}
+		logInfo("👁️  Describing %s with %s", filepath.Base(p), visionModel)
+		if err := describeContextImage(ctx, client, &img, data, mimeType, topic, visionModel); err != nil {
+			logError("Failed to describe %s, embedding it without a description: %v", filepath.Base(p), err)
+		}
+		images = append(images, img)
+	}
+	return images, nil
+}
+
+// describeContextImage asks a vision model what a screenshot shows, in enough
+// detail that the post can walk through it, plus alt text and a caption
+func describeContextImage(ctx context.Context, client *openai.Client, img *contextImage, data []byte, mimeType, topic, model string) error {
+	prompt := fmt.Sprintf(`This screenshot is for a blog post about: %s
+
+Describe what it shows so a writer who can't see it can walk readers through it: the application or page, its layout, and every label, setting, value, number, and piece of text that matters. Say what state it is in (what is configured, selected, running, or failing). Only report what is visible; don't guess at anything cut off or unreadable.
+
+Return JSON only:
+{"description": "the detailed description", "alt": "alt text under 125 characters", "caption": "a one-sentence caption for the post"}`, topic)
+
+	resp, err := createChatCompletion(ctx, client, openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role: openai.ChatMessageRoleUser,
+				MultiContent: []openai.ChatMessagePart{
+					{Type: openai.ChatMessagePartTypeText, Text: prompt},
+					{Type: openai.ChatMessagePartTypeImageURL, ImageURL: &openai.ChatMessageImageURL{
+						URL:    "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data),
+						Detail: openai.ImageURLDetailHigh,
+					}},
+				},
+			},
+		},
+		MaxTokens: 1200,
+	})
+	if err != nil {
+		return err
+	}
+	if len(resp.Choices) == 0 {
+		return fmt.Errorf("no description returned")
+	}
+
+	var reply struct {
+		Description string `json:"description"`
+		Alt         string `json:"alt"`
+		Caption     string `json:"caption"`
+	}
+	if err := decodeModelJSON(resp.Choices[0].Message.Content, &reply); err != nil {
+		return err
+	}
+	img.Description = strings.TrimSpace(reply.Description)
+	img.Alt = firstNonEmpty(trimToLength(reply.Alt, 125), img.Alt)
+	img.Caption = strings.TrimSpace(reply.Caption)
+	return nil
+}
+
+// figure returns the Hugo figure shortcode that embeds the screenshot
+func (img contextImage) figure() string {
+	shortcode := fmt.Sprintf(`{{< figure src="/images/site/%s" alt=%s`, img.Name, yamlQuote(img.Alt))
+	if img.Caption != "" {
+		shortcode += fmt.Sprintf(` caption=%s`, yamlQuote(img.Caption))
+	}
+	return shortcode + " >}}"
+}
+
+// contextImagesSection renders the screenshots for the generation prompt: what
+// each shows, and the shortcode that places it in the body
+func contextImagesSection(images []contextImage) string {
+	if len(images) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(`
+
+## Screenshots (from the author)
+The author took these screenshots for the post. Describe what they show where
+it helps the reader, using the details below, and place each one in the body,
+once, right where the text discusses it, with its shortcode exactly as given.
+Don't describe anything in them that the descriptions don't mention.
+`)
+	for i, img := range images {
+		fmt.Fprintf(&b, "\nScreenshot %d: %s\n", i+1, img.figure())
+		if img.Description != "" {
+			fmt.Fprintf(&b, "What it shows: %s\n", img.Description)
+		}
+	}
+	return b.String()
+}
+
+// placeContextImages puts any screenshot the model left out before the post's
+// conclusion, so every one the author passed is embedded
+func placeContextImages(content string, images []contextImage) string {
+	for _, img := range images {
+		if strings.Contains(content, "/images/site/"+img.Name) {
+			continue
+		}
+		logInfo("🖼️  The post didn't place %s; adding it before the conclusion", img.Name)
+		content = placeSection(content, img.figure(), placementBeforeConclusion)
+	}
+	return content
+}
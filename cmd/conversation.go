@@ -0,0 +1,334 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// chatConversation picks the conversation to use from an export with several
+// (a title substring); the most recent one is used by default
+var chatConversation string
+
+var (
+	// chatSpeakerRegex matches the start of a speaker line in markdown chat
+	// exports: "## User", "**Assistant:**", "You said:", "ChatGPT said:",
+	// "Human: ..."; isChatSpeakerLine weeds out sentences that merely start
+	// with one of the names
+	chatSpeakerRegex = regexp.MustCompile(`(?im)^(#{1,6}[ \t]*|\*\*)?(you|user|human|me|chatgpt|assistant|claude|ai|gpt-?[\w.-]*)([ \t]+said)?[ \t]*(:?\*\*:?|:)?[ \t]*(.*)$`)
+	chatTitleRegex   = regexp.MustCompile(`(?m)^#[ \t]+(.+)$`)
+)
+
+type chatMessage struct {
+	Author bool // written by the author rather than the assistant
+	Text   string
+}
+
+type chatTranscript struct {
+	Title     string
+	Assistant string
+	Messages  []chatMessage
+}
+
+// isChatTranscriptFile reports whether a topic is a chat export on disk
+func isChatTranscriptFile(input string) bool {
+	switch strings.ToLower(filepath.Ext(input)) {
+	case ".json", ".md", ".markdown", ".txt":
+	default:
+		return false
+	}
+	info, err := os.Stat(input)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// loadChatTranscript reads a conversation exported from ChatGPT
+// (conversations.json, or one conversation from it), Claude
+// (conversations.json), or saved as markdown with a line naming each speaker
+func loadChatTranscript(path string) (*chatTranscript, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transcript: %w", err)
+	}
+	var t *chatTranscript
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		t, err = parseChatExport(data)
+	} else {
+		t, err = parseChatMarkdown(string(data))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if t.Title == "" {
+		t.Title = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return t, nil
+}
+
+// chatExport covers the fields of both ChatGPT's and Claude's exports
+type chatExport struct {
+	// ChatGPT
+	Title       string  `json:"title"`
+	UpdateTime  float64 `json:"update_time"`
+	CurrentNode string  `json:"current_node"`
+	Mapping     map[string]struct {
+		Parent  string `json:"parent"`
+		Message *struct {
+			Author struct {
+				Role string `json:"role"`
+			} `json:"author"`
+			Content struct {
+				ContentType string            `json:"content_type"`
+				Parts       []json.RawMessage `json:"parts"`
+			} `json:"content"`
+		} `json:"message"`
+	} `json:"mapping"`
+
+	// Claude
+	Name         string `json:"name"`
+	UpdatedAt    string `json:"updated_at"`
+	ChatMessages []struct {
+		Sender  string `json:"sender"`
+		Text    string `json:"text"`
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+	} `json:"chat_messages"`
+}
+
+func (c chatExport) title() string {
+	return firstNonEmpty(c.Title, c.Name)
+}
+
+// updated sorts conversations by when they last changed. Claude's timestamps
+// are RFC 3339, which sorts as text; ChatGPT's are Unix seconds.
+func (c chatExport) updated() string {
+	if c.UpdatedAt != "" {
+		return c.UpdatedAt
+	}
+	return fmt.Sprintf("%020.3f", c.UpdateTime)
+}
+
+func parseChatExport(data []byte) (*chatTranscript, error) {
+	var conversations []chatExport
+	if err := json.Unmarshal(data, &conversations); err != nil {
+		var single chatExport
+		if err := json.Unmarshal(data, &single); err != nil {
+			return nil, fmt.Errorf("not a ChatGPT or Claude export: %w", err)
+		}
+		conversations = []chatExport{single}
+	}
+	if len(conversations) == 0 {
+		return nil, fmt.Errorf("the export has no conversations")
+	}
+
+	sort.SliceStable(conversations, func(i, j int) bool {
+		return conversations[i].updated() > conversations[j].updated()
+	})
+	conv := conversations[0]
+	if chatConversation != "" {
+		found := false
+		for _, c := range conversations {
+			if strings.Contains(strings.ToLower(c.title()), strings.ToLower(chatConversation)) {
+				conv, found = c, true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no conversation titled like %q", chatConversation)
+		}
+	} else if len(conversations) > 1 {
+		logInfo("💬 The export has %d conversations; using the most recent, %q (pick another with --conversation)", len(conversations), conv.title())
+	}
+
+	t := &chatTranscript{Title: conv.title()}
+	if len(conv.ChatMessages) > 0 {
+		t.Assistant = "Claude"
+		for _, m := range conv.ChatMessages {
+			text := m.Text
+			if text == "" {
+				var parts []string
+				for _, c := range m.Content {
+					if c.Type == "text" {
+						parts = append(parts, c.Text)
+					}
+				}
+				text = strings.Join(parts, "\n\n")
+			}
+			t.add(m.Sender == "human", text)
+		}
+	} else {
+		// ChatGPT stores a tree of messages (edits and regenerations branch
+		// it); the conversation as last seen is the path to current_node
+		t.Assistant = "ChatGPT"
+		var path []string
+		for id := conv.CurrentNode; id != "" && len(path) <= len(conv.Mapping); id = conv.Mapping[id].Parent {
+			path = append(path, id)
+		}
+		for i := len(path) - 1; i >= 0; i-- {
+			m := conv.Mapping[path[i]].Message
+			if m == nil || m.Content.ContentType != "text" {
+				continue
+			}
+			role := m.Author.Role
+			if role != "user" && role != "assistant" {
+				continue
+			}
+			var parts []string
+			for _, raw := range m.Content.Parts {
+				var s string
+				if json.Unmarshal(raw, &s) == nil {
+					parts = append(parts, s)
+				}
+			}
+			t.add(role == "user", strings.Join(parts, "\n\n"))
+		}
+	}
+	if len(t.Messages) == 0 {
+		return nil, fmt.Errorf("conversation %q has no messages", t.Title)
+	}
+	return t, nil
+}
+
+func parseChatMarkdown(text string) (*chatTranscript, error) {
+	t := &chatTranscript{Assistant: "the assistant"}
+	var speakers [][]int
+	for _, loc := range chatSpeakerRegex.FindAllStringSubmatchIndex(text, -1) {
+		if isChatSpeakerLine(text, loc) {
+			speakers = append(speakers, loc)
+		}
+	}
+	if len(speakers) == 0 {
+		return nil, fmt.Errorf("no speakers found (start each message with a line like \"User:\" or \"## Assistant\")")
+	}
+	if m := chatTitleRegex.FindStringSubmatchIndex(text[:speakers[0][0]]); m != nil {
+		t.Title = strings.TrimSpace(text[m[2]:m[3]])
+	}
+
+	for i, loc := range speakers {
+		end := len(text)
+		if i+1 < len(speakers) {
+			end = speakers[i+1][0]
+		}
+		name := text[loc[4]:loc[5]]
+		speaker := strings.ToLower(name)
+		author := speaker == "you" || speaker == "user" || speaker == "human" || speaker == "me"
+		if !author && speaker != "assistant" && speaker != "ai" {
+			t.Assistant = name
+		}
+		// The message starts on the speaker line itself after "Human:"
+		t.add(author, text[loc[10]:end])
+	}
+	return t, nil
+}
+
+// isChatSpeakerLine accepts a heading or bold line naming only the speaker,
+// or a name followed by a colon
+func isChatSpeakerLine(text string, loc []int) bool {
+	marker := loc[2] >= 0 && loc[3] > loc[2]
+	colon := loc[8] >= 0 && strings.Contains(text[loc[8]:loc[9]], ":")
+	restEmpty := strings.TrimSpace(text[loc[10]:loc[11]]) == ""
+	bold := marker && strings.HasPrefix(text[loc[2]:loc[3]], "**")
+	switch {
+	case bold:
+		return loc[8] >= 0 && strings.Contains(text[loc[8]:loc[9]], "**")
+	case marker:
+		return restEmpty
+	default:
+		return colon
+	}
+}
+
+func (t *chatTranscript) add(author bool, text string) {
+	if text = strings.TrimSpace(text); text != "" {
+		t.Messages = append(t.Messages, chatMessage{Author: author, Text: text})
+	}
+}
+
+// String renders the transcript with the speakers labeled for the model
+func (t *chatTranscript) String() string {
+	var b strings.Builder
+	for _, m := range t.Messages {
+		speaker := "AUTHOR"
+		if !m.Author {
+			speaker = "ASSISTANT (" + t.Assistant + ")"
+		}
+		fmt.Fprintf(&b, "### %s\n%s\n\n", speaker, m.Text)
+	}
+	return b.String()
+}
+
+// distillConversation turns an exploratory chat into notes for the post:
+// where the conversation ended up, with the back-and-forth collapsed and each
+// conclusion attributed to whoever reached it
+func distillConversation(ctx context.Context, apiKey string, t *chatTranscript, model string) (string, error) {
+	prompt := fmt.Sprintf(`Below is a conversation between the author of a blog and an AI assistant
+(%s), in which the author explored an idea. Distill it into notes for a blog
+post written by the author.
+
+- Collapse the back-and-forth: repeated questions, rephrasings, corrections,
+  and abandoned tangents become one statement of where the discussion ended up.
+  Later messages override earlier ones.
+- Attribute carefully. The author's conclusions are views the author stated,
+  or assistant suggestions the author explicitly agreed with or built on.
+  Suggestions the author ignored or pushed back on are not the author's views.
+- Keep concrete details: examples, numbers, code, names, trade-offs.
+
+Use exactly these markdown sections (omit empty ones):
+## Author's conclusions
+## Reasoning and examples behind them
+## Ideas considered and rejected (with why)
+## Facts, code, and references supplied by the assistant (unverified)
+## Open questions
+
+Conversation: %s
+
+%s`, t.Assistant, t.Title, summarizeTokens(24000, t.String()))
+
+	resp, err := createChatCompletion(ctx, openai.NewClient(apiKey), openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: "You are an editor who turns a writer's exploratory conversations into accurate, well-attributed notes. You never credit the writer with a view they didn't hold.",
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: prompt,
+			},
+		},
+		Temperature: 0.2,
+	})
+	if err != nil {
+		return "", fmt.Errorf("OpenAI API error: %w", err)
+	}
+	if len(resp.Choices) == 0 || strings.TrimSpace(resp.Choices[0].Message.Content) == "" {
+		return "", fmt.Errorf("no response from OpenAI")
+	}
+	return resp.Choices[0].Message.Content, nil
+}
+
+// conversationPromptSection tells the writer how to use distilled chat notes
+func conversationPromptSection() string {
+	return `
+
+## Source: the author's own exploratory conversation
+The research material is the author's distilled notes from working through
+this idea with an AI assistant. Write it as the author's post:
+- Present the author's conclusions in the first person as the post's argument,
+  with the reasoning and examples that led there.
+- Don't present rejected ideas as recommendations; mention them only as
+  alternatives the author considered and why they lost.
+- Facts supplied by the assistant are unverified; keep only what you're
+  confident is accurate, and don't cite the assistant as a source.
+- Don't narrate the conversation ("I asked ChatGPT...") unless the notes make
+  it part of the story.
+`
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSchedule is a parsed five-field cron expression (minute hour dom month dow)
+type cronSchedule struct {
+	minute, hour, dom, month, dow map[int]bool
+	domAny, dowAny                bool
+}
+
+var cronNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var cronMacros = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+// parseCron parses expressions like "0 9 * * MON-FRI", "*/15 * * * *", or "@weekly"
+func parseCron(expr string) (*cronSchedule, error) {
+	if macro, ok := cronMacros[strings.ToLower(strings.TrimSpace(expr))]; ok {
+		expr = macro
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields", expr)
+	}
+
+	var s cronSchedule
+	var err error
+	if s.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, err
+	}
+	if s.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, err
+	}
+	if s.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, err
+	}
+	if s.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, err
+	}
+	if s.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, err
+	}
+	if s.dow[7] {
+		s.dow[0] = true // 7 is also Sunday
+	}
+	s.domAny = fields[2] == "*"
+	s.dowAny = fields[4] == "*"
+
+	return &s, nil
+}
+
+func parseCronField(field string, min, max int) (map[int]bool, error) {
+	values := make(map[int]bool)
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i != -1 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid cron step in %q", field)
+			}
+			step = n
+			part = part[:i]
+		}
+
+		lo, hi := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if lo, err = cronValue(bounds[0]); err != nil {
+				return nil, err
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = cronValue(bounds[1]); err != nil {
+					return nil, err
+				}
+			} else if step > 1 {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return nil, fmt.Errorf("cron value out of range in %q", field)
+		}
+
+		for v := lo; v <= hi; v += step {
+			values[v] = true
+		}
+	}
+	return values, nil
+}
+
+func cronValue(s string) (int, error) {
+	if v, ok := cronNames[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cron value %q", s)
+	}
+	return v, nil
+}
+
+// matches reports whether the schedule fires at t (to the minute). Like
+// standard cron, when both day-of-month and day-of-week are restricted either may match.
+func (s *cronSchedule) matches(t time.Time) bool {
+	if !s.minute[t.Minute()] || !s.hour[t.Hour()] || !s.month[int(t.Month())] {
+		return false
+	}
+	domMatch := s.dom[t.Day()]
+	dowMatch := s.dow[int(t.Weekday())]
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dowMatch
+	case s.dowAny:
+		return domMatch
+	default:
+		return domMatch || dowMatch
+	}
+}
+
+// firedBetween reports whether the schedule fired in (after, until]
+func (s *cronSchedule) firedBetween(after, until time.Time) bool {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	// Cap the scan so a long-idle state file doesn't loop for ages
+	if limit := until.Add(-31 * 24 * time.Hour); t.Before(limit) {
+		t = limit.Truncate(time.Minute)
+	}
+	for ; !t.After(until); t = t.Add(time.Minute) {
+		if s.matches(t) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,426 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	devToAPI    = "https://dev.to/api"
+	hashnodeAPI = "https://gql.hashnode.com"
+)
+
+var (
+	crosspostTo      []string
+	crosspostPublish bool
+	crosspostBaseURL string
+)
+
+var crosspostCmd = &cobra.Command{
+	Use:   "crosspost <post>",
+	Short: "Publish a post on dev.to and Hashnode with its canonical URL",
+	Long: `Converts a post for dev.to and Hashnode and uploads it through their APIs.
+The canonical URL points back at the post on your site, tags are converted to
+each platform's rules, the hero becomes the cover image, shortcodes are
+expanded to plain markdown, and site images get absolute URLs.
+
+Posts are uploaded as drafts unless --publish is passed. Crossposting again
+updates the article on each platform instead of creating another one; a draft
+is published by crossposting again with --publish. What was uploaded is
+recorded in .megafone/state/crossposts.json, and published URLs are added to the
+post's syndication list.
+
+Credentials:
+  devto     DEVTO_API_KEY
+  hashnode  HASHNODE_TOKEN and HASHNODE_PUBLICATION (the blog's host, e.g. blog.example.com)
+
+Examples:
+  megafone crosspost content/posts/en/my-post.md
+  megafone crosspost content/posts/en/my-post.md --to devto --publish
+  megafone crosspost content/posts/en/my-post.md --dry-run`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runCrosspost(args[0]); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(crosspostCmd)
+
+	crosspostCmd.Flags().StringSliceVar(&crosspostTo, "to", []string{platformDevTo, platformHashnode}, "Platforms to crosspost to: devto, hashnode")
+	crosspostCmd.Flags().BoolVar(&crosspostPublish, "publish", false, "Publish the articles instead of uploading them as drafts")
+	crosspostCmd.Flags().StringVar(&crosspostBaseURL, "base-url", "", "Published site URL for the canonical URL and images (default: baseURL from the Hugo config)")
+	crosspostCmd.Flags().StringVarP(&siteSource, "site-source", "s", "", "Path to local Hugo site repository (default: found from the post path)")
+	crosspostCmd.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "Show the converted articles without uploading them")
+}
+
+// crosspostArticle is a post converted for a platform
+type crosspostArticle struct {
+	Title       string
+	Description string
+	Tags        []string
+	Canonical   string
+	Cover       string
+	Body        string
+}
+
+// crosspostRecord is what was uploaded to a platform, so the next crosspost
+// updates it
+type crosspostRecord struct {
+	ID        string `json:"id"`
+	URL       string `json:"url,omitempty"`
+	Published bool   `json:"published"`
+	Updated   string `json:"updated"`
+}
+
+// crosspostLog is .megafone/state/crossposts.json: post path → platform → record
+type crosspostLog map[string]map[string]crosspostRecord
+
+func runCrosspost(postPath string) error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	for _, p := range crosspostTo {
+		if p != platformDevTo && p != platformHashnode {
+			return fmt.Errorf("unknown platform %q (use devto or hashnode)", p)
+		}
+	}
+	basePath, err := scheduleSitePath(postPath)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(postPath)
+	if err != nil {
+		return fmt.Errorf("failed to read post: %w", err)
+	}
+	content := string(data)
+	if frontMatterString(content, "draft") == "true" && crosspostPublish {
+		return fmt.Errorf("%s is still a draft on your site; publish it there first so the canonical URL works", postPath)
+	}
+
+	baseURL := strings.TrimRight(firstNonEmpty(crosspostBaseURL, siteBaseURL(basePath)), "/")
+	if baseURL == "" {
+		return fmt.Errorf("the Hugo config has no baseURL, so the canonical URL can't be built (set --base-url)")
+	}
+	canonical := baseURL + postURL(basePath, postPath, content)
+
+	absBase, _ := filepath.Abs(basePath)
+	absPost, _ := filepath.Abs(postPath)
+	rel := filepath.ToSlash(mustRel(absBase, absPost))
+	slug := strings.TrimSuffix(filepath.Base(rel), ".md")
+
+	records, err := loadCrosspostLog(basePath)
+	if err != nil {
+		return err
+	}
+	if records[rel] == nil {
+		records[rel] = make(map[string]crosspostRecord)
+	}
+
+	ctx := context.Background()
+	experiment := experimentForPost(basePath, postPath)
+	failed := 0
+	for _, platform := range crosspostTo {
+		post := content
+		if v, ok := experiment.platformVariant(platform); ok {
+			logInfo("🧪 Using experiment variant %s on %s: %s", v.Name, platform, v.Title)
+			post = withVariant(post, v)
+		}
+		article := crosspostArticleFor(ctx, post, platform, baseURL, canonical, slug)
+
+		if dryRun {
+			state := "draft"
+			if crosspostPublish {
+				state = "published"
+			}
+			fmt.Printf("%s (%s)\n  title:     %s\n  canonical: %s\n  tags:      %s\n  cover:     %s\n  body:      %s chars\n\n",
+				platform, state, article.Title, article.Canonical, strings.Join(article.Tags, ", "), article.Cover, formatCount(len(article.Body)))
+			continue
+		}
+
+		previous, exists := records[rel][platform]
+		var record crosspostRecord
+		switch platform {
+		case platformDevTo:
+			record, err = crosspostDevTo(ctx, article, previous, exists)
+		case platformHashnode:
+			record, err = crosspostHashnode(ctx, article, previous, exists)
+		}
+		if err != nil {
+			failed++
+			logError("Failed to crosspost to %s: %v", platform, err)
+			continue
+		}
+		record.Updated = time.Now().Format(time.RFC3339)
+		records[rel][platform] = record
+		verb := "Uploaded a draft to"
+		if record.Published {
+			verb = "Published on"
+		}
+		logSuccess("✅ %s %s: %s", verb, platform, firstNonEmpty(record.URL, record.ID))
+		if record.Published && record.URL != "" {
+			content = addSyndicationURL(content, record.URL)
+		}
+	}
+
+	if dryRun {
+		logInfo("Dry run mode - not uploading")
+		return nil
+	}
+	if err := saveCrosspostLog(basePath, records); err != nil {
+		return fmt.Errorf("failed to save crosspost log: %w", err)
+	}
+	if content != string(data) {
+		if err := os.WriteFile(postPath, []byte(content), 0644); err != nil {
+			return fmt.Errorf("failed to update post: %w", err)
+		}
+		recordSiteChange("modified", postPath, "", false)
+	}
+	if failed > 0 {
+		return fmt.Errorf("crossposting failed on %d platforms", failed)
+	}
+	return nil
+}
+
+// crosspostArticleFor converts a post's front matter and body for a platform
+func crosspostArticleFor(ctx context.Context, content, platform, baseURL, canonical, slug string) crosspostArticle {
+	body := expandShortcodesMarkdown(postBody(content))
+	body = absolutizeSiteURLs(trackMarkdownLinks(ctx, body, baseURL, platform, slug, slug), baseURL)
+	article := crosspostArticle{
+		Title:       frontMatterString(content, "title"),
+		Description: frontMatterString(content, "description"),
+		Canonical:   canonical,
+		Body:        body,
+	}
+	if hero := frontMatterString(content, "hero"); hero != "" {
+		article.Cover = absolutizeSiteURLs(hero, baseURL)
+	}
+	switch platform {
+	case platformDevTo:
+		article.Tags = devToTags(content)
+	case platformHashnode:
+		// Hashnode takes up to five tags, each a slug and a display name
+		for _, t := range frontMatterList(content, "tags") {
+			if len(article.Tags) < 5 && hashnodeTagSlug(t) != "" {
+				article.Tags = append(article.Tags, t)
+			}
+		}
+	}
+	return article
+}
+
+func hashnodeTagSlug(tag string) string {
+	words := strings.Fields(tagCharsRegex.ReplaceAllString(strings.ToLower(tag), " "))
+	return strings.Join(words, "-")
+}
+
+// crosspostDevTo creates or updates a dev.to article. dev.to publishes by
+// setting published on the article, and an article can't be unpublished by
+// the API, so a published one stays published.
+func crosspostDevTo(ctx context.Context, a crosspostArticle, previous crosspostRecord, exists bool) (crosspostRecord, error) {
+	apiKey := os.Getenv("DEVTO_API_KEY")
+	if apiKey == "" {
+		return crosspostRecord{}, classify(ErrAuth, fmt.Errorf("DEVTO_API_KEY is required to crosspost to dev.to"))
+	}
+	published := crosspostPublish || previous.Published
+	article := map[string]interface{}{
+		"title":         a.Title,
+		"body_markdown": a.Body,
+		"published":     published,
+		"tags":          a.Tags,
+		"canonical_url": a.Canonical,
+	}
+	if a.Description != "" {
+		article["description"] = a.Description
+	}
+	if a.Cover != "" {
+		article["main_image"] = a.Cover
+	}
+
+	var reply struct {
+		ID  int    `json:"id"`
+		URL string `json:"url"`
+	}
+	headers := map[string]string{"api-key": apiKey}
+	body := map[string]interface{}{"article": article}
+	if exists {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return crosspostRecord{}, err
+		}
+		if err := socialRequest(ctx, http.MethodPut, "dev.to", devToAPI+"/articles/"+previous.ID, headers, bytes.NewReader(payload), &reply); err != nil {
+			return crosspostRecord{}, err
+		}
+	} else if err := postSocialJSON(ctx, "dev.to", devToAPI+"/articles", headers, body, &reply); err != nil {
+		return crosspostRecord{}, err
+	}
+	return crosspostRecord{ID: fmt.Sprint(reply.ID), URL: reply.URL, Published: published}, nil
+}
+
+// crosspostHashnode creates a draft or post on Hashnode, publishes an earlier
+// draft with --publish, or updates an earlier published post
+func crosspostHashnode(ctx context.Context, a crosspostArticle, previous crosspostRecord, exists bool) (crosspostRecord, error) {
+	token := os.Getenv("HASHNODE_TOKEN")
+	host := os.Getenv("HASHNODE_PUBLICATION")
+	if token == "" || host == "" {
+		return crosspostRecord{}, classify(ErrAuth, fmt.Errorf("HASHNODE_TOKEN and HASHNODE_PUBLICATION are required to crosspost to Hashnode"))
+	}
+
+	var publication struct {
+		Publication *struct {
+			ID string `json:"id"`
+		} `json:"publication"`
+	}
+	if err := hashnodeQuery(ctx, token, `query($host: String!) { publication(host: $host) { id } }`, map[string]interface{}{"host": host}, &publication); err != nil {
+		return crosspostRecord{}, err
+	}
+	if publication.Publication == nil {
+		return crosspostRecord{}, fmt.Errorf("no Hashnode publication at %s", host)
+	}
+
+	tags := make([]map[string]string, len(a.Tags))
+	for i, t := range a.Tags {
+		tags[i] = map[string]string{"slug": hashnodeTagSlug(t), "name": t}
+	}
+	input := map[string]interface{}{
+		"title":              a.Title,
+		"contentMarkdown":    a.Body,
+		"tags":               tags,
+		"originalArticleURL": a.Canonical,
+	}
+	if a.Description != "" {
+		input["subtitle"] = a.Description
+	}
+	if a.Cover != "" {
+		input["coverImageOptions"] = map[string]string{"coverImageURL": a.Cover}
+	}
+
+	switch {
+	case exists && previous.Published:
+		input["id"] = previous.ID
+		var reply struct {
+			UpdatePost struct {
+				Post struct{ ID, URL string } `json:"post"`
+			} `json:"updatePost"`
+		}
+		err := hashnodeQuery(ctx, token, `mutation($input: UpdatePostInput!) { updatePost(input: $input) { post { id url } } }`, map[string]interface{}{"input": input}, &reply)
+		return crosspostRecord{ID: reply.UpdatePost.Post.ID, URL: reply.UpdatePost.Post.URL, Published: true}, err
+
+	case exists && !crosspostPublish:
+		// Hashnode's API can't replace a draft's content; edit it there, or publish it
+		logInfo("Already a draft on Hashnode; edit it there, or crosspost with --publish to publish it")
+		return previous, nil
+
+	case exists:
+		var reply struct {
+			PublishDraft struct {
+				Post struct{ ID, URL string } `json:"post"`
+			} `json:"publishDraft"`
+		}
+		if err := hashnodeQuery(ctx, token, `mutation($input: PublishDraftInput!) { publishDraft(input: $input) { post { id url } } }`, map[string]interface{}{"input": map[string]string{"draftId": previous.ID}}, &reply); err != nil {
+			return crosspostRecord{}, err
+		}
+		// Bring the newly published post up to date with the site
+		input["id"] = reply.PublishDraft.Post.ID
+		var updated struct {
+			UpdatePost struct {
+				Post struct{ ID, URL string } `json:"post"`
+			} `json:"updatePost"`
+		}
+		if err := hashnodeQuery(ctx, token, `mutation($input: UpdatePostInput!) { updatePost(input: $input) { post { id url } } }`, map[string]interface{}{"input": input}, &updated); err != nil {
+			logError("Published the Hashnode draft but couldn't update it: %v", err)
+		}
+		return crosspostRecord{ID: reply.PublishDraft.Post.ID, URL: reply.PublishDraft.Post.URL, Published: true}, nil
+	}
+
+	input["publicationId"] = publication.Publication.ID
+	if crosspostPublish {
+		var reply struct {
+			PublishPost struct {
+				Post struct{ ID, URL string } `json:"post"`
+			} `json:"publishPost"`
+		}
+		err := hashnodeQuery(ctx, token, `mutation($input: PublishPostInput!) { publishPost(input: $input) { post { id url } } }`, map[string]interface{}{"input": input}, &reply)
+		return crosspostRecord{ID: reply.PublishPost.Post.ID, URL: reply.PublishPost.Post.URL, Published: true}, err
+	}
+	var reply struct {
+		CreateDraft struct {
+			Draft struct{ ID string } `json:"draft"`
+		} `json:"createDraft"`
+	}
+	err := hashnodeQuery(ctx, token, `mutation($input: CreateDraftInput!) { createDraft(input: $input) { draft { id } } }`, map[string]interface{}{"input": input}, &reply)
+	return crosspostRecord{ID: reply.CreateDraft.Draft.ID}, err
+}
+
+// hashnodeQuery runs a GraphQL query or mutation against Hashnode and decodes its data into v
+func hashnodeQuery(ctx context.Context, token, query string, variables map[string]interface{}, v interface{}) error {
+	var reply struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	headers := map[string]string{"Authorization": token}
+	body := map[string]interface{}{"query": query, "variables": variables}
+	if err := postSocialJSON(ctx, "Hashnode", hashnodeAPI, headers, body, &reply); err != nil {
+		return err
+	}
+	if len(reply.Errors) > 0 {
+		return fmt.Errorf("Hashnode API error: %s", reply.Errors[0].Message)
+	}
+	return json.Unmarshal(reply.Data, v)
+}
+
+// addSyndicationURL lists a copy of the post elsewhere in its syndication
+// front matter, which themes use for "also on" links
+func addSyndicationURL(content, url string) string {
+	urls := frontMatterList(content, "syndication")
+	for _, u := range urls {
+		if u == url {
+			return content
+		}
+	}
+	return setFrontMatterList(content, "syndication", append(urls, url))
+}
+
+func crosspostLogPath(basePath string) string {
+	return workspacePath(basePath, "state", "crossposts.json")
+}
+
+func loadCrosspostLog(basePath string) (crosspostLog, error) {
+	records := make(crosspostLog)
+	data, err := os.ReadFile(crosspostLogPath(basePath))
+	if os.IsNotExist(err) {
+		return records, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("invalid crosspost log %s: %w", crosspostLogPath(basePath), err)
+	}
+	return records, nil
+}
+
+func saveCrosspostLog(basePath string, records crosspostLog) error {
+	path := crosspostLogPath(basePath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	dateFormatDate     = "date"
+	dateFormatDatetime = "datetime"
+)
+
+var (
+	publishDate    string
+	postTimezone   string
+	postDateFormat string
+)
+
+var (
+	buildFutureRegex  = regexp.MustCompile(`(?mi)^\s*buildFuture\s*[:=]\s*["']?(true|false)`)
+	hugoTimeZoneRegex = regexp.MustCompile(`(?mi)^\s*timeZone\s*[:=]\s*["']?([^"'\s]+)`)
+)
+
+// datesConfig is the dates block of megafone.yaml
+type datesConfig struct {
+	Timezone string `yaml:"timezone"`
+	Format   string `yaml:"format"`
+}
+
+// postDate is the date generated posts carry, resolved once per run by resolvePostDate
+var postDate struct {
+	time   time.Time
+	format string
+	set    bool // --publish-date was given
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&publishDate, "publish-date", "", "Date for generated posts: 2006-01-02, 2006-01-02T15:04, or an offset from now like +3d or +12h (default: now)")
+	rootCmd.PersistentFlags().StringVar(&postTimezone, "timezone", "", "IANA timezone for post dates, e.g. Europe/Berlin (default: dates.timezone in config, then the system zone)")
+	rootCmd.PersistentFlags().StringVar(&postDateFormat, "date-format", "", "Front matter date style: date (2006-01-02) or datetime (RFC 3339 with offset) (default: dates.format in config, then date)")
+}
+
+// resolvePostDate applies the date flags and config. It runs before every
+// command so a bad --publish-date fails fast.
+func resolvePostDate() error {
+	tz := firstNonEmpty(postTimezone, appConfig.Dates.Timezone)
+	loc := time.Local
+	if tz != "" {
+		var err error
+		if loc, err = time.LoadLocation(tz); err != nil {
+			return fmt.Errorf("invalid timezone %q: %w", tz, err)
+		}
+	}
+
+	format := firstNonEmpty(postDateFormat, appConfig.Dates.Format)
+	switch format {
+	case "", dateFormatDate, dateFormatDatetime:
+	default:
+		return fmt.Errorf("invalid date format %q (use date or datetime)", format)
+	}
+
+	now := time.Now().In(loc)
+	postDate.time, postDate.set = now, publishDate != ""
+	if publishDate != "" {
+		t, hasTime, err := parsePublishDate(publishDate, now, loc)
+		if err != nil {
+			return err
+		}
+		postDate.time = t
+		// A specific time only survives in datetime format
+		if format == "" && hasTime {
+			format = dateFormatDatetime
+		}
+	}
+	postDate.format = firstNonEmpty(format, dateFormatDate)
+	return nil
+}
+
+// parsePublishDate accepts a date, a date and time, or a +offset (3d, 2w, 12h)
+// from now, and reports whether the value pins a time of day
+func parsePublishDate(value string, now time.Time, loc *time.Location) (time.Time, bool, error) {
+	if strings.HasPrefix(value, "+") {
+		offset := value[1:]
+		if n := len(offset); n > 1 {
+			var count int
+			if _, err := fmt.Sscanf(offset[:n-1], "%d", &count); err == nil {
+				switch offset[n-1] {
+				case 'd':
+					return now.AddDate(0, 0, count), false, nil
+				case 'w':
+					return now.AddDate(0, 0, 7*count), false, nil
+				}
+			}
+		}
+		if d, err := time.ParseDuration(offset); err == nil {
+			return now.Add(d), true, nil
+		}
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.In(loc), true, nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04"} {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, true, nil
+		}
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, loc); err == nil {
+		return t, false, nil
+	}
+	return time.Time{}, false, fmt.Errorf("invalid --publish-date value %q (use 2006-01-02, 2006-01-02T15:04, or +3d)", value)
+}
+
+// postDateString is the front matter date for generated posts
+func postDateString() string {
+	if postDate.format == dateFormatDatetime {
+		return postDate.time.Format(time.RFC3339)
+	}
+	return postDate.time.Format("2006-01-02")
+}
+
+// applyDatePolicy makes sure a generated post carries the date it was told to
+// use, correcting the model if it wrote another, and warns when Hugo will hold
+// the post back as future-dated
+func applyDatePolicy(content, expected, basePath string) string {
+	got := frontMatterString(content, "date")
+	if got != expected {
+		if got == "" {
+			logInfo("⚠️  Model left out the date; setting date: %s", expected)
+		} else {
+			logInfo("⚠️  Model dated the post %s instead of %s; correcting", got, expected)
+		}
+		content = upsertFrontMatterField(content, "date", expected)
+	}
+	checkFutureDate(expected, basePath)
+	return content
+}
+
+// checkFutureDate explains what Hugo's buildFuture setting means for a post
+// dated after now. Hugo reads date-only values as midnight in the site's
+// timeZone (UTC by default), so a post dated "today" in a zone ahead of UTC
+// can count as future too.
+func checkFutureDate(date, basePath string) {
+	siteLoc := time.UTC
+	buildFuture := false
+	if basePath != "" {
+		if tz := hugoConfigValue(basePath, hugoTimeZoneRegex); tz != "" {
+			if loc, err := time.LoadLocation(tz); err == nil {
+				siteLoc = loc
+			}
+		}
+		buildFuture = hugoConfigValue(basePath, buildFutureRegex) == "true"
+	}
+
+	t, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		if t, err = time.ParseInLocation("2006-01-02", date, siteLoc); err != nil {
+			return
+		}
+	}
+	if !t.After(time.Now()) {
+		return
+	}
+
+	switch {
+	case buildFuture:
+		logInfo("📅 Post is dated %s, but buildFuture is on, so Hugo publishes it on the next build", date)
+	case postDate.set:
+		logInfo("📅 Post is scheduled for %s; Hugo skips it until then, so rebuild the site after that date", date)
+	default:
+		logInfo("⚠️  Hugo reads date %s as %s, which is still in the future, so the post won't be built until then. Use --date-format datetime or set timeZone in the Hugo config.",
+			date, t.In(siteLoc).Format(time.RFC3339))
+	}
+}
+
+// hugoConfigValue returns the first match of re in the site's Hugo config file
+func hugoConfigValue(basePath string, re *regexp.Regexp) string {
+	for _, name := range []string{"hugo.toml", "hugo.yaml", "hugo.yml", "config.toml", "config.yaml", "config.yml"} {
+		data, err := os.ReadFile(filepath.Join(basePath, name))
+		if err != nil {
+			continue
+		}
+		if m := re.FindSubmatch(data); m != nil {
+			return string(m[1])
+		}
+	}
+	return ""
+}
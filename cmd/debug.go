@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	verbose   bool
+	debugMode bool
+)
+
+// maxDebugBody caps how much of a request body --debug prints
+const maxDebugBody = 4000
+
+func init() {
+	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Log request URLs, response statuses, retries, and token usage per API call")
+	rootCmd.PersistentFlags().BoolVar(&debugMode, "debug", false, "Like --verbose, and also log sanitized request bodies")
+}
+
+// enableHTTPTracing wraps the default transport so every API client logs its
+// requests. Clients that bring their own transport are not traced.
+func enableHTTPTracing() {
+	if !verbose && !debugMode {
+		return
+	}
+	if _, ok := http.DefaultTransport.(*tracingTransport); ok {
+		return
+	}
+	http.DefaultTransport = &tracingTransport{base: http.DefaultTransport}
+}
+
+type tracingTransport struct {
+	base http.RoundTripper
+}
+
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	logVerbose("→ %s %s", req.Method, sanitizeURL(req.URL))
+	if debugMode && req.Body != nil && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			data, _ := io.ReadAll(io.LimitReader(body, maxDebugBody+1))
+			body.Close()
+			if len(data) > 0 {
+				logVerbose("  request body: %s", sanitizeBody(data))
+			}
+		}
+	}
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		logVerbose("← %s %s failed after %s: %v", req.Method, sanitizeURL(req.URL), elapsed, err)
+		return resp, err
+	}
+	logVerbose("← %s %s (%s)", resp.Status, sanitizeURL(req.URL), elapsed)
+	if debugMode && resp.StatusCode >= 400 {
+		// Error bodies are small and usually explain the failure
+		data, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(data))
+		logVerbose("  response body: %s", sanitizeBody(data))
+	}
+	return resp, nil
+}
+
+// logVerbose logs only with --verbose or --debug
+func logVerbose(format string, v ...interface{}) {
+	if (!verbose && !debugMode) || logger == nil {
+		return
+	}
+	msg := fmt.Sprintf(format, v...)
+	timestamp := time.Now().Format("2006-01-02 15:04:05")
+	logger.Printf("[%s] DEBUG: %s", timestamp, msg)
+}
+
+var (
+	secretQueryParams  = []string{"key", "api_key", "apikey", "token", "access_token", "client_secret", "password"}
+	secretValuePattern = regexp.MustCompile(`(sk-[A-Za-z0-9_-]{4})[A-Za-z0-9_-]{8,}|(gh[pousr]_)[A-Za-z0-9]{20,}`)
+	secretFieldPattern = regexp.MustCompile(`(?i)("[^"]*(?:key|token|secret|password)[^"]*"\s*:\s*)"[^"]*"`)
+	// Inline images would otherwise flood the log
+	dataURIPattern = regexp.MustCompile(`data:[a-z]+/[a-z0-9.+-]+;base64,[A-Za-z0-9+/=]{64,}`)
+)
+
+func sanitizeURL(u *url.URL) string {
+	clean := *u
+	clean.User = nil
+	q := clean.Query()
+	for _, name := range secretQueryParams {
+		if q.Has(name) {
+			q.Set(name, "***")
+		}
+	}
+	clean.RawQuery = q.Encode()
+	return clean.String()
+}
+
+// sanitizeBody masks credentials and inline images in a request or response body
+func sanitizeBody(data []byte) string {
+	s := string(data)
+	truncated := len(s) > maxDebugBody
+	if truncated {
+		s = s[:maxDebugBody]
+	}
+	s = secretValuePattern.ReplaceAllString(s, "$1$2***")
+	s = secretFieldPattern.ReplaceAllString(s, `$1"***"`)
+	s = dataURIPattern.ReplaceAllString(s, "data:...(base64 omitted)")
+	s = strings.TrimSpace(s)
+	if truncated {
+		s += " ...(truncated)"
+	}
+	return s
+}
@@ -0,0 +1,305 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/sashabaranov/go-openai"
+	"github.com/spf13/cobra"
+)
+
+var (
+	digestRepo  string
+	digestSince string
+)
+
+var digestCmd = &cobra.Command{
+	Use:   "digest",
+	Short: "Write a \"this month in <repo>\" development update post",
+	Long: `Collects a repository's merged pull requests, closed issues, and releases over
+a period and writes a development-update post that groups the changes by theme.
+Set GITHUB_TOKEN for private repositories and higher rate limits.
+
+Examples:
+  megafone digest --repo michaeldvinci/megafone -s ~/hugo
+  megafone digest --repo michaeldvinci/megafone --since 2w --dry-run`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runDigest(cmd); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(digestCmd)
+
+	digestCmd.Flags().StringVarP(&digestRepo, "repo", "r", "", "GitHub repository (owner/repo or URL) (required)")
+	digestCmd.Flags().StringVar(&digestSince, "since", "30d", "Period to cover: a duration like 30d or 2w, or a date (2006-01-02)")
+	digestCmd.Flags().StringVarP(&tags, "tags", "T", "", "Comma-separated tags (AI will suggest if not provided)")
+	digestCmd.Flags().StringVarP(&promptFile, "prompt", "p", "prompts/github-project.txt", "Path to prompt template file")
+	digestCmd.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "Print generated content without writing files")
+	digestCmd.Flags().StringVarP(&model, "model", "m", "gpt-4o", "OpenAI model to use")
+	digestCmd.Flags().StringVarP(&siteSource, "site-source", "s", "", "Path to local Hugo site repository")
+
+	digestCmd.MarkFlagRequired("repo")
+}
+
+// repoActivity is what happened in a repository over a period
+type repoActivity struct {
+	PullRequests []*github.Issue
+	Issues       []*github.Issue
+	Releases     []*github.RepositoryRelease
+}
+
+func runDigest(cmd *cobra.Command) error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	ctx := context.Background()
+
+	owner, repo, err := parseGitHubURL(digestRepo)
+	if err != nil {
+		return fmt.Errorf("invalid repository: %w", err)
+	}
+	since, err := parseSince(digestSince, time.Now())
+	if err != nil {
+		return err
+	}
+
+	basePath, err := resolveSitePath()
+	if err != nil {
+		return err
+	}
+	apiKey, err := getOpenAIKey(cmd)
+	if err != nil {
+		return err
+	}
+
+	logInfo("📊 Collecting activity in %s/%s since %s", owner, repo, since.Format("2006-01-02"))
+	activity, err := fetchRepoActivity(ctx, newGitHubClient(), owner, repo, since)
+	if err != nil {
+		return err
+	}
+	logInfo("Found %d merged PRs, %d closed issues, %d releases",
+		len(activity.PullRequests), len(activity.Issues), len(activity.Releases))
+	if len(activity.PullRequests)+len(activity.Issues)+len(activity.Releases) == 0 {
+		return fmt.Errorf("no activity in %s/%s since %s", owner, repo, since.Format("2006-01-02"))
+	}
+
+	promptTemplate, err := loadPrompt(promptFile, "digest", "https://github.com/"+owner+"/"+repo)
+	if err != nil {
+		return err
+	}
+
+	content, err := generateDigest(ctx, apiKey, promptTemplate, owner+"/"+repo, since, activity, tags, model)
+	if err != nil {
+		return err
+	}
+
+	content = applyDatePolicy(content, postDateString(), basePath)
+	content = applyHighlights(ctx, openai.NewClient(apiKey), content)
+	if content, err = applySummarySections(ctx, openai.NewClient(apiKey), content, "digest"); err != nil {
+		return err
+	}
+	content = applyAccessibility(content)
+	if content, err = applyDisclosure(content, basePath); err != nil {
+		return err
+	}
+	if content, err = applyFrontMatterDefaults(content, "digest", "https://github.com/"+owner+"/"+repo); err != nil {
+		return err
+	}
+	if content, err = applySecretScan(content); err != nil {
+		return err
+	}
+	filename := fmt.Sprintf("%s-update-%s", sanitizeFilename(repo), time.Now().Format("2006-01"))
+	if content, filename, err = applySlugStrategy(content, filename, ""); err != nil {
+		return err
+	}
+
+	if dryRun {
+		logInfo("Dry run mode - not writing files")
+		fmt.Println("\n" + strings.Repeat("=", 80))
+		fmt.Println("DRY RUN - Generated Content:")
+		fmt.Println(strings.Repeat("=", 80))
+		fmt.Println(content)
+		fmt.Println(strings.Repeat("=", 80))
+		return nil
+	}
+
+	postPath, err := writeNewPost(basePath, filepath.Join(postsDir(basePath), filename+".md"), content)
+	if err != nil {
+		return fmt.Errorf("failed to write post: %w", err)
+	}
+	logSuccess("✅ Digest created: %s", postPath)
+
+	var tagList []string
+	if tags != "" {
+		tagList = strings.Split(tags, ",")
+	}
+	logGeneration("https://github.com/"+owner+"/"+repo, postPath, "", tagList)
+	return runHooks("post_generate", appConfig.Hooks.PostGenerate, hookRun{Post: postPath, Source: "https://github.com/" + owner + "/" + repo, Site: basePath})
+}
+
+// parseSince accepts durations with d/w suffixes (30d, 2w), Go durations, or a date
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	if n := len(value); n > 1 {
+		var count int
+		if _, err := fmt.Sscanf(value[:n-1], "%d", &count); err == nil {
+			switch value[n-1] {
+			case 'd':
+				return now.AddDate(0, 0, -count), nil
+			case 'w':
+				return now.AddDate(0, 0, -7*count), nil
+			}
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since value %q (use 30d, 2w, 72h, or 2006-01-02)", value)
+}
+
+func fetchRepoActivity(ctx context.Context, client *github.Client, owner, repo string, since time.Time) (*repoActivity, error) {
+	var activity repoActivity
+	day := since.Format("2006-01-02")
+
+	search := func(query string) ([]*github.Issue, error) {
+		var all []*github.Issue
+		opts := &github.SearchOptions{Sort: "updated", ListOptions: github.ListOptions{PerPage: 100}}
+		for {
+			result, resp, err := client.Search.Issues(ctx, query, opts)
+			if err != nil {
+				return nil, err
+			}
+			all = append(all, result.Issues...)
+			if resp.NextPage == 0 || len(all) >= 300 {
+				return all, nil
+			}
+			opts.Page = resp.NextPage
+		}
+	}
+
+	var err error
+	activity.PullRequests, err = search(fmt.Sprintf("repo:%s/%s is:pr is:merged merged:>=%s", owner, repo, day))
+	if err != nil {
+		return nil, fmt.Errorf("failed to search pull requests: %w", err)
+	}
+	activity.Issues, err = search(fmt.Sprintf("repo:%s/%s is:issue is:closed closed:>=%s", owner, repo, day))
+	if err != nil {
+		return nil, fmt.Errorf("failed to search issues: %w", err)
+	}
+
+	releases, _, err := client.Repositories.ListReleases(ctx, owner, repo, &github.ListOptions{PerPage: 50})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list releases: %w", err)
+	}
+	for _, r := range releases {
+		if !r.GetDraft() && r.GetPublishedAt().After(since) {
+			activity.Releases = append(activity.Releases, r)
+		}
+	}
+	return &activity, nil
+}
+
+// summary renders the activity as prompt context, trimming long bodies
+func (a *repoActivity) summary() string {
+	var b strings.Builder
+	trim := func(s string, n int) string {
+		s = strings.Join(strings.Fields(s), " ")
+		if len(s) > n {
+			return s[:n] + "..."
+		}
+		return s
+	}
+
+	if len(a.Releases) > 0 {
+		b.WriteString("Releases:\n")
+		for _, r := range a.Releases {
+			fmt.Fprintf(&b, "- %s (%s): %s\n  Notes: %s\n", firstNonEmpty(r.GetName(), r.GetTagName()),
+				r.GetPublishedAt().Format("2006-01-02"), r.GetHTMLURL(), trim(r.GetBody(), 800))
+		}
+	}
+	if len(a.PullRequests) > 0 {
+		b.WriteString("\nMerged pull requests:\n")
+		for _, pr := range a.PullRequests {
+			fmt.Fprintf(&b, "- #%d %s by @%s [%s]: %s\n", pr.GetNumber(), pr.GetTitle(), pr.GetUser().GetLogin(),
+				issueLabels(pr), trim(pr.GetBody(), 300))
+		}
+	}
+	if len(a.Issues) > 0 {
+		b.WriteString("\nClosed issues:\n")
+		for _, is := range a.Issues {
+			fmt.Fprintf(&b, "- #%d %s [%s]\n", is.GetNumber(), is.GetTitle(), issueLabels(is))
+		}
+	}
+	return b.String()
+}
+
+func issueLabels(issue *github.Issue) string {
+	var names []string
+	for _, l := range issue.Labels {
+		names = append(names, l.GetName())
+	}
+	return strings.Join(names, ", ")
+}
+
+func generateDigest(ctx context.Context, apiKey, promptTemplate, fullName string, since time.Time, activity *repoActivity, userTags, model string) (string, error) {
+	client := openai.NewClient(apiKey)
+
+	summary := activity.summary()
+	if len(summary) > 20000 {
+		summary = summary[:20000] + "\n[activity truncated]"
+	}
+
+	userPrompt := fmt.Sprintf(`%s
+
+Please write a development-update post ("This month in %s") covering %s to %s.
+
+Activity:
+%s
+
+Instructions:
+- Group changes by theme (e.g. new features, performance, fixes, docs, infrastructure), not by PR order
+- Lead with releases and the most user-visible changes
+- Link PRs and issues as [#123](https://github.com/%s/pull/123) or /issues/123
+- Thank outside contributors by @handle
+- Skip dependency bumps and trivial chores unless they matter to users
+
+User-provided tags: %s (suggest appropriate tags if none provided)
+
+IMPORTANT: Your response must be ONLY valid markdown. Do not include any explanatory text before or after the markdown.
+IMPORTANT: Use date: %s in the front matter.
+
+Generate a complete Hugo markdown post following the style guide above.
+`, promptTemplate, fullName, since.Format("January 2"), time.Now().Format("January 2, 2006"),
+		summary, fullName, userTags, postDateString())
+
+	resp, err := createChatCompletion(withStreaming(ctx), client, openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: "You are an open-source maintainer writing a clear, friendly project update. Follow the style guide precisely. Output ONLY the markdown content, no explanations.",
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: userPrompt,
+			},
+		},
+		Temperature: 0.6,
+	})
+	if err != nil {
+		return "", fmt.Errorf("OpenAI API error: %w", err)
+	}
+	if len(resp.Choices) == 0 || resp.Choices[0].Message.Content == "" {
+		return "", fmt.Errorf("no response from OpenAI")
+	}
+	return resp.Choices[0].Message.Content, nil
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	aiDisclosure   bool
+	disclosureMode string
+	disclosureText string
+)
+
+const defaultDisclosureText = "This post was drafted with AI assistance ({model}) and reviewed by the author."
+
+const aiDisclosureShortcode = `{{/* Installed by megafone: standard AI-assistance disclosure */}}
+<aside class="ai-disclosure" role="note">
+  <p>{{ with .Get "text" }}{{ . }}{{ else }}This post was drafted with AI assistance{{ with $.Get "model" }} ({{ . }}){{ end }} and reviewed by the author.{{ end }}</p>
+</aside>
+`
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&aiDisclosure, "ai-disclosure", false, "Mark generated posts with ai_generated, ai_model, and ai_provider front matter")
+	rootCmd.PersistentFlags().StringVar(&disclosureMode, "disclosure", "none", "Disclosure notice at the end of generated posts: none, text, or shortcode (implies --ai-disclosure)")
+	rootCmd.PersistentFlags().StringVar(&disclosureText, "disclosure-text", defaultDisclosureText, "Disclosure wording; {model} is replaced with the model name")
+}
+
+// applyDisclosure adds the configured AI-disclosure front matter and notice to
+// a generated post. In shortcode mode the shortcode is installed into the site
+// unless it already has one.
+func applyDisclosure(content, basePath string) (string, error) {
+	switch disclosureMode {
+	case "none", "":
+		if !aiDisclosure {
+			return content, nil
+		}
+	case "text", "shortcode":
+	default:
+		return content, fmt.Errorf("invalid --disclosure value %q (use none, text, or shortcode)", disclosureMode)
+	}
+
+	content = upsertFrontMatterField(content, "ai_generated", "true")
+	usedModel := effectiveModel()
+	content = upsertFrontMatterField(content, "ai_model", yamlQuote(usedModel))
+	content = upsertFrontMatterField(content, "ai_provider", modelProvider(usedModel))
+
+	text := strings.ReplaceAll(disclosureText, "{model}", usedModel)
+	switch disclosureMode {
+	case "text":
+		if !strings.Contains(content, text) {
+			content = strings.TrimRight(content, "\n") + "\n\n---\n\n*" + text + "*\n"
+		}
+	case "shortcode":
+		if !strings.Contains(content, "{{< ai-disclosure") {
+			shortcode := fmt.Sprintf(`{{< ai-disclosure model=%q >}}`, usedModel)
+			if disclosureText != defaultDisclosureText {
+				shortcode = fmt.Sprintf(`{{< ai-disclosure model=%q text=%q >}}`, usedModel, text)
+			}
+			content = strings.TrimRight(content, "\n") + "\n\n" + shortcode + "\n"
+		}
+		if basePath != "" && !dryRun {
+			if err := ensureDisclosureShortcode(basePath); err != nil {
+				return content, fmt.Errorf("failed to install ai-disclosure shortcode: %w", err)
+			}
+		}
+	}
+	return content, nil
+}
+
+// ensureDisclosureShortcode installs layouts/shortcodes/ai-disclosure.html unless the site already has one
+func ensureDisclosureShortcode(basePath string) error {
+	path := filepath.Join(basePath, "layouts", "shortcodes", "ai-disclosure.html")
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	logInfo("Installing ai-disclosure shortcode: %s", path)
+	if err := os.WriteFile(path, []byte(aiDisclosureShortcode), 0644); err != nil {
+		return err
+	}
+	recordSiteChange("created", path, "", false)
+	return nil
+}
@@ -0,0 +1,267 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// discussionComment is one comment from an HN thread, Reddit thread, or comment page
+type discussionComment struct {
+	Author  string
+	Text    string
+	Score   int
+	Replies int
+	Depth   int
+}
+
+var (
+	htmlTagRegex  = regexp.MustCompile(`<[^>]+>`)
+	discussClient = &http.Client{Timeout: 30 * time.Second}
+)
+
+// fetchDiscussion loads the comments at discussionURL. Hacker News and Reddit
+// threads are read through their APIs; anything else is treated as a page whose
+// text is the discussion.
+func fetchDiscussion(discussionURL string) ([]discussionComment, error) {
+	u, err := url.Parse(discussionURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid discussion URL: %w", err)
+	}
+
+	if err := checkLLMHost(discussionURL); err != nil {
+		return nil, err
+	}
+	host := strings.TrimPrefix(u.Hostname(), "www.")
+	switch {
+	case host == "news.ycombinator.com":
+		id := u.Query().Get("id")
+		if id == "" {
+			return nil, fmt.Errorf("Hacker News URL has no item id: %s", discussionURL)
+		}
+		return fetchHNComments(id)
+	case host == "reddit.com" || strings.HasSuffix(host, ".reddit.com"):
+		return fetchRedditComments(u)
+	default:
+		content, _, _, err := fetchWebsiteContent(discussionURL)
+		if err != nil {
+			return nil, err
+		}
+		return []discussionComment{{Author: host, Text: content}}, nil
+	}
+}
+
+// threadMaxCommentChars caps one comment in the prompt, so a single
+// essay-length reply doesn't crowd out the rest of the thread
+const threadMaxCommentChars = 1500
+
+// discussionThread is an HN or Reddit thread given as a generate topic: the
+// link it was submitted with, or its own text, and its top comments rendered
+// for the prompt
+type discussionThread struct {
+	Title      string
+	Author     string
+	Site       string
+	LinkURL    string // the submitted article; empty for text posts
+	Text       string // the post's own text, the source when there's no link
+	Discussion string
+}
+
+// fetchTopicThread reads the thread when topicURL is an HN item or Reddit
+// comments page, and returns nil for any other URL
+func fetchTopicThread(ctx context.Context, topicURL string) (*discussionThread, error) {
+	if hnItemID(topicURL) != "" || isRedditThreadURL(topicURL) {
+		if err := checkLLMHost(topicURL); err != nil {
+			return nil, err
+		}
+	}
+	if id := hnItemID(topicURL); id != "" {
+		return fetchHNDiscussionThread(ctx, topicURL, id)
+	}
+	if isRedditThreadURL(topicURL) {
+		return fetchRedditDiscussionThread(topicURL)
+	}
+	return nil, nil
+}
+
+// hnItem is the Algolia HN API item shape
+type hnItem struct {
+	ID       int      `json:"id"`
+	Type     string   `json:"type"`
+	Author   string   `json:"author"`
+	Title    string   `json:"title"`
+	URL      string   `json:"url"`
+	Text     string   `json:"text"`
+	Points   int      `json:"points"`
+	Children []hnItem `json:"children"`
+}
+
+func fetchHNItem(id string) (*hnItem, error) {
+	resp, err := discussClient.Get("https://hn.algolia.com/api/v1/items/" + url.PathEscape(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch HN thread: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP error fetching HN thread: %s", resp.Status)
+	}
+
+	var item hnItem
+	if err := json.NewDecoder(resp.Body).Decode(&item); err != nil {
+		return nil, fmt.Errorf("failed to parse HN thread: %w", err)
+	}
+	return &item, nil
+}
+
+func fetchHNComments(id string) ([]discussionComment, error) {
+	item, err := fetchHNItem(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// HN hides comment scores, so reply count stands in for engagement
+	var comments []discussionComment
+	var walk func(children []hnItem, depth int)
+	walk = func(children []hnItem, depth int) {
+		for _, c := range children {
+			if text := htmlToText(c.Text); text != "" {
+				comments = append(comments, discussionComment{
+					Author:  c.Author,
+					Text:    text,
+					Replies: countHNReplies(c),
+					Depth:   depth,
+				})
+			}
+			walk(c.Children, depth+1)
+		}
+	}
+	walk(item.Children, 0)
+	return comments, nil
+}
+
+func countHNReplies(item hnItem) int {
+	n := len(item.Children)
+	for _, c := range item.Children {
+		n += countHNReplies(c)
+	}
+	return n
+}
+
+// redditThing is the subset of Reddit's listing JSON we read
+type redditThing struct {
+	Kind string `json:"kind"`
+	Data struct {
+		Author      string          `json:"author"`
+		Body        string          `json:"body"`
+		Title       string          `json:"title"`
+		Selftext    string          `json:"selftext"`
+		URL         string          `json:"url"`
+		Permalink   string          `json:"permalink"`
+		Score       int             `json:"score"`
+		Subreddit   string          `json:"subreddit"`
+		NumComments int             `json:"num_comments"`
+		IsSelf      bool            `json:"is_self"`
+		Stickied    bool            `json:"stickied"`
+		CreatedUTC  float64         `json:"created_utc"`
+		Replies     json.RawMessage `json:"replies"`
+		Children    []redditThing   `json:"children"`
+	} `json:"data"`
+}
+
+// fetchRedditListing requests the .json form of a Reddit URL
+func fetchRedditListing(u *url.URL) ([]redditThing, error) {
+	jsonURL := *u
+	jsonURL.Path = strings.TrimSuffix(jsonURL.Path, "/") + ".json"
+	jsonURL.RawQuery = ""
+
+	req, err := http.NewRequest(http.MethodGet, jsonURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	// Reddit throttles generic user agents hard
+	req.Header.Set("User-Agent", "megafone/1.0 (content tooling)")
+
+	resp, err := discussClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch Reddit thread: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP error fetching Reddit thread: %s", resp.Status)
+	}
+
+	var listings []redditThing
+	if err := json.NewDecoder(resp.Body).Decode(&listings); err != nil {
+		return nil, fmt.Errorf("failed to parse Reddit thread: %w", err)
+	}
+	return listings, nil
+}
+
+func fetchRedditComments(u *url.URL) ([]discussionComment, error) {
+	listings, err := fetchRedditListing(u)
+	if err != nil {
+		return nil, err
+	}
+	if len(listings) < 2 {
+		return nil, fmt.Errorf("unexpected Reddit response shape")
+	}
+
+	var comments []discussionComment
+	var walk func(things []redditThing, depth int)
+	walk = func(things []redditThing, depth int) {
+		for _, t := range things {
+			if t.Kind != "t1" {
+				continue // "more" stubs and non-comments
+			}
+			if body := strings.TrimSpace(t.Data.Body); body != "" && body != "[deleted]" && body != "[removed]" {
+				comments = append(comments, discussionComment{
+					Author: t.Data.Author,
+					Text:   html.UnescapeString(body),
+					Score:  t.Data.Score,
+					Depth:  depth,
+				})
+			}
+			// replies is "" when empty, otherwise a listing
+			var replies redditThing
+			if len(t.Data.Replies) > 0 && json.Unmarshal(t.Data.Replies, &replies) == nil {
+				walk(replies.Data.Children, depth+1)
+			}
+		}
+	}
+	walk(listings[1].Data.Children, 0)
+	return comments, nil
+}
+
+func htmlToText(s string) string {
+	s = strings.ReplaceAll(s, "<p>", "\n\n")
+	s = htmlTagRegex.ReplaceAllString(s, "")
+	return strings.TrimSpace(html.UnescapeString(s))
+}
+
+// formatDiscussion renders the most engaged comments for a prompt, staying
+// under maxChars
+func formatDiscussion(comments []discussionComment, maxChars int) string {
+	ranked := append([]discussionComment(nil), comments...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Score+ranked[i].Replies > ranked[j].Score+ranked[j].Replies
+	})
+
+	var b strings.Builder
+	for _, c := range ranked {
+		entry := fmt.Sprintf("- %s (score %d, %d replies): %s\n", c.Author, c.Score, c.Replies, strings.ReplaceAll(c.Text, "\n", " "))
+		if b.Len()+len(entry) > maxChars {
+			break
+		}
+		b.WriteString(entry)
+	}
+	return b.String()
+}
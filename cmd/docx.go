@@ -0,0 +1,342 @@
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	extast "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// Images wider than this are scaled down to fit the page (6 inches in EMU)
+const docxMaxImageWidth = 5486400
+
+// docxWriter renders a goldmark AST into WordprocessingML
+type docxWriter struct {
+	source    []byte
+	body      strings.Builder
+	rels      []string
+	media     map[string][]byte
+	loadImage func(src string) ([]byte, error)
+	imageID   int
+}
+
+// writeDocx renders a markdown post as a .docx file. loadImage fetches image
+// bytes for a src; images it can't load are replaced by their alt text.
+func writeDocx(w io.Writer, title, markdown string, loadImage func(src string) ([]byte, error)) error {
+	source := []byte(markdown)
+	doc := goldmark.New(goldmark.WithExtensions(extension.GFM)).Parser().Parse(text.NewReader(source))
+
+	d := &docxWriter{source: source, media: make(map[string][]byte), loadImage: loadImage}
+	if title != "" {
+		d.paragraph("Title", "", escapeXML(title))
+	}
+	d.blocks(doc)
+
+	zw := zip.NewWriter(w)
+	files := []struct{ name, content string }{
+		{"[Content_Types].xml", docxContentTypes},
+		{"_rels/.rels", docxRootRels},
+		{"word/styles.xml", docxStyles},
+		{"word/_rels/document.xml.rels", d.relsXML()},
+		{"word/document.xml", d.documentXML()},
+	}
+	for _, f := range files {
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(fw, f.content); err != nil {
+			return err
+		}
+	}
+	for name, data := range d.media {
+		fw, err := zw.Create("word/media/" + name)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func (d *docxWriter) blocks(parent ast.Node) {
+	for n := parent.FirstChild(); n != nil; n = n.NextSibling() {
+		d.block(n, "", "")
+	}
+}
+
+func (d *docxWriter) block(n ast.Node, style, prefix string) {
+	switch node := n.(type) {
+	case *ast.Heading:
+		d.paragraph(fmt.Sprintf("Heading%d", min(node.Level, 3)), "", d.inlines(node))
+	case *ast.Paragraph, *ast.TextBlock:
+		d.paragraph(style, prefix, d.inlines(node))
+	case *ast.Blockquote:
+		for c := node.FirstChild(); c != nil; c = c.NextSibling() {
+			d.block(c, "Quote", "")
+		}
+	case *ast.List:
+		i := node.Start
+		for item := node.FirstChild(); item != nil; item = item.NextSibling() {
+			marker := "• "
+			if node.IsOrdered() {
+				marker = fmt.Sprintf("%d. ", i)
+				i++
+			}
+			for c := item.FirstChild(); c != nil; c = c.NextSibling() {
+				d.block(c, "ListParagraph", marker)
+				marker = ""
+			}
+		}
+	case *ast.FencedCodeBlock, *ast.CodeBlock:
+		lines := node.Lines()
+		for i := 0; i < lines.Len(); i++ {
+			seg := lines.At(i)
+			line := strings.TrimRight(string(seg.Value(d.source)), "\n")
+			d.paragraph("Code", "", `<w:r><w:t xml:space="preserve">`+escapeXML(line)+`</w:t></w:r>`)
+		}
+	case *extast.Table:
+		for row := node.FirstChild(); row != nil; row = row.NextSibling() {
+			var cells []string
+			for cell := row.FirstChild(); cell != nil; cell = cell.NextSibling() {
+				cells = append(cells, d.inlines(cell))
+			}
+			d.paragraph(style, "", strings.Join(cells, `<w:r><w:tab/></w:r>`))
+		}
+	case *ast.ThematicBreak:
+		d.paragraph("", "", "")
+	case *ast.HTMLBlock:
+		// Raw HTML has no Word equivalent
+	default:
+		for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+			d.block(c, style, prefix)
+		}
+	}
+}
+
+func (d *docxWriter) paragraph(style, prefix, runs string) {
+	d.body.WriteString("<w:p>")
+	if style != "" {
+		fmt.Fprintf(&d.body, `<w:pPr><w:pStyle w:val="%s"/></w:pPr>`, style)
+	}
+	if prefix != "" {
+		fmt.Fprintf(&d.body, `<w:r><w:t xml:space="preserve">%s</w:t></w:r>`, escapeXML(prefix))
+	}
+	d.body.WriteString(runs)
+	d.body.WriteString("</w:p>")
+}
+
+// inlines renders a block's inline children as runs
+func (d *docxWriter) inlines(parent ast.Node) string {
+	var b strings.Builder
+	for n := parent.FirstChild(); n != nil; n = n.NextSibling() {
+		d.inline(&b, n, "")
+	}
+	return b.String()
+}
+
+func (d *docxWriter) inline(b *strings.Builder, n ast.Node, props string) {
+	switch node := n.(type) {
+	case *ast.Text:
+		t := string(node.Segment.Value(d.source))
+		if node.SoftLineBreak() {
+			t += " "
+		}
+		fmt.Fprintf(b, `<w:r>%s<w:t xml:space="preserve">%s</w:t></w:r>`, runProps(props), escapeXML(t))
+		if node.HardLineBreak() {
+			b.WriteString("<w:r><w:br/></w:r>")
+		}
+	case *ast.String:
+		fmt.Fprintf(b, `<w:r>%s<w:t xml:space="preserve">%s</w:t></w:r>`, runProps(props), escapeXML(string(node.Value)))
+	case *ast.CodeSpan:
+		var code strings.Builder
+		for c := node.FirstChild(); c != nil; c = c.NextSibling() {
+			if t, ok := c.(*ast.Text); ok {
+				code.Write(t.Segment.Value(d.source))
+			}
+		}
+		fmt.Fprintf(b, `<w:r>%s<w:t xml:space="preserve">%s</w:t></w:r>`, runProps(props+`<w:rStyle w:val="CodeChar"/>`), escapeXML(code.String()))
+	case *ast.Emphasis:
+		p := props + "<w:i/>"
+		if node.Level >= 2 {
+			p = props + "<w:b/>"
+		}
+		for c := node.FirstChild(); c != nil; c = c.NextSibling() {
+			d.inline(b, c, p)
+		}
+	case *extast.Strikethrough:
+		for c := node.FirstChild(); c != nil; c = c.NextSibling() {
+			d.inline(b, c, props+"<w:strike/>")
+		}
+	case *ast.Link:
+		id := d.addRel("http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink", string(node.Destination), true)
+		fmt.Fprintf(b, `<w:hyperlink r:id="%s">`, id)
+		for c := node.FirstChild(); c != nil; c = c.NextSibling() {
+			d.inline(b, c, props+`<w:rStyle w:val="Hyperlink"/>`)
+		}
+		b.WriteString("</w:hyperlink>")
+	case *ast.AutoLink:
+		url := string(node.URL(d.source))
+		id := d.addRel("http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink", url, true)
+		fmt.Fprintf(b, `<w:hyperlink r:id="%s"><w:r>%s<w:t>%s</w:t></w:r></w:hyperlink>`, id, runProps(`<w:rStyle w:val="Hyperlink"/>`), escapeXML(url))
+	case *ast.Image:
+		b.WriteString(d.image(string(node.Destination), altText(node, d.source)))
+	case *ast.RawHTML:
+		// Inline HTML has no Word equivalent
+	default:
+		for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+			d.inline(b, c, props)
+		}
+	}
+}
+
+func runProps(props string) string {
+	if props == "" {
+		return ""
+	}
+	return "<w:rPr>" + props + "</w:rPr>"
+}
+
+func altText(n ast.Node, source []byte) string {
+	var b strings.Builder
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		if t, ok := c.(*ast.Text); ok {
+			b.Write(t.Segment.Value(source))
+		}
+	}
+	return b.String()
+}
+
+// image embeds an image as an inline drawing, falling back to its alt text
+func (d *docxWriter) image(src, alt string) string {
+	fallback := fmt.Sprintf(`<w:r><w:t xml:space="preserve">[%s]</w:t></w:r>`, escapeXML(firstNonEmpty(alt, "image")))
+	if d.loadImage == nil {
+		return fallback
+	}
+	data, err := d.loadImage(src)
+	if err != nil {
+		logInfo("Could not embed image %s: %v", src, err)
+		return fallback
+	}
+	width, height, err := decodeImageDimensions(data)
+	if err != nil || width == 0 || height == 0 {
+		return fallback
+	}
+
+	d.imageID++
+	ext := strings.ToLower(filepath.Ext(strings.SplitN(src, "?", 2)[0]))
+	if ext == "" || ext == ".jpeg" {
+		ext = ".jpg"
+	}
+	name := fmt.Sprintf("image%d%s", d.imageID, ext)
+	d.media[name] = data
+	relID := d.addRel("http://schemas.openxmlformats.org/officeDocument/2006/relationships/image", "media/"+name, false)
+
+	// 9525 EMU per pixel at 96 DPI
+	cx, cy := int64(width)*9525, int64(height)*9525
+	if cx > docxMaxImageWidth {
+		cy = cy * docxMaxImageWidth / cx
+		cx = docxMaxImageWidth
+	}
+
+	return fmt.Sprintf(`<w:r><w:drawing><wp:inline><wp:extent cx="%d" cy="%d"/><wp:docPr id="%d" name="%s" descr="%s"/>`+
+		`<a:graphic><a:graphicData uri="http://schemas.openxmlformats.org/drawingml/2006/picture"><pic:pic>`+
+		`<pic:nvPicPr><pic:cNvPr id="%d" name="%s"/><pic:cNvPicPr/></pic:nvPicPr>`+
+		`<pic:blipFill><a:blip r:embed="%s"/><a:stretch><a:fillRect/></a:stretch></pic:blipFill>`+
+		`<pic:spPr><a:xfrm><a:off x="0" y="0"/><a:ext cx="%d" cy="%d"/></a:xfrm><a:prstGeom prst="rect"><a:avLst/></a:prstGeom></pic:spPr>`+
+		`</pic:pic></a:graphicData></a:graphic></wp:inline></w:drawing></w:r>`,
+		cx, cy, d.imageID, name, escapeXML(alt), d.imageID, name, relID, cx, cy)
+}
+
+func (d *docxWriter) addRel(relType, target string, external bool) string {
+	id := fmt.Sprintf("rId%d", len(d.rels)+2) // rId1 is the styles part
+	mode := ""
+	if external {
+		mode = ` TargetMode="External"`
+	}
+	d.rels = append(d.rels, fmt.Sprintf(`<Relationship Id="%s" Type="%s" Target="%s"%s/>`, id, relType, escapeXML(target), mode))
+	return id
+}
+
+func (d *docxWriter) relsXML() string {
+	return `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+		strings.Join(d.rels, "") + `</Relationships>`
+}
+
+func (d *docxWriter) documentXML() string {
+	return `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" ` +
+		`xmlns:wp="http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing" ` +
+		`xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" ` +
+		`xmlns:pic="http://schemas.openxmlformats.org/drawingml/2006/picture">` +
+		`<w:body>` + d.body.String() + `<w:sectPr/></w:body></w:document>`
+}
+
+func escapeXML(s string) string {
+	var b bytes.Buffer
+	for _, r := range s {
+		switch r {
+		case '&':
+			b.WriteString("&amp;")
+		case '<':
+			b.WriteString("&lt;")
+		case '>':
+			b.WriteString("&gt;")
+		case '"':
+			b.WriteString("&quot;")
+		default:
+			// XML 1.0 forbids most control characters
+			if r < 0x20 && r != '\t' && r != '\n' && r != '\r' {
+				continue
+			}
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+const docxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Default Extension="png" ContentType="image/png"/>
+<Default Extension="jpg" ContentType="image/jpeg"/>
+<Default Extension="gif" ContentType="image/gif"/>
+<Default Extension="webp" ContentType="image/webp"/>
+<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>
+<Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>
+</Types>`
+
+const docxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>
+</Relationships>`
+
+const docxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:docDefaults><w:rPrDefault><w:rPr><w:rFonts w:ascii="Calibri" w:hAnsi="Calibri"/><w:sz w:val="22"/></w:rPr></w:rPrDefault>
+<w:pPrDefault><w:pPr><w:spacing w:after="160" w:line="276" w:lineRule="auto"/></w:pPr></w:pPrDefault></w:docDefaults>
+<w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/></w:style>
+<w:style w:type="paragraph" w:styleId="Title"><w:name w:val="Title"/><w:basedOn w:val="Normal"/><w:rPr><w:b/><w:sz w:val="48"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading1"><w:name w:val="heading 1"/><w:basedOn w:val="Normal"/><w:pPr><w:keepNext/><w:spacing w:before="360"/><w:outlineLvl w:val="0"/></w:pPr><w:rPr><w:b/><w:sz w:val="36"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading2"><w:name w:val="heading 2"/><w:basedOn w:val="Normal"/><w:pPr><w:keepNext/><w:spacing w:before="280"/><w:outlineLvl w:val="1"/></w:pPr><w:rPr><w:b/><w:sz w:val="30"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading3"><w:name w:val="heading 3"/><w:basedOn w:val="Normal"/><w:pPr><w:keepNext/><w:spacing w:before="240"/><w:outlineLvl w:val="2"/></w:pPr><w:rPr><w:b/><w:sz w:val="26"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="ListParagraph"><w:name w:val="List Paragraph"/><w:basedOn w:val="Normal"/><w:pPr><w:ind w:left="720" w:hanging="360"/></w:pPr></w:style>
+<w:style w:type="paragraph" w:styleId="Quote"><w:name w:val="Quote"/><w:basedOn w:val="Normal"/><w:pPr><w:ind w:left="720"/></w:pPr><w:rPr><w:i/><w:color w:val="555555"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Code"><w:name w:val="Code"/><w:basedOn w:val="Normal"/><w:pPr><w:spacing w:after="0" w:line="240" w:lineRule="auto"/><w:shd w:val="clear" w:fill="F5F5F5"/></w:pPr><w:rPr><w:rFonts w:ascii="Consolas" w:hAnsi="Consolas"/><w:sz w:val="18"/></w:rPr></w:style>
+<w:style w:type="character" w:styleId="CodeChar"><w:name w:val="Code Char"/><w:rPr><w:rFonts w:ascii="Consolas" w:hAnsi="Consolas"/><w:sz w:val="20"/></w:rPr></w:style>
+<w:style w:type="character" w:styleId="Hyperlink"><w:name w:val="Hyperlink"/><w:rPr><w:color w:val="0563C1"/><w:u w:val="single"/></w:rPr></w:style>
+</w:styles>`
@@ -0,0 +1,317 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+)
+
+// ecosystemContext adds package downloads, alternatives, and community
+// activity to repo posts
+var ecosystemContext bool
+
+var (
+	manifestNameRegex = regexp.MustCompile(`(?m)^\s*name\s*=\s*["']([^"']+)["']`)
+	goModuleRegex     = regexp.MustCompile(`(?m)^module\s+(\S+)`)
+	importedByRegex   = regexp.MustCompile(`Imported by:?\s*(?:<[^>]*>\s*)*([\d,]+)`)
+)
+
+// ecosystemFacts is what's known about a project's place in its ecosystem,
+// as lines of plain facts for the prompt
+type ecosystemFacts struct {
+	mu           sync.Mutex
+	Packages     []string
+	Alternatives []string
+	Activity     []string
+}
+
+func (f *ecosystemFacts) add(list *[]string, format string, args ...interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	*list = append(*list, fmt.Sprintf(format, args...))
+}
+
+// gatherEcosystemFacts looks up the project's package registries, similar
+// repositories, and recent activity concurrently. Every lookup is optional:
+// one that fails is logged and left out.
+func gatherEcosystemFacts(ctx context.Context, gh *github.Client, repoData *github.Repository) *ecosystemFacts {
+	owner, repo := repoData.GetOwner().GetLogin(), repoData.GetName()
+	raw := func(p string) string {
+		return fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s/%s", owner, repo, firstNonEmpty(repoData.GetDefaultBranch(), "main"), p)
+	}
+	facts := &ecosystemFacts{}
+	lookups := map[string]func() error{
+		"npm": func() error {
+			data, err := fetchRepoFile(raw("package.json"))
+			if err != nil {
+				return nil
+			}
+			var pkg struct {
+				Name    string `json:"name"`
+				Private bool   `json:"private"`
+			}
+			if json.Unmarshal(data, &pkg) != nil || pkg.Name == "" || pkg.Private {
+				return nil
+			}
+			var reply struct {
+				Downloads int `json:"downloads"`
+			}
+			if err := getSocialJSON(ctx, "npm", "https://api.npmjs.org/downloads/point/last-month/"+pkg.Name, nil, &reply); err != nil {
+				return err
+			}
+			facts.add(&facts.Packages, "npm package %s: %s downloads in the last month", pkg.Name, formatCount(reply.Downloads))
+			return nil
+		},
+		"PyPI": func() error {
+			var name string
+			for _, manifest := range []string{"pyproject.toml", "setup.cfg", "setup.py"} {
+				if data, err := fetchRepoFile(raw(manifest)); err == nil {
+					if m := manifestNameRegex.FindSubmatch(data); m != nil {
+						name = string(m[1])
+						break
+					}
+				}
+			}
+			if name == "" {
+				return nil
+			}
+			var reply struct {
+				Data struct {
+					LastMonth int `json:"last_month"`
+				} `json:"data"`
+			}
+			if err := getSocialJSON(ctx, "PyPI Stats", "https://pypistats.org/api/packages/"+url.PathEscape(strings.ToLower(name))+"/recent", nil, &reply); err != nil {
+				return err
+			}
+			facts.add(&facts.Packages, "PyPI package %s: %s downloads in the last month", name, formatCount(reply.Data.LastMonth))
+			return nil
+		},
+		"crates.io": func() error {
+			data, err := fetchRepoFile(raw("Cargo.toml"))
+			if err != nil {
+				return nil
+			}
+			m := manifestNameRegex.FindSubmatch(data)
+			if m == nil {
+				return nil
+			}
+			var reply struct {
+				Crate struct {
+					Downloads       int `json:"downloads"`
+					RecentDownloads int `json:"recent_downloads"`
+				} `json:"crate"`
+			}
+			// crates.io rejects requests without a descriptive user agent
+			headers := map[string]string{"User-Agent": "megafone/1.0 (content tooling)"}
+			if err := getSocialJSON(ctx, "crates.io", "https://crates.io/api/v1/crates/"+url.PathEscape(string(m[1])), headers, &reply); err != nil {
+				return err
+			}
+			facts.add(&facts.Packages, "crates.io crate %s: %s downloads in the last 90 days, %s all time", m[1], formatCount(reply.Crate.RecentDownloads), formatCount(reply.Crate.Downloads))
+			return nil
+		},
+		"pkg.go.dev": func() error {
+			data, err := fetchRepoFile(raw("go.mod"))
+			if err != nil {
+				return nil
+			}
+			m := goModuleRegex.FindSubmatch(data)
+			if m == nil {
+				return nil
+			}
+			// The Go module proxy doesn't count downloads; importers are the closest measure
+			page, err := getText(ctx, "https://pkg.go.dev/"+string(m[1]))
+			if err != nil {
+				return err
+			}
+			if n := importedByRegex.FindStringSubmatch(page); n != nil {
+				facts.add(&facts.Packages, "Go module %s: imported by %s packages (pkg.go.dev)", m[1], n[1])
+			}
+			return nil
+		},
+		"alternatives": func() error {
+			return findAlternatives(ctx, gh, repoData, facts)
+		},
+		"activity": func() error {
+			return recentActivity(ctx, gh, repoData, facts)
+		},
+		"Hacker News": func() error {
+			var reply struct {
+				Hits []struct {
+					Title       string `json:"title"`
+					Points      int    `json:"points"`
+					NumComments int    `json:"num_comments"`
+					CreatedAt   string `json:"created_at"`
+				} `json:"hits"`
+			}
+			endpoint := "https://hn.algolia.com/api/v1/search?tags=story&query=" + url.QueryEscape(repoData.GetFullName())
+			if err := getSocialJSON(ctx, "Hacker News", endpoint, nil, &reply); err != nil {
+				return err
+			}
+			sort.Slice(reply.Hits, func(i, j int) bool { return reply.Hits[i].Points > reply.Hits[j].Points })
+			for i, h := range reply.Hits {
+				if i == 3 {
+					break
+				}
+				facts.add(&facts.Activity, "Hacker News: %q, %d points, %d comments (%.10s)", h.Title, h.Points, h.NumComments, h.CreatedAt)
+			}
+			return nil
+		},
+	}
+
+	var wg sync.WaitGroup
+	for name, lookup := range lookups {
+		wg.Add(1)
+		go func(name string, lookup func() error) {
+			defer wg.Done()
+			if err := lookup(); err != nil {
+				logVerbose("Ecosystem lookup %s failed: %v", name, err)
+			}
+		}(name, lookup)
+	}
+	wg.Wait()
+
+	// Goroutines finish in any order; keep the prompt stable between runs
+	sort.Strings(facts.Packages)
+	sort.Strings(facts.Activity)
+	return facts
+}
+
+// findAlternatives lists popular repositories sharing the project's topics,
+// the ones sharing the most topics first
+func findAlternatives(ctx context.Context, gh *github.Client, repoData *github.Repository, facts *ecosystemFacts) error {
+	topics := repoData.Topics
+	if len(topics) == 0 {
+		return nil
+	}
+	if len(topics) > 4 {
+		topics = topics[:4]
+	}
+	type alternative struct {
+		repo   *github.Repository
+		shared int
+	}
+	found := make(map[string]*alternative)
+	for _, topic := range topics {
+		result, _, err := gh.Search.Repositories(ctx, fmt.Sprintf("topic:%s stars:>=50", topic), &github.SearchOptions{Sort: "stars", ListOptions: github.ListOptions{PerPage: 10}})
+		if err != nil {
+			return err
+		}
+		for _, r := range result.Repositories {
+			if r.GetFullName() == repoData.GetFullName() || r.GetArchived() {
+				continue
+			}
+			if a, ok := found[r.GetFullName()]; ok {
+				a.shared++
+			} else {
+				found[r.GetFullName()] = &alternative{repo: r, shared: 1}
+			}
+		}
+	}
+	alternatives := make([]*alternative, 0, len(found))
+	for _, a := range found {
+		alternatives = append(alternatives, a)
+	}
+	sort.Slice(alternatives, func(i, j int) bool {
+		if alternatives[i].shared != alternatives[j].shared {
+			return alternatives[i].shared > alternatives[j].shared
+		}
+		return alternatives[i].repo.GetStargazersCount() > alternatives[j].repo.GetStargazersCount()
+	})
+	for i, a := range alternatives {
+		if i == 5 {
+			break
+		}
+		facts.add(&facts.Alternatives, "%s (%s stars, %s): %s", a.repo.GetFullName(), formatCount(a.repo.GetStargazersCount()), firstNonEmpty(a.repo.GetLanguage(), "unknown language"), a.repo.GetDescription())
+	}
+	return nil
+}
+
+// recentActivity describes how alive the project is: releases, commits,
+// issues, and stars
+func recentActivity(ctx context.Context, gh *github.Client, repoData *github.Repository, facts *ecosystemFacts) error {
+	owner, repo := repoData.GetOwner().GetLogin(), repoData.GetName()
+	facts.add(&facts.Activity, "GitHub: %s stars, %s forks, %s open issues, last push %s", formatCount(repoData.GetStargazersCount()), formatCount(repoData.GetForksCount()), formatCount(repoData.GetOpenIssuesCount()), repoData.GetPushedAt().Format("2006-01-02"))
+
+	since := time.Now().AddDate(0, -3, 0)
+	commits, _, err := gh.Repositories.ListCommits(ctx, owner, repo, &github.CommitsListOptions{Since: since, ListOptions: github.ListOptions{PerPage: 100}})
+	if err != nil {
+		return err
+	}
+	count := strconv.Itoa(len(commits))
+	if len(commits) == 100 {
+		count = "100+"
+	}
+	facts.add(&facts.Activity, "Commits to the default branch in the last 3 months: %s", count)
+
+	releases, _, err := gh.Repositories.ListReleases(ctx, owner, repo, &github.ListOptions{PerPage: 3})
+	if err != nil {
+		return err
+	}
+	for _, r := range releases {
+		facts.add(&facts.Activity, "Release %s on %s", r.GetTagName(), r.GetPublishedAt().Format("2006-01-02"))
+	}
+	return nil
+}
+
+// promptSection asks for a "Where It Fits" section built from the facts
+func (f *ecosystemFacts) promptSection() string {
+	if len(f.Packages)+len(f.Alternatives)+len(f.Activity) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(`
+
+## Ecosystem Context (fetched today)
+Include a section titled "## Where It Fits" that places the project in its
+ecosystem using only the facts below: how widely it's used, how active it is,
+and how it compares with the alternatives (name them, and be fair about what
+each does better). Give numbers with their time frame. Don't invent figures.
+`)
+	for _, group := range []struct {
+		title string
+		lines []string
+	}{{"Package downloads", f.Packages}, {"Alternatives (repositories sharing its GitHub topics)", f.Alternatives}, {"Community activity", f.Activity}} {
+		if len(group.lines) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n%s:\n- %s\n", group.title, strings.Join(group.lines, "\n- "))
+	}
+	return b.String()
+}
+
+// formatCount writes a count with thousands separators
+func formatCount(n int) string {
+	s := strconv.Itoa(n)
+	for i := len(s) - 3; i > 0 && s[i-1] != '-'; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
+func getText(ctx context.Context, endpoint string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := socialClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP error: %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 5<<20))
+	return string(data), err
+}
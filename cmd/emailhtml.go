@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	youtubeShortcodeRegex = regexp.MustCompile(`\{\{<\s*youtube\s+(?:id=)?"?([\w-]+)"?\s*>\}\}`)
+	emailPreCodeRegex     = regexp.MustCompile(`<pre><code[^>]*>`)
+	emailTagRegex         = regexp.MustCompile(`<(p|h[1-6]|a|ul|ol|li|blockquote|pre|code|img|figure|figcaption|hr|table|th|td|sup)(\s[^>]*?)?(\s*/?)>`)
+	footnoteRefRegex      = regexp.MustCompile(`<sup id="fnref:[^"]*"><a href="#fn:[^"]*" class="footnote-ref" role="doc-noteref">([^<]*)</a></sup>`)
+	footnoteBackRegex     = regexp.MustCompile(`(?:&#160;|&nbsp;)?<a href="#fnref:[^"]*" class="footnote-backref" role="doc-backlink">[^<]*</a>`)
+	footnoteBlockRegex    = regexp.MustCompile(`<div class="footnotes" role="doc-endnotes">\s*<hr\s*/?>`)
+	footnoteItemRegex     = regexp.MustCompile(`<li id="fn:[^"]*">`)
+)
+
+// emailStyles are inlined on each element because most email clients ignore
+// <style> blocks and strip classes
+var emailStyles = map[string]string{
+	"p":          "margin:0 0 16px;font-size:16px;line-height:1.6;color:#222222;",
+	"h1":         "margin:0 0 12px;font-size:28px;line-height:1.25;color:#111111;",
+	"h2":         "margin:28px 0 12px;font-size:22px;line-height:1.3;color:#111111;",
+	"h3":         "margin:24px 0 10px;font-size:18px;line-height:1.3;color:#111111;",
+	"h4":         "margin:20px 0 8px;font-size:16px;line-height:1.3;color:#111111;",
+	"h5":         "margin:20px 0 8px;font-size:15px;line-height:1.3;color:#111111;",
+	"h6":         "margin:20px 0 8px;font-size:14px;line-height:1.3;color:#555555;",
+	"a":          "color:#1a73e8;text-decoration:underline;",
+	"ul":         "margin:0 0 16px;padding-left:24px;",
+	"ol":         "margin:0 0 16px;padding-left:24px;",
+	"li":         "margin:0 0 6px;font-size:16px;line-height:1.6;color:#222222;",
+	"blockquote": "margin:0 0 16px;padding:4px 16px;border-left:4px solid #dddddd;color:#555555;",
+	"pre":        "margin:0 0 16px;padding:12px 16px;background-color:#f6f8fa;border:1px solid #e1e4e8;border-radius:4px;font-family:Menlo,Consolas,'Courier New',monospace;font-size:13px;line-height:1.45;color:#24292e;white-space:pre-wrap;word-wrap:break-word;",
+	"code":       "padding:2px 4px;background-color:#f6f8fa;border-radius:3px;font-family:Menlo,Consolas,'Courier New',monospace;font-size:14px;color:#24292e;",
+	"img":        "display:block;max-width:100%;height:auto;border:0;margin:0 auto;",
+	"figure":     "margin:0 0 16px;",
+	"figcaption": "padding-top:6px;font-size:13px;line-height:1.4;color:#666666;text-align:center;",
+	"hr":         "border:0;border-top:1px solid #e5e5e5;margin:24px 0;",
+	"table":      "margin:0 0 16px;border-collapse:collapse;",
+	"th":         "padding:6px 10px;border:1px solid #dddddd;background-color:#f6f8fa;font-size:14px;text-align:left;",
+	"td":         "padding:6px 10px;border:1px solid #dddddd;font-size:14px;",
+	"sup":        "font-size:11px;line-height:0;",
+}
+
+// exportEmailHTML renders a post as a self-contained, table-laid-out HTML email
+// with inline styles, absolute image URLs, and footnotes as a plain numbered list
+func exportEmailHTML(ctx context.Context, content, basePath, baseURL, canonical, backlink string) (string, error) {
+	body := postBody(content)
+	// Embeds don't play in email, so link a thumbnail instead
+	body = youtubeShortcodeRegex.ReplaceAllString(body,
+		"[![Watch on YouTube](https://img.youtube.com/vi/$1/hqdefault.jpg)](https://www.youtube.com/watch?v=$1)")
+	rendered, err := markdownToHTML(expandShortcodesMarkdown(body))
+	if err != nil {
+		return "", err
+	}
+
+	rendered = footnoteRefRegex.ReplaceAllString(rendered, "<sup>[$1]</sup>")
+	rendered = footnoteBackRegex.ReplaceAllString(rendered, "")
+	rendered = footnoteBlockRegex.ReplaceAllString(rendered, `<div><hr><p><strong>Notes</strong></p>`)
+	rendered = footnoteItemRegex.ReplaceAllString(rendered, "<li>")
+
+	title := frontMatterString(content, "title")
+	description := frontMatterString(content, "description")
+	var header strings.Builder
+	fmt.Fprintf(&header, "<h1>%s</h1>\n", html.EscapeString(title))
+	if description != "" {
+		fmt.Fprintf(&header, "<p><em>%s</em></p>\n", html.EscapeString(description))
+	}
+	if hero := frontMatterString(content, "hero"); hero != "" {
+		fmt.Fprintf(&header, `<p><img src="%s" alt="%s" width="600"></p>`+"\n", html.EscapeString(hero), html.EscapeString(title))
+	}
+	footer := ""
+	if canonical != "" {
+		footer = fmt.Sprintf(`<hr><p>Read this post on the web: <a href="%s">%s</a></p>`, html.EscapeString(backlink), html.EscapeString(canonical))
+	}
+
+	inner, err := hostEmailImages(ctx, basePath, baseURL, header.String()+rendered+footer)
+	if err != nil {
+		return "", err
+	}
+	inner = inlineEmailStyles(inner)
+
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>%s</title>
+</head>
+<body style="margin:0;padding:0;background-color:#f4f4f4;">
+<table role="presentation" width="100%%" cellpadding="0" cellspacing="0" border="0" style="background-color:#f4f4f4;">
+<tr><td align="center" style="padding:24px 12px;">
+<table role="presentation" width="600" cellpadding="0" cellspacing="0" border="0" style="width:100%%;max-width:600px;background-color:#ffffff;">
+<tr><td style="padding:32px 28px;font-family:-apple-system,'Segoe UI',Helvetica,Arial,sans-serif;">
+%s
+</td></tr>
+</table>
+</td></tr>
+</table>
+</body>
+</html>
+`, html.EscapeString(title), inner), nil
+}
+
+// inlineEmailStyles adds a style attribute to each element that has none
+func inlineEmailStyles(s string) string {
+	// Code inside a block shouldn't get the inline-code background too
+	s = emailPreCodeRegex.ReplaceAllString(s, fmt.Sprintf(`<pre style="%s"><code style="font-family:inherit;font-size:inherit;">`, emailStyles["pre"]))
+	return emailTagRegex.ReplaceAllStringFunc(s, func(tag string) string {
+		m := emailTagRegex.FindStringSubmatch(tag)
+		name, attrs, end := m[1], m[2], m[3]
+		if strings.Contains(attrs, "style=") {
+			return tag
+		}
+		return fmt.Sprintf(`<%s%s style="%s"%s>`, name, attrs, emailStyles[name], end)
+	})
+}
+
+// hostEmailImages gives every site image an absolute URL: uploaded to the
+// object store when --asset-bucket is set, otherwise on the published site
+func hostEmailImages(ctx context.Context, basePath, baseURL, s string) (string, error) {
+	store, err := newObjectStore()
+	if err != nil {
+		return "", err
+	}
+	if store == nil || basePath == "" {
+		return absolutizeSiteURLs(s, baseURL), nil
+	}
+
+	uploaded := make(map[string]string)
+	for _, match := range siteImageRefRegex.FindAllStringSubmatch(s, -1) {
+		name := match[1]
+		if _, ok := uploaded[name]; ok {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(siteImageDir(basePath), name))
+		if err != nil {
+			continue
+		}
+		publicURL, err := store.put(ctx, contentAddressedName(name, data), data, map[string]string{
+			"Cache-Control": "public, max-age=31536000, immutable",
+		})
+		if err != nil {
+			return "", err
+		}
+		uploaded[name] = publicURL
+		logSuccess("☁️  Uploaded %s → %s", name, publicURL)
+	}
+	for name, publicURL := range uploaded {
+		s = strings.ReplaceAll(s, "/images/site/"+name, publicURL)
+	}
+	return absolutizeSiteURLs(s, baseURL), nil
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const embeddingBatchSize = 100
+
+// embedTexts returns an embedding vector for each input text, batching requests
+func embedTexts(ctx context.Context, client *openai.Client, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(texts))
+
+	for start := 0; start < len(texts); start += embeddingBatchSize {
+		end := start + embeddingBatchSize
+		if end > len(texts) {
+			end = len(texts)
+		}
+
+		batch := make([]string, end-start)
+		for i, text := range texts[start:end] {
+			// Newlines hurt embedding quality; empty inputs are rejected
+			text = strings.Join(strings.Fields(text), " ")
+			if text == "" {
+				text = "untitled"
+			}
+			batch[i] = text
+		}
+
+		resp, err := client.CreateEmbeddings(ctx, openai.EmbeddingRequestStrings{
+			Input: batch,
+			Model: openai.SmallEmbedding3,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("embeddings API error: %w", err)
+		}
+		if len(resp.Data) != len(batch) {
+			return nil, fmt.Errorf("expected %d embeddings, got %d", len(batch), len(resp.Data))
+		}
+		for _, d := range resp.Data {
+			vectors = append(vectors, d.Embedding)
+		}
+	}
+
+	return vectors, nil
+}
+
+func cosineSimilarity(a, b []float32) float64 {
+	var dot, normA, normB float64
+	for i := range a {
+		if i >= len(b) {
+			break
+		}
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
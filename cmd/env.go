@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// envPrefix is prepended to a flag's upper-cased name to form its environment
+// variable, e.g. --site-source becomes MEGAFONE_SITE_SOURCE
+const envPrefix = "MEGAFONE_"
+
+// envVarForFlag returns the environment variable that configures a flag
+func envVarForFlag(name string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// applyEnvFlags sets every flag the user didn't pass on the command line from
+// its MEGAFONE_* environment variable, so containers can be configured with env alone
+func applyEnvFlags(cmd *cobra.Command) error {
+	var errs []string
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if f.Changed || f.Name == "help" {
+			return
+		}
+		value, ok := os.LookupEnv(envVarForFlag(f.Name))
+		if !ok {
+			return
+		}
+		if err := cmd.Flags().Set(f.Name, value); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", envVarForFlag(f.Name), err))
+		}
+	})
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid environment configuration: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// changedRootFlagEnv returns the global flags set for this run as MEGAFONE_*
+// variables, so child megafone processes inherit them unless their own
+// arguments say otherwise
+func changedRootFlagEnv() []string {
+	var env []string
+	rootCmd.PersistentFlags().VisitAll(func(f *pflag.Flag) {
+		if !f.Changed {
+			return
+		}
+		value := f.Value.String()
+		if slice, ok := f.Value.(pflag.SliceValue); ok {
+			value = strings.Join(slice.GetSlice(), ",")
+		}
+		env = append(env, envVarForFlag(f.Name)+"="+value)
+	})
+	return env
+}
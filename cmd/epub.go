@@ -0,0 +1,255 @@
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	gmhtml "github.com/yuin/goldmark/renderer/html"
+)
+
+// ebookChapter is one section of a compiled ebook, already rendered to XHTML
+type ebookChapter struct {
+	Title string
+	Body  string
+}
+
+// ebook is a collection of chapters plus the images they reference
+type ebook struct {
+	Title    string
+	Author   string
+	Language string
+	Chapters []ebookChapter
+	Images   map[string][]byte // file name under images/ → data
+}
+
+// markdownToXHTML renders markdown as XHTML for EPUB chapters, which must be well-formed XML
+func markdownToXHTML(markdown string) (string, error) {
+	md := goldmark.New(
+		goldmark.WithExtensions(extension.GFM),
+		goldmark.WithRendererOptions(gmhtml.WithXHTML()),
+	)
+	var buf bytes.Buffer
+	if err := md.Convert([]byte(markdown), &buf); err != nil {
+		return "", fmt.Errorf("failed to render markdown: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// writeEPUB packages the book as EPUB 3 with an EPUB 2 NCX for older readers
+func (b *ebook) writeEPUB(w io.Writer) error {
+	zw := zip.NewWriter(w)
+
+	// The mimetype entry must come first and be stored uncompressed
+	mt, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	io.WriteString(mt, "application/epub+zip")
+
+	files := map[string]string{
+		"META-INF/container.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles>
+</container>`,
+		"OEBPS/style.css": `body { font-family: serif; line-height: 1.5; }
+h1 { page-break-before: always; }
+pre { white-space: pre-wrap; font-size: 0.85em; background: #f5f5f5; padding: 0.5em; }
+img { max-width: 100%; }
+figcaption { font-size: 0.85em; font-style: italic; }`,
+		"OEBPS/content.opf": b.opf(),
+		"OEBPS/nav.xhtml":   b.nav(),
+		"OEBPS/toc.ncx":     b.ncx(),
+	}
+	for i, ch := range b.Chapters {
+		files[fmt.Sprintf("OEBPS/chapter%03d.xhtml", i+1)] = xhtmlPage(ch.Title, ch.Body)
+	}
+
+	for name, content := range files {
+		fw, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(fw, content); err != nil {
+			return err
+		}
+	}
+	for name, data := range b.Images {
+		fw, err := zw.Create("OEBPS/images/" + name)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func xhtmlPage(title, body string) string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">
+<head><title>%s</title><link rel="stylesheet" type="text/css" href="style.css"/></head>
+<body>
+<h1>%s</h1>
+%s
+</body>
+</html>`, escapeXML(title), escapeXML(title), body)
+}
+
+func (b *ebook) opf() string {
+	var manifest, spine strings.Builder
+	manifest.WriteString(`<item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+<item id="ncx" href="toc.ncx" media-type="application/x-dtbncx+xml"/>
+<item id="css" href="style.css" media-type="text/css"/>
+`)
+	for i := range b.Chapters {
+		id := fmt.Sprintf("chapter%03d", i+1)
+		fmt.Fprintf(&manifest, `<item id="%s" href="%s.xhtml" media-type="application/xhtml+xml"/>`+"\n", id, id)
+		fmt.Fprintf(&spine, `<itemref idref="%s"/>`+"\n", id)
+	}
+	i := 0
+	for name := range b.Images {
+		i++
+		mediaType := mime.TypeByExtension(strings.ToLower(filepath.Ext(name)))
+		if mediaType == "" {
+			mediaType = "image/jpeg"
+		}
+		fmt.Fprintf(&manifest, `<item id="img%d" href="images/%s" media-type="%s"/>`+"\n", i, escapeXML(name), mediaType)
+	}
+
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="bookid">
+<metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+<dc:identifier id="bookid">urn:megafone:%s</dc:identifier>
+<dc:title>%s</dc:title>
+<dc:creator>%s</dc:creator>
+<dc:language>%s</dc:language>
+<meta property="dcterms:modified">%s</meta>
+</metadata>
+<manifest>
+%s</manifest>
+<spine toc="ncx">
+%s</spine>
+</package>`, sanitizeFilename(b.Title), escapeXML(b.Title), escapeXML(b.Author), b.Language,
+		time.Now().UTC().Format("2006-01-02T15:04:05Z"), manifest.String(), spine.String())
+}
+
+func (b *ebook) nav() string {
+	var items strings.Builder
+	for i, ch := range b.Chapters {
+		fmt.Fprintf(&items, `<li><a href="chapter%03d.xhtml">%s</a></li>`+"\n", i+1, escapeXML(ch.Title))
+	}
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">
+<head><title>Contents</title></head>
+<body>
+<nav epub:type="toc" id="toc"><h1>Contents</h1>
+<ol>
+%s</ol>
+</nav>
+</body>
+</html>`, items.String())
+}
+
+func (b *ebook) ncx() string {
+	var points strings.Builder
+	for i, ch := range b.Chapters {
+		fmt.Fprintf(&points, `<navPoint id="np%d" playOrder="%d"><navLabel><text>%s</text></navLabel><content src="chapter%03d.xhtml"/></navPoint>`+"\n",
+			i+1, i+1, escapeXML(ch.Title), i+1)
+	}
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1">
+<head><meta name="dtb:uid" content="urn:megafone:%s"/></head>
+<docTitle><text>%s</text></docTitle>
+<navMap>
+%s</navMap>
+</ncx>`, sanitizeFilename(b.Title), escapeXML(b.Title), points.String())
+}
+
+// singleHTML renders the whole book as one HTML document for PDF conversion;
+// images are referenced from an images/ directory next to it
+func (b *ebook) singleHTML() string {
+	var body strings.Builder
+	body.WriteString(`<nav><h1>Contents</h1><ol>`)
+	for i, ch := range b.Chapters {
+		fmt.Fprintf(&body, `<li><a href="#ch%d">%s</a></li>`, i+1, escapeXML(ch.Title))
+	}
+	body.WriteString(`</ol></nav>`)
+	for i, ch := range b.Chapters {
+		fmt.Fprintf(&body, "<section id=\"ch%d\"><h1>%s</h1>\n%s</section>\n", i+1, escapeXML(ch.Title), ch.Body)
+	}
+
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html lang="%s"><head><meta charset="utf-8"><title>%s</title>
+<style>
+body { font: 11pt/1.5 Georgia, serif; max-width: 40em; margin: auto; }
+h1 { page-break-before: always; }
+.cover h1 { page-break-before: avoid; font-size: 2.5em; margin-top: 30%%; }
+pre { white-space: pre-wrap; font-size: 0.85em; background: #f5f5f5; padding: 0.5em; }
+img { max-width: 100%%; }
+</style></head>
+<body><div class="cover"><h1>%s</h1><p>%s</p></div>
+%s
+</body></html>`, b.Language, escapeXML(b.Title), escapeXML(b.Title), escapeXML(b.Author), body.String())
+}
+
+// writePDF prints the book with headless Chrome/Chromium, or wkhtmltopdf if
+// no browser is installed
+func (b *ebook) writePDF(outPath string) error {
+	dir, err := os.MkdirTemp("", "megafone-pdf-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	if err := os.MkdirAll(filepath.Join(dir, "images"), 0755); err != nil {
+		return err
+	}
+	for name, data := range b.Images {
+		if err := os.WriteFile(filepath.Join(dir, "images", name), data, 0644); err != nil {
+			return err
+		}
+	}
+	htmlPath := filepath.Join(dir, "book.html")
+	if err := os.WriteFile(htmlPath, []byte(b.singleHTML()), 0644); err != nil {
+		return err
+	}
+
+	absOut, err := filepath.Abs(outPath)
+	if err != nil {
+		return err
+	}
+
+	var cmd *exec.Cmd
+	for _, browser := range []string{"chromium", "chromium-browser", "google-chrome", "google-chrome-stable"} {
+		if path, err := exec.LookPath(browser); err == nil {
+			cmd = exec.Command(path, "--headless", "--disable-gpu", "--no-pdf-header-footer",
+				"--print-to-pdf="+absOut, "file://"+htmlPath)
+			break
+		}
+	}
+	if cmd == nil {
+		path, err := exec.LookPath("wkhtmltopdf")
+		if err != nil {
+			return fmt.Errorf("PDF output needs chromium, google-chrome, or wkhtmltopdf on PATH")
+		}
+		cmd = exec.Command(path, "--enable-local-file-access", "--quiet", htmlPath, absOut)
+	}
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s failed: %w\n%s", filepath.Base(cmd.Path), err, output)
+	}
+	return nil
+}
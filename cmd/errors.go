@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/sashabaranov/go-openai"
+)
+
+// Failure classes. Commands wrap errors with classify so scripts can branch on
+// the exit code (or the "class" field with --error-format json).
+var (
+	ErrAuth       = errors.New("authentication failed")
+	ErrRateLimit  = errors.New("rate limited")
+	ErrSource     = errors.New("source unavailable")
+	ErrSiteLayout = errors.New("invalid site layout")
+	ErrGeneration = errors.New("generation failed")
+	ErrImage      = errors.New("image handling failed")
+	ErrPolicy     = errors.New("blocked by host policy")
+	ErrSecrets    = errors.New("secrets in generated post")
+)
+
+// errorClasses maps each failure class to its exit code. Anything
+// unclassified exits 1.
+var errorClasses = []struct {
+	kind error
+	name string
+	code int
+}{
+	{ErrAuth, "auth", 3},
+	{ErrRateLimit, "rate_limit", 4},
+	{ErrSource, "source", 5},
+	{ErrSiteLayout, "site_layout", 6},
+	{ErrGeneration, "generation", 7},
+	{ErrImage, "image", 8},
+	{ErrPolicy, "policy", 9},
+	{ErrSecrets, "secrets", 10},
+}
+
+var errorFormat string
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&errorFormat, "error-format", "text", "How fatal errors are printed to stderr: text or json")
+}
+
+// classifiedError tags an error with its failure class while keeping its message
+type classifiedError struct {
+	kind error
+	err  error
+}
+
+func (e *classifiedError) Error() string   { return e.err.Error() }
+func (e *classifiedError) Unwrap() []error { return []error{e.err, e.kind} }
+
+// classify tags err with a failure class. A nil err stays nil.
+func classify(kind, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &classifiedError{kind: kind, err: err}
+}
+
+// errorClass finds err's failure class. Host policy errors, then provider auth
+// and rate-limit responses, win over the class a command assigned, since they
+// say more about the fix.
+func errorClass(err error) error {
+	if errors.Is(err, ErrPolicy) {
+		return ErrPolicy
+	}
+	status := 0
+	var apiErr *openai.APIError
+	var reqErr *openai.RequestError
+	var anthErr *anthropicStatusError
+	var ghErr *github.ErrorResponse
+	var ghRate *github.RateLimitError
+	var ghAbuse *github.AbuseRateLimitError
+	switch {
+	case errors.As(err, &ghRate), errors.As(err, &ghAbuse):
+		return ErrRateLimit
+	case errors.As(err, &apiErr):
+		status = apiErr.HTTPStatusCode
+	case errors.As(err, &reqErr):
+		status = reqErr.HTTPStatusCode
+	case errors.As(err, &anthErr):
+		status = anthErr.StatusCode
+	case errors.As(err, &ghErr) && ghErr.Response != nil:
+		status = ghErr.Response.StatusCode
+	}
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrAuth
+	case http.StatusTooManyRequests, 529:
+		return ErrRateLimit
+	}
+
+	for _, c := range errorClasses {
+		if errors.Is(err, c.kind) {
+			return c.kind
+		}
+	}
+	return nil
+}
+
+// exitWithError prints a command's fatal error in --error-format and exits with its class's code
+func exitWithError(err error) {
+	kind := errorClass(err)
+	name, code := "error", 1
+	for _, c := range errorClasses {
+		if c.kind == kind {
+			name, code = c.name, c.code
+		}
+	}
+
+	if errorFormat == "json" {
+		json.NewEncoder(os.Stderr).Encode(map[string]interface{}{
+			"error":     err.Error(),
+			"class":     name,
+			"exit_code": code,
+			"command":   journalCommand,
+			"run_id":    journalRunID,
+		})
+	} else {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	}
+	os.Exit(code)
+}
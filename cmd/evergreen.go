@@ -0,0 +1,236 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/spf13/cobra"
+)
+
+var (
+	evergreenOldURL  string
+	evergreenSources int
+	evergreenKeep    bool
+)
+
+var (
+	markdownLinkRegex  = regexp.MustCompile(`\]\((https?://[^)\s]+)\)`)
+	sourceCardURLRegex = regexp.MustCompile(`(?m)^source_card:.*\burl:\s*"([^"]+)"`)
+)
+
+var evergreenCmd = &cobra.Command{
+	Use:   "evergreen <post>",
+	Short: "Rewrite a dated news post into an evergreen explainer",
+	Long: `Rewrites a news-reaction post so it stays useful: time references are
+removed, facts are refreshed from a re-fetch of its sources, and it gets a new
+title and filename. The old URL keeps working through an alias in the new post's
+front matter, and the original file is removed.
+
+Examples:
+  megafone evergreen content/posts/en/go-1-22-released.md
+  megafone evergreen content/posts/en/go-1-22-released.md --old-url /2024/02/go-1-22/ --dry-run`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runEvergreen(cmd, args[0]); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(evergreenCmd)
+
+	evergreenCmd.Flags().StringVar(&evergreenOldURL, "old-url", "", "Published URL of the post to alias (default: /posts/<slug>/)")
+	evergreenCmd.Flags().IntVar(&evergreenSources, "sources", 3, "How many of the post's linked sources to re-fetch for updated facts")
+	evergreenCmd.Flags().BoolVar(&evergreenKeep, "keep-original", false, "Keep the original post file instead of removing it")
+	evergreenCmd.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "Print the rewritten post without writing files")
+	evergreenCmd.Flags().StringVarP(&model, "model", "m", "gpt-4o", "OpenAI model to use")
+}
+
+func runEvergreen(cmd *cobra.Command, postPath string) error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	ctx := context.Background()
+
+	apiKey, err := getOpenAIKey(cmd)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(postPath)
+	if err != nil {
+		return fmt.Errorf("failed to read post: %w", err)
+	}
+	original := string(data)
+
+	oldURL := evergreenOldURL
+	if oldURL == "" {
+		oldURL = defaultPostURL(postPath, original)
+	}
+
+	var refreshed strings.Builder
+	for _, src := range postSourceURLs(original, evergreenSources) {
+		logInfo("🔄 Re-fetching source: %s", src)
+		text, _, _, err := fetchWebsiteContent(src)
+		if err != nil {
+			logError("Skipping source %s: %v", src, err)
+			continue
+		}
+		if len(text) > 4000 {
+			text = text[:4000] + "\n[truncated]"
+		}
+		fmt.Fprintf(&refreshed, "\nSource: %s\n%s\n", src, text)
+	}
+
+	content, err := rewriteEvergreen(ctx, apiKey, original, refreshed.String(), model)
+	if err != nil {
+		return err
+	}
+
+	// Keep the old URL resolving to the new post
+	content = addAliases(content, "", append(frontMatterList(original, "aliases"), oldURL)...)
+	content = upsertFrontMatterField(content, "lastmod", time.Now().Format("2006-01-02"))
+	if hero := frontMatterString(original, "hero"); hero != "" && frontMatterString(content, "hero") == "" {
+		content = upsertFrontMatterField(content, "hero", hero)
+	}
+
+	content = applyHighlights(ctx, openai.NewClient(apiKey), content)
+	if content, err = applySummarySections(ctx, openai.NewClient(apiKey), content, "evergreen"); err != nil {
+		return err
+	}
+	content = applyAccessibility(content)
+	if content, err = applyDisclosure(content, findSiteRoot(filepath.Dir(postPath))); err != nil {
+		return err
+	}
+	if content, err = applyFrontMatterDefaults(content, "evergreen", oldURL); err != nil {
+		return err
+	}
+	if content, err = applySecretScan(content); err != nil {
+		return err
+	}
+
+	if dryRun {
+		logInfo("Dry run mode - not writing files")
+		fmt.Println("\n" + strings.Repeat("=", 80))
+		fmt.Println("DRY RUN - Generated Content:")
+		fmt.Println(strings.Repeat("=", 80))
+		fmt.Println(content)
+		fmt.Println(strings.Repeat("=", 80))
+		return nil
+	}
+
+	filename, err := generateFilename(ctx, openai.NewClient(apiKey), content, model)
+	if err != nil || filename == "" {
+		logError("Failed to generate filename, keeping the original: %v", err)
+		filename = strings.TrimSuffix(filepath.Base(postPath), ".md")
+	}
+	if content, filename, err = applySlugStrategy(content, filename, ""); err != nil {
+		return err
+	}
+	newPath := filepath.Join(filepath.Dir(postPath), filename+".md")
+
+	if err := os.WriteFile(newPath, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write post: %w", err)
+	}
+	logSuccess("✅ Evergreen post created: %s (alias %s)", newPath, oldURL)
+	if newPath == postPath {
+		recordSiteChange("modified", newPath, oldURL, true)
+	} else {
+		recordSiteChange("created", newPath, oldURL, true)
+	}
+
+	if !evergreenKeep && newPath != postPath {
+		if err := os.Remove(postPath); err != nil {
+			return fmt.Errorf("failed to remove original post: %w", err)
+		}
+		recordSiteChange("deleted", postPath, "", false)
+		logInfo("🗑️  Removed original: %s", postPath)
+	}
+	return runHooks("post_generate", appConfig.Hooks.PostGenerate, hookRun{Post: newPath, Source: oldURL, Site: findSiteRoot(filepath.Dir(newPath))})
+}
+
+// defaultPostURL guesses a post's published path from its url/slug front matter
+// or file name, matching Hugo's default /posts/<slug>/ permalinks
+func defaultPostURL(postPath, content string) string {
+	if u := frontMatterString(content, "url"); u != "" {
+		return u
+	}
+	slug := frontMatterString(content, "slug")
+	if slug == "" {
+		slug = strings.TrimSuffix(filepath.Base(postPath), filepath.Ext(postPath))
+	}
+	return "/posts/" + slug + "/"
+}
+
+// postSourceURLs returns up to limit external URLs a post is based on, starting
+// with its source card
+func postSourceURLs(content string, limit int) []string {
+	var urls []string
+	seen := make(map[string]bool)
+	add := func(u string) {
+		if !seen[u] && len(urls) < limit && !isImageFile(u) {
+			seen[u] = true
+			urls = append(urls, u)
+		}
+	}
+
+	if m := sourceCardURLRegex.FindStringSubmatch(frontMatterBlock(content)); m != nil {
+		add(m[1])
+	}
+	for _, m := range markdownLinkRegex.FindAllStringSubmatch(postBody(content), -1) {
+		add(m[1])
+	}
+	return urls
+}
+
+func rewriteEvergreen(ctx context.Context, apiKey, original, refreshed, model string) (string, error) {
+	client := openai.NewClient(apiKey)
+
+	userPrompt := fmt.Sprintf(`Rewrite this news-reaction blog post into an evergreen explainer that will still be accurate and useful in two years.
+
+Rules:
+- Remove time-relative language ("today", "this week", "just announced", "recently", "upcoming")
+- Reframe announcements as explanations of what the thing is, how it works, and when to use it
+- Update facts using the refreshed source material below; where sources disagree with the post, trust the sources
+- Write a new timeless title and description (no version-launch framing)
+- Keep the author's voice, code examples, images, and shortcodes
+- Keep the date field from the original front matter unchanged
+- Keep the remaining front matter fields, updating title, description, and tags as needed
+
+Original post:
+%s
+
+Refreshed source material:
+%s
+
+IMPORTANT: Your response must be ONLY valid markdown with front matter. Do not include any explanatory text before or after the markdown.`, original, firstNonEmpty(refreshed, "(no sources could be re-fetched)"))
+
+	resp, err := createChatCompletion(withStreaming(ctx), client, openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: "You are a technical editor who turns dated news posts into durable explainers. Output ONLY the markdown content, no explanations.",
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: userPrompt,
+			},
+		},
+		Temperature: 0.5,
+	})
+	if err != nil {
+		return "", fmt.Errorf("OpenAI API error: %w", err)
+	}
+	if len(resp.Choices) == 0 || resp.Choices[0].Message.Content == "" {
+		return "", fmt.Errorf("no response from OpenAI")
+	}
+	return resp.Choices[0].Message.Content, nil
+}
@@ -0,0 +1,348 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"regexp"
+	"time"
+)
+
+var (
+	stripImageMetadata bool
+	imageCopyright     string
+
+	// imageStamp is the comment embedded in images written during this run
+	imageStamp string
+)
+
+// imageStampPrefix starts every copyright comment megafone embeds
+const imageStampPrefix = "© "
+
+// pngStampHeader precedes the comment in the iTXt chunk stampImage writes:
+// keyword, NUL, compression flag, compression method, empty language, empty
+// translated keyword
+const pngStampHeader = "Copyright\x00\x00\x00\x00\x00"
+
+// imageStampYearRegex matches the year that opens a copyright stamp
+var imageStampYearRegex = regexp.MustCompile(`^` + imageStampPrefix + `\d+ `)
+
+// buildImageStamp composes the copyright comment for images from a source
+func buildImageStamp(holder, sourceURL string) string {
+	if holder == "" {
+		return ""
+	}
+	stamp := fmt.Sprintf("%s%d %s", imageStampPrefix, time.Now().Year(), holder)
+	if sourceURL != "" {
+		stamp += ". Source: " + sourceURL
+	}
+	return stamp
+}
+
+// sanitizeImage removes EXIF, XMP, IPTC, and text metadata (including GPS
+// location) from JPEG, PNG, and WebP data. A JPEG's EXIF orientation is kept
+// so rotated phone photos still display upright. Pixel data is never
+// re-encoded. Unknown formats are returned unchanged.
+func sanitizeImage(data []byte) []byte {
+	var cleaned []byte
+	var err error
+
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8}):
+		orientation := 0
+		cleaned, err = filterJPEG(data, "", func(marker byte, payload []byte) bool {
+			if marker == 0xE1 && orientation == 0 {
+				orientation = exifOrientation(payload)
+			}
+			// APP1 (EXIF/XMP), APP13 (IPTC), and COM; JFIF, ICC profiles, and
+			// Adobe markers stay so colors render the same
+			return marker == 0xE1 || marker == 0xED || marker == 0xFE
+		})
+		if err == nil && orientation > 1 {
+			cleaned = insertJPEGSegment(cleaned, 0xE1, orientationEXIF(orientation))
+		}
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		cleaned, err = filterPNG(data, "", func(chunkType string, payload []byte) bool {
+			switch chunkType {
+			case "eXIf", "tEXt", "zTXt", "iTXt", "tIME":
+				return true
+			}
+			return false
+		})
+	case len(data) > 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		cleaned, err = sanitizeWebP(data)
+	default:
+		return data
+	}
+
+	if err != nil {
+		logError("Failed to strip image metadata, keeping original: %v", err)
+		return data
+	}
+	logInfo("🧽 Stripped image metadata (%d → %d bytes)", len(data), len(cleaned))
+	return cleaned
+}
+
+// stampImage embeds comment as a JPEG COM segment or a PNG iTXt Copyright
+// chunk, replacing an earlier Copyright chunk and leaving other metadata alone.
+// Other formats are returned unchanged.
+func stampImage(data []byte, comment string) []byte {
+	if comment == "" {
+		return data
+	}
+	var stamped []byte
+	var err error
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8}):
+		stamped, err = filterJPEG(data, comment, func(byte, []byte) bool { return false })
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		stamped, err = filterPNG(data, comment, isPNGCopyright)
+	default:
+		return data
+	}
+	if err != nil {
+		logError("Failed to embed the image copyright, keeping the image as is: %v", err)
+		return data
+	}
+	return stamped
+}
+
+// withoutImageStamp returns data without the comment stampImage embedded, so
+// images are compared regardless of the stamp's year
+func withoutImageStamp(data []byte) []byte {
+	var plain []byte
+	var err error
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8}):
+		plain, err = filterJPEG(data, "", func(marker byte, payload []byte) bool {
+			return marker == 0xFE && bytes.HasPrefix(payload, []byte(imageStampPrefix))
+		})
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		plain, err = filterPNG(data, "", isPNGCopyright)
+	default:
+		return data
+	}
+	if err != nil {
+		return data
+	}
+	return plain
+}
+
+// imageCredit returns the copyright stamp embedded in data without its year:
+// two copies of an image are only interchangeable when their credits match
+func imageCredit(data []byte) string {
+	stamp := ""
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8}):
+		filterJPEG(data, "", func(marker byte, payload []byte) bool {
+			if marker == 0xFE && stamp == "" && bytes.HasPrefix(payload, []byte(imageStampPrefix)) {
+				stamp = string(payload)
+			}
+			return false
+		})
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		filterPNG(data, "", func(chunkType string, payload []byte) bool {
+			if stamp == "" && isPNGCopyright(chunkType, payload) {
+				stamp = string(bytes.TrimPrefix(payload, []byte(pngStampHeader)))
+			}
+			return false
+		})
+	}
+	return imageStampYearRegex.ReplaceAllString(stamp, imageStampPrefix)
+}
+
+// filterJPEG drops the segments before the image data that drop matches, given
+// each segment's marker and payload, and writes comment as a COM segment first
+// when non-empty
+func filterJPEG(data []byte, comment string, drop func(marker byte, payload []byte) bool) ([]byte, error) {
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:2]) // SOI
+
+	if comment != "" {
+		seg := []byte(comment)
+		if len(seg) > 65533 {
+			seg = seg[:65533]
+		}
+		out.Write([]byte{0xFF, 0xFE})
+		binary.Write(out, binary.BigEndian, uint16(len(seg)+2))
+		out.Write(seg)
+	}
+
+	pos := 2
+	for pos < len(data) {
+		if data[pos] != 0xFF || pos+1 >= len(data) {
+			return nil, fmt.Errorf("malformed JPEG marker at offset %d", pos)
+		}
+		marker := data[pos+1]
+
+		// Start of scan: the rest is entropy-coded image data
+		if marker == 0xDA {
+			out.Write(data[pos:])
+			return out.Bytes(), nil
+		}
+		// Standalone markers carry no length
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) || marker == 0xFF {
+			out.Write(data[pos : pos+2])
+			pos += 2
+			continue
+		}
+		if pos+4 > len(data) {
+			return nil, fmt.Errorf("truncated JPEG segment")
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		end := pos + 2 + length
+		if end > len(data) {
+			return nil, fmt.Errorf("truncated JPEG segment")
+		}
+
+		if !drop(marker, data[pos+4:end]) {
+			out.Write(data[pos:end])
+		}
+		pos = end
+	}
+	return out.Bytes(), nil
+}
+
+// isPNGCopyright matches the iTXt chunk stampImage writes
+func isPNGCopyright(chunkType string, payload []byte) bool {
+	return chunkType == "iTXt" && bytes.HasPrefix(payload, []byte("Copyright\x00"))
+}
+
+// filterPNG drops the chunks drop matches and, when comment is non-empty, adds
+// it as an iTXt Copyright chunk
+func filterPNG(data []byte, comment string, drop func(chunkType string, payload []byte) bool) ([]byte, error) {
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:8])
+
+	pos := 8
+	for pos+12 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos : pos+4]))
+		chunkType := string(data[pos+4 : pos+8])
+		end := pos + 12 + length
+		if end > len(data) {
+			return nil, fmt.Errorf("truncated PNG chunk %q", chunkType)
+		}
+
+		if !drop(chunkType, data[pos+8:end-4]) {
+			out.Write(data[pos:end])
+		}
+
+		// Text chunks must come after IHDR; iTXt because the comment is UTF-8
+		if chunkType == "IHDR" && comment != "" {
+			writePNGChunk(out, "iTXt", append([]byte(pngStampHeader), []byte(comment)...))
+		}
+		pos = end
+	}
+	return out.Bytes(), nil
+}
+
+func writePNGChunk(out *bytes.Buffer, chunkType string, payload []byte) {
+	binary.Write(out, binary.BigEndian, uint32(len(payload)))
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(chunkType))
+	crc.Write(payload)
+	out.WriteString(chunkType)
+	out.Write(payload)
+	binary.Write(out, binary.BigEndian, crc.Sum32())
+}
+
+// sanitizeWebP drops EXIF and XMP chunks and clears their VP8X feature flags
+func sanitizeWebP(data []byte) ([]byte, error) {
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:12])
+
+	pos := 12
+	for pos+8 <= len(data) {
+		chunkType := string(data[pos : pos+4])
+		length := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		end := pos + 8 + length + length%2 // chunks are padded to even sizes
+		if end > len(data) {
+			return nil, fmt.Errorf("truncated WebP chunk %q", chunkType)
+		}
+
+		switch chunkType {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			if length < 1 {
+				return nil, fmt.Errorf("malformed WebP VP8X chunk")
+			}
+			chunk := append([]byte(nil), data[pos:end]...)
+			chunk[8] &^= 0x08 | 0x04 // EXIF and XMP present flags
+			out.Write(chunk)
+		default:
+			out.Write(data[pos:end])
+		}
+		pos = end
+	}
+
+	result := out.Bytes()
+	binary.LittleEndian.PutUint32(result[4:8], uint32(len(result)-8))
+	return result, nil
+}
+
+// exifOrientation returns the Orientation tag (1-8) of an APP1 EXIF payload,
+// or 0 if it has none
+func exifOrientation(payload []byte) int {
+	tiff, ok := bytes.CutPrefix(payload, []byte("Exif\x00\x00"))
+	if !ok || len(tiff) < 8 {
+		return 0
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 0
+	}
+	count := int(order.Uint16(tiff[ifd : ifd+2]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		// Orientation is a single SHORT stored in the value field
+		if order.Uint16(tiff[entry:entry+2]) == 0x0112 && order.Uint16(tiff[entry+2:entry+4]) == 3 {
+			if o := int(order.Uint16(tiff[entry+8 : entry+10])); o >= 1 && o <= 8 {
+				return o
+			}
+			return 0
+		}
+	}
+	return 0
+}
+
+// orientationEXIF builds an APP1 EXIF payload holding only the Orientation tag
+func orientationEXIF(orientation int) []byte {
+	out := bytes.NewBufferString("Exif\x00\x00MM\x00\x2A")
+	binary.Write(out, binary.BigEndian, uint32(8)) // IFD0 offset
+	binary.Write(out, binary.BigEndian, uint16(1)) // one entry
+	binary.Write(out, binary.BigEndian, uint16(0x0112))
+	binary.Write(out, binary.BigEndian, uint16(3)) // SHORT
+	binary.Write(out, binary.BigEndian, uint32(1)) // count
+	binary.Write(out, binary.BigEndian, uint16(orientation))
+	binary.Write(out, binary.BigEndian, uint16(0)) // value field padding
+	binary.Write(out, binary.BigEndian, uint32(0)) // no next IFD
+	return out.Bytes()
+}
+
+// insertJPEGSegment adds a segment after SOI and the JFIF APP0 segment, if
+// present, where readers expect EXIF
+func insertJPEGSegment(data []byte, marker byte, payload []byte) []byte {
+	at := 2
+	if len(data) >= 6 && data[2] == 0xFF && data[3] == 0xE0 {
+		if end := 4 + int(binary.BigEndian.Uint16(data[4:6])); end <= len(data) {
+			at = end
+		}
+	}
+	seg := []byte{0xFF, marker}
+	seg = binary.BigEndian.AppendUint16(seg, uint16(len(payload)+2))
+	seg = append(seg, payload...)
+	return append(append(append([]byte{}, data[:at]...), seg...), data[at:]...)
+}
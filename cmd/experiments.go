@@ -0,0 +1,698 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/spf13/cobra"
+)
+
+const (
+	experimentPlatforms = "platforms"
+	experimentRotate    = "rotate"
+
+	experimentRunning = "running"
+	experimentDone    = "done"
+	experimentStopped = "stopped"
+)
+
+var (
+	experimentMode        string
+	experimentTitle       string
+	experimentDescription string
+	experimentPlatformsA  []string
+	experimentPlatformsB  []string
+	experimentDays        int
+	experimentsAll        bool
+)
+
+// experimentsConfig is the experiments block of megafone.yaml
+type experimentsConfig struct {
+	SearchConsole  string `yaml:"search_console"`  // property, e.g. sc-domain:example.com (default: the baseURL)
+	Days           int    `yaml:"days"`            // default length of each phase
+	MinImpressions int    `yaml:"min_impressions"` // per variant, for rotate experiments to count
+}
+
+var experimentsCmd = &cobra.Command{
+	Use:   "experiments",
+	Short: "Run A/B experiments on post titles and descriptions",
+}
+
+var experimentsStartCmd = &cobra.Command{
+	Use:   "start <post>",
+	Short: "Start an experiment with a second title and description for a post",
+	Long: `Starts an A/B experiment between a post's title and description (a) and a
+second variant (b), written by the model unless --title is given. Titles that
+won or lost earlier experiments on the site guide the model.
+
+Modes:
+  platforms  a goes out on --platforms-a and b on --platforms-b, through
+             'megafone schedule drip' and 'megafone export'; engagement from
+             'megafone metrics sync' picks the winner
+  rotate     a stays on the site for --days, then b for --days; click-through
+             rate from Search Console picks the winner (GOOGLE_APPLICATION_CREDENTIALS)
+
+'megafone experiments check' moves experiments along and, once one is decided,
+gives the post the winning title and description.
+
+Examples:
+  megafone experiments start content/posts/en/my-post.md
+  megafone experiments start content/posts/en/my-post.md --mode rotate --days 21
+  megafone experiments start content/posts/en/my-post.md --title "Raft, Explained With Failures" --platforms-b bluesky,devto`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runExperimentsStart(cmd, args[0]); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+var experimentsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Show experiments and their results",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runExperimentsList(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+var experimentsCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Switch variants and decide experiments whose time is up",
+	Long: `Moves each running experiment along: a rotate experiment puts b on the site
+once a's days are up, and each experiment is decided once both variants have
+data (Search Console reports with a delay of about 3 days). The post then gets
+the winning title and description. Run it from cron or an automation, e.g. daily.
+
+Examples:
+  megafone experiments check -s ~/hugo
+  megafone experiments check -s ~/hugo --dry-run`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runExperimentsCheck(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+var experimentsStopCmd = &cobra.Command{
+	Use:   "stop <id>",
+	Short: "End an experiment and give the post back its original title",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runExperimentsStop(args[0]); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(experimentsCmd)
+	experimentsCmd.AddCommand(experimentsStartCmd)
+	experimentsCmd.AddCommand(experimentsListCmd)
+	experimentsCmd.AddCommand(experimentsCheckCmd)
+	experimentsCmd.AddCommand(experimentsStopCmd)
+
+	for _, c := range []*cobra.Command{experimentsStartCmd, experimentsListCmd, experimentsCheckCmd, experimentsStopCmd} {
+		c.Flags().StringVarP(&siteSource, "site-source", "s", "", "Path to local Hugo site repository (default: found from the post path)")
+	}
+	experimentsStartCmd.Flags().StringVar(&experimentMode, "mode", experimentPlatforms, "How the variants are shown: platforms or rotate")
+	experimentsStartCmd.Flags().StringVar(&experimentTitle, "title", "", "Title of variant b (default: written by the model)")
+	experimentsStartCmd.Flags().StringVar(&experimentDescription, "description", "", "Description of variant b (default: written by the model, or the post's with --title)")
+	experimentsStartCmd.Flags().StringSliceVar(&experimentPlatformsA, "platforms-a", []string{platformMastodon}, "Platforms that get variant a (platforms mode)")
+	experimentsStartCmd.Flags().StringSliceVar(&experimentPlatformsB, "platforms-b", []string{platformBluesky}, "Platforms that get variant b (platforms mode)")
+	experimentsStartCmd.Flags().IntVar(&experimentDays, "days", 0, "Days each variant runs (default: experiments.days in config, or 14)")
+	experimentsStartCmd.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "Show the variants without starting the experiment")
+	experimentsListCmd.Flags().BoolVar(&experimentsAll, "all", false, "Include finished and stopped experiments")
+	experimentsCheckCmd.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "Show what would change without changing it")
+}
+
+type experimentStore struct {
+	Experiments []experiment `json:"experiments"`
+}
+
+// experiment is one A/B test of a post's title and description. Variants[0]
+// is the post as it was when the experiment started.
+type experiment struct {
+	ID        string               `json:"id"`
+	Post      string               `json:"post"` // relative to the site root
+	Mode      string               `json:"mode"`
+	Variants  [2]experimentVariant `json:"variants"`
+	Platforms map[string]string    `json:"platforms,omitempty"` // platforms mode: platform → variant
+	Days      int                  `json:"days"`
+	Started   time.Time            `json:"started"`
+	Switched  time.Time            `json:"switched,omitempty"` // rotate mode: when b went on the site
+	Status    string               `json:"status"`
+	Winner    string               `json:"winner,omitempty"`
+	Note      string               `json:"note,omitempty"`
+}
+
+type experimentVariant struct {
+	Name        string             `json:"name"`
+	Title       string             `json:"title"`
+	Description string             `json:"description,omitempty"`
+	Results     *experimentResults `json:"results,omitempty"`
+}
+
+// experimentResults is how a variant did: Search Console numbers in rotate
+// mode, engagement from the metrics history in platforms mode
+type experimentResults struct {
+	searchConsoleStats
+	Posts      int `json:"posts,omitempty"`
+	Engagement int `json:"engagement,omitempty"`
+}
+
+// describe summarizes the results in the terms of the experiment's mode
+func (r *experimentResults) describe(mode string) string {
+	switch {
+	case r == nil:
+		return "-"
+	case mode == experimentRotate:
+		return fmt.Sprintf("%.1f%% CTR (%d clicks / %d impressions)", r.CTR*100, r.Clicks, r.Impressions)
+	default:
+		return fmt.Sprintf("%d engagement on %d posts", r.Engagement, r.Posts)
+	}
+}
+
+func experimentsPath(basePath string) string {
+	return workspacePath(basePath, "state", "experiments.json")
+}
+
+func loadExperiments(basePath string) (*experimentStore, error) {
+	var s experimentStore
+	data, err := os.ReadFile(experimentsPath(basePath))
+	if os.IsNotExist(err) {
+		return &s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid experiments file %s: %w", experimentsPath(basePath), err)
+	}
+	return &s, nil
+}
+
+func saveExperiments(basePath string, s *experimentStore) error {
+	path := experimentsPath(basePath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// runningExperiment returns the post's running experiment, if it has one
+func (s *experimentStore) runningExperiment(rel string) *experiment {
+	for i := range s.Experiments {
+		if e := &s.Experiments[i]; e.Post == rel && e.Status == experimentRunning {
+			return e
+		}
+	}
+	return nil
+}
+
+// platformVariant returns the variant a platforms experiment shows on a platform
+func (e *experiment) platformVariant(platform string) (experimentVariant, bool) {
+	if e == nil || e.Mode != experimentPlatforms {
+		return experimentVariant{}, false
+	}
+	switch e.Platforms[platform] {
+	case "a":
+		return e.Variants[0], true
+	case "b":
+		return e.Variants[1], true
+	}
+	return experimentVariant{}, false
+}
+
+// withVariant gives a post a variant's title and description. Other
+// descriptions (social_blurb, summary) are left alone.
+func withVariant(content string, v experimentVariant) string {
+	content = upsertFrontMatterField(content, "title", yamlQuote(v.Title))
+	if v.Description == "" {
+		return removeFrontMatterField(content, "description")
+	}
+	return upsertFrontMatterField(content, "description", yamlQuote(v.Description))
+}
+
+// experimentForPost returns the running experiment of a post, for commands
+// that publish it elsewhere. Failures to read the experiments are logged.
+func experimentForPost(basePath, postPath string) *experiment {
+	if basePath == "" {
+		return nil
+	}
+	s, err := loadExperiments(basePath)
+	if err != nil {
+		logError("Failed to read experiments: %v", err)
+		return nil
+	}
+	absBase, _ := filepath.Abs(basePath)
+	absPost, _ := filepath.Abs(postPath)
+	return s.runningExperiment(filepath.ToSlash(mustRel(absBase, absPost)))
+}
+
+func runExperimentsStart(cmd *cobra.Command, postPath string) error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	if experimentMode != experimentPlatforms && experimentMode != experimentRotate {
+		return fmt.Errorf("invalid --mode value %q (use platforms or rotate)", experimentMode)
+	}
+	basePath, err := scheduleSitePath(postPath)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(postPath)
+	if err != nil {
+		return fmt.Errorf("failed to read post: %w", err)
+	}
+	content := string(data)
+
+	store, err := loadExperiments(basePath)
+	if err != nil {
+		return err
+	}
+	absBase, _ := filepath.Abs(basePath)
+	absPost, _ := filepath.Abs(postPath)
+	rel := filepath.ToSlash(mustRel(absBase, absPost))
+	if e := store.runningExperiment(rel); e != nil {
+		return fmt.Errorf("%s already has a running experiment, %s (stop it first)", rel, e.ID)
+	}
+
+	e := experiment{
+		ID:      strings.TrimSuffix(filepath.Base(rel), ".md") + "-" + time.Now().Format("20060102"),
+		Post:    rel,
+		Mode:    experimentMode,
+		Days:    experimentDays,
+		Started: time.Now().UTC(),
+		Status:  experimentRunning,
+	}
+	if e.Days <= 0 {
+		e.Days = appConfig.Experiments.Days
+	}
+	if e.Days <= 0 {
+		e.Days = 14
+	}
+	if e.Mode == experimentPlatforms {
+		e.Platforms = make(map[string]string)
+		for variant, platforms := range map[string][]string{"a": experimentPlatformsA, "b": experimentPlatformsB} {
+			for _, p := range platforms {
+				switch p {
+				case platformMastodon, platformBluesky, platformDevTo, platformMedium:
+				default:
+					return fmt.Errorf("unknown platform %q (use mastodon, bluesky, devto, or medium)", p)
+				}
+				if e.Platforms[p] != "" {
+					return fmt.Errorf("%s can't show both variants", p)
+				}
+				e.Platforms[p] = variant
+			}
+		}
+		if len(experimentPlatformsA) == 0 || len(experimentPlatformsB) == 0 {
+			return fmt.Errorf("each variant needs at least one platform")
+		}
+	} else if siteBaseURL(basePath) == "" && appConfig.Experiments.SearchConsole == "" {
+		return fmt.Errorf("the Hugo config has no baseURL, so the post's Search Console page can't be found")
+	}
+
+	a := experimentVariant{Name: "a", Title: frontMatterString(content, "title"), Description: frontMatterString(content, "description")}
+	if a.Title == "" {
+		return fmt.Errorf("%s has no title to test against", postPath)
+	}
+	b := experimentVariant{Name: "b", Title: experimentTitle, Description: firstNonEmpty(experimentDescription, a.Description)}
+	if b.Title == "" {
+		apiKey, err := getOpenAIKey(cmd)
+		if err != nil {
+			return err
+		}
+		logInfo("🧪 Writing a second title and description...")
+		b, err = writeExperimentVariant(context.Background(), openai.NewClient(apiKey), content, store.Experiments)
+		if err != nil {
+			return classify(ErrGeneration, err)
+		}
+		if experimentDescription != "" {
+			b.Description = experimentDescription
+		}
+	}
+	if b.Title == a.Title && b.Description == a.Description {
+		return fmt.Errorf("variant b is the same as the post's title and description")
+	}
+	e.Variants = [2]experimentVariant{a, b}
+
+	fmt.Printf("a: %s\n   %s\nb: %s\n   %s\n", a.Title, a.Description, b.Title, b.Description)
+	if dryRun {
+		logInfo("Dry run mode - not starting the experiment")
+		return nil
+	}
+	store.Experiments = append(store.Experiments, e)
+	if err := saveExperiments(basePath, store); err != nil {
+		return fmt.Errorf("failed to save experiments: %w", err)
+	}
+	if e.Mode == experimentPlatforms {
+		logSuccess("✅ Started %s: a on %s, b on %s", e.ID, strings.Join(experimentPlatformsA, ","), strings.Join(experimentPlatformsB, ","))
+	} else {
+		logSuccess("✅ Started %s: a stays on the site for %d days, then b for %d", e.ID, e.Days, e.Days)
+	}
+	return nil
+}
+
+// writeExperimentVariant writes a second title and description for a post,
+// shown what won and lost in the site's earlier experiments
+func writeExperimentVariant(ctx context.Context, client *openai.Client, content string, past []experiment) (experimentVariant, error) {
+	var history strings.Builder
+	for _, e := range past {
+		if e.Status != experimentDone || e.Winner == "" {
+			continue
+		}
+		winner, loser := e.Variants[0], e.Variants[1]
+		if e.Winner == "b" {
+			winner, loser = loser, winner
+		}
+		fmt.Fprintf(&history, "- won: %q, lost: %q\n", winner.Title, loser.Title)
+	}
+	pastTitles := ""
+	if history.Len() > 0 {
+		pastTitles = "\nTitles from earlier experiments on this site:\n" + history.String()
+	}
+
+	prompt := fmt.Sprintf(`Current title: %s
+Current description: %s
+
+Post:
+%s
+%s
+Write an alternative title and meta description for this post to A/B test against
+the current ones. Take a clearly different angle (e.g. the problem instead of the
+solution, or a concrete result instead of the topic), keep it accurate to the post,
+and avoid clickbait. The description must be under 160 characters.
+Respond with only a JSON object: {"title": "...", "description": "..."}`,
+		frontMatterString(content, "title"), frontMatterString(content, "description"), summarizeTokens(6000, postBody(content)), pastTitles)
+
+	resp, err := createChatCompletion(ctx, client, openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: "You write titles and meta descriptions for technical blog posts. You output only JSON.",
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: prompt,
+			},
+		},
+		Temperature: 0.8,
+	})
+	if err != nil {
+		return experimentVariant{}, err
+	}
+	if len(resp.Choices) == 0 {
+		return experimentVariant{}, fmt.Errorf("no response from model")
+	}
+	var reply struct {
+		Title       string `json:"title"`
+		Description string `json:"description"`
+	}
+	if err := decodeModelJSON(resp.Choices[0].Message.Content, &reply); err != nil {
+		return experimentVariant{}, fmt.Errorf("failed to parse variant: %w", err)
+	}
+	if strings.TrimSpace(reply.Title) == "" {
+		return experimentVariant{}, fmt.Errorf("the model returned no title")
+	}
+	return experimentVariant{Name: "b", Title: strings.TrimSpace(reply.Title), Description: trimToLength(strings.TrimSpace(reply.Description), 160)}, nil
+}
+
+func runExperimentsList() error {
+	basePath, err := scheduleSitePath("")
+	if err != nil {
+		return err
+	}
+	store, err := loadExperiments(basePath)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tMODE\tSTATUS\tSTARTED\tVARIANT\tTITLE\tRESULTS")
+	shown := 0
+	for _, e := range store.Experiments {
+		if !experimentsAll && e.Status != experimentRunning {
+			continue
+		}
+		shown++
+		status := e.Status
+		if e.Status == experimentDone && e.Winner != "" {
+			status = "done, " + e.Winner + " won"
+		} else if e.Status == experimentDone {
+			status = "done, inconclusive"
+		}
+		for i, v := range e.Variants {
+			if i == 0 {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", e.ID, e.Mode, status, e.Started.Format("2006-01-02"), v.Name, trimToLength(v.Title, 60), v.Results.describe(e.Mode))
+			} else {
+				fmt.Fprintf(w, "\t\t\t\t%s\t%s\t%s\n", v.Name, trimToLength(v.Title, 60), v.Results.describe(e.Mode))
+			}
+		}
+	}
+	if shown == 0 {
+		fmt.Println("No experiments running")
+		return nil
+	}
+	return w.Flush()
+}
+
+func runExperimentsCheck() error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	basePath, err := scheduleSitePath("")
+	if err != nil {
+		return err
+	}
+	store, err := loadExperiments(basePath)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+	changed := false
+	for i := range store.Experiments {
+		e := &store.Experiments[i]
+		if e.Status != experimentRunning {
+			continue
+		}
+		var moved bool
+		var err error
+		if e.Mode == experimentRotate {
+			moved, err = checkRotateExperiment(ctx, basePath, e, now)
+		} else {
+			moved, err = checkPlatformsExperiment(basePath, e, now)
+		}
+		if err != nil {
+			logError("Experiment %s: %v", e.ID, err)
+		}
+		changed = changed || moved
+	}
+
+	if !changed {
+		logInfo("Nothing to do")
+		return nil
+	}
+	if dryRun {
+		logInfo("Dry run mode - not saving")
+		return nil
+	}
+	if err := saveExperiments(basePath, store); err != nil {
+		return fmt.Errorf("failed to save experiments: %w", err)
+	}
+	return nil
+}
+
+// checkRotateExperiment puts b on the site once a's days are up, then reads
+// each variant's Search Console numbers once they're complete
+func checkRotateExperiment(ctx context.Context, basePath string, e *experiment, now time.Time) (bool, error) {
+	phase := time.Duration(e.Days) * 24 * time.Hour
+	if e.Switched.IsZero() {
+		if now.Before(e.Started.Add(phase)) {
+			return false, nil
+		}
+		logInfo("🔀 %s: putting variant b on the site", e.ID)
+		if err := applyExperimentVariant(basePath, e, e.Variants[1]); err != nil {
+			return false, err
+		}
+		e.Switched = now
+		return true, nil
+	}
+
+	a, b := &e.Variants[0], &e.Variants[1]
+	bEnd := e.Switched.Add(phase)
+	if (a.Results != nil || now.Before(e.Switched.Add(searchConsoleLag))) && (b.Results != nil || now.Before(bEnd.Add(searchConsoleLag))) {
+		return false, nil
+	}
+
+	path := filepath.Join(basePath, filepath.FromSlash(e.Post))
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read post: %w", err)
+	}
+	property := firstNonEmpty(appConfig.Experiments.SearchConsole, siteBaseURL(basePath)+"/")
+	page := siteBaseURL(basePath) + postURL(basePath, path, string(data))
+	token, err := searchConsoleToken(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	// A switch day shows both titles, so it counts for neither
+	day := 24 * time.Hour
+	if a.Results == nil {
+		stats, err := searchConsoleQuery(ctx, token, property, page, e.Started.Add(day), e.Switched.Add(-day))
+		if err != nil {
+			return false, err
+		}
+		a.Results = &experimentResults{searchConsoleStats: stats}
+		logInfo("📊 %s a: %s", e.ID, a.Results.describe(e.Mode))
+	}
+	if b.Results == nil && !now.Before(bEnd.Add(searchConsoleLag)) {
+		stats, err := searchConsoleQuery(ctx, token, property, page, e.Switched.Add(day), bEnd.Add(-day))
+		if err != nil {
+			return true, err
+		}
+		b.Results = &experimentResults{searchConsoleStats: stats}
+		logInfo("📊 %s b: %s", e.ID, b.Results.describe(e.Mode))
+	}
+	if b.Results == nil {
+		return true, nil
+	}
+
+	minImpressions := appConfig.Experiments.MinImpressions
+	if minImpressions <= 0 {
+		minImpressions = 100
+	}
+	switch {
+	case a.Results.Impressions < minImpressions || b.Results.Impressions < minImpressions:
+		e.Note = fmt.Sprintf("fewer than %d impressions for a variant", minImpressions)
+	case b.Results.CTR > a.Results.CTR:
+		e.Winner = "b"
+	default:
+		e.Winner = "a"
+	}
+	return true, finishExperiment(basePath, e)
+}
+
+// checkPlatformsExperiment compares the engagement each variant's platforms
+// got once the experiment's days are up
+func checkPlatformsExperiment(basePath string, e *experiment, now time.Time) (bool, error) {
+	if now.Before(e.Started.Add(time.Duration(e.Days) * 24 * time.Hour)) {
+		return false, nil
+	}
+	history, err := loadMetrics(basePath)
+	if err != nil {
+		return false, err
+	}
+	latest := make(map[string]metricsSnapshot)
+	for _, s := range history {
+		if s.Post != e.Post || e.Platforms[s.Platform] == "" {
+			continue
+		}
+		key := s.Platform + " " + s.URL
+		if s.Time.After(latest[key].Time) {
+			latest[key] = s
+		}
+	}
+
+	results := map[string]*experimentResults{"a": {}, "b": {}}
+	for _, s := range latest {
+		r := results[e.Platforms[s.Platform]]
+		r.Posts++
+		r.Engagement += s.engagement()
+	}
+	e.Variants[0].Results, e.Variants[1].Results = results["a"], results["b"]
+	a, b := results["a"], results["b"]
+	logInfo("📊 %s a: %s; b: %s", e.ID, a.describe(e.Mode), b.describe(e.Mode))
+
+	switch {
+	case a.Posts == 0 || b.Posts == 0:
+		e.Note = "no metrics for a variant's platforms (run megafone metrics sync)"
+	case float64(b.Engagement)/float64(b.Posts) > float64(a.Engagement)/float64(a.Posts):
+		e.Winner = "b"
+	default:
+		e.Winner = "a"
+	}
+	return true, finishExperiment(basePath, e)
+}
+
+// finishExperiment gives the post the winning variant, or back its original
+// title and description when there's no winner
+func finishExperiment(basePath string, e *experiment) error {
+	e.Status = experimentDone
+	winner := e.Variants[0]
+	if e.Winner == "b" {
+		winner = e.Variants[1]
+	}
+	if e.Winner == "" {
+		logInfo("🤷 %s is inconclusive: %s", e.ID, e.Note)
+	} else {
+		logSuccess("🏆 %s: variant %s won (%s)", e.ID, winner.Name, winner.Title)
+	}
+	return applyExperimentVariant(basePath, e, winner)
+}
+
+func applyExperimentVariant(basePath string, e *experiment, v experimentVariant) error {
+	path := filepath.Join(basePath, filepath.FromSlash(e.Post))
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read post: %w", err)
+	}
+	updated := withVariant(string(data), v)
+	if updated == string(data) || dryRun {
+		return nil
+	}
+	if err := os.WriteFile(path, []byte(updated), 0644); err != nil {
+		return fmt.Errorf("failed to write post: %w", err)
+	}
+	recordSiteChange("modified", path, "", false)
+	return nil
+}
+
+func runExperimentsStop(id string) error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	basePath, err := scheduleSitePath("")
+	if err != nil {
+		return err
+	}
+	store, err := loadExperiments(basePath)
+	if err != nil {
+		return err
+	}
+	for i := range store.Experiments {
+		e := &store.Experiments[i]
+		if e.ID != id {
+			continue
+		}
+		if e.Status != experimentRunning {
+			return fmt.Errorf("experiment %s is already %s", id, e.Status)
+		}
+		if err := applyExperimentVariant(basePath, e, e.Variants[0]); err != nil {
+			return err
+		}
+		e.Status = experimentStopped
+		if err := saveExperiments(basePath, store); err != nil {
+			return fmt.Errorf("failed to save experiments: %w", err)
+		}
+		logSuccess("✅ Stopped %s", id)
+		return nil
+	}
+	return fmt.Errorf("no experiment %q (see megafone experiments list --all)", id)
+}
@@ -0,0 +1,251 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportFormat   string
+	exportOutput   string
+	exportBaseURL  string
+	exportCampaign string
+)
+
+var (
+	blockEndRegex   = regexp.MustCompile(`(?i)</(p|h[1-6]|li|pre|blockquote|tr|figure)>|<br\s*/?>`)
+	blankLinesRegex = regexp.MustCompile(`\n{3,}`)
+	tagCharsRegex   = regexp.MustCompile(`[^a-z0-9]`)
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export <post>",
+	Short: "Export a post for another platform (dev.to, Medium, Word, email, plain text)",
+	Long: `Converts a post for guest posting or cross-publishing. Shortcodes are expanded
+to plain markdown and site images become absolute URLs on your published site
+(from the Hugo config's baseURL, or --base-url). The docx export embeds images.
+
+Formats:
+  devto       markdown with dev.to front matter (published: false, canonical_url, cover_image, max 4 tags)
+  medium      markdown with the title, subtitle, and hero inline, for Medium's importer and API
+  docx        Word document for publications that require .docx submissions
+  email-html  inline-styled HTML for pasting into newsletter tools without markdown support
+  plain       plain text
+
+For email-html, site images need absolute URLs: they point at the published
+site, or are uploaded to S3-compatible storage when --asset-bucket is set.
+During a platforms experiment ('megafone experiments'), devto and medium
+exports get the title and description of the variant assigned to them.
+
+Examples:
+  megafone export content/posts/en/my-post.md --format devto
+  megafone export content/posts/en/my-post.md --format docx -o ~/Desktop/submission.docx
+  megafone export content/posts/en/my-post.md --format email-html --asset-bucket newsletter-images`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runExport(args[0]); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+
+	exportCmd.Flags().StringVarP(&exportFormat, "format", "f", "devto", "Export format: devto, medium, docx, email-html, or plain")
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "Output file (default: <slug>.<ext> in the current directory)")
+	exportCmd.Flags().StringVar(&exportBaseURL, "base-url", "", "Published site URL for absolute links (default: baseURL from the Hugo config)")
+	exportCmd.Flags().StringVar(&exportCampaign, "utm-campaign", "", "utm_campaign for links back to the site when tracking is configured (default: the post's slug)")
+	exportCmd.Flags().StringVarP(&siteSource, "site-source", "s", "", "Path to local Hugo site repository (default: found from the post path)")
+	addAssetStoreFlags(exportCmd)
+}
+
+func runExport(postPath string) error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+
+	data, err := os.ReadFile(postPath)
+	if err != nil {
+		return fmt.Errorf("failed to read post: %w", err)
+	}
+	content := string(data)
+
+	basePath := siteSource
+	if basePath == "" {
+		basePath = findSiteRoot(filepath.Dir(postPath))
+	}
+	if v, ok := experimentForPost(basePath, postPath).platformVariant(exportFormat); ok {
+		logInfo("🧪 Using experiment variant %s: %s", v.Name, v.Title)
+		content = withVariant(content, v)
+	}
+	baseURL := exportBaseURL
+	if baseURL == "" && basePath != "" {
+		baseURL = siteBaseURL(basePath)
+	}
+	if baseURL == "" && exportFormat != "docx" && !(exportFormat == "email-html" && assetBucket != "") {
+		logInfo("⚠️  No baseURL found; /images/site/ links stay relative (set --base-url)")
+	}
+
+	slug := strings.TrimSuffix(filepath.Base(postPath), filepath.Ext(postPath))
+	canonical := ""
+	if baseURL != "" {
+		canonical = strings.TrimRight(baseURL, "/") + defaultPostURL(postPath, content)
+	}
+
+	// Links back to the site carry UTM parameters; canonical_url stays clean
+	ctx := context.Background()
+	campaign := firstNonEmpty(exportCampaign, slug)
+	backlink := trackLink(ctx, canonical, exportFormat, campaign, slug)
+	fm := frontMatterBlock(content)
+	content = fm + trackMarkdownLinks(ctx, content[len(fm):], baseURL, exportFormat, campaign, slug)
+
+	var out []byte
+	var ext string
+	switch exportFormat {
+	case "devto":
+		out, ext = []byte(exportDevTo(content, baseURL, canonical)), ".md"
+	case "medium":
+		out, ext = []byte(exportMedium(content, baseURL, canonical, backlink)), ".md"
+	case "plain":
+		text, err := exportPlain(content)
+		if err != nil {
+			return err
+		}
+		out, ext = []byte(text), ".txt"
+	case "docx":
+		var buf bytes.Buffer
+		body := expandShortcodesMarkdown(postBody(content))
+		if hero := frontMatterString(content, "hero"); hero != "" {
+			body = fmt.Sprintf("![](%s)\n\n", hero) + body
+		}
+		if err := writeDocx(&buf, frontMatterString(content, "title"), body, exportImageLoader(basePath, baseURL)); err != nil {
+			return fmt.Errorf("failed to build docx: %w", err)
+		}
+		out, ext = buf.Bytes(), ".docx"
+	case "email-html":
+		page, err := exportEmailHTML(ctx, content, basePath, baseURL, canonical, backlink)
+		if err != nil {
+			return err
+		}
+		out, ext = []byte(page), ".html"
+	default:
+		return fmt.Errorf("invalid --format value %q (use devto, medium, docx, email-html, or plain)", exportFormat)
+	}
+
+	outPath := exportOutput
+	if outPath == "" {
+		outPath = slug + "." + exportFormat + ext
+		if exportFormat == "docx" || exportFormat == "plain" {
+			outPath = slug + ext
+		} else if exportFormat == "email-html" {
+			outPath = slug + ".email" + ext
+		}
+	}
+	if err := os.WriteFile(outPath, out, 0644); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	logSuccess("✅ Exported %s as %s: %s", postPath, exportFormat, outPath)
+	return nil
+}
+
+// exportDevTo rewrites the front matter into the fields dev.to's editor understands
+func exportDevTo(content, baseURL, canonical string) string {
+	tags := devToTags(content)
+
+	var fm strings.Builder
+	fm.WriteString("---\n")
+	fmt.Fprintf(&fm, "title: %s\n", yamlQuote(frontMatterString(content, "title")))
+	fm.WriteString("published: false\n")
+	if d := frontMatterString(content, "description"); d != "" {
+		fmt.Fprintf(&fm, "description: %s\n", yamlQuote(d))
+	}
+	if len(tags) > 0 {
+		fmt.Fprintf(&fm, "tags: %s\n", strings.Join(tags, ", "))
+	}
+	if canonical != "" {
+		fmt.Fprintf(&fm, "canonical_url: %s\n", canonical)
+	}
+	if hero := frontMatterString(content, "hero"); hero != "" {
+		fmt.Fprintf(&fm, "cover_image: %s\n", absolutizeSiteURLs(hero, baseURL))
+	}
+	fm.WriteString("---\n\n")
+
+	return fm.String() + absolutizeSiteURLs(expandShortcodesMarkdown(postBody(content)), baseURL)
+}
+
+// devToTags are the post's tags as dev.to accepts them: lowercase
+// alphanumeric, four at most
+func devToTags(content string) []string {
+	var tags []string
+	for _, t := range frontMatterList(content, "tags") {
+		if t = tagCharsRegex.ReplaceAllString(strings.ToLower(t), ""); t != "" && len(tags) < 4 {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// exportMedium puts the title, subtitle, and hero inline since Medium has no front matter
+func exportMedium(content, baseURL, canonical, backlink string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", frontMatterString(content, "title"))
+	if d := frontMatterString(content, "description"); d != "" {
+		fmt.Fprintf(&b, "## %s\n\n", d)
+	}
+	if hero := frontMatterString(content, "hero"); hero != "" {
+		fmt.Fprintf(&b, "![](%s)\n\n", absolutizeSiteURLs(hero, baseURL))
+	}
+	b.WriteString(absolutizeSiteURLs(expandShortcodesMarkdown(postBody(content)), baseURL))
+	if canonical != "" {
+		fmt.Fprintf(&b, "\n\n---\n\n*Originally published at [%s](%s).*\n", canonical, backlink)
+	}
+	return b.String()
+}
+
+func exportPlain(content string) (string, error) {
+	rendered, err := markdownToHTML(expandShortcodesMarkdown(postBody(content)))
+	if err != nil {
+		return "", err
+	}
+	rendered = strings.ReplaceAll(rendered, "<li>", "- ")
+	rendered = blockEndRegex.ReplaceAllString(rendered, "\n\n")
+	text := strings.TrimSpace(blankLinesRegex.ReplaceAllString(htmlToText(rendered), "\n\n"))
+
+	title := frontMatterString(content, "title")
+	return title + "\n" + strings.Repeat("=", len([]rune(title))) + "\n\n" + text + "\n", nil
+}
+
+// exportImageLoader reads /images/site/ images from the local site and downloads
+// anything absolute
+func exportImageLoader(basePath, baseURL string) func(src string) ([]byte, error) {
+	return func(src string) ([]byte, error) {
+		if strings.HasPrefix(src, "/images/site/") && basePath != "" {
+			return os.ReadFile(filepath.Join(siteImageDir(basePath), strings.TrimPrefix(src, "/images/site/")))
+		}
+		if strings.HasPrefix(src, "/") && baseURL != "" {
+			src = strings.TrimRight(baseURL, "/") + src
+		}
+		if !strings.HasPrefix(src, "http://") && !strings.HasPrefix(src, "https://") {
+			return nil, fmt.Errorf("cannot resolve image %s", src)
+		}
+		resp, err := http.Get(src)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("HTTP error: %s", resp.Status)
+		}
+		return io.ReadAll(resp.Body)
+	}
+}
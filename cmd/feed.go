@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// feedItem is an entry from an RSS 2.0 or Atom feed
+type feedItem struct {
+	Title        string
+	Link         string
+	GUID         string
+	Published    string
+	Description  string
+	EnclosureURL string
+}
+
+// id returns a stable identifier for de-duplicating feed items
+func (f feedItem) id() string {
+	return firstNonEmpty(f.GUID, f.Link, f.Title)
+}
+
+// fetchFeed downloads and parses an RSS or Atom feed
+func fetchFeed(feedURL string) ([]feedItem, error) {
+	resp, err := http.Get(feedURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch feed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP error fetching feed: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read feed: %w", err)
+	}
+	return parseFeed(body)
+}
+
+func parseFeed(data []byte) ([]feedItem, error) {
+	var doc struct {
+		XMLName xml.Name
+		// RSS 2.0
+		Channel struct {
+			Items []struct {
+				Title       string `xml:"title"`
+				Link        string `xml:"link"`
+				GUID        string `xml:"guid"`
+				PubDate     string `xml:"pubDate"`
+				Description string `xml:"description"`
+				Enclosure   struct {
+					URL string `xml:"url,attr"`
+				} `xml:"enclosure"`
+			} `xml:"item"`
+		} `xml:"channel"`
+		// Atom
+		Entries []struct {
+			Title string `xml:"title"`
+			ID    string `xml:"id"`
+			Links []struct {
+				Href string `xml:"href,attr"`
+				Rel  string `xml:"rel,attr"`
+			} `xml:"link"`
+			Published string `xml:"published"`
+			Updated   string `xml:"updated"`
+			Summary   string `xml:"summary"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse feed: %w", err)
+	}
+
+	var items []feedItem
+	for _, it := range doc.Channel.Items {
+		items = append(items, feedItem{
+			Title:        strings.TrimSpace(it.Title),
+			Link:         strings.TrimSpace(it.Link),
+			GUID:         strings.TrimSpace(it.GUID),
+			Published:    strings.TrimSpace(it.PubDate),
+			Description:  strings.TrimSpace(it.Description),
+			EnclosureURL: strings.TrimSpace(it.Enclosure.URL),
+		})
+	}
+	for _, e := range doc.Entries {
+		item := feedItem{
+			Title:       strings.TrimSpace(e.Title),
+			GUID:        strings.TrimSpace(e.ID),
+			Published:   firstNonEmpty(e.Published, e.Updated),
+			Description: strings.TrimSpace(e.Summary),
+		}
+		for _, l := range e.Links {
+			if l.Rel == "" || l.Rel == "alternate" {
+				item.Link = l.Href
+				break
+			}
+			if l.Rel == "enclosure" {
+				item.EnclosureURL = l.Href
+			}
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
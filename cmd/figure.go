@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// imageAttribution holds the caption and credit that accompany a source image
+type imageAttribution struct {
+	Caption   string
+	Credit    string
+	CreditURL string
+	License   string
+}
+
+func (a imageAttribution) isEmpty() bool {
+	return a.Caption == "" && a.Credit == ""
+}
+
+// extractImageAttribution finds the caption and credit for an image on a web page.
+// It looks at the <figure> wrapping the image first, then falls back to the
+// alt text recorded in the page metadata, and finally credits the source site.
+func extractImageAttribution(html string, img pageImage, pageURL string) imageAttribution {
+	var attr imageAttribution
+
+	if figure := findFigureForImage(html, img.URL); figure != "" {
+		figcaptionRegex := regexp.MustCompile(`(?is)<figcaption[^>]*>(.*?)</figcaption>`)
+		if matches := figcaptionRegex.FindStringSubmatch(figure); len(matches) > 1 {
+			caption := matches[1]
+
+			// Pull out explicitly marked credit elements before flattening the caption
+			creditRegex := regexp.MustCompile(`(?is)<(span|small|cite|div|p)[^>]*class=["'][^"']*(credit|byline|attribution|copyright)[^"']*["'][^>]*>(.*?)</(span|small|cite|div|p)>`)
+			if creditMatches := creditRegex.FindStringSubmatch(caption); len(creditMatches) > 3 {
+				attr.Credit = cleanCaptionText(creditMatches[3])
+				caption = strings.Replace(caption, creditMatches[0], " ", 1)
+			}
+			attr.Caption = cleanCaptionText(caption)
+		}
+
+		if attr.Caption == "" {
+			attr.Caption = findImageAlt(figure)
+		}
+	}
+
+	// Fall back to the alt text from og:image:alt, twitter:image:alt, or JSON-LD
+	if attr.Caption == "" {
+		attr.Caption = img.Alt
+	}
+
+	// Split inline credits like "A protest in Paris. (Photo: Jane Doe/AP)"
+	if attr.Credit == "" && attr.Caption != "" {
+		attr.Caption, attr.Credit = splitInlineCredit(attr.Caption)
+	}
+
+	// Always attribute to the source page, even without an explicit photographer credit
+	if parsed, err := url.Parse(pageURL); err == nil && parsed.Host != "" {
+		if attr.Credit == "" {
+			attr.Credit = strings.TrimPrefix(parsed.Host, "www.")
+		}
+		attr.CreditURL = pageURL
+	}
+
+	return attr
+}
+
+// findFigureForImage returns the <figure> element containing the given image, if any
+func findFigureForImage(html, imageURL string) string {
+	needle := imageURL
+	if parsed, err := url.Parse(imageURL); err == nil && parsed.Path != "" {
+		needle = path.Base(parsed.Path)
+	}
+	if needle == "" || needle == "/" || needle == "." {
+		return ""
+	}
+
+	figureRegex := regexp.MustCompile(`(?is)<figure[^>]*>.*?</figure>`)
+	for _, figure := range figureRegex.FindAllString(html, -1) {
+		if strings.Contains(figure, needle) {
+			return figure
+		}
+	}
+	return ""
+}
+
+// findImageAlt returns the alt text of the first <img> in an HTML fragment
+func findImageAlt(fragment string) string {
+	altRegex := regexp.MustCompile(`(?is)<img[^>]*alt=["']([^"']*)["']`)
+	if matches := altRegex.FindStringSubmatch(fragment); len(matches) > 1 {
+		return cleanCaptionText(matches[1])
+	}
+	return ""
+}
+
+// findMarkdownImageAlt returns the alt text of the markdown or HTML image in a
+// README that references the given image URL
+func findMarkdownImageAlt(markdown, imageURL string) string {
+	base := path.Base(imageURL)
+	if parsed, err := url.Parse(imageURL); err == nil && parsed.Path != "" {
+		base = path.Base(parsed.Path)
+	}
+
+	markdownRegex := regexp.MustCompile(`!\[([^\]]*)\]\(([^)]+)\)`)
+	for _, matches := range markdownRegex.FindAllStringSubmatch(markdown, -1) {
+		if strings.Contains(matches[2], base) {
+			return strings.TrimSpace(matches[1])
+		}
+	}
+
+	imgRegex := regexp.MustCompile(`(?i)<img[^>]*>`)
+	for _, tag := range imgRegex.FindAllString(markdown, -1) {
+		if strings.Contains(tag, base) {
+			return findImageAlt(tag)
+		}
+	}
+	return ""
+}
+
+// splitInlineCredit separates a trailing "(Photo: X)" / "Credit: X" / "© X" from a caption
+func splitInlineCredit(caption string) (string, string) {
+	creditRegex := regexp.MustCompile(`(?i)\s*\(?\s*((?:photo|image|credit|photograph|illustration)s?\s*(?:by|:)\s*[^()]+|©\s*[^()]+)\)?\s*$`)
+	loc := creditRegex.FindStringSubmatchIndex(caption)
+	if loc == nil {
+		return caption, ""
+	}
+	credit := strings.TrimSpace(caption[loc[2]:loc[3]])
+	credit = regexp.MustCompile(`(?i)^(photo|image|credit|photograph|illustration)s?\s*(by|:)\s*`).ReplaceAllString(credit, "")
+	return strings.TrimSpace(caption[:loc[0]]), strings.TrimSpace(credit)
+}
+
+func cleanCaptionText(s string) string {
+	s = regexp.MustCompile(`<[^>]+>`).ReplaceAllString(s, " ")
+	s = strings.NewReplacer("&amp;", "&", "&quot;", `"`, "&#39;", "'", "&nbsp;", " ", "&copy;", "©").Replace(s)
+	s = regexp.MustCompile(`\s+`).ReplaceAllString(s, " ")
+	return strings.TrimSpace(s)
+}
+
+// heroFigureInstructions tells the model how to place the hero image in the body
+// so that its caption and credit are preserved
+func heroFigureInstructions(heroImage string, attr imageAttribution) string {
+	if heroImage == "" || attr.isEmpty() {
+		return ""
+	}
+
+	shortcode := fmt.Sprintf(`{{< figure src="/images/site/%s"`, heroImage)
+	if attr.Caption != "" {
+		shortcode += fmt.Sprintf(` caption=%s`, yamlQuote(attr.Caption))
+	}
+	if attr.Credit != "" {
+		shortcode += fmt.Sprintf(` attr=%s`, yamlQuote(attr.Credit))
+	}
+	if attr.CreditURL != "" {
+		shortcode += fmt.Sprintf(` attrlink=%s`, yamlQuote(attr.CreditURL))
+	}
+	shortcode += " >}}"
+
+	return fmt.Sprintf("\nIMPORTANT: If you include the hero image in the body, use this figure shortcode exactly so the caption and credit are kept:\n%s", shortcode)
+}
+
+// applyImageAttribution records the hero caption and credit in the front matter
+func applyImageAttribution(content string, attr imageAttribution) string {
+	if attr.Caption != "" {
+		content = upsertFrontMatterField(content, "hero_caption", yamlQuote(attr.Caption))
+	}
+	if attr.Credit != "" {
+		content = upsertFrontMatterField(content, "hero_credit", yamlQuote(attr.Credit))
+	}
+	if attr.CreditURL != "" {
+		content = upsertFrontMatterField(content, "hero_credit_url", yamlQuote(attr.CreditURL))
+	}
+	if attr.License != "" {
+		content = upsertFrontMatterField(content, "hero_license", yamlQuote(attr.License))
+	}
+	return content
+}
@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/spf13/cobra"
+)
+
+var (
+	followupPost        string
+	followupDiscussions []string
+)
+
+var followupCmd = &cobra.Command{
+	Use:   "followup",
+	Short: "Write a follow-up post answering the discussion of a published post",
+	Long: `Reads a published post and its discussion threads (Hacker News, Reddit, or a
+page of blog comments), picks out the strongest questions and criticisms, and
+writes a follow-up post that addresses them and links back to the original.
+
+Examples:
+  megafone followup --post https://myblog.com/posts/go-generics/ \
+    --discussion https://news.ycombinator.com/item?id=40000000 \
+    --discussion https://www.reddit.com/r/golang/comments/abc123/go_generics/ \
+    -s ~/hugo`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runFollowup(cmd); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(followupCmd)
+
+	followupCmd.Flags().StringVar(&followupPost, "post", "", "URL of the published post (required)")
+	followupCmd.Flags().StringArrayVar(&followupDiscussions, "discussion", nil, "Discussion thread URL (repeatable; HN, Reddit, or a comments page) (required)")
+	followupCmd.Flags().StringVarP(&tags, "tags", "T", "", "Comma-separated tags (AI will suggest if not provided)")
+	followupCmd.Flags().StringVarP(&promptFile, "prompt", "p", "prompts/technical-article.txt", "Path to prompt template file")
+	followupCmd.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "Print generated content without writing files")
+	followupCmd.Flags().StringVarP(&model, "model", "m", "gpt-4o", "OpenAI model to use")
+	followupCmd.Flags().StringVarP(&siteSource, "site-source", "s", "", "Path to local Hugo site repository")
+
+	followupCmd.MarkFlagRequired("post")
+	followupCmd.MarkFlagRequired("discussion")
+}
+
+func runFollowup(cmd *cobra.Command) error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	ctx := context.Background()
+
+	basePath, err := resolveSitePath()
+	if err != nil {
+		return err
+	}
+	apiKey, err := getOpenAIKey(cmd)
+	if err != nil {
+		return err
+	}
+
+	logInfo("📖 Fetching original post: %s", followupPost)
+	original, meta, _, err := fetchWebsiteContent(followupPost)
+	if err != nil {
+		return fmt.Errorf("failed to fetch original post: %w", err)
+	}
+	if len(original) > 6000 {
+		original = original[:6000] + "\n\n[Original post truncated]"
+	}
+
+	var comments []discussionComment
+	for _, d := range followupDiscussions {
+		logInfo("💬 Fetching discussion: %s", d)
+		found, err := fetchDiscussion(d)
+		if err != nil {
+			logError("Skipping discussion %s: %v", d, err)
+			continue
+		}
+		logInfo("Found %d comments", len(found))
+		comments = append(comments, found...)
+	}
+	if len(comments) == 0 {
+		return fmt.Errorf("no comments found in the given discussions")
+	}
+
+	promptTemplate, err := loadPrompt(promptFile, "followup", followupPost)
+	if err != nil {
+		return err
+	}
+
+	content, filename, err := generateFollowup(ctx, apiKey, promptTemplate, meta, original, formatDiscussion(comments, 15000), tags, model)
+	if err != nil {
+		return err
+	}
+	content = upsertFrontMatterField(content, "follow_up_to", yamlQuote(followupPost))
+
+	content = applyDatePolicy(content, postDateString(), basePath)
+	content = applyHighlights(ctx, openai.NewClient(apiKey), content)
+	if content, err = applySummarySections(ctx, openai.NewClient(apiKey), content, "followup"); err != nil {
+		return err
+	}
+	content = applyAccessibility(content)
+	if content, err = applyDisclosure(content, basePath); err != nil {
+		return err
+	}
+	if content, err = applyFrontMatterDefaults(content, "followup", followupPost); err != nil {
+		return err
+	}
+	if content, err = applySecretScan(content); err != nil {
+		return err
+	}
+	if content, filename, err = applySlugStrategy(content, filename, ""); err != nil {
+		return err
+	}
+
+	if dryRun {
+		logInfo("Dry run mode - not writing files")
+		fmt.Println("\n" + strings.Repeat("=", 80))
+		fmt.Println("DRY RUN - Generated Content:")
+		fmt.Println(strings.Repeat("=", 80))
+		fmt.Println(content)
+		fmt.Println(strings.Repeat("=", 80))
+		return nil
+	}
+
+	postPath, err := writeNewPost(basePath, filepath.Join(postsDir(basePath), fmt.Sprintf("%s.md", filename)), content)
+	if err != nil {
+		return fmt.Errorf("failed to write post: %w", err)
+	}
+	logSuccess("✅ Follow-up created: %s", postPath)
+
+	var tagList []string
+	if tags != "" {
+		tagList = strings.Split(tags, ",")
+	}
+	logGeneration(followupPost, postPath, "", tagList)
+	return runHooks("post_generate", appConfig.Hooks.PostGenerate, hookRun{Post: postPath, Source: followupPost, Site: basePath})
+}
+
+func generateFollowup(ctx context.Context, apiKey, promptTemplate string, meta pageMetadata, original, discussion, userTags, model string) (content, filename string, err error) {
+	client := openai.NewClient(apiKey)
+
+	userPrompt := fmt.Sprintf(`%s
+
+Please write a follow-up blog post to one of my published posts, responding to the discussion it received.
+
+Original post: %s
+URL: %s
+
+Original content:
+%s
+
+Discussion (most engaged comments first):
+%s
+
+Instructions:
+- Pick the 3-6 strongest questions, criticisms, and corrections; skip jokes, tangents, and repeats
+- Address each one directly and honestly, conceding points where commenters were right
+- Link back to the original post in the opening paragraph: [%s](%s)
+- Paraphrase commenters rather than quoting them by username
+- Title it as a follow-up, not a repeat of the original
+
+User-provided tags: %s (suggest appropriate tags if none provided)
+
+IMPORTANT: Your response must be ONLY valid markdown. Do not include any explanatory text before or after the markdown.
+IMPORTANT: Use date: %s in the front matter.
+
+Generate a complete Hugo markdown post following the style guide above.
+`, promptTemplate, meta.Title, followupPost, original, discussion, meta.Title, followupPost, userTags, postDateString())
+
+	resp, err := createChatCompletion(withStreaming(ctx), client, openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: "You are a technical blog writer who responds to reader feedback thoughtfully and without defensiveness. Follow the style guide precisely. Output ONLY the markdown content, no explanations.",
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: userPrompt,
+			},
+		},
+		Temperature: 0.7,
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("OpenAI API error: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", "", fmt.Errorf("no response from OpenAI")
+	}
+	content = resp.Choices[0].Message.Content
+
+	filename, err = generateFilename(ctx, client, content, model)
+	if err != nil || filename == "" {
+		logError("Failed to generate filename, using original title: %v", err)
+		filename = sanitizeFilename(meta.Title) + "-follow-up"
+	}
+	return content, filename, nil
+}
@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// upsertFrontMatterField sets a top-level front matter key, replacing the
+// existing lines for that key (including an indented block value) or appending
+// it before the closing delimiter
+func upsertFrontMatterField(content, key, value string) string {
+	line := fmt.Sprintf("%s: %s", key, value)
+
+	fm := frontMatterBlock(content)
+	lines := strings.Split(fm, "\n")
+	if start, end, ok := frontMatterFieldLines(lines, key); ok {
+		updated := append(append(append([]string{}, lines[:start]...), line), lines[end:]...)
+		return strings.Join(updated, "\n") + content[len(fm):]
+	}
+
+	// Insert before the closing --- of the front matter
+	if !strings.HasPrefix(strings.TrimLeft(content, "\n"), "---") {
+		return content
+	}
+	start := strings.Index(content, "---") + 3
+	end := strings.Index(content[start:], "\n---")
+	if end == -1 {
+		return content
+	}
+	end += start
+	return content[:end] + "\n" + line + content[end:]
+}
+
+// frontMatterBlock returns the leading portion of content up to and including
+// the closing front matter delimiter, or "" if there is no front matter
+func frontMatterBlock(content string) string {
+	if !strings.HasPrefix(strings.TrimLeft(content, "\n"), "---") {
+		return ""
+	}
+	start := strings.Index(content, "---") + 3
+	end := strings.Index(content[start:], "\n---")
+	if end == -1 {
+		return ""
+	}
+	return content[:start+end]
+}
+
+// yamlQuote renders a string as a double-quoted YAML scalar
+func yamlQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", " ")
+	return `"` + s + `"`
+}
+
+// frontMatterList reads a top-level list field, accepting both flow style
+// (tags: ["a", "b"]) and block style (tags:\n  - a\n  - b)
+func frontMatterList(content, key string) []string {
+	fm := frontMatterBlock(content)
+	if fm == "" {
+		return nil
+	}
+
+	lines := strings.Split(fm, "\n")
+	for i, line := range lines {
+		if !strings.HasPrefix(line, key+":") {
+			continue
+		}
+		value := strings.TrimSpace(strings.TrimPrefix(line, key+":"))
+
+		var items []string
+		if strings.HasPrefix(value, "[") {
+			value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+			for _, item := range strings.Split(value, ",") {
+				if item = unquoteYAML(item); item != "" {
+					items = append(items, item)
+				}
+			}
+			return items
+		}
+		if value != "" {
+			return []string{unquoteYAML(value)}
+		}
+
+		for _, next := range lines[i+1:] {
+			trimmed := strings.TrimSpace(next)
+			if !strings.HasPrefix(trimmed, "- ") {
+				break
+			}
+			if item := unquoteYAML(strings.TrimPrefix(trimmed, "- ")); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items
+	}
+	return nil
+}
+
+// yamlList renders strings as a flow-style YAML list of quoted scalars
+func yamlList(items []string) string {
+	quoted := make([]string, len(items))
+	for i, item := range items {
+		quoted[i] = yamlQuote(item)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+// setFrontMatterList writes a top-level list field in flow style, replacing
+// the field whether it was written in flow or block style
+func setFrontMatterList(content, key string, items []string) string {
+	fm := frontMatterBlock(content)
+	lines := strings.Split(fm, "\n")
+	for i, line := range lines {
+		if !strings.HasPrefix(line, key+":") {
+			continue
+		}
+		end := i + 1
+		if strings.TrimSpace(strings.TrimPrefix(line, key+":")) == "" {
+			for end < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[end]), "- ") {
+				end++
+			}
+		}
+		updated := append(append(append([]string{}, lines[:i]...), key+": "+yamlList(items)), lines[end:]...)
+		return strings.Join(updated, "\n") + content[len(fm):]
+	}
+	return upsertFrontMatterField(content, key, yamlList(items))
+}
+
+// removeFrontMatterField drops a top-level key, including the items of a
+// block-style list under it
+func removeFrontMatterField(content, key string) string {
+	fm := frontMatterBlock(content)
+	lines := strings.Split(fm, "\n")
+	for i, line := range lines {
+		if !strings.HasPrefix(line, key+":") {
+			continue
+		}
+		end := i + 1
+		for end < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[end]), "- ") && strings.TrimSpace(strings.TrimPrefix(line, key+":")) == "" {
+			end++
+		}
+		updated := append(append([]string{}, lines[:i]...), lines[end:]...)
+		return strings.Join(updated, "\n") + content[len(fm):]
+	}
+	return content
+}
+
+// hasFrontMatterField reports whether a top-level key is set, whatever the
+// style of its value
+func hasFrontMatterField(content, key string) bool {
+	for _, line := range strings.Split(frontMatterBlock(content), "\n") {
+		if strings.HasPrefix(line, key+":") {
+			return true
+		}
+	}
+	return false
+}
+
+// frontMatterString reads a top-level scalar field
+func frontMatterString(content, key string) string {
+	keyRegex := regexp.MustCompile(`(?m)^` + regexp.QuoteMeta(key) + `:[ \t]*(.*)$`)
+	if matches := keyRegex.FindStringSubmatch(frontMatterBlock(content)); len(matches) > 1 {
+		return unquoteYAML(matches[1])
+	}
+	return ""
+}
+
+func unquoteYAML(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '"' && s[len(s)-1] == '"' || s[0] == '\'' && s[len(s)-1] == '\'') {
+		s = s[1 : len(s)-1]
+		s = strings.ReplaceAll(s, `\"`, `"`)
+		s = strings.ReplaceAll(s, `\\`, `\`)
+	}
+	return s
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// frontMatterData is what front_matter templates in megafone.yaml can reference,
+// e.g. categories: ["{{ .SourceType }}"]
+type frontMatterData struct {
+	Title      string
+	Tags       []string
+	SourceType string
+	Source     string
+	Model      string
+	Date       string
+}
+
+// applyFrontMatterDefaults sets the front_matter fields from megafone.yaml on a
+// generated post, replacing any value the model wrote. String values are
+// text/templates; a field or list item that renders empty is left out.
+func applyFrontMatterDefaults(content, sourceType, source string) (string, error) {
+	fields := appConfig.FrontMatter
+	if fields.Kind == 0 {
+		return content, nil
+	}
+	if fields.Kind != yaml.MappingNode {
+		return content, fmt.Errorf("front_matter in %s must be a mapping of field names to values", configPath)
+	}
+
+	data := frontMatterData{
+		Title:      frontMatterString(content, "title"),
+		Tags:       frontMatterList(content, "tags"),
+		SourceType: sourceType,
+		Source:     source,
+		Model:      effectiveModel(),
+		Date:       postDateString(),
+	}
+	funcs := promptFuncs(filepath.Dir(configPath))
+
+	for i := 0; i+1 < len(fields.Content); i += 2 {
+		key := fields.Content[i].Value
+		value, err := renderFrontMatterValue(fields.Content[i+1], data, funcs)
+		if err != nil {
+			return content, fmt.Errorf("front_matter.%s: %w", key, err)
+		}
+		if value != "" {
+			content = upsertFrontMatterField(content, key, value)
+		}
+	}
+	return content, nil
+}
+
+// renderFrontMatterValue renders a config value as a single-line YAML value,
+// using flow style for lists and maps so it fits one front matter line
+func renderFrontMatterValue(node *yaml.Node, data frontMatterData, funcs template.FuncMap) (string, error) {
+	rendered, err := renderFrontMatterNode(node, data, funcs)
+	if err != nil || rendered == nil {
+		return "", err
+	}
+	if rendered.Kind == yaml.ScalarNode {
+		if rendered.Tag == "!!str" {
+			return yamlQuote(rendered.Value), nil
+		}
+		return rendered.Value, nil
+	}
+	out, err := yaml.Marshal(rendered)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// renderFrontMatterNode returns a copy of node with its strings executed as
+// templates, or nil if it renders empty
+func renderFrontMatterNode(node *yaml.Node, data frontMatterData, funcs template.FuncMap) (*yaml.Node, error) {
+	out := *node
+	out.HeadComment, out.LineComment, out.FootComment = "", "", ""
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Tag != "!!str" {
+			return &out, nil
+		}
+		tmpl, err := template.New("front_matter").Funcs(funcs).Parse(node.Value)
+		if err != nil {
+			return nil, err
+		}
+		var b bytes.Buffer
+		if err := tmpl.Execute(&b, data); err != nil {
+			return nil, err
+		}
+		out.Value = strings.TrimSpace(b.String())
+		if out.Value == "" {
+			return nil, nil
+		}
+		out.Style = yaml.DoubleQuotedStyle
+		return &out, nil
+	case yaml.SequenceNode, yaml.MappingNode:
+		out.Style = yaml.FlowStyle
+		out.Content = nil
+		step := 1
+		if node.Kind == yaml.MappingNode {
+			step = 2
+		}
+		for i := 0; i+step-1 < len(node.Content); i += step {
+			value, err := renderFrontMatterNode(node.Content[i+step-1], data, funcs)
+			if err != nil {
+				return nil, err
+			}
+			if value == nil {
+				continue
+			}
+			if step == 2 {
+				out.Content = append(out.Content, node.Content[i])
+			}
+			out.Content = append(out.Content, value)
+		}
+		if len(out.Content) == 0 {
+			return nil, nil
+		}
+		return &out, nil
+	}
+	return &out, nil
+}
@@ -0,0 +1,321 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/spf13/cobra"
+)
+
+var (
+	gapCompetitor string
+	gapLimit      int
+	gapThreshold  float64
+	gapJSON       bool
+	gapStubsDir   string
+)
+
+var gapClient = newPolicyClient(30 * time.Second)
+
+var gapCmd = &cobra.Command{
+	Use:   "gap",
+	Short: "Find topics a competitor covers that your site doesn't",
+	Long: `Crawls a competitor's sitemap, embeds their post topics and yours, and lists
+the competitor topics with no close match on your site.
+
+Examples:
+  megafone gap --competitor https://otherblog.com -s ~/hugo
+
+  # Queue the gaps as stubs for megafone generate --from-stub
+  megafone gap --competitor https://otherblog.com -s ~/hugo --stubs-dir content/posts/en/ideas`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runGap(cmd); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(gapCmd)
+
+	gapCmd.Flags().StringVarP(&gapCompetitor, "competitor", "c", "", "Competitor site URL (its sitemap.xml is crawled) (required)")
+	gapCmd.Flags().StringVarP(&siteSource, "site-source", "s", "", "Path to local Hugo site repository")
+	gapCmd.Flags().IntVar(&gapLimit, "limit", 300, "Maximum number of competitor URLs to analyze")
+	gapCmd.Flags().Float64Var(&gapThreshold, "threshold", 0.55, "Similarity below which a competitor topic counts as a gap (0-1)")
+	gapCmd.Flags().BoolVar(&gapJSON, "json", false, "Output gaps as JSON")
+	gapCmd.Flags().StringVar(&gapStubsDir, "stubs-dir", "", "Write a post stub per gap into this directory (relative to the site)")
+
+	gapCmd.MarkFlagRequired("competitor")
+}
+
+// coverageGap is a competitor topic with no close match among your posts
+type coverageGap struct {
+	Topic       string  `json:"topic"`
+	URL         string  `json:"url"`
+	ClosestPost string  `json:"closest_post,omitempty"`
+	Similarity  float64 `json:"similarity"`
+}
+
+func runGap(cmd *cobra.Command) error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	ctx := context.Background()
+
+	basePath, err := resolveSitePath()
+	if err != nil {
+		return err
+	}
+	apiKey, err := getOpenAIKey(cmd)
+	if err != nil {
+		return err
+	}
+
+	posts, err := loadSitePosts(basePath)
+	if err != nil {
+		return fmt.Errorf("failed to load site posts: %w", err)
+	}
+	if len(posts) == 0 {
+		return fmt.Errorf("no posts found under %s", filepath.Join(basePath, "content", "posts"))
+	}
+
+	logInfo("🗺️  Crawling sitemap for %s", gapCompetitor)
+	urls, err := crawlSitemap(gapCompetitor, gapLimit)
+	if err != nil {
+		return err
+	}
+	logInfo("Found %d competitor URLs, %d local posts", len(urls), len(posts))
+
+	var topics []string
+	var topicURLs []string
+	for _, u := range urls {
+		if topic := topicFromURL(u); topic != "" {
+			topics = append(topics, topic)
+			topicURLs = append(topicURLs, u)
+		}
+	}
+	if len(topics) == 0 {
+		return fmt.Errorf("no article-like URLs found in the competitor sitemap")
+	}
+
+	mine := make([]string, len(posts))
+	for i, p := range posts {
+		mine[i] = p.summaryText()
+	}
+
+	client := openai.NewClient(apiKey)
+	logInfo("🧮 Embedding %d competitor topics and %d posts...", len(topics), len(mine))
+	theirVectors, err := embedTexts(ctx, client, topics)
+	if err != nil {
+		return err
+	}
+	myVectors, err := embedTexts(ctx, client, mine)
+	if err != nil {
+		return err
+	}
+
+	var gaps []coverageGap
+	for i, tv := range theirVectors {
+		best, bestIdx := -1.0, -1
+		for j, mv := range myVectors {
+			if sim := cosineSimilarity(tv, mv); sim > best {
+				best, bestIdx = sim, j
+			}
+		}
+		if best >= gapThreshold {
+			continue
+		}
+		gap := coverageGap{Topic: topics[i], URL: topicURLs[i], Similarity: best}
+		if bestIdx >= 0 {
+			gap.ClosestPost = posts[bestIdx].Title
+		}
+		gaps = append(gaps, gap)
+	}
+
+	// Least-covered topics first
+	sort.Slice(gaps, func(i, j int) bool { return gaps[i].Similarity < gaps[j].Similarity })
+
+	if gapStubsDir != "" {
+		if err := writeGapStubs(basePath, gaps); err != nil {
+			return err
+		}
+	}
+
+	if gapJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(gaps)
+	}
+
+	if len(gaps) == 0 {
+		fmt.Println("✨ No coverage gaps found - you cover everything they do.")
+		return nil
+	}
+	fmt.Printf("\n%d topics %s covers that you don't:\n\n", len(gaps), gapCompetitor)
+	for _, g := range gaps {
+		fmt.Printf("  %.2f  %s\n        %s\n", g.Similarity, g.Topic, g.URL)
+		if g.ClosestPost != "" {
+			fmt.Printf("        closest of yours: %s\n", g.ClosestPost)
+		}
+	}
+	return nil
+}
+
+// crawlSitemap returns page URLs from a site's sitemap, following sitemap indexes
+func crawlSitemap(siteURL string, limit int) ([]string, error) {
+	if !strings.HasPrefix(siteURL, "http://") && !strings.HasPrefix(siteURL, "https://") {
+		siteURL = "https://" + siteURL
+	}
+
+	sitemapURL := siteURL
+	if !strings.HasSuffix(strings.ToLower(siteURL), ".xml") {
+		sitemapURL = strings.TrimRight(siteURL, "/") + "/sitemap.xml"
+	}
+
+	var urls []string
+	queue := []string{sitemapURL}
+	visited := make(map[string]bool)
+	for len(queue) > 0 && len(urls) < limit {
+		current := queue[0]
+		queue = queue[1:]
+		if visited[current] {
+			continue
+		}
+		visited[current] = true
+
+		// The page URLs become topics that are sent to a model
+		if err := checkLLMHost(current); err != nil {
+			return nil, err
+		}
+		resp, err := gapClient.Get(current)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch sitemap: %w", err)
+		}
+		if err := checkLLMHost(resp.Request.URL.String()); err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read sitemap: %w", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			logError("Sitemap %s returned %s", current, resp.Status)
+			continue
+		}
+
+		var doc struct {
+			Sitemaps []struct {
+				Loc string `xml:"loc"`
+			} `xml:"sitemap"`
+			URLs []struct {
+				Loc string `xml:"loc"`
+			} `xml:"url"`
+		}
+		if err := xml.Unmarshal(body, &doc); err != nil {
+			logError("Failed to parse sitemap %s: %v", current, err)
+			continue
+		}
+
+		for _, s := range doc.Sitemaps {
+			queue = append(queue, strings.TrimSpace(s.Loc))
+		}
+		for _, u := range doc.URLs {
+			if len(urls) >= limit {
+				break
+			}
+			loc := strings.TrimSpace(u.Loc)
+			if err := checkLLMHost(loc); err != nil {
+				logVerbose("Skipping %s: %v", loc, err)
+				continue
+			}
+			urls = append(urls, loc)
+		}
+	}
+
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("no URLs found in sitemap %s", sitemapURL)
+	}
+	return urls, nil
+}
+
+// topicFromURL turns an article slug into a readable topic; listing pages
+// (tags, categories, pagination) and the home page are skipped
+func topicFromURL(pageURL string) string {
+	parsed, err := url.Parse(pageURL)
+	if err != nil {
+		return ""
+	}
+	p := strings.Trim(parsed.Path, "/")
+	if p == "" {
+		return ""
+	}
+	for _, skip := range []string{"tags/", "tag/", "categories/", "category/", "page/", "author/"} {
+		if strings.Contains(p+"/", skip) {
+			return ""
+		}
+	}
+
+	slug := path.Base(p)
+	slug = strings.TrimSuffix(slug, path.Ext(slug))
+	words := strings.FieldsFunc(slug, func(r rune) bool { return r == '-' || r == '_' })
+
+	// Drop leading date parts like 2024-06-01-title
+	for len(words) > 0 && isAllDigits(words[0]) {
+		words = words[1:]
+	}
+	if len(words) < 2 {
+		return ""
+	}
+	return strings.Join(words, " ")
+}
+
+func isAllDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// writeGapStubs queues each gap as a stub for megafone generate --from-stub
+func writeGapStubs(basePath string, gaps []coverageGap) error {
+	dir := gapStubsDir
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(basePath, dir)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	written := 0
+	for _, g := range gaps {
+		stubPath := filepath.Join(dir, sanitizeFilename(g.Topic)+".md")
+		if _, err := os.Stat(stubPath); err == nil {
+			continue // already queued
+		}
+		stub := fmt.Sprintf("---\ntopic: %s\nsources: [%s]\ndraft: true\n---\n\nCoverage gap found against %s (closest existing post: %s).\n",
+			yamlQuote(g.Topic), yamlQuote(g.URL), gapCompetitor, g.ClosestPost)
+		if err := os.WriteFile(stubPath, []byte(stub), 0644); err != nil {
+			return err
+		}
+		recordSiteChange("created", stubPath, g.URL, false)
+		written++
+	}
+	logSuccess("✅ Queued %d gap stubs in %s", written, dir)
+	return nil
+}
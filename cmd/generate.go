@@ -2,16 +2,15 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
-	"time"
 
 	"github.com/google/go-github/v57/github"
 	"github.com/sashabaranov/go-openai"
@@ -26,6 +25,14 @@ var (
 	dryRun     bool
 	model      string
 	siteSource string
+
+	minImageWidth int
+
+	sourceType            string
+	generationTemperature float32
+	targetWords           int
+	heroImagePrompt       string
+	replacePrevious       bool
 )
 
 var generateCmd = &cobra.Command{
@@ -43,10 +50,13 @@ Examples:
 
   # Research a topic
   megafone generate -t "kubernetes security best practices" -s ~/hugo
-  megafone generate -t "how LLMs work" -s ~/hugo`,
+  megafone generate -t "how LLMs work" -s ~/hugo
+
+  # From a ChatGPT or Claude conversation you exported
+  megafone generate -t conversations.json --conversation "cache design" -s ~/hugo`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := runGenerate(cmd); err != nil {
-			log.Fatalf("Error: %v", err)
+			exitWithError(err)
 		}
 	},
 }
@@ -54,15 +64,54 @@ Examples:
 func init() {
 	rootCmd.AddCommand(generateCmd)
 
-	generateCmd.Flags().StringVarP(&topicURL, "topic", "t", "", "GitHub URL, website URL, or research topic string (required)")
+	generateCmd.Flags().StringVarP(&topicURL, "topic", "t", "", "GitHub URL, website URL, research topic string, or chat export file (required unless --from-stub)")
 	generateCmd.Flags().StringVarP(&imagePath, "image", "i", "", "Path to hero image")
 	generateCmd.Flags().StringVarP(&tags, "tags", "T", "", "Comma-separated tags (AI will suggest if not provided)")
 	generateCmd.Flags().StringVarP(&promptFile, "prompt", "p", "", "Path to prompt template file (auto-selected if not provided)")
 	generateCmd.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "Print generated content without writing files")
 	generateCmd.Flags().StringVarP(&model, "model", "m", "gpt-4o", "OpenAI model to use (gpt-4o, gpt-4o-mini, gpt-4-turbo, or gpt-5)")
 	generateCmd.Flags().StringVarP(&siteSource, "site-source", "s", "", "Path to local Hugo site repository (if not provided, will show git clone command)")
-
-	generateCmd.MarkFlagRequired("topic")
+	generateCmd.Flags().IntVar(&minImageWidth, "min-image-width", 600, "Reject auto-detected hero images narrower than this many pixels")
+	generateCmd.Flags().BoolVar(&stripImageMetadata, "strip-metadata", true, "Strip EXIF/XMP/location metadata from downloaded and provided images")
+	generateCmd.Flags().StringVar(&imageCopyright, "image-copyright", "", "Copyright holder to embed in image metadata (e.g. your site name)")
+	generateCmd.Flags().StringVar(&imageSource, "image-source", imageSourceAuto, "Fallback when no source image is found: auto (DALL-E), library (curated tag pools, then DALL-E), screenshot (of a website topic, then DALL-E), or none")
+	generateCmd.Flags().StringVar(&imageLibrary, "image-library", "", "Path to the curated image library (default: <site>/assets/images/library)")
+	generateCmd.Flags().StringVar(&gifHeroMode, "gif-hero", gifHeroStatic, "How to handle GIF hero images: static (extract a frame), animated (frame + MP4 via shortcode, needs ffmpeg), or keep")
+	generateCmd.Flags().IntVar(&codeSnippets, "code-snippets", 0, "For repos: quote up to this many short source excerpts, with permalinks (entry points and code search finds)")
+	generateCmd.Flags().BoolVar(&ecosystemContext, "ecosystem", false, "For repos: add a \"Where It Fits\" section from package downloads, alternatives with the same topics, and recent activity")
+	generateCmd.Flags().StringVar(&terminalDemo, "terminal-demo", terminalDemoOff, "For CLI repos: off, detect (embed the repo's asciinema or VHS recording), or generate (detect, else record a VHS tape of basic usage, after review)")
+	generateCmd.Flags().StringVar(&imageLicensePolicy, "image-license-policy", licensePolicyWarn, "When a downloaded image's license is unknown or not allowed: warn, block (skip the image), or off")
+	generateCmd.Flags().StringSliceVar(&allowedLicenses, "allowed-licenses", defaultAllowedLicenses, "Image licenses acceptable for reuse (SPDX identifiers, plus Unsplash, Pexels, and PDM)")
+	generateCmd.Flags().StringVar(&heroImagePrompt, "image-prompt", "", "DALL-E prompt for the hero image (default: composed from the post)")
+	generateCmd.Flags().IntVar(&imageCandidates, "image-candidates", 1, "Offer this many hero options (from the source or DALL-E) and pick one in the terminal")
+	generateCmd.Flags().StringVar(&truncationStrategy, "truncation", truncateHead, "How sources too long for the prompt are cut: head, head-tail, or relevant (chunks most similar to the topic, by embeddings)")
+	generateCmd.Flags().StringVar(&researchBackend, "research-backend", researchBackendChat, "For research topics: chat (the model's own knowledge), grounded (the provider's web search and file inputs), or auto (grounded when the model supports it)")
+	generateCmd.Flags().StringSliceVar(&researchFiles, "research-file", nil, "For research topics: files to research from alongside the web (PDFs are sent as files by the grounded backend; others as text)")
+	generateCmd.Flags().IntVar(&hnComments, "hn-comments", 30, "For Hacker News threads: how many top comments to read")
+	generateCmd.Flags().IntVar(&redditComments, "reddit-comments", 30, "For Reddit threads: how many top comments to read")
+	generateCmd.Flags().IntVar(&redditMinScore, "reddit-min-score", 2, "For Reddit threads: skip comments scored below this")
+	generateCmd.Flags().StringVar(&podcastEpisodeMatch, "episode", "", "For podcast feeds: the episode whose title contains this (default: the latest)")
+	generateCmd.Flags().BoolVar(&transcribeOnly, "transcribe-only", false, "For podcast episodes: print the timestamped transcript and stop")
+	generateCmd.Flags().StringVar(&transcriber, "transcriber", transcriberAPI, "For podcast episodes: api (OpenAI's transcription API) or local (the whisper CLI)")
+	generateCmd.Flags().StringVar(&whisperModel, "whisper-model", "base", "With --transcriber local: the whisper model (tiny, base, small, medium, large, turbo)")
+	generateCmd.Flags().BoolVar(&contextReport, "context-report", false, "Print what went into the prompt (source, template, includes, sections) and what was cut to fit")
+	generateCmd.Flags().BoolVar(&interactive, "interactive", false, "Pause to review the --long-form outline, the draft (accept, regenerate, give feedback, or edit it), and the hero image prompt before writing")
+	generateCmd.Flags().StringVar(&sourceType, "source-type", "", "Treat the topic as github, website, podcast, research, chat, or feed (default: detected) and use that config block")
+	generateCmd.Flags().Float32Var(&generationTemperature, "temperature", 0.7, "Sampling temperature for writing the post")
+	generateCmd.Flags().IntVar(&targetWords, "words", 0, "Target post length in words (default: the prompt's guidance)")
+	generateCmd.Flags().BoolVar(&longForm, "long-form", false, "Write a long post (3,000+ words) section by section from an outline, then check it reads as one piece")
+	generateCmd.Flags().StringVar(&longFormOutline, "outline", "", "With --long-form: outline file to write the post from; when it doesn't exist, the outline is written there for review and generation stops")
+	generateCmd.Flags().BoolVar(&replacePrevious, "replace-previous", false, "When regenerating a source, remove the post made from it before and alias its URL to the new post")
+	addAssetStoreFlags(generateCmd)
+
+	generateCmd.Flags().StringVar(&briefPath, "brief", "", "Content brief (YAML file, or notion:<database-id>) with target keyword, audience, key points, and competing articles")
+	generateCmd.Flags().StringVar(&chatConversation, "conversation", "", "With a chat export as the topic, use the conversation whose title contains this (default: the most recent)")
+	generateCmd.Flags().StringSliceVar(&contextImages, "context-image", nil, "Screenshot to describe with a vision model and embed in the post where it's discussed (repeatable)")
+	generateCmd.Flags().StringVar(&notesPath, "notes", "", "Markdown file with your own opinions and bullet notes; the post argues these instead of inventing a take")
+	generateCmd.Flags().StringVar(&notesAudioPath, "notes-audio", "", "Voice memo of you talking through the topic (m4a, mp3, wav, ...); transcribed and used as your notes")
+	generateCmd.Flags().StringSliceVar(&postVariants, "variants", nil, "Also write these reading-level variants of the post (simple: a beginner's version)")
+	generateCmd.Flags().StringVar(&variantPlacement, "variant-placement", variantPlacementCompanion, "Where variants go: companion (a separate post linked from this one) or section (collapsed at the top of the post)")
+	generateCmd.Flags().StringVar(&fromStub, "from-stub", "", "Expand a stub post in place, reading topic, sources, tags, tone, length, and image preferences from its front matter")
 }
 
 func runGenerate(cmd *cobra.Command) error {
@@ -73,6 +122,139 @@ func runGenerate(cmd *cobra.Command) error {
 
 	ctx := context.Background()
 
+	if err := checkVariantFlags(); err != nil {
+		return err
+	}
+
+	// Load per-post directives from a stub file
+	var stub *postStub
+	if fromStub != "" {
+		var err error
+		stub, err = loadPostStub(fromStub)
+		if err != nil {
+			return err
+		}
+		stub.apply(cmd)
+		logInfo("📌 Expanding stub %s", stub.Path)
+	}
+
+	// Load the content brief that steers research and generation
+	var brief *contentBrief
+	if briefPath != "" {
+		var err error
+		brief, err = loadBrief(briefPath)
+		if err != nil {
+			return err
+		}
+		if topicURL == "" {
+			topicURL = brief.topic()
+		}
+	}
+
+	var authorNotes string
+	if notesPath != "" {
+		var err error
+		authorNotes, err = loadAuthorNotes(notesPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	if topicURL == "" {
+		return fmt.Errorf("a topic is required (use --topic, --from-stub, or a brief with a topic)")
+	}
+
+	// Determine content type: GitHub URL, website URL, or research topic.
+	// Without --source-type, a matching route can set it.
+	contentType := detectContentType(topicURL)
+	settingsType := contentType
+	var route *routeRule
+	switch sourceType {
+	case "":
+		if err := checkRoutes(activeRoutes()); err != nil {
+			return err
+		}
+		if route = matchRoute(topicURL); route != nil && route.Type != "" {
+			settingsType = route.Type
+			if route.Type != "feed" {
+				contentType = route.Type
+			}
+		}
+	case "github", "website", "podcast", "research", "chat":
+		contentType, settingsType = sourceType, sourceType
+	case "feed":
+		settingsType = sourceType
+	default:
+		return fmt.Errorf("invalid --source-type value %q (use github, website, podcast, research, chat, or feed)", sourceType)
+	}
+	if err := applySourceSettings(cmd, settingsType); err != nil {
+		return err
+	}
+	if contentType == "github" || contentType == "website" || contentType == "podcast" {
+		if err := checkLLMHost(topicURL); err != nil {
+			return err
+		}
+	}
+
+	switch gifHeroMode {
+	case gifHeroStatic, gifHeroAnimated, gifHeroKeep:
+	default:
+		return fmt.Errorf("invalid --gif-hero value %q (use static, animated, or keep)", gifHeroMode)
+	}
+	switch imageSource {
+	case imageSourceAuto, imageSourceLibrary, imageSourceScreenshot, imageSourceNone:
+	default:
+		return fmt.Errorf("invalid --image-source value %q (use auto, library, screenshot, or none)", imageSource)
+	}
+	switch terminalDemo {
+	case terminalDemoOff, terminalDemoDetect, terminalDemoGenerate:
+	default:
+		return fmt.Errorf("invalid --terminal-demo value %q (use off, detect, or generate)", terminalDemo)
+	}
+	if err := checkTruncationStrategy(); err != nil {
+		return err
+	}
+	if err := checkResearchBackend(); err != nil {
+		return err
+	}
+	if err := checkTranscriber(); err != nil {
+		return err
+	}
+	if _, err := effectiveSecretScanMode(); err != nil {
+		return err
+	}
+	switch imageLicensePolicy {
+	case licensePolicyOff, licensePolicyWarn, licensePolicyBlock:
+	default:
+		return fmt.Errorf("invalid --image-license-policy value %q (use warn, block, or off)", imageLicensePolicy)
+	}
+
+	// The transcript alone needs no site
+	if transcribeOnly {
+		if contentType != "podcast" {
+			return fmt.Errorf("--transcribe-only needs a podcast episode (got a %s topic; use --source-type podcast for episode pages megafone doesn't recognize)", contentType)
+		}
+		apiKey := ""
+		if transcriber == transcriberAPI {
+			key, err := getOpenAIKey(cmd)
+			if err != nil {
+				return err
+			}
+			apiKey = key
+		}
+		_, transcript, err := loadPodcastEpisode(ctx, apiKey, topicURL)
+		if err != nil {
+			return err
+		}
+		fmt.Print(transcript)
+		return nil
+	}
+
+	store, err := newObjectStore()
+	if err != nil {
+		return err
+	}
+
 	logInfo("Starting post generation for %s", topicURL)
 
 	// Determine base path for Hugo site
@@ -82,46 +264,82 @@ func runGenerate(cmd *cobra.Command) error {
 	}
 	logInfo("Using Hugo site at: %s", basePath)
 
-	// Get OpenAI API key
-	apiKey, _ := cmd.Flags().GetString("openai-key")
-	if apiKey == "" {
-		apiKey = os.Getenv("OPENAI_API_KEY")
+	if err := runHooks("pre_generate", appConfig.Hooks.PreGenerate, hookRun{Source: topicURL, Site: basePath}); err != nil {
+		return err
 	}
-	if apiKey == "" {
+
+	// Get OpenAI API key
+	apiKey, err := getOpenAIKey(cmd)
+	if err != nil {
 		logError("OpenAI API key not provided")
-		return fmt.Errorf("OpenAI API key required (use --openai-key or OPENAI_API_KEY env var)")
+		return err
 	}
 
-	// Determine content type: GitHub URL, website URL, or research topic
-	contentType := detectContentType(topicURL)
+	if notesAudioPath != "" {
+		transcript, err := transcribeVoiceMemo(ctx, apiKey, notesAudioPath, topicURL)
+		if err != nil {
+			return classify(ErrGeneration, err)
+		}
+		authorNotes = strings.TrimSpace(authorNotes + "\n\n" + voiceMemoNotes(transcript))
+	}
+
+	var screenshots []contextImage
+	if len(contextImages) > 0 {
+		screenshots, err = loadContextImages(ctx, apiKey, contextImages, topicURL, basePath, model)
+		if err != nil {
+			return classify(ErrImage, err)
+		}
+	}
+
+	// Prompts need the terminal, so the live display is only used without them
+	startProgress(!interactive && imageCandidates <= 1 && terminalDemo != terminalDemoGenerate)
+	defer stopProgress()
+
+	// Copyright comment embedded into every image written this run
+	if contentType == "research" || contentType == "chat" {
+		imageStamp = buildImageStamp(imageCopyright, "")
+	} else {
+		imageStamp = buildImageStamp(imageCopyright, topicURL)
+	}
 
 	// Auto-select prompt template if not specified
 	if promptFile == "" {
-		promptFile = selectPromptTemplate(contentType, topicURL)
-		logInfo("📋 Auto-selected prompt template: %s", promptFile)
+		promptFile = selectPromptTemplate(contentType, route)
+		if route != nil {
+			logInfo("📋 Auto-selected prompt template: %s (route %s)", promptFile, firstNonEmpty(route.Name, "(unnamed)"))
+		} else {
+			logInfo("📋 Auto-selected prompt template: %s", promptFile)
+		}
 	}
 
 	var repoData *github.Repository
 	var readmeContent string
 	var contentTitle string
 	var imageName string
+	var heroAttr imageAttribution
+	var heroAnimation string
+	var heroSourceURL string
+	var snippetSection string
+	var ecosystemSection string
+	var pageMeta pageMetadata
 
 	if contentType == "github" {
 		// Parse GitHub repo URL
 		owner, repo, err := parseGitHubURL(topicURL)
 		if err != nil {
 			logError("Invalid GitHub URL: %s", topicURL)
-			return fmt.Errorf("invalid GitHub URL: %w", err)
+			return classify(ErrSource, fmt.Errorf("invalid GitHub URL: %w", err))
 		}
 
+		progressStage("fetch")
 		logInfo("📦 Fetching repository: %s/%s", owner, repo)
 
 		// Fetch repo metadata
-		ghClient := github.NewClient(nil)
+		ghClient := newGitHubClient()
 		repoData, _, err = ghClient.Repositories.Get(ctx, owner, repo)
 		if err != nil {
 			logError("Failed to fetch repository: %v", err)
-			return fmt.Errorf("failed to fetch repository: %w", err)
+			return classify(ErrSource, fmt.Errorf("failed to fetch repository: %w", err))
 		}
 
 		// Fetch README
@@ -130,43 +348,102 @@ func runGenerate(cmd *cobra.Command) error {
 		if err == nil && readme != nil {
 			content, err := readme.GetContent()
 			if err == nil {
-				readmeContent = content
+				readmeContent = fitSource(ctx, apiKey, "source", content, repoData.GetFullName()+": "+repoData.GetDescription(), maxPageChars)
+			}
+		}
+
+		// Quote the project's own code instead of paraphrasing the README
+		if codeSnippets > 0 {
+			logInfo("🧩 Looking for code snippets to quote...")
+			snippets, err := findCodeSnippets(ctx, apiKey, ghClient, repoData, readmeContent, codeSnippets)
+			if err != nil {
+				logError("No code snippets: %v", err)
+			} else {
+				logInfo("🧩 Found %d code snippets", len(snippets))
+				snippetSection = snippetPromptSection(snippets)
 			}
 		}
 
+		if ecosystemContext {
+			logInfo("🌍 Gathering ecosystem context...")
+			ecosystemSection = gatherEcosystemFacts(ctx, ghClient, repoData).promptSection()
+		}
+
 		// Detect/process image FIRST so we can include it in the generated content
 		if imagePath != "" {
 			logInfo("🖼️  Processing provided image: %s", imagePath)
 			imageName, err = processImage(imagePath, repo, basePath)
 			if err != nil {
 				logError("Failed to process image: %v", err)
-				return fmt.Errorf("failed to process image: %w", err)
+				return classify(ErrImage, fmt.Errorf("failed to process image: %w", err))
 			}
 		} else {
 			// Try to auto-detect image from repository
 			logInfo("🔍 Searching for hero image in repository...")
-			autoImage, err := findBestImage(ctx, ghClient, apiKey, owner, repo, model)
+			autoImage, err := findBestImage(ctx, ghClient, apiKey, owner, repo, model, minImageWidth)
 			if err != nil {
 				logInfo("No suitable image found in repository: %v", err)
 			} else if autoImage != "" {
 				logInfo("✨ Found image: %s", autoImage)
-				imageName, err = downloadAndProcessImage(autoImage, repo, basePath)
-				if err != nil {
+				license := repoImageLicense(repoData)
+				if err := checkImageLicense(license, autoImage); err != nil {
+					logError("Skipping image: %v", err)
+				} else if imageName, heroAnimation, err = downloadAndProcessImage(autoImage, repo, basePath); err != nil {
 					logError("Failed to download image: %v", err)
+				} else {
+					heroSourceURL = autoImage
+					heroAttr = imageAttribution{
+						Caption:   findMarkdownImageAlt(readmeContent, autoImage),
+						Credit:    repoData.GetFullName(),
+						CreditURL: repoData.GetHTMLURL(),
+						License:   license,
+					}
 				}
 			}
 		}
 	} else if contentType == "website" {
 		// Handle regular website
-		logInfo("🌐 Fetching website content...")
-		websiteContent, title, htmlContent, err := fetchWebsiteContent(topicURL)
+		progressStage("fetch")
+
+		// A Hacker News or Reddit thread is written up from the article it
+		// links to, with the discussion added after it
+		pageURL := topicURL
+		thread, err := fetchTopicThread(ctx, topicURL)
 		if err != nil {
-			logError("Failed to fetch website: %v", err)
-			return fmt.Errorf("failed to fetch website: %w", err)
+			logError("Failed to fetch discussion thread: %v", err)
+			return classify(ErrSource, err)
+		}
+		if thread != nil {
+			pageURL = thread.LinkURL
+		}
+
+		var websiteContent, htmlContent string
+		var meta pageMetadata
+		if pageURL == "" {
+			// Ask HN, Reddit self posts, and other text posts: the post itself is the article
+			websiteContent = thread.Text
+			meta = pageMetadata{URL: topicURL, Title: thread.Title, SiteName: thread.Site, Author: thread.Author}
+		} else {
+			logInfo("🌐 Fetching website content...")
+			websiteContent, meta, htmlContent, err = fetchWebsiteContent(pageURL)
+			if err != nil {
+				logError("Failed to fetch website: %v", err)
+				return classify(ErrSource, fmt.Errorf("failed to fetch website: %w", err))
+			}
 		}
 		readmeContent = websiteContent
+		pageMeta = meta
+		if text := pageText(htmlContent); len(text) > maxPageChars {
+			readmeContent = fitSource(ctx, apiKey, "source", text, firstNonEmpty(meta.Title, pageURL)+"\n"+meta.Description, maxPageChars)
+		}
+		title := meta.Title
+		if thread != nil {
+			readmeContent += thread.Discussion
+			title = firstNonEmpty(thread.Title, title)
+		}
 		contentTitle = title
 		logInfo("📄 Fetched content from: %s", title)
+		logInfo("🪪 Metadata: site=%q author=%q published=%q images=%d", meta.SiteName, meta.Author, meta.Published, len(meta.Images))
 
 		// Process image if provided, otherwise try to extract from page
 		if imagePath != "" {
@@ -176,30 +453,120 @@ func runGenerate(cmd *cobra.Command) error {
 			imageName, err = processImageWithName(imagePath, imgBaseName, basePath)
 			if err != nil {
 				logError("Failed to process image: %v", err)
-				return fmt.Errorf("failed to process image: %w", err)
+				return classify(ErrImage, fmt.Errorf("failed to process image: %w", err))
 			}
 		} else {
 			// Try to extract hero image from the webpage
 			logInfo("🔍 Searching for hero image in webpage...")
-			imageURL := extractBestImage(htmlContent, topicURL)
-			if imageURL != "" {
-				logInfo("✨ Found image: %s", imageURL)
-				imgBaseName := sanitizeFilename(title)
-				imageName, err = downloadAndProcessWebImage(imageURL, imgBaseName, basePath)
+			ranked := rankImageCandidates(meta.Images, minImageWidth)
+			if imageCandidates > 1 && len(ranked) > 1 {
+				urls := make([]string, len(ranked))
+				for i, img := range ranked {
+					urls[i] = img.URL
+				}
+				choice, err := chooseImageCandidate(urls)
 				if err != nil {
+					return err
+				}
+				if choice < 0 {
+					ranked = nil
+				} else {
+					ranked = []pageImage{ranked[choice]}
+				}
+			}
+			if len(ranked) > 0 {
+				heroCandidate := ranked[0]
+				logInfo("✨ Found image: %s (from %s, %dx%d)", heroCandidate.URL, heroCandidate.Source, heroCandidate.Width, heroCandidate.Height)
+				imgBaseName := sanitizeFilename(title)
+				license := pageImageLicense(htmlContent, heroCandidate.URL)
+				if err := checkImageLicense(license, heroCandidate.URL); err != nil {
+					logError("Skipping image: %v", err)
+				} else if imageName, heroAnimation, err = downloadAndProcessWebImage(heroCandidate.URL, imgBaseName, basePath); err != nil {
 					logError("Failed to download image: %v", err)
+				} else {
+					heroAttr = extractImageAttribution(htmlContent, heroCandidate, pageURL)
+					heroAttr.License = license
+					if heroAttr.Caption != "" {
+						logInfo("📝 Image caption: %s", heroAttr.Caption)
+					}
+					logInfo("📝 Image credit: %s", heroAttr.Credit)
 				}
 			} else {
 				logInfo("No suitable image found in webpage")
 			}
 		}
+
+		// A screenshot shows the site or tool itself, unlike a generated image
+		if imageName == "" && imageSource == imageSourceScreenshot && pageURL != "" {
+			if imageName, err = captureScreenshotHero(ctx, pageURL, sanitizeFilename(title), basePath); err != nil {
+				logError("Failed to capture screenshot: %v", err)
+			} else {
+				heroAttr = screenshotAttribution(meta, pageURL)
+			}
+		}
+	} else if contentType == "podcast" {
+		progressStage("fetch")
+		episode, transcript, err := loadPodcastEpisode(ctx, apiKey, topicURL)
+		if err != nil {
+			logError("Failed to transcribe episode: %v", err)
+			return err
+		}
+		readmeContent = podcastSource(episode, transcript)
+		if len(readmeContent) > podcastMaxTranscriptChars {
+			readmeContent = fitSource(ctx, apiKey, "transcript", readmeContent, episode.Title+"\n"+trimToLength(episode.Notes, 500), podcastMaxTranscriptChars)
+		}
+		contentTitle = episode.Title
+		pageMeta = pageMetadata{URL: episode.Link, Title: episode.Title, Description: trimToLength(episode.Notes, 300), SiteName: episode.Show, Author: episode.Author, Published: episode.Published}
+
+		if imagePath != "" {
+			logInfo("🖼️  Processing provided image: %s", imagePath)
+			imageName, err = processImageWithName(imagePath, sanitizeFilename(contentTitle), basePath)
+			if err != nil {
+				logError("Failed to process image: %v", err)
+				return classify(ErrImage, fmt.Errorf("failed to process image: %w", err))
+			}
+		} else if episode.ImageURL != "" {
+			// The episode or show artwork
+			logInfo("✨ Using the episode artwork: %s", episode.ImageURL)
+			if imageName, heroAnimation, err = downloadAndProcessWebImage(episode.ImageURL, sanitizeFilename(contentTitle), basePath); err != nil {
+				logError("Failed to download image: %v", err)
+			} else {
+				heroAttr = imageAttribution{Credit: firstNonEmpty(episode.Show, episode.Title), CreditURL: episode.Link}
+			}
+		}
+	} else if contentType == "chat" {
+		progressStage("research")
+		transcript, err := loadChatTranscript(topicURL)
+		if err != nil {
+			return classify(ErrSource, err)
+		}
+		logInfo("💬 Distilling conversation %q (%d messages)...", transcript.Title, len(transcript.Messages))
+		readmeContent, err = distillConversation(ctx, apiKey, transcript, model)
+		if err != nil {
+			return classify(ErrGeneration, fmt.Errorf("failed to distill conversation: %w", err))
+		}
+		contentTitle = transcript.Title
+
+		if imagePath != "" {
+			logInfo("🖼️  Processing provided image: %s", imagePath)
+			imageName, err = processImageWithName(imagePath, sanitizeFilename(contentTitle), basePath)
+			if err != nil {
+				logError("Failed to process image: %v", err)
+				return classify(ErrImage, fmt.Errorf("failed to process image: %w", err))
+			}
+		}
 	} else {
 		// Handle research topic
+		progressStage("research")
 		logInfo("🔬 Researching topic: %s", topicURL)
-		researchContent, title, err := researchTopic(ctx, apiKey, topicURL, model)
+		researchGuidance := ""
+		if brief != nil {
+			researchGuidance = brief.researchGuidance()
+		}
+		researchContent, title, err := researchTopic(ctx, apiKey, topicURL, researchGuidance, model)
 		if err != nil {
 			logError("Failed to research topic: %v", err)
-			return fmt.Errorf("failed to research topic: %w", err)
+			return classify(ErrGeneration, fmt.Errorf("failed to research topic: %w", err))
 		}
 		readmeContent = researchContent
 		contentTitle = title
@@ -212,42 +579,101 @@ func runGenerate(cmd *cobra.Command) error {
 			imageName, err = processImageWithName(imagePath, imgBaseName, basePath)
 			if err != nil {
 				logError("Failed to process image: %v", err)
-				return fmt.Errorf("failed to process image: %w", err)
+				return classify(ErrImage, fmt.Errorf("failed to process image: %w", err))
 			}
 		}
 		// Note: For research topics, we'll generate an image after the post is created
 	}
 
+	// Internal docs can carry keys and hostnames; keep them from the model so
+	// it can't echo them into the post
+	if readmeContent, err = maskSourceSecrets(readmeContent, "source"); err != nil {
+		return err
+	}
+
 	// Load prompt template
 	logInfo("📝 Loading prompt template from %s", promptFile)
-	promptTemplate, err := os.ReadFile(promptFile)
+	promptTemplate, err := loadPrompt(promptFile, contentType, topicURL)
 	if err != nil {
-		logError("Failed to read prompt file: %v", err)
-		return fmt.Errorf("failed to read prompt file: %w", err)
+		logError("Failed to load prompt: %v", err)
+		return err
+	}
+	budgetRecord("prompt template", promptTemplate)
+	if targetWords > 0 {
+		promptTemplate += fmt.Sprintf("\n\nTarget length: about %d words.\n", targetWords)
+	}
+	if stub != nil {
+		directives := stub.directives()
+		budgetRecord("stub directives", directives)
+		promptTemplate += directives
+	}
+	if brief != nil {
+		budgetRecord("brief", brief.promptSection())
+		promptTemplate += brief.promptSection()
+	}
+	if contentType == "chat" {
+		promptTemplate += conversationPromptSection()
+	}
+	budgetRecord("code snippets", snippetSection)
+	budgetRecord("ecosystem facts", ecosystemSection)
+	promptTemplate += snippetSection + ecosystemSection
+	if authorNotes != "" {
+		budgetRecord("author's notes", authorNotesSection(authorNotes))
+		promptTemplate += authorNotesSection(authorNotes)
+	}
+	if len(screenshots) > 0 {
+		budgetRecord("screenshots", contextImagesSection(screenshots))
+		promptTemplate += contextImagesSection(screenshots)
 	}
+	budgetRecord("source", readmeContent)
 
 	// Generate content with OpenAI (now with image info)
+	progressStage("generate")
 	logInfo("🤖 Generating blog post with OpenAI (%s)...", model)
-	var content, filename string
-	if contentType == "github" {
-		content, filename, err = generateWithOpenAI(ctx, apiKey, string(promptTemplate), repoData, readmeContent, tags, imageName, model)
-	} else if contentType == "website" {
-		content, filename, err = generateFromWebsite(ctx, apiKey, string(promptTemplate), topicURL, contentTitle, readmeContent, tags, imageName, model)
-	} else {
-		// Research topic
-		content, filename, err = generateFromResearch(ctx, apiKey, string(promptTemplate), topicURL, contentTitle, readmeContent, tags, imageName, model)
+	// writeDraft runs the model on a prompt template; --interactive calls it
+	// again to regenerate the draft
+	writeDraft := func(template string) (string, string, error) {
+		if longForm {
+			var sourceContext string
+			switch contentType {
+			case "github":
+				sourceContext = repoPromptContext(repoData, readmeContent)
+			case "website", "podcast":
+				sourceContext = websitePromptContext(topicURL, pageMeta, readmeContent)
+			default:
+				sourceContext = researchPromptContext(firstNonEmpty(contentTitle, topicURL), readmeContent)
+			}
+			return generateLongForm(ctx, apiKey, template, sourceContext, tags, imageName, heroAttr, model)
+		}
+		switch contentType {
+		case "github":
+			return generateWithOpenAI(ctx, apiKey, template, repoData, readmeContent, tags, imageName, heroAttr, model)
+		case "website", "podcast":
+			return generateFromWebsite(ctx, apiKey, template, topicURL, pageMeta, readmeContent, tags, imageName, heroAttr, model)
+		}
+		// Research topic, or the notes distilled from a chat
+		topic := topicURL
+		if contentType == "chat" {
+			topic = contentTitle
+		}
+		return generateFromResearch(ctx, apiKey, template, topic, contentTitle, readmeContent, tags, imageName, model)
+	}
+	content, filename, err := writeDraft(promptTemplate)
+	if errors.Is(err, errOutlineWritten) {
+		return nil
 	}
 	if err != nil {
 		logError("OpenAI generation failed: %v", err)
-		return fmt.Errorf("failed to generate content: %w", err)
+		return classify(ErrGeneration, fmt.Errorf("failed to generate content: %w", err))
 	}
+	finishContextBudget(basePath, topicURL)
 
 	logInfo("Generated filename: %s", filename)
 
 	// Validate we have content and filename before proceeding
 	if content == "" {
 		logError("Generated content is empty! Aborting.")
-		return fmt.Errorf("content generation returned empty result")
+		return classify(ErrGeneration, fmt.Errorf("content generation returned empty result"))
 	}
 	if filename == "" {
 		logError("Generated filename is empty! Using fallback.")
@@ -257,43 +683,247 @@ func runGenerate(cmd *cobra.Command) error {
 		}
 	}
 
-	// Generate hero image if we don't have one yet
-	if imageName == "" && !dryRun {
-		logInfo("🎨 No image found, generating hero image with DALL-E...")
-		generatedImageName, err := generateHeroImage(ctx, apiKey, content, filename, basePath)
+	if interactive && stdinIsTerminal() {
+		content, filename, err = reviewDraft(content, filename, func(draft, feedback string) (string, string, error) {
+			if feedback == "" {
+				return writeDraft(promptTemplate)
+			}
+			return writeDraft(promptTemplate + revisionSection(draft, feedback))
+		})
+		if errors.Is(err, errDraftDiscarded) {
+			logInfo("🗑️  Draft discarded; nothing was written")
+			return nil
+		}
 		if err != nil {
-			logError("Failed to generate image: %v", err)
-			logInfo("Continuing without hero image...")
+			return classify(ErrGeneration, err)
+		}
+	}
+
+	content = placeContextImages(content, screenshots)
+
+	// Carry the source image caption and credit into the front matter
+	if imageName != "" && (!heroAttr.isEmpty() || heroAttr.License != "") {
+		content = applyImageAttribution(content, heroAttr)
+	}
+
+	// Embed the animated demo when the GIF hero was converted to video
+	if heroAnimation != "" {
+		content = embedHeroAnimation(content, heroAnimation, imageName)
+	}
+
+	// Show a CLI project in action
+	if contentType == "github" && terminalDemo != terminalDemoOff {
+		owner, repo, _ := parseGitHubURL(topicURL)
+		if content, err = embedTerminalDemo(ctx, apiKey, content, owner, repo, readmeContent, heroSourceURL, basePath); err != nil {
+			logError("Failed to add a terminal demo: %v", err)
+		}
+	}
+
+	// Attach the source card for the theme's attribution partial
+	if contentType == "website" || contentType == "podcast" {
+		content = upsertFrontMatterField(content, "source_card", newSourceCard(pageMeta).frontMatterValue())
+	}
+
+	progressStage("image")
+
+	// Pick a hero from the curated library before paying for DALL-E
+	if imageName == "" && !dryRun && imageSource == imageSourceLibrary {
+		libraryDir := imageLibrary
+		if libraryDir == "" {
+			libraryDir = defaultImageLibrary(basePath)
+		}
+		postTags := frontMatterList(content, "tags")
+		if tags != "" {
+			postTags = append(postTags, strings.Split(tags, ",")...)
+		}
+		libraryImageName, err := pickLibraryImage(libraryDir, basePath, postTags)
+		if err != nil {
+			logInfo("No library image used: %v", err)
 		} else {
-			imageName = generatedImageName
-			logSuccess("✨ Generated hero image: %s", imageName)
+			imageName = libraryImageName
+			content = updateContentWithImage(content, imageName)
+		}
+	}
+
+	// Generate hero image if we don't have one yet
+	if imageName == "" && imageSource != imageSourceNone {
+		prompt := heroImagePrompt
+		if prompt == "" {
+			prompt = createImagePrompt(content)
+		}
+		generateImage := !dryRun
+		if dryRun {
+			logInfo("🖼️  Image prompt (not generated in dry run): %s", prompt)
+		} else if interactive && stdinIsTerminal() {
+			if prompt, generateImage, err = reviewImagePrompt(prompt); err != nil {
+				return err
+			}
+			if !generateImage {
+				logInfo("Skipping hero image generation")
+			}
+		}
+
+		if generateImage {
+			logInfo("🎨 No image found, generating hero image with DALL-E...")
+			generatedImageName, err := generateHeroImage(ctx, apiKey, prompt, filename, basePath, imageCandidates)
+			if err != nil {
+				logError("Failed to generate image: %v", err)
+				logInfo("Continuing without hero image...")
+			} else {
+				imageName = generatedImageName
+				logSuccess("✨ Generated hero image: %s", imageName)
 
-			// Update the content to include the generated image
-			if contentType == "research" || contentType == "website" {
-				content = updateContentWithImage(content, imageName)
+				// Update the content to include the generated image
+				if contentType == "research" || contentType == "website" || contentType == "podcast" || contentType == "chat" {
+					content = updateContentWithImage(content, imageName)
+				}
 			}
 		}
 	}
 
+	content = applyDatePolicy(content, postDateString(), basePath)
+	content = applyHighlights(ctx, openai.NewClient(apiKey), content)
+	if content, err = applySummarySections(ctx, openai.NewClient(apiKey), content, contentType); err != nil {
+		return err
+	}
+	content = applyAccessibility(content)
+	if content, err = applyDisclosure(content, basePath); err != nil {
+		return err
+	}
+	if content, err = applyFrontMatterDefaults(content, contentType, topicURL); err != nil {
+		return err
+	}
+	if content, err = applySecretScan(content); err != nil {
+		return err
+	}
+	if stub == nil {
+		keyword := ""
+		if brief != nil {
+			keyword = brief.TargetKeyword
+		}
+		if content, filename, err = applySlugStrategy(content, filename, keyword); err != nil {
+			return err
+		}
+	}
+
+	// Reading-level variants are written from the finished post
+	simple := ""
+	if wantVariant(variantSimple) {
+		progressStage("variants")
+		logInfo("🧒 Writing the beginner's version...")
+		simple, err = writeSimpleVariant(ctx, openai.NewClient(apiKey), content, variantPlacement == variantPlacementSection)
+		if err != nil {
+			logError("Failed to write the simple variant: %v", err)
+			logInfo("Continuing without it...")
+		} else if variantPlacement == variantPlacementSection {
+			content = addSimpleSection(content, applyAccessibility(simple))
+			simple = ""
+		}
+	}
+
 	if dryRun {
 		logInfo("Dry run mode - not writing files")
+		stopProgress()
 		fmt.Println("\n" + strings.Repeat("=", 80))
 		fmt.Println("DRY RUN - Generated Content:")
 		fmt.Println(strings.Repeat("=", 80))
 		fmt.Println(content)
 		fmt.Println(strings.Repeat("=", 80))
+		if simple != "" {
+			fmt.Println("DRY RUN - Simple Variant:")
+			fmt.Println(strings.Repeat("=", 80))
+			fmt.Println(simple)
+			fmt.Println(strings.Repeat("=", 80))
+		}
 		return nil
 	}
 
+	progressStage("write")
+
+	// Move this post's images to the bucket so binaries stay out of git
+	if store != nil {
+		content, err = offloadImages(ctx, store, basePath, content)
+		if err != nil {
+			return fmt.Errorf("failed to offload images: %w", err)
+		}
+	}
+
 	// Write post to content directory
-	postPath := filepath.Join(basePath, "content", "posts", "en", fmt.Sprintf("%s.md", filename))
-	if err := os.WriteFile(postPath, []byte(content), 0644); err != nil {
-		logError("Failed to write post file: %v", err)
-		return fmt.Errorf("failed to write post: %w", err)
+	postPath := filepath.Join(postsDir(basePath), fmt.Sprintf("%s.md", filename))
+	if stub != nil {
+		// Stubs are expanded in place
+		postPath = stub.Path
+	}
+
+	// Other runs writing to this site wait until the post, the earlier post's
+	// removal, and the companion are all on disk
+	err = withSiteLock(basePath, "writing "+filepath.Base(postPath), func() error {
+		if stub == nil {
+			postPath, content = claimPostPath(postPath, content)
+		}
+
+		// With --replace-previous, regenerating a source replaces the post made
+		// from it before; its URL (and any file being overwritten's aliases)
+		// carry over as aliases. The earlier post may have been edited by hand,
+		// so it's otherwise left alone.
+		previous := ""
+		if stub == nil {
+			previous = previousPostFor(basePath, topicURL)
+			if previous != "" && previous != postPath && !replacePrevious {
+				logInfo("An earlier post from this source is kept: %s (pass --replace-previous to replace it)", previous)
+				previous = ""
+			}
+		}
+		for _, old := range []string{previous, postPath} {
+			if old == "" {
+				continue
+			}
+			if data, err := os.ReadFile(old); err == nil {
+				content = carryOverURLs(content, postPath, old, string(data))
+			}
+		}
+
+		if simple != "" {
+			content = linkSimpleCompanion(content, simpleCompanionPath(postPath))
+		}
+
+		// A --language other than the site's usual ones may not have a directory yet
+		if err := os.MkdirAll(filepath.Dir(postPath), 0755); err != nil {
+			return fmt.Errorf("failed to create posts directory: %w", err)
+		}
+		if err := os.WriteFile(postPath, []byte(content), 0644); err != nil {
+			logError("Failed to write post file: %v", err)
+			return fmt.Errorf("failed to write post: %w", err)
+		}
+		if previous != "" && previous != postPath {
+			if err := os.Remove(previous); err != nil {
+				logError("Failed to remove the earlier post %s: %v", previous, err)
+			} else {
+				recordSiteChange("deleted", previous, topicURL, false)
+				logInfo("♻️  Replaced the earlier post from this source: %s (its URL is now an alias)", previous)
+			}
+			// Its companion would link to a post that no longer exists
+			if err := os.Remove(simpleCompanionPath(previous)); err == nil {
+				recordSiteChange("deleted", simpleCompanionPath(previous), "", false)
+			}
+		}
+		if simple != "" {
+			companionPath := simpleCompanionPath(postPath)
+			if err := os.WriteFile(companionPath, []byte(simpleCompanion(content, simple, postPath)), 0644); err != nil {
+				logError("Failed to write the simple variant: %v", err)
+			} else {
+				recordSiteChange("created", companionPath, "", true)
+				logSuccess("✅ Beginner's version created: %s", companionPath)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	logSuccess("✅ Post created: %s", postPath)
-	if imageName != "" {
+	if imageName != "" && store == nil {
 		logSuccess("✅ Image copied: assets/images/site/%s", imageName)
 	}
 
@@ -305,31 +935,24 @@ func runGenerate(cmd *cobra.Command) error {
 
 	// Log the successful generation
 	logGeneration(topicURL, postPath, imagePath, tagList)
+	stopProgress()
 
-	return nil
+	return runHooks("post_generate", appConfig.Hooks.PostGenerate, hookRun{Post: postPath, Source: topicURL, Site: basePath})
 }
 
-func generateWithOpenAI(ctx context.Context, apiKey, promptTemplate string, repo *github.Repository, readme, userTags, heroImage, model string) (content, filename string, err error) {
+func generateWithOpenAI(ctx context.Context, apiKey, promptTemplate string, repo *github.Repository, readme, userTags, heroImage string, heroAttr imageAttribution, model string) (content, filename string, err error) {
 	client := openai.NewClient(apiKey)
 
 	// Build context for the AI
-	repoContext := fmt.Sprintf(`
-Repository: %s
-Description: %s
-Language: %s
-Stars: %d
-URL: %s
-
-README Content:
-%s
-`, repo.GetFullName(), repo.GetDescription(), repo.GetLanguage(), repo.GetStargazersCount(), repo.GetHTMLURL(), readme)
+	repoContext := repoPromptContext(repo, readme)
 
 	// Get current date for the post
-	currentDate := time.Now().Format("2006-01-02")
+	currentDate := postDateString()
 
 	heroImageInfo := ""
 	if heroImage != "" {
 		heroImageInfo = fmt.Sprintf("\nHero image available: %s (use path: /images/site/%s)", heroImage, heroImage)
+		heroImageInfo += heroFigureInstructions(heroImage, heroAttr)
 	}
 
 	userPrompt := fmt.Sprintf(`%s
@@ -354,7 +977,7 @@ Generate a complete Hugo markdown post following the style guide above.
 			return ""
 		}())
 
-	resp, err := client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+	request := openai.ChatCompletionRequest{
 		Model: model,
 		Messages: []openai.ChatCompletionMessage{
 			{
@@ -366,8 +989,11 @@ Generate a complete Hugo markdown post following the style guide above.
 				Content: userPrompt,
 			},
 		},
-		Temperature: 0.7,
-	})
+		Temperature: generationTemperature,
+	}
+
+	budgetPrompt(userPrompt)
+	resp, err := createChatCompletion(withStreaming(ctx), client, request)
 
 	if err != nil {
 		return "", "", fmt.Errorf("OpenAI API error: %w\n\nTroubleshooting:\n- Check your API key is valid\n- Verify your OpenAI account has credits: https://platform.openai.com/usage\n- Try a different model with --model gpt-4o-mini\n- Check rate limits: https://platform.openai.com/account/limits", err)
@@ -377,7 +1003,10 @@ Generate a complete Hugo markdown post following the style guide above.
 		return "", "", fmt.Errorf("no response from OpenAI")
 	}
 
-	content = resp.Choices[0].Message.Content
+	// A post cut off at the output limit is continued, not written half-finished
+	if content, err = continueTruncated(withStreaming(ctx), client, request, resp); err != nil {
+		return "", "", err
+	}
 
 	// Generate filename from content
 	filename, err = generateFilename(ctx, client, content, model)
@@ -390,6 +1019,45 @@ Generate a complete Hugo markdown post following the style guide above.
 	return content, filename, nil
 }
 
+// repoPromptContext describes a repository and its README for the model
+func repoPromptContext(repo *github.Repository, readme string) string {
+	return fmt.Sprintf(`
+Repository: %s
+Description: %s
+Language: %s
+Stars: %d
+URL: %s
+
+README Content:
+%s
+`, repo.GetFullName(), repo.GetDescription(), repo.GetLanguage(), repo.GetStargazersCount(), repo.GetHTMLURL(), readme)
+}
+
+// websitePromptContext describes a web page and its text for the model
+func websitePromptContext(urlStr string, meta pageMetadata, content string) string {
+	return fmt.Sprintf(`
+Website URL: %s
+Title: %s
+Site: %s
+Author: %s
+Published: %s
+Description: %s
+
+Content:
+%s
+`, urlStr, meta.Title, meta.SiteName, meta.Author, meta.Published, meta.Description, content)
+}
+
+// researchPromptContext gives the model a topic and its research material
+func researchPromptContext(topic, researchContent string) string {
+	return fmt.Sprintf(`
+Research Topic: %s
+
+Research Material:
+%s
+`, topic, researchContent)
+}
+
 func generateFilename(ctx context.Context, client *openai.Client, content, model string) (string, error) {
 	prompt := fmt.Sprintf(`Given this blog post content, generate a short, SEO-friendly filename (without .md extension).
 
@@ -406,7 +1074,7 @@ Blog post:
 
 Respond with ONLY the filename, nothing else.`, content)
 
-	resp, err := client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+	resp, err := createChatCompletion(ctx, client, openai.ChatCompletionRequest{
 		Model: model,
 		Messages: []openai.ChatCompletionMessage{
 			{
@@ -459,15 +1127,9 @@ func parseGitHubURL(url string) (owner, repo string, err error) {
 }
 
 func processImage(srcPath, repoName, basePath string) (string, error) {
-	// Determine destination path
+	// Determine destination name
 	ext := filepath.Ext(srcPath)
 	imageName := fmt.Sprintf("%s%s", strings.ToLower(repoName), ext)
-	destPath := filepath.Join(basePath, "assets", "images", "site", imageName)
-
-	// Ensure destination directory exists
-	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
-		return "", err
-	}
 
 	// Copy image file
 	data, err := os.ReadFile(srcPath)
@@ -475,47 +1137,94 @@ func processImage(srcPath, repoName, basePath string) (string, error) {
 		return "", err
 	}
 
-	if err := os.WriteFile(destPath, data, 0644); err != nil {
+	return writeSiteImage(basePath, imageName, data)
+}
+
+// resolveSitePath locates the Hugo site and checks that its .megafone
+// workspace is in the layout this version uses
+func resolveSitePath() (string, error) {
+	basePath, err := locateSitePath()
+	if err != nil {
 		return "", err
 	}
+	if err := checkWorkspace(basePath); err != nil {
+		return "", err
+	}
+	return basePath, nil
+}
+
+// siteReadOnly is set once a command resolved the site with inspectSitePath
+var siteReadOnly bool
 
-	return imageName, nil
+// inspectSitePath is resolveSitePath for commands that only read the site:
+// the workspace is checked but nothing in it is created or stamped
+func inspectSitePath() (string, error) {
+	basePath, err := locateSitePath()
+	if err != nil {
+		return "", err
+	}
+	if err := checkWorkspaceSchema(basePath); err != nil {
+		return "", err
+	}
+	siteReadOnly = true
+	return basePath, nil
 }
 
-func resolveSitePath() (string, error) {
+// locateSitePath returns the Hugo site from --site-source, or clones --site-repo
+func locateSitePath() (string, error) {
 	// If user provided a path, validate it
 	if siteSource != "" {
 		absPath, err := filepath.Abs(siteSource)
 		if err != nil {
-			return "", fmt.Errorf("invalid site-source: %w", err)
+			return "", classify(ErrSiteLayout, fmt.Errorf("invalid site-source: %w", err))
 		}
 
 		// Check if path exists
 		if _, err := os.Stat(absPath); os.IsNotExist(err) {
-			return "", fmt.Errorf("site-source does not exist: %s", absPath)
+			return "", classify(ErrSiteLayout, fmt.Errorf("site-source does not exist: %s", absPath))
 		}
 
 		// Check if it looks like a Hugo site (has content/ directory)
 		contentDir := filepath.Join(absPath, "content")
 		if _, err := os.Stat(contentDir); os.IsNotExist(err) {
-			return "", fmt.Errorf("path does not appear to be a Hugo site (no content/ directory): %s", absPath)
+			return "", classify(ErrSiteLayout, fmt.Errorf("path does not appear to be a Hugo site (no content/ directory): %s", absPath))
 		}
 
 		return absPath, nil
 	}
 
+	// Clone (or refresh) the site into the cache
+	if siteRepo != "" {
+		path, err := cachedSitePath()
+		if err != nil {
+			return "", err
+		}
+		siteSource = path
+		return locateSitePath()
+	}
+
 	// No path provided - show git clone stub
 	fmt.Println("\n⚠️  No Hugo site source path provided.")
-	fmt.Println("\nTo clone your Hugo site repository, run:")
-	fmt.Println("  git clone <your-hugo-site-repo-url> /path/to/hugo-site")
-	fmt.Println("\nThen use --site-source flag:")
-	fmt.Println("  hugo-companion generate --topic <url> --site-source /path/to/hugo-site")
+	fmt.Println("\nLet megafone clone your Hugo site repository:")
+	fmt.Println("  megafone generate --topic <url> --site-repo git@github.com:you/hugo-site.git")
+	fmt.Println("\nOr clone it yourself and use --site-source:")
+	fmt.Println("  megafone generate --topic <url> --site-source /path/to/hugo-site")
 	fmt.Println()
 
-	return "", fmt.Errorf("Hugo site source path required (use --site-source)")
+	return "", classify(ErrSiteLayout, fmt.Errorf("Hugo site source path required (use --site-source or --site-repo)"))
 }
 
 func detectContentType(input string) string {
+	// A chat export on disk
+	if isChatTranscriptFile(input) {
+		return "chat"
+	}
+
+	// A link straight to an episode's audio
+	if isAudioURL(input) {
+		return "podcast"
+	}
+
 	// Check if it's a GitHub URL
 	if strings.Contains(input, "github.com") {
 		return "github"
@@ -537,56 +1246,24 @@ func detectContentType(input string) string {
 	return "research"
 }
 
-func selectPromptTemplate(contentType string, input string) string {
-	// If GitHub, use the project template
-	if contentType == "github" {
-		return "prompts/github-project.txt"
-	}
-
-	// If research topic, use research template
-	if contentType == "research" {
-		return "prompts/research-topic.txt"
-	}
-
-	// For websites, detect content type based on URL patterns
-	urlLower := strings.ToLower(input)
-
-	// News sites and articles
-	newsPatterns := []string{
-		"cnn.com", "bbc.com", "reuters.com", "apnews.com",
-		"nytimes.com", "wsj.com", "bloomberg.com", "techcrunch.com",
-		"theverge.com", "arstechnica.com", "wired.com",
-		"/news/", "/article/", "/story/",
-	}
-
-	for _, pattern := range newsPatterns {
-		if strings.Contains(urlLower, pattern) {
-			return "prompts/news-article.txt"
-		}
+// selectPromptTemplate returns the template of the route a topic matched, or
+// the default for its source type
+func selectPromptTemplate(contentType string, route *routeRule) string {
+	if route != nil {
+		return route.Template
 	}
-
-	// Technical documentation and tutorials
-	technicalPatterns := []string{
-		"stackoverflow.com", "dev.to", "medium.com",
-		"docs.", "documentation", "/tutorial/", "/guide/",
-		"/blog/", "hashnode.com", "substack.com",
+	if template, ok := fallbackTemplates[contentType]; ok {
+		return template
 	}
-
-	for _, pattern := range technicalPatterns {
-		if strings.Contains(urlLower, pattern) {
-			return "prompts/technical-article.txt"
-		}
-	}
-
 	// Default to news article template for general websites
 	return "prompts/news-article.txt"
 }
 
-func fetchWebsiteContent(urlStr string) (content, title, htmlContent string, err error) {
+func fetchWebsiteContent(urlStr string) (content string, meta pageMetadata, htmlContent string, err error) {
 	// Parse and validate URL
 	parsedURL, err := url.Parse(urlStr)
 	if err != nil {
-		return "", "", "", fmt.Errorf("invalid URL: %w", err)
+		return "", pageMetadata{}, "", fmt.Errorf("invalid URL: %w", err)
 	}
 
 	// Ensure we have a scheme
@@ -595,55 +1272,56 @@ func fetchWebsiteContent(urlStr string) (content, title, htmlContent string, err
 	}
 
 	// Fetch the webpage
+	// Page text goes into prompts, so the page must be allowed there
+	if err := checkLLMHost(urlStr); err != nil {
+		return "", pageMetadata{}, "", err
+	}
 	resp, err := http.Get(urlStr)
 	if err != nil {
-		return "", "", "", fmt.Errorf("failed to fetch URL: %w", err)
+		return "", pageMetadata{}, "", fmt.Errorf("failed to fetch URL: %w", err)
 	}
 	defer resp.Body.Close()
+	if err := checkLLMHost(resp.Request.URL.String()); err != nil {
+		return "", pageMetadata{}, "", err
+	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", "", "", fmt.Errorf("HTTP error: %s", resp.Status)
+		return "", pageMetadata{}, "", fmt.Errorf("HTTP error: %s", resp.Status)
 	}
 
 	// Read the body
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", "", "", fmt.Errorf("failed to read response: %w", err)
+		return "", pageMetadata{}, "", fmt.Errorf("failed to read response: %w", err)
 	}
 
 	htmlContent = string(body)
 
-	// Extract title from HTML
-	title = extractTitle(htmlContent)
-	if title == "" {
-		title = parsedURL.Host
+	// Extract structured metadata (JSON-LD, OpenGraph, Twitter card)
+	meta = extractPageMetadata(htmlContent, urlStr)
+	if meta.Title == "" {
+		meta.Title = parsedURL.Host
 	}
 
 	// Basic HTML to text conversion (strip tags)
 	content = stripHTMLTags(htmlContent)
 
-	return content, title, htmlContent, nil
+	return content, meta, htmlContent, nil
 }
 
-func extractTitle(html string) string {
-	// Try to extract <title> tag
-	titleRegex := regexp.MustCompile(`<title[^>]*>([^<]+)</title>`)
-	matches := titleRegex.FindStringSubmatch(html)
-	if len(matches) > 1 {
-		return strings.TrimSpace(matches[1])
-	}
+// maxPageChars caps the text kept from a page (~12.5k tokens)
+const maxPageChars = 50000
 
-	// Try og:title meta tag
-	ogTitleRegex := regexp.MustCompile(`<meta[^>]*property="og:title"[^>]*content="([^"]+)"`)
-	matches = ogTitleRegex.FindStringSubmatch(html)
-	if len(matches) > 1 {
-		return strings.TrimSpace(matches[1])
+func stripHTMLTags(html string) string {
+	text := pageText(html)
+	if len(text) > maxPageChars {
+		text = text[:maxPageChars] + "... [content truncated]"
 	}
-
-	return ""
+	return text
 }
 
-func stripHTMLTags(html string) string {
+// pageText is the readable text of a page, before stripHTMLTags cuts it to size
+func pageText(html string) string {
 	// Try to extract main article content first
 	articleContent := extractArticleContent(html)
 	if articleContent != "" {
@@ -670,15 +1348,7 @@ func stripHTMLTags(html string) string {
 	spaceRegex := regexp.MustCompile(`\s+`)
 	text = spaceRegex.ReplaceAllString(text, " ")
 
-	text = strings.TrimSpace(text)
-
-	// Truncate if still too large (max ~50k characters = ~12.5k tokens roughly)
-	maxChars := 50000
-	if len(text) > maxChars {
-		text = text[:maxChars] + "... [content truncated]"
-	}
-
-	return text
+	return strings.TrimSpace(text)
 }
 
 func extractArticleContent(html string) string {
@@ -720,12 +1390,6 @@ func sanitizeFilename(s string) string {
 func processImageWithName(srcPath, baseName, basePath string) (string, error) {
 	ext := filepath.Ext(srcPath)
 	imageName := fmt.Sprintf("%s%s", baseName, ext)
-	destPath := filepath.Join(basePath, "assets", "images", "site", imageName)
-
-	// Ensure destination directory exists
-	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
-		return "", err
-	}
 
 	// Copy image file
 	data, err := os.ReadFile(srcPath)
@@ -733,59 +1397,7 @@ func processImageWithName(srcPath, baseName, basePath string) (string, error) {
 		return "", err
 	}
 
-	if err := os.WriteFile(destPath, data, 0644); err != nil {
-		return "", err
-	}
-
-	return imageName, nil
-}
-
-func extractBestImage(html, baseURL string) string {
-	// Try Open Graph image first (most reliable for hero images)
-	ogImageRegex := regexp.MustCompile(`<meta[^>]*property=["']og:image["'][^>]*content=["']([^"']+)["']`)
-	matches := ogImageRegex.FindStringSubmatch(html)
-	if len(matches) > 1 {
-		return makeAbsoluteURL(matches[1], baseURL)
-	}
-
-	// Try Twitter card image
-	twitterImageRegex := regexp.MustCompile(`<meta[^>]*name=["']twitter:image["'][^>]*content=["']([^"']+)["']`)
-	matches = twitterImageRegex.FindStringSubmatch(html)
-	if len(matches) > 1 {
-		return makeAbsoluteURL(matches[1], baseURL)
-	}
-
-	// Try to find large images in the article content
-	// Look for images with common hero/featured image patterns
-	heroPatterns := []string{
-		`<img[^>]*class=["'][^"']*hero[^"']*["'][^>]*src=["']([^"']+)["']`,
-		`<img[^>]*class=["'][^"']*featured[^"']*["'][^>]*src=["']([^"']+)["']`,
-		`<img[^>]*class=["'][^"']*main[^"']*["'][^>]*src=["']([^"']+)["']`,
-		`<img[^>]*src=["']([^"']+)["'][^>]*class=["'][^"']*hero[^"']*["']`,
-		`<img[^>]*src=["']([^"']+)["'][^>]*class=["'][^"']*featured[^"']*["']`,
-	}
-
-	for _, pattern := range heroPatterns {
-		regex := regexp.MustCompile(pattern)
-		matches = regex.FindStringSubmatch(html)
-		if len(matches) > 1 {
-			return makeAbsoluteURL(matches[1], baseURL)
-		}
-	}
-
-	// Fallback: Find first img tag in article content
-	articleImgRegex := regexp.MustCompile(`<article[^>]*>.*?<img[^>]*src=["']([^"']+)["']`)
-	matches = articleImgRegex.FindStringSubmatch(html)
-	if len(matches) > 1 {
-		imgURL := matches[1]
-		// Filter out tracking pixels, icons, etc.
-		if !isValidImageURL(imgURL) {
-			return ""
-		}
-		return makeAbsoluteURL(imgURL, baseURL)
-	}
-
-	return ""
+	return writeSiteImage(basePath, imageName, data)
 }
 
 func makeAbsoluteURL(imageURL, baseURL string) string {
@@ -846,22 +1458,27 @@ func isValidImageURL(imageURL string) bool {
 	return hasValidExt
 }
 
-func downloadAndProcessWebImage(imageURL, baseName, basePath string) (string, error) {
+func downloadAndProcessWebImage(imageURL, baseName, basePath string) (imageName, animationName string, err error) {
 	// Download the image
 	resp, err := http.Get(imageURL)
 	if err != nil {
-		return "", fmt.Errorf("failed to download image: %w", err)
+		return "", "", fmt.Errorf("failed to download image: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("HTTP error downloading image: %s", resp.Status)
+		return "", "", fmt.Errorf("HTTP error downloading image: %s", resp.Status)
 	}
 
 	// Read image data
 	imageData, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read image: %w", err)
+		return "", "", fmt.Errorf("failed to read image: %w", err)
+	}
+
+	// GIF demos are converted instead of being copied verbatim as heroes
+	if isGIF(imageData) {
+		return processGIFHero(imageData, baseName, basePath)
 	}
 
 	// Determine file extension from URL or content-type
@@ -882,20 +1499,12 @@ func downloadAndProcessWebImage(imageURL, baseName, basePath string) (string, er
 		}
 	}
 
-	imageName := fmt.Sprintf("%s%s", baseName, ext)
-	destPath := filepath.Join(basePath, "assets", "images", "site", imageName)
-
-	// Ensure destination directory exists
-	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
-		return "", err
-	}
-
-	// Write image file
-	if err := os.WriteFile(destPath, imageData, 0644); err != nil {
-		return "", err
+	imageName, err = writeSiteImage(basePath, fmt.Sprintf("%s%s", baseName, ext), imageData)
+	if err != nil {
+		return "", "", err
 	}
 
-	return imageName, nil
+	return imageName, "", nil
 }
 
 func extractImageExtension(imageURL string) string {
@@ -919,24 +1528,19 @@ func extractImageExtension(imageURL string) string {
 	return ""
 }
 
-func generateFromWebsite(ctx context.Context, apiKey, promptTemplate, urlStr, title, content, userTags, heroImage, model string) (postContent, filename string, err error) {
+func generateFromWebsite(ctx context.Context, apiKey, promptTemplate, urlStr string, meta pageMetadata, content, userTags, heroImage string, heroAttr imageAttribution, model string) (postContent, filename string, err error) {
 	client := openai.NewClient(apiKey)
 
 	// Build context for the AI
-	websiteContext := fmt.Sprintf(`
-Website URL: %s
-Title: %s
-
-Content:
-%s
-`, urlStr, title, content)
+	websiteContext := websitePromptContext(urlStr, meta, content)
 
 	// Get current date for the post
-	currentDate := time.Now().Format("2006-01-02")
+	currentDate := postDateString()
 
 	heroImageInfo := ""
 	if heroImage != "" {
 		heroImageInfo = fmt.Sprintf("\nHero image available: %s (use path: /images/site/%s)", heroImage, heroImage)
+		heroImageInfo += heroFigureInstructions(heroImage, heroAttr)
 	}
 
 	userPrompt := fmt.Sprintf(`%s
@@ -961,7 +1565,7 @@ Generate a complete Hugo markdown post following the style guide above.
 			return ""
 		}())
 
-	resp, err := client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+	request := openai.ChatCompletionRequest{
 		Model: model,
 		Messages: []openai.ChatCompletionMessage{
 			{
@@ -973,8 +1577,11 @@ Generate a complete Hugo markdown post following the style guide above.
 				Content: userPrompt,
 			},
 		},
-		Temperature: 0.7,
-	})
+		Temperature: generationTemperature,
+	}
+
+	budgetPrompt(userPrompt)
+	resp, err := createChatCompletion(withStreaming(ctx), client, request)
 
 	if err != nil {
 		return "", "", fmt.Errorf("OpenAI API error: %w\n\nTroubleshooting:\n- Check your API key is valid\n- Verify your OpenAI account has credits: https://platform.openai.com/usage\n- Try a different model with --model gpt-4o-mini\n- Check rate limits: https://platform.openai.com/account/limits", err)
@@ -984,20 +1591,23 @@ Generate a complete Hugo markdown post following the style guide above.
 		return "", "", fmt.Errorf("no response from OpenAI")
 	}
 
-	postContent = resp.Choices[0].Message.Content
+	// A post cut off at the output limit is continued, not written half-finished
+	if postContent, err = continueTruncated(withStreaming(ctx), client, request, resp); err != nil {
+		return "", "", err
+	}
 
 	// Generate filename from content
 	filename, err = generateFilename(ctx, client, postContent, model)
 	if err != nil {
 		// Fallback to sanitized title if filename generation fails
 		logError("Failed to generate filename, using article title: %v", err)
-		filename = sanitizeFilename(title)
+		filename = sanitizeFilename(meta.Title)
 	}
 
 	return postContent, filename, nil
 }
 
-func researchTopic(ctx context.Context, apiKey, topic, model string) (researchContent, title string, err error) {
+func researchTopic(ctx context.Context, apiKey, topic, guidance, model string) (researchContent, title string, err error) {
 	client := openai.NewClient(apiKey)
 
 	// Use OpenAI to research the topic and gather comprehensive information
@@ -1016,7 +1626,25 @@ Please provide:
 8. Current trends or future directions
 9. Real-world examples
 
-Organize the information clearly and comprehensively. This will be used as research material for writing a blog post.`, topic)
+Organize the information clearly and comprehensively. This will be used as research material for writing a blog post.%s`, topic, guidance)
+	systemPrompt := "You are a knowledgeable research assistant who provides comprehensive, accurate information on technical topics. Provide detailed, well-organized research material."
+
+	files, err := loadResearchFiles(researchFiles)
+	if err != nil {
+		return "", "", err
+	}
+	if useGroundedResearch(model) {
+		logInfo("🌐 Researching with %s's web search", model)
+		researchContent, err = groundedResearch(ctx, apiKey, systemPrompt, researchPrompt, model, files)
+		if err == nil {
+			return researchContent, topic, nil
+		}
+		if errors.Is(err, ErrAuth) {
+			return "", "", err
+		}
+		logError("Grounded research failed, researching from the model's knowledge instead: %v", err)
+	}
+	researchPrompt += researchFilesText(files)
 
 	// Build request with model-specific parameters
 	request := openai.ChatCompletionRequest{
@@ -1024,7 +1652,7 @@ Organize the information clearly and comprehensively. This will be used as resea
 		Messages: []openai.ChatCompletionMessage{
 			{
 				Role:    openai.ChatMessageRoleSystem,
-				Content: "You are a knowledgeable research assistant who provides comprehensive, accurate information on technical topics. Provide detailed, well-organized research material.",
+				Content: systemPrompt,
 			},
 			{
 				Role:    openai.ChatMessageRoleUser,
@@ -1035,7 +1663,7 @@ Organize the information clearly and comprehensively. This will be used as resea
 		MaxTokens:   4000,
 	}
 
-	resp, err := client.CreateChatCompletion(ctx, request)
+	resp, err := createChatCompletion(ctx, client, request)
 
 	if err != nil {
 		return "", "", fmt.Errorf("research API error: %w", err)
@@ -1054,23 +1682,14 @@ Organize the information clearly and comprehensively. This will be used as resea
 func generateFromResearch(ctx context.Context, apiKey, promptTemplate, topic, title, researchContent, userTags, heroImage, model string) (postContent, filename string, err error) {
 	client := openai.NewClient(apiKey)
 
-	// Truncate research content if too large (keep first 12000 chars ~ 3000 tokens)
-	maxResearchChars := 12000
-	if len(researchContent) > maxResearchChars {
-		logInfo("Research content is %d chars, truncating to %d chars", len(researchContent), maxResearchChars)
-		researchContent = researchContent[:maxResearchChars] + "\n\n[Research content truncated for length]"
-	}
+	// Keep the research material to 12000 chars (~3000 tokens)
+	researchContent = fitSource(ctx, apiKey, "source", researchContent, topic, 12000)
 
 	// Build context for the AI
-	researchContext := fmt.Sprintf(`
-Research Topic: %s
-
-Research Material:
-%s
-`, topic, researchContent)
+	researchContext := researchPromptContext(topic, researchContent)
 
 	// Get current date for the post
-	currentDate := time.Now().Format("2006-01-02")
+	currentDate := postDateString()
 
 	heroImageInfo := ""
 	if heroImage != "" {
@@ -1113,11 +1732,12 @@ Generate a complete Hugo markdown post following the style guide above.
 				Content: userPrompt,
 			},
 		},
-		Temperature: 0.7,
+		Temperature: generationTemperature,
 		MaxTokens:   3000,
 	}
 
-	resp, err := client.CreateChatCompletion(ctx, request)
+	budgetPrompt(userPrompt)
+	resp, err := createChatCompletion(withStreaming(ctx), client, request)
 
 	if err != nil {
 		return "", "", fmt.Errorf("OpenAI API error: %w\n\nTroubleshooting:\n- Check your API key is valid\n- Verify your OpenAI account has credits: https://platform.openai.com/usage\n- Try a different model with --model gpt-4o-mini\n- Check rate limits: https://platform.openai.com/account/limits", err)
@@ -1127,10 +1747,14 @@ Generate a complete Hugo markdown post following the style guide above.
 		return "", "", fmt.Errorf("no response from OpenAI")
 	}
 
-	postContent = resp.Choices[0].Message.Content
-
 	// Debug: Log response details
 	logInfo("Response finish reason: %s", resp.Choices[0].FinishReason)
+
+	// A post cut off at MaxTokens is continued, not written half-finished
+	if postContent, err = continueTruncated(withStreaming(ctx), client, request, resp); err != nil {
+		return "", "", err
+	}
+
 	logInfo("Content length: %d characters", len(postContent))
 
 	// Check if content is empty
@@ -1157,32 +1781,43 @@ Generate a complete Hugo markdown post following the style guide above.
 	return postContent, filename, nil
 }
 
-func generateHeroImage(ctx context.Context, apiKey, postContent, filename, basePath string) (string, error) {
+func generateHeroImage(ctx context.Context, apiKey, imagePrompt, filename, basePath string, candidates int) (string, error) {
 	client := openai.NewClient(apiKey)
 
-	// Extract the title and key themes from the post to create a good prompt
-	imagePrompt := createImagePrompt(postContent)
-
 	logInfo("🖼️  Image prompt: %s", imagePrompt)
 
-	// Generate image with DALL-E (landscape format)
-	resp, err := client.CreateImage(ctx, openai.ImageRequest{
-		Prompt:         imagePrompt,
-		N:              1,
-		Size:           openai.CreateImageSize1792x1024, // Landscape format
-		ResponseFormat: openai.CreateImageResponseFormatURL,
-		Model:          openai.CreateImageModelDallE3,
-	})
+	// DALL-E 3 returns one image per request, so candidates are separate calls.
+	// Without a terminal to pick in, extra candidates would be wasted.
+	if candidates < 1 || !stdinIsTerminal() {
+		candidates = 1
+	}
+	var imageURLs []string
+	for i := 0; i < candidates; i++ {
+		// Generate image with DALL-E (landscape format)
+		resp, err := client.CreateImage(ctx, openai.ImageRequest{
+			Prompt:         imagePrompt,
+			N:              1,
+			Size:           openai.CreateImageSize1792x1024, // Landscape format
+			ResponseFormat: openai.CreateImageResponseFormatURL,
+			Model:          openai.CreateImageModelDallE3,
+		})
+		if err != nil {
+			return "", fmt.Errorf("DALL-E API error: %w", err)
+		}
+		if len(resp.Data) == 0 {
+			return "", fmt.Errorf("no image generated")
+		}
+		imageURLs = append(imageURLs, resp.Data[0].URL)
+	}
 
+	choice, err := chooseImageCandidate(imageURLs)
 	if err != nil {
-		return "", fmt.Errorf("DALL-E API error: %w", err)
+		return "", err
 	}
-
-	if len(resp.Data) == 0 {
-		return "", fmt.Errorf("no image generated")
+	if choice < 0 {
+		return "", fmt.Errorf("no candidate chosen")
 	}
-
-	imageURL := resp.Data[0].URL
+	imageURL := imageURLs[choice]
 
 	// Download the generated image
 	imgResp, err := http.Get(imageURL)
@@ -1202,20 +1837,7 @@ func generateHeroImage(ctx context.Context, apiKey, postContent, filename, baseP
 	}
 
 	// Save with .png extension (DALL-E returns PNG)
-	imageName := fmt.Sprintf("%s.png", filename)
-	destPath := filepath.Join(basePath, "assets", "images", "site", imageName)
-
-	// Ensure destination directory exists
-	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
-		return "", err
-	}
-
-	// Write image file
-	if err := os.WriteFile(destPath, imageData, 0644); err != nil {
-		return "", err
-	}
-
-	return imageName, nil
+	return writeSiteImage(basePath, fmt.Sprintf("%s.png", filename), imageData)
 }
 
 func createImagePrompt(postContent string) string {
@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// GIF hero handling modes for --gif-hero
+const (
+	gifHeroStatic   = "static"   // extract a representative frame as a PNG hero
+	gifHeroAnimated = "animated" // static poster hero plus an MP4 embedded via shortcode
+	gifHeroKeep     = "keep"     // copy the GIF verbatim (previous behavior)
+)
+
+var gifHeroMode string
+
+// gifVideoShortcode is installed into the site when animated heroes are used
+const gifVideoShortcode = `{{/* Installed by megafone: renders a GIF-style looping video */}}
+<figure class="gif-video">
+  <video autoplay loop muted playsinline preload="metadata"{{ with .Get "poster" }} poster="{{ . }}"{{ end }}>
+    <source src="{{ .Get "src" }}" type="video/mp4">
+  </video>
+  {{ with .Get "caption" }}<figcaption>{{ . }}</figcaption>{{ end }}
+</figure>
+`
+
+func isGIF(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("GIF87a")) || bytes.HasPrefix(data, []byte("GIF89a"))
+}
+
+// processGIFHero converts a downloaded GIF according to --gif-hero. It returns
+// the hero image name and, in animated mode, the name of the MP4 to embed.
+func processGIFHero(data []byte, baseName, basePath string) (imageName, animationName string, err error) {
+	if gifHeroMode == gifHeroKeep {
+		imageName, err = writeSiteImage(basePath, baseName+".gif", data)
+		return imageName, "", err
+	}
+
+	frame, err := representativeGIFFrame(data)
+	if err != nil {
+		return "", "", err
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, frame); err != nil {
+		return "", "", fmt.Errorf("failed to encode GIF frame: %w", err)
+	}
+	imageName, err = writeSiteImage(basePath, baseName+".png", buf.Bytes())
+	if err != nil {
+		return "", "", err
+	}
+	logInfo("🎞️  Extracted static hero frame from GIF (%d KB → %d KB)", len(data)/1024, buf.Len()/1024)
+
+	if gifHeroMode != gifHeroAnimated {
+		return imageName, "", nil
+	}
+
+	animationName, err = convertGIFToMP4(data, baseName, basePath)
+	if err != nil {
+		logError("Failed to convert GIF to MP4, using static hero only: %v", err)
+		return imageName, "", nil
+	}
+	if err := ensureGIFVideoShortcode(basePath); err != nil {
+		logError("Failed to install gif-video shortcode: %v", err)
+	}
+	return imageName, animationName, nil
+}
+
+// representativeGIFFrame composites the animation up to roughly a third of the
+// way through, past title cards and before fade-outs
+func representativeGIFFrame(data []byte) (image.Image, error) {
+	g, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode GIF: %w", err)
+	}
+	if len(g.Image) == 0 {
+		return nil, fmt.Errorf("GIF has no frames")
+	}
+
+	bounds := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	if bounds.Empty() {
+		bounds = g.Image[0].Bounds()
+	}
+	canvas := image.NewRGBA(bounds)
+
+	// Frames may be partial deltas, so replay disposal rules up to the target
+	target := len(g.Image) / 3
+	for i := 0; i <= target; i++ {
+		frame := g.Image[i]
+		var previous *image.RGBA
+		disposal := byte(0)
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+		if disposal == gif.DisposalPrevious {
+			previous = image.NewRGBA(bounds)
+			draw.Draw(previous, bounds, canvas, bounds.Min, draw.Src)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+		if i == target {
+			break
+		}
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+
+	return canvas, nil
+}
+
+// convertGIFToMP4 shells out to ffmpeg to produce a small looping MP4
+func convertGIFToMP4(data []byte, baseName, basePath string) (string, error) {
+	ffmpeg, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return "", fmt.Errorf("ffmpeg not found in PATH")
+	}
+
+	tmpDir, err := os.MkdirTemp("", "megafone-gif")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	inPath := filepath.Join(tmpDir, "in.gif")
+	if err := os.WriteFile(inPath, data, 0644); err != nil {
+		return "", err
+	}
+
+	videoName := baseName + ".mp4"
+	outPath := filepath.Join(siteImageDir(basePath), videoName)
+	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+		return "", err
+	}
+
+	// yuv420p and even dimensions are required for broad browser support
+	cmd := exec.Command(ffmpeg, "-y", "-loglevel", "error", "-i", inPath,
+		"-movflags", "+faststart", "-pix_fmt", "yuv420p",
+		"-vf", "scale=trunc(iw/2)*2:trunc(ih/2)*2", "-an", outPath)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("ffmpeg failed: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	if info, err := os.Stat(outPath); err == nil {
+		logInfo("🎬 Converted GIF to MP4 (%d KB → %d KB)", len(data)/1024, info.Size()/1024)
+	}
+	return videoName, nil
+}
+
+// ensureGIFVideoShortcode installs layouts/shortcodes/gif-video.html unless the site already has one
+func ensureGIFVideoShortcode(basePath string) error {
+	return ensureSiteShortcode(basePath, "gif-video", gifVideoShortcode)
+}
+
+// ensureSiteShortcode installs layouts/shortcodes/<name>.html unless the site already has one
+func ensureSiteShortcode(basePath, name, body string) error {
+	p := filepath.Join(basePath, "layouts", "shortcodes", name+".html")
+	if _, err := os.Stat(p); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	logInfo("Installing %s shortcode: %s", name, p)
+	if err := os.WriteFile(p, []byte(body), 0644); err != nil {
+		return err
+	}
+	recordSiteChange("created", p, "", false)
+	return nil
+}
+
+// embedHeroAnimation places the animated demo after the post's opening paragraph
+func embedHeroAnimation(content, animationName, posterName string) string {
+	shortcode := fmt.Sprintf(`{{< gif-video src="/images/site/%s" poster="/images/site/%s" >}}`, animationName, posterName)
+	return insertAfterFirstParagraph(content, shortcode)
+}
+
+// insertAfterFirstParagraph adds a block after the post's opening paragraph,
+// or at the end of a post without one
+func insertAfterFirstParagraph(content, block string) string {
+	fm := frontMatterBlock(content)
+	bodyStart := len(fm)
+	if fm != "" {
+		bodyStart += len("\n---")
+	}
+
+	// First blank line after the first non-empty body paragraph
+	paragraphEnd := regexp.MustCompile(`\S[^\n]*(\n[^\n]+)*\n\s*\n`)
+	if loc := paragraphEnd.FindStringIndex(content[bodyStart:]); loc != nil {
+		insertAt := bodyStart + loc[1]
+		return content[:insertAt] + block + "\n\n" + content[insertAt:]
+	}
+	return strings.TrimRight(content, "\n") + "\n\n" + block + "\n"
+}
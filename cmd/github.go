@@ -0,0 +1,17 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/google/go-github/v57/github"
+)
+
+// newGitHubClient returns a GitHub API client, authenticated with GITHUB_TOKEN
+// when it is set to lift the 60 requests/hour anonymous limit
+func newGitHubClient() *github.Client {
+	client := github.NewClient(nil)
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		client = client.WithAuthToken(token)
+	}
+	return client
+}
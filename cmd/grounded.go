@@ -0,0 +1,364 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Research backends
+const (
+	researchBackendAuto     = "auto"     // grounded when the model supports it, else chat
+	researchBackendChat     = "chat"     // the model's own knowledge, over Chat Completions
+	researchBackendGrounded = "grounded" // the provider's native web search and file inputs
+)
+
+var (
+	researchBackend string
+	researchFiles   []string
+)
+
+var (
+	openAIResponsesURL   = "https://api.openai.com/v1/responses"
+	anthropicMessagesURL = "https://api.anthropic.com/v1/messages"
+)
+
+// groundingCapabilities is what a model's provider API offers natively for
+// research: web search it runs itself, and PDF inputs
+type groundingCapabilities struct {
+	WebSearch  bool
+	FileInputs bool
+}
+
+// groundingFor returns the native research tools available to a model.
+// OpenAI's web search runs in the Responses API for the GPT-4o, GPT-4.1,
+// GPT-5, and o-series models; Anthropic's runs in the Messages API.
+func groundingFor(name string) groundingCapabilities {
+	if modelProvider(name) == "anthropic" {
+		return groundingCapabilities{WebSearch: true, FileInputs: true}
+	}
+	for _, prefix := range []string{"gpt-4o", "gpt-4.1", "gpt-5", "o3", "o4"} {
+		if strings.HasPrefix(name, prefix) {
+			return groundingCapabilities{WebSearch: true, FileInputs: true}
+		}
+	}
+	return groundingCapabilities{}
+}
+
+// checkResearchBackend rejects an unknown --research-backend before any work is done
+func checkResearchBackend() error {
+	switch researchBackend {
+	case researchBackendAuto, researchBackendChat, researchBackendGrounded:
+		return nil
+	default:
+		return fmt.Errorf("invalid --research-backend value %q (use auto, chat, or grounded)", researchBackend)
+	}
+}
+
+// useGroundedResearch decides the backend for a model: grounded when asked
+// for or, with auto, when the model's provider can search the web itself
+func useGroundedResearch(name string) bool {
+	caps := groundingFor(name)
+	switch researchBackend {
+	case researchBackendGrounded:
+		if !caps.WebSearch {
+			logInfo("⚠️  %s has no native web search; researching from the model's knowledge", name)
+		}
+		return caps.WebSearch
+	case researchBackendAuto:
+		return caps.WebSearch
+	default:
+		return false
+	}
+}
+
+// researchFile is a --research-file: PDFs go to the model as files where the
+// provider accepts them, anything else is read as text
+type researchFile struct {
+	Name string
+	PDF  []byte
+	Text string
+}
+
+func loadResearchFiles(paths []string) ([]researchFile, error) {
+	var files []researchFile
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read research file: %w", err)
+		}
+		f := researchFile{Name: filepath.Base(p)}
+		if strings.EqualFold(filepath.Ext(p), ".pdf") {
+			f.PDF = data
+		} else {
+			f.Text = string(data)
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// researchFilesText inlines the text files for a backend without file
+// inputs; PDFs it can't read are skipped with a warning
+func researchFilesText(files []researchFile) string {
+	var b strings.Builder
+	for _, f := range files {
+		if f.PDF != nil {
+			logInfo("⚠️  Skipping %s: this backend can't read PDFs (use --research-backend grounded)", f.Name)
+			continue
+		}
+		fmt.Fprintf(&b, "\n\n--- %s ---\n%s", f.Name, f.Text)
+	}
+	if b.Len() == 0 {
+		return ""
+	}
+	return "\n\nReference files provided by the author:" + b.String()
+}
+
+// groundedCitation is a web page the model's answer cited
+type groundedCitation struct {
+	URL   string
+	Title string
+}
+
+// groundedResearch researches with the provider's own web search and file
+// inputs, returning the research with its cited sources listed after it
+func groundedResearch(ctx context.Context, apiKey, system, prompt, name string, files []researchFile) (string, error) {
+	provider := modelProvider(name)
+	estimate := (len(system)+len(prompt))/4 + 4000
+	release, err := paceRequest(ctx, provider, estimate)
+	if err != nil {
+		return "", err
+	}
+	var text string
+	var citations []groundedCitation
+	var usage openai.Usage
+	if provider == "anthropic" {
+		text, citations, usage, err = anthropicGroundedResearch(ctx, system, prompt, name, files)
+	} else {
+		text, citations, usage, err = openAIGroundedResearch(ctx, apiKey, system, prompt, name, files)
+	}
+	release()
+	if err != nil {
+		return "", err
+	}
+	recordUsage(provider, estimate, openai.ChatCompletionResponse{Usage: usage})
+	progressAddTokens(usage.TotalTokens)
+	logVerbose("%s used %d tokens for grounded research", name, usage.TotalTokens)
+
+	if strings.TrimSpace(text) == "" {
+		return "", fmt.Errorf("%s returned no research", name)
+	}
+	sources := groundedSourcesSection(citations)
+	logInfo("🔎 Grounded research cited %d sources", strings.Count(sources, "\n- "))
+	return text + sources, nil
+}
+
+// groundedSourcesSection lists the cited pages once each, so the post can link them
+func groundedSourcesSection(citations []groundedCitation) string {
+	seen := make(map[string]bool)
+	var b strings.Builder
+	for _, c := range citations {
+		if c.URL == "" || seen[c.URL] {
+			continue
+		}
+		seen[c.URL] = true
+		fmt.Fprintf(&b, "\n- %s: %s", firstNonEmpty(c.Title, c.URL), c.URL)
+	}
+	if b.Len() == 0 {
+		return ""
+	}
+	return "\n\nSources found by web search (cite these by URL where the post uses them):" + b.String()
+}
+
+// openAIGroundedResearch calls the Responses API with its web_search tool
+func openAIGroundedResearch(ctx context.Context, apiKey, system, prompt, name string, files []researchFile) (string, []groundedCitation, openai.Usage, error) {
+	content := []map[string]interface{}{}
+	for _, f := range files {
+		if f.PDF != nil {
+			content = append(content, map[string]interface{}{
+				"type":      "input_file",
+				"filename":  f.Name,
+				"file_data": "data:application/pdf;base64," + base64.StdEncoding.EncodeToString(f.PDF),
+			})
+		} else {
+			content = append(content, map[string]interface{}{"type": "input_text", "text": fmt.Sprintf("--- %s ---\n%s", f.Name, f.Text)})
+		}
+	}
+	content = append(content, map[string]interface{}{"type": "input_text", "text": prompt})
+	body := map[string]interface{}{
+		"model":             name,
+		"instructions":      system,
+		"input":             []map[string]interface{}{{"role": "user", "content": content}},
+		"tools":             []map[string]string{{"type": "web_search"}},
+		"max_output_tokens": 4000,
+	}
+
+	var reply struct {
+		Status            string `json:"status"`
+		IncompleteDetails *struct {
+			Reason string `json:"reason"`
+		} `json:"incomplete_details"`
+		Output []struct {
+			Type    string `json:"type"`
+			Content []struct {
+				Type        string `json:"type"`
+				Text        string `json:"text"`
+				Annotations []struct {
+					Type  string `json:"type"`
+					URL   string `json:"url"`
+					Title string `json:"title"`
+				} `json:"annotations"`
+			} `json:"content"`
+		} `json:"output"`
+		Usage struct {
+			InputTokens  int `json:"input_tokens"`
+			OutputTokens int `json:"output_tokens"`
+			TotalTokens  int `json:"total_tokens"`
+		} `json:"usage"`
+	}
+	if err := postProviderJSON(ctx, "OpenAI", openAIResponsesURL, map[string]string{"Authorization": "Bearer " + apiKey}, body, &reply); err != nil {
+		return "", nil, openai.Usage{}, err
+	}
+
+	var text strings.Builder
+	var citations []groundedCitation
+	for _, item := range reply.Output {
+		if item.Type != "message" {
+			continue
+		}
+		for _, c := range item.Content {
+			if c.Type != "output_text" {
+				continue
+			}
+			text.WriteString(c.Text)
+			for _, a := range c.Annotations {
+				if a.Type == "url_citation" {
+					citations = append(citations, groundedCitation{URL: a.URL, Title: a.Title})
+				}
+			}
+		}
+	}
+	if reply.IncompleteDetails != nil {
+		logError("Grounded research stopped early (%s); using what was returned", reply.IncompleteDetails.Reason)
+	}
+	usage := openai.Usage{PromptTokens: reply.Usage.InputTokens, CompletionTokens: reply.Usage.OutputTokens, TotalTokens: reply.Usage.TotalTokens}
+	return text.String(), citations, usage, nil
+}
+
+// anthropicGroundedResearch calls the Messages API with the web_search server tool
+func anthropicGroundedResearch(ctx context.Context, system, prompt, name string, files []researchFile) (string, []groundedCitation, openai.Usage, error) {
+	apiKey := os.Getenv("ANTHROPIC_API_KEY")
+	if apiKey == "" {
+		return "", nil, openai.Usage{}, classify(ErrAuth, fmt.Errorf("ANTHROPIC_API_KEY is required for %s", name))
+	}
+	content := []map[string]interface{}{}
+	for _, f := range files {
+		if f.PDF != nil {
+			content = append(content, map[string]interface{}{
+				"type":   "document",
+				"title":  f.Name,
+				"source": map[string]string{"type": "base64", "media_type": "application/pdf", "data": base64.StdEncoding.EncodeToString(f.PDF)},
+			})
+		} else {
+			content = append(content, map[string]interface{}{
+				"type":   "document",
+				"title":  f.Name,
+				"source": map[string]string{"type": "text", "media_type": "text/plain", "data": f.Text},
+			})
+		}
+	}
+	content = append(content, map[string]interface{}{"type": "text", "text": prompt})
+	body := map[string]interface{}{
+		"model":      name,
+		"max_tokens": 4000,
+		"system":     system,
+		"messages":   []map[string]interface{}{{"role": "user", "content": content}},
+		"tools":      []map[string]interface{}{{"type": "web_search_20250305", "name": "web_search", "max_uses": 5}},
+	}
+
+	var reply struct {
+		Content []struct {
+			Type      string `json:"type"`
+			Text      string `json:"text"`
+			Citations []struct {
+				Type  string `json:"type"`
+				URL   string `json:"url"`
+				Title string `json:"title"`
+			} `json:"citations"`
+		} `json:"content"`
+		StopReason string `json:"stop_reason"`
+		Usage      struct {
+			InputTokens  int `json:"input_tokens"`
+			OutputTokens int `json:"output_tokens"`
+		} `json:"usage"`
+	}
+	headers := map[string]string{"x-api-key": apiKey, "anthropic-version": "2023-06-01"}
+	if err := postProviderJSON(ctx, "Anthropic", anthropicMessagesURL, headers, body, &reply); err != nil {
+		return "", nil, openai.Usage{}, err
+	}
+
+	var text strings.Builder
+	var citations []groundedCitation
+	for _, c := range reply.Content {
+		if c.Type != "text" {
+			continue
+		}
+		text.WriteString(c.Text)
+		for _, cite := range c.Citations {
+			if cite.Type == "web_search_result_location" {
+				citations = append(citations, groundedCitation{URL: cite.URL, Title: cite.Title})
+			}
+		}
+	}
+	if reply.StopReason == "max_tokens" {
+		logError("Grounded research hit the output limit; using what was returned")
+	}
+	usage := openai.Usage{PromptTokens: reply.Usage.InputTokens, CompletionTokens: reply.Usage.OutputTokens, TotalTokens: reply.Usage.InputTokens + reply.Usage.OutputTokens}
+	return text.String(), citations, usage, nil
+}
+
+// postProviderJSON posts to a model provider's API outside the chat client,
+// turning rejected credentials into ErrAuth
+func postProviderJSON(ctx context.Context, service, endpoint string, headers map[string]string, body, v interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, val := range headers {
+		req.Header.Set(k, val)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s API error: %w", service, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return classify(ErrAuth, fmt.Errorf("%s rejected the API key: %s", service, resp.Status))
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("%s API error: %s: %s", service, resp.Status, strings.TrimSpace(string(data)))
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("invalid %s response: %w", service, err)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+var postHighlights bool
+
+// quoteReplacer folds typographic quotes and dashes so a highlight still
+// matches the post after the model normalizes its punctuation
+var quoteReplacer = strings.NewReplacer("“", `"`, "”", `"`, "‘", "'", "’", "'", "—", "-", "–", "-")
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&postHighlights, "highlights", true, "Store 2-3 quotable sentences from generated posts in highlights front matter, for social posts and cards")
+}
+
+// applyHighlights stores a generated post's quotable sentences in its
+// highlights front matter. A failed call is logged and the post is kept as it is.
+func applyHighlights(ctx context.Context, client *openai.Client, content string) string {
+	if !postHighlights || len(frontMatterList(content, "highlights")) > 0 {
+		return content
+	}
+	highlights, err := extractHighlights(ctx, client, content)
+	if err != nil {
+		logError("Failed to extract highlights: %v", err)
+		return content
+	}
+	if len(highlights) == 0 {
+		logInfo("⚠️  No quotable sentences found for highlights")
+		return content
+	}
+	logInfo("💬 Highlights: %d quotable sentences", len(highlights))
+	return setFrontMatterList(content, "highlights", highlights)
+}
+
+// extractHighlights asks for the post's most quotable sentences and keeps the
+// ones that appear in it word for word, so a pull quote is never misattributed
+func extractHighlights(ctx context.Context, client *openai.Client, content string) ([]string, error) {
+	text, err := exportPlain(content)
+	if err != nil {
+		return nil, err
+	}
+
+	prompt := fmt.Sprintf(`Post:
+%s
+
+Pick the 3 sentences from this post that would work best as pull quotes on a social
+media post or a share card: specific, surprising, or opinionated, and understandable
+without the rest of the post. Copy each sentence exactly as written, under 200 characters.
+Respond with only a JSON object: {"highlights": ["...", "...", "..."]}`, summarizeTokens(8000, text))
+
+	resp, err := createChatCompletion(ctx, client, openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: "You are an editor choosing pull quotes. You quote sentences verbatim and never paraphrase. You output only JSON.",
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: prompt,
+			},
+		},
+		Temperature: 0.2,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no response from model")
+	}
+
+	var reply struct {
+		Highlights []string `json:"highlights"`
+	}
+	if err := decodeModelJSON(resp.Choices[0].Message.Content, &reply); err != nil {
+		return nil, fmt.Errorf("failed to parse highlights: %w", err)
+	}
+
+	normalize := func(s string) string {
+		return strings.Join(strings.Fields(quoteReplacer.Replace(s)), " ")
+	}
+	body := normalize(text)
+	var highlights []string
+	for _, h := range reply.Highlights {
+		h = strings.TrimSpace(h)
+		if h == "" || len([]rune(h)) > 240 {
+			continue
+		}
+		if !strings.Contains(body, normalize(h)) {
+			logVerbose("Dropping highlight not found in the post: %s", h)
+			continue
+		}
+		highlights = append(highlights, h)
+		if len(highlights) == 3 {
+			break
+		}
+	}
+	return highlights, nil
+}
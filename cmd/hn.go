@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var hnComments int
+
+var hnFirebaseAPI = "https://hacker-news.firebaseio.com/v0"
+
+// hnFirebaseItem is a story or comment from the official Firebase HN API
+type hnFirebaseItem struct {
+	ID          int    `json:"id"`
+	Type        string `json:"type"`
+	By          string `json:"by"`
+	Time        int64  `json:"time"`
+	Title       string `json:"title"`
+	URL         string `json:"url"`
+	Text        string `json:"text"`
+	Score       int    `json:"score"`
+	Descendants int    `json:"descendants"`
+	Kids        []int  `json:"kids"`
+	Deleted     bool   `json:"deleted"`
+	Dead        bool   `json:"dead"`
+}
+
+// hnItemID returns the item id of a news.ycombinator.com/item?id= URL, or ""
+// for anything else
+func hnItemID(input string) string {
+	u, err := url.Parse(input)
+	if err != nil || strings.TrimPrefix(u.Hostname(), "www.") != "news.ycombinator.com" || u.Path != "/item" {
+		return ""
+	}
+	if _, err := strconv.Atoi(u.Query().Get("id")); err != nil {
+		return ""
+	}
+	return u.Query().Get("id")
+}
+
+func fetchHNFirebaseItem(ctx context.Context, id string) (*hnFirebaseItem, error) {
+	var item hnFirebaseItem
+	if err := getSocialJSON(ctx, "Hacker News", hnFirebaseAPI+"/item/"+id+".json", nil, &item); err != nil {
+		return nil, err
+	}
+	if item.ID == 0 {
+		return nil, fmt.Errorf("Hacker News item %s not found", id)
+	}
+	return &item, nil
+}
+
+// fetchHNThread loads a story and its top n comments, in the order HN ranks
+// them on the page. Deleted and flagged comments are skipped.
+func fetchHNThread(ctx context.Context, id string, n int) (*hnFirebaseItem, []discussionComment, error) {
+	story, err := fetchHNFirebaseItem(ctx, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch HN story: %w", err)
+	}
+	kids := story.Kids
+	if len(kids) > n {
+		kids = kids[:n]
+	}
+
+	items := make([]*hnFirebaseItem, len(kids))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 8)
+	for i, kid := range kids {
+		wg.Add(1)
+		go func(i, kid int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			item, err := fetchHNFirebaseItem(ctx, strconv.Itoa(kid))
+			if err != nil {
+				logVerbose("Skipping HN comment %d: %v", kid, err)
+				return
+			}
+			items[i] = item
+		}(i, kid)
+	}
+	wg.Wait()
+
+	var comments []discussionComment
+	for _, item := range items {
+		if item == nil || item.Deleted || item.Dead {
+			continue
+		}
+		if text := htmlToText(item.Text); text != "" {
+			comments = append(comments, discussionComment{Author: item.By, Text: text, Replies: len(item.Kids)})
+		}
+	}
+	return story, comments, nil
+}
+
+// fetchHNDiscussionThread reads an HN story and its top comments for a post
+func fetchHNDiscussionThread(ctx context.Context, topicURL, id string) (*discussionThread, error) {
+	logInfo("🟧 Fetching Hacker News thread %s...", id)
+	story, comments, err := fetchHNThread(ctx, id, hnComments)
+	if err != nil {
+		return nil, err
+	}
+	logInfo("💬 %q: %d points, read %d of %d comments", story.Title, story.Score, len(comments), story.Descendants)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n\n## Hacker News Discussion\nThread: %s\n", topicURL)
+	fmt.Fprintf(&b, "%q, submitted by %s on %s: %d points, %d comments. The top %d comments, in the order HN ranks them:\n\n",
+		story.Title, story.By, time.Unix(story.Time, 0).UTC().Format("2006-01-02"), story.Score, story.Descendants, len(comments))
+	for _, c := range comments {
+		fmt.Fprintf(&b, "- %s (%d replies): %s\n", c.Author, c.Replies, trimToLength(strings.ReplaceAll(c.Text, "\n", " "), threadMaxCommentChars))
+	}
+
+	return &discussionThread{
+		Title:      story.Title,
+		Author:     story.By,
+		Site:       "Hacker News",
+		LinkURL:    story.URL,
+		Text:       fmt.Sprintf("# %s\n\nPosted by %s on Hacker News\n\n%s", story.Title, story.By, htmlToText(story.Text)),
+		Discussion: b.String(),
+	}, nil
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// hooksConfig lists shell commands run at points in the generation lifecycle
+type hooksConfig struct {
+	PreGenerate  hookList `yaml:"pre_generate"`
+	PostGenerate hookList `yaml:"post_generate"`
+}
+
+// hookList accepts either a single command or a list of commands
+type hookList []string
+
+func (h *hookList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*h = hookList{node.Value}
+		return nil
+	}
+	var list []string
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+	*h = list
+	return nil
+}
+
+// hookRun is the metadata available to hook commands
+type hookRun struct {
+	Post   string
+	Source string
+	Site   string
+}
+
+// runHooks runs each command for an event through sh in the site directory.
+// {{post}}, {{source}}, {{site}}, {{run_id}}, {{command}}, and {{model}} are replaced
+// with shell-quoted values, which are also exported as MEGAFONE_HOOK_* variables.
+// The first failing command stops the run.
+func runHooks(event string, commands hookList, run hookRun) error {
+	if len(commands) == 0 {
+		return nil
+	}
+
+	values := map[string]string{
+		"post":    run.Post,
+		"source":  run.Source,
+		"site":    run.Site,
+		"run_id":  journalRunID,
+		"command": journalCommand,
+		"model":   effectiveModel(),
+	}
+	funcs := template.FuncMap{}
+	env := os.Environ()
+	for name, value := range values {
+		value := value
+		funcs[name] = func() string { return shellQuote(value) }
+		env = append(env, "MEGAFONE_HOOK_"+strings.ToUpper(name)+"="+value)
+	}
+	env = append(env, "MEGAFONE_HOOK_EVENT="+event)
+
+	for _, command := range commands {
+		tmpl, err := template.New(event).Funcs(funcs).Parse(command)
+		if err != nil {
+			return fmt.Errorf("invalid %s hook %q: %w", event, command, err)
+		}
+		var expanded bytes.Buffer
+		if err := tmpl.Execute(&expanded, nil); err != nil {
+			return fmt.Errorf("invalid %s hook %q: %w", event, command, err)
+		}
+
+		logInfo("🪝 %s: %s", event, expanded.String())
+		c := exec.Command("sh", "-c", expanded.String())
+		c.Dir = run.Site
+		c.Env = env
+		c.Stdout = os.Stdout
+		c.Stderr = os.Stderr
+		if err := c.Run(); err != nil {
+			return fmt.Errorf("%s hook %q failed: %w", event, expanded.String(), err)
+		}
+	}
+	return nil
+}
+
+// shellQuote wraps s in single quotes for sh
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
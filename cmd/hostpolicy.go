@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// hostPolicyConfig is the host_policy block of megafone.yaml. Entries are
+// hostnames and cover their subdomains; "*.example.com" is the same as
+// "example.com".
+type hostPolicyConfig struct {
+	Allow    []string `yaml:"allow"`     // when set, the only hosts megafone may request
+	Deny     []string `yaml:"deny"`      // hosts megafone never requests
+	LLMAllow []string `yaml:"llm_allow"` // when set, the only hosts whose content may go into a prompt
+	LLMDeny  []string `yaml:"llm_deny"`  // hosts whose content never goes into a prompt
+}
+
+// modelAPIHosts are always allowed by host_policy.allow, since nothing works
+// without them. host_policy.deny still applies.
+var modelAPIHosts = []string{"api.openai.com", "api.anthropic.com"}
+
+// enableHostPolicy wraps the default transport so every request is checked
+// against host_policy. Clients that bring their own transport are not checked.
+func enableHostPolicy() {
+	p := appConfig.HostPolicy
+	if len(p.Allow) == 0 && len(p.Deny) == 0 {
+		return
+	}
+	if _, ok := http.DefaultTransport.(*policyTransport); ok {
+		return
+	}
+	http.DefaultTransport = &policyTransport{base: http.DefaultTransport}
+}
+
+// newPolicyClient returns an HTTP client whose requests, redirects included,
+// are always checked against host_policy, whichever transport is the default
+func newPolicyClient(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: &policyTransport{}}
+}
+
+// policyTransport checks requests against host_policy before passing them to
+// base, or to whatever http.DefaultTransport is at the time when base is nil
+type policyTransport struct {
+	base http.RoundTripper
+}
+
+func (t *policyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := checkFetchHost(req.URL.String()); err != nil {
+		return nil, err
+	}
+	if t.base == nil {
+		return http.DefaultTransport.RoundTrip(req)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// checkFetchHost returns a policy error when host_policy forbids requesting rawURL
+func checkFetchHost(rawURL string) error {
+	host := policyHost(rawURL)
+	p := appConfig.HostPolicy
+	if hostListed(host, p.Deny) {
+		return policyError("requests to %s are denied by host_policy.deny in %s", host, configPath)
+	}
+	if len(p.Allow) > 0 && !hostListed(host, p.Allow) && !hostListed(host, modelAPIHosts) {
+		return policyError("%s is not in host_policy.allow in %s", host, configPath)
+	}
+	return nil
+}
+
+// checkLLMHost returns a policy error when host_policy forbids sending
+// content from rawURL to a model
+func checkLLMHost(rawURL string) error {
+	host := policyHost(rawURL)
+	p := appConfig.HostPolicy
+	if hostListed(host, p.LLMDeny) {
+		return policyError("content from %s may not be sent to a model (host_policy.llm_deny in %s)", host, configPath)
+	}
+	if len(p.LLMAllow) > 0 && !hostListed(host, p.LLMAllow) {
+		return policyError("content from %s may not be sent to a model: it is not in host_policy.llm_allow in %s", host, configPath)
+	}
+	return nil
+}
+
+func policyError(format string, v ...interface{}) error {
+	return classify(ErrPolicy, fmt.Errorf("host policy: "+format, v...))
+}
+
+// policyHost returns the lowercased hostname of a URL, which may lack a scheme
+func policyHost(rawURL string) string {
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return strings.ToLower(rawURL)
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// hostListed reports whether host is one of hosts or a subdomain of one
+func hostListed(host string, hosts []string) bool {
+	for _, h := range hosts {
+		h = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(h), "*."))
+		if h != "" && (host == h || strings.HasSuffix(host, "."+h)) {
+			return true
+		}
+	}
+	return false
+}
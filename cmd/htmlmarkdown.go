@@ -0,0 +1,327 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	youtubeEmbedRegex  = regexp.MustCompile(`youtube(?:-nocookie)?\.com/embed/([\w-]+)`)
+	codeLanguageRegex  = regexp.MustCompile(`(?:language|lang)-([\w+#-]+)`)
+	markdownEscapeRepl = strings.NewReplacer(`\`, `\\`, "*", `\*`, "`", "\\`", "[", `\[`, "]", `\]`)
+)
+
+// htmlToMarkdown converts post HTML from another CMS into Hugo markdown.
+// Figures become figure shortcodes and YouTube embeds become youtube shortcodes;
+// markup with no markdown equivalent is kept as raw HTML.
+func htmlToMarkdown(source string) (string, error) {
+	nodes, err := html.ParseFragment(strings.NewReader(source), &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body})
+	if err != nil {
+		return "", fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	for _, n := range nodes {
+		body.AppendChild(n)
+	}
+	var b strings.Builder
+	c := &markdownConverter{out: &b}
+	c.children(body)
+	out := blankLinesRegex.ReplaceAllString(b.String(), "\n\n")
+	return strings.TrimSpace(out) + "\n", nil
+}
+
+type markdownConverter struct {
+	out        *strings.Builder
+	listDepth  int
+	quoteDepth int
+}
+
+func (c *markdownConverter) write(s string) { c.out.WriteString(s) }
+
+// paragraph writes a block separated from its neighbours by a blank line
+func (c *markdownConverter) paragraph(text string) {
+	text = strings.ReplaceAll(strings.TrimSpace(text), "  \n ", "  \n")
+	if text == "" {
+		return
+	}
+	if c.quoteDepth > 0 {
+		prefix := strings.Repeat("> ", c.quoteDepth)
+		text = prefix + strings.ReplaceAll(text, "\n", "\n"+prefix)
+	}
+	c.write("\n\n" + text + "\n\n")
+}
+
+func (c *markdownConverter) block(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		c.paragraph(collapseSpace(n.Data))
+		return
+	case html.CommentNode:
+		return // WordPress block markers like <!-- wp:paragraph -->
+	case html.ElementNode:
+	default:
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		level := int(n.Data[1] - '0')
+		// The post title is the only H1, so headings shift down one level
+		level = min(level+1, 6)
+		c.paragraph(strings.Repeat("#", level) + " " + c.inlineText(n))
+	case atom.P:
+		c.paragraph(c.inlineText(n))
+	case atom.Ul, atom.Ol:
+		c.list(n)
+	case atom.Blockquote:
+		c.quoteDepth++
+		c.children(n)
+		c.quoteDepth--
+	case atom.Pre:
+		c.codeBlock(n)
+	case atom.Hr:
+		c.paragraph("---")
+	case atom.Figure:
+		c.figure(n)
+	case atom.Img:
+		c.paragraph(c.inline(n))
+	case atom.Iframe:
+		if m := youtubeEmbedRegex.FindStringSubmatch(attr(n, "src")); m != nil {
+			c.paragraph(fmt.Sprintf(`{{< youtube %s >}}`, m[1]))
+		} else {
+			c.paragraph(renderHTML(n))
+		}
+	case atom.Table, atom.Video, atom.Audio:
+		c.paragraph(renderHTML(n))
+	case atom.Script, atom.Style, atom.Noscript:
+	case atom.Div, atom.Section, atom.Article, atom.Header, atom.Footer, atom.Main, atom.Aside, atom.Body:
+		c.children(n)
+	default:
+		c.paragraph(c.inlineText(n))
+	}
+}
+
+func (c *markdownConverter) children(n *html.Node) {
+	// Runs of inline nodes (text, links, emphasis) between blocks form one paragraph
+	var run strings.Builder
+	flush := func() {
+		c.paragraph(run.String())
+		run.Reset()
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if isInlineNode(child) {
+			run.WriteString(c.inline(child))
+			continue
+		}
+		flush()
+		c.block(child)
+	}
+	flush()
+}
+
+func isInlineNode(n *html.Node) bool {
+	if n.Type == html.TextNode {
+		return true
+	}
+	if n.Type != html.ElementNode {
+		return false
+	}
+	switch n.DataAtom {
+	case atom.A, atom.Strong, atom.B, atom.Em, atom.I, atom.Code, atom.Br, atom.Span, atom.Img,
+		atom.Sup, atom.Sub, atom.Mark, atom.Small, atom.Abbr, atom.Del, atom.S, atom.U, atom.Kbd:
+		return true
+	}
+	return false
+}
+
+// inlineText renders a node's children as inline markdown
+func (c *markdownConverter) inlineText(n *html.Node) string {
+	var b strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		b.WriteString(c.inline(child))
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func (c *markdownConverter) inline(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return markdownEscapeRepl.Replace(collapseSpace(n.Data))
+	}
+	if n.Type != html.ElementNode {
+		return ""
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		return "  \n"
+	case atom.Strong, atom.B:
+		return wrapInline("**", c.inlineText(n))
+	case atom.Em, atom.I:
+		return wrapInline("*", c.inlineText(n))
+	case atom.Del, atom.S:
+		return wrapInline("~~", c.inlineText(n))
+	case atom.Code, atom.Kbd:
+		return "`" + textContent(n) + "`"
+	case atom.A:
+		text := c.inlineText(n)
+		href := attr(n, "href")
+		if href == "" {
+			return text
+		}
+		if text == "" {
+			text = href
+		}
+		return fmt.Sprintf("[%s](%s)", text, href)
+	case atom.Img:
+		return fmt.Sprintf("![%s](%s)", attr(n, "alt"), attr(n, "src"))
+	case atom.Script, atom.Style:
+		return ""
+	}
+	// Lists and other blocks nested inside inline content keep their text
+	return c.inlineText(n)
+}
+
+func wrapInline(marker, text string) string {
+	if text == "" {
+		return ""
+	}
+	return marker + text + marker
+}
+
+func (c *markdownConverter) list(n *html.Node) {
+	c.paragraph(c.listItems(n))
+}
+
+// listItems renders a list's items, indenting nested lists under their parent item
+func (c *markdownConverter) listItems(n *html.Node) string {
+	ordered := n.DataAtom == atom.Ol
+	indent := strings.Repeat("  ", c.listDepth)
+	var items []string
+	i := 1
+	for li := n.FirstChild; li != nil; li = li.NextSibling {
+		if li.Type != html.ElementNode || li.DataAtom != atom.Li {
+			continue
+		}
+		marker := "- "
+		if ordered {
+			marker = fmt.Sprintf("%d. ", i)
+		}
+		i++
+
+		var text strings.Builder
+		var nested []string
+		for child := li.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type == html.ElementNode && (child.DataAtom == atom.Ul || child.DataAtom == atom.Ol) {
+				c.listDepth++
+				nested = append(nested, c.listItems(child))
+				c.listDepth--
+				continue
+			}
+			if child.Type == html.ElementNode && child.DataAtom == atom.P {
+				text.WriteString(c.inlineText(child) + " ")
+				continue
+			}
+			text.WriteString(c.inline(child))
+		}
+		item := indent + marker + strings.TrimSpace(text.String())
+		for _, sub := range nested {
+			item += "\n" + sub
+		}
+		items = append(items, item)
+	}
+	return strings.Join(items, "\n")
+}
+
+func (c *markdownConverter) codeBlock(n *html.Node) {
+	lang := ""
+	if m := codeLanguageRegex.FindStringSubmatch(attr(n, "class")); m != nil {
+		lang = m[1]
+	}
+	if code := n.FirstChild; code != nil && code.DataAtom == atom.Code {
+		if m := codeLanguageRegex.FindStringSubmatch(attr(code, "class")); m != nil {
+			lang = m[1]
+		}
+	}
+	body := strings.Trim(textContent(n), "\n")
+	c.paragraph("```" + lang + "\n" + body + "\n```")
+}
+
+// figure turns <figure><img><figcaption> into Hugo's figure shortcode
+func (c *markdownConverter) figure(n *html.Node) {
+	img := findElement(n, atom.Img)
+	if img == nil {
+		c.children(n)
+		return
+	}
+	caption := ""
+	if fc := findElement(n, atom.Figcaption); fc != nil {
+		caption = collapseSpace(textContent(fc))
+	}
+	sc := fmt.Sprintf(`{{< figure src=%q alt=%q`, attr(img, "src"), attr(img, "alt"))
+	if caption != "" {
+		sc += fmt.Sprintf(` caption=%q`, strings.TrimSpace(caption))
+	}
+	c.paragraph(sc + " >}}")
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func findElement(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if found := findElement(child, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		b.WriteString(textContent(child))
+	}
+	return b.String()
+}
+
+func renderHTML(n *html.Node) string {
+	var b strings.Builder
+	html.Render(&b, n)
+	return b.String()
+}
+
+func collapseSpace(s string) string {
+	if strings.TrimSpace(s) == "" {
+		if s == "" {
+			return ""
+		}
+		return " "
+	}
+	lead := strings.TrimLeft(s, " \t\n\r") != s
+	trail := strings.TrimRight(s, " \t\n\r") != s
+	out := strings.Join(strings.Fields(s), " ")
+	if lead {
+		out = " " + out
+	}
+	if trail {
+		out += " "
+	}
+	return out
+}
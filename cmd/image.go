@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"os"
 	"path/filepath"
 	"strings"
 
@@ -14,7 +13,7 @@ import (
 )
 
 // findBestImage searches the README for images and selects the best one
-func findBestImage(ctx context.Context, ghClient *github.Client, apiKey, owner, repo, model string) (string, error) {
+func findBestImage(ctx context.Context, ghClient *github.Client, apiKey, owner, repo, model string, minWidth int) (string, error) {
 	// Fetch README content
 	readme, _, err := ghClient.Repositories.GetReadme(ctx, owner, repo, nil)
 	if err != nil {
@@ -35,11 +34,35 @@ func findBestImage(ctx context.Context, ghClient *github.Client, apiKey, owner,
 
 	logInfo("Found %d images in README", len(imageURLs))
 
+	// Drop badges, avatars, and other small images by their real pixel dimensions
+	candidates := make([]pageImage, 0, len(imageURLs))
+	for _, u := range imageURLs {
+		candidates = append(candidates, pageImage{URL: u, Source: "readme"})
+	}
+	ranked := rankImageCandidates(candidates, minWidth)
+	if len(ranked) == 0 {
+		return "", fmt.Errorf("no README images at least %dpx wide", minWidth)
+	}
+	imageURLs = imageURLs[:0]
+	for _, img := range ranked {
+		imageURLs = append(imageURLs, img.URL)
+	}
+	logInfo("%d images passed dimension filtering", len(imageURLs))
+
 	// If only one image, return it
 	if len(imageURLs) == 1 {
 		return imageURLs[0], nil
 	}
 
+	// Let the user pick from the top candidates instead of asking the model
+	if imageCandidates > 1 {
+		choice, err := chooseImageCandidate(imageURLs)
+		if err != nil || choice < 0 {
+			return "", err
+		}
+		return imageURLs[choice], nil
+	}
+
 	// Use OpenAI to select the best image
 	bestImage, err := selectBestImageWithAI(ctx, apiKey, imageURLs, model)
 	if err != nil {
@@ -161,7 +184,7 @@ Choose the BEST image for a blog post hero image. Prefer:
 
 Respond with ONLY the number (1-5) of the best image. No explanation.`, imageList.String())
 
-	resp, err := client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+	resp, err := createChatCompletion(ctx, client, openai.ChatCompletionRequest{
 		Model: model,
 		Messages: []openai.ChatCompletionMessage{
 			{
@@ -197,16 +220,26 @@ Respond with ONLY the number (1-5) of the best image. No explanation.`, imageLis
 	return imageURLs[selectedIndex-1], nil
 }
 
-func downloadAndProcessImage(imageURL, repoName, basePath string) (string, error) {
+func downloadAndProcessImage(imageURL, repoName, basePath string) (imageName, animationName string, err error) {
 	// Download the image
 	resp, err := http.Get(imageURL)
 	if err != nil {
-		return "", fmt.Errorf("failed to download image: %w", err)
+		return "", "", fmt.Errorf("failed to download image: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("failed to download image: status %d", resp.StatusCode)
+		return "", "", fmt.Errorf("failed to download image: status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", "", err
+	}
+
+	// GIF demos are converted instead of being copied verbatim as heroes
+	if isGIF(data) {
+		return processGIFHero(data, strings.ToLower(repoName), basePath)
 	}
 
 	// Determine file extension from URL
@@ -216,27 +249,11 @@ func downloadAndProcessImage(imageURL, repoName, basePath string) (string, error
 	}
 
 	// Create destination filename
-	imageName := fmt.Sprintf("%s%s", strings.ToLower(repoName), ext)
-	destPath := filepath.Join(basePath, "assets", "images", "site", imageName)
-
-	// Ensure destination directory exists
-	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
-		return "", err
-	}
-
-	// Create the file
-	outFile, err := os.Create(destPath)
+	imageName, err = writeSiteImage(basePath, fmt.Sprintf("%s%s", strings.ToLower(repoName), ext), data)
 	if err != nil {
-		return "", err
-	}
-	defer outFile.Close()
-
-	// Copy the data
-	_, err = io.Copy(outFile, resp.Body)
-	if err != nil {
-		return "", err
+		return "", "", err
 	}
 
 	logSuccess("Downloaded and saved image: %s", imageName)
-	return imageName, nil
+	return imageName, "", nil
 }
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// imageCandidates is how many hero options to offer with --image-candidates
+var imageCandidates int
+
+// chooseImageCandidate downloads up to imageCandidates images into a temporary
+// preview and asks which one to use. It returns the index picked, or -1 for none.
+// Nothing is written to the site; without a terminal the first candidate wins.
+func chooseImageCandidate(urls []string) (int, error) {
+	if len(urls) > imageCandidates {
+		urls = urls[:imageCandidates]
+	}
+	if len(urls) <= 1 || !stdinIsTerminal() {
+		return 0, nil
+	}
+
+	dir, err := os.MkdirTemp("", "megafone-candidates-")
+	if err != nil {
+		return -1, err
+	}
+	defer os.RemoveAll(dir)
+
+	var page strings.Builder
+	page.WriteString("<!DOCTYPE html><html><head><meta charset=\"utf-8\"><title>Hero candidates</title></head><body style=\"font-family:sans-serif\">\n")
+	fmt.Println("\n" + strings.Repeat("=", 80))
+	fmt.Println("Hero image candidates:")
+	fmt.Println(strings.Repeat("=", 80))
+	for i, u := range urls {
+		data, err := downloadCandidate(u)
+		if err != nil {
+			fmt.Printf("  %d. %s (preview failed: %v)\n", i+1, u, err)
+			continue
+		}
+		name := fmt.Sprintf("%d%s", i+1, firstNonEmpty(extractImageExtension(u), ".png"))
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			return -1, err
+		}
+		fmt.Fprintf(&page, "<h2>%d</h2><p>%s</p><img src=\"%s\" style=\"max-width:100%%\">\n", i+1, html.EscapeString(u), name)
+
+		fmt.Printf("  %d. %s\n", i+1, u)
+		printInlineImage(name, data)
+	}
+	page.WriteString("</body></html>\n")
+
+	previewPath := filepath.Join(dir, "index.html")
+	if err := os.WriteFile(previewPath, []byte(page.String()), 0644); err != nil {
+		return -1, err
+	}
+	fmt.Printf("\nPreview: file://%s\n", previewPath)
+
+	for {
+		fmt.Printf("Pick a hero image [1-%d, 0 for none]: ", len(urls))
+		answer, err := stdinReader.ReadString('\n')
+		if err != nil {
+			return 0, nil
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(answer))
+		if err == nil && n >= 0 && n <= len(urls) {
+			return n - 1, nil
+		}
+	}
+}
+
+func downloadCandidate(u string) ([]byte, error) {
+	resp, err := http.Get(u)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP error: %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// printInlineImage shows an image in terminals that speak the iTerm2 inline
+// image protocol (iTerm2, WezTerm); elsewhere the HTML preview is used
+func printInlineImage(name string, data []byte) {
+	switch os.Getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm":
+	default:
+		return
+	}
+	fmt.Printf("\x1b]1337;File=name=%s;size=%d;width=60;preserveAspectRatio=1;inline=1:%s\a\n",
+		base64.StdEncoding.EncodeToString([]byte(name)), len(data), base64.StdEncoding.EncodeToString(data))
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// Enough bytes to cover the header of every format we care about, including
+// JPEGs with large EXIF blocks before the SOF marker
+const imageProbeBytes = 128 * 1024
+
+var probeClient = &http.Client{Timeout: 15 * time.Second}
+
+// probeImageDimensions fetches the start of an image with a ranged GET and
+// decodes just the header to learn its pixel dimensions
+func probeImageDimensions(imageURL string) (width, height int, err error) {
+	req, err := http.NewRequest(http.MethodGet, imageURL, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", imageProbeBytes-1))
+
+	resp, err := probeClient.Do(req)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to probe image: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		return 0, 0, fmt.Errorf("HTTP error probing image: %s", resp.Status)
+	}
+
+	// Servers that ignore Range send the whole file; only read what we need
+	header, err := io.ReadAll(io.LimitReader(resp.Body, imageProbeBytes))
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read image header: %w", err)
+	}
+
+	return decodeImageDimensions(header)
+}
+
+// decodeImageDimensions reads pixel dimensions from an image header
+func decodeImageDimensions(header []byte) (width, height int, err error) {
+	if w, h, ok := decodeWebPDimensions(header); ok {
+		return w, h, nil
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(header))
+	if err != nil {
+		return 0, 0, fmt.Errorf("unrecognized image header: %w", err)
+	}
+	return cfg.Width, cfg.Height, nil
+}
+
+// decodeWebPDimensions handles the three WebP chunk layouts (the standard library
+// has no WebP decoder)
+func decodeWebPDimensions(b []byte) (width, height int, ok bool) {
+	if len(b) < 30 || string(b[0:4]) != "RIFF" || string(b[8:12]) != "WEBP" {
+		return 0, 0, false
+	}
+
+	switch string(b[12:16]) {
+	case "VP8 ":
+		// Lossy: 14-bit dimensions after the frame start code
+		w := int(binary.LittleEndian.Uint16(b[26:28]) & 0x3fff)
+		h := int(binary.LittleEndian.Uint16(b[28:30]) & 0x3fff)
+		return w, h, true
+	case "VP8L":
+		// Lossless: 14-bit width-1 and height-1 packed after the signature byte
+		bits := binary.LittleEndian.Uint32(b[21:25])
+		return int(bits&0x3fff) + 1, int((bits>>14)&0x3fff) + 1, true
+	case "VP8X":
+		// Extended: 24-bit canvas width-1 and height-1
+		w := int(b[24]) | int(b[25])<<8 | int(b[26])<<16
+		h := int(b[27]) | int(b[28])<<8 | int(b[29])<<16
+		return w + 1, h + 1, true
+	}
+	return 0, 0, false
+}
+
+// rankImageCandidates probes each candidate's real dimensions, drops anything
+// narrower than minWidth or with a badge/banner aspect ratio, and orders the
+// rest by size weighted toward a landscape hero shape
+func rankImageCandidates(candidates []pageImage, minWidth int) []pageImage {
+	type scored struct {
+		img   pageImage
+		score float64
+	}
+
+	var ranked []scored
+	for _, img := range candidates {
+		w, h, err := probeImageDimensions(img.URL)
+		if err != nil {
+			// Fall back to declared dimensions when the probe fails
+			logInfo("Could not probe %s: %v", img.URL, err)
+			w, h = img.Width, img.Height
+		}
+		img.Width, img.Height = w, h
+
+		if w == 0 || h == 0 {
+			logInfo("Skipping image with unknown dimensions: %s", img.URL)
+			continue
+		}
+		if w < minWidth {
+			logInfo("Skipping small image (%dx%d < %dpx wide): %s", w, h, minWidth, img.URL)
+			continue
+		}
+
+		ratio := float64(w) / float64(h)
+		if ratio < 0.5 || ratio > 4 {
+			logInfo("Skipping image with unsuitable aspect ratio (%dx%d): %s", w, h, img.URL)
+			continue
+		}
+
+		// Penalize distance from 16:9 on a log scale so 4:3 and 2:1 still do well
+		aspectFactor := 1 / (1 + 2*math.Abs(math.Log(ratio/(16.0/9.0))))
+		ranked = append(ranked, scored{img: img, score: float64(w*h) * aspectFactor})
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].score > ranked[j].score
+	})
+
+	result := make([]pageImage, 0, len(ranked))
+	for _, r := range ranked {
+		result = append(result, r.img)
+	}
+	return result
+}
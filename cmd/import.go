@@ -0,0 +1,660 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/spf13/cobra"
+)
+
+var (
+	importSourceURL  string
+	importImages     bool
+	importAICleanup  bool
+	importSkipDrafts bool
+	importOverwrite  bool
+	importLimit      int
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import posts from another CMS into the Hugo site",
+}
+
+var importWordPressCmd = &cobra.Command{
+	Use:   "wordpress <export.xml>",
+	Short: "Import posts from a WordPress export (WXR) file",
+	Long: `Converts the posts in a WordPress export (Tools → Export → Posts) into Hugo
+posts under content/posts/en, with title, date, description, tags, categories,
+featured image, and an alias for the old URL in the front matter.
+
+Images the posts reference are downloaded into assets/images/site and the
+links rewritten. [caption] shortcodes become figure shortcodes and YouTube
+embeds become youtube shortcodes.
+
+Examples:
+  megafone import wordpress export.xml -s ~/hugo
+  megafone import wordpress export.xml -s ~/hugo --ai-cleanup --skip-drafts`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runImport(cmd, "wordpress", args[0]); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+var importGhostCmd = &cobra.Command{
+	Use:   "ghost <export.json>",
+	Short: "Import posts from a Ghost JSON export",
+	Long: `Converts the posts in a Ghost export (Settings → Labs → Export) into Hugo
+posts under content/posts/en. Ghost stores image URLs as __GHOST_URL__/...,
+so pass --source-url with the blog's address to download them.
+
+Examples:
+  megafone import ghost blog.ghost.json -s ~/hugo --source-url https://blog.example.com`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runImport(cmd, "ghost", args[0]); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+	importCmd.AddCommand(importWordPressCmd)
+	importCmd.AddCommand(importGhostCmd)
+
+	for _, c := range []*cobra.Command{importWordPressCmd, importGhostCmd} {
+		c.Flags().StringVarP(&siteSource, "site-source", "s", "", "Path to local Hugo site repository")
+		c.Flags().StringVar(&importSourceURL, "source-url", "", "Address of the old blog, for resolving relative and __GHOST_URL__ image links")
+		c.Flags().BoolVar(&importImages, "images", true, "Download referenced images into assets/images/site")
+		c.Flags().BoolVar(&importAICleanup, "ai-cleanup", false, "Have the model tidy each converted post's markdown (wording is kept)")
+		c.Flags().BoolVar(&importSkipDrafts, "skip-drafts", false, "Don't import drafts and unpublished posts")
+		c.Flags().BoolVar(&importOverwrite, "overwrite", false, "Replace posts that already exist in the site")
+		c.Flags().IntVar(&importLimit, "limit", 0, "Import at most this many posts (0 for all)")
+		c.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "List what would be imported without writing files")
+		c.Flags().StringVarP(&model, "model", "m", "gpt-4o-mini", "OpenAI model for --ai-cleanup")
+	}
+}
+
+// importedPost is a post read from another CMS's export, before conversion
+type importedPost struct {
+	Title       string
+	Slug        string
+	Date        time.Time
+	Draft       bool
+	Tags        []string
+	Categories  []string
+	Description string
+	HTML        string
+	Hero        string
+	OriginalURL string
+}
+
+func runImport(cmd *cobra.Command, kind, exportPath string) error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+
+	basePath, err := resolveSitePath()
+	if err != nil {
+		return err
+	}
+
+	var client *openai.Client
+	if importAICleanup && !dryRun {
+		apiKey, err := getOpenAIKey(cmd)
+		if err != nil {
+			return err
+		}
+		client = openai.NewClient(apiKey)
+	}
+
+	f, err := os.Open(exportPath)
+	if err != nil {
+		return classify(ErrSource, fmt.Errorf("failed to open export: %w", err))
+	}
+	defer f.Close()
+
+	var posts []importedPost
+	var siteURL string
+	if kind == "wordpress" {
+		posts, siteURL, err = parseWordPressExport(f)
+	} else {
+		posts, err = parseGhostExport(f)
+	}
+	if err != nil {
+		return classify(ErrSource, fmt.Errorf("failed to parse %s export: %w", kind, err))
+	}
+	if importSourceURL != "" {
+		siteURL = strings.TrimRight(importSourceURL, "/")
+	}
+	logInfo("📥 Found %d posts in %s", len(posts), exportPath)
+
+	ctx := context.Background()
+	postDir := postsDir(basePath)
+	imported, skipped := 0, 0
+	for _, p := range posts {
+		if importLimit > 0 && imported >= importLimit {
+			break
+		}
+		if p.Draft && importSkipDrafts {
+			skipped++
+			continue
+		}
+		if p.Slug == "" {
+			p.Slug = sanitizeFilename(p.Title)
+		}
+		if p.Slug == "" || strings.TrimSpace(p.HTML) == "" {
+			logInfo("Skipping %q: no slug or content", p.Title)
+			skipped++
+			continue
+		}
+
+		postPath := filepath.Join(postDir, p.Slug+".md")
+		if _, err := os.Stat(postPath); err == nil && !importOverwrite {
+			logInfo("Skipping %s: already exists (use --overwrite to replace)", postPath)
+			skipped++
+			continue
+		}
+
+		if dryRun {
+			state := "published"
+			if p.Draft {
+				state = "draft"
+			}
+			fmt.Printf("%s  %-9s %s → %s\n", p.Date.Format("2006-01-02"), state, p.Title, postPath)
+			imported++
+			continue
+		}
+
+		content, err := convertImportedPost(ctx, client, basePath, siteURL, kind, p)
+		if err != nil {
+			logError("Failed to import %q: %v", p.Title, err)
+			skipped++
+			continue
+		}
+		if err := os.MkdirAll(postDir, 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(postPath, []byte(content), 0644); err != nil {
+			return fmt.Errorf("failed to write post: %w", err)
+		}
+		recordSiteChange("created", postPath, p.OriginalURL, importAICleanup)
+		logSuccess("✅ Imported %s → %s", p.Title, postPath)
+		imported++
+	}
+
+	if dryRun {
+		logInfo("Dry run: %d posts would be imported, %d skipped", imported, skipped)
+	} else {
+		logSuccess("📥 Imported %d posts, skipped %d", imported, skipped)
+	}
+	return nil
+}
+
+// convertImportedPost turns an imported post into a Hugo markdown file
+func convertImportedPost(ctx context.Context, client *openai.Client, basePath, siteURL, kind string, p importedPost) (string, error) {
+	source := p.HTML
+	if kind == "wordpress" {
+		source = wordPressAutoP(wordPressCaptions(source))
+	}
+	body, err := htmlToMarkdown(source)
+	if err != nil {
+		return "", err
+	}
+
+	hero := p.Hero
+	if importImages {
+		body, hero = localizeImportedImages(basePath, siteURL, p.Slug, body, hero)
+	}
+
+	if client != nil {
+		cleaned, err := cleanupImportedMarkdown(ctx, client, p.Title, body)
+		if err != nil {
+			logError("AI cleanup failed for %q, keeping the plain conversion: %v", p.Title, err)
+		} else {
+			body = cleaned
+		}
+	}
+
+	description := p.Description
+	if description == "" {
+		description = leadParagraph(body)
+	}
+
+	var fm strings.Builder
+	fm.WriteString("---\n")
+	fmt.Fprintf(&fm, "title: %s\n", yamlQuote(p.Title))
+	fmt.Fprintf(&fm, "date: %s\n", p.Date.Format("2006-01-02"))
+	if description != "" {
+		fmt.Fprintf(&fm, "description: %s\n", yamlQuote(description))
+	}
+	fmt.Fprintf(&fm, "tags: %s\n", yamlList(p.Tags))
+	if len(p.Categories) > 0 {
+		fmt.Fprintf(&fm, "categories: %s\n", yamlList(p.Categories))
+	}
+	if hero != "" {
+		fmt.Fprintf(&fm, "hero: %s\n", hero)
+	}
+	if p.Draft {
+		fm.WriteString("draft: true\n")
+	}
+	// Keep the old URL working
+	if u, err := url.Parse(p.OriginalURL); err == nil && u.Path != "" && u.Path != "/" && strings.Trim(u.Path, "/") != "posts/"+p.Slug {
+		fmt.Fprintf(&fm, "aliases: [%s]\n", yamlQuote(u.Path))
+	}
+	fmt.Fprintf(&fm, "imported_from: %s\n", kind)
+	fm.WriteString("---\n\n")
+
+	return fm.String() + body, nil
+}
+
+// leadParagraph returns the post's first prose paragraph as plain text, for use as a description
+func leadParagraph(body string) string {
+	for _, para := range strings.Split(body, "\n\n") {
+		para = strings.TrimSpace(para)
+		if para == "" || strings.ContainsAny(para[:1], "#{`->|<!") || strings.HasPrefix(para, "1. ") {
+			continue
+		}
+		rendered, err := markdownToHTML(para)
+		if err != nil {
+			return ""
+		}
+		return truncateWords(30, strings.Join(strings.Fields(htmlToText(rendered)), " "))
+	}
+	return ""
+}
+
+var (
+	importImageRegex = regexp.MustCompile(`!\[[^\]]*\]\(([^)\s]+)\)|\{\{<\s*figure\s+src="([^"]+)"`)
+	// WordPress serves resized copies as name-1024x768.jpg; the original has no suffix
+	wpSizeSuffixRegex = regexp.MustCompile(`-\d+x\d+(\.\w+)$`)
+)
+
+// localizeImportedImages downloads the post's images into the site and points
+// the markdown and hero at the local copies. Images that fail to download keep
+// their original URL.
+func localizeImportedImages(basePath, siteURL, slug, body, hero string) (string, string) {
+	var srcs []string
+	for _, m := range importImageRegex.FindAllStringSubmatch(body, -1) {
+		srcs = append(srcs, firstNonEmpty(m[1], m[2]))
+	}
+
+	local := make(map[string]string)
+	download := func(src, name string) string {
+		if dest, ok := local[src]; ok {
+			return dest
+		}
+		resolved := resolveImportURL(siteURL, src)
+		if resolved == "" {
+			logInfo("⚠️  Can't resolve image %s (set --source-url)", src)
+			local[src] = ""
+			return ""
+		}
+		data, ext, err := fetchImportImage(resolved)
+		if err != nil {
+			logInfo("⚠️  Keeping remote image %s: %v", resolved, err)
+			local[src] = ""
+			return ""
+		}
+		saved, err := writeSiteImage(basePath, name+ext, data)
+		if err != nil {
+			logError("Failed to save image %s: %v", resolved, err)
+			local[src] = ""
+			return ""
+		}
+		dest := "/images/site/" + saved
+		local[src] = dest
+		return dest
+	}
+
+	if hero != "" {
+		if dest := download(hero, slug); dest != "" {
+			hero = dest
+		}
+	}
+	n := 1
+	for _, src := range srcs {
+		if strings.HasPrefix(src, "/images/site/") {
+			continue
+		}
+		if _, seen := local[src]; seen {
+			continue
+		}
+		if dest := download(src, fmt.Sprintf("%s-%d", slug, n)); dest != "" {
+			body = strings.ReplaceAll(body, "("+src+")", "("+dest+")")
+			body = strings.ReplaceAll(body, `src="`+src+`"`, `src="`+dest+`"`)
+			n++
+		}
+	}
+	return body, hero
+}
+
+// resolveImportURL makes an image link absolute using the old blog's address
+func resolveImportURL(siteURL, src string) string {
+	if strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://") {
+		return src
+	}
+	if siteURL == "" {
+		return ""
+	}
+	if strings.HasPrefix(src, "__GHOST_URL__") {
+		return siteURL + strings.TrimPrefix(src, "__GHOST_URL__")
+	}
+	if strings.HasPrefix(src, "//") {
+		return "https:" + src
+	}
+	return siteURL + "/" + strings.TrimLeft(src, "/")
+}
+
+// fetchImportImage downloads an image, preferring WordPress's full-size original
+func fetchImportImage(imageURL string) ([]byte, string, error) {
+	candidates := []string{imageURL}
+	if original := wpSizeSuffixRegex.ReplaceAllString(imageURL, "$1"); original != imageURL {
+		candidates = []string{original, imageURL}
+	}
+
+	var lastErr error
+	for _, candidate := range candidates {
+		resp, err := http.Get(candidate)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			lastErr = fmt.Errorf("HTTP error: %s", resp.Status)
+			continue
+		}
+		contentType := resp.Header.Get("Content-Type")
+		if !strings.HasPrefix(contentType, "image/") {
+			lastErr = fmt.Errorf("not an image (%s)", contentType)
+			continue
+		}
+
+		ext := strings.ToLower(path.Ext(strings.SplitN(candidate, "?", 2)[0]))
+		if ext == "" || len(ext) > 5 {
+			ext = ".jpg"
+			if exts, _ := mime.ExtensionsByType(contentType); len(exts) > 0 {
+				ext = exts[0]
+			}
+		}
+		return data, ext, nil
+	}
+	return nil, "", lastErr
+}
+
+// cleanupImportedMarkdown asks the model to repair conversion artifacts without rewriting the post
+func cleanupImportedMarkdown(ctx context.Context, client *openai.Client, title, body string) (string, error) {
+	resp, err := createChatCompletion(ctx, client, openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role: openai.ChatMessageRoleSystem,
+				Content: `You clean up blog posts that were converted from HTML to Hugo markdown. Fix conversion artifacts only: broken or oddly nested lists, leftover HTML that has a markdown equivalent, unnecessary backslash escapes, code blocks missing a language, stray empty emphasis, and inconsistent heading levels (use ## and below).
+Do not change the wording, add or remove content, or touch Hugo shortcodes ({{< ... >}}), links, or image paths.
+Return only the corrected markdown body, with no front matter and no code fence around it.`,
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: fmt.Sprintf("Post title: %s\n\n%s", title, body),
+			},
+		},
+		Temperature: 0.1,
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("no response from model")
+	}
+	cleaned := strings.TrimSpace(resp.Choices[0].Message.Content)
+	cleaned = strings.TrimSuffix(strings.TrimPrefix(cleaned, "```markdown\n"), "\n```")
+	if cleaned == "" {
+		return "", fmt.Errorf("model returned an empty post")
+	}
+	return cleaned + "\n", nil
+}
+
+// wxrRSS is the subset of a WordPress eXtended RSS export that megafone reads
+type wxrRSS struct {
+	Channel struct {
+		BaseSiteURL string    `xml:"base_site_url"`
+		Items       []wxrItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+type wxrItem struct {
+	Title         string `xml:"title"`
+	Link          string `xml:"link"`
+	PostID        string `xml:"post_id"`
+	PostDate      string `xml:"post_date"`
+	PostName      string `xml:"post_name"`
+	Status        string `xml:"status"`
+	PostType      string `xml:"post_type"`
+	AttachmentURL string `xml:"attachment_url"`
+	Encoded       []struct {
+		XMLName xml.Name
+		Value   string `xml:",chardata"`
+	} `xml:"encoded"`
+	Categories []struct {
+		Domain string `xml:"domain,attr"`
+		Name   string `xml:",chardata"`
+	} `xml:"category"`
+	Meta []struct {
+		Key   string `xml:"meta_key"`
+		Value string `xml:"meta_value"`
+	} `xml:"postmeta"`
+}
+
+func parseWordPressExport(r io.Reader) ([]importedPost, string, error) {
+	var rss wxrRSS
+	dec := xml.NewDecoder(r)
+	dec.Strict = false
+	if err := dec.Decode(&rss); err != nil {
+		return nil, "", err
+	}
+
+	attachments := make(map[string]string)
+	for _, item := range rss.Channel.Items {
+		if item.PostType == "attachment" && item.AttachmentURL != "" {
+			attachments[item.PostID] = item.AttachmentURL
+		}
+	}
+
+	var posts []importedPost
+	for _, item := range rss.Channel.Items {
+		if item.PostType != "post" || item.Status == "trash" || item.Status == "auto-draft" {
+			continue
+		}
+		p := importedPost{
+			Title:       strings.TrimSpace(item.Title),
+			Slug:        sanitizeFilename(item.PostName),
+			Draft:       item.Status != "publish" && item.Status != "future",
+			OriginalURL: item.Link,
+		}
+		p.Date, _ = time.Parse("2006-01-02 15:04:05", item.PostDate)
+		for _, enc := range item.Encoded {
+			if strings.Contains(enc.XMLName.Space, "excerpt") {
+				p.Description = strings.TrimSpace(htmlToText(enc.Value))
+			} else {
+				p.HTML = enc.Value
+			}
+		}
+		for _, c := range item.Categories {
+			name := strings.TrimSpace(c.Name)
+			switch {
+			case c.Domain == "post_tag":
+				p.Tags = append(p.Tags, name)
+			case c.Domain == "category" && name != "Uncategorized":
+				p.Categories = append(p.Categories, name)
+			}
+		}
+		for _, m := range item.Meta {
+			if m.Key == "_thumbnail_id" {
+				p.Hero = attachments[m.Value]
+			}
+		}
+		posts = append(posts, p)
+	}
+	return posts, strings.TrimRight(rss.Channel.BaseSiteURL, "/"), nil
+}
+
+var (
+	wpCaptionRegex = regexp.MustCompile(`(?s)\[caption[^\]]*\](.*?)\[/caption\]`)
+	wpImageRegex   = regexp.MustCompile(`(?s)^\s*(?:<a[^>]*>)?\s*(<img[^>]*>)\s*(?:</a>)?(.*)$`)
+	wpPreRegex     = regexp.MustCompile(`(?is)<pre.*?</pre>`)
+	wpBlockRegex   = regexp.MustCompile(`(?i)^\s*<(p|div|h[1-6]|ul|ol|li|blockquote|pre|figure|table|hr|iframe|!--)`)
+)
+
+// wordPressCaptions turns [caption]<img> text[/caption] into figure HTML
+func wordPressCaptions(content string) string {
+	return wpCaptionRegex.ReplaceAllStringFunc(content, func(sc string) string {
+		inner := wpCaptionRegex.FindStringSubmatch(sc)[1]
+		m := wpImageRegex.FindStringSubmatch(inner)
+		if m == nil {
+			return inner
+		}
+		return fmt.Sprintf("<figure>%s<figcaption>%s</figcaption></figure>", m[1], strings.TrimSpace(m[2]))
+	})
+}
+
+// wordPressAutoP applies WordPress's paragraph rule to classic-editor posts,
+// which store paragraphs as blank-line-separated text rather than <p> tags
+func wordPressAutoP(content string) string {
+	if strings.Contains(content, "<p>") || strings.Contains(content, "<!-- wp:") {
+		return content
+	}
+
+	var out strings.Builder
+	last := 0
+	for _, loc := range wpPreRegex.FindAllStringIndex(content, -1) {
+		out.WriteString(autoParagraphs(content[last:loc[0]]))
+		out.WriteString(content[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	out.WriteString(autoParagraphs(content[last:]))
+	return out.String()
+}
+
+func autoParagraphs(text string) string {
+	var out []string
+	for _, para := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		para = strings.TrimSpace(para)
+		if para == "" {
+			continue
+		}
+		if wpBlockRegex.MatchString(para) {
+			out = append(out, para)
+			continue
+		}
+		out = append(out, "<p>"+strings.ReplaceAll(para, "\n", "<br>\n")+"</p>")
+	}
+	return strings.Join(out, "\n")
+}
+
+// ghostExport covers both the {"db": [{"data": ...}]} and {"data": ...} export shapes
+type ghostExport struct {
+	DB []struct {
+		Data ghostData `json:"data"`
+	} `json:"db"`
+	Data *ghostData `json:"data"`
+}
+
+type ghostData struct {
+	Posts []struct {
+		ID            ghostID `json:"id"`
+		Title         string  `json:"title"`
+		Slug          string  `json:"slug"`
+		HTML          string  `json:"html"`
+		FeatureImage  string  `json:"feature_image"`
+		Status        string  `json:"status"`
+		Type          string  `json:"type"`
+		Page          bool    `json:"page"`
+		PublishedAt   string  `json:"published_at"`
+		CreatedAt     string  `json:"created_at"`
+		CustomExcerpt string  `json:"custom_excerpt"`
+	} `json:"posts"`
+	Tags []struct {
+		ID   ghostID `json:"id"`
+		Name string  `json:"name"`
+	} `json:"tags"`
+	PostsTags []struct {
+		PostID ghostID `json:"post_id"`
+		TagID  ghostID `json:"tag_id"`
+	} `json:"posts_tags"`
+}
+
+// ghostID accepts both the numeric IDs of old Ghost exports and the string IDs of newer ones
+type ghostID string
+
+func (id *ghostID) UnmarshalJSON(data []byte) error {
+	*id = ghostID(strings.Trim(string(data), `"`))
+	return nil
+}
+
+func parseGhostExport(r io.Reader) ([]importedPost, error) {
+	var export ghostExport
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return nil, err
+	}
+	data := export.Data
+	if data == nil {
+		if len(export.DB) == 0 {
+			return nil, fmt.Errorf("no data in export")
+		}
+		data = &export.DB[0].Data
+	}
+
+	tagNames := make(map[ghostID]string)
+	for _, t := range data.Tags {
+		tagNames[t.ID] = t.Name
+	}
+	postTags := make(map[ghostID][]string)
+	for _, pt := range data.PostsTags {
+		// Internal tags (#name) are Ghost-only
+		if name := tagNames[pt.TagID]; name != "" && !strings.HasPrefix(name, "#") {
+			postTags[pt.PostID] = append(postTags[pt.PostID], name)
+		}
+	}
+
+	var posts []importedPost
+	for _, gp := range data.Posts {
+		if gp.Type == "page" || gp.Page {
+			continue
+		}
+		p := importedPost{
+			Title:       gp.Title,
+			Slug:        sanitizeFilename(gp.Slug),
+			Draft:       gp.Status != "published",
+			Tags:        postTags[gp.ID],
+			Description: gp.CustomExcerpt,
+			HTML:        gp.HTML,
+			Hero:        gp.FeatureImage,
+			OriginalURL: "/" + gp.Slug + "/",
+		}
+		p.Date, _ = parsePostDate(firstNonEmpty(gp.PublishedAt, gp.CreatedAt))
+		posts = append(posts, p)
+	}
+	return posts, nil
+}
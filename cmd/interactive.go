@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// interactive enables the review steps that pause for input during generation
+var interactive bool
+
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// errDraftDiscarded stops generation when the reviewer quits without a post
+var errDraftDiscarded = errors.New("draft discarded")
+
+// stdinIsTerminal reports whether someone is at the keyboard to answer prompts
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// askChoice prints a question with single-letter choices and returns the
+// letter picked, or def when the answer is empty or unreadable
+func askChoice(question string, choices []string, def string) string {
+	fmt.Printf("%s [%s]: ", question, strings.Join(choices, "/"))
+	answer, err := stdinReader.ReadString('\n')
+	if err != nil {
+		return def
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	if answer == "" {
+		return def
+	}
+	for _, c := range choices {
+		if strings.HasPrefix(answer, strings.ToLower(c[:1])) {
+			return strings.ToLower(c[:1])
+		}
+	}
+	return def
+}
+
+// editText opens text in $VISUAL or $EDITOR (default vi) and returns the result
+func editText(text, suffix string) (string, error) {
+	editor := firstNonEmpty(os.Getenv("VISUAL"), os.Getenv("EDITOR"), "vi")
+
+	f, err := os.CreateTemp("", "megafone-*"+suffix)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(text); err != nil {
+		f.Close()
+		return "", err
+	}
+	f.Close()
+
+	c := exec.Command("sh", "-c", editor+` "$1"`, "sh", f.Name())
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	if err := c.Run(); err != nil {
+		return "", fmt.Errorf("editor %s failed: %w", editor, err)
+	}
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// reviewImagePrompt shows the DALL-E prompt and lets the user generate, edit, or
+// skip before any money is spent
+func reviewImagePrompt(imagePrompt string) (string, bool, error) {
+	for {
+		fmt.Println("\n" + strings.Repeat("=", 80))
+		fmt.Println("Hero image prompt:")
+		fmt.Println(strings.Repeat("=", 80))
+		fmt.Println(imagePrompt)
+		fmt.Println(strings.Repeat("=", 80))
+
+		switch askChoice("Generate this image?", []string{"Generate", "edit", "skip"}, "g") {
+		case "g":
+			return imagePrompt, true, nil
+		case "s":
+			return imagePrompt, false, nil
+		case "e":
+			edited, err := editText(imagePrompt, ".txt")
+			if err != nil {
+				return imagePrompt, false, err
+			}
+			if edited = strings.TrimSpace(edited); edited != "" {
+				imagePrompt = edited
+			}
+		}
+	}
+}
+
+// showInPager shows text in $PAGER (default less), printing it instead when no
+// pager can run
+func showInPager(text string) {
+	pager := firstNonEmpty(os.Getenv("PAGER"), "less")
+	c := exec.Command("sh", "-c", pager)
+	c.Stdin = strings.NewReader(text)
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	if err := c.Run(); err != nil {
+		fmt.Println("\n" + strings.Repeat("=", 80))
+		fmt.Println(text)
+		fmt.Println(strings.Repeat("=", 80))
+	}
+}
+
+// reviewDraft pages a generated post and asks what to do with it until it's
+// accepted: regenerate it, regenerate it with the reviewer's comments, edit it
+// in $EDITOR, or quit without writing anything. rewrite produces a new draft
+// and filename from the current draft and a comment (empty to start over).
+func reviewDraft(content, filename string, rewrite func(draft, feedback string) (string, string, error)) (string, string, error) {
+	for {
+		showInPager(content)
+
+		choice := askChoice("Write this post?", []string{"Accept", "regenerate", "feedback", "edit", "quit"}, "a")
+		switch choice {
+		case "a":
+			return content, filename, nil
+		case "q":
+			return content, filename, errDraftDiscarded
+		case "e":
+			edited, err := editText(content, ".md")
+			if err != nil {
+				return content, filename, err
+			}
+			if strings.TrimSpace(edited) != "" {
+				content = edited
+			}
+		case "r", "f":
+			feedback := ""
+			if choice == "f" {
+				fmt.Print("What should change? ")
+				answer, err := stdinReader.ReadString('\n')
+				if feedback = strings.TrimSpace(answer); err != nil || feedback == "" {
+					continue
+				}
+			}
+			logInfo("🤖 Regenerating the draft with %s...", model)
+			draft, name, err := rewrite(content, feedback)
+			if err != nil {
+				logError("Regeneration failed; keeping the current draft: %v", err)
+				continue
+			}
+			if strings.TrimSpace(draft) == "" {
+				logError("Regeneration returned nothing; keeping the current draft")
+				continue
+			}
+			content = draft
+			if name != "" {
+				filename = name
+			}
+		}
+	}
+}
+
+// revisionSection asks the model to rework its previous draft per a reviewer's comments
+func revisionSection(draft, feedback string) string {
+	return fmt.Sprintf(`
+
+## Revision Request
+A reviewer read your previous draft of this post and asked for changes:
+
+%s
+
+Rewrite the whole post to address these comments. Keep what they didn't ask
+to change, and follow the style guide above as before.
+
+### Previous Draft
+%s
+`, feedback, draft)
+}
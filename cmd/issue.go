@@ -0,0 +1,462 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/sashabaranov/go-openai"
+	"github.com/spf13/cobra"
+)
+
+const (
+	issueStyleDecision   = "decision"
+	issueStylePostmortem = "postmortem"
+
+	anonymizeNone       = "none"
+	anonymizeCommenters = "commenters"
+	anonymizeAll        = "all"
+)
+
+var (
+	issueStyle     string
+	issueAnonymize string
+	issueCredit    []string
+)
+
+var (
+	issueURLRegex = regexp.MustCompile(`github\.com/([^/]+)/([^/]+)/(issues|pull|discussions)/(\d+)`)
+	mentionRegex  = regexp.MustCompile(`@([A-Za-z0-9](?:[A-Za-z0-9-]{0,38}))`)
+)
+
+var issueCmd = &cobra.Command{
+	Use:   "issue <url>",
+	Short: "Turn a GitHub issue or discussion thread into a writeup",
+	Long: `Reads a GitHub issue, pull request, or discussion with its full comment thread
+and writes a "design decision" or "postmortem" post explaining the problem, the
+options considered, and the resolution.
+
+Commenter names can be anonymized. --anonymize commenters replaces everyone but
+the thread author with stable placeholders ("Contributor A"), --anonymize all
+includes the author, and --credit keeps names of people who agreed to be credited.
+Discussions need GITHUB_TOKEN (they are only available through GraphQL).
+
+Examples:
+  megafone issue https://github.com/me/tool/issues/412 --style postmortem -s ~/hugo
+  megafone issue https://github.com/me/tool/discussions/88 --anonymize commenters --credit alice,bob`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runIssue(cmd, args[0]); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(issueCmd)
+
+	issueCmd.Flags().StringVar(&issueStyle, "style", issueStyleDecision, "Writeup style: decision (design decision record) or postmortem")
+	issueCmd.Flags().StringVar(&issueAnonymize, "anonymize", anonymizeNone, "Replace names: none, commenters, or all")
+	issueCmd.Flags().StringSliceVar(&issueCredit, "credit", nil, "Usernames that consented to be named even when anonymizing")
+	issueCmd.Flags().StringVarP(&tags, "tags", "T", "", "Comma-separated tags (AI will suggest if not provided)")
+	issueCmd.Flags().StringVarP(&promptFile, "prompt", "p", "prompts/technical-article.txt", "Path to prompt template file")
+	issueCmd.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "Print generated content without writing files")
+	issueCmd.Flags().StringVarP(&model, "model", "m", "gpt-4o", "OpenAI model to use")
+	issueCmd.Flags().StringVarP(&siteSource, "site-source", "s", "", "Path to local Hugo site repository")
+}
+
+// issueThread is an issue, pull request, or discussion with its comments
+type issueThread struct {
+	Kind     string
+	Title    string
+	URL      string
+	Author   string
+	Body     string
+	State    string
+	Comments []issueComment
+}
+
+type issueComment struct {
+	Author string
+	Body   string
+}
+
+func runIssue(cmd *cobra.Command, threadURL string) error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	ctx := context.Background()
+
+	switch issueStyle {
+	case issueStyleDecision, issueStylePostmortem:
+	default:
+		return fmt.Errorf("invalid --style value %q (use decision or postmortem)", issueStyle)
+	}
+	switch issueAnonymize {
+	case anonymizeNone, anonymizeCommenters, anonymizeAll:
+	default:
+		return fmt.Errorf("invalid --anonymize value %q (use none, commenters, or all)", issueAnonymize)
+	}
+
+	m := issueURLRegex.FindStringSubmatch(threadURL)
+	if m == nil {
+		return fmt.Errorf("not a GitHub issue, pull request, or discussion URL: %s", threadURL)
+	}
+	owner, repo, kind := m[1], m[2], m[3]
+	number, _ := strconv.Atoi(m[4])
+
+	basePath, err := resolveSitePath()
+	if err != nil {
+		return err
+	}
+	apiKey, err := getOpenAIKey(cmd)
+	if err != nil {
+		return err
+	}
+
+	logInfo("🧵 Fetching %s/%s #%d", owner, repo, number)
+	var thread *issueThread
+	if kind == "discussions" {
+		thread, err = fetchDiscussionThread(ctx, owner, repo, number)
+	} else {
+		thread, err = fetchIssueThread(ctx, newGitHubClient(), owner, repo, number)
+	}
+	if err != nil {
+		return err
+	}
+	logInfo("Found %d comments", len(thread.Comments))
+
+	anonymizeThread(thread, issueAnonymize, issueCredit)
+
+	promptTemplate, err := loadPrompt(promptFile, "issue", threadURL)
+	if err != nil {
+		return err
+	}
+
+	content, filename, err := generateIssueWriteup(ctx, apiKey, promptTemplate, thread, issueStyle, tags, model)
+	if err != nil {
+		return err
+	}
+
+	content = applyDatePolicy(content, postDateString(), basePath)
+	content = applyHighlights(ctx, openai.NewClient(apiKey), content)
+	if content, err = applySummarySections(ctx, openai.NewClient(apiKey), content, "issue"); err != nil {
+		return err
+	}
+	content = applyAccessibility(content)
+	if content, err = applyDisclosure(content, basePath); err != nil {
+		return err
+	}
+	if content, err = applyFrontMatterDefaults(content, "issue", threadURL); err != nil {
+		return err
+	}
+	if content, err = applySecretScan(content); err != nil {
+		return err
+	}
+	if content, filename, err = applySlugStrategy(content, filename, ""); err != nil {
+		return err
+	}
+
+	if dryRun {
+		logInfo("Dry run mode - not writing files")
+		fmt.Println("\n" + strings.Repeat("=", 80))
+		fmt.Println("DRY RUN - Generated Content:")
+		fmt.Println(strings.Repeat("=", 80))
+		fmt.Println(content)
+		fmt.Println(strings.Repeat("=", 80))
+		return nil
+	}
+
+	postPath, err := writeNewPost(basePath, filepath.Join(postsDir(basePath), filename+".md"), content)
+	if err != nil {
+		return fmt.Errorf("failed to write post: %w", err)
+	}
+	logSuccess("✅ Writeup created: %s", postPath)
+
+	var tagList []string
+	if tags != "" {
+		tagList = strings.Split(tags, ",")
+	}
+	logGeneration(threadURL, postPath, "", tagList)
+	return runHooks("post_generate", appConfig.Hooks.PostGenerate, hookRun{Post: postPath, Source: threadURL, Site: basePath})
+}
+
+func fetchIssueThread(ctx context.Context, client *github.Client, owner, repo string, number int) (*issueThread, error) {
+	issue, _, err := client.Issues.Get(ctx, owner, repo, number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch issue: %w", err)
+	}
+
+	thread := &issueThread{
+		Kind:   "issue",
+		Title:  issue.GetTitle(),
+		URL:    issue.GetHTMLURL(),
+		Author: issue.GetUser().GetLogin(),
+		Body:   issue.GetBody(),
+		State:  issue.GetState(),
+	}
+	if issue.IsPullRequest() {
+		thread.Kind = "pull request"
+	}
+
+	opts := &github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		comments, resp, err := client.Issues.ListComments(ctx, owner, repo, number, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch comments: %w", err)
+		}
+		for _, c := range comments {
+			thread.Comments = append(thread.Comments, issueComment{Author: c.GetUser().GetLogin(), Body: c.GetBody()})
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return thread, nil
+}
+
+// fetchDiscussionThread reads a discussion through GraphQL, including replies
+func fetchDiscussionThread(ctx context.Context, owner, repo string, number int) (*issueThread, error) {
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("GitHub discussions require GITHUB_TOKEN")
+	}
+
+	query := `query($owner: String!, $repo: String!, $number: Int!) {
+  repository(owner: $owner, name: $repo) {
+    discussion(number: $number) {
+      title url body closed
+      author { login }
+      answer { id }
+      comments(first: 100) {
+        nodes {
+          id body author { login }
+          replies(first: 50) { nodes { body author { login } } }
+        }
+      }
+    }
+  }
+}`
+	payload, err := json.Marshal(map[string]interface{}{
+		"query":     query,
+		"variables": map[string]interface{}{"owner": owner, "repo": repo, "number": number},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.github.com/graphql", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	type author struct {
+		Login string `json:"login"`
+	}
+	var result struct {
+		Data struct {
+			Repository struct {
+				Discussion *struct {
+					Title  string `json:"title"`
+					URL    string `json:"url"`
+					Body   string `json:"body"`
+					Closed bool   `json:"closed"`
+					Author author `json:"author"`
+					Answer *struct {
+						ID string `json:"id"`
+					} `json:"answer"`
+					Comments struct {
+						Nodes []struct {
+							ID      string `json:"id"`
+							Body    string `json:"body"`
+							Author  author `json:"author"`
+							Replies struct {
+								Nodes []struct {
+									Body   string `json:"body"`
+									Author author `json:"author"`
+								} `json:"nodes"`
+							} `json:"replies"`
+						} `json:"nodes"`
+					} `json:"comments"`
+				} `json:"discussion"`
+			} `json:"repository"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := doJSON(req, &result); err != nil {
+		return nil, fmt.Errorf("failed to fetch discussion: %w", err)
+	}
+	if len(result.Errors) > 0 {
+		return nil, fmt.Errorf("failed to fetch discussion: %s", result.Errors[0].Message)
+	}
+	d := result.Data.Repository.Discussion
+	if d == nil {
+		return nil, fmt.Errorf("discussion #%d not found", number)
+	}
+
+	thread := &issueThread{
+		Kind:   "discussion",
+		Title:  d.Title,
+		URL:    d.URL,
+		Author: d.Author.Login,
+		Body:   d.Body,
+		State:  "open",
+	}
+	if d.Closed {
+		thread.State = "closed"
+	}
+	for _, c := range d.Comments.Nodes {
+		body := c.Body
+		if d.Answer != nil && d.Answer.ID == c.ID {
+			body = "[Marked as the answer] " + body
+		}
+		thread.Comments = append(thread.Comments, issueComment{Author: c.Author.Login, Body: body})
+		for _, r := range c.Replies.Nodes {
+			thread.Comments = append(thread.Comments, issueComment{Author: r.Author.Login, Body: "(reply) " + r.Body})
+		}
+	}
+	return thread, nil
+}
+
+// anonymizeThread replaces usernames (as authors and @mentions) with stable
+// placeholders, keeping anyone listed in credit
+func anonymizeThread(thread *issueThread, mode string, credit []string) {
+	if mode == anonymizeNone {
+		return
+	}
+
+	keep := make(map[string]bool)
+	for _, c := range credit {
+		keep[strings.ToLower(strings.TrimPrefix(c, "@"))] = true
+	}
+	if mode == anonymizeCommenters {
+		keep[strings.ToLower(thread.Author)] = true
+	}
+
+	aliases := make(map[string]string)
+	alias := func(login string) string {
+		if login == "" || keep[strings.ToLower(login)] {
+			return login
+		}
+		key := strings.ToLower(login)
+		if _, ok := aliases[key]; !ok {
+			n := len(aliases)
+			name := "Contributor " + string(rune('A'+n%26))
+			if n >= 26 {
+				name += strconv.Itoa(n / 26)
+			}
+			aliases[key] = name
+		}
+		return aliases[key]
+	}
+	scrub := func(text string) string {
+		return mentionRegex.ReplaceAllStringFunc(text, func(mention string) string {
+			login := strings.TrimPrefix(mention, "@")
+			if a := alias(login); a != login {
+				return a
+			}
+			return mention
+		})
+	}
+
+	thread.Author = alias(thread.Author)
+	thread.Body = scrub(thread.Body)
+	for i := range thread.Comments {
+		thread.Comments[i].Author = alias(thread.Comments[i].Author)
+		thread.Comments[i].Body = scrub(thread.Comments[i].Body)
+	}
+	logInfo("🕶️  Anonymized %d participants", len(aliases))
+}
+
+func generateIssueWriteup(ctx context.Context, apiKey, promptTemplate string, thread *issueThread, style, userTags, model string) (content, filename string, err error) {
+	client := openai.NewClient(apiKey)
+
+	var conversation strings.Builder
+	fmt.Fprintf(&conversation, "%s opened this %s (%s):\n%s\n", thread.Author, thread.Kind, thread.State, thread.Body)
+	for _, c := range thread.Comments {
+		fmt.Fprintf(&conversation, "\n%s:\n%s\n", c.Author, c.Body)
+	}
+	text := conversation.String()
+	if len(text) > 24000 {
+		text = text[:24000] + "\n[thread truncated]"
+	}
+
+	structure := `Structure it as a design decision record:
+- Context: the problem and why it mattered
+- Options considered, with the trade-offs raised in the thread
+- Decision and the reasoning behind it
+- Consequences and follow-up work`
+	if style == issueStylePostmortem {
+		structure = `Structure it as a blameless postmortem:
+- Summary and impact
+- Timeline of how the problem was noticed, investigated, and fixed
+- Root cause
+- Resolution
+- Lessons learned and follow-up actions
+Never assign blame to individuals.`
+	}
+
+	userPrompt := fmt.Sprintf(`%s
+
+Please write a blog post based on this GitHub %s thread.
+
+Title: %s
+URL: %s
+
+Thread:
+%s
+
+%s
+
+Refer to participants exactly as they are named in the thread (some names are placeholders; never guess real identities).
+Link to the original thread once.
+
+User-provided tags: %s (suggest appropriate tags if none provided)
+
+IMPORTANT: Your response must be ONLY valid markdown. Do not include any explanatory text before or after the markdown.
+IMPORTANT: Use date: %s in the front matter.
+
+Generate a complete Hugo markdown post following the style guide above.
+`, promptTemplate, thread.Kind, thread.Title, thread.URL, text, structure, userTags, postDateString())
+
+	resp, err := createChatCompletion(withStreaming(ctx), client, openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: "You are a technical writer who turns engineering discussions into clear, fair writeups. Follow the style guide precisely. Output ONLY the markdown content, no explanations.",
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: userPrompt,
+			},
+		},
+		Temperature: 0.6,
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("OpenAI API error: %w", err)
+	}
+	if len(resp.Choices) == 0 || resp.Choices[0].Message.Content == "" {
+		return "", "", fmt.Errorf("no response from OpenAI")
+	}
+	content = resp.Choices[0].Message.Content
+
+	filename, err = generateFilename(ctx, client, content, model)
+	if err != nil || filename == "" {
+		logError("Failed to generate filename, using thread title: %v", err)
+		filename = sanitizeFilename(thread.Title)
+	}
+	return content, filename, nil
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// journalEntry is one line of .megafone/journal.jsonl in the site repo, recording
+// a file megafone created, modified, or deleted
+type journalEntry struct {
+	Time         string `json:"time"`
+	RunID        string `json:"run_id"`
+	Command      string `json:"command"`
+	Action       string `json:"action"`
+	Path         string `json:"path"`
+	Source       string `json:"source,omitempty"`
+	AIGenerated  bool   `json:"ai_generated"`
+	Model        string `json:"model,omitempty"`
+	FallbackFrom string `json:"fallback_from,omitempty"`
+}
+
+var (
+	// journalRunID ties together every change made by one invocation
+	journalRunID = newRunID()
+	// journalCommand is the cobra command path, set before each command runs
+	journalCommand string
+	noJournal      bool
+)
+
+func newRunID() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return time.Now().UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(b)
+}
+
+// journalPath returns the journal file for the site containing path
+func journalPath(siteRoot string) string {
+	return workspacePath(siteRoot, "journal.jsonl")
+}
+
+// recordSiteChange appends a journal entry for a file in the site. Paths outside
+// a Hugo site are ignored, and journal failures are logged rather than failing the command.
+func recordSiteChange(action, path, source string, aiGenerated bool) {
+	if noJournal || path == "" {
+		return
+	}
+	root := findSiteRoot(filepath.Dir(path))
+	if root == "" {
+		return
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil {
+		return
+	}
+
+	entry := journalEntry{
+		Time:        time.Now().Format(time.RFC3339),
+		RunID:       journalRunID,
+		Command:     journalCommand,
+		Action:      action,
+		Path:        filepath.ToSlash(rel),
+		Source:      source,
+		AIGenerated: aiGenerated,
+	}
+	if aiGenerated {
+		entry.Model = effectiveModel()
+		entry.FallbackFrom = fallbackFrom
+	}
+
+	if err := appendJournal(journalPath(root), entry); err != nil {
+		logError("Failed to update journal: %v", err)
+	}
+}
+
+func appendJournal(path string, entry journalEntry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
@@ -0,0 +1,281 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/sashabaranov/go-openai"
+	"github.com/spf13/cobra"
+)
+
+var (
+	launchRepo    string
+	launchTagline string
+	launchPricing string
+	launchLinks   []string
+	launchDate    string
+	launchOut     string
+)
+
+// launchAsset is one piece of the launch kit and the instructions for writing it
+type launchAsset struct {
+	File         string
+	Name         string
+	Instructions string
+}
+
+var launchAssets = []launchAsset{
+	{
+		File: "blog-post.md",
+		Name: "launch blog post",
+		Instructions: `Write the launch-day blog post announcing the project: the problem, what it does, a quick
+example, pricing, and where to get it. Output a complete Hugo markdown post with front matter
+(title, description, date, tags).`,
+	},
+	{
+		File: "product-hunt.md",
+		Name: "Product Hunt listing",
+		Instructions: `Write the Product Hunt listing as markdown with these sections:
+## Name
+## Tagline (max 60 characters)
+## Description (max 260 characters)
+## First comment (the maker's comment: why you built it, what's next, an ask for feedback)
+## Topics (3-5 Product Hunt topics)`,
+	},
+	{
+		File: "show-hn.md",
+		Name: "Show HN post",
+		Instructions: `Write a Hacker News "Show HN" submission as markdown:
+## Title (starts with "Show HN: ", max 80 characters, no superlatives)
+## URL
+## Text (plain, modest, technical; explain how it works and what's novel; invite criticism;
+no marketing language, no emoji)`,
+	},
+	{
+		File: "social-thread.md",
+		Name: "social media thread",
+		Instructions: `Write a 5-8 post thread for X/Bluesky/Mastodon. Each post under 280 characters, separated
+by a line containing only "---". The first post must stand alone as a hook; the last links to the
+project. At most two hashtags in the whole thread.`,
+	},
+}
+
+var launchCmd = &cobra.Command{
+	Use:   "launch",
+	Short: "Generate a launch kit: blog post, Product Hunt listing, Show HN text, and social thread",
+	Long: `Reads your project repository plus launch details and writes everything for
+launch day into one directory:
+
+  blog-post.md       launch announcement (also written to the site with --site-source)
+  product-hunt.md    tagline, description, maker's first comment, topics
+  show-hn.md         Show HN title and text
+  social-thread.md   thread for X/Bluesky/Mastodon
+
+Examples:
+  megafone launch --repo me/tool --tagline "Postgres backups that test themselves" \
+    --pricing "Free for open source, $9/mo for teams" \
+    --link Website=https://tool.dev --link Docs=https://tool.dev/docs
+
+  megafone launch --repo me/tool --tagline "..." --out launch/ -s ~/hugo`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runLaunch(cmd); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(launchCmd)
+
+	launchCmd.Flags().StringVarP(&launchRepo, "repo", "r", "", "GitHub repository being launched (owner/repo or URL) (required)")
+	launchCmd.Flags().StringVar(&launchTagline, "tagline", "", "One-line pitch for the project")
+	launchCmd.Flags().StringVar(&launchPricing, "pricing", "", "Pricing summary, e.g. \"Free, $9/mo for teams\"")
+	launchCmd.Flags().StringArrayVar(&launchLinks, "link", nil, "Launch link as Label=URL (repeatable)")
+	launchCmd.Flags().StringVar(&launchDate, "launch-date", "", "Launch date (default: today)")
+	launchCmd.Flags().StringVarP(&launchOut, "out", "o", "", "Launch kit directory (default: launch-kit/<repo>)")
+	launchCmd.Flags().StringVarP(&model, "model", "m", "gpt-4o", "OpenAI model to use")
+	launchCmd.Flags().StringVarP(&promptFile, "prompt", "p", "prompts/github-project.txt", "Style guide used for the blog post")
+	launchCmd.Flags().StringVarP(&siteSource, "site-source", "s", "", "Also write the blog post into this Hugo site")
+
+	launchCmd.MarkFlagRequired("repo")
+}
+
+func runLaunch(cmd *cobra.Command) error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	ctx := context.Background()
+
+	owner, repo, err := parseGitHubURL(launchRepo)
+	if err != nil {
+		return fmt.Errorf("invalid repository: %w", err)
+	}
+	apiKey, err := getOpenAIKey(cmd)
+	if err != nil {
+		return err
+	}
+
+	var basePath string
+	if siteSource != "" {
+		if basePath, err = resolveSitePath(); err != nil {
+			return err
+		}
+	}
+
+	outDir := launchOut
+	if outDir == "" {
+		outDir = filepath.Join("launch-kit", sanitizeFilename(repo))
+	}
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return fmt.Errorf("failed to create launch kit directory: %w", err)
+	}
+
+	logInfo("🚀 Building launch kit for %s/%s", owner, repo)
+	brief, err := launchBrief(ctx, newGitHubClient(), owner, repo)
+	if err != nil {
+		return err
+	}
+
+	styleGuide, err := loadPrompt(promptFile, "launch", "https://github.com/"+owner+"/"+repo)
+	if err != nil {
+		return err
+	}
+
+	client := openai.NewClient(apiKey)
+	launchPostDate := firstNonEmpty(launchDate, postDateString())
+	launchSlug := "launching-" + sanitizeFilename(repo)
+	for _, asset := range launchAssets {
+		logInfo("✍️  Writing %s...", asset.Name)
+		style := ""
+		if asset.File == "blog-post.md" {
+			style = styleGuide
+		}
+		content, err := generateLaunchAsset(ctx, client, asset, brief, style, model)
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", asset.Name, err)
+		}
+
+		if asset.File == "blog-post.md" {
+			content = applyDatePolicy(content, launchPostDate, basePath)
+			content = applyHighlights(ctx, client, content)
+			if content, err = applySummarySections(ctx, client, content, "launch"); err != nil {
+				return err
+			}
+			content = applyAccessibility(content)
+			if content, err = applyDisclosure(content, basePath); err != nil {
+				return err
+			}
+			if content, err = applyFrontMatterDefaults(content, "launch", "https://github.com/"+owner+"/"+repo); err != nil {
+				return err
+			}
+			if content, launchSlug, err = applySlugStrategy(content, launchSlug, ""); err != nil {
+				return err
+			}
+		}
+		if content, err = applySecretScan(content); err != nil {
+			return err
+		}
+
+		path := filepath.Join(outDir, asset.File)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		logSuccess("✅ %s: %s", asset.Name, path)
+
+		if asset.File == "blog-post.md" && basePath != "" {
+			postPath := filepath.Join(postsDir(basePath), launchSlug+".md")
+			if err := os.WriteFile(postPath, []byte(content), 0644); err != nil {
+				return fmt.Errorf("failed to write post: %w", err)
+			}
+			logSuccess("✅ Post created: %s", postPath)
+			logGeneration("https://github.com/"+owner+"/"+repo, postPath, "", nil)
+			if err := runHooks("post_generate", appConfig.Hooks.PostGenerate, hookRun{Post: postPath, Source: "https://github.com/" + owner + "/" + repo, Site: basePath}); err != nil {
+				return err
+			}
+		}
+	}
+
+	logSuccess("🎉 Launch kit ready in %s", outDir)
+	return nil
+}
+
+// launchBrief collects the repository facts and launch details every asset is written from
+func launchBrief(ctx context.Context, client *github.Client, owner, repo string) (string, error) {
+	repoData, _, err := client.Repositories.Get(ctx, owner, repo)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch repository: %w", err)
+	}
+
+	readmeContent := ""
+	if readme, _, err := client.Repositories.GetReadme(ctx, owner, repo, nil); err == nil {
+		readmeContent, _ = readme.GetContent()
+	}
+	if len(readmeContent) > 10000 {
+		readmeContent = readmeContent[:10000] + "\n[README truncated]"
+	}
+
+	date := launchDate
+	if date == "" {
+		date = postDateString()
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Project: %s\nRepository: %s\nDescription: %s\nLanguage: %s\nStars: %d\nHomepage: %s\nLaunch date: %s\n",
+		repoData.GetName(), repoData.GetHTMLURL(), repoData.GetDescription(), repoData.GetLanguage(),
+		repoData.GetStargazersCount(), repoData.GetHomepage(), date)
+	if launchTagline != "" {
+		fmt.Fprintf(&b, "Tagline: %s\n", launchTagline)
+	}
+	if launchPricing != "" {
+		fmt.Fprintf(&b, "Pricing: %s\n", launchPricing)
+	}
+	for _, link := range launchLinks {
+		label, target, ok := strings.Cut(link, "=")
+		if !ok {
+			return "", fmt.Errorf("invalid --link %q (use Label=URL)", link)
+		}
+		fmt.Fprintf(&b, "Link - %s: %s\n", strings.TrimSpace(label), strings.TrimSpace(target))
+	}
+	fmt.Fprintf(&b, "\nREADME:\n%s\n", readmeContent)
+	return b.String(), nil
+}
+
+func generateLaunchAsset(ctx context.Context, client *openai.Client, asset launchAsset, brief, styleGuide, model string) (string, error) {
+	userPrompt := fmt.Sprintf(`%s
+
+Launch details:
+%s
+
+Task: %s
+
+Use the tagline, pricing, and links exactly as given; never invent features, numbers, or testimonials.
+
+IMPORTANT: Your response must be ONLY the requested markdown. Do not include any explanatory text before or after it.`,
+		styleGuide, brief, asset.Instructions)
+
+	resp, err := createChatCompletion(ctx, client, openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: "You are a developer-tools founder writing honest, specific launch copy for each platform's norms. Output ONLY the requested content.",
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: userPrompt,
+			},
+		},
+		Temperature: 0.7,
+	})
+	if err != nil {
+		return "", fmt.Errorf("OpenAI API error: %w", err)
+	}
+	if len(resp.Choices) == 0 || resp.Choices[0].Message.Content == "" {
+		return "", fmt.Errorf("no response from OpenAI")
+	}
+	return resp.Choices[0].Message.Content, nil
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Hero image sources for --image-source
+const (
+	imageSourceAuto       = "auto"       // repo/page image, then DALL-E
+	imageSourceLibrary    = "library"    // repo/page image, then the curated library, then DALL-E
+	imageSourceScreenshot = "screenshot" // page image, then a screenshot of the page, then DALL-E
+	imageSourceNone       = "none"       // repo/page image only, never generate
+)
+
+var (
+	imageSource  string
+	imageLibrary string
+)
+
+var siteImageRefRegex = regexp.MustCompile(`/images/site/([^\s"')\]]+)`)
+
+// defaultImageLibrary is where the curated tag → image pools live when --image-library is not set.
+// Each subdirectory is a tag whose images form that tag's pool.
+func defaultImageLibrary(basePath string) string {
+	return filepath.Join(basePath, "assets", "images", "library")
+}
+
+// pickLibraryImage chooses a hero from the pools matching the post's tags and
+// copies it into the site image store. Within the matching pools the least-used
+// image wins, so posts in the same topic area share a visual family without
+// every post getting the identical hero.
+func pickLibraryImage(libraryDir, basePath string, postTags []string) (string, error) {
+	pools, err := os.ReadDir(libraryDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read image library: %w", err)
+	}
+
+	wanted := make(map[string]bool)
+	for _, tag := range postTags {
+		wanted[normalizeTag(tag)] = true
+	}
+
+	type candidate struct {
+		tag  string
+		path string
+		name string
+	}
+	var candidates []candidate
+	for _, pool := range pools {
+		if !pool.IsDir() || !wanted[normalizeTag(pool.Name())] {
+			continue
+		}
+		files, err := os.ReadDir(filepath.Join(libraryDir, pool.Name()))
+		if err != nil {
+			continue
+		}
+		for _, f := range files {
+			if f.IsDir() || !isImageFile(f.Name()) {
+				continue
+			}
+			candidates = append(candidates, candidate{
+				tag:  pool.Name(),
+				path: filepath.Join(libraryDir, pool.Name(), f.Name()),
+				name: fmt.Sprintf("library-%s-%s", sanitizeFilename(pool.Name()), strings.ToLower(f.Name())),
+			})
+		}
+	}
+
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("no library images match tags %v", postTags)
+	}
+
+	usage := countImageUsage(basePath)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		ui, uj := usage[candidates[i].name], usage[candidates[j].name]
+		if ui != uj {
+			return ui < uj
+		}
+		return candidates[i].name < candidates[j].name
+	})
+
+	chosen := candidates[0]
+	logInfo("📚 Picked library image %s from pool %q (used by %d posts)", chosen.name, chosen.tag, usage[chosen.name])
+
+	data, err := os.ReadFile(chosen.path)
+	if err != nil {
+		return "", err
+	}
+	return writeSiteImage(basePath, chosen.name, data)
+}
+
+// countImageUsage counts how many posts reference each image in the site store
+func countImageUsage(basePath string) map[string]int {
+	usage := make(map[string]int)
+	filepath.WalkDir(filepath.Join(basePath, "content"), func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".md" {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		for _, match := range siteImageRefRegex.FindAllStringSubmatch(string(data), -1) {
+			usage[match[1]]++
+		}
+		return nil
+	})
+	return usage
+}
+
+func normalizeTag(tag string) string {
+	return sanitizeFilename(strings.TrimSpace(tag))
+}
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+)
+
+const (
+	licensePolicyOff   = "off"
+	licensePolicyWarn  = "warn"
+	licensePolicyBlock = "block"
+)
+
+var (
+	imageLicensePolicy string
+	allowedLicenses    []string
+)
+
+// Licenses that permit reusing an image in a blog post with attribution
+var defaultAllowedLicenses = []string{
+	"MIT", "Apache-2.0", "BSD-2-Clause", "BSD-3-Clause", "ISC", "MPL-2.0", "Unlicense", "0BSD",
+	"CC0-1.0", "CC-BY-4.0", "CC-BY-SA-4.0", "CC-BY-3.0", "CC-BY-SA-3.0", "PDM",
+	"Unsplash", "Pexels",
+}
+
+var (
+	licenseLinkRegex = regexp.MustCompile(`(?i)<(?:link|a)[^>]*rel=["'][^"']*\blicense\b[^"']*["'][^>]*href=["']([^"']+)["']|<(?:link|a)[^>]*href=["']([^"']+)["'][^>]*rel=["'][^"']*\blicense\b[^"']*["']`)
+	ccURLRegex       = regexp.MustCompile(`(?i)creativecommons\.org/(licenses|publicdomain)/([a-z-]+)/(\d\.\d)`)
+)
+
+// repoImageLicense returns the SPDX identifier of the repository's license, which
+// covers images committed to it
+func repoImageLicense(repo *github.Repository) string {
+	id := repo.GetLicense().GetSPDXID()
+	if id == "NOASSERTION" {
+		return ""
+	}
+	return id
+}
+
+// pageImageLicense determines the license of an image from its host (stock sites
+// with a single site-wide license) or the page's license metadata
+func pageImageLicense(html, imageURL string) string {
+	if u, err := url.Parse(imageURL); err == nil {
+		host := strings.ToLower(u.Hostname())
+		switch {
+		case host == "images.unsplash.com" || host == "plus.unsplash.com":
+			return "Unsplash"
+		case host == "images.pexels.com":
+			return "Pexels"
+		}
+	}
+
+	ld := extractJSONLD(html)
+	for _, candidate := range []string{
+		imageJSONLDLicense(ld, imageURL),
+		jsonLDString(ld, "license"),
+		extractMetaContent(html, "name", "license"),
+		extractMetaContent(html, "property", "og:license"),
+		extractMetaContent(html, "name", "dcterms.license"),
+		extractMetaContent(html, "name", "dc.rights"),
+		licenseLink(html),
+	} {
+		if id := normalizeLicense(candidate); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// imageJSONLDLicense looks for an ImageObject describing this image with a license
+func imageJSONLDLicense(objects []map[string]interface{}, imageURL string) string {
+	for _, obj := range objects {
+		if contentURL, _ := obj["contentUrl"].(string); contentURL != "" && contentURL == imageURL {
+			if license, ok := obj["license"].(string); ok {
+				return license
+			}
+		}
+	}
+	return ""
+}
+
+func licenseLink(html string) string {
+	if matches := licenseLinkRegex.FindStringSubmatch(html); matches != nil {
+		return firstNonEmpty(matches[1], matches[2])
+	}
+	return ""
+}
+
+// normalizeLicense maps license URLs and common spellings to SPDX-style identifiers
+func normalizeLicense(license string) string {
+	license = strings.TrimSpace(license)
+	if license == "" {
+		return ""
+	}
+	if m := ccURLRegex.FindStringSubmatch(license); m != nil {
+		kind, version := strings.ToLower(m[2]), m[3]
+		switch {
+		case kind == "zero":
+			return "CC0-" + version
+		case kind == "mark":
+			return "PDM"
+		default:
+			return "CC-" + strings.ToUpper(kind) + "-" + version
+		}
+	}
+
+	lower := strings.ToLower(license)
+	switch {
+	case strings.Contains(lower, "public domain"):
+		return "PDM"
+	case strings.Contains(lower, "unsplash.com/license"):
+		return "Unsplash"
+	case strings.Contains(lower, "pexels.com/license"):
+		return "Pexels"
+	case strings.Contains(lower, "all rights reserved"):
+		return "All-Rights-Reserved"
+	}
+	return license
+}
+
+// checkImageLicense applies --image-license-policy to a candidate image. It returns
+// an error only when the policy is block and the license is missing or not allowed.
+func checkImageLicense(license, imageURL string) error {
+	if imageLicensePolicy == licensePolicyOff {
+		return nil
+	}
+
+	problem := ""
+	if license == "" {
+		problem = "license could not be determined"
+	} else {
+		allowed := false
+		for _, a := range allowedLicenses {
+			if strings.EqualFold(a, license) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			problem = fmt.Sprintf("license %s is not in --allowed-licenses", license)
+		}
+	}
+	if problem == "" {
+		logInfo("📜 Image license: %s", license)
+		return nil
+	}
+
+	if imageLicensePolicy == licensePolicyBlock {
+		return fmt.Errorf("reuse rights unclear for %s: %s", imageURL, problem)
+	}
+	logInfo("⚠️  Reuse rights unclear for %s: %s", imageURL, problem)
+	return nil
+}
@@ -0,0 +1,368 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+var fallbackModels []string
+
+// modelUsed and fallbackFrom record which model actually answered, for the
+// journal and disclosure front matter
+var (
+	modelUsed    string
+	fallbackFrom string
+)
+
+func init() {
+	rootCmd.PersistentFlags().StringSliceVar(&fallbackModels, "fallback-models", nil, "Models to try in order when the main model fails or refuses, e.g. gpt-4o-mini,claude-sonnet-4-5 (default: fallback_models in config)")
+}
+
+// effectiveModel is the model that produced this run's content
+func effectiveModel() string {
+	return firstNonEmpty(modelUsed, model)
+}
+
+// modelProvider names the API serving a model
+func modelProvider(name string) string {
+	if strings.HasPrefix(name, "claude") {
+		return "anthropic"
+	}
+	return "openai"
+}
+
+// createChatCompletion sends req to its model and, if that fails (outage,
+// quota, rate limit, or content-filter refusal), retries down the fallback chain.
+// Claude models are sent to Anthropic using ANTHROPIC_API_KEY.
+func createChatCompletion(ctx context.Context, client *openai.Client, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	chain := []string{req.Model}
+	fallbacks := fallbackModels
+	if len(fallbacks) == 0 {
+		fallbacks = appConfig.FallbackModels
+	}
+	for _, m := range fallbacks {
+		if m = strings.TrimSpace(m); m != "" && m != req.Model {
+			chain = append(chain, m)
+		}
+	}
+
+	var lastErr error
+	for i, m := range chain {
+		req.Model = m
+		resp, err := pacedChatCompletion(ctx, client, req)
+		if err == nil && len(resp.Choices) > 0 && resp.Choices[0].FinishReason == openai.FinishReasonContentFilter {
+			err = fmt.Errorf("response blocked by the content filter")
+		}
+		if err == nil {
+			if i > 0 {
+				logInfo("↪️  Used fallback model %s (%s failed)", m, chain[0])
+				fallbackFrom = chain[0]
+			}
+			modelUsed = m
+			budgetUsage(resp.Usage.PromptTokens)
+			return resp, nil
+		}
+
+		lastErr = err
+		if ctx.Err() != nil {
+			break
+		}
+		if i < len(chain)-1 {
+			logError("%s failed, falling back to %s: %v", m, chain[i+1], err)
+		}
+	}
+	return openai.ChatCompletionResponse{}, lastErr
+}
+
+// maxContinuations is how many times a reply cut off at the output limit is
+// continued before it's kept as is
+const maxContinuations = 3
+
+// continueTruncated returns the text of resp, the reply to req. When the reply
+// stopped at the output limit, the model is asked to go on from where it
+// stopped, and the parts are spliced with any repeated overlap removed.
+func continueTruncated(ctx context.Context, client *openai.Client, req openai.ChatCompletionRequest, resp openai.ChatCompletionResponse) (string, error) {
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("no response from model")
+	}
+	text := resp.Choices[0].Message.Content
+	finish := resp.Choices[0].FinishReason
+	messages := req.Messages
+	for i := 1; finish == openai.FinishReasonLength; i++ {
+		if i > maxContinuations {
+			logError("⚠️  The reply still hit the output limit after %d continuations and may end abruptly", maxContinuations)
+			break
+		}
+		logInfo("✂️  The reply hit the output limit (%s chars so far); asking for the rest (%d of %d)", formatCount(len(text)), i, maxContinuations)
+		messages = append(messages,
+			openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: resp.Choices[0].Message.Content},
+			openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: "You were cut off. Continue exactly where you stopped, mid-sentence if need be. Don't repeat anything, don't add a preamble, and don't restart the document."},
+		)
+		req.Messages = messages
+		next, err := createChatCompletion(ctx, client, req)
+		if err != nil {
+			logError("Continuation failed, keeping the reply as it is: %v", err)
+			break
+		}
+		if len(next.Choices) == 0 {
+			break
+		}
+		resp = next
+		text = spliceContinuation(text, resp.Choices[0].Message.Content)
+		finish = resp.Choices[0].FinishReason
+	}
+	return text, nil
+}
+
+// spliceContinuation appends a continuation to text, dropping what it repeats
+// of text's end (models often restate the last words before going on)
+func spliceContinuation(text, next string) string {
+	next = strings.TrimPrefix(next, "```markdown\n")
+	trimmed := strings.TrimLeft(next, " \n")
+	for n := min(len(text), len(trimmed), 400); n >= 12; n-- {
+		if strings.HasSuffix(text, trimmed[:n]) {
+			return text + trimmed[n:]
+		}
+	}
+	// Trimmed-whitespace overlap: "...the quick" + "the quick brown"
+	tail := strings.TrimRight(text, " \n")
+	for n := min(len(tail), len(trimmed), 400); n >= 12; n-- {
+		if strings.HasSuffix(tail, trimmed[:n]) {
+			return tail + trimmed[n:]
+		}
+	}
+	return text + next
+}
+
+// pacedChatCompletion sends one request within the provider's shared TPM and
+// concurrency limits, retrying rate-limited (429) responses with backoff
+func pacedChatCompletion(ctx context.Context, client *openai.Client, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	provider := modelProvider(req.Model)
+	estimate := estimateTokens(req)
+
+	for attempt := 0; ; attempt++ {
+		release, err := paceRequest(ctx, provider, estimate)
+		if err != nil {
+			return openai.ChatCompletionResponse{}, err
+		}
+		var resp openai.ChatCompletionResponse
+		show, done := replyStream(ctx)
+		switch {
+		case provider == "anthropic" && show != nil:
+			resp, err = anthropicStreamCompletion(ctx, req, show)
+			done()
+		case provider == "anthropic":
+			resp, err = anthropicChatCompletion(ctx, req)
+		case show != nil:
+			resp, err = openaiStreamCompletion(ctx, client, req, show)
+			done()
+		default:
+			resp, err = client.CreateChatCompletion(ctx, req)
+		}
+		release()
+		if err == nil {
+			logVerbose("%s used %d tokens (prompt %d, completion %d)", req.Model, resp.Usage.TotalTokens, resp.Usage.PromptTokens, resp.Usage.CompletionTokens)
+			recordUsage(provider, estimate, resp)
+			progressAddTokens(resp.Usage.TotalTokens)
+			return resp, nil
+		}
+
+		logVerbose("%s attempt %d failed: %v", req.Model, attempt+1, err)
+		wait, retryable := rateLimitBackoff(err, attempt)
+		if !retryable || attempt >= 2 || ctx.Err() != nil {
+			return resp, err
+		}
+		logInfo("⏳ %s rate limited, retrying in %s", req.Model, wait.Round(time.Second))
+		blockProvider(provider, wait)
+	}
+}
+
+// anthropicStatusError is a non-200 response from the Anthropic API
+type anthropicStatusError struct {
+	StatusCode int
+	Status     string
+	Body       string
+	RetryAfter time.Duration
+}
+
+func (e *anthropicStatusError) Error() string {
+	return fmt.Sprintf("Anthropic API error: %s: %s", e.Status, e.Body)
+}
+
+type anthropicContent struct {
+	Type   string           `json:"type"`
+	Text   string           `json:"text,omitempty"`
+	Source *anthropicSource `json:"source,omitempty"`
+}
+
+type anthropicSource struct {
+	Type      string `json:"type"`
+	URL       string `json:"url,omitempty"`
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+}
+
+// anthropicImageSource passes an image by URL, or inline when it is a base64
+// data URL such as a local screenshot
+func anthropicImageSource(imageURL string) *anthropicSource {
+	if meta, data, ok := strings.Cut(strings.TrimPrefix(imageURL, "data:"), ";base64,"); ok && strings.HasPrefix(imageURL, "data:") {
+		return &anthropicSource{Type: "base64", MediaType: meta, Data: data}
+	}
+	return &anthropicSource{Type: "url", URL: imageURL}
+}
+
+type anthropicMessage struct {
+	Role    string             `json:"role"`
+	Content []anthropicContent `json:"content"`
+}
+
+// anthropicChatCompletion adapts an OpenAI chat request to Anthropic's Messages API
+func anthropicChatCompletion(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	resp, err := anthropicPost(ctx, req, false)
+	if err != nil {
+		return openai.ChatCompletionResponse{}, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return openai.ChatCompletionResponse{}, err
+	}
+
+	var result struct {
+		Model      string             `json:"model"`
+		Content    []anthropicContent `json:"content"`
+		StopReason string             `json:"stop_reason"`
+		Usage      struct {
+			InputTokens  int `json:"input_tokens"`
+			OutputTokens int `json:"output_tokens"`
+		} `json:"usage"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return openai.ChatCompletionResponse{}, fmt.Errorf("failed to parse Anthropic response: %w", err)
+	}
+
+	var text strings.Builder
+	for _, c := range result.Content {
+		if c.Type == "text" {
+			text.WriteString(c.Text)
+		}
+	}
+	completion := anthropicCompletion(result.Model, text.String(), result.StopReason, result.Usage.InputTokens, result.Usage.OutputTokens)
+	completion.SetHeader(resp.Header)
+	return completion, nil
+}
+
+// anthropicPost sends a chat request to the Messages API, as an event stream
+// when stream is set. Non-200 responses are returned as *anthropicStatusError.
+func anthropicPost(ctx context.Context, req openai.ChatCompletionRequest, stream bool) (*http.Response, error) {
+	apiKey := os.Getenv("ANTHROPIC_API_KEY")
+	if apiKey == "" {
+		return nil, classify(ErrAuth, fmt.Errorf("ANTHROPIC_API_KEY is required for %s", req.Model))
+	}
+
+	var system []string
+	var messages []anthropicMessage
+	for _, m := range req.Messages {
+		if m.Role == openai.ChatMessageRoleSystem {
+			system = append(system, m.Content)
+			continue
+		}
+		msg := anthropicMessage{Role: m.Role}
+		if m.Content != "" {
+			msg.Content = append(msg.Content, anthropicContent{Type: "text", Text: m.Content})
+		}
+		for _, part := range m.MultiContent {
+			switch {
+			case part.Type == openai.ChatMessagePartTypeText:
+				msg.Content = append(msg.Content, anthropicContent{Type: "text", Text: part.Text})
+			case part.ImageURL != nil:
+				msg.Content = append(msg.Content, anthropicContent{Type: "image", Source: anthropicImageSource(part.ImageURL.URL)})
+			}
+		}
+		messages = append(messages, msg)
+	}
+
+	maxTokens := req.MaxTokens
+	if req.MaxCompletionTokens > maxTokens {
+		maxTokens = req.MaxCompletionTokens
+	}
+	if maxTokens == 0 {
+		maxTokens = 8192
+	}
+
+	body := map[string]interface{}{
+		"model":      req.Model,
+		"max_tokens": maxTokens,
+		"messages":   messages,
+	}
+	if len(system) > 0 {
+		body["system"] = strings.Join(system, "\n\n")
+	}
+	if req.Temperature > 0 {
+		body["temperature"] = req.Temperature
+	}
+	if stream {
+		body["stream"] = true
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, anthropicMessagesURL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-api-key", apiKey)
+	httpReq.Header.Set("anthropic-version", "2023-06-01")
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("Anthropic API error: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		statusErr := &anthropicStatusError{StatusCode: resp.StatusCode, Status: resp.Status, Body: strings.TrimSpace(string(data))}
+		if secs, err := strconv.Atoi(resp.Header.Get("retry-after")); err == nil {
+			statusErr.RetryAfter = time.Duration(secs) * time.Second
+		}
+		return nil, statusErr
+	}
+	return resp, nil
+}
+
+// anthropicCompletion builds the OpenAI-shaped response for an Anthropic reply
+func anthropicCompletion(model, text, stopReason string, inputTokens, outputTokens int) openai.ChatCompletionResponse {
+	finish := openai.FinishReasonStop
+	switch stopReason {
+	case "max_tokens":
+		finish = openai.FinishReasonLength
+	case "refusal":
+		finish = openai.FinishReasonContentFilter
+	}
+
+	return openai.ChatCompletionResponse{
+		Model: model,
+		Choices: []openai.ChatCompletionChoice{{
+			Message:      openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: text},
+			FinishReason: finish,
+		}},
+		Usage: openai.Usage{
+			PromptTokens:     inputTokens,
+			CompletionTokens: outputTokens,
+			TotalTokens:      inputTokens + outputTokens,
+		},
+	}
+}
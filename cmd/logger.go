@@ -9,7 +9,11 @@ import (
 	"time"
 )
 
-var logger *log.Logger
+var (
+	logger *log.Logger
+	// logFile keeps receiving full logs while the console is redirected
+	logFile io.Writer
+)
 
 func initLogger() error {
 	logPath := getLogFilePath()
@@ -21,19 +25,28 @@ func initLogger() error {
 	}
 
 	// Open log file (append mode)
-	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	f, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 	if err != nil {
 		return fmt.Errorf("failed to open log file: %w", err)
 	}
+	logFile = f
 
 	// Write to both file and stdout
-	multiWriter := io.MultiWriter(os.Stdout, logFile)
-	logger = log.New(multiWriter, "", 0)
+	setLogConsole(os.Stdout)
 
 	return nil
 }
 
+// setLogConsole changes where log lines are echoed; the log file always gets them
+func setLogConsole(w io.Writer) {
+	logger = log.New(io.MultiWriter(w, logFile), "", 0)
+}
+
 func getLogFilePath() string {
+	// Containers with a read-only working directory can point logs at a volume
+	if dir := os.Getenv("MEGAFONE_LOG_DIR"); dir != "" {
+		return filepath.Join(dir, "generation.log")
+	}
 	return filepath.Join("logs", "generation.log")
 }
 
@@ -59,4 +72,5 @@ func logGeneration(repo, postPath, imagePath string, tags []string) {
 	timestamp := time.Now().Format("2006-01-02 15:04:05")
 	logger.Printf("[%s] GENERATION: repo=%s, post=%s, image=%s, tags=%v",
 		timestamp, repo, postPath, imagePath, tags)
+	recordSiteChange("created", postPath, repo, true)
 }
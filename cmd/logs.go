@@ -18,8 +18,7 @@ var logsCmd = &cobra.Command{
 	Long:  `Display the log file showing all post generation activity.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		if err := runLogs(); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
+			exitWithError(err)
 		}
 	},
 }
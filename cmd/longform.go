@@ -0,0 +1,403 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+var (
+	longForm        bool
+	longFormOutline string
+)
+
+// longFormWords is the default length of a long-form post
+const longFormWords = 3000
+
+// errOutlineWritten stops generation once an outline is written for review
+var errOutlineWritten = errors.New("outline written for review")
+
+var outlineWordsRegex = regexp.MustCompile(`\s*\(~?(\d+) words?\)\s*$`)
+
+// longFormSection is one ## section of a long-form outline
+type longFormSection struct {
+	Heading string
+	Points  []string
+	Words   int
+}
+
+// longFormOutlineDoc is the outline a long-form post is written from: the
+// post's front matter and its sections, as a markdown file the author can edit
+type longFormOutlineDoc struct {
+	FrontMatter string
+	Sections    []longFormSection
+}
+
+func (o *longFormOutlineDoc) markdown() string {
+	var b strings.Builder
+	b.WriteString(strings.TrimSpace(o.FrontMatter) + "\n")
+	for _, s := range o.Sections {
+		fmt.Fprintf(&b, "\n## %s (~%d words)\n\n", s.Heading, s.Words)
+		for _, p := range s.Points {
+			fmt.Fprintf(&b, "- %s\n", p)
+		}
+	}
+	return b.String()
+}
+
+func (o *longFormOutlineDoc) words() int {
+	total := 0
+	for _, s := range o.Sections {
+		total += s.Words
+	}
+	return total
+}
+
+// parseLongFormOutline reads an outline file: front matter, then ## headings
+// with an optional (~N words) and bulleted points
+func parseLongFormOutline(text string) (*longFormOutlineDoc, error) {
+	fm := frontMatterBlock(text)
+	if fm == "" {
+		return nil, fmt.Errorf("the outline has no front matter")
+	}
+	doc := &longFormOutlineDoc{FrontMatter: fm + "\n---"}
+	for _, line := range strings.Split(text[len(fm)+len("\n---"):], "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "## "):
+			heading := strings.TrimSpace(line[3:])
+			words := 0
+			if m := outlineWordsRegex.FindStringSubmatch(heading); m != nil {
+				words, _ = strconv.Atoi(m[1])
+				heading = strings.TrimSpace(heading[:len(heading)-len(m[0])])
+			}
+			doc.Sections = append(doc.Sections, longFormSection{Heading: heading, Words: words})
+		case len(doc.Sections) > 0 && (strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* ")):
+			s := &doc.Sections[len(doc.Sections)-1]
+			s.Points = append(s.Points, strings.TrimSpace(line[2:]))
+		}
+	}
+	if len(doc.Sections) == 0 {
+		return nil, fmt.Errorf("the outline has no ## sections")
+	}
+	// Sections without a length share what's left of the target evenly
+	unsized, left := 0, longFormTarget()
+	for _, s := range doc.Sections {
+		if s.Words <= 0 {
+			unsized++
+		}
+		left -= max(s.Words, 0)
+	}
+	for i := range doc.Sections {
+		if doc.Sections[i].Words <= 0 {
+			doc.Sections[i].Words = max(left/unsized, 150)
+		}
+	}
+	return doc, nil
+}
+
+func longFormTarget() int {
+	if targetWords > 0 {
+		return targetWords
+	}
+	return longFormWords
+}
+
+// generateLongForm writes a post too long for one completion: it outlines the
+// post (or follows --outline), writes it section by section, continuing any
+// section cut off at the output limit, and ends with a coherence pass over
+// the stitched draft. sourceContext is the source as the one-call prompts
+// describe it.
+func generateLongForm(ctx context.Context, apiKey, promptTemplate, sourceContext, userTags, heroImage string, heroAttr imageAttribution, model string) (content, filename string, err error) {
+	client := openai.NewClient(apiKey)
+
+	heroInfo := ""
+	if heroImage != "" {
+		heroInfo = fmt.Sprintf("\nHero image available: %s (use path: /images/site/%s)%s\nIMPORTANT: Include 'hero: /images/site/%s' in the front matter.",
+			heroImage, heroImage, heroFigureInstructions(heroImage, heroAttr), heroImage)
+	}
+
+	outline, err := approvedOutline(ctx, client, promptTemplate, sourceContext, userTags, heroInfo, model)
+	if err != nil {
+		return "", "", err
+	}
+	title := frontMatterString(outline.FrontMatter, "title")
+	logInfo("📑 Writing %q in %d sections (~%s words)", title, len(outline.Sections), formatCount(outline.words()))
+
+	var sections []string
+	for i, section := range outline.Sections {
+		logInfo("✍️  Section %d of %d: %s", i+1, len(outline.Sections), section.Heading)
+		previous := ""
+		if len(sections) > 0 {
+			previous = sections[len(sections)-1]
+		}
+		text, err := writeLongFormSection(ctx, client, promptTemplate, sourceContext, outline, i, previous, model)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to write section %q: %w", section.Heading, err)
+		}
+		sections = append(sections, text)
+	}
+
+	logInfo("🧵 Checking the stitched draft for coherence...")
+	if sections, err = longFormCoherencePass(ctx, client, outline, sections, model); err != nil {
+		logError("Coherence pass failed, keeping the sections as written: %v", err)
+	}
+
+	content = strings.TrimSpace(outline.FrontMatter) + "\n\n" + strings.Join(sections, "\n\n") + "\n"
+	if filename, err = generateFilename(ctx, client, content, model); err != nil {
+		logError("Failed to generate filename, using the title: %v", err)
+		filename = sanitizeFilename(title)
+	}
+	return content, filename, nil
+}
+
+// approvedOutline returns the outline to write from. With --outline, an
+// existing file is followed as written and a missing one is written for
+// review; with --interactive, the outline can be edited before writing starts.
+func approvedOutline(ctx context.Context, client *openai.Client, promptTemplate, sourceContext, userTags, heroInfo, model string) (*longFormOutlineDoc, error) {
+	if longFormOutline != "" {
+		if data, err := os.ReadFile(longFormOutline); err == nil {
+			logInfo("📑 Following the outline in %s", longFormOutline)
+			return parseLongFormOutline(string(data))
+		}
+	}
+
+	outline, err := draftLongFormOutline(ctx, client, promptTemplate, sourceContext, userTags, heroInfo, model)
+	if err != nil {
+		return nil, fmt.Errorf("failed to outline the post: %w", err)
+	}
+
+	if longFormOutline != "" {
+		if err := os.WriteFile(longFormOutline, []byte(outline.markdown()), 0644); err != nil {
+			return nil, fmt.Errorf("failed to write outline: %w", err)
+		}
+		logSuccess("📑 Outline written to %s. Edit it, then run the same command again to write the post.", longFormOutline)
+		return nil, errOutlineWritten
+	}
+	if !interactive || !stdinIsTerminal() {
+		logVerbose("Outline:\n%s", outline.markdown())
+		return outline, nil
+	}
+
+	for {
+		fmt.Println("\n" + strings.Repeat("=", 80))
+		fmt.Println("Outline:")
+		fmt.Println(strings.Repeat("=", 80))
+		fmt.Print(outline.markdown())
+		fmt.Println(strings.Repeat("=", 80))
+
+		switch askChoice("Write the post from this outline?", []string{"Write", "edit", "quit"}, "w") {
+		case "w":
+			return outline, nil
+		case "q":
+			return nil, fmt.Errorf("outline not approved")
+		case "e":
+			edited, err := editText(outline.markdown(), ".md")
+			if err != nil {
+				return nil, err
+			}
+			parsed, err := parseLongFormOutline(edited)
+			if err != nil {
+				logError("Keeping the previous outline: %v", err)
+				continue
+			}
+			outline = parsed
+		}
+	}
+}
+
+// draftLongFormOutline has the model write the post's front matter and plan
+// its sections
+func draftLongFormOutline(ctx context.Context, client *openai.Client, promptTemplate, sourceContext, userTags, heroInfo, model string) (*longFormOutlineDoc, error) {
+	prompt := fmt.Sprintf(`%s
+
+Plan a long-form deep dive of about %d words on this source:
+
+%s
+%s
+
+User-provided tags: %s (suggest appropriate tags if none provided)
+
+Write the post's Hugo front matter following the style guide above, using date: %s,
+and outline its sections: a heading, the points each covers, and its length in
+words. Lengths should add up to about %d words. Don't repeat a point across sections.
+
+Respond with only JSON: {"front_matter": "---\n...\n---", "sections": [{"heading": "...", "points": ["..."], "words": 500}]}`,
+		promptTemplate, longFormTarget(), sourceContext, heroInfo, userTags, postDateString(), longFormTarget())
+
+	budgetPrompt(prompt)
+	resp, err := createChatCompletion(ctx, client, openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: "You are a technical blog writer planning a detailed, well-structured post. Follow the style guide precisely."},
+			{Role: openai.ChatMessageRoleUser, Content: prompt},
+		},
+		Temperature: 0.4,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no response from model")
+	}
+	var planned struct {
+		FrontMatter string `json:"front_matter"`
+		Sections    []struct {
+			Heading string   `json:"heading"`
+			Points  []string `json:"points"`
+			Words   int      `json:"words"`
+		} `json:"sections"`
+	}
+	if err := decodeModelJSON(resp.Choices[0].Message.Content, &planned); err != nil {
+		return nil, err
+	}
+
+	fm := strings.TrimSpace(planned.FrontMatter)
+	if !strings.HasPrefix(fm, "---") {
+		fm = "---\n" + fm + "\n---"
+	}
+	doc := &longFormOutlineDoc{FrontMatter: fm}
+	for _, s := range planned.Sections {
+		heading := strings.TrimSpace(strings.TrimLeft(s.Heading, "# "))
+		if heading != "" {
+			doc.Sections = append(doc.Sections, longFormSection{Heading: heading, Points: s.Points, Words: s.Words})
+		}
+	}
+	// Round-tripping through markdown fills in missing lengths the same way an edited file would
+	return parseLongFormOutline(doc.markdown())
+}
+
+// writeLongFormSection writes one section given the whole outline and the
+// end of the section before it
+func writeLongFormSection(ctx context.Context, client *openai.Client, promptTemplate, sourceContext string, outline *longFormOutlineDoc, index int, previous, model string) (string, error) {
+	section := outline.Sections[index]
+	position := "a middle section: don't reintroduce the topic or wrap up the post"
+	switch {
+	case index == 0:
+		position = "the first section: open the post as the style guide describes"
+	case index == len(outline.Sections)-1:
+		position = "the last section: close the post as the style guide describes"
+	}
+	lead := ""
+	if previous != "" {
+		lead = fmt.Sprintf("\nThe previous section ends:\n\n%s\n\nPick up from there without repeating it.\n", lastChars(previous, 2000))
+	}
+
+	prompt := fmt.Sprintf(`%s
+
+Source:
+%s
+
+You are writing a long post one section at a time. The outline:
+
+%s
+%s
+Write only the section "## %s", about %d words, covering:
+- %s
+
+This is %s. Start with the "## %s" heading. Output only the section's markdown: no front matter and no other sections.`,
+		promptTemplate, sourceContext, outline.markdown(), lead, section.Heading, section.Words,
+		strings.Join(section.Points, "\n- "), position, section.Heading)
+
+	// About four tokens for every three words, with room to spare
+	request := openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: "You are a technical blog writer who creates detailed, honest posts. Follow the style guide precisely. Output ONLY the markdown content, no explanations."},
+			{Role: openai.ChatMessageRoleUser, Content: prompt},
+		},
+		Temperature: generationTemperature,
+		MaxTokens:   max(section.Words*2, 1200),
+	}
+	resp, err := createChatCompletion(withStreaming(ctx), client, request)
+	if err != nil {
+		return "", err
+	}
+	text, err := continueTruncated(withStreaming(ctx), client, request, resp)
+	if err != nil {
+		return "", err
+	}
+
+	body := strings.TrimSpace(text)
+	body = strings.TrimSuffix(strings.TrimPrefix(body, "```markdown\n"), "\n```")
+	if !strings.HasPrefix(body, "## ") {
+		body = "## " + section.Heading + "\n\n" + body
+	}
+	return body, nil
+}
+
+// longFormCoherencePass reads the stitched draft and fixes what writing it in
+// pieces gets wrong: repeated explanations, terms that drift between sections,
+// and abrupt transitions. Rewriting the whole post would hit the output limit
+// again, so the model returns small edits, each applied only when its text
+// occurs exactly once in a section.
+func longFormCoherencePass(ctx context.Context, client *openai.Client, outline *longFormOutlineDoc, sections []string, model string) ([]string, error) {
+	var draft strings.Builder
+	for i, s := range sections {
+		fmt.Fprintf(&draft, "[SECTION %d]\n%s\n\n", i, s)
+	}
+	prompt := fmt.Sprintf(`This post, "%s", was written one section at a time. Read it as one piece
+and fix only what that caused: explanations repeated across sections, a term or
+name used inconsistently, claims that contradict each other, and abrupt
+transitions between sections (the first and last sentences of a section are
+the place to fix those). Don't rewrite anything else.
+
+Respond with only JSON: {"edits": [{"section": 0, "find": "exact text from that section", "replace": "new text"}]}
+Keep each find short but unique within its section. Return {"edits": []} if nothing needs fixing.
+
+%s`, frontMatterString(outline.FrontMatter, "title"), draft.String())
+
+	resp, err := createChatCompletion(ctx, client, openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleUser, Content: prompt},
+		},
+		Temperature: 0.2,
+	})
+	if err != nil {
+		return sections, err
+	}
+	if len(resp.Choices) == 0 {
+		return sections, fmt.Errorf("no response from model")
+	}
+	var reply struct {
+		Edits []struct {
+			Section int    `json:"section"`
+			Find    string `json:"find"`
+			Replace string `json:"replace"`
+		} `json:"edits"`
+	}
+	if err := decodeModelJSON(resp.Choices[0].Message.Content, &reply); err != nil {
+		return sections, err
+	}
+
+	applied := 0
+	for _, e := range reply.Edits {
+		if e.Section < 0 || e.Section >= len(sections) || e.Find == "" || strings.Count(sections[e.Section], e.Find) != 1 {
+			logVerbose("Skipping coherence edit that doesn't match its section: %q", trimToLength(e.Find, 60))
+			continue
+		}
+		sections[e.Section] = strings.Replace(sections[e.Section], e.Find, e.Replace, 1)
+		applied++
+	}
+	logInfo("🧵 Applied %d of %d coherence edits", applied, len(reply.Edits))
+	return sections, nil
+}
+
+// lastChars returns about the last n characters of s, from a paragraph start
+func lastChars(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	tail := s[len(s)-n:]
+	if i := strings.Index(tail, "\n\n"); i >= 0 && i < n/2 {
+		tail = tail[i+2:]
+	}
+	return tail
+}
@@ -0,0 +1,444 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var metaJSON bool
+
+// metaKeyRegex is a top-level front matter key that meta set can write
+var metaKeyRegex = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_-]*$`)
+
+var metaCmd = &cobra.Command{
+	Use:   "meta",
+	Short: "Read and edit a post's front matter",
+	Long: `Reads and edits the YAML front matter of a post from scripts. Edits change only
+the lines of the fields being set; the rest of the front matter, its comments
+and formatting, and the post body are left byte for byte as they were.`,
+}
+
+var metaGetCmd = &cobra.Command{
+	Use:   "get <post> [key]...",
+	Short: "Print front matter fields",
+	Long: `Prints the value of each key: a scalar as is, a list one item per line, and
+anything else as YAML. Nested fields use dots (params.series). With several keys
+each is printed as key: value; with none, the whole front matter is printed.
+A missing key is an error.
+
+Examples:
+  megafone meta get content/posts/en/my-post.md title
+  megafone meta get content/posts/en/my-post.md tags
+  megafone meta get content/posts/en/my-post.md --json`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runMetaGet(args[0], args[1:]); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+var metaSetCmd = &cobra.Command{
+	Use:   "set <post|dir> key=value...",
+	Short: "Set top-level front matter fields",
+	Long: `Sets top-level front matter fields. YAML literals keep their type: true,
+false, numbers, dates, null, quoted strings, and flow lists and maps such as
+[go, cli]. Any other value is written as a quoted string. key+=value adds an
+item to a list field unless it's already there, key-=value removes one, and
+key?=value sets the field only where it's missing.
+
+Given a directory, every post under it is edited as one transaction: the
+changes are shown as a diff and applied after confirmation, and can be
+reverted with 'megafone transactions undo'.
+
+An edit that would leave the front matter unparseable is refused.
+
+Examples:
+  megafone meta set content/posts/en/my-post.md draft=false
+  megafone meta set content/posts/en/my-post.md title="Shipping a CLI in Go" weight=10
+  megafone meta set content/posts/en/my-post.md aliases+=/posts/old-slug/ tags-=draft
+
+  # Backfill a field across the site
+  megafone meta set content/posts author?="Jane Doe"`,
+	Args: cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runMetaSet(args[0], args[1:]); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+var metaUnsetCmd = &cobra.Command{
+	Use:   "unset <post|dir> key...",
+	Short: "Remove top-level front matter fields",
+	Args:  cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runMetaUnset(args[0], args[1:]); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(metaCmd)
+	metaCmd.AddCommand(metaGetCmd)
+	metaCmd.AddCommand(metaSetCmd)
+	metaCmd.AddCommand(metaUnsetCmd)
+
+	metaGetCmd.Flags().BoolVar(&metaJSON, "json", false, "Print the fields as a JSON object")
+	metaSetCmd.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "Print the new front matter without writing it")
+	metaUnsetCmd.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "Print the new front matter without writing it")
+	metaSetCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Apply edits to a directory without asking for confirmation")
+	metaUnsetCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Apply edits to a directory without asking for confirmation")
+}
+
+// parseFrontMatter decodes a post's YAML front matter into a mapping node
+func parseFrontMatter(content string) (*yaml.Node, error) {
+	fm := frontMatterBlock(content)
+	if fm == "" {
+		return nil, fmt.Errorf("no YAML front matter (--- ... ---)")
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(strings.TrimPrefix(strings.TrimLeft(fm, "\n"), "---")), &doc); err != nil {
+		return nil, fmt.Errorf("invalid front matter: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return &yaml.Node{Kind: yaml.MappingNode}, nil
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("front matter is not a mapping of fields")
+	}
+	return doc.Content[0], nil
+}
+
+// frontMatterNode finds a field by its dotted path, e.g. params.series
+func frontMatterNode(root *yaml.Node, path string) *yaml.Node {
+	node := root
+	for _, part := range strings.Split(path, ".") {
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		var next *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == part {
+				next = node.Content[i+1]
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		node = next
+	}
+	return node
+}
+
+// metaText renders a field for the terminal: scalars as is, lists of scalars
+// one per line, anything else as YAML
+func metaText(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return node.Value
+	case yaml.SequenceNode:
+		items := make([]string, 0, len(node.Content))
+		for _, item := range node.Content {
+			if item.Kind != yaml.ScalarNode {
+				items = nil
+				break
+			}
+			items = append(items, item.Value)
+		}
+		if items != nil || len(node.Content) == 0 {
+			return strings.Join(items, "\n")
+		}
+	}
+	data, err := yaml.Marshal(node)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimRight(string(data), "\n")
+}
+
+func runMetaGet(postPath string, keys []string) error {
+	data, err := os.ReadFile(postPath)
+	if err != nil {
+		return fmt.Errorf("failed to read post: %w", err)
+	}
+	root, err := parseFrontMatter(string(data))
+	if err != nil {
+		return fmt.Errorf("%s: %w", postPath, err)
+	}
+
+	if len(keys) == 0 {
+		if metaJSON {
+			return printMetaJSON(root)
+		}
+		fmt.Println(strings.TrimSpace(strings.TrimPrefix(strings.TrimLeft(frontMatterBlock(string(data)), "\n"), "---")))
+		return nil
+	}
+
+	fields := &yaml.Node{Kind: yaml.MappingNode}
+	for _, key := range keys {
+		node := frontMatterNode(root, key)
+		if node == nil {
+			return fmt.Errorf("%s has no front matter field %q", postPath, key)
+		}
+		fields.Content = append(fields.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, node)
+	}
+
+	switch {
+	case metaJSON:
+		return printMetaJSON(fields)
+	case len(keys) == 1:
+		fmt.Println(metaText(fields.Content[1]))
+	default:
+		for i := 0; i+1 < len(fields.Content); i += 2 {
+			fmt.Printf("%s: %s\n", fields.Content[i].Value, strings.ReplaceAll(metaText(fields.Content[i+1]), "\n", ", "))
+		}
+	}
+	return nil
+}
+
+func printMetaJSON(node *yaml.Node) error {
+	var fields map[string]interface{}
+	if err := node.Decode(&fields); err != nil {
+		return err
+	}
+	out, err := json.MarshalIndent(fields, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+// metaValue renders a command-line value as a front matter value. YAML
+// literals keep their type; anything else becomes a quoted string.
+func metaValue(raw string) (string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(raw), &doc); err == nil && len(doc.Content) == 1 {
+		node := doc.Content[0]
+		switch {
+		case node.Kind == yaml.ScalarNode && (node.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0 || node.Tag != "!!str"):
+			return raw, nil
+		case (node.Kind == yaml.SequenceNode || node.Kind == yaml.MappingNode) && node.Style&yaml.FlowStyle != 0:
+			return raw, nil
+		}
+	}
+	if trimmed := strings.TrimSpace(raw); strings.HasPrefix(trimmed, "[") || strings.HasPrefix(trimmed, "{") {
+		return "", fmt.Errorf("%q is not a valid YAML list or map", raw)
+	}
+	return yamlQuote(raw), nil
+}
+
+// frontMatterFieldLines finds the lines of a top-level field: its key line and
+// any block list items, nested fields, or multi-line text under it
+func frontMatterFieldLines(lines []string, key string) (int, int, bool) {
+	for i, line := range lines {
+		if !strings.HasPrefix(line, key+":") {
+			continue
+		}
+		end := i + 1
+		for end < len(lines) && (strings.HasPrefix(lines[end], " ") || strings.HasPrefix(lines[end], "\t") || strings.HasPrefix(lines[end], "- ")) {
+			end++
+		}
+		return i, end, true
+	}
+	return 0, 0, false
+}
+
+// replaceFrontMatterField sets a top-level field to a one-line value, replacing
+// every line of the old value, or appends it when the field isn't there
+func replaceFrontMatterField(content, key, value string) string {
+	fm := frontMatterBlock(content)
+	lines := strings.Split(fm, "\n")
+	start, end, ok := frontMatterFieldLines(lines, key)
+	if !ok {
+		return upsertFrontMatterField(content, key, value)
+	}
+	updated := append(append(append([]string{}, lines[:start]...), key+": "+value), lines[end:]...)
+	return strings.Join(updated, "\n") + content[len(fm):]
+}
+
+// deleteFrontMatterField removes every line of a top-level field
+func deleteFrontMatterField(content, key string) string {
+	fm := frontMatterBlock(content)
+	lines := strings.Split(fm, "\n")
+	start, end, ok := frontMatterFieldLines(lines, key)
+	if !ok {
+		return content
+	}
+	updated := append(append([]string{}, lines[:start]...), lines[end:]...)
+	return strings.Join(updated, "\n") + content[len(fm):]
+}
+
+// applyMetaAssignment applies one key=value, key+=value, key-=value, or key?=value
+func applyMetaAssignment(content, assignment string) (string, error) {
+	eq := strings.Index(assignment, "=")
+	if eq < 1 {
+		return content, fmt.Errorf("expected key=value, got %q", assignment)
+	}
+	key, raw, op := assignment[:eq], assignment[eq+1:], byte('=')
+	if last := key[len(key)-1]; last == '+' || last == '-' || last == '?' {
+		key, op = key[:len(key)-1], last
+	}
+	if !metaKeyRegex.MatchString(key) {
+		return content, fmt.Errorf("invalid key %q (only top-level fields can be set)", key)
+	}
+
+	switch op {
+	case '+':
+		items := frontMatterList(content, key)
+		for _, item := range items {
+			if item == raw {
+				return content, nil
+			}
+		}
+		return replaceFrontMatterField(content, key, yamlList(append(items, raw))), nil
+	case '-':
+		var kept []string
+		for _, item := range frontMatterList(content, key) {
+			if item != raw {
+				kept = append(kept, item)
+			}
+		}
+		return replaceFrontMatterField(content, key, yamlList(kept)), nil
+	case '?':
+		if hasFrontMatterField(content, key) {
+			return content, nil
+		}
+	}
+	value, err := metaValue(raw)
+	if err != nil {
+		return content, fmt.Errorf("%s: %w", key, err)
+	}
+	return replaceFrontMatterField(content, key, value), nil
+}
+
+func runMetaSet(postPath string, assignments []string) error {
+	return editPostMeta(postPath, func(content string) (string, error) {
+		for _, a := range assignments {
+			var err error
+			if content, err = applyMetaAssignment(content, a); err != nil {
+				return content, err
+			}
+		}
+		return content, nil
+	})
+}
+
+func runMetaUnset(postPath string, keys []string) error {
+	return editPostMeta(postPath, func(content string) (string, error) {
+		for _, key := range keys {
+			if !metaKeyRegex.MatchString(key) {
+				return content, fmt.Errorf("invalid key %q (only top-level fields can be removed)", key)
+			}
+			content = deleteFrontMatterField(content, key)
+		}
+		return content, nil
+	})
+}
+
+// editPostMeta rewrites a post's front matter with edit, under the site lock
+// when the post is in a site, refusing results that no longer parse. A
+// directory is edited post by post as one transaction.
+func editPostMeta(postPath string, edit func(content string) (string, error)) error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	if info, err := os.Stat(postPath); err == nil && info.IsDir() {
+		return editPostsMeta(postPath, edit)
+	}
+
+	apply := func() error {
+		data, err := os.ReadFile(postPath)
+		if err != nil {
+			return fmt.Errorf("failed to read post: %w", err)
+		}
+		content := string(data)
+		if _, err := parseFrontMatter(content); err != nil {
+			return fmt.Errorf("%s: %w", postPath, err)
+		}
+
+		updated, err := edit(content)
+		if err != nil {
+			return err
+		}
+		if _, err := parseFrontMatter(updated); err != nil {
+			return fmt.Errorf("not writing %s: the edit would leave %w", postPath, err)
+		}
+
+		if dryRun {
+			fmt.Println(frontMatterBlock(updated) + "\n---")
+			return nil
+		}
+		if updated == content {
+			logInfo("No changes to %s", postPath)
+			return nil
+		}
+		if err := os.WriteFile(postPath, []byte(updated), 0644); err != nil {
+			return fmt.Errorf("failed to write post: %w", err)
+		}
+		recordSiteChange("modified", postPath, "", false)
+		logSuccess("✅ Updated front matter: %s", postPath)
+		return nil
+	}
+
+	if root := findSiteRoot(filepath.Dir(postPath)); root != "" && !dryRun {
+		return withSiteLock(root, "editing "+filepath.Base(postPath), apply)
+	}
+	return apply()
+}
+
+// editPostsMeta stages edit for every post under dir and applies the edits as
+// one transaction. Files without front matter are skipped; an edit that fails
+// or breaks any post's front matter stops the whole run before anything changes.
+func editPostsMeta(dir string, edit func(content string) (string, error)) error {
+	basePath := findSiteRoot(dir)
+	if basePath == "" {
+		return fmt.Errorf("%s is not inside a Hugo site (no content/ directory above it)", dir)
+	}
+	if err := checkWorkspace(basePath); err != nil {
+		return err
+	}
+
+	txn := newSiteTxn(basePath, "meta "+filepath.Base(dir))
+	skipped := 0
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".md") {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		content := string(data)
+		if _, err := parseFrontMatter(content); err != nil {
+			logInfo("Skipping %s: %v", path, err)
+			skipped++
+			return nil
+		}
+		updated, err := edit(content)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if _, err := parseFrontMatter(updated); err != nil {
+			return fmt.Errorf("not editing %s: the edit would leave %w", path, err)
+		}
+		return txn.write(path, []byte(updated), "", frontMatterString(content, "ai_generated") == "true")
+	})
+	if err != nil {
+		return err
+	}
+	if skipped > 0 {
+		logInfo("Skipped %d file(s) without readable front matter", skipped)
+	}
+	_, err = applyTxn(txn)
+	return err
+}
@@ -0,0 +1,332 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// pageMetadata is the structured metadata extracted from a fetched web page.
+// It combines JSON-LD, OpenGraph, Twitter card, and plain HTML fallbacks.
+type pageMetadata struct {
+	URL         string
+	Title       string
+	Description string
+	SiteName    string
+	Author      string
+	Published   string
+	Favicon     string
+	Images      []pageImage
+}
+
+// pageImage is an image candidate discovered in page metadata or markup.
+// Width and Height are zero when the page did not declare them.
+type pageImage struct {
+	URL    string
+	Alt    string
+	Width  int
+	Height int
+	Source string // og, twitter, jsonld, hero, article
+}
+
+// extractPageMetadata parses everything downstream stages need from a page's HTML
+func extractPageMetadata(html, pageURL string) pageMetadata {
+	ld := extractJSONLD(html)
+
+	meta := pageMetadata{URL: pageURL}
+
+	meta.Title = firstNonEmpty(
+		extractMetaContent(html, "property", "og:title"),
+		extractMetaContent(html, "name", "twitter:title"),
+		jsonLDString(ld, "headline"),
+		extractTitleTag(html),
+	)
+	meta.Description = firstNonEmpty(
+		extractMetaContent(html, "property", "og:description"),
+		extractMetaContent(html, "name", "twitter:description"),
+		jsonLDString(ld, "description"),
+		extractMetaContent(html, "name", "description"),
+	)
+	meta.SiteName = firstNonEmpty(
+		extractMetaContent(html, "property", "og:site_name"),
+		jsonLDString(ld, "publisher", "name"),
+		extractMetaContent(html, "name", "application-name"),
+	)
+	meta.Author = firstNonEmpty(
+		jsonLDString(ld, "author", "name"),
+		extractMetaContent(html, "name", "author"),
+		extractMetaContent(html, "property", "article:author"),
+		extractMetaContent(html, "name", "parsely-author"),
+	)
+	meta.Published = firstNonEmpty(
+		jsonLDString(ld, "datePublished"),
+		extractMetaContent(html, "property", "article:published_time"),
+		extractMetaContent(html, "itemprop", "datePublished"),
+		extractMetaContent(html, "name", "date"),
+		extractTimeDatetime(html),
+	)
+	meta.Favicon = extractFavicon(html, pageURL)
+	meta.Images = extractPageImages(html, ld, pageURL)
+
+	if parsed, err := url.Parse(pageURL); err == nil && meta.SiteName == "" {
+		meta.SiteName = strings.TrimPrefix(parsed.Host, "www.")
+	}
+
+	// article:author is frequently a profile URL rather than a name
+	if strings.HasPrefix(meta.Author, "http://") || strings.HasPrefix(meta.Author, "https://") {
+		meta.Author = ""
+	}
+
+	return meta
+}
+
+// extractPageImages collects image candidates in priority order: OpenGraph,
+// Twitter card, JSON-LD, hero/featured <img> tags, then the first article image
+func extractPageImages(html string, ld []map[string]interface{}, pageURL string) []pageImage {
+	var images []pageImage
+	seen := make(map[string]bool)
+	add := func(img pageImage) {
+		if img.URL == "" {
+			return
+		}
+		img.URL = makeAbsoluteURL(img.URL, pageURL)
+		if seen[img.URL] {
+			return
+		}
+		seen[img.URL] = true
+		images = append(images, img)
+	}
+
+	add(pageImage{
+		URL:    extractMetaContent(html, "property", "og:image"),
+		Alt:    extractMetaContent(html, "property", "og:image:alt"),
+		Width:  atoiOrZero(extractMetaContent(html, "property", "og:image:width")),
+		Height: atoiOrZero(extractMetaContent(html, "property", "og:image:height")),
+		Source: "og",
+	})
+	add(pageImage{
+		URL:    extractMetaContent(html, "name", "twitter:image"),
+		Alt:    extractMetaContent(html, "name", "twitter:image:alt"),
+		Source: "twitter",
+	})
+
+	for _, obj := range ld {
+		for _, img := range jsonLDImages(obj["image"]) {
+			add(img)
+		}
+	}
+
+	// Look for images with common hero/featured image patterns
+	heroPatterns := []string{
+		`<img[^>]*class=["'][^"']*hero[^"']*["'][^>]*src=["']([^"']+)["']`,
+		`<img[^>]*class=["'][^"']*featured[^"']*["'][^>]*src=["']([^"']+)["']`,
+		`<img[^>]*class=["'][^"']*main[^"']*["'][^>]*src=["']([^"']+)["']`,
+		`<img[^>]*src=["']([^"']+)["'][^>]*class=["'][^"']*hero[^"']*["']`,
+		`<img[^>]*src=["']([^"']+)["'][^>]*class=["'][^"']*featured[^"']*["']`,
+	}
+	for _, pattern := range heroPatterns {
+		regex := regexp.MustCompile(pattern)
+		if matches := regex.FindStringSubmatch(html); len(matches) > 1 {
+			add(imageFromTag(matches[0], matches[1], "hero"))
+		}
+	}
+
+	// Fallback: first img tag in article content, filtering tracking pixels, icons, etc.
+	articleImgRegex := regexp.MustCompile(`(?s)<article[^>]*>.*?(<img[^>]*src=["']([^"']+)["'][^>]*>)`)
+	if matches := articleImgRegex.FindStringSubmatch(html); len(matches) > 2 && isValidImageURL(matches[2]) {
+		add(imageFromTag(matches[1], matches[2], "article"))
+	}
+
+	return images
+}
+
+// imageFromTag builds a pageImage from an <img> tag, reading alt/width/height attributes
+func imageFromTag(tag, src, source string) pageImage {
+	img := pageImage{URL: src, Alt: findImageAlt(tag), Source: source}
+	if matches := regexp.MustCompile(`(?i)\swidth=["']?(\d+)`).FindStringSubmatch(tag); len(matches) > 1 {
+		img.Width = atoiOrZero(matches[1])
+	}
+	if matches := regexp.MustCompile(`(?i)\sheight=["']?(\d+)`).FindStringSubmatch(tag); len(matches) > 1 {
+		img.Height = atoiOrZero(matches[1])
+	}
+	return img
+}
+
+// jsonLDImages reads a schema.org image property, which may be a URL string,
+// an ImageObject, or an array of either
+func jsonLDImages(v interface{}) []pageImage {
+	switch val := v.(type) {
+	case string:
+		return []pageImage{{URL: val, Source: "jsonld"}}
+	case []interface{}:
+		var images []pageImage
+		for _, item := range val {
+			images = append(images, jsonLDImages(item)...)
+		}
+		return images
+	case map[string]interface{}:
+		img := pageImage{Source: "jsonld"}
+		if u, ok := val["url"].(string); ok {
+			img.URL = u
+		} else if u, ok := val["contentUrl"].(string); ok {
+			img.URL = u
+		}
+		img.Alt, _ = val["caption"].(string)
+		img.Width = jsonLDInt(val["width"])
+		img.Height = jsonLDInt(val["height"])
+		return []pageImage{img}
+	}
+	return nil
+}
+
+// jsonLDInt reads a dimension that may be a number, a string, or a QuantitativeValue
+func jsonLDInt(v interface{}) int {
+	switch val := v.(type) {
+	case float64:
+		return int(val)
+	case string:
+		return atoiOrZero(val)
+	case map[string]interface{}:
+		return jsonLDInt(val["value"])
+	}
+	return 0
+}
+
+func extractTitleTag(html string) string {
+	titleRegex := regexp.MustCompile(`<title[^>]*>([^<]+)</title>`)
+	if matches := titleRegex.FindStringSubmatch(html); len(matches) > 1 {
+		return cleanCaptionText(matches[1])
+	}
+	return ""
+}
+
+func atoiOrZero(s string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimSuffix(s, "px")))
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// extractMetaContent returns the content of a <meta> tag, accepting either attribute order
+func extractMetaContent(html, attr, name string) string {
+	quoted := regexp.QuoteMeta(name)
+	patterns := []string{
+		`(?i)<meta[^>]*` + attr + `=["']` + quoted + `["'][^>]*content=["']([^"']+)["']`,
+		`(?i)<meta[^>]*content=["']([^"']+)["'][^>]*` + attr + `=["']` + quoted + `["']`,
+	}
+	for _, pattern := range patterns {
+		if matches := regexp.MustCompile(pattern).FindStringSubmatch(html); len(matches) > 1 {
+			return cleanCaptionText(matches[1])
+		}
+	}
+	return ""
+}
+
+// extractFavicon finds the best icon link on the page, falling back to /favicon.ico
+func extractFavicon(html, pageURL string) string {
+	for _, rel := range []string{"apple-touch-icon", "icon", "shortcut icon"} {
+		patterns := []string{
+			`(?i)<link[^>]*rel=["']` + rel + `["'][^>]*href=["']([^"']+)["']`,
+			`(?i)<link[^>]*href=["']([^"']+)["'][^>]*rel=["']` + rel + `["']`,
+		}
+		for _, pattern := range patterns {
+			if matches := regexp.MustCompile(pattern).FindStringSubmatch(html); len(matches) > 1 {
+				return makeAbsoluteURL(matches[1], pageURL)
+			}
+		}
+	}
+
+	parsed, err := url.Parse(pageURL)
+	if err != nil || parsed.Host == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s://%s/favicon.ico", parsed.Scheme, parsed.Host)
+}
+
+func extractTimeDatetime(html string) string {
+	timeRegex := regexp.MustCompile(`(?i)<time[^>]*datetime=["']([^"']+)["']`)
+	if matches := timeRegex.FindStringSubmatch(html); len(matches) > 1 {
+		return matches[1]
+	}
+	return ""
+}
+
+// extractJSONLD decodes every application/ld+json block on the page into a flat
+// list of schema.org objects (expanding @graph containers and top-level arrays)
+func extractJSONLD(html string) []map[string]interface{} {
+	scriptRegex := regexp.MustCompile(`(?is)<script[^>]*type=["']application/ld\+json["'][^>]*>(.*?)</script>`)
+
+	var objects []map[string]interface{}
+	var collect func(v interface{})
+	collect = func(v interface{}) {
+		switch val := v.(type) {
+		case []interface{}:
+			for _, item := range val {
+				collect(item)
+			}
+		case map[string]interface{}:
+			if graph, ok := val["@graph"]; ok {
+				collect(graph)
+			}
+			objects = append(objects, val)
+		}
+	}
+
+	for _, matches := range scriptRegex.FindAllStringSubmatch(html, -1) {
+		var data interface{}
+		if err := json.Unmarshal([]byte(strings.TrimSpace(matches[1])), &data); err != nil {
+			continue
+		}
+		collect(data)
+	}
+
+	return objects
+}
+
+// jsonLDString walks a key path through the JSON-LD objects and returns the first
+// string found. Arrays pick their first element; objects are unwrapped by "name".
+func jsonLDString(objects []map[string]interface{}, path ...string) string {
+	for _, obj := range objects {
+		var current interface{} = obj
+		for _, key := range path {
+			current = jsonLDFirst(current)
+			m, ok := current.(map[string]interface{})
+			if !ok {
+				current = nil
+				break
+			}
+			current = m[key]
+		}
+		current = jsonLDFirst(current)
+		if m, ok := current.(map[string]interface{}); ok {
+			current = m["name"]
+		}
+		if s, ok := current.(string); ok && strings.TrimSpace(s) != "" {
+			return strings.TrimSpace(s)
+		}
+	}
+	return ""
+}
+
+func jsonLDFirst(v interface{}) interface{} {
+	if arr, ok := v.([]interface{}); ok {
+		if len(arr) == 0 {
+			return nil
+		}
+		return arr[0]
+	}
+	return v
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,642 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	platformDevTo    = "devto"
+	platformMedium   = "medium"
+	platformHashnode = "hashnode"
+)
+
+var (
+	metricsPlatforms []string
+	metricsSince     string
+	metricsFormat    string
+)
+
+// errMetricsNotConfigured means a platform has no credentials set, so it's skipped quietly
+var errMetricsNotConfigured = errors.New("no credentials set")
+
+// metricsSyncPlatforms are the platforms metrics sync knows, in the order it reads them
+var metricsSyncPlatforms = []string{platformDevTo, platformMedium, platformHashnode, platformMastodon, platformBluesky}
+
+var metricsCmd = &cobra.Command{
+	Use:   "metrics",
+	Short: "Collect and report engagement with cross-posted and shared posts",
+}
+
+var metricsSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Pull views, likes, and comments from the platforms posts went out on",
+	Long: `Reads the engagement of the site's posts on each platform and appends it to
+the metrics history (.megafone/history/metrics.jsonl), so a report can show how it grows.
+
+  devto     articles whose canonical_url points at the site (DEVTO_API_KEY)
+  hashnode  posts in HASHNODE_PUBLICATION (e.g. blog.example.com); drafts and
+            private stats need HASHNODE_TOKEN
+  mastodon  statuses sent by 'megafone schedule run'
+  bluesky   posts sent by 'megafone schedule run'
+  medium    skipped: Medium has no API for stats
+
+Platforms without credentials are skipped.
+
+Examples:
+  megafone metrics sync -s ~/hugo
+  megafone metrics sync -s ~/hugo --platforms devto,mastodon`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runMetricsSync(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+var metricsReportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Show which platforms and topics drive engagement",
+	Long: `Totals the latest numbers for each post on each platform, by platform and by
+topic (the posts' tags), with the posts that did best.
+
+Examples:
+  megafone metrics report -s ~/hugo
+  megafone metrics report -s ~/hugo --since 2024-01-01 --format json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runMetricsReport(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(metricsCmd)
+	metricsCmd.AddCommand(metricsSyncCmd)
+	metricsCmd.AddCommand(metricsReportCmd)
+
+	for _, c := range []*cobra.Command{metricsSyncCmd, metricsReportCmd} {
+		c.Flags().StringVarP(&siteSource, "site-source", "s", "", "Path to local Hugo site repository (default: found from the working directory)")
+	}
+	metricsSyncCmd.Flags().StringSliceVar(&metricsPlatforms, "platforms", metricsSyncPlatforms, "Platforms to read (devto, medium, hashnode, mastodon, bluesky)")
+	metricsSyncCmd.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "Show the numbers without saving them")
+	metricsReportCmd.Flags().StringVar(&metricsSince, "since", "", "Only count posts on platforms since this date, 2006-01-02")
+	metricsReportCmd.Flags().StringVar(&metricsFormat, "format", "table", "Output format: table or json")
+}
+
+// metricsSnapshot is one platform's numbers for one post at one time
+type metricsSnapshot struct {
+	Time     time.Time `json:"time"`
+	Platform string    `json:"platform"`
+	Post     string    `json:"post,omitempty"` // relative to the site root, when known
+	URL      string    `json:"url"`            // the post on the platform
+	Views    int       `json:"views,omitempty"`
+	Likes    int       `json:"likes"`
+	Comments int       `json:"comments"`
+	Shares   int       `json:"shares,omitempty"`
+}
+
+func (s metricsSnapshot) engagement() int {
+	return s.Likes + s.Comments + s.Shares
+}
+
+func metricsPath(basePath string) string {
+	return workspacePath(basePath, "history", "metrics.jsonl")
+}
+
+func loadMetrics(basePath string) ([]metricsSnapshot, error) {
+	f, err := os.Open(metricsPath(basePath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var snapshots []metricsSnapshot
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var s metricsSnapshot
+		if err := json.Unmarshal(scanner.Bytes(), &s); err != nil {
+			return nil, fmt.Errorf("invalid metrics history %s line %d: %w", metricsPath(basePath), line, err)
+		}
+		snapshots = append(snapshots, s)
+	}
+	return snapshots, scanner.Err()
+}
+
+func appendMetrics(basePath string, snapshots []metricsSnapshot) error {
+	path := metricsPath(basePath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	enc := json.NewEncoder(f)
+	for _, s := range snapshots {
+		if err := enc.Encode(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sitePostsByPath maps the path of each of the site's posts on the site (e.g.
+// /posts/my-post/) to the post's path relative to the site root, so
+// cross-posts can be matched by their canonical URL
+func sitePostsByPath(basePath string) (map[string]string, error) {
+	posts, err := loadSitePosts(basePath)
+	if err != nil {
+		return nil, err
+	}
+	pattern := sitePermalink(basePath)
+	byPath := make(map[string]string)
+	for _, p := range posts {
+		link := p.URL
+		if link == "" {
+			link = permalinkFor(pattern, p.Path, p.Slug, p.Time())
+		}
+		byPath[urlPath(link)] = filepath.ToSlash(mustRel(basePath, p.Path))
+	}
+	return byPath, nil
+}
+
+// urlPath is the path of a link with a trailing slash, ignoring its host and query
+func urlPath(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+	return strings.TrimRight(u.Path, "/") + "/"
+}
+
+func runMetricsSync() error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	basePath, err := scheduleSitePath("")
+	if err != nil {
+		return err
+	}
+	known := make(map[string]bool)
+	for _, p := range metricsSyncPlatforms {
+		known[p] = true
+	}
+	for _, p := range metricsPlatforms {
+		if !known[p] {
+			return fmt.Errorf("unknown platform %q (use devto, medium, hashnode, mastodon, or bluesky)", p)
+		}
+	}
+	posts, err := sitePostsByPath(basePath)
+	if err != nil {
+		return fmt.Errorf("failed to load posts: %w", err)
+	}
+	queue, err := loadScheduleQueue(basePath)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+	var snapshots []metricsSnapshot
+	for _, platform := range metricsPlatforms {
+		var got []metricsSnapshot
+		var err error
+		switch platform {
+		case platformDevTo:
+			got, err = devToMetrics(ctx, posts)
+		case platformHashnode:
+			got, err = hashnodeMetrics(ctx, posts)
+		case platformMastodon:
+			got, err = mastodonMetrics(ctx, queue)
+		case platformBluesky:
+			got, err = blueskyMetrics(ctx, queue)
+		case platformMedium:
+			logInfo("⏭️  Skipping Medium: it has no API for stats")
+			continue
+		}
+		if errors.Is(err, errMetricsNotConfigured) {
+			logVerbose("Skipping %s: %v", platform, err)
+			continue
+		}
+		if err != nil {
+			logError("Failed to read %s metrics: %v", platform, err)
+			continue
+		}
+		for i := range got {
+			got[i].Time = now
+			got[i].Platform = platform
+		}
+		logInfo("📈 %s: %d posts", platform, len(got))
+		snapshots = append(snapshots, got...)
+	}
+
+	if len(snapshots) == 0 {
+		logInfo("No metrics found")
+		return nil
+	}
+	if dryRun {
+		printMetricsSnapshots(snapshots)
+		return nil
+	}
+	if err := appendMetrics(basePath, snapshots); err != nil {
+		return fmt.Errorf("failed to save metrics: %w", err)
+	}
+	logSuccess("Saved %d snapshots to %s", len(snapshots), metricsPath(basePath))
+	return nil
+}
+
+// devToMetrics reads the numbers of the DEVTO_API_KEY account's articles
+// whose canonical URL is one of the site's posts
+func devToMetrics(ctx context.Context, posts map[string]string) ([]metricsSnapshot, error) {
+	apiKey := os.Getenv("DEVTO_API_KEY")
+	if apiKey == "" {
+		return nil, errMetricsNotConfigured
+	}
+	var snapshots []metricsSnapshot
+	for page := 1; ; page++ {
+		var articles []struct {
+			URL          string `json:"url"`
+			CanonicalURL string `json:"canonical_url"`
+			Views        int    `json:"page_views_count"`
+			Reactions    int    `json:"public_reactions_count"`
+			Comments     int    `json:"comments_count"`
+		}
+		endpoint := fmt.Sprintf(devToAPI+"/articles/me/all?per_page=100&page=%d", page)
+		if err := getSocialJSON(ctx, "dev.to", endpoint, map[string]string{"api-key": apiKey}, &articles); err != nil {
+			return nil, err
+		}
+		for _, a := range articles {
+			post, ok := posts[urlPath(a.CanonicalURL)]
+			if !ok || a.CanonicalURL == "" {
+				continue
+			}
+			snapshots = append(snapshots, metricsSnapshot{Post: post, URL: a.URL, Views: a.Views, Likes: a.Reactions, Comments: a.Comments})
+		}
+		if len(articles) < 100 {
+			return snapshots, nil
+		}
+	}
+}
+
+// hashnodeMetrics reads the numbers of the posts in HASHNODE_PUBLICATION
+// whose canonical URL is one of the site's posts
+func hashnodeMetrics(ctx context.Context, posts map[string]string) ([]metricsSnapshot, error) {
+	host := os.Getenv("HASHNODE_PUBLICATION")
+	if host == "" {
+		return nil, errMetricsNotConfigured
+	}
+	headers := map[string]string{}
+	if token := os.Getenv("HASHNODE_TOKEN"); token != "" {
+		headers["Authorization"] = token
+	}
+
+	const query = `query Posts($host: String!, $after: String) {
+  publication(host: $host) {
+    posts(first: 50, after: $after) {
+      edges { node { url canonicalUrl views reactionCount responseCount } }
+      pageInfo { hasNextPage endCursor }
+    }
+  }
+}`
+	var snapshots []metricsSnapshot
+	var after *string
+	for {
+		var reply struct {
+			Data struct {
+				Publication *struct {
+					Posts struct {
+						Edges []struct {
+							Node struct {
+								URL           string `json:"url"`
+								CanonicalURL  string `json:"canonicalUrl"`
+								Views         int    `json:"views"`
+								ReactionCount int    `json:"reactionCount"`
+								ResponseCount int    `json:"responseCount"`
+							} `json:"node"`
+						} `json:"edges"`
+						PageInfo struct {
+							HasNextPage bool   `json:"hasNextPage"`
+							EndCursor   string `json:"endCursor"`
+						} `json:"pageInfo"`
+					} `json:"posts"`
+				} `json:"publication"`
+			} `json:"data"`
+			Errors []struct {
+				Message string `json:"message"`
+			} `json:"errors"`
+		}
+		body := map[string]interface{}{
+			"query":     query,
+			"variables": map[string]interface{}{"host": host, "after": after},
+		}
+		if err := postSocialJSON(ctx, "Hashnode", hashnodeAPI, headers, body, &reply); err != nil {
+			return nil, err
+		}
+		if len(reply.Errors) > 0 {
+			return nil, fmt.Errorf("Hashnode API error: %s", reply.Errors[0].Message)
+		}
+		if reply.Data.Publication == nil {
+			return nil, fmt.Errorf("no Hashnode publication at %s", host)
+		}
+
+		for _, edge := range reply.Data.Publication.Posts.Edges {
+			n := edge.Node
+			// Posts written on Hashnode first have no canonical URL
+			post, ok := posts[urlPath(n.CanonicalURL)]
+			if !ok || n.CanonicalURL == "" {
+				continue
+			}
+			snapshots = append(snapshots, metricsSnapshot{Post: post, URL: n.URL, Views: n.Views, Likes: n.ReactionCount, Comments: n.ResponseCount})
+		}
+		info := reply.Data.Publication.Posts.PageInfo
+		if !info.HasNextPage || info.EndCursor == "" {
+			return snapshots, nil
+		}
+		cursor := info.EndCursor
+		after = &cursor
+	}
+}
+
+// sentPosts returns the site post and social post URL of each post the schedule sent on a platform
+func sentPosts(queue *scheduleQueue, platform string) map[string]string {
+	sent := make(map[string]string)
+	for _, item := range queue.Items {
+		if link := item.Sent[platform]; link != "" {
+			sent[link] = item.Post
+		}
+	}
+	return sent
+}
+
+// mastodonMetrics reads the favourites, boosts, and replies of the statuses
+// the schedule sent. Public statuses need no token.
+func mastodonMetrics(ctx context.Context, queue *scheduleQueue) ([]metricsSnapshot, error) {
+	sent := sentPosts(queue, platformMastodon)
+	if len(sent) == 0 {
+		return nil, nil
+	}
+	token := os.Getenv("MASTODON_ACCESS_TOKEN")
+	server := strings.TrimPrefix(strings.TrimPrefix(strings.TrimRight(os.Getenv("MASTODON_SERVER"), "/"), "https://"), "http://")
+
+	var snapshots []metricsSnapshot
+	for link, post := range sent {
+		// Status URLs look like https://server/@user/<id>
+		u, err := url.Parse(link)
+		if err != nil {
+			continue
+		}
+		// The token only goes to the server it belongs to
+		headers := map[string]string{}
+		if token != "" && u.Host == server {
+			headers["Authorization"] = "Bearer " + token
+		}
+		id := u.Path[strings.LastIndex(u.Path, "/")+1:]
+		var status struct {
+			Favourites int `json:"favourites_count"`
+			Reblogs    int `json:"reblogs_count"`
+			Replies    int `json:"replies_count"`
+		}
+		endpoint := u.Scheme + "://" + u.Host + "/api/v1/statuses/" + url.PathEscape(id)
+		if err := getSocialJSON(ctx, "Mastodon", endpoint, headers, &status); err != nil {
+			logError("Failed to read %s: %v", link, err)
+			continue
+		}
+		snapshots = append(snapshots, metricsSnapshot{Post: post, URL: link, Likes: status.Favourites, Comments: status.Replies, Shares: status.Reblogs})
+	}
+	return snapshots, nil
+}
+
+// blueskyMetrics reads the likes, reposts, quotes, and replies of the posts
+// the schedule sent, from Bluesky's public API
+func blueskyMetrics(ctx context.Context, queue *scheduleQueue) ([]metricsSnapshot, error) {
+	sent := sentPosts(queue, platformBluesky)
+	if len(sent) == 0 {
+		return nil, nil
+	}
+	const appView = "https://public.api.bsky.app/xrpc/"
+
+	dids := make(map[string]string)
+	var snapshots []metricsSnapshot
+	for link, post := range sent {
+		// Post URLs look like https://bsky.app/profile/<handle>/post/<rkey>
+		parts := strings.Split(strings.Trim(strings.TrimPrefix(link, "https://bsky.app/"), "/"), "/")
+		if len(parts) != 4 || parts[0] != "profile" || parts[2] != "post" {
+			continue
+		}
+		handle, rkey := parts[1], parts[3]
+		did, ok := dids[handle]
+		if !ok {
+			var resolved struct {
+				DID string `json:"did"`
+			}
+			if err := getSocialJSON(ctx, "Bluesky", appView+"com.atproto.identity.resolveHandle?handle="+url.QueryEscape(handle), nil, &resolved); err != nil {
+				logError("Failed to resolve %s: %v", handle, err)
+				continue
+			}
+			did = resolved.DID
+			dids[handle] = did
+		}
+
+		var reply struct {
+			Posts []struct {
+				LikeCount   int `json:"likeCount"`
+				RepostCount int `json:"repostCount"`
+				QuoteCount  int `json:"quoteCount"`
+				ReplyCount  int `json:"replyCount"`
+			} `json:"posts"`
+		}
+		uri := "at://" + did + "/app.bsky.feed.post/" + rkey
+		if err := getSocialJSON(ctx, "Bluesky", appView+"app.bsky.feed.getPosts?uris="+url.QueryEscape(uri), nil, &reply); err != nil {
+			logError("Failed to read %s: %v", link, err)
+			continue
+		}
+		if len(reply.Posts) == 0 {
+			logVerbose("Bluesky post %s no longer exists", link)
+			continue
+		}
+		p := reply.Posts[0]
+		snapshots = append(snapshots, metricsSnapshot{Post: post, URL: link, Likes: p.LikeCount, Comments: p.ReplyCount, Shares: p.RepostCount + p.QuoteCount})
+	}
+	return snapshots, nil
+}
+
+func printMetricsSnapshots(snapshots []metricsSnapshot) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PLATFORM\tVIEWS\tLIKES\tCOMMENTS\tSHARES\tPOST\tURL")
+	for _, s := range snapshots {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%s\t%s\n", s.Platform, s.Views, s.Likes, s.Comments, s.Shares, s.Post, s.URL)
+	}
+	w.Flush()
+}
+
+// metricsTotal is the engagement of a group of posts on the platforms
+type metricsTotal struct {
+	Name       string `json:"name"`
+	Posts      int    `json:"posts"`
+	Views      int    `json:"views"`
+	Likes      int    `json:"likes"`
+	Comments   int    `json:"comments"`
+	Shares     int    `json:"shares"`
+	Engagement int    `json:"engagement"`
+}
+
+func (t *metricsTotal) add(s metricsSnapshot) {
+	t.Posts++
+	t.Views += s.Views
+	t.Likes += s.Likes
+	t.Comments += s.Comments
+	t.Shares += s.Shares
+	t.Engagement += s.engagement()
+}
+
+type metricsReport struct {
+	Platforms []metricsTotal `json:"platforms"`
+	Topics    []metricsTotal `json:"topics"`
+	TopPosts  []metricsTotal `json:"top_posts"`
+}
+
+func runMetricsReport() error {
+	basePath, err := scheduleSitePath("")
+	if err != nil {
+		return err
+	}
+	var since time.Time
+	if metricsSince != "" {
+		if since, err = time.Parse("2006-01-02", metricsSince); err != nil {
+			return fmt.Errorf("invalid --since value %q (use 2006-01-02)", metricsSince)
+		}
+	}
+	if metricsFormat != "table" && metricsFormat != "json" {
+		return fmt.Errorf("invalid --format value %q (use table or json)", metricsFormat)
+	}
+
+	history, err := loadMetrics(basePath)
+	if err != nil {
+		return err
+	}
+	// The latest snapshot of each post on each platform holds its current
+	// numbers; a post first seen before --since is left out
+	latest := make(map[string]metricsSnapshot)
+	first := make(map[string]time.Time)
+	for _, s := range history {
+		key := s.Platform + " " + s.URL
+		if t, ok := first[key]; !ok || s.Time.Before(t) {
+			first[key] = s.Time
+		}
+		if s.Time.After(latest[key].Time) {
+			latest[key] = s
+		}
+	}
+	if len(latest) == 0 {
+		fmt.Println("No metrics yet; run 'megafone metrics sync' first")
+		return nil
+	}
+
+	posts, err := loadSitePosts(basePath)
+	if err != nil {
+		return fmt.Errorf("failed to load posts: %w", err)
+	}
+	tags := make(map[string][]string)
+	titles := make(map[string]string)
+	for _, p := range posts {
+		rel := filepath.ToSlash(mustRel(basePath, p.Path))
+		tags[rel] = p.Tags
+		titles[rel] = firstNonEmpty(p.Title, p.Slug)
+	}
+
+	platforms := make(map[string]*metricsTotal)
+	topics := make(map[string]*metricsTotal)
+	byPost := make(map[string]*metricsTotal)
+	total := func(m map[string]*metricsTotal, name string) *metricsTotal {
+		if m[name] == nil {
+			m[name] = &metricsTotal{Name: name}
+		}
+		return m[name]
+	}
+	for key, s := range latest {
+		if !since.IsZero() && first[key].Before(since) {
+			continue
+		}
+		total(platforms, s.Platform).add(s)
+		if s.Post == "" {
+			continue
+		}
+		total(byPost, firstNonEmpty(titles[s.Post], s.Post)).add(s)
+		for _, tag := range tags[s.Post] {
+			total(topics, strings.ToLower(tag)).add(s)
+		}
+	}
+
+	report := metricsReport{
+		Platforms: sortedMetricsTotals(platforms, 0),
+		Topics:    sortedMetricsTotals(topics, 15),
+		TopPosts:  sortedMetricsTotals(byPost, 10),
+	}
+	if metricsFormat == "json" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	for _, section := range []struct {
+		heading string
+		totals  []metricsTotal
+	}{
+		{"PLATFORM", report.Platforms},
+		{"TOPIC", report.Topics},
+		{"POST", report.TopPosts},
+	} {
+		if len(section.totals) == 0 {
+			continue
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "%s\tPOSTS\tVIEWS\tLIKES\tCOMMENTS\tSHARES\tENGAGEMENT\tPER POST\n", section.heading)
+		for _, t := range section.totals {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\t%d\t%.1f\n", trimToLength(t.Name, 50), t.Posts, t.Views, t.Likes, t.Comments, t.Shares,
+				t.Engagement, float64(t.Engagement)/float64(t.Posts))
+		}
+		w.Flush()
+		fmt.Println()
+	}
+	return nil
+}
+
+// sortedMetricsTotals orders totals by engagement, keeping the first limit (all if 0)
+func sortedMetricsTotals(m map[string]*metricsTotal, limit int) []metricsTotal {
+	totals := make([]metricsTotal, 0, len(m))
+	for _, t := range m {
+		totals = append(totals, *t)
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].Engagement != totals[j].Engagement {
+			return totals[i].Engagement > totals[j].Engagement
+		}
+		return totals[i].Name < totals[j].Name
+	})
+	if limit > 0 && len(totals) > limit {
+		totals = totals[:limit]
+	}
+	return totals
+}
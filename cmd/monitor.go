@@ -0,0 +1,502 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/spf13/cobra"
+)
+
+const (
+	sourceOK      = "ok"
+	sourceChanged = "changed"
+	sourceGone    = "gone"
+)
+
+var (
+	monitorPosts     []string
+	monitorNoNotices bool
+)
+
+var monitorClient = newPolicyClient(30 * time.Second)
+
+// monitorConfig is the monitor block of megafone.yaml
+type monitorConfig struct {
+	Webhook   string  `yaml:"webhook"`   // Slack-compatible webhook for notices
+	Threshold float64 `yaml:"threshold"` // share of a source's sentences that must change to count (default 0.2)
+}
+
+var monitorCmd = &cobra.Command{
+	Use:   "monitor",
+	Short: "Watch the sources posts cite for changes",
+}
+
+var monitorRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Re-fetch cited sources and suggest notices for posts whose sources changed",
+	Long: `Fetches every external page the site's posts link to and compares it with the
+version seen last time (kept in .megafone/cache/monitor). The first run only
+records them.
+
+When a source is gone (404 or 410), or at least monitor.threshold of its
+sentences changed, the model reads the post's passage citing it next to the
+old and new source and, if the post is affected, writes a suggested correction
+or update notice to .megafone/notices/. If the post has a Wayback Machine
+snapshot of a gone source ('megafone archive'), the notice points at it.
+Notices are sent to monitor.webhook. Run it from cron or an automation, e.g.
+weekly.
+
+Examples:
+  megafone monitor run -s ~/hugo
+  megafone monitor run -s ~/hugo --post content/posts/en/my-post.md --dry-run`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runMonitor(cmd); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(monitorCmd)
+	monitorCmd.AddCommand(monitorRunCmd)
+
+	monitorRunCmd.Flags().StringSliceVar(&monitorPosts, "post", nil, "Only check the sources of these posts")
+	monitorRunCmd.Flags().BoolVar(&monitorNoNotices, "no-notices", false, "Record changes without asking the model for notices")
+	monitorRunCmd.Flags().StringVarP(&siteSource, "site-source", "s", "", "Path to local Hugo site repository (default: found from the working directory)")
+	monitorRunCmd.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "Report changes without saving them or writing notices")
+}
+
+type monitorState struct {
+	Sources map[string]*monitoredSource `json:"sources"`
+}
+
+// monitoredSource is what monitor run last saw of a cited page
+type monitoredSource struct {
+	Hash    string    `json:"hash"`
+	Status  string    `json:"status"`
+	Checked time.Time `json:"checked"`
+	Changed time.Time `json:"changed"`
+}
+
+func monitorStatePath(basePath string) string {
+	return workspacePath(basePath, "state", "monitor.json")
+}
+
+// monitorTextPath is where the text of a source seen last time is kept
+func monitorTextPath(basePath, sourceURL string) string {
+	sum := sha256.Sum256([]byte(sourceURL))
+	return workspacePath(basePath, "cache", "monitor", hex.EncodeToString(sum[:8])+".txt")
+}
+
+func loadMonitorState(basePath string) (*monitorState, error) {
+	s := &monitorState{Sources: make(map[string]*monitoredSource)}
+	data, err := os.ReadFile(monitorStatePath(basePath))
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("invalid monitor state %s: %w", monitorStatePath(basePath), err)
+	}
+	if s.Sources == nil {
+		s.Sources = make(map[string]*monitoredSource)
+	}
+	return s, nil
+}
+
+func saveMonitorState(basePath string, s *monitorState) error {
+	path := monitorStatePath(basePath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// sourceChange is a material change to a source and the posts citing it
+type sourceChange struct {
+	URL string
+	// FetchedURL is where the source was read from after redirects
+	FetchedURL string
+	Status     string // changed or gone
+	OldText    string
+	NewText    string
+	Posts      []sitePost
+}
+
+func runMonitor(cmd *cobra.Command) error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	basePath, err := scheduleSitePath("")
+	if err != nil {
+		return err
+	}
+	baseURL := siteBaseURL(basePath)
+	threshold := appConfig.Monitor.Threshold
+	if threshold <= 0 {
+		threshold = 0.2
+	}
+
+	posts, err := loadSitePosts(basePath)
+	if err != nil {
+		return fmt.Errorf("failed to load posts: %w", err)
+	}
+	only := make(map[string]bool)
+	for _, p := range monitorPosts {
+		abs, _ := filepath.Abs(p)
+		only[abs] = true
+	}
+	citing := make(map[string][]sitePost)
+	for _, p := range posts {
+		abs, _ := filepath.Abs(p.Path)
+		if p.Draft || len(only) > 0 && !only[abs] {
+			continue
+		}
+		for _, u := range postSourceURLs("---\n---\n"+p.Body, 50) {
+			if baseURL != "" && onSite(u, baseURL) || waybackSnapshotRegex.MatchString(u) {
+				continue
+			}
+			citing[u] = append(citing[u], p)
+		}
+	}
+	if len(citing) == 0 {
+		logInfo("No cited sources found")
+		return nil
+	}
+	urls := make([]string, 0, len(citing))
+	for u := range citing {
+		urls = append(urls, u)
+	}
+	sort.Strings(urls)
+
+	state, err := loadMonitorState(basePath)
+	if err != nil {
+		return err
+	}
+	logInfo("🔎 Checking %d sources cited by %d posts", len(urls), len(posts))
+
+	var changes []sourceChange
+	now := time.Now().UTC()
+	for _, u := range urls {
+		seen := state.Sources[u]
+		text, status, fetchedURL, err := fetchSourceText(u)
+		if err != nil {
+			logError("Failed to fetch %s: %v", u, err)
+			continue
+		}
+		if seen == nil {
+			seen = &monitoredSource{Status: status}
+			state.Sources[u] = seen
+		}
+		seen.Checked = now
+		textPath := monitorTextPath(basePath, u)
+		oldText := ""
+		if data, err := os.ReadFile(textPath); err == nil {
+			oldText = string(data)
+		}
+
+		if status == sourceGone {
+			if seen.Status != sourceGone {
+				logInfo("🚫 Gone: %s", u)
+				seen.Status, seen.Changed = sourceGone, now
+				changes = append(changes, sourceChange{URL: u, FetchedURL: fetchedURL, Status: sourceGone, OldText: oldText, Posts: citing[u]})
+			}
+			continue
+		}
+
+		hash := sha256.Sum256([]byte(text))
+		newHash := hex.EncodeToString(hash[:])
+		first := seen.Hash == ""
+		if newHash == seen.Hash && seen.Status != sourceGone {
+			continue
+		}
+		seen.Hash, seen.Status = newHash, sourceOK
+		if !dryRun {
+			if err := os.MkdirAll(filepath.Dir(textPath), 0755); err != nil {
+				return err
+			}
+			if err := os.WriteFile(textPath, []byte(text), 0644); err != nil {
+				return err
+			}
+		}
+		if first || oldText == "" {
+			continue
+		}
+		ratio := sentenceChangeRatio(oldText, text)
+		if ratio < threshold {
+			logVerbose("Minor change (%.0f%%): %s", ratio*100, u)
+			continue
+		}
+		logInfo("✏️  Changed (%.0f%% of sentences): %s", ratio*100, u)
+		seen.Status, seen.Changed = sourceChanged, now
+		changes = append(changes, sourceChange{URL: u, FetchedURL: fetchedURL, Status: sourceChanged, OldText: oldText, NewText: text, Posts: citing[u]})
+	}
+
+	if dryRun {
+		logInfo("Dry run mode - %d sources changed or gone; not saving", len(changes))
+		return nil
+	}
+	if err := saveMonitorState(basePath, state); err != nil {
+		return fmt.Errorf("failed to save monitor state: %w", err)
+	}
+	if len(changes) == 0 {
+		logSuccess("✅ No material changes")
+		return nil
+	}
+	if monitorNoNotices {
+		logInfo("%d sources changed or gone; skipping notices", len(changes))
+		return nil
+	}
+
+	apiKey, err := getOpenAIKey(cmd)
+	if err != nil {
+		return err
+	}
+	client := openai.NewClient(apiKey)
+	ctx := context.Background()
+	var written []string
+	for _, change := range changes {
+		// Both versions of the source go into the prompt
+		if err := checkLLMHost(change.URL); err != nil {
+			logError("Not writing notices for %s: %v", change.URL, err)
+			continue
+		}
+		if err := checkLLMHost(change.FetchedURL); err != nil {
+			logError("Not writing notices for %s: %v", change.URL, err)
+			continue
+		}
+		for _, post := range change.Posts {
+			path, err := writeCorrectionNotice(ctx, client, basePath, change, post)
+			if err != nil {
+				logError("Failed to write a notice for %s: %v", post.Path, err)
+				continue
+			}
+			if path != "" {
+				written = append(written, path)
+			}
+		}
+	}
+	if len(written) == 0 {
+		logSuccess("✅ The changes don't affect any post")
+		return nil
+	}
+
+	if webhook := appConfig.Monitor.Webhook; webhook != "" {
+		message := fmt.Sprintf("megafone monitor: %d suggested notices for changed sources\n%s", len(written), strings.Join(written, "\n"))
+		if err := sendWebhookText(webhook, message); err != nil {
+			logError("Failed to send notification: %v", err)
+		}
+	}
+	logSuccess("📝 Wrote %d suggested notices to %s", len(written), workspacePath(basePath, "notices"))
+	return nil
+}
+
+// fetchSourceText downloads a source and returns its text, or sourceGone for
+// a 404 or 410, and the URL it was read from after redirects. Other errors
+// are returned, since a page that fails to load once hasn't necessarily gone.
+func fetchSourceText(sourceURL string) (string, string, string, error) {
+	resp, err := monitorClient.Get(sourceURL)
+	if err != nil {
+		return "", "", "", err
+	}
+	defer resp.Body.Close()
+	fetchedURL := resp.Request.URL.String()
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return "", sourceGone, fetchedURL, nil
+	case resp.StatusCode != http.StatusOK:
+		return "", "", "", fmt.Errorf("HTTP error: %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return "", "", "", err
+	}
+	if !strings.Contains(resp.Header.Get("Content-Type"), "html") {
+		// PDFs and other files are compared byte for byte
+		sum := sha256.Sum256(body)
+		return hex.EncodeToString(sum[:]), sourceOK, fetchedURL, nil
+	}
+	return stripHTMLTags(string(body)), sourceOK, fetchedURL, nil
+}
+
+// sentenceChangeRatio is the share of sentences in either version that the
+// other doesn't have
+func sentenceChangeRatio(oldText, newText string) float64 {
+	sentences := func(text string) map[string]bool {
+		set := make(map[string]bool)
+		for _, s := range splitSentences(strings.Join(strings.Fields(text), " ")) {
+			set[s] = true
+		}
+		return set
+	}
+	before, after := sentences(oldText), sentences(newText)
+	if len(before)+len(after) == 0 {
+		return 0
+	}
+	differ := 0
+	for s := range before {
+		if !after[s] {
+			differ++
+		}
+	}
+	for s := range after {
+		if !before[s] {
+			differ++
+		}
+	}
+	return float64(differ) / float64(len(before)+len(after))
+}
+
+// sentenceDiff lists up to limit sentences only the first text has
+func sentenceDiff(a, b string, limit int) []string {
+	have := make(map[string]bool)
+	for _, s := range splitSentences(strings.Join(strings.Fields(b), " ")) {
+		have[s] = true
+	}
+	var out []string
+	for _, s := range splitSentences(strings.Join(strings.Fields(a), " ")) {
+		if !have[s] && len(out) < limit {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// citingPassage returns the paragraphs of a post that link to a source
+func citingPassage(body, sourceURL string) string {
+	var paras []string
+	for _, para := range strings.Split(body, "\n\n") {
+		if strings.Contains(para, sourceURL) {
+			paras = append(paras, strings.TrimSpace(para))
+		}
+	}
+	return strings.Join(paras, "\n\n")
+}
+
+// writeCorrectionNotice asks whether a source change affects a post and, if
+// it does, writes the suggested notice to .megafone/notices. It returns the
+// notice's path, or "" when the post isn't affected.
+func writeCorrectionNotice(ctx context.Context, client *openai.Client, basePath string, change sourceChange, post sitePost) (string, error) {
+	data, err := os.ReadFile(post.Path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read post: %w", err)
+	}
+	content := string(data)
+
+	archived := ""
+	for _, snapshot := range frontMatterList(content, "source_archives") {
+		if m := waybackSnapshotRegex.FindStringSubmatch(snapshot); m != nil && m[1] == change.URL {
+			archived = snapshot
+		}
+	}
+
+	var what string
+	if change.Status == sourceGone {
+		what = "The source no longer exists (HTTP 404/410)."
+		if archived != "" {
+			what += " An archived copy is at " + archived + "; suggest linking it."
+		}
+		what += "\n\nWhat the source said when last seen:\n" + summarizeTokens(2000, change.OldText)
+	} else {
+		what = fmt.Sprintf("The source changed.\n\nSentences removed:\n- %s\n\nSentences added:\n- %s",
+			strings.Join(sentenceDiff(change.OldText, change.NewText, 40), "\n- "),
+			strings.Join(sentenceDiff(change.NewText, change.OldText, 40), "\n- "))
+	}
+
+	prompt := fmt.Sprintf(`Post: %s
+Source: %s
+
+Where the post cites the source:
+%s
+
+%s
+
+Does this change make anything the post says wrong, outdated, or unsupported?
+If it does, write a short notice for the top of the post, in the author's voice,
+starting with "**Update (%s):**", saying what changed and what it means for the
+post, and list the sentences of the post that should be edited.
+Respond with only a JSON object: {"affected": true|false, "reason": "...", "notice": "...", "edits": ["..."]}`,
+		post.Title, change.URL, firstNonEmpty(citingPassage(post.Body, change.URL), summarizeTokens(1500, post.Body)),
+		what, time.Now().Format("January 2, 2006"))
+
+	resp, err := createChatCompletion(ctx, client, openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: "You are a careful editor maintaining the accuracy of published blog posts. You flag only changes that matter to what the post says. You output only JSON.",
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: prompt,
+			},
+		},
+		Temperature: 0.2,
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("no response from model")
+	}
+	var reply struct {
+		Affected bool     `json:"affected"`
+		Reason   string   `json:"reason"`
+		Notice   string   `json:"notice"`
+		Edits    []string `json:"edits"`
+	}
+	if err := decodeModelJSON(resp.Choices[0].Message.Content, &reply); err != nil {
+		return "", fmt.Errorf("failed to parse notice: %w", err)
+	}
+	if !reply.Affected || strings.TrimSpace(reply.Notice) == "" {
+		logVerbose("%s isn't affected by %s: %s", post.Path, change.URL, reply.Reason)
+		return "", nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Suggested notice for %s\n\n", mustRel(basePath, post.Path))
+	fmt.Fprintf(&b, "Source: %s (%s)\n", change.URL, change.Status)
+	if archived != "" {
+		fmt.Fprintf(&b, "Archived copy: %s\n", archived)
+	}
+	fmt.Fprintf(&b, "\nWhy: %s\n\n## Notice\n\n> %s\n", reply.Reason, strings.TrimSpace(reply.Notice))
+	if len(reply.Edits) > 0 {
+		b.WriteString("\n## Sentences to revisit\n\n")
+		for _, e := range reply.Edits {
+			fmt.Fprintf(&b, "- %s\n", e)
+		}
+	}
+
+	path := workspacePath(basePath, "notices", fmt.Sprintf("%s-%s.md", post.Slug, time.Now().Format("20060102")))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	// Several sources of one post changing on the same day share a file
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := f.WriteString(b.String() + "\n"); err != nil {
+		return "", err
+	}
+	logInfo("📝 %s: %s", post.Path, path)
+	return path, nil
+}
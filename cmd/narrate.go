@@ -0,0 +1,277 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/sashabaranov/go-openai"
+	"github.com/spf13/cobra"
+)
+
+var (
+	narrateSkip        []string
+	narrateSkipAuthors []string
+)
+
+// Conventional-commit chores, merges, and dependency bumps rarely matter to readers
+var defaultNarrateSkip = []string{
+	`^(chore|test|tests|ci|build|style)(\(.*\))?!?:`,
+	`^Merge (pull request|branch|remote-tracking)`,
+	`(?i)^bump .+ from .+ to `,
+}
+
+var narrateCmd = &cobra.Command{
+	Use:   "narrate-commits <owner/repo> <base>..<head>",
+	Short: "Write a narrative changelog post for a commit range",
+	Long: `Fetches the commits and diff stats between two refs and writes a "what changed
+and why it matters" post. Commits matching --skip patterns (by default chore/test/ci
+commits, merge commits, and dependency bumps) or written by --skip-authors are left out.
+
+Examples:
+  megafone narrate-commits michaeldvinci/megafone v1.2.0..v1.3.0 -s ~/hugo
+  megafone narrate-commits me/tool v2.0.0..main --skip '^docs:' --skip-authors github-actions[bot]`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runNarrateCommits(cmd, args[0], args[1]); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(narrateCmd)
+
+	narrateCmd.Flags().StringArrayVar(&narrateSkip, "skip", defaultNarrateSkip, "Regex for commit subjects to leave out (repeatable; replaces the defaults)")
+	narrateCmd.Flags().StringSliceVar(&narrateSkipAuthors, "skip-authors", []string{"dependabot[bot]", "renovate[bot]"}, "Commit authors to leave out")
+	narrateCmd.Flags().StringVarP(&tags, "tags", "T", "", "Comma-separated tags (AI will suggest if not provided)")
+	narrateCmd.Flags().StringVarP(&promptFile, "prompt", "p", "prompts/github-project.txt", "Path to prompt template file")
+	narrateCmd.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "Print generated content without writing files")
+	narrateCmd.Flags().StringVarP(&model, "model", "m", "gpt-4o", "OpenAI model to use")
+	narrateCmd.Flags().StringVarP(&siteSource, "site-source", "s", "", "Path to local Hugo site repository")
+}
+
+func runNarrateCommits(cmd *cobra.Command, repoArg, rangeArg string) error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	ctx := context.Background()
+
+	owner, repo, err := parseGitHubURL(repoArg)
+	if err != nil {
+		return fmt.Errorf("invalid repository: %w", err)
+	}
+	base, head, ok := strings.Cut(rangeArg, "..")
+	if !ok || base == "" || head == "" {
+		return fmt.Errorf("range must look like v1.2.0..v1.3.0, got %q", rangeArg)
+	}
+	head = strings.TrimPrefix(head, ".") // accept base...head too
+
+	var skip []*regexp.Regexp
+	for _, pattern := range narrateSkip {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid --skip pattern %q: %w", pattern, err)
+		}
+		skip = append(skip, re)
+	}
+
+	basePath, err := resolveSitePath()
+	if err != nil {
+		return err
+	}
+	apiKey, err := getOpenAIKey(cmd)
+	if err != nil {
+		return err
+	}
+
+	logInfo("🔍 Comparing %s/%s %s...%s", owner, repo, base, head)
+	comparison, err := fetchComparison(ctx, newGitHubClient(), owner, repo, base, head)
+	if err != nil {
+		return err
+	}
+
+	kept, skipped := filterCommits(comparison.Commits, skip, narrateSkipAuthors)
+	logInfo("Keeping %d of %d commits (%d filtered), %d files changed",
+		len(kept), len(comparison.Commits), skipped, len(comparison.Files))
+	if len(kept) == 0 {
+		return fmt.Errorf("every commit in %s was filtered out", rangeArg)
+	}
+
+	promptTemplate, err := loadPrompt(promptFile, "narrate", "https://github.com/"+owner+"/"+repo)
+	if err != nil {
+		return err
+	}
+
+	content, err := generateCommitNarrative(ctx, apiKey, promptTemplate, owner+"/"+repo, base, head, kept, comparison.Files, tags, model)
+	if err != nil {
+		return err
+	}
+
+	content = applyDatePolicy(content, postDateString(), basePath)
+	content = applyHighlights(ctx, openai.NewClient(apiKey), content)
+	if content, err = applySummarySections(ctx, openai.NewClient(apiKey), content, "narrate"); err != nil {
+		return err
+	}
+	content = applyAccessibility(content)
+	if content, err = applyDisclosure(content, basePath); err != nil {
+		return err
+	}
+	if content, err = applyFrontMatterDefaults(content, "narrate", "https://github.com/"+owner+"/"+repo); err != nil {
+		return err
+	}
+	if content, err = applySecretScan(content); err != nil {
+		return err
+	}
+	filename := sanitizeFilename(fmt.Sprintf("%s %s to %s", repo, base, head))
+	if content, filename, err = applySlugStrategy(content, filename, ""); err != nil {
+		return err
+	}
+
+	if dryRun {
+		logInfo("Dry run mode - not writing files")
+		fmt.Println("\n" + strings.Repeat("=", 80))
+		fmt.Println("DRY RUN - Generated Content:")
+		fmt.Println(strings.Repeat("=", 80))
+		fmt.Println(content)
+		fmt.Println(strings.Repeat("=", 80))
+		return nil
+	}
+
+	postPath, err := writeNewPost(basePath, filepath.Join(postsDir(basePath), filename+".md"), content)
+	if err != nil {
+		return fmt.Errorf("failed to write post: %w", err)
+	}
+	logSuccess("✅ Changelog narrative created: %s", postPath)
+
+	var tagList []string
+	if tags != "" {
+		tagList = strings.Split(tags, ",")
+	}
+	logGeneration(fmt.Sprintf("https://github.com/%s/%s/compare/%s...%s", owner, repo, base, head), postPath, "", tagList)
+	return runHooks("post_generate", appConfig.Hooks.PostGenerate, hookRun{Post: postPath, Source: fmt.Sprintf("https://github.com/%s/%s/compare/%s...%s", owner, repo, base, head), Site: basePath})
+}
+
+// fetchComparison pages through a compare so ranges over 250 commits are complete
+func fetchComparison(ctx context.Context, client *github.Client, owner, repo, base, head string) (*github.CommitsComparison, error) {
+	var result *github.CommitsComparison
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := client.Repositories.CompareCommits(ctx, owner, repo, base, head, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compare %s...%s: %w", base, head, err)
+		}
+		if result == nil {
+			result = page
+		} else {
+			result.Commits = append(result.Commits, page.Commits...)
+		}
+		if resp.NextPage == 0 {
+			return result, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+func filterCommits(commits []*github.RepositoryCommit, skip []*regexp.Regexp, skipAuthors []string) (kept []*github.RepositoryCommit, skipped int) {
+	authors := make(map[string]bool)
+	for _, a := range skipAuthors {
+		authors[strings.ToLower(a)] = true
+	}
+
+	for _, c := range commits {
+		subject, _, _ := strings.Cut(c.GetCommit().GetMessage(), "\n")
+		drop := authors[strings.ToLower(c.GetAuthor().GetLogin())]
+		for _, re := range skip {
+			if drop || re.MatchString(subject) {
+				drop = true
+				break
+			}
+		}
+		if drop {
+			skipped++
+			continue
+		}
+		kept = append(kept, c)
+	}
+	return kept, skipped
+}
+
+func generateCommitNarrative(ctx context.Context, apiKey, promptTemplate, fullName, base, head string, commits []*github.RepositoryCommit, files []*github.CommitFile, userTags, model string) (string, error) {
+	client := openai.NewClient(apiKey)
+
+	var log strings.Builder
+	for _, c := range commits {
+		message := strings.TrimSpace(c.GetCommit().GetMessage())
+		if len(message) > 600 {
+			message = message[:600] + "..."
+		}
+		fmt.Fprintf(&log, "- %s (@%s): %s\n", c.GetSHA()[:7], c.GetAuthor().GetLogin(), strings.ReplaceAll(message, "\n", " "))
+	}
+	history := log.String()
+	if len(history) > 16000 {
+		history = history[:16000] + "\n[commit log truncated]"
+	}
+
+	// The most-changed files show where the real work happened
+	sort.Slice(files, func(i, j int) bool { return files[i].GetChanges() > files[j].GetChanges() })
+	var stats strings.Builder
+	for i, f := range files {
+		if i == 40 {
+			fmt.Fprintf(&stats, "... and %d more files\n", len(files)-40)
+			break
+		}
+		fmt.Fprintf(&stats, "- %s (%s, +%d/-%d)\n", f.GetFilename(), f.GetStatus(), f.GetAdditions(), f.GetDeletions())
+	}
+
+	userPrompt := fmt.Sprintf(`%s
+
+Please write a narrative changelog post for %s covering %s to %s.
+
+Commits:
+%s
+
+Most-changed files:
+%s
+
+Instructions:
+- Explain what changed and why it matters to users, grouped into a few themes
+- Lead with the changes that most affect how people use the project
+- Mention breaking changes and migration steps prominently if there are any
+- Link notable commits as https://github.com/%s/commit/<sha>
+- Don't list every commit; tell the story of the release
+
+User-provided tags: %s (suggest appropriate tags if none provided)
+
+IMPORTANT: Your response must be ONLY valid markdown. Do not include any explanatory text before or after the markdown.
+IMPORTANT: Use date: %s in the front matter.
+
+Generate a complete Hugo markdown post following the style guide above.
+`, promptTemplate, fullName, base, head, history, stats.String(), fullName, userTags, postDateString())
+
+	resp, err := createChatCompletion(withStreaming(ctx), client, openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: "You are a maintainer who writes release stories people actually read. Follow the style guide precisely. Output ONLY the markdown content, no explanations.",
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: userPrompt,
+			},
+		},
+		Temperature: 0.6,
+	})
+	if err != nil {
+		return "", fmt.Errorf("OpenAI API error: %w", err)
+	}
+	if len(resp.Choices) == 0 || resp.Choices[0].Message.Content == "" {
+		return "", fmt.Errorf("no response from OpenAI")
+	}
+	return resp.Choices[0].Message.Content, nil
+}
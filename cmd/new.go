@@ -0,0 +1,262 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	newArchetype   string
+	newTags        []string
+	newDescription string
+	newImage       string
+	newNoDraft     bool
+	newForce       bool
+)
+
+// builtinArchetypes are the section skeletons used when the site has no
+// archetypes/<name>.md of its own
+var builtinArchetypes = map[string]string{
+	"default": `---
+title: "{{ .Title }}"
+date: {{ .Date }}
+draft: true
+---
+
+Open with the one thing a reader should take away.
+
+## Background
+
+## The Details
+
+## Trade-offs
+
+## Wrapping Up
+`,
+	"project": `---
+title: "{{ .Title }}"
+date: {{ .Date }}
+draft: true
+---
+
+What the project is, and who it's for.
+
+## What It Does
+
+## Getting Started
+
+## How It Works
+
+## Where It Fits
+
+## Verdict
+`,
+	"tutorial": `---
+title: "{{ .Title }}"
+date: {{ .Date }}
+draft: true
+---
+
+What you'll build, and what you need before starting.
+
+## Prerequisites
+
+## Step 1
+
+## Step 2
+
+## Step 3
+
+## Troubleshooting
+
+## Next Steps
+`,
+}
+
+var newCmd = &cobra.Command{
+	Use:   "new <title>",
+	Short: "Scaffold an empty post to write by hand",
+	Long: `Creates a post with the site's front matter conventions and a section
+skeleton, without calling a model. The slug, date, and front matter defaults
+follow the same settings as generated posts.
+
+The skeleton comes from the site's Hugo archetype (archetypes/<name>.md) when
+it has one, else from the built-in archetype of that name (default, project, or
+tutorial), else from archetypes/default.md. Archetypes can use .Title, .Name,
+.Date, .Type, and .File.ContentBaseName with the replace, title, lower, upper,
+and now functions.
+
+Examples:
+  megafone new "Why I Moved Off Kubernetes"
+  megafone new "Getting Started with Zig" --archetype tutorial --tags zig,tutorial
+  megafone new "A Look at htmx" --archetype project --image ~/Pictures/htmx.png`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runNew(args[0]); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(newCmd)
+
+	newCmd.Flags().StringVarP(&newArchetype, "archetype", "a", "default", "Archetype to scaffold from (the site's archetypes/, or built in: default, project, tutorial)")
+	newCmd.Flags().StringSliceVarP(&newTags, "tags", "t", nil, "Comma-separated tags")
+	newCmd.Flags().StringVar(&newDescription, "description", "", "Post description")
+	newCmd.Flags().StringVarP(&newImage, "image", "i", "", "Path to a hero image to copy into the site (default: an empty hero placeholder)")
+	newCmd.Flags().BoolVar(&newNoDraft, "no-draft", false, "Don't mark the post as a draft")
+	newCmd.Flags().BoolVarP(&newForce, "force", "f", false, "Overwrite an existing post with the same slug")
+	newCmd.Flags().StringVarP(&siteSource, "site-source", "s", "", "Path to local Hugo site repository (default: found from the working directory)")
+	newCmd.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "Print the post instead of writing it")
+}
+
+func runNew(title string) error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return fmt.Errorf("the post needs a title")
+	}
+	basePath, err := resolveSitePath()
+	if err != nil {
+		return err
+	}
+
+	date := postDateString()
+	// Slugs come from the title here; there's no model to suggest one
+	slug, err := slugFor(fmt.Sprintf("---\ntitle: %s\n---\n", yamlQuote(title)), title, "", postDate.time)
+	if err != nil {
+		return err
+	}
+
+	content, source, err := renderArchetype(basePath, newArchetype, title, slug, date)
+	if err != nil {
+		return err
+	}
+	logVerbose("Using archetype %s", source)
+
+	if frontMatterBlock(content) == "" {
+		content = "---\n---\n\n" + strings.TrimLeft(content, "\n")
+	}
+	// The archetype's layout is kept; the fields megafone owns are set as generate would
+	content = upsertFrontMatterField(content, "title", yamlQuote(title))
+	content = upsertFrontMatterField(content, "date", date)
+	content = upsertFrontMatterField(content, "draft", fmt.Sprint(!newNoDraft))
+	if newDescription != "" {
+		content = upsertFrontMatterField(content, "description", yamlQuote(newDescription))
+	}
+	if len(newTags) > 0 {
+		content = setFrontMatterList(content, "tags", newTags)
+	}
+	if content, err = applyFrontMatterDefaults(content, "manual", ""); err != nil {
+		return err
+	}
+	content = upsertFrontMatterField(content, "slug", yamlQuote(slug))
+	checkFutureDate(date, basePath)
+
+	postPath := filepath.Join(postsDir(basePath), slug+".md")
+	if _, err := os.Stat(postPath); err == nil && !newForce {
+		return fmt.Errorf("%s already exists (use --force to overwrite it)", postPath)
+	}
+
+	if newImage != "" && !dryRun {
+		imageName, err := processImageWithName(newImage, slug, basePath)
+		if err != nil {
+			return classify(ErrImage, fmt.Errorf("failed to process image: %w", err))
+		}
+		content = upsertFrontMatterField(content, "hero", "/images/site/"+imageName)
+		logSuccess("✅ Image copied: assets/images/site/%s", imageName)
+	} else if !hasFrontMatterField(content, "hero") {
+		content = upsertFrontMatterField(content, "hero", `""`)
+		content = insertAfterFrontMatter(content, fmt.Sprintf("<!-- Hero: save an image as assets/images/site/%s.jpg and set hero: /images/site/%s.jpg -->", slug, slug))
+	}
+
+	if dryRun {
+		logInfo("Dry run mode - not writing files")
+		fmt.Println("\n" + strings.Repeat("=", 80))
+		fmt.Printf("DRY RUN - %s:\n", postPath)
+		fmt.Println(strings.Repeat("=", 80))
+		fmt.Println(content)
+		fmt.Println(strings.Repeat("=", 80))
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(postPath), 0755); err != nil {
+		return fmt.Errorf("failed to create posts directory: %w", err)
+	}
+	if err := os.WriteFile(postPath, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write post: %w", err)
+	}
+	recordSiteChange("created", postPath, "", false)
+	logSuccess("✅ Post created: %s", postPath)
+	return nil
+}
+
+// renderArchetype renders the named archetype: the site's archetypes/<name>.md,
+// the built-in one by that name, then the site's archetypes/default.md. It
+// returns the post and where the archetype came from.
+func renderArchetype(basePath, name, title, slug, date string) (string, string, error) {
+	text, source := "", ""
+	site := func(n string) bool {
+		p := filepath.Join(basePath, "archetypes", n+".md")
+		data, err := os.ReadFile(p)
+		if err == nil {
+			text, source = string(data), p
+		}
+		return err == nil
+	}
+	builtin := func(n string) bool {
+		text, source = builtinArchetypes[n], "built-in "+n
+		return text != ""
+	}
+	if !site(name) && !builtin(name) {
+		logInfo("⚠️  No archetype named %q; using the default", name)
+		if !site("default") {
+			builtin("default")
+		}
+	}
+
+	funcs := template.FuncMap{
+		// Hugo's argument order: replace INPUT OLD NEW
+		"replace": func(s, old, new string) string { return strings.ReplaceAll(s, old, new) },
+		"title":   strings.Title,
+		"lower":   strings.ToLower,
+		"upper":   strings.ToUpper,
+		"now":     time.Now,
+	}
+	tmpl, err := template.New(source).Funcs(funcs).Parse(text)
+	if err != nil {
+		return "", source, fmt.Errorf("failed to parse archetype %s: %w", source, err)
+	}
+	data := map[string]interface{}{
+		"Title": title,
+		"Name":  title,
+		"Date":  date,
+		"Type":  "posts",
+		"File":  map[string]string{"ContentBaseName": slug, "BaseFileName": slug},
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", source, fmt.Errorf("failed to render archetype %s (megafone supports .Title, .Name, .Date, .Type, and .File.ContentBaseName): %w", source, err)
+	}
+	return buf.String(), source, nil
+}
+
+// insertAfterFrontMatter puts a line at the top of the post body
+func insertAfterFrontMatter(content, line string) string {
+	fm := frontMatterBlock(content)
+	if fm == "" {
+		return line + "\n\n" + content
+	}
+	end := len(fm) + len("\n---")
+	return content[:end] + "\n\n" + line + "\n\n" + strings.TrimLeft(content[end:], "\n")
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+var notesPath string
+
+// loadAuthorNotes reads the author's own notes on the topic: opinions, bullet
+// points, experiences, disagreements with the source
+func loadAuthorNotes(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read notes: %w", err)
+	}
+	notes := strings.TrimSpace(string(data))
+	if notes == "" {
+		return "", fmt.Errorf("notes file %s is empty", path)
+	}
+	return notes, nil
+}
+
+// authorNotesSection renders the author's notes for the generation prompt.
+// The notes are the post's point of view: the model argues them instead of
+// supplying opinions of its own.
+func authorNotesSection(notes string) string {
+	return `
+
+## Author's Notes (the post's point of view)
+These are the author's own views on the topic, in their words. They override
+any guidance above about offering a personal take:
+- Every opinion, judgment, recommendation, and prediction in the post must come
+  from these notes. Don't invent views, experiences, or anecdotes the notes
+  don't contain; where the notes are silent, explain and analyze the source
+  without taking a side.
+- Work each point into the post where it fits, as the author's argument in the
+  first person, supported with details from the source. Keep the author's
+  stance and strength of feeling; tidy the wording but don't soften it.
+- Where the notes disagree with the source, say so plainly and give the
+  author's reasoning.
+
+` + notes + "\n"
+}
@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	assetBucket   string
+	assetEndpoint string
+	assetRegion   string
+	assetPrefix   string
+	assetCDNURL   string
+)
+
+// addAssetStoreFlags registers the S3-compatible storage flags on a command
+func addAssetStoreFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&assetBucket, "asset-bucket", "", "S3-compatible bucket to upload images to instead of committing them")
+	cmd.Flags().StringVar(&assetEndpoint, "asset-endpoint", "", "S3 API endpoint, e.g. https://<account>.r2.cloudflarestorage.com (default: AWS S3 for --asset-region)")
+	cmd.Flags().StringVar(&assetRegion, "asset-region", "us-east-1", "Bucket region (use \"auto\" for R2)")
+	cmd.Flags().StringVar(&assetPrefix, "asset-prefix", "images", "Key prefix for uploaded objects")
+	cmd.Flags().StringVar(&assetCDNURL, "asset-cdn-url", "", "Public base URL that serves the bucket (default: the endpoint URL)")
+}
+
+// objectStore uploads objects to S3-compatible storage (S3, R2, B2, MinIO)
+// using path-style requests signed with AWS Signature Version 4
+type objectStore struct {
+	endpoint  string
+	bucket    string
+	region    string
+	prefix    string
+	publicURL string
+	accessKey string
+	secretKey string
+	client    *http.Client
+}
+
+// newObjectStore builds a store from the --asset-* flags and the standard
+// AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY variables. It returns nil when
+// no bucket is configured.
+func newObjectStore() (*objectStore, error) {
+	if assetBucket == "" {
+		return nil, nil
+	}
+
+	accessKey := os.Getenv("AWS_ACCESS_KEY_ID")
+	secretKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if accessKey == "" || secretKey == "" {
+		return nil, fmt.Errorf("--asset-bucket requires AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+
+	endpoint := strings.TrimRight(assetEndpoint, "/")
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", assetRegion)
+	}
+	publicURL := strings.TrimRight(assetCDNURL, "/")
+	if publicURL == "" {
+		publicURL = endpoint + "/" + assetBucket
+	}
+
+	return &objectStore{
+		endpoint:  endpoint,
+		bucket:    assetBucket,
+		region:    assetRegion,
+		prefix:    strings.Trim(assetPrefix, "/"),
+		publicURL: publicURL,
+		accessKey: accessKey,
+		secretKey: secretKey,
+		client:    &http.Client{Timeout: 2 * time.Minute},
+	}, nil
+}
+
+// put uploads data under name (below the configured prefix) and returns its public URL
+func (s *objectStore) put(ctx context.Context, name string, data []byte, headers map[string]string) (string, error) {
+	key := path.Join(s.prefix, name)
+	objectURL := fmt.Sprintf("%s/%s/%s", s.endpoint, s.bucket, key)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, objectURL, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	contentType := mime.TypeByExtension(filepath.Ext(name))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	s.sign(req, data, time.Now().UTC())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("upload of %s failed: %s: %s", key, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return s.publicURL + "/" + key, nil
+}
+
+// sign adds SigV4 authentication headers, signing every header already set
+func (s *objectStore) sign(req *http.Request, payload []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	payloadHash := sha256Hex(payload)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	// Canonical headers must be lowercase and sorted; host is not in req.Header
+	names := []string{"host"}
+	values := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		lower := strings.ToLower(name)
+		names = append(names, lower)
+		values[lower] = strings.TrimSpace(req.Header.Get(name))
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + values[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		(&url.URL{Path: req.URL.Path}).EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", day, s.region)
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), day)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// contentAddressedName adds a hash of data to an image name, so the uploaded
+// object can be cached forever: a regenerated image under the same slug gets
+// a new key instead of replacing what CDNs and inboxes already hold
+func contentAddressedName(name string, data []byte) string {
+	sum := sha256.Sum256(data)
+	ext := filepath.Ext(name)
+	return strings.TrimSuffix(name, ext) + "-" + hex.EncodeToString(sum[:])[:12] + ext
+}
+
+// offloadImages uploads the site images a post references to the object store,
+// rewrites the references to their public URLs, and removes local copies that
+// no other post uses so the binaries never reach git
+func offloadImages(ctx context.Context, store *objectStore, basePath, content string) (string, error) {
+	dir := siteImageDir(basePath)
+	usage := countImageUsage(basePath)
+
+	uploaded := make(map[string]bool)
+	for _, match := range siteImageRefRegex.FindAllStringSubmatch(content, -1) {
+		name := match[1]
+		if uploaded[name] {
+			continue
+		}
+		localPath := filepath.Join(dir, name)
+		data, err := os.ReadFile(localPath)
+		if err != nil {
+			continue // not a local file (already offloaded or a theme asset)
+		}
+
+		publicURL, err := store.put(ctx, contentAddressedName(name, data), data, map[string]string{
+			"Cache-Control": "public, max-age=31536000, immutable",
+		})
+		if err != nil {
+			return content, err
+		}
+		uploaded[name] = true
+		logSuccess("☁️  Uploaded %s → %s", name, publicURL)
+
+		content = strings.ReplaceAll(content, "/images/site/"+name, publicURL)
+		if usage[name] == 0 && os.Remove(localPath) == nil {
+			recordSiteChange("deleted", localPath, publicURL, false)
+		}
+	}
+	return content, nil
+}
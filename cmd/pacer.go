@@ -0,0 +1,315 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+var (
+	tpmBudget           int
+	providerConcurrency int
+)
+
+// providerLimits is a megafone.yaml block of pacing limits for one provider
+type providerLimits struct {
+	TPMBudget   int `yaml:"tpm_budget"`
+	Concurrency int `yaml:"concurrency"`
+}
+
+// pacerUsage is the shared per-provider state every megafone process reads and
+// updates under a file lock, so parallel runs pace against one budget
+type pacerUsage struct {
+	Window       []pacerEntry `json:"window"`
+	BlockedUntil time.Time    `json:"blocked_until"`
+}
+
+type pacerEntry struct {
+	At     time.Time `json:"at"`
+	Tokens int       `json:"tokens"`
+}
+
+func init() {
+	rootCmd.PersistentFlags().IntVar(&tpmBudget, "tpm-budget", 0, "Tokens per minute each provider may use across all running megafone processes (default: providers.<name>.tpm_budget in config, else unlimited)")
+	rootCmd.PersistentFlags().IntVar(&providerConcurrency, "provider-concurrency", 0, "Concurrent requests per provider across all running megafone processes (default: providers.<name>.concurrency in config, else unlimited)")
+}
+
+func limitsFor(provider string) providerLimits {
+	limits := appConfig.Providers[provider]
+	if tpmBudget > 0 {
+		limits.TPMBudget = tpmBudget
+	}
+	if providerConcurrency > 0 {
+		limits.Concurrency = providerConcurrency
+	}
+	return limits
+}
+
+func pacerDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(cacheDir, "megafone", "ratelimit")
+	return dir, os.MkdirAll(dir, 0755)
+}
+
+// pacerMu serializes withPacerState within the process; the file lock only
+// covers other processes, and isn't taken at all where flock is missing
+var pacerMu sync.Mutex
+
+// withPacerState loads, updates, and saves a provider's usage under an exclusive lock
+func withPacerState(provider string, update func(u *pacerUsage)) error {
+	pacerMu.Lock()
+	defer pacerMu.Unlock()
+
+	dir, err := pacerDir()
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filepath.Join(dir, provider+".json"), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := lockFile(f, false); err != nil {
+		return err
+	}
+	defer unlockFile(f)
+
+	var usage pacerUsage
+	if data, err := io.ReadAll(f); err == nil && len(data) > 0 {
+		json.Unmarshal(data, &usage)
+	}
+
+	// Keep only the last minute of usage
+	cutoff := time.Now().Add(-time.Minute)
+	kept := usage.Window[:0]
+	for _, e := range usage.Window {
+		if e.At.After(cutoff) {
+			kept = append(kept, e)
+		}
+	}
+	usage.Window = kept
+
+	update(&usage)
+
+	data, err := json.Marshal(usage)
+	if err != nil {
+		return err
+	}
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	_, err = f.WriteAt(data, 0)
+	return err
+}
+
+// paceRequest waits for a concurrency slot and enough token budget for a request
+// of roughly estimate tokens. The returned func releases the slot.
+func paceRequest(ctx context.Context, provider string, estimate int) (func(), error) {
+	limits := limitsFor(provider)
+	release, err := acquireProviderSlot(ctx, provider, limits.Concurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		var wait time.Duration
+		err := withPacerState(provider, func(u *pacerUsage) {
+			now := time.Now()
+			if u.BlockedUntil.After(now) {
+				wait = u.BlockedUntil.Sub(now)
+				return
+			}
+			if limits.TPMBudget > 0 && len(u.Window) > 0 {
+				used := 0
+				for _, e := range u.Window {
+					used += e.Tokens
+				}
+				if over := used + estimate - limits.TPMBudget; over > 0 {
+					// Wait until enough of the window expires to fit this request
+					freed := 0
+					for _, e := range u.Window {
+						freed += e.Tokens
+						if freed >= over {
+							wait = e.At.Add(time.Minute).Sub(now)
+							break
+						}
+					}
+					if wait <= 0 {
+						wait = time.Second
+					}
+					return
+				}
+			}
+			u.Window = append(u.Window, pacerEntry{At: now, Tokens: estimate})
+		})
+		if err != nil {
+			// Pacing is best-effort; a broken cache dir shouldn't stop generation
+			return release, nil
+		}
+		if wait <= 0 {
+			return release, nil
+		}
+
+		logInfo("⏳ Pacing %s requests: waiting %s", provider, wait.Round(time.Second))
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// acquireProviderSlot holds one of limit lock files for the provider, so at most
+// limit requests run at once across processes
+func acquireProviderSlot(ctx context.Context, provider string, limit int) (func(), error) {
+	if limit <= 0 {
+		return func() {}, nil
+	}
+	dir, err := pacerDir()
+	if err != nil {
+		return func() {}, nil
+	}
+
+	for waited := false; ; waited = true {
+		for i := 0; i < limit; i++ {
+			f, err := os.OpenFile(filepath.Join(dir, fmt.Sprintf("%s.slot%d.lock", provider, i)), os.O_RDWR|os.O_CREATE, 0644)
+			if err != nil {
+				return func() {}, nil
+			}
+			if lockFile(f, true) == nil {
+				return func() {
+					unlockFile(f)
+					f.Close()
+				}, nil
+			}
+			f.Close()
+		}
+		if !waited {
+			logInfo("⏳ Waiting for a free %s slot (%d in use)", provider, limit)
+		}
+		select {
+		case <-time.After(500 * time.Millisecond):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// recordUsage corrects the reserved estimate with the real token count and
+// blocks the provider until reset when its rate-limit headers say we're nearly out
+func recordUsage(provider string, estimate int, resp openai.ChatCompletionResponse) {
+	blockUntil := rateLimitResetFromHeaders(provider, resp.Header())
+	withPacerState(provider, func(u *pacerUsage) {
+		if actual := resp.Usage.TotalTokens; actual > 0 && actual != estimate {
+			u.Window = append(u.Window, pacerEntry{At: time.Now(), Tokens: actual - estimate})
+		}
+		if blockUntil.After(u.BlockedUntil) {
+			u.BlockedUntil = blockUntil
+		}
+	})
+}
+
+// blockProvider pauses every process's requests to a provider, e.g. after a 429
+func blockProvider(provider string, d time.Duration) {
+	until := time.Now().Add(d)
+	withPacerState(provider, func(u *pacerUsage) {
+		if until.After(u.BlockedUntil) {
+			u.BlockedUntil = until
+		}
+	})
+}
+
+// rateLimitResetFromHeaders returns when the provider's limits reset if the
+// response shows fewer than 5% of tokens or no requests remaining
+func rateLimitResetFromHeaders(provider string, h http.Header) time.Time {
+	if h == nil {
+		return time.Time{}
+	}
+	prefix, parseReset := "x-ratelimit-", func(v string) time.Time {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return time.Time{}
+		}
+		return time.Now().Add(d)
+	}
+	tokensRemaining, tokensLimit, tokensReset := "remaining-tokens", "limit-tokens", "reset-tokens"
+	requestsRemaining, requestsReset := "remaining-requests", "reset-requests"
+	if provider == "anthropic" {
+		prefix = "anthropic-ratelimit-"
+		parseReset = func(v string) time.Time {
+			t, _ := time.Parse(time.RFC3339, v)
+			return t
+		}
+		tokensRemaining, tokensLimit, tokensReset = "tokens-remaining", "tokens-limit", "tokens-reset"
+		requestsRemaining, requestsReset = "requests-remaining", "requests-reset"
+	}
+
+	var until time.Time
+	if limit, err := strconv.Atoi(h.Get(prefix + tokensLimit)); err == nil && limit > 0 {
+		if remaining, err := strconv.Atoi(h.Get(prefix + tokensRemaining)); err == nil && remaining < limit/20 {
+			until = parseReset(h.Get(prefix + tokensReset))
+		}
+	}
+	if remaining, err := strconv.Atoi(h.Get(prefix + requestsRemaining)); err == nil && remaining == 0 {
+		if t := parseReset(h.Get(prefix + requestsReset)); t.After(until) {
+			until = t
+		}
+	}
+	return until
+}
+
+// estimateTokens guesses a request's cost as prompt characters / 4 plus the output allowance
+func estimateTokens(req openai.ChatCompletionRequest) int {
+	chars := 0
+	for _, m := range req.Messages {
+		chars += len(m.Content)
+		for _, part := range m.MultiContent {
+			chars += len(part.Text)
+		}
+	}
+	output := max(req.MaxTokens, req.MaxCompletionTokens)
+	if output == 0 {
+		output = 1000
+	}
+	return chars/4 + output
+}
+
+// rateLimitBackoff reports whether err is a retryable rate limit (not an
+// exhausted quota) and how long to wait before retrying
+func rateLimitBackoff(err error, attempt int) (time.Duration, bool) {
+	backoff := time.Duration(10*(attempt+1)*(attempt+1)) * time.Second
+
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		if apiErr.HTTPStatusCode != http.StatusTooManyRequests || fmt.Sprint(apiErr.Code) == "insufficient_quota" {
+			return 0, false
+		}
+		return backoff, true
+	}
+	var anthErr *anthropicStatusError
+	if errors.As(err, &anthErr) {
+		// 529 is Anthropic's "overloaded", which clears like a rate limit
+		if anthErr.StatusCode != http.StatusTooManyRequests && anthErr.StatusCode != 529 {
+			return 0, false
+		}
+		if anthErr.RetryAfter > 0 {
+			return anthErr.RetryAfter, true
+		}
+		return backoff, true
+	}
+	return 0, false
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package cmd
+
+import "os"
+
+// Without flock, pacing is still enforced within a single process (pacerMu)
+// but not across concurrent megafone processes
+func lockFile(f *os.File, nonBlocking bool) error { return nil }
+
+func unlockFile(f *os.File) error { return nil }
@@ -0,0 +1,332 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	pingIndexNow = "indexnow"
+	pingWebSub   = "websub"
+	pingSitemap  = "sitemap"
+
+	// pingAttempts is how many times a ping is sent before giving up on it
+	pingAttempts = 3
+)
+
+var (
+	pingTargets []string
+	pingURLs    []string
+)
+
+// indexNowKeyRegex is the key format IndexNow accepts
+var indexNowKeyRegex = regexp.MustCompile(`^[a-zA-Z0-9-]{8,128}$`)
+
+// pingConfig is the ping block of megafone.yaml
+type pingConfig struct {
+	Targets  []string `yaml:"targets"` // default: the ones configured below
+	IndexNow struct {
+		Key      string `yaml:"key"`      // or INDEXNOW_KEY
+		Endpoint string `yaml:"endpoint"` // default https://api.indexnow.org/indexnow
+	} `yaml:"indexnow"`
+	WebSub struct {
+		Hub   string   `yaml:"hub"`   // e.g. https://pubsubhubbub.appspot.com/
+		Feeds []string `yaml:"feeds"` // feeds that list the posts (default /index.xml)
+	} `yaml:"websub"`
+	Sitemap []string `yaml:"sitemap"` // sitemap ping endpoints, called with ?sitemap=<url>
+}
+
+var pingCmd = &cobra.Command{
+	Use:   "ping [post...]",
+	Short: "Tell search engines and WebSub hubs about new or updated posts",
+	Long: `Run after the site is deployed to get new and updated posts indexed quickly.
+Each post's URL is built from the Hugo config's baseURL and permalinks, the
+same way the site builds it. Drafts and posts whose canonical_url points at
+another site are skipped.
+
+Targets (configured under ping: in megafone.yaml):
+  indexnow  submits the URLs to IndexNow (Bing, Yandex, Seznam, and others). The
+            key is INDEXNOW_KEY or ping.indexnow.key; its key file is written to
+            static/<key>.txt the first time and must be deployed before pinging.
+  websub    tells ping.websub.hub that the site's feeds changed
+  sitemap   calls each ping.sitemap endpoint with the sitemap URL
+
+Failed requests are retried up to 3 times. With comments.on_ping set, each
+post's comment thread is created too (see 'megafone comments seed'), and with
+archive.on_ping, each post is saved to the Wayback Machine ('megafone archive').
+
+Examples:
+  megafone ping content/posts/en/my-post.md
+  megafone ping $(git diff --name-only HEAD~1 -- content/posts) --targets indexnow
+  megafone ping --url https://example.com/about/ -s ~/hugo`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runPing(args); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(pingCmd)
+
+	pingCmd.Flags().StringSliceVar(&pingTargets, "targets", nil, "Targets to ping: indexnow, websub, sitemap (default: ping.targets, or every configured target)")
+	pingCmd.Flags().StringSliceVar(&pingURLs, "url", nil, "Ping this URL on the site as well")
+	pingCmd.Flags().StringVarP(&siteSource, "site-source", "s", "", "Path to local Hugo site repository (default: found from the post path)")
+	pingCmd.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "Show the URLs and targets without pinging")
+}
+
+func runPing(postPaths []string) error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	if len(postPaths) == 0 && len(pingURLs) == 0 {
+		return fmt.Errorf("nothing to ping: pass posts or --url")
+	}
+	first := ""
+	if len(postPaths) > 0 {
+		first = postPaths[0]
+	}
+	basePath, err := scheduleSitePath(first)
+	if err != nil {
+		return err
+	}
+	baseURL := siteBaseURL(basePath)
+	if baseURL == "" {
+		return fmt.Errorf("the Hugo config has no baseURL, so post URLs can't be built")
+	}
+
+	urls := append([]string(nil), pingURLs...)
+	var posts []string
+	for _, postPath := range postPaths {
+		data, err := os.ReadFile(postPath)
+		if err != nil {
+			return fmt.Errorf("failed to read post: %w", err)
+		}
+		content := string(data)
+		if frontMatterString(content, "draft") == "true" {
+			logInfo("⏭️  Skipping draft %s", postPath)
+			continue
+		}
+		if c := frontMatterString(content, "canonical_url"); c != "" && !onSite(c, baseURL) {
+			logInfo("⏭️  Skipping %s: its canonical URL is on another site", postPath)
+			continue
+		}
+		urls = append(urls, baseURL+postURL(basePath, postPath, content))
+		posts = append(posts, postPath)
+	}
+	for _, u := range urls {
+		if !onSite(u, baseURL) {
+			return fmt.Errorf("%s is not on the site (%s)", u, baseURL)
+		}
+	}
+	if len(urls) == 0 {
+		logInfo("Nothing to ping")
+		return nil
+	}
+
+	cfg := appConfig.Ping
+	targets := firstNonEmptyList(pingTargets, cfg.Targets)
+	if len(targets) == 0 {
+		if pingIndexNowKey() != "" {
+			targets = append(targets, pingIndexNow)
+		}
+		if cfg.WebSub.Hub != "" {
+			targets = append(targets, pingWebSub)
+		}
+		if len(cfg.Sitemap) > 0 {
+			targets = append(targets, pingSitemap)
+		}
+	}
+	if len(targets) == 0 {
+		return fmt.Errorf("no ping targets configured (set INDEXNOW_KEY, or ping.websub.hub or ping.sitemap in %s)", configPath)
+	}
+
+	for _, u := range urls {
+		logInfo("🔔 %s", u)
+	}
+	if dryRun {
+		logInfo("Dry run mode - would ping %s", strings.Join(targets, ", "))
+		return nil
+	}
+
+	ctx := context.Background()
+	failed := 0
+	for _, target := range targets {
+		var err error
+		switch target {
+		case pingIndexNow:
+			err = pingIndexNowURLs(ctx, basePath, baseURL, urls)
+		case pingWebSub:
+			err = pingWebSubHub(ctx, baseURL)
+		case pingSitemap:
+			err = pingSitemaps(ctx, baseURL)
+		default:
+			err = fmt.Errorf("unknown target %q (use indexnow, websub, or sitemap)", target)
+		}
+		if err != nil {
+			logError("Failed to ping %s: %v", target, err)
+			failed++
+			continue
+		}
+		logSuccess("✅ Pinged %s", target)
+	}
+
+	for _, postPath := range posts {
+		if appConfig.Comments.OnPing {
+			if err := seedComments(ctx, basePath, baseURL, postPath, appConfig.Comments.System); err != nil {
+				logError("Failed to seed comments for %s: %v", postPath, err)
+			}
+		}
+		if appConfig.Archive.OnPing {
+			if err := archivePost(ctx, basePath, baseURL, postPath); err != nil {
+				logError("Failed to archive %s: %v", postPath, err)
+			}
+		}
+	}
+	if failed == len(targets) {
+		return fmt.Errorf("every ping failed")
+	}
+	return nil
+}
+
+func pingIndexNowKey() string {
+	return firstNonEmpty(os.Getenv("INDEXNOW_KEY"), appConfig.Ping.IndexNow.Key)
+}
+
+// pingIndexNowURLs submits URLs to IndexNow, which shares them with every
+// participating search engine. IndexNow checks the key against a file on the
+// site, which is written to static/ if it's missing.
+func pingIndexNowURLs(ctx context.Context, basePath, baseURL string, urls []string) error {
+	key := pingIndexNowKey()
+	if !indexNowKeyRegex.MatchString(key) {
+		return fmt.Errorf("the IndexNow key must be 8-128 letters, digits, or dashes")
+	}
+	keyFile := filepath.Join(basePath, "static", key+".txt")
+	if _, err := os.Stat(keyFile); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(keyFile), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(keyFile, []byte(key), 0644); err != nil {
+			return fmt.Errorf("failed to write key file: %w", err)
+		}
+		recordSiteChange("created", keyFile, "", false)
+		return fmt.Errorf("wrote the key file %s; deploy the site, then ping again", mustRel(basePath, keyFile))
+	}
+
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"host":        u.Host,
+		"key":         key,
+		"keyLocation": baseURL + "/" + key + ".txt",
+		"urlList":     urls,
+	})
+	if err != nil {
+		return err
+	}
+	endpoint := firstNonEmpty(appConfig.Ping.IndexNow.Endpoint, "https://api.indexnow.org/indexnow")
+	return pingRequest(ctx, http.MethodPost, endpoint, "application/json; charset=utf-8", body)
+}
+
+// pingWebSubHub tells the hub that the site's feeds have new content, so it
+// pushes them to subscribers (feed readers, other sites)
+func pingWebSubHub(ctx context.Context, baseURL string) error {
+	cfg := appConfig.Ping.WebSub
+	if cfg.Hub == "" {
+		return fmt.Errorf("ping.websub.hub is not set in %s", configPath)
+	}
+	feeds := cfg.Feeds
+	if len(feeds) == 0 {
+		feeds = []string{"/index.xml"}
+	}
+	form := url.Values{"hub.mode": {"publish"}}
+	for _, feed := range feeds {
+		if strings.HasPrefix(feed, "/") {
+			feed = baseURL + feed
+		}
+		form.Add("hub.url", feed)
+	}
+	return pingRequest(ctx, http.MethodPost, cfg.Hub, "application/x-www-form-urlencoded", []byte(form.Encode()))
+}
+
+// pingSitemaps calls each sitemap ping endpoint with the site's sitemap
+func pingSitemaps(ctx context.Context, baseURL string) error {
+	if len(appConfig.Ping.Sitemap) == 0 {
+		return fmt.Errorf("ping.sitemap is not set in %s", configPath)
+	}
+	sitemap := baseURL + "/sitemap.xml"
+	var failed []string
+	for _, endpoint := range appConfig.Ping.Sitemap {
+		sep := "?"
+		if strings.Contains(endpoint, "?") {
+			sep = "&"
+		}
+		if err := pingRequest(ctx, http.MethodGet, endpoint+sep+"sitemap="+url.QueryEscape(sitemap), "", nil); err != nil {
+			logError("%s: %v", endpoint, err)
+			failed = append(failed, endpoint)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d sitemap pings failed", len(failed), len(appConfig.Ping.Sitemap))
+	}
+	return nil
+}
+
+// pingRequest sends a ping, retrying network errors, rate limits, and server
+// errors with a growing delay. Other client errors aren't retried.
+func pingRequest(ctx context.Context, method, endpoint, contentType string, body []byte) error {
+	var lastErr error
+	for attempt := 0; attempt < pingAttempts; attempt++ {
+		if attempt > 0 {
+			wait := time.Duration(attempt*attempt) * 2 * time.Second
+			logVerbose("Retrying %s in %s: %v", endpoint, wait, lastErr)
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		resp, err := socialClient.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		resp.Body.Close()
+
+		switch {
+		case resp.StatusCode >= 200 && resp.StatusCode < 300:
+			return nil
+		case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+			lastErr = fmt.Errorf("%s", resp.Status)
+		case resp.StatusCode == http.StatusForbidden:
+			// IndexNow's answer when the key file can't be found or doesn't match
+			return classify(ErrAuth, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(data))))
+		default:
+			return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(data)))
+		}
+	}
+	return fmt.Errorf("gave up after %d attempts: %w", pingAttempts, lastErr)
+}
@@ -0,0 +1,522 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Transcribers for podcast audio
+const (
+	transcriberAPI   = "api"   // the OpenAI transcription API
+	transcriberLocal = "local" // the whisper CLI on this machine
+)
+
+var (
+	podcastEpisodeMatch string
+	transcribeOnly      bool
+	transcriber         string
+	whisperModel        string
+)
+
+// podcastMaxTranscriptChars keeps about two hours of speech, which fits the
+// prompt with room for the template and show notes
+const podcastMaxTranscriptChars = 120000
+
+// transcriptBlockSeconds is how much speech each timestamped line of the
+// prompt's transcript covers
+const transcriptBlockSeconds = 30
+
+// audioExtensions are the enclosure types treated as podcast audio
+var audioExtensions = map[string]bool{
+	".mp3": true, ".m4a": true, ".aac": true, ".ogg": true, ".oga": true,
+	".opus": true, ".wav": true, ".flac": true, ".mp4": true, ".webm": true,
+}
+
+// podcastEpisode is an episode found from a feed, an episode page, or a
+// direct link to its audio
+type podcastEpisode struct {
+	Title     string
+	Show      string
+	Author    string
+	Published string
+	Link      string
+	AudioURL  string
+	ImageURL  string
+	Notes     string
+}
+
+// transcriptSegment is a stretch of speech and when it starts, in seconds
+type transcriptSegment struct {
+	Start float64 `json:"start"`
+	Text  string  `json:"text"`
+}
+
+// checkTranscriber rejects an unknown --transcriber before any work is done
+func checkTranscriber() error {
+	switch transcriber {
+	case transcriberAPI, transcriberLocal:
+		return nil
+	default:
+		return fmt.Errorf("invalid --transcriber value %q (use api or local)", transcriber)
+	}
+}
+
+// isAudioURL reports whether a URL points straight at an audio file
+func isAudioURL(input string) bool {
+	u, err := url.Parse(input)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	return audioExtensions[strings.ToLower(path.Ext(u.Path))]
+}
+
+// resolvePodcastEpisode finds the episode a podcast topic refers to. The topic
+// can be a direct audio link, the show's RSS feed (the latest episode, or the
+// one --episode names), or an episode page, whose audio is found through the
+// feed it links to or the page's own audio tags.
+func resolvePodcastEpisode(topicURL string) (*podcastEpisode, error) {
+	if isAudioURL(topicURL) {
+		u, _ := url.Parse(topicURL)
+		return &podcastEpisode{Title: strings.TrimSuffix(path.Base(u.Path), path.Ext(u.Path)), Link: topicURL, AudioURL: topicURL}, nil
+	}
+
+	resp, err := http.Get(topicURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch podcast page: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP error fetching podcast page: %s", resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read podcast page: %w", err)
+	}
+
+	// The topic is the feed itself
+	if episodes, err := parsePodcastFeed(body); err == nil && len(episodes) > 0 {
+		return pickFeedEpisode(episodes, podcastEpisodeMatch)
+	}
+
+	html := string(body)
+	meta := extractPageMetadata(html, topicURL)
+	if feedURL := podcastFeedLink(html, topicURL); feedURL != "" {
+		logVerbose("Looking for the episode in the show's feed %s", feedURL)
+		if episodes, err := fetchPodcastFeed(feedURL); err != nil {
+			logVerbose("Feed unavailable: %v", err)
+		} else if ep := matchFeedEpisode(episodes, topicURL, meta.Title); ep != nil {
+			return ep, nil
+		}
+	}
+
+	audio := firstNonEmpty(
+		extractMetaContent(html, "property", "og:audio"),
+		extractMetaContent(html, "property", "og:audio:url"),
+		extractMetaContent(html, "name", "twitter:player:stream"),
+		pageAudioSource(html),
+	)
+	if audio == "" {
+		return nil, fmt.Errorf("no episode audio found at %s (pass the show's feed or the audio file's URL instead)", topicURL)
+	}
+	ep := &podcastEpisode{
+		Title:     meta.Title,
+		Show:      meta.SiteName,
+		Author:    meta.Author,
+		Published: meta.Published,
+		Link:      topicURL,
+		AudioURL:  makeAbsoluteURL(audio, topicURL),
+		Notes:     meta.Description,
+	}
+	if len(meta.Images) > 0 {
+		ep.ImageURL = meta.Images[0].URL
+	}
+	return ep, nil
+}
+
+func fetchPodcastFeed(feedURL string) ([]podcastEpisode, error) {
+	resp, err := http.Get(feedURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch feed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP error fetching feed: %s", resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read feed: %w", err)
+	}
+	return parsePodcastFeed(body)
+}
+
+// parsePodcastFeed reads the episodes of an RSS podcast feed, newest first as
+// feeds list them. Items without an audio enclosure are skipped.
+func parsePodcastFeed(data []byte) ([]podcastEpisode, error) {
+	type itunesImage struct {
+		Href string `xml:"href,attr"`
+	}
+	var doc struct {
+		XMLName xml.Name `xml:"rss"`
+		Channel struct {
+			Title  string      `xml:"title"`
+			Author string      `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd author"`
+			Image  itunesImage `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
+			Items  []struct {
+				Title       string      `xml:"title"`
+				Link        string      `xml:"link"`
+				GUID        string      `xml:"guid"`
+				PubDate     string      `xml:"pubDate"`
+				Description string      `xml:"description"`
+				Encoded     string      `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+				Image       itunesImage `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
+				Enclosure   struct {
+					URL  string `xml:"url,attr"`
+					Type string `xml:"type,attr"`
+				} `xml:"enclosure"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("not an RSS feed: %w", err)
+	}
+
+	var episodes []podcastEpisode
+	for _, item := range doc.Channel.Items {
+		enc := item.Enclosure
+		if enc.URL == "" || !(strings.HasPrefix(enc.Type, "audio/") || isAudioURL(enc.URL)) {
+			continue
+		}
+		episodes = append(episodes, podcastEpisode{
+			Title:     strings.TrimSpace(item.Title),
+			Show:      strings.TrimSpace(doc.Channel.Title),
+			Author:    strings.TrimSpace(doc.Channel.Author),
+			Published: item.PubDate,
+			Link:      firstNonEmpty(strings.TrimSpace(item.Link), enc.URL),
+			AudioURL:  enc.URL,
+			ImageURL:  firstNonEmpty(item.Image.Href, doc.Channel.Image.Href),
+			Notes:     htmlToText(firstNonEmpty(item.Encoded, item.Description)),
+		})
+	}
+	return episodes, nil
+}
+
+// pickFeedEpisode returns the episode whose title contains match, or the
+// latest when match is empty
+func pickFeedEpisode(episodes []podcastEpisode, match string) (*podcastEpisode, error) {
+	if match == "" {
+		return &episodes[0], nil
+	}
+	for i := range episodes {
+		if strings.Contains(strings.ToLower(episodes[i].Title), strings.ToLower(match)) {
+			return &episodes[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no episode in the feed has %q in its title", match)
+}
+
+// matchFeedEpisode finds an episode page's entry in its show's feed, by link
+// or, failing that, by title
+func matchFeedEpisode(episodes []podcastEpisode, pageURL, title string) *podcastEpisode {
+	norm := func(s string) string {
+		return strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(s, "https://"), "http://"), "/")
+	}
+	for i := range episodes {
+		if norm(episodes[i].Link) == norm(pageURL) {
+			return &episodes[i]
+		}
+	}
+	title = strings.ToLower(strings.TrimSpace(title))
+	for i := range episodes {
+		if t := strings.ToLower(episodes[i].Title); t != "" && title != "" && (strings.Contains(title, t) || strings.Contains(t, title)) {
+			return &episodes[i]
+		}
+	}
+	return nil
+}
+
+var (
+	feedLinkRegex    = regexp.MustCompile(`(?i)<link[^>]+type=["']application/rss\+xml["'][^>]*>`)
+	hrefRegex        = regexp.MustCompile(`(?i)href=["']([^"']+)["']`)
+	audioSourceRegex = regexp.MustCompile(`(?i)<(?:audio|source)[^>]+src=["']([^"']+)["']`)
+)
+
+// podcastFeedLink returns the RSS feed an episode page advertises
+func podcastFeedLink(html, pageURL string) string {
+	if tag := feedLinkRegex.FindString(html); tag != "" {
+		if m := hrefRegex.FindStringSubmatch(tag); len(m) > 1 {
+			return makeAbsoluteURL(m[1], pageURL)
+		}
+	}
+	return ""
+}
+
+// pageAudioSource returns the first <audio> or <source> with an audio file
+func pageAudioSource(html string) string {
+	for _, m := range audioSourceRegex.FindAllStringSubmatch(html, -1) {
+		if isAudioURL(m[1]) || strings.HasPrefix(m[1], "/") {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// downloadPodcastAudio saves an episode's audio into dir and returns its path
+func downloadPodcastAudio(ctx context.Context, audioURL, dir string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, audioURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to download episode audio: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP error downloading episode audio: %s", resp.Status)
+	}
+
+	// Enclosure URLs often go through tracking redirects; the final URL or the
+	// content type says what the file is
+	ext := strings.ToLower(path.Ext(resp.Request.URL.Path))
+	if !audioExtensions[ext] {
+		ext = ".mp3"
+		if exts, _ := mime.ExtensionsByType(resp.Header.Get("Content-Type")); len(exts) > 0 {
+			ext = exts[0]
+		}
+	}
+	f, err := os.Create(filepath.Join(dir, "episode"+ext))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	n, err := io.Copy(f, resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to download episode audio: %w", err)
+	}
+	logInfo("🎧 Downloaded %d MB of audio", n>>20)
+	return f.Name(), nil
+}
+
+// transcribePodcast transcribes an episode's audio with timestamps, with
+// --transcriber. Transcripts are cached by the audio's hash, like voice memos.
+func transcribePodcast(ctx context.Context, apiKey, audioPath, prompt string) ([]transcriptSegment, error) {
+	f, err := os.Open(audioPath)
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+	cachePath := ""
+	if cacheDir, err := os.UserCacheDir(); err == nil {
+		cachePath = filepath.Join(cacheDir, "megafone", "transcripts", hex.EncodeToString(h.Sum(nil)[:16])+".segments.json")
+		if data, err := os.ReadFile(cachePath); err == nil {
+			var segments []transcriptSegment
+			if json.Unmarshal(data, &segments) == nil {
+				logInfo("🎙️  Using the cached transcript of this episode")
+				return segments, nil
+			}
+		}
+	}
+
+	logInfo("🎙️  Transcribing %d MB of audio with the %s transcriber...", size>>20, transcriber)
+	var segments []transcriptSegment
+	if transcriber == transcriberLocal {
+		segments, err = transcribeLocally(ctx, audioPath)
+	} else {
+		segments, err = transcribeWithAPI(ctx, apiKey, audioPath, size, prompt)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("no speech found in the episode")
+	}
+
+	if cachePath != "" {
+		if data, err := json.Marshal(segments); err == nil && os.MkdirAll(filepath.Dir(cachePath), 0755) == nil {
+			os.WriteFile(cachePath, data, 0644)
+		}
+	}
+	return segments, nil
+}
+
+// transcribeWithAPI sends the audio to the transcription API, split into
+// 20-minute parts when it is too large, and shifts each part's timestamps by
+// where the part starts
+func transcribeWithAPI(ctx context.Context, apiKey, audioPath string, size int64, prompt string) ([]transcriptSegment, error) {
+	parts := []string{audioPath}
+	if size > whisperMaxBytes || !whisperFormats[strings.ToLower(filepath.Ext(audioPath))] {
+		tmpDir, err := os.MkdirTemp("", "megafone-podcast-parts")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(tmpDir)
+		if parts, err = splitVoiceMemo(audioPath, tmpDir); err != nil {
+			return nil, err
+		}
+	}
+
+	client := openai.NewClient(apiKey)
+	var segments []transcriptSegment
+	for i, part := range parts {
+		if len(parts) > 1 {
+			logInfo("🎙️  Part %d of %d...", i+1, len(parts))
+		}
+		resp, err := client.CreateTranscription(ctx, openai.AudioRequest{
+			Model:    openai.Whisper1,
+			FilePath: part,
+			Prompt:   prompt,
+			Format:   openai.AudioResponseFormatVerboseJSON,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("transcription API error (part %d of %d): %w", i+1, len(parts), err)
+		}
+		offset := float64(i * 1200)
+		for _, s := range resp.Segments {
+			segments = append(segments, transcriptSegment{Start: offset + s.Start, Text: strings.TrimSpace(s.Text)})
+		}
+	}
+	return segments, nil
+}
+
+// transcribeLocally runs the whisper CLI (openai-whisper) on the audio. It
+// reads any format ffmpeg does, so nothing is split or converted first.
+func transcribeLocally(ctx context.Context, audioPath string) ([]transcriptSegment, error) {
+	whisper := os.Getenv("WHISPER_PATH")
+	if whisper == "" {
+		var err error
+		if whisper, err = exec.LookPath("whisper"); err != nil {
+			return nil, fmt.Errorf("whisper isn't in PATH (install openai-whisper, set WHISPER_PATH, or use --transcriber api)")
+		}
+	}
+	outDir, err := os.MkdirTemp("", "megafone-whisper")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(outDir)
+
+	c := exec.CommandContext(ctx, whisper, audioPath, "--model", whisperModel, "--output_format", "json", "--output_dir", outDir)
+	if out, err := c.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("whisper failed: %v: %s", err, lastChars(strings.TrimSpace(string(out)), 500))
+	}
+	data, err := os.ReadFile(filepath.Join(outDir, strings.TrimSuffix(filepath.Base(audioPath), filepath.Ext(audioPath))+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("whisper wrote no transcript: %w", err)
+	}
+	var result struct {
+		Segments []transcriptSegment `json:"segments"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("invalid whisper output: %w", err)
+	}
+	for i := range result.Segments {
+		result.Segments[i].Text = strings.TrimSpace(result.Segments[i].Text)
+	}
+	return result.Segments, nil
+}
+
+// formatTranscript renders segments as timestamped lines, each covering about
+// blockSeconds of speech
+func formatTranscript(segments []transcriptSegment, blockSeconds float64) string {
+	var b strings.Builder
+	var line []string
+	lineStart := 0.0
+	flush := func() {
+		if len(line) > 0 {
+			fmt.Fprintf(&b, "[%s] %s\n", formatTimestamp(lineStart), strings.Join(line, " "))
+			line = nil
+		}
+	}
+	for _, s := range segments {
+		if s.Text == "" {
+			continue
+		}
+		if len(line) > 0 && s.Start-lineStart >= blockSeconds {
+			flush()
+		}
+		if len(line) == 0 {
+			lineStart = s.Start
+		}
+		line = append(line, s.Text)
+	}
+	flush()
+	return b.String()
+}
+
+// formatTimestamp renders seconds as m:ss, or h:mm:ss past the hour
+func formatTimestamp(seconds float64) string {
+	s := int(seconds)
+	if s >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+	}
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
+
+// podcastSource renders the episode for the prompt: its details, show notes,
+// and the timestamped transcript
+func podcastSource(ep *podcastEpisode, transcript string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Podcast: %s\nEpisode: %s\n", firstNonEmpty(ep.Show, "(unknown show)"), ep.Title)
+	if ep.Author != "" {
+		fmt.Fprintf(&b, "Hosts: %s\n", ep.Author)
+	}
+	if ep.Published != "" {
+		fmt.Fprintf(&b, "Published: %s\n", ep.Published)
+	}
+	fmt.Fprintf(&b, "Episode link: %s\n", ep.Link)
+	if ep.Notes != "" {
+		fmt.Fprintf(&b, "\n## Show Notes\n%s\n", trimToLength(ep.Notes, 4000))
+	}
+	fmt.Fprintf(&b, "\n## Transcript\nTimestamps are [minutes:seconds] (or [hours:minutes:seconds]) from the start of the episode.\n\n%s", transcript)
+	return b.String()
+}
+
+// loadPodcastEpisode finds, downloads, and transcribes the episode at topicURL,
+// returning it and its timestamped transcript
+func loadPodcastEpisode(ctx context.Context, apiKey, topicURL string) (*podcastEpisode, string, error) {
+	logInfo("🎧 Finding the podcast episode...")
+	ep, err := resolvePodcastEpisode(topicURL)
+	if err != nil {
+		return nil, "", classify(ErrSource, err)
+	}
+	logInfo("🎧 %s: %s", firstNonEmpty(ep.Show, "Episode"), ep.Title)
+
+	tmpDir, err := os.MkdirTemp("", "megafone-podcast")
+	if err != nil {
+		return nil, "", err
+	}
+	defer os.RemoveAll(tmpDir)
+	audioPath, err := downloadPodcastAudio(ctx, ep.AudioURL, tmpDir)
+	if err != nil {
+		return nil, "", classify(ErrSource, err)
+	}
+
+	// The show and episode titles help with the spelling of names and jargon
+	segments, err := transcribePodcast(ctx, apiKey, audioPath, strings.TrimSpace(ep.Show+": "+ep.Title))
+	if err != nil {
+		return nil, "", classify(ErrGeneration, err)
+	}
+	last := segments[len(segments)-1].Start
+	logInfo("📝 Transcribed %d segments (%s)", len(segments), formatTimestamp(last))
+	return ep, formatTranscript(segments, transcriptBlockSeconds), nil
+}
@@ -0,0 +1,258 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// sitePost is a post in the Hugo content tree, parsed from its front matter
+type sitePost struct {
+	Path        string
+	Slug        string
+	Title       string
+	URL         string // explicit url front matter, if any
+	Description string
+	Date        string
+	Tags        []string
+	Draft       bool
+	Hero        string
+	AIGenerated bool
+	Body        string
+}
+
+// postsCacheVersion changes whenever sitePost or its parsing does, invalidating old caches
+const postsCacheVersion = 1
+
+// postsCache holds parsed posts keyed by their path relative to the site, so
+// unchanged files (same size and modification time) aren't read again
+type postsCache struct {
+	Version int                       `json:"version"`
+	Posts   map[string]cachedSitePost `json:"posts"`
+}
+
+type cachedSitePost struct {
+	Size    int64    `json:"size"`
+	ModTime int64    `json:"mod_time"`
+	Post    sitePost `json:"post"`
+}
+
+func postsCachePath(basePath string) string {
+	return workspacePath(basePath, "cache", "posts.json")
+}
+
+// loadSitePosts reads every markdown file under content/posts. Parsed posts
+// are cached in .megafone/cache, so only files changed since the last call are read.
+func loadSitePosts(basePath string) ([]sitePost, error) {
+	root := filepath.Join(basePath, "content", "posts")
+
+	var cache postsCache
+	if data, err := os.ReadFile(postsCachePath(basePath)); err == nil {
+		json.Unmarshal(data, &cache)
+	}
+	if cache.Version != postsCacheVersion || cache.Posts == nil {
+		cache = postsCache{Version: postsCacheVersion, Posts: make(map[string]cachedSitePost)}
+	}
+	seen := make(map[string]bool)
+	changed := false
+
+	var posts []sitePost
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".md" {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		key := filepath.ToSlash(mustRel(basePath, path))
+		seen[key] = true
+		if cached, ok := cache.Posts[key]; ok && cached.Size == info.Size() && cached.ModTime == info.ModTime().UnixNano() {
+			cached.Post.Path = path
+			posts = append(posts, cached.Post)
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		post := parseSitePost(path, string(data))
+		posts = append(posts, post)
+		cache.Posts[key] = cachedSitePost{Size: info.Size(), ModTime: info.ModTime().UnixNano(), Post: post}
+		changed = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for key := range cache.Posts {
+		if !seen[key] {
+			delete(cache.Posts, key)
+			changed = true
+		}
+	}
+	// A read-only command only refreshes a cache that's already kept out of git
+	if changed && (!siteReadOnly || workspaceIgnoresCache(basePath)) {
+		if err := savePostsCache(basePath, cache); err != nil {
+			logError("Failed to save the posts cache: %v", err)
+		}
+	}
+
+	// Newest first
+	sort.SliceStable(posts, func(i, j int) bool {
+		return posts[i].Date > posts[j].Date
+	})
+	return posts, nil
+}
+
+func savePostsCache(basePath string, cache postsCache) error {
+	path := postsCachePath(basePath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	// The cache holds every post's body; keep it out of the site's history
+	if err := ensureWorkspaceGitignore(basePath); err != nil {
+		return err
+	}
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+func parseSitePost(path, content string) sitePost {
+	fm := frontMatterBlock(content)
+	body := content
+	if fm != "" {
+		body = strings.TrimLeft(content[len(fm)+len("\n---"):], "\n")
+	}
+
+	slug := frontMatterString(content, "slug")
+	if slug == "" {
+		// Bundles take their slug from the directory
+		slug = strings.TrimSuffix(filepath.Base(path), ".md")
+		if slug == "index" {
+			slug = filepath.Base(filepath.Dir(path))
+		}
+	}
+
+	return sitePost{
+		Path:        path,
+		Slug:        slug,
+		Title:       frontMatterString(content, "title"),
+		URL:         frontMatterString(content, "url"),
+		Description: frontMatterString(content, "description"),
+		Date:        frontMatterString(content, "date"),
+		Tags:        frontMatterList(content, "tags"),
+		Draft:       frontMatterString(content, "draft") == "true",
+		Hero:        frontMatterString(content, "hero"),
+		AIGenerated: frontMatterString(content, "ai_generated") == "true",
+		Body:        body,
+	}
+}
+
+// summaryText is the short text used to represent a post for embeddings
+func (p sitePost) summaryText() string {
+	text := p.Title
+	if p.Description != "" {
+		text += ". " + p.Description
+	}
+	if len(p.Tags) > 0 {
+		text += " Tags: " + strings.Join(p.Tags, ", ")
+	}
+	return text
+}
+
+// WordCount counts the words in the body, ignoring shortcodes
+func (p sitePost) WordCount() int {
+	return len(strings.Fields(anyShortcodeRegex.ReplaceAllString(p.Body, "")))
+}
+
+// Time parses the post's date, returning the zero time if it has none
+func (p sitePost) Time() time.Time {
+	t, _ := parsePostDate(p.Date)
+	return t
+}
+
+// parsePostDate accepts the date formats Hugo front matter commonly uses
+func parsePostDate(s string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// postFilter selects posts from the site index. Zero values match everything.
+type postFilter struct {
+	Tags   []string
+	Since  time.Time
+	Until  time.Time
+	Drafts bool
+}
+
+func (f postFilter) match(p sitePost) bool {
+	if p.Draft && !f.Drafts {
+		return false
+	}
+	t := p.Time()
+	if !f.Since.IsZero() && t.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !t.Before(f.Until) {
+		return false
+	}
+	if len(f.Tags) == 0 {
+		return true
+	}
+	for _, want := range f.Tags {
+		for _, tag := range p.Tags {
+			if strings.EqualFold(strings.TrimSpace(want), tag) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func filterPosts(posts []sitePost, f postFilter) []sitePost {
+	var matched []sitePost
+	for _, p := range posts {
+		if f.match(p) {
+			matched = append(matched, p)
+		}
+	}
+	return matched
+}
+
+// parsePeriod reads a year, month, or day (2024, 2024-01, 2024-01-15) as the
+// half-open range it covers
+func parsePeriod(s string) (start, end time.Time, ok bool) {
+	for _, p := range []struct {
+		layout string
+		next   func(time.Time) time.Time
+	}{
+		{"2006-01-02", func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }},
+		{"2006-01", func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }},
+		{"2006", func(t time.Time) time.Time { return t.AddDate(1, 0, 0) }},
+	} {
+		if t, err := time.Parse(p.layout, s); err == nil {
+			return t, p.next(t), true
+		}
+	}
+	return time.Time{}, time.Time{}, false
+}
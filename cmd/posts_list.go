@@ -0,0 +1,285 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	postsTags   []string
+	postsSince  string
+	postsUntil  string
+	postsDrafts bool
+	postsFormat string
+
+	postsListLimit   int
+	postsSearchLimit int
+)
+
+var postsCmd = &cobra.Command{
+	Use:   "posts",
+	Short: "Browse the posts in the Hugo site",
+}
+
+var postsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List posts, newest first",
+	Long: `Lists the posts under content/posts with their date, title, and tags.
+Drafts are hidden unless --drafts is set.
+
+Examples:
+  megafone posts list -s ~/hugo --tag go --since 2024-01
+  megafone posts list -s ~/hugo --since 2024 --until 2024-06 --format json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runPostsList(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+var postsSearchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Search post titles, descriptions, tags, and bodies",
+	Long: `Finds posts containing every word of the query, ranked by where the words
+appear: title matches count most, then tags, description, and body.
+
+Examples:
+  megafone posts search "raft" -s ~/hugo
+  megafone posts search "leader election" -s ~/hugo --tag distributed-systems --format json`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runPostsSearch(strings.Join(args, " ")); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(postsCmd)
+	postsCmd.AddCommand(postsListCmd)
+	postsCmd.AddCommand(postsSearchCmd)
+
+	for _, c := range []*cobra.Command{postsListCmd, postsSearchCmd} {
+		c.Flags().StringVarP(&siteSource, "site-source", "s", "", "Path to local Hugo site repository")
+		c.Flags().StringSliceVarP(&postsTags, "tag", "T", nil, "Only posts with any of these tags (repeatable)")
+		c.Flags().StringVar(&postsSince, "since", "", "Only posts dated in or after this year, month, or day (2024, 2024-01, 2024-01-15)")
+		c.Flags().StringVar(&postsUntil, "until", "", "Only posts dated in or before this year, month, or day")
+		c.Flags().BoolVar(&postsDrafts, "drafts", false, "Include drafts")
+		c.Flags().StringVar(&postsFormat, "format", "table", "Output format: table or json")
+	}
+	postsListCmd.Flags().IntVarP(&postsListLimit, "limit", "n", 0, "Show at most this many posts (0 for all)")
+	postsSearchCmd.Flags().IntVarP(&postsSearchLimit, "limit", "n", 20, "Show at most this many results (0 for all)")
+}
+
+// postSummary is the JSON form of a post in list and search output
+type postSummary struct {
+	Path        string   `json:"path"`
+	Slug        string   `json:"slug"`
+	Title       string   `json:"title"`
+	Description string   `json:"description,omitempty"`
+	Date        string   `json:"date"`
+	Tags        []string `json:"tags"`
+	Draft       bool     `json:"draft,omitempty"`
+	Hero        string   `json:"hero,omitempty"`
+	Words       int      `json:"words"`
+	Score       int      `json:"score,omitempty"`
+	Snippet     string   `json:"snippet,omitempty"`
+}
+
+// loadFilteredPosts resolves the site and applies the shared list/search flags
+func loadFilteredPosts() (string, []sitePost, error) {
+	if err := initLogger(); err != nil {
+		return "", nil, fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	basePath, err := inspectSitePath()
+	if err != nil {
+		return "", nil, err
+	}
+	filter := postFilter{Tags: postsTags, Drafts: postsDrafts}
+	if postsSince != "" {
+		start, _, ok := parsePeriod(postsSince)
+		if !ok {
+			return "", nil, fmt.Errorf("invalid --since value %q (use 2024, 2024-01, or 2024-01-15)", postsSince)
+		}
+		filter.Since = start
+	}
+	if postsUntil != "" {
+		_, end, ok := parsePeriod(postsUntil)
+		if !ok {
+			return "", nil, fmt.Errorf("invalid --until value %q (use 2024, 2024-01, or 2024-01-15)", postsUntil)
+		}
+		filter.Until = end
+	}
+
+	posts, err := loadSitePosts(basePath)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read posts: %w", err)
+	}
+	return basePath, filterPosts(posts, filter), nil
+}
+
+func runPostsList() error {
+	basePath, posts, err := loadFilteredPosts()
+	if err != nil {
+		return err
+	}
+	if postsListLimit > 0 && len(posts) > postsListLimit {
+		posts = posts[:postsListLimit]
+	}
+
+	summaries := make([]postSummary, len(posts))
+	for i, p := range posts {
+		summaries[i] = summarizePost(p)
+	}
+	return printPostSummaries(basePath, summaries, false)
+}
+
+func runPostsSearch(query string) error {
+	if strings.TrimSpace(query) == "" {
+		return fmt.Errorf("search query is empty")
+	}
+	basePath, posts, err := loadFilteredPosts()
+	if err != nil {
+		return err
+	}
+
+	var results []postSummary
+	for _, p := range posts {
+		if score, snippet := scorePost(p, query); score > 0 {
+			s := summarizePost(p)
+			s.Score, s.Snippet = score, snippet
+			results = append(results, s)
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	if postsSearchLimit > 0 && len(results) > postsSearchLimit {
+		results = results[:postsSearchLimit]
+	}
+	return printPostSummaries(basePath, results, true)
+}
+
+func summarizePost(p sitePost) postSummary {
+	return postSummary{
+		Path:        p.Path,
+		Slug:        p.Slug,
+		Title:       p.Title,
+		Description: p.Description,
+		Date:        p.Date,
+		Tags:        p.Tags,
+		Draft:       p.Draft,
+		Hero:        p.Hero,
+		Words:       p.WordCount(),
+	}
+}
+
+// scorePost ranks a post against every word of query, returning 0 unless all
+// words appear somewhere, along with a body snippet around the first match
+func scorePost(p sitePost, query string) (int, string) {
+	title := strings.ToLower(p.Title)
+	desc := strings.ToLower(p.Description)
+	tags := strings.ToLower(strings.Join(p.Tags, " "))
+	body := strings.ToLower(p.Body)
+
+	score := 0
+	for _, word := range strings.Fields(strings.ToLower(query)) {
+		wordScore := 0
+		if strings.Contains(title, word) {
+			wordScore += 10
+		}
+		if strings.Contains(tags, word) {
+			wordScore += 5
+		}
+		if strings.Contains(desc, word) {
+			wordScore += 3
+		}
+		wordScore += min(strings.Count(body, word), 10)
+		if wordScore == 0 {
+			return 0, ""
+		}
+		score += wordScore
+	}
+
+	phrase := strings.ToLower(strings.TrimSpace(query))
+	if strings.Contains(title, phrase) || strings.Contains(body, phrase) {
+		score += 5
+	}
+
+	snippet := ""
+	first := strings.Fields(phrase)[0]
+	if i := strings.Index(body, phrase); i >= 0 {
+		snippet = snippetAround(p.Body, i, len(phrase))
+	} else if i := strings.Index(body, first); i >= 0 {
+		snippet = snippetAround(p.Body, i, len(first))
+	}
+	return score, snippet
+}
+
+// snippetAround returns about 100 characters of text on one line around a match
+func snippetAround(text string, at, length int) string {
+	start := max(at-50, 0)
+	end := min(at+length+50, len(text))
+	snippet := strings.Join(strings.Fields(text[start:end]), " ")
+	if start > 0 {
+		snippet = "..." + snippet
+	}
+	if end < len(text) {
+		snippet += "..."
+	}
+	return snippet
+}
+
+func printPostSummaries(basePath string, posts []postSummary, search bool) error {
+	if postsFormat == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if posts == nil {
+			posts = []postSummary{}
+		}
+		return enc.Encode(posts)
+	}
+	if postsFormat != "table" {
+		return fmt.Errorf("invalid --format value %q (use table or json)", postsFormat)
+	}
+
+	if len(posts) == 0 {
+		fmt.Println("No posts found")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if !search {
+		fmt.Fprintln(w, "DATE\tTITLE\tTAGS\tWORDS\tPATH")
+	}
+	for _, p := range posts {
+		date := p.Date
+		if len(date) > 10 {
+			date = date[:10]
+		}
+		title := p.Title
+		if p.Draft {
+			title += " (draft)"
+		}
+		rel, err := filepath.Rel(basePath, p.Path)
+		if err != nil {
+			rel = p.Path
+		}
+		if search {
+			// Snippets are too long for columns
+			fmt.Printf("%s  %s  (score %d)\n    %s\n", date, title, p.Score, rel)
+			if p.Snippet != "" {
+				fmt.Printf("    %s\n", p.Snippet)
+			}
+		} else {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", date, title, strings.Join(p.Tags, ", "), p.Words, rel)
+		}
+	}
+	w.Flush()
+	fmt.Printf("\n%d posts\n", len(posts))
+	return nil
+}
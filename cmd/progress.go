@@ -0,0 +1,255 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// pipelineStages are the steps of a generate run, in order
+var pipelineStages = []string{"fetch", "research", "generate", "image", "variants", "write"}
+
+var noProgress bool
+
+// progress is the state of the current run's progress display. Without a
+// terminal it only tracks timings and tokens and reports them as log lines.
+var progress struct {
+	mu           sync.Mutex
+	program      *tea.Program
+	exited       chan struct{}
+	start        time.Time
+	stage        string
+	stageStart   time.Time
+	tokens       atomic.Int64
+	started      bool
+	finishedOnce sync.Once
+}
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&noProgress, "no-progress", false, "Disable the live progress display and print plain logs")
+}
+
+func stdoutIsTerminal() bool {
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// startProgress begins tracking pipeline stages. The live display is only used
+// on a terminal, and not when megafone needs to prompt the user.
+func startProgress(live bool) {
+	progress.mu.Lock()
+	defer progress.mu.Unlock()
+	progress.start = time.Now()
+	progress.started = true
+	if !live || noProgress || !stdoutIsTerminal() {
+		return
+	}
+
+	p := tea.NewProgram(newProgressModel(), tea.WithInput(nil), tea.WithOutput(os.Stdout))
+	progress.program = p
+	progress.exited = make(chan struct{})
+	setLogConsole(progressLogWriter{p})
+
+	go func() {
+		final, err := p.Run()
+		setLogConsole(os.Stdout)
+		close(progress.exited)
+		if m, ok := final.(progressModel); err == nil && ok && !m.finished {
+			// The display quit on Ctrl+C before the run finished
+			os.Exit(130)
+		}
+	}()
+}
+
+// progressStage marks the start of a pipeline stage, finishing the previous one
+func progressStage(name string) {
+	progress.mu.Lock()
+	defer progress.mu.Unlock()
+	if !progress.started {
+		return
+	}
+	now := time.Now()
+	if progress.program != nil {
+		progress.program.Send(progressStageMsg{name: name, at: now})
+	} else if progress.stage != "" {
+		logInfo("⏱  %s took %s", progress.stage, now.Sub(progress.stageStart).Round(100*time.Millisecond))
+	}
+	progress.stage = name
+	progress.stageStart = now
+}
+
+// progressAddTokens adds an API call's token usage to the running total
+func progressAddTokens(n int) {
+	total := progress.tokens.Add(int64(n))
+	progress.mu.Lock()
+	defer progress.mu.Unlock()
+	if progress.program != nil {
+		progress.program.Send(progressTokensMsg(total))
+	}
+}
+
+// progressLive reports whether the live display is on screen
+func progressLive() bool {
+	progress.mu.Lock()
+	defer progress.mu.Unlock()
+	return progress.program != nil
+}
+
+// progressStreamText adds a piece of a streamed reply to the display's tail line
+func progressStreamText(chunk string) {
+	progress.mu.Lock()
+	defer progress.mu.Unlock()
+	if progress.program != nil {
+		progress.program.Send(progressStreamMsg(chunk))
+	}
+}
+
+// progressStreamReset clears the tail line when a streamed reply ends
+func progressStreamReset() {
+	progress.mu.Lock()
+	defer progress.mu.Unlock()
+	if progress.program != nil {
+		progress.program.Send(progressStreamMsg(""))
+	}
+}
+
+// stopProgress ends the display and restores plain logging. It is safe to call more than once.
+func stopProgress() {
+	progress.mu.Lock()
+	if !progress.started {
+		progress.mu.Unlock()
+		return
+	}
+	p, exited := progress.program, progress.exited
+	progress.mu.Unlock()
+
+	progress.finishedOnce.Do(func() {
+		if p != nil {
+			p.Send(progressDoneMsg{at: time.Now()})
+			<-exited
+			return
+		}
+		if progress.stage != "" {
+			logInfo("⏱  %s took %s", progress.stage, time.Since(progress.stageStart).Round(100*time.Millisecond))
+		}
+		logInfo("⏱  Finished in %s (%d tokens)", time.Since(progress.start).Round(time.Second), progress.tokens.Load())
+	})
+}
+
+// progressLogWriter prints log lines above the live display
+type progressLogWriter struct{ p *tea.Program }
+
+func (w progressLogWriter) Write(b []byte) (int, error) {
+	w.p.Println(strings.TrimRight(string(b), "\n"))
+	return len(b), nil
+}
+
+type progressStageMsg struct {
+	name string
+	at   time.Time
+}
+
+type progressTokensMsg int64
+
+// progressStreamMsg is the next piece of a streamed reply; empty clears it
+type progressStreamMsg string
+
+// streamTailWidth is how much of a streamed reply the display's tail line shows
+const streamTailWidth = 72
+
+type progressDoneMsg struct{ at time.Time }
+
+type stageTiming struct {
+	start, end time.Time
+}
+
+type progressModel struct {
+	spinner  spinner.Model
+	start    time.Time
+	current  int
+	timings  []stageTiming
+	tokens   int64
+	finished bool
+	// streamed is the reply being written, while a reply streams
+	streamed []rune
+}
+
+func newProgressModel() progressModel {
+	return progressModel{
+		spinner: spinner.New(spinner.WithSpinner(spinner.Dot)),
+		start:   time.Now(),
+		current: -1,
+		timings: make([]stageTiming, len(pipelineStages)),
+	}
+}
+
+func (m progressModel) Init() tea.Cmd {
+	return m.spinner.Tick
+}
+
+func (m progressModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case progressStageMsg:
+		for i, name := range pipelineStages {
+			if name != msg.name {
+				continue
+			}
+			if m.current >= 0 {
+				m.timings[m.current].end = msg.at
+			}
+			m.current = i
+			m.timings[i].start = msg.at
+		}
+	case progressTokensMsg:
+		m.tokens = int64(msg)
+	case progressStreamMsg:
+		if msg == "" {
+			m.streamed = nil
+		} else {
+			m.streamed = append(m.streamed, []rune(string(msg))...)
+		}
+	case progressDoneMsg:
+		if m.current >= 0 && m.timings[m.current].end.IsZero() {
+			m.timings[m.current].end = msg.at
+		}
+		m.finished = true
+		return m, tea.Quit
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+func (m progressModel) View() string {
+	var b strings.Builder
+	for i, name := range pipelineStages {
+		t := m.timings[i]
+		switch {
+		case !t.end.IsZero():
+			fmt.Fprintf(&b, "  ✓ %-10s %s\n", name, t.end.Sub(t.start).Round(100*time.Millisecond))
+		case !t.start.IsZero():
+			fmt.Fprintf(&b, "  %s%-10s %s\n", m.spinner.View(), name, time.Since(t.start).Round(time.Second))
+		case i < m.current:
+			fmt.Fprintf(&b, "  – %-10s skipped\n", name)
+		default:
+			fmt.Fprintf(&b, "  · %s\n", name)
+		}
+	}
+	if len(m.streamed) > 0 {
+		tail := strings.Join(strings.Fields(string(m.streamed[max(0, len(m.streamed)-streamTailWidth):])), " ")
+		if len(m.streamed) > streamTailWidth {
+			tail = "…" + tail
+		}
+		fmt.Fprintf(&b, "    ✎ %s (%d chars)\n", tail, len(m.streamed))
+	}
+	fmt.Fprintf(&b, "  %s elapsed · %d tokens\n", time.Since(m.start).Round(time.Second), m.tokens)
+	return b.String()
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"text/template"
+)
+
+// promptData is what prompt templates can reference, e.g. {{ if eq .SourceType "github" }}
+type promptData struct {
+	SourceType string // github, website, research, digest, followup, issue, narrate, launch
+	Source     string
+	Tags       []string
+	Model      string
+	Date       string
+}
+
+// Hugo shortcodes in prompts are protected from text/template
+var shortcodeDelims = strings.NewReplacer("{{<", "\x00SC<", ">}}", ">SC\x00", "{{%", "\x00SC%", "%}}", "%SC\x00")
+var shortcodeRestore = strings.NewReplacer("\x00SC<", "{{<", ">SC\x00", ">}}", "\x00SC%", "{{%", "%SC\x00", "%}}")
+
+// loadPrompt reads a prompt file and renders it as a text/template with the
+// prompt function library. Hugo shortcodes like {{< figure >}} pass through untouched.
+func loadPrompt(path, sourceType, source string) (string, error) {
+	path = resolvePromptPath(path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read prompt file: %w", err)
+	}
+
+	var tagList []string
+	if tags != "" {
+		tagList = strings.Split(tags, ",")
+	}
+
+	tmpl, err := template.New(filepath.Base(path)).
+		Funcs(promptFuncs(filepath.Dir(path))).
+		Parse(shortcodeDelims.Replace(string(data)))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse prompt template: %w", err)
+	}
+	var out bytes.Buffer
+	err = tmpl.Execute(&out, promptData{
+		SourceType: sourceType,
+		Source:     source,
+		Tags:       tagList,
+		Model:      model,
+		Date:       postDateString(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to render prompt template: %w", err)
+	}
+	return shortcodeRestore.Replace(out.String()) + languagePromptSection(), nil
+}
+
+// promptFuncs is the helper library available to prompt templates. readFile is
+// confined to the prompt's own directory.
+func promptFuncs(dir string) template.FuncMap {
+	return template.FuncMap{
+		"truncateWords":   truncateWords,
+		"summarizeTokens": summarizeTokens,
+		"jsonEscape": func(s string) string {
+			b, _ := json.Marshal(s)
+			return string(b[1 : len(b)-1])
+		},
+		"listJoin": func(sep string, list interface{}) string {
+			v := reflect.ValueOf(list)
+			if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+				return fmt.Sprint(list)
+			}
+			items := make([]string, v.Len())
+			for i := range items {
+				items[i] = fmt.Sprint(v.Index(i).Interface())
+			}
+			return strings.Join(items, sep)
+		},
+		"readFile": func(name string) (string, error) {
+			path := filepath.Join(dir, name)
+			if rel, err := filepath.Rel(dir, path); err != nil || strings.HasPrefix(rel, "..") {
+				return "", fmt.Errorf("readFile %q is outside the prompts directory", name)
+			}
+			data, err := os.ReadFile(path)
+			if err == nil {
+				budgetInclude(name, string(data))
+			}
+			return string(data), err
+		},
+		"contains": strings.Contains,
+		"lower":    strings.ToLower,
+		"upper":    strings.ToUpper,
+	}
+}
+
+// truncateWords keeps the first n words of s
+func truncateWords(n int, s string) string {
+	words := strings.Fields(s)
+	if len(words) <= n {
+		return s
+	}
+	return strings.Join(words[:n], " ") + "..."
+}
+
+// summarizeTokens fits s into roughly n tokens (about four characters each),
+// cutting at the last sentence or line break before the limit
+func summarizeTokens(n int, s string) string {
+	limit := n * 4
+	if len(s) <= limit {
+		return s
+	}
+	cut := s[:limit]
+	if i := strings.LastIndexAny(cut, ".\n"); i > limit/2 {
+		cut = cut[:i+1]
+	}
+	return cut + " [truncated]"
+}
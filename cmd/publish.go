@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// publishCampaign is the campaign name publish records its posts under in the
+// schedule queue, for metrics and link tracking
+const publishCampaign = "publish"
+
+var (
+	publishPlatforms []string
+	publishText      string
+	publishForce     bool
+)
+
+var publishCmd = &cobra.Command{
+	Use:   "publish <post>",
+	Short: "Announce a post on Mastodon, Bluesky, and X now",
+	Long: `Posts a summary of a post and its link to each platform right away. The
+summary is the post's social_blurb, else its description, summary, or title,
+shortened to fit each platform with the link.
+
+By default it posts to every platform whose credentials are set, in the
+environment or under credentials: in ~/.megafone.yaml:
+
+  mastodon  MASTODON_SERVER, MASTODON_ACCESS_TOKEN
+  bluesky   BLUESKY_HANDLE, BLUESKY_APP_PASSWORD
+  x         X_API_KEY, X_API_SECRET, X_ACCESS_TOKEN, X_ACCESS_TOKEN_SECRET
+
+What was sent is recorded in the schedule queue (.megafone/state/schedule.json), so
+'megafone metrics sync' reads its engagement, a platform that failed is
+retried by 'megafone schedule run', and publishing again skips the platforms
+already posted to. Drafts aren't published without --force.
+
+Examples:
+  megafone publish content/posts/en/my-post.md
+  megafone publish content/posts/en/my-post.md --platforms mastodon,bluesky
+  megafone publish content/posts/en/my-post.md --text "New post on building a CLI in Go" --dry-run`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runPublish(args[0]); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(publishCmd)
+
+	publishCmd.Flags().StringSliceVar(&publishPlatforms, "platforms", nil, "Platforms to post to: mastodon, bluesky, x (default: those with credentials set)")
+	publishCmd.Flags().StringVar(&publishText, "text", "", "Text to post instead of the post's social_blurb or description")
+	publishCmd.Flags().BoolVarP(&publishForce, "force", "f", false, "Publish drafts, and post again to platforms the post was already published on")
+	publishCmd.Flags().StringVarP(&siteSource, "site-source", "s", "", "Path to local Hugo site repository (default: found from the post path)")
+	publishCmd.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "Show what would be posted without posting")
+}
+
+func runPublish(postPath string) error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	basePath, err := scheduleSitePath(postPath)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(postPath)
+	if err != nil {
+		return fmt.Errorf("failed to read post: %w", err)
+	}
+	content := string(data)
+	if frontMatterString(content, "draft") == "true" && !publishForce {
+		return fmt.Errorf("%s is a draft (use --force to publish it anyway)", postPath)
+	}
+
+	platforms := publishPlatforms
+	if len(platforms) == 0 {
+		for _, p := range socialPlatforms {
+			if socialConfigured(p) {
+				platforms = append(platforms, p)
+			}
+		}
+		if len(platforms) == 0 && dryRun {
+			platforms = socialPlatforms
+		}
+		if len(platforms) == 0 {
+			return classify(ErrAuth, fmt.Errorf("no social platform credentials are set (see 'megafone publish --help')"))
+		}
+	}
+	if err := checkSocialPlatforms(platforms); err != nil {
+		return err
+	}
+
+	baseURL := siteBaseURL(basePath)
+	if baseURL == "" {
+		return fmt.Errorf("the Hugo config has no baseURL, so the post's link can't be built")
+	}
+	link := baseURL + postURL(basePath, postPath, content)
+	text := firstNonEmpty(publishText, frontMatterString(content, "social_blurb"), frontMatterString(content, "description"), frontMatterString(content, "summary"), frontMatterString(content, "title"))
+
+	absBase, _ := filepath.Abs(basePath)
+	absPost, _ := filepath.Abs(postPath)
+	rel := filepath.ToSlash(mustRel(absBase, absPost))
+	slug := strings.TrimSuffix(filepath.Base(rel), ".md")
+
+	q, err := loadScheduleQueue(basePath)
+	if err != nil {
+		return err
+	}
+	var item *scheduledPost
+	for i := range q.Items {
+		if q.Items[i].Post == rel && q.Items[i].Campaign == publishCampaign {
+			item = &q.Items[i]
+		}
+	}
+	if item == nil {
+		q.Items = append(q.Items, scheduledPost{ID: slug + "-" + publishCampaign, Post: rel, Campaign: publishCampaign, Kind: dripAnnouncement})
+		item = &q.Items[len(q.Items)-1]
+	}
+	item.Text, item.Link, item.Due = text, link, time.Now()
+	for _, p := range platforms {
+		if !slices.Contains(item.Platforms, p) {
+			item.Platforms = append(item.Platforms, p)
+		}
+	}
+	for _, m := range []*map[string]string{&item.Sent, &item.Errors} {
+		if *m == nil {
+			*m = make(map[string]string)
+		}
+	}
+	if item.Attempts == nil {
+		item.Attempts = make(map[string]int)
+	}
+
+	ctx := context.Background()
+	sent, failed := 0, 0
+	for _, platform := range platforms {
+		if url := item.Sent[platform]; url != "" && !publishForce {
+			logInfo("Already published on %s: %s (use --force to post again)", platform, url)
+			continue
+		}
+		tracked := trackLink(ctx, link, platform, publishCampaign, slug)
+		post := fitSocialPost(trackTextLinks(ctx, text, baseURL, platform, publishCampaign, slug), tracked, socialPostLimits[platform])
+		if dryRun {
+			fmt.Printf("%s:\n  %s\n\n", platform, post)
+			continue
+		}
+
+		url, err := postToSocial(ctx, platform, post)
+		item.Attempts[platform]++
+		if err != nil {
+			failed++
+			item.Errors[platform] = err.Error()
+			logError("Failed to post to %s: %v", platform, err)
+			if errors.Is(err, ErrAuth) {
+				// Missing credentials don't use up schedule run's retries
+				item.Attempts[platform]--
+			}
+			continue
+		}
+		sent++
+		delete(item.Errors, platform)
+		item.Sent[platform] = url
+		logSuccess("📣 Posted to %s: %s", platform, url)
+	}
+
+	if dryRun {
+		logInfo("Dry run mode - not posting")
+		return nil
+	}
+	sort.SliceStable(q.Items, func(i, j int) bool { return q.Items[i].Due.Before(q.Items[j].Due) })
+	if err := saveScheduleQueue(basePath, q); err != nil {
+		return fmt.Errorf("failed to save schedule queue: %w", err)
+	}
+	if failed > 0 {
+		return fmt.Errorf("posting failed on %d platforms; 'megafone schedule run' retries them", failed)
+	}
+	logSuccess("✅ Published %s on %d platforms", rel, sent)
+	return nil
+}
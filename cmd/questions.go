@@ -0,0 +1,274 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/sashabaranov/go-openai"
+	"github.com/spf13/cobra"
+)
+
+const (
+	questionsMarkdown = "markdown"
+	questionsEmail    = "email"
+	questionsStub     = "stub"
+)
+
+var (
+	questionsCount  int
+	questionsFormat string
+	questionsOutput string
+	questionsFrom   string
+)
+
+var questionsCmd = &cobra.Command{
+	Use:   "questions <repo>",
+	Short: "Write interview questions for a project's maintainer",
+	Long: `Analyzes a GitHub repository (README, recent releases, the most discussed
+issues, alternatives, and activity) and writes specific questions for its
+maintainer, for interview-style posts that need their answers first.
+
+Formats:
+  markdown  the questions with space for answers (default)
+  email     a ready-to-send email asking the maintainer to answer them
+  stub      a post stub ('megafone generate --from-stub') whose body holds the
+            questions; fill in the answers, then expand it into the post
+
+Examples:
+  megafone questions https://github.com/user/repo
+  megafone questions user/repo --format email --from "Sam" -o email.txt
+  megafone questions user/repo --format stub -o ~/hugo/content/posts/en/ideas/repo-interview.md`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runQuestions(cmd, args[0]); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(questionsCmd)
+
+	questionsCmd.Flags().IntVarP(&questionsCount, "count", "n", 10, "Number of questions")
+	questionsCmd.Flags().StringVarP(&questionsFormat, "format", "f", questionsMarkdown, "Output format: markdown, email, or stub")
+	questionsCmd.Flags().StringVarP(&questionsOutput, "output", "o", "", "Output file (default: print to stdout)")
+	questionsCmd.Flags().StringVar(&questionsFrom, "from", "", "Your name, to sign the email")
+	questionsCmd.Flags().StringVarP(&model, "model", "m", "gpt-4o", "OpenAI model to use")
+}
+
+type interviewQuestion struct {
+	Topic    string `json:"topic"`
+	Question string `json:"question"`
+	Why      string `json:"why"`
+}
+
+type interviewQuestions struct {
+	Maintainer string              `json:"-"`
+	Repo       *github.Repository  `json:"-"`
+	Intro      string              `json:"intro"`
+	Questions  []interviewQuestion `json:"questions"`
+}
+
+func runQuestions(cmd *cobra.Command, repoArg string) error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	switch questionsFormat {
+	case questionsMarkdown, questionsEmail, questionsStub:
+	default:
+		return fmt.Errorf("invalid --format value %q (use markdown, email, or stub)", questionsFormat)
+	}
+	owner, repo, err := parseGitHubURL(repoArg)
+	if err != nil {
+		return err
+	}
+	apiKey, err := getOpenAIKey(cmd)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	logInfo("📦 Analyzing %s/%s...", owner, repo)
+	gh := newGitHubClient()
+	repoData, _, err := gh.Repositories.Get(ctx, owner, repo)
+	if err != nil {
+		return classify(ErrSource, fmt.Errorf("failed to fetch repository: %w", err))
+	}
+	analysis := analyzeRepoForInterview(ctx, gh, repoData)
+
+	logInfo("🤔 Writing %d questions...", questionsCount)
+	q, err := writeInterviewQuestions(ctx, openai.NewClient(apiKey), repoData, analysis)
+	if err != nil {
+		return classify(ErrGeneration, err)
+	}
+	q.Maintainer = maintainerName(ctx, gh, repoData)
+
+	var out string
+	switch questionsFormat {
+	case questionsEmail:
+		out = q.email(questionsFrom)
+	case questionsStub:
+		out = q.stub()
+	default:
+		out = q.markdown()
+	}
+	if questionsOutput == "" {
+		fmt.Print(out)
+		return nil
+	}
+	if err := os.WriteFile(questionsOutput, []byte(out), 0644); err != nil {
+		return fmt.Errorf("failed to write questions: %w", err)
+	}
+	logSuccess("✅ Wrote %d questions to %s", len(q.Questions), questionsOutput)
+	return nil
+}
+
+// analyzeRepoForInterview collects what a good interviewer would read first:
+// the README, release notes, the most discussed issues, and the project's
+// place among its alternatives
+func analyzeRepoForInterview(ctx context.Context, gh *github.Client, repoData *github.Repository) string {
+	owner, repo := repoData.GetOwner().GetLogin(), repoData.GetName()
+	var b strings.Builder
+	fmt.Fprintf(&b, "Project: %s\nDescription: %s\nCreated: %s\nTopics: %s\n",
+		repoData.GetFullName(), repoData.GetDescription(), repoData.GetCreatedAt().Format("2006-01-02"), strings.Join(repoData.Topics, ", "))
+
+	if readme, _, err := gh.Repositories.GetReadme(ctx, owner, repo, nil); err == nil {
+		if text, err := readme.GetContent(); err == nil {
+			fmt.Fprintf(&b, "\nREADME:\n%s\n", summarizeTokens(5000, text))
+		}
+	}
+
+	if releases, _, err := gh.Repositories.ListReleases(ctx, owner, repo, &github.ListOptions{PerPage: 5}); err == nil && len(releases) > 0 {
+		b.WriteString("\nRecent releases:\n")
+		for _, r := range releases {
+			fmt.Fprintf(&b, "- %s (%s): %s\n", r.GetTagName(), r.GetPublishedAt().Format("2006-01-02"), summarizeTokens(300, r.GetBody()))
+		}
+	}
+
+	issues, _, err := gh.Search.Issues(ctx, fmt.Sprintf("repo:%s is:issue", repoData.GetFullName()), &github.SearchOptions{Sort: "comments", Order: "desc", ListOptions: github.ListOptions{PerPage: 8}})
+	if err == nil && len(issues.Issues) > 0 {
+		b.WriteString("\nMost discussed issues:\n")
+		for _, i := range issues.Issues {
+			fmt.Fprintf(&b, "- #%d %s (%s, %d comments): %s\n", i.GetNumber(), i.GetTitle(), i.GetState(), i.GetComments(), summarizeTokens(150, i.GetBody()))
+		}
+	} else if err != nil {
+		logVerbose("Issue search failed: %v", err)
+	}
+
+	facts := gatherEcosystemFacts(ctx, gh, repoData)
+	if len(facts.Alternatives) > 0 {
+		fmt.Fprintf(&b, "\nAlternatives:\n- %s\n", strings.Join(facts.Alternatives, "\n- "))
+	}
+	if lines := append(facts.Packages, facts.Activity...); len(lines) > 0 {
+		fmt.Fprintf(&b, "\nAdoption and activity:\n- %s\n", strings.Join(lines, "\n- "))
+	}
+	return b.String()
+}
+
+func writeInterviewQuestions(ctx context.Context, client *openai.Client, repoData *github.Repository, analysis string) (*interviewQuestions, error) {
+	prompt := fmt.Sprintf(`You are preparing an interview with the maintainer of %s for a technical blog.
+Write %d questions that only the maintainer can answer and that make for a good
+read: why it exists, the design decisions and trade-offs visible in the code and
+issues, what they'd do differently, how they handle contributors and feature
+requests, what's next, and how it compares with the alternatives.
+
+Each question must be specific to this project, citing what prompted it (a
+release, an issue, a README claim, an alternative), open-ended, and answerable
+in a paragraph. No questions the README already answers. Order them so the
+interview flows from origin to future.
+
+Also write a two-sentence intro for the maintainer explaining what the interview
+will cover.
+
+Respond with only JSON: {"intro": "...", "questions": [{"topic": "short theme", "question": "...", "why": "what prompted it"}]}
+
+%s`, repoData.GetFullName(), questionsCount, analysis)
+
+	resp, err := createChatCompletion(ctx, client, openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: "You are a well-prepared technical interviewer. Your questions show you've read the code and the issue tracker.",
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: prompt,
+			},
+		},
+		Temperature: 0.7,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("OpenAI API error: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no response from OpenAI")
+	}
+	q := &interviewQuestions{Repo: repoData}
+	if err := decodeModelJSON(resp.Choices[0].Message.Content, q); err != nil {
+		return nil, fmt.Errorf("failed to parse questions: %w", err)
+	}
+	if len(q.Questions) == 0 {
+		return nil, fmt.Errorf("no questions returned")
+	}
+	return q, nil
+}
+
+// maintainerName is the name to address the interview to: the owner's
+// display name, or their login
+func maintainerName(ctx context.Context, gh *github.Client, repoData *github.Repository) string {
+	login := repoData.GetOwner().GetLogin()
+	if repoData.GetOwner().GetType() == "User" {
+		if user, _, err := gh.Users.Get(ctx, login); err == nil && user.GetName() != "" {
+			return user.GetName()
+		}
+	}
+	return login
+}
+
+func (q *interviewQuestions) markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Interview: %s\n\n", q.Repo.GetFullName())
+	fmt.Fprintf(&b, "With %s · %s\n\n%s\n", q.Maintainer, q.Repo.GetHTMLURL(), q.Intro)
+	for i, item := range q.Questions {
+		fmt.Fprintf(&b, "\n## %d. %s\n\n%s\n\n<!-- Prompted by: %s -->\n\n**Answer:**\n\n", i+1, item.Topic, item.Question, item.Why)
+	}
+	return b.String()
+}
+
+func (q *interviewQuestions) email(from string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Subject: A short interview about %s?\n\n", q.Repo.GetName())
+	fmt.Fprintf(&b, "Hi %s,\n\n", q.Maintainer)
+	fmt.Fprintf(&b, "I'm writing a post about %s and would love to include your perspective. %s\n\n", q.Repo.GetFullName(), q.Intro)
+	b.WriteString("Answer as many or as few as you like, in as much detail as you like; a few sentences each is plenty. Reply inline and I'll send you the draft before it goes up.\n\n")
+	for i, item := range q.Questions {
+		fmt.Fprintf(&b, "%d. %s\n\n", i+1, item.Question)
+	}
+	b.WriteString("Thanks for building it!\n")
+	if from != "" {
+		fmt.Fprintf(&b, "\n%s\n", from)
+	}
+	return b.String()
+}
+
+// stub renders the questions as a post stub; once answered, its body is the
+// notes 'megafone generate --from-stub' writes the interview from
+func (q *interviewQuestions) stub() string {
+	var b strings.Builder
+	b.WriteString("---\n")
+	fmt.Fprintf(&b, "topic: %s\n", yamlQuote(q.Repo.GetHTMLURL()))
+	if len(q.Repo.Topics) > 0 {
+		fmt.Fprintf(&b, "tags: [%s]\n", strings.Join(q.Repo.Topics, ", "))
+	}
+	b.WriteString("tone: \"interview: let the maintainer's answers carry the post\"\n")
+	b.WriteString("---\n\n")
+	fmt.Fprintf(&b, "Interview with %s, maintainer of %s. The answers below are theirs, not mine:\nquote them directly and attribute them to %s. Skip unanswered questions.\n", q.Maintainer, q.Repo.GetFullName(), q.Maintainer)
+	for i, item := range q.Questions {
+		fmt.Fprintf(&b, "\n## Q%d. %s\n\n**A:**\n", i+1, item.Question)
+	}
+	return b.String()
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	redditComments int
+	redditMinScore int
+)
+
+// isRedditThreadURL reports whether a URL is a Reddit post's comments page
+func isRedditThreadURL(input string) bool {
+	u, err := url.Parse(input)
+	if err != nil {
+		return false
+	}
+	host := strings.TrimPrefix(u.Hostname(), "www.")
+	return (host == "reddit.com" || strings.HasSuffix(host, ".reddit.com")) && strings.Contains(u.Path, "/comments/")
+}
+
+// fetchRedditDiscussionThread reads a Reddit post and its top-level comments
+// through the thread's .json endpoint. Comments scored under
+// --reddit-min-score and pinned moderator comments are skipped; the
+// --reddit-comments highest scored are kept.
+func fetchRedditDiscussionThread(topicURL string) (*discussionThread, error) {
+	u, err := url.Parse(topicURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Reddit URL: %w", err)
+	}
+	logInfo("👽 Fetching Reddit thread...")
+	listings, err := fetchRedditListing(u)
+	if err != nil {
+		return nil, err
+	}
+	if len(listings) < 2 || len(listings[0].Data.Children) == 0 {
+		return nil, fmt.Errorf("unexpected Reddit response shape")
+	}
+	post := listings[0].Data.Children[0].Data
+	title := html.UnescapeString(post.Title)
+
+	var comments []discussionComment
+	for _, t := range listings[1].Data.Children {
+		if t.Kind != "t1" || t.Data.Stickied || t.Data.Score < redditMinScore {
+			continue
+		}
+		body := strings.TrimSpace(t.Data.Body)
+		if body == "" || body == "[deleted]" || body == "[removed]" {
+			continue
+		}
+		comments = append(comments, discussionComment{
+			Author:  t.Data.Author,
+			Text:    html.UnescapeString(body),
+			Score:   t.Data.Score,
+			Replies: countRedditReplies(t),
+		})
+	}
+	sort.SliceStable(comments, func(i, j int) bool { return comments[i].Score > comments[j].Score })
+	if len(comments) > redditComments {
+		comments = comments[:redditComments]
+	}
+	logInfo("💬 %q in r/%s: %d points, read %d of %d comments", title, post.Subreddit, post.Score, len(comments), post.NumComments)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n\n## Reddit Discussion\nThread: %s\n", topicURL)
+	fmt.Fprintf(&b, "%q, posted by u/%s in r/%s on %s: %d points, %d comments. The top %d comments by score (minimum score %d):\n\n",
+		title, post.Author, post.Subreddit, time.Unix(int64(post.CreatedUTC), 0).UTC().Format("2006-01-02"), post.Score, post.NumComments, len(comments), redditMinScore)
+	for _, c := range comments {
+		fmt.Fprintf(&b, "- u/%s (score %d, %d replies): %s\n", c.Author, c.Score, c.Replies, trimToLength(strings.ReplaceAll(c.Text, "\n", " "), threadMaxCommentChars))
+	}
+
+	thread := &discussionThread{
+		Title:      title,
+		Author:     "u/" + post.Author,
+		Site:       "r/" + post.Subreddit,
+		Text:       fmt.Sprintf("# %s\n\nPosted by u/%s in r/%s\n\n%s", title, post.Author, post.Subreddit, html.UnescapeString(post.Selftext)),
+		Discussion: b.String(),
+	}
+	// Image, video, and gallery posts have no article to read
+	if !post.IsSelf && redditArticleLink(post.URL) {
+		thread.LinkURL = post.URL
+	}
+	return thread, nil
+}
+
+// redditArticleLink reports whether a link post points at a page worth
+// fetching, rather than Reddit's own media hosts
+func redditArticleLink(link string) bool {
+	u, err := url.Parse(link)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	host := strings.TrimPrefix(u.Hostname(), "www.")
+	for _, media := range []string{"i.redd.it", "v.redd.it", "reddit.com", "i.imgur.com"} {
+		if host == media || strings.HasSuffix(host, "."+media) {
+			return false
+		}
+	}
+	return true
+}
+
+func countRedditReplies(t redditThing) int {
+	var replies redditThing
+	if len(t.Data.Replies) == 0 || json.Unmarshal(t.Data.Replies, &replies) != nil {
+		return 0
+	}
+	n := 0
+	for _, r := range replies.Data.Children {
+		if r.Kind == "t1" {
+			n += 1 + countRedditReplies(r)
+		}
+	}
+	return n
+}
@@ -0,0 +1,410 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/sashabaranov/go-openai"
+	"github.com/spf13/cobra"
+)
+
+var (
+	releaseRepo string
+	releaseFrom string
+	releaseTo   string
+)
+
+// releaseMaxPRs caps how many pull requests are read for one release
+const releaseMaxPRs = 80
+
+// prNumberRegex finds the pull request a commit came from: "Merge pull request
+// #12 from ..." for merge commits, or a trailing "(#12)" for squash merges
+var prNumberRegex = regexp.MustCompile(`^Merge pull request #(\d+)|\(#(\d+)\)\s*$`)
+
+// releaseChanges is what went into a release: its notes, the pull requests
+// merged for it, and the commits between the two tags
+type releaseChanges struct {
+	Release      *github.RepositoryRelease
+	PullRequests []*github.PullRequest
+	Commits      []*github.RepositoryCommit
+	Skipped      int
+	Files        []*github.CommitFile
+}
+
+var releaseCmd = &cobra.Command{
+	Use:   "release",
+	Short: "Write a \"what's new\" post for a release from its notes, pull requests, and commits",
+	Long: `Reads what changed between two tags — the GitHub release body, the pull
+requests merged in between, and the commits — and writes a "what's new" post
+announcing the release. Unlike generate, which reads the README, this covers
+what is actually in the release.
+
+--to defaults to the latest release, and --from to the release before --to.
+Commits are filtered like narrate-commits (--skip, --skip-authors). Set
+GITHUB_TOKEN for private repositories and higher rate limits.
+
+Examples:
+  megafone release -t michaeldvinci/megafone -s ~/hugo
+  megafone release -t michaeldvinci/megafone --from v1.2.0 --to v1.3.0 --dry-run`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runRelease(cmd); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(releaseCmd)
+
+	releaseCmd.Flags().StringVarP(&releaseRepo, "topic", "t", "", "GitHub repository (owner/repo or URL) (required)")
+	releaseCmd.Flags().StringVar(&releaseFrom, "from", "", "Tag of the previous release (default: the release before --to)")
+	releaseCmd.Flags().StringVar(&releaseTo, "to", "", "Tag of the release to announce (default: the latest release)")
+	releaseCmd.Flags().StringArrayVar(&narrateSkip, "skip", defaultNarrateSkip, "Regex for commit subjects to leave out (repeatable; replaces the defaults)")
+	releaseCmd.Flags().StringSliceVar(&narrateSkipAuthors, "skip-authors", []string{"dependabot[bot]", "renovate[bot]"}, "Commit and pull request authors to leave out")
+	releaseCmd.Flags().StringVarP(&tags, "tags", "T", "", "Comma-separated tags (AI will suggest if not provided)")
+	releaseCmd.Flags().StringVarP(&promptFile, "prompt", "p", "prompts/release-notes.txt", "Path to prompt template file")
+	releaseCmd.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "Print generated content without writing files")
+	releaseCmd.Flags().StringVarP(&model, "model", "m", "gpt-4o", "OpenAI model to use")
+	releaseCmd.Flags().StringVarP(&siteSource, "site-source", "s", "", "Path to local Hugo site repository")
+
+	releaseCmd.MarkFlagRequired("topic")
+}
+
+func runRelease(cmd *cobra.Command) error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	ctx := context.Background()
+
+	owner, repo, err := parseGitHubURL(releaseRepo)
+	if err != nil {
+		return fmt.Errorf("invalid repository: %w", err)
+	}
+	var skip []*regexp.Regexp
+	for _, pattern := range narrateSkip {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid --skip pattern %q: %w", pattern, err)
+		}
+		skip = append(skip, re)
+	}
+
+	basePath, err := resolveSitePath()
+	if err != nil {
+		return err
+	}
+	apiKey, err := getOpenAIKey(cmd)
+	if err != nil {
+		return err
+	}
+
+	client := newGitHubClient()
+	from, to, err := resolveReleaseRange(ctx, client, owner, repo, releaseFrom, releaseTo)
+	if err != nil {
+		return classify(ErrSource, err)
+	}
+
+	logInfo("🏷️  Collecting what changed in %s/%s %s...%s", owner, repo, from, to)
+	changes, err := fetchReleaseChanges(ctx, client, owner, repo, from, to, skip, narrateSkipAuthors)
+	if err != nil {
+		return classify(ErrSource, err)
+	}
+	logInfo("Found %d pull requests and %d commits (%d filtered), %d files changed; release notes: %t",
+		len(changes.PullRequests), len(changes.Commits), changes.Skipped, len(changes.Files), changes.Release != nil)
+	if len(changes.PullRequests)+len(changes.Commits) == 0 && changes.Release.GetBody() == "" {
+		return fmt.Errorf("nothing to write about between %s and %s: no pull requests, commits, or release notes", from, to)
+	}
+
+	source := fmt.Sprintf("https://github.com/%s/%s/releases/tag/%s", owner, repo, to)
+	if changes.Release == nil {
+		source = fmt.Sprintf("https://github.com/%s/%s/compare/%s...%s", owner, repo, from, to)
+	}
+	promptTemplate, err := loadPrompt(promptFile, "release", source)
+	if err != nil {
+		return err
+	}
+
+	oc := openai.NewClient(apiKey)
+	logInfo("🤖 Writing the release post with %s...", model)
+	content, err := generateReleasePost(ctx, oc, promptTemplate, owner+"/"+repo, from, to, changes, tags, model)
+	if err != nil {
+		return classify(ErrGeneration, err)
+	}
+
+	content = applyDatePolicy(content, postDateString(), basePath)
+	content = applyHighlights(ctx, oc, content)
+	if content, err = applySummarySections(ctx, oc, content, "release"); err != nil {
+		return err
+	}
+	content = applyAccessibility(content)
+	if content, err = applyDisclosure(content, basePath); err != nil {
+		return err
+	}
+	if content, err = applyFrontMatterDefaults(content, "release", source); err != nil {
+		return err
+	}
+	if content, err = applySecretScan(content); err != nil {
+		return err
+	}
+	filename := sanitizeFilename(fmt.Sprintf("whats new in %s %s", repo, to))
+	if content, filename, err = applySlugStrategy(content, filename, ""); err != nil {
+		return err
+	}
+
+	if dryRun {
+		logInfo("Dry run mode - not writing files")
+		fmt.Println("\n" + strings.Repeat("=", 80))
+		fmt.Println("DRY RUN - Generated Content:")
+		fmt.Println(strings.Repeat("=", 80))
+		fmt.Println(content)
+		fmt.Println(strings.Repeat("=", 80))
+		return nil
+	}
+
+	postPath, err := writeNewPost(basePath, filepath.Join(postsDir(basePath), filename+".md"), content)
+	if err != nil {
+		return fmt.Errorf("failed to write post: %w", err)
+	}
+	logSuccess("✅ Release post created: %s", postPath)
+
+	var tagList []string
+	if tags != "" {
+		tagList = strings.Split(tags, ",")
+	}
+	logGeneration(source, postPath, "", tagList)
+	return runHooks("post_generate", appConfig.Hooks.PostGenerate, hookRun{Post: postPath, Source: source, Site: basePath})
+}
+
+// resolveReleaseRange fills in --to with the latest release and --from with
+// the release published before it
+func resolveReleaseRange(ctx context.Context, client *github.Client, owner, repo, from, to string) (string, string, error) {
+	if from != "" && to != "" {
+		return from, to, nil
+	}
+	releases, _, err := client.Repositories.ListReleases(ctx, owner, repo, &github.ListOptions{PerPage: 100})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to list releases: %w", err)
+	}
+	var published []*github.RepositoryRelease
+	for _, r := range releases {
+		if !r.GetDraft() {
+			published = append(published, r)
+		}
+	}
+	sort.SliceStable(published, func(i, j int) bool {
+		return published[i].GetPublishedAt().After(published[j].GetPublishedAt().Time)
+	})
+
+	if to == "" {
+		if len(published) == 0 {
+			return "", "", fmt.Errorf("%s/%s has no releases; pass --from and --to", owner, repo)
+		}
+		to = published[0].GetTagName()
+		logInfo("🏷️  Latest release: %s", to)
+	}
+	if from == "" {
+		for i, r := range published {
+			if r.GetTagName() == to && i+1 < len(published) {
+				from = published[i+1].GetTagName()
+			}
+		}
+		if from == "" {
+			return "", "", fmt.Errorf("no release before %s in %s/%s; pass --from", to, owner, repo)
+		}
+		logInfo("🏷️  Previous release: %s", from)
+	}
+	return from, to, nil
+}
+
+// fetchReleaseChanges collects the release notes for to, the commits since
+// from, and the pull requests those commits were merged from
+func fetchReleaseChanges(ctx context.Context, client *github.Client, owner, repo, from, to string, skip []*regexp.Regexp, skipAuthors []string) (*releaseChanges, error) {
+	var changes releaseChanges
+	release, resp, err := client.Repositories.GetReleaseByTag(ctx, owner, repo, to)
+	switch {
+	case err == nil:
+		changes.Release = release
+	case resp != nil && resp.StatusCode == http.StatusNotFound:
+		logInfo("No GitHub release for %s; writing from pull requests and commits", to)
+	default:
+		return nil, fmt.Errorf("failed to fetch release %s: %w", to, err)
+	}
+
+	comparison, err := fetchComparison(ctx, client, owner, repo, from, to)
+	if err != nil {
+		return nil, err
+	}
+	changes.Files = comparison.Files
+
+	// Pull requests are found from every commit, merge commits included, before
+	// the filters drop them
+	var numbers []int
+	seen := map[int]bool{}
+	for _, c := range comparison.Commits {
+		subject, _, _ := strings.Cut(c.GetCommit().GetMessage(), "\n")
+		if m := prNumberRegex.FindStringSubmatch(subject); m != nil {
+			n, _ := strconv.Atoi(firstNonEmpty(m[1], m[2]))
+			if n > 0 && !seen[n] {
+				seen[n] = true
+				numbers = append(numbers, n)
+			}
+		}
+	}
+	if len(numbers) > releaseMaxPRs {
+		logInfo("Reading the last %d of %d pull requests", releaseMaxPRs, len(numbers))
+		numbers = numbers[len(numbers)-releaseMaxPRs:]
+	}
+	changes.PullRequests = fetchPullRequests(ctx, client, owner, repo, numbers, skipAuthors)
+	changes.Commits, changes.Skipped = filterCommits(comparison.Commits, skip, skipAuthors)
+	return &changes, nil
+}
+
+// fetchPullRequests loads pull requests by number, in order, leaving out those
+// by skipAuthors and any that fail to load
+func fetchPullRequests(ctx context.Context, client *github.Client, owner, repo string, numbers []int, skipAuthors []string) []*github.PullRequest {
+	authors := make(map[string]bool)
+	for _, a := range skipAuthors {
+		authors[strings.ToLower(a)] = true
+	}
+
+	prs := make([]*github.PullRequest, len(numbers))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 8)
+	for i, n := range numbers {
+		wg.Add(1)
+		go func(i, n int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			pr, _, err := client.PullRequests.Get(ctx, owner, repo, n)
+			if err != nil {
+				logVerbose("Skipping pull request #%d: %v", n, err)
+				return
+			}
+			prs[i] = pr
+		}(i, n)
+	}
+	wg.Wait()
+
+	var kept []*github.PullRequest
+	for _, pr := range prs {
+		if pr != nil && !authors[strings.ToLower(pr.GetUser().GetLogin())] {
+			kept = append(kept, pr)
+		}
+	}
+	return kept
+}
+
+// summary renders the release for the prompt: the notes first, since the
+// maintainers wrote them, then pull requests, then the remaining commits
+func (c *releaseChanges) summary() string {
+	var b strings.Builder
+	if c.Release != nil {
+		fmt.Fprintf(&b, "Release: %s (%s), published %s\n", firstNonEmpty(c.Release.GetName(), c.Release.GetTagName()),
+			c.Release.GetHTMLURL(), c.Release.GetPublishedAt().Format("2006-01-02"))
+		if c.Release.GetPrerelease() {
+			b.WriteString("This is a pre-release.\n")
+		}
+		fmt.Fprintf(&b, "\nRelease notes:\n%s\n", firstNonEmpty(trimToLength(c.Release.GetBody(), 12000), "(none)"))
+	}
+
+	if len(c.PullRequests) > 0 {
+		b.WriteString("\nMerged pull requests:\n")
+		for _, pr := range c.PullRequests {
+			fmt.Fprintf(&b, "- #%d %s by @%s", pr.GetNumber(), pr.GetTitle(), pr.GetUser().GetLogin())
+			var labels []string
+			for _, l := range pr.Labels {
+				labels = append(labels, l.GetName())
+			}
+			if len(labels) > 0 {
+				fmt.Fprintf(&b, " [%s]", strings.Join(labels, ", "))
+			}
+			if body := strings.Join(strings.Fields(pr.GetBody()), " "); body != "" {
+				fmt.Fprintf(&b, ": %s", trimToLength(body, 400))
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	// Commits that came in through a pull request are already covered by it
+	var commits strings.Builder
+	for _, commit := range c.Commits {
+		message := strings.TrimSpace(commit.GetCommit().GetMessage())
+		subject, _, _ := strings.Cut(message, "\n")
+		if len(c.PullRequests) > 0 && prNumberRegex.MatchString(subject) {
+			continue
+		}
+		fmt.Fprintf(&commits, "- %s (@%s): %s\n", commit.GetSHA()[:7], commit.GetAuthor().GetLogin(), trimToLength(strings.ReplaceAll(message, "\n", " "), 300))
+	}
+	if commits.Len() > 0 {
+		fmt.Fprintf(&b, "\nOther commits:\n%s\n", trimToLength(commits.String(), 10000))
+	}
+
+	files := append([]*github.CommitFile(nil), c.Files...)
+	sort.Slice(files, func(i, j int) bool { return files[i].GetChanges() > files[j].GetChanges() })
+	if len(files) > 0 {
+		b.WriteString("\nMost-changed files:\n")
+		for i, f := range files {
+			if i == 25 {
+				fmt.Fprintf(&b, "... and %d more files\n", len(files)-25)
+				break
+			}
+			fmt.Fprintf(&b, "- %s (+%d/-%d)\n", f.GetFilename(), f.GetAdditions(), f.GetDeletions())
+		}
+	}
+	return b.String()
+}
+
+func generateReleasePost(ctx context.Context, client *openai.Client, promptTemplate, fullName, from, to string, changes *releaseChanges, userTags, model string) (string, error) {
+	userPrompt := fmt.Sprintf(`%s
+
+Please write a "what's new" post announcing %s %s (changes since %s).
+
+%s
+
+Instructions:
+- Lead with the changes that most affect users; the release notes say what the maintainers consider important
+- Group changes into a few themes; don't list every pull request
+- Put breaking changes and upgrade steps in their own section if there are any
+- Link pull requests as [#123](https://github.com/%s/pull/123)
+- Thank outside contributors by @handle
+- Only describe changes in the material above; never invent features or numbers
+
+User-provided tags: %s (suggest appropriate tags if none provided)
+
+IMPORTANT: Your response must be ONLY valid markdown. Do not include any explanatory text before or after the markdown.
+IMPORTANT: Use date: %s in the front matter.
+
+Generate a complete Hugo markdown post following the style guide above.
+`, promptTemplate, fullName, to, from, changes.summary(), fullName, userTags, postDateString())
+
+	resp, err := createChatCompletion(withStreaming(ctx), client, openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: "You are a maintainer announcing a release to its users: clear about what's new and what to do about it. Follow the style guide precisely. Output ONLY the markdown content, no explanations.",
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: userPrompt,
+			},
+		},
+		Temperature: 0.6,
+	})
+	if err != nil {
+		return "", fmt.Errorf("OpenAI API error: %w", err)
+	}
+	if len(resp.Choices) == 0 || resp.Choices[0].Message.Content == "" {
+		return "", fmt.Errorf("no response from OpenAI")
+	}
+	return resp.Choices[0].Message.Content, nil
+}
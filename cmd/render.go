@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"mime"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	gmhtml "github.com/yuin/goldmark/renderer/html"
+)
+
+var (
+	figureShortcodeRegex   = regexp.MustCompile(`\{\{<\s*figure\s+([^>]*?)\s*>\}\}`)
+	gifVideoShortcodeRegex = regexp.MustCompile(`\{\{<\s*gif-video\s+([^>]*?)\s*>\}\}`)
+	shortcodeArgRegex      = regexp.MustCompile(`(\w+)="([^"]*)"`)
+	anyShortcodeRegex      = regexp.MustCompile(`\{\{[<%].*?[>%]\}\}`)
+	baseURLRegex           = regexp.MustCompile(`(?mi)^\s*baseURL\s*[:=]\s*["']?([^"'\s]+)`)
+)
+
+func shortcodeArgs(sc string) map[string]string {
+	args := make(map[string]string)
+	for _, m := range shortcodeArgRegex.FindAllStringSubmatch(sc, -1) {
+		args[m[1]] = m[2]
+	}
+	return args
+}
+
+// expandShortcodesMarkdown rewrites figure and gif-video shortcodes as plain
+// markdown and drops any other shortcode, for platforms that don't run Hugo
+func expandShortcodesMarkdown(markdown string) string {
+	markdown = figureShortcodeRegex.ReplaceAllStringFunc(markdown, func(sc string) string {
+		args := shortcodeArgs(sc)
+		out := fmt.Sprintf("![%s](%s)", args["alt"], args["src"])
+		if caption := strings.TrimSpace(args["caption"] + " " + args["attr"]); caption != "" {
+			out += "\n*" + caption + "*"
+		}
+		return out
+	})
+	markdown = gifVideoShortcodeRegex.ReplaceAllStringFunc(markdown, func(sc string) string {
+		args := shortcodeArgs(sc)
+		if poster := args["poster"]; poster != "" {
+			return fmt.Sprintf("[![Watch the demo](%s)](%s)", poster, args["src"])
+		}
+		return fmt.Sprintf("[Watch the demo](%s)", args["src"])
+	})
+	return anyShortcodeRegex.ReplaceAllString(markdown, "")
+}
+
+// absolutizeSiteURLs points /images/site/ references at the published site
+func absolutizeSiteURLs(text, baseURL string) string {
+	if baseURL == "" {
+		return text
+	}
+	return strings.ReplaceAll(text, "/images/site/", strings.TrimRight(baseURL, "/")+"/images/site/")
+}
+
+// siteBaseURL reads baseURL from the Hugo site's config file
+func siteBaseURL(basePath string) string {
+	return strings.TrimRight(hugoConfigValue(basePath, baseURLRegex), "/")
+}
+
+// postBody returns the markdown after the front matter
+func postBody(content string) string {
+	fm := frontMatterBlock(content)
+	if fm == "" {
+		return content
+	}
+	return strings.TrimLeft(content[len(fm)+len("\n---"):], "\n")
+}
+
+// markdownToHTML renders post markdown the way Hugo's default goldmark setup
+// would, after replacing figure shortcodes with plain HTML and dropping others
+func markdownToHTML(markdown string) (string, error) {
+	markdown = figureShortcodeRegex.ReplaceAllStringFunc(markdown, func(sc string) string {
+		args := shortcodeArgs(sc)
+		caption := strings.TrimSpace(args["caption"] + " " + args["attr"])
+		return fmt.Sprintf(`<figure><img src="%s" alt="%s"><figcaption>%s</figcaption></figure>`,
+			html.EscapeString(args["src"]), html.EscapeString(args["alt"]), html.EscapeString(caption))
+	})
+	markdown = anyShortcodeRegex.ReplaceAllString(markdown, "")
+
+	// Unsafe so the figure HTML above and raw HTML in posts survive
+	md := goldmark.New(
+		goldmark.WithExtensions(extension.GFM, extension.Footnote),
+		goldmark.WithRendererOptions(gmhtml.WithUnsafe()),
+	)
+	var buf bytes.Buffer
+	if err := md.Convert([]byte(markdown), &buf); err != nil {
+		return "", fmt.Errorf("failed to render markdown: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// inlineSiteImages replaces /images/site/ references with data URIs so the
+// HTML is viewable without the site
+func inlineSiteImages(htmlContent, basePath string) string {
+	return siteImageRefRegex.ReplaceAllStringFunc(htmlContent, func(ref string) string {
+		name := strings.TrimPrefix(ref, "/images/site/")
+		if uri := imageDataURI(filepath.Join(siteImageDir(basePath), name)); uri != "" {
+			return uri
+		}
+		return ref
+	})
+}
+
+func imageDataURI(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	mimeType := mime.TypeByExtension(filepath.Ext(path))
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
+
+// renderStandalonePost renders a post to a single self-contained HTML page with
+// its hero and site images inlined
+func renderStandalonePost(content, basePath string) (string, error) {
+	body, err := markdownToHTML(postBody(content))
+	if err != nil {
+		return "", err
+	}
+
+	title := frontMatterString(content, "title")
+	description := frontMatterString(content, "description")
+
+	var heroHTML string
+	if hero := frontMatterString(content, "hero"); hero != "" {
+		heroHTML = fmt.Sprintf(`<img class="hero" src="%s" alt="">`, html.EscapeString(hero))
+		if caption := frontMatterString(content, "hero_caption"); caption != "" {
+			heroHTML = fmt.Sprintf(`<figure>%s<figcaption>%s</figcaption></figure>`, heroHTML, html.EscapeString(caption))
+		}
+	}
+
+	page := fmt.Sprintf(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>%s</title>
+<style>
+body { max-width: 46rem; margin: 2rem auto; padding: 0 1rem; font: 18px/1.6 system-ui, sans-serif; color: #222; }
+img, video { max-width: 100%%; height: auto; }
+img.hero { width: 100%%; border-radius: 6px; }
+pre { background: #f5f5f5; padding: 1rem; overflow-x: auto; }
+code { font-size: 0.9em; }
+figcaption { font-size: 0.85em; color: #666; }
+.draft { background: #fff3cd; padding: 0.5rem 1rem; border-radius: 4px; font-size: 0.9em; }
+</style>
+</head>
+<body>
+<p class="draft">Draft preview</p>
+<h1>%s</h1>
+<p><em>%s</em></p>
+%s
+%s
+</body>
+</html>
+`, html.EscapeString(title), html.EscapeString(title), html.EscapeString(description), heroHTML, body)
+
+	if basePath != "" {
+		page = inlineSiteImages(page, basePath)
+	}
+	return page, nil
+}
@@ -13,6 +13,24 @@ var rootCmd = &cobra.Command{
 	Long: `megafone is a CLI tool that generates technical blog posts from GitHub
 repositories and publishes them across multiple platforms. Uses AI to analyze
 repos and create content that matches your writing style.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		journalCommand = cmd.CommandPath()
+		enableHTTPTracing()
+		if err := applyUserConfig(cmd); err != nil {
+			return err
+		}
+		if err := applyEnvFlags(cmd); err != nil {
+			return err
+		}
+		if err := loadConfig(); err != nil {
+			return err
+		}
+		enableHostPolicy()
+		if err := resolvePostDate(); err != nil {
+			return err
+		}
+		return checkSlugStrategy()
+	},
 }
 
 func Execute() {
@@ -22,6 +40,24 @@ func Execute() {
 	}
 }
 
+// getOpenAIKey returns the API key from --openai-key or OPENAI_API_KEY
+func getOpenAIKey(cmd *cobra.Command) (string, error) {
+	apiKey, _ := cmd.Flags().GetString("openai-key")
+	if apiKey == "" {
+		apiKey = os.Getenv("OPENAI_API_KEY")
+	}
+	if apiKey == "" {
+		return "", classify(ErrAuth, fmt.Errorf("OpenAI API key required (use --openai-key or OPENAI_API_KEY env var)"))
+	}
+	return apiKey, nil
+}
+
 func init() {
+	rootCmd.PersistentFlags().BoolVar(&noJournal, "no-journal", false, "Don't record changes in the site's .megafone/journal.jsonl")
 	rootCmd.PersistentFlags().StringP("openai-key", "k", "", "OpenAI API key (or set OPENAI_API_KEY env var)")
+	rootCmd.PersistentFlags().StringVar(&siteRepo, "site-repo", "", "Git URL of the Hugo site to clone into the cache when --site-source is not set")
+	rootCmd.PersistentFlags().StringVar(&siteCacheDir, "site-cache", "", "Directory for --site-repo clones (default: user cache dir)")
+	rootCmd.PersistentFlags().StringVar(&siteDeployKey, "deploy-key", "", "SSH private key for cloning --site-repo (default: SSH agent)")
+	rootCmd.PersistentFlags().BoolVar(&siteSparse, "sparse", os.Getenv("CI") == "true", "Shallow, sparse --site-repo clone of only --sparse-paths (default on in CI)")
+	rootCmd.PersistentFlags().StringSliceVar(&siteSparsePaths, "sparse-paths", []string{"content", "assets", "data", "layouts", ".megafone"}, "Directories to check out with --sparse")
 }
@@ -0,0 +1,273 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/sashabaranov/go-openai"
+	"github.com/spf13/cobra"
+)
+
+var (
+	roundupRepos []string
+	roundupAngle string
+)
+
+// roundupSourceChars is the README budget shared by all the repositories in
+// a roundup; each gets an equal part, but never less than roundupMinReadme
+const (
+	roundupSourceChars = 60000
+	roundupMinReadme   = 4000
+)
+
+// roundupRepo is one project in a roundup: its metadata and README
+type roundupRepo struct {
+	Repo          *github.Repository
+	LatestRelease string
+	Readme        string
+}
+
+var roundupCmd = &cobra.Command{
+	Use:   "roundup",
+	Short: "Write one post comparing several GitHub projects",
+	Long: `Fetches the metadata and README of several GitHub repositories and writes one
+roundup post about them, like "5 self-hosted dashboards worth trying": what each
+project is for, how they compare, and which to pick for what. --angle sets the
+framing; without it, the model finds what the projects have in common.
+Set GITHUB_TOKEN for private repositories and higher rate limits.
+
+Examples:
+  megafone roundup -t gethomepage/homepage,bastienwirtz/homer,pawelmalak/flame -s ~/hugo
+  megafone roundup -t owner/a,owner/b --angle "self-hosted dashboards worth trying" --dry-run`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runRoundup(cmd); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(roundupCmd)
+
+	roundupCmd.Flags().StringSliceVarP(&roundupRepos, "topic", "t", nil, "GitHub repositories to cover, comma-separated (owner/repo or URL) (required)")
+	roundupCmd.Flags().StringVar(&roundupAngle, "angle", "", "What the roundup is about, e.g. \"self-hosted dashboards worth trying\"")
+	roundupCmd.Flags().StringVarP(&tags, "tags", "T", "", "Comma-separated tags (AI will suggest if not provided)")
+	roundupCmd.Flags().StringVarP(&promptFile, "prompt", "p", "prompts/github-roundup.txt", "Path to prompt template file")
+	roundupCmd.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "Print generated content without writing files")
+	roundupCmd.Flags().StringVarP(&model, "model", "m", "gpt-4o", "OpenAI model to use")
+	roundupCmd.Flags().StringVarP(&siteSource, "site-source", "s", "", "Path to local Hugo site repository")
+
+	roundupCmd.MarkFlagRequired("topic")
+}
+
+func runRoundup(cmd *cobra.Command) error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	ctx := context.Background()
+
+	type ownerRepo struct{ owner, repo string }
+	var targets []ownerRepo
+	seen := map[string]bool{}
+	for _, r := range roundupRepos {
+		owner, repo, err := parseGitHubURL(strings.TrimSpace(r))
+		if err != nil {
+			return fmt.Errorf("invalid repository %q: %w", r, err)
+		}
+		key := strings.ToLower(owner + "/" + repo)
+		if !seen[key] {
+			seen[key] = true
+			targets = append(targets, ownerRepo{owner, repo})
+		}
+	}
+	if len(targets) < 2 {
+		return fmt.Errorf("a roundup needs at least two repositories (got %d)", len(targets))
+	}
+
+	basePath, err := resolveSitePath()
+	if err != nil {
+		return err
+	}
+	apiKey, err := getOpenAIKey(cmd)
+	if err != nil {
+		return err
+	}
+
+	readmeChars := max(roundupSourceChars/len(targets), roundupMinReadme)
+	ghClient := newGitHubClient()
+	var repos []roundupRepo
+	var sources []string
+	for _, t := range targets {
+		logInfo("📦 Fetching repository: %s/%s", t.owner, t.repo)
+		r, err := fetchRoundupRepo(ctx, ghClient, t.owner, t.repo, readmeChars)
+		if err != nil {
+			return classify(ErrSource, err)
+		}
+		repos = append(repos, *r)
+		sources = append(sources, r.Repo.GetHTMLURL())
+	}
+
+	promptTemplate, err := loadPrompt(promptFile, "roundup", strings.Join(sources, ", "))
+	if err != nil {
+		return err
+	}
+
+	client := openai.NewClient(apiKey)
+	logInfo("🤖 Writing the roundup of %d projects with %s...", len(repos), model)
+	content, err := generateRoundup(ctx, client, promptTemplate, repos, roundupAngle, tags, model)
+	if err != nil {
+		return classify(ErrGeneration, err)
+	}
+
+	filename, err := generateFilename(ctx, client, content, model)
+	if err != nil || filename == "" {
+		filename = sanitizeFilename(firstNonEmpty(roundupAngle, "roundup-"+postDateString()))
+	}
+
+	content = applyDatePolicy(content, postDateString(), basePath)
+	content = applyHighlights(ctx, client, content)
+	if content, err = applySummarySections(ctx, client, content, "roundup"); err != nil {
+		return err
+	}
+	content = applyAccessibility(content)
+	if content, err = applyDisclosure(content, basePath); err != nil {
+		return err
+	}
+	if content, err = applyFrontMatterDefaults(content, "roundup", sources[0]); err != nil {
+		return err
+	}
+	if content, err = applySecretScan(content); err != nil {
+		return err
+	}
+	if content, filename, err = applySlugStrategy(content, filename, ""); err != nil {
+		return err
+	}
+
+	if dryRun {
+		logInfo("Dry run mode - not writing files")
+		fmt.Println("\n" + strings.Repeat("=", 80))
+		fmt.Println("DRY RUN - Generated Content:")
+		fmt.Println(strings.Repeat("=", 80))
+		fmt.Println(content)
+		fmt.Println(strings.Repeat("=", 80))
+		return nil
+	}
+
+	postPath, err := writeNewPost(basePath, filepath.Join(postsDir(basePath), filename+".md"), content)
+	if err != nil {
+		return fmt.Errorf("failed to write post: %w", err)
+	}
+	logSuccess("✅ Roundup created: %s", postPath)
+
+	var tagList []string
+	if tags != "" {
+		tagList = strings.Split(tags, ",")
+	}
+	logGeneration(strings.Join(sources, ","), postPath, "", tagList)
+	return runHooks("post_generate", appConfig.Hooks.PostGenerate, hookRun{Post: postPath, Source: sources[0], Site: basePath})
+}
+
+// fetchRoundupRepo reads a repository's metadata, latest release, and README,
+// cut to readmeChars
+func fetchRoundupRepo(ctx context.Context, client *github.Client, owner, repo string, readmeChars int) (*roundupRepo, error) {
+	repoData, _, err := client.Repositories.Get(ctx, owner, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s/%s: %w", owner, repo, err)
+	}
+	r := &roundupRepo{Repo: repoData}
+	if release, _, err := client.Repositories.GetLatestRelease(ctx, owner, repo); err == nil {
+		r.LatestRelease = fmt.Sprintf("%s (%s)", firstNonEmpty(release.GetName(), release.GetTagName()), release.GetPublishedAt().Format("2006-01-02"))
+	}
+	if readme, _, err := client.Repositories.GetReadme(ctx, owner, repo, nil); err == nil {
+		if content, err := readme.GetContent(); err == nil {
+			r.Readme = content
+			if len(content) > readmeChars {
+				r.Readme = content[:readmeChars] + "\n[README truncated]"
+			}
+		}
+	}
+	if r.Readme == "" {
+		logInfo("⚠️  %s/%s has no README; writing from its metadata only", owner, repo)
+	}
+	return r, nil
+}
+
+// promptContext renders a project's facts and README for the roundup prompt
+func (r roundupRepo) promptContext(n int) string {
+	repo := r.Repo
+	var b strings.Builder
+	fmt.Fprintf(&b, "### Project %d: %s\n", n, repo.GetFullName())
+	fmt.Fprintf(&b, "URL: %s\nDescription: %s\nLanguage: %s\nStars: %d\nForks: %d\nOpen issues: %d\n",
+		repo.GetHTMLURL(), repo.GetDescription(), repo.GetLanguage(),
+		repo.GetStargazersCount(), repo.GetForksCount(), repo.GetOpenIssuesCount())
+	if license := repo.GetLicense().GetSPDXID(); license != "" {
+		fmt.Fprintf(&b, "License: %s\n", license)
+	}
+	if len(repo.Topics) > 0 {
+		fmt.Fprintf(&b, "Topics: %s\n", strings.Join(repo.Topics, ", "))
+	}
+	if homepage := repo.GetHomepage(); homepage != "" {
+		fmt.Fprintf(&b, "Homepage: %s\n", homepage)
+	}
+	fmt.Fprintf(&b, "Created: %s\nLast push: %s\n", repo.GetCreatedAt().Format("2006-01-02"), repo.GetPushedAt().Format("2006-01-02"))
+	if r.LatestRelease != "" {
+		fmt.Fprintf(&b, "Latest release: %s\n", r.LatestRelease)
+	}
+	if repo.GetArchived() {
+		b.WriteString("Archived: yes (no longer maintained)\n")
+	}
+	fmt.Fprintf(&b, "\nREADME:\n%s\n", firstNonEmpty(r.Readme, "(none)"))
+	return b.String()
+}
+
+func generateRoundup(ctx context.Context, client *openai.Client, promptTemplate string, repos []roundupRepo, angle, userTags, model string) (string, error) {
+	var projects strings.Builder
+	for i, r := range repos {
+		projects.WriteString(r.promptContext(i + 1))
+		projects.WriteString("\n")
+	}
+
+	angleInfo := "Find what these projects have in common and frame the roundup around it."
+	if angle != "" {
+		angleInfo = fmt.Sprintf("The roundup is about: %s", angle)
+	}
+
+	userPrompt := fmt.Sprintf(`%s
+
+Please write a roundup post covering these %d GitHub projects, in one post. %s
+
+%s
+User-provided tags: %s (suggest appropriate tags if none provided)
+
+IMPORTANT: Cover every project above, and only these projects.
+IMPORTANT: Your response must be ONLY valid markdown. Do not include any explanatory text before or after the markdown.
+IMPORTANT: Use date: %s in the front matter.
+
+Generate a complete Hugo markdown post following the style guide above.
+`, promptTemplate, len(repos), angleInfo, projects.String(), userTags, postDateString())
+
+	resp, err := createChatCompletion(withStreaming(ctx), client, openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: "You are a technical blog writer who compares software projects fairly and specifically. Follow the style guide precisely. Output ONLY the markdown content, no explanations.",
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: userPrompt,
+			},
+		},
+		Temperature: 0.7,
+	})
+	if err != nil {
+		return "", fmt.Errorf("OpenAI API error: %w", err)
+	}
+	if len(resp.Choices) == 0 || resp.Choices[0].Message.Content == "" {
+		return "", fmt.Errorf("no response from OpenAI")
+	}
+	return resp.Choices[0].Message.Content, nil
+}
@@ -0,0 +1,262 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// routeRule sends matching URLs to a prompt template and, optionally, a
+// source type. A rule matches when any of its domains, substrings, or its
+// regex does.
+type routeRule struct {
+	Name     string   `yaml:"name,omitempty"`
+	Domains  []string `yaml:"domains,omitempty"`  // the host or any subdomain of it
+	Contains []string `yaml:"contains,omitempty"` // substrings of the lowercased URL
+	Regex    string   `yaml:"regex,omitempty"`    // matched against the whole URL
+	Type     string   `yaml:"type,omitempty"`     // github, website, podcast, research, chat, or feed
+	Template string   `yaml:"template"`
+}
+
+// defaultRoutes apply when megafone.yaml has no routes block. A routes block
+// replaces them; 'megafone routes list' prints them to start from.
+var defaultRoutes = []routeRule{
+	{Name: "github", Domains: []string{"github.com"}, Type: "github", Template: "prompts/github-project.txt"},
+	{Name: "hacker-news", Regex: `^https?://(www\.)?news\.ycombinator\.com/item\?id=\d+`, Template: "prompts/hn-discussion.txt"},
+	{
+		Name: "podcast",
+		Domains: []string{
+			"anchor.fm", "podbean.com", "buzzsprout.com", "transistor.fm",
+			"simplecast.com", "libsyn.com", "megaphone.fm", "captivate.fm",
+		},
+		Type:     "podcast",
+		Template: "prompts/podcast-episode.txt",
+	},
+	{Name: "reddit", Regex: `^https?://([a-z0-9-]+\.)?reddit\.com/r/[^/]+/comments/`, Template: "prompts/reddit-discussion.txt"},
+	{
+		Name: "news",
+		Contains: []string{
+			"cnn.com", "bbc.com", "reuters.com", "apnews.com",
+			"nytimes.com", "wsj.com", "bloomberg.com", "techcrunch.com",
+			"theverge.com", "arstechnica.com", "wired.com",
+			"/news/", "/article/", "/story/",
+		},
+		Template: "prompts/news-article.txt",
+	},
+	{
+		Name: "technical",
+		Contains: []string{
+			"stackoverflow.com", "dev.to", "medium.com",
+			"docs.", "documentation", "/tutorial/", "/guide/",
+			"/blog/", "hashnode.com", "substack.com",
+		},
+		Template: "prompts/technical-article.txt",
+	},
+}
+
+// Templates for topics no route matches, by source type
+var fallbackTemplates = map[string]string{
+	"github":   "prompts/github-project.txt",
+	"research": "prompts/research-topic.txt",
+	"chat":     "prompts/research-topic.txt",
+	"website":  "prompts/news-article.txt",
+	"podcast":  "prompts/podcast-episode.txt",
+}
+
+var routesCmd = &cobra.Command{
+	Use:   "routes",
+	Short: "Show and test how topics are routed to prompt templates",
+	Long: `URLs passed to 'megafone generate' are matched against the routes in
+megafone.yaml, in order, to pick the prompt template and, optionally, the
+source type. The first matching route wins. Without a routes block, the
+built-in routes apply; a routes block replaces them.
+
+Research topics and chat exports aren't routed: they always use
+prompts/research-topic.txt unless --prompt says otherwise.`,
+}
+
+var routesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Print the routes in effect, as YAML for megafone.yaml",
+	Long: `Prints the routes generate uses, in the format of the routes block in
+megafone.yaml. To customize routing, copy the output into the config and edit
+it.
+
+Examples:
+  megafone routes list
+  megafone routes list >> megafone.yaml`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runRoutesList(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+var routesTestCmd = &cobra.Command{
+	Use:   "test <url>",
+	Short: "Show which route, template, and source type a topic would get",
+	Long: `Shows how 'megafone generate' would treat a topic: the detected source type,
+the route that matches it, the prompt template, and the sources block of
+megafone.yaml whose settings apply.
+
+Examples:
+  megafone routes test https://arstechnica.com/gadgets/2025/01/some-story/
+  megafone routes test "https://news.ycombinator.com/item?id=40000000"`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runRoutesTest(args[0]); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(routesCmd)
+	routesCmd.AddCommand(routesListCmd)
+	routesCmd.AddCommand(routesTestCmd)
+}
+
+// activeRoutes returns the configured routes, or the built-in ones
+func activeRoutes() []routeRule {
+	if appConfig.Routes != nil {
+		return appConfig.Routes
+	}
+	return defaultRoutes
+}
+
+// checkRoutes rejects a routes block with a bad regex, type, or missing template
+func checkRoutes(routes []routeRule) error {
+	for i, r := range routes {
+		label := firstNonEmpty(r.Name, fmt.Sprintf("#%d", i+1))
+		if r.Template == "" {
+			return fmt.Errorf("route %s has no template", label)
+		}
+		if len(r.Domains) == 0 && len(r.Contains) == 0 && r.Regex == "" {
+			return fmt.Errorf("route %s has nothing to match (set domains, contains, or regex)", label)
+		}
+		if r.Regex != "" {
+			if _, err := regexp.Compile(r.Regex); err != nil {
+				return fmt.Errorf("route %s has an invalid regex: %w", label, err)
+			}
+		}
+		switch r.Type {
+		case "", "github", "website", "podcast", "research", "chat", "feed":
+		default:
+			return fmt.Errorf("route %s has an invalid type %q (use github, website, podcast, research, chat, or feed)", label, r.Type)
+		}
+	}
+	return nil
+}
+
+// matches reports whether a route applies to a URL
+func (r routeRule) matches(input string) bool {
+	lower := strings.ToLower(input)
+	if len(r.Domains) > 0 {
+		raw := lower
+		if !strings.Contains(raw, "://") {
+			raw = "https://" + raw
+		}
+		if u, err := url.Parse(raw); err == nil {
+			host := strings.TrimPrefix(u.Hostname(), "www.")
+			for _, d := range r.Domains {
+				d = strings.ToLower(d)
+				if host == d || strings.HasSuffix(host, "."+d) {
+					return true
+				}
+			}
+		}
+	}
+	for _, s := range r.Contains {
+		if strings.Contains(lower, strings.ToLower(s)) {
+			return true
+		}
+	}
+	if r.Regex != "" {
+		if re, err := regexp.Compile(r.Regex); err == nil && re.MatchString(input) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchRoute returns the first route for a URL topic, or nil. Research topics
+// and chat exports are never routed.
+func matchRoute(input string) *routeRule {
+	if detected := detectContentType(input); detected != "github" && detected != "website" {
+		return nil
+	}
+	routes := activeRoutes()
+	for i := range routes {
+		if routes[i].matches(input) {
+			return &routes[i]
+		}
+	}
+	return nil
+}
+
+func runRoutesList() error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	if err := checkRoutes(activeRoutes()); err != nil {
+		return err
+	}
+	if appConfig.Routes == nil {
+		fmt.Println("# Built-in routes (no routes block in " + configPath + ")")
+	}
+	enc := yaml.NewEncoder(os.Stdout)
+	enc.SetIndent(2)
+	if err := enc.Encode(map[string][]routeRule{"routes": activeRoutes()}); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func runRoutesTest(input string) error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	if err := checkRoutes(activeRoutes()); err != nil {
+		return err
+	}
+	detected := detectContentType(input)
+	contentType, settingsType := detected, detected
+	route := matchRoute(input)
+	if route != nil && route.Type != "" {
+		settingsType = route.Type
+		if route.Type != "feed" {
+			contentType = route.Type
+		}
+	}
+
+	fmt.Printf("Topic:     %s\n", input)
+	fmt.Printf("Detected:  %s\n", detected)
+	switch {
+	case route != nil:
+		fmt.Printf("Route:     %s\n", firstNonEmpty(route.Name, "(unnamed)"))
+	case contentType == "github" || contentType == "website":
+		fmt.Printf("Route:     none (the %s default)\n", contentType)
+	default:
+		fmt.Printf("Route:     none (%s topics aren't routed)\n", contentType)
+	}
+	if route != nil && route.Type != "" {
+		fmt.Printf("Type:      %s (set by the route)\n", route.Type)
+	}
+	fmt.Printf("Template:  %s\n", selectPromptTemplate(contentType, route))
+
+	if _, ok := appConfig.Sources[settingsType]; !ok && settingsType == "feed" {
+		settingsType = "website"
+	}
+	if _, ok := appConfig.Sources[settingsType]; ok {
+		fmt.Printf("Settings:  sources.%s\n", settingsType)
+	} else {
+		fmt.Printf("Settings:  none (no sources.%s block)\n", settingsType)
+	}
+	return nil
+}
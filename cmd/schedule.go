@@ -0,0 +1,667 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+	"unicode"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	dripAnnouncement = "announcement"
+	dripQuote        = "quote"
+	dripStat         = "stat"
+	dripCustom       = "custom"
+
+	// scheduleMaxAttempts is how many runs retry a failed platform before giving up on it
+	scheduleMaxAttempts = 5
+)
+
+var (
+	dripCampaign  string
+	dripStart     string
+	dripPlatforms []string
+	scheduleAll   bool
+)
+
+// defaultDripCampaign is used when megafone.yaml has no campaigns.default
+var defaultDripCampaign = campaignConfig{
+	Platforms: []string{platformMastodon, platformBluesky},
+	Time:      "09:00",
+	Steps: []campaignStep{
+		{Day: 0, Kind: dripAnnouncement},
+		{Day: 3, Kind: dripQuote},
+		{Day: 7, Kind: dripStat},
+	},
+}
+
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Queue social posts for later and send the ones that are due",
+}
+
+var scheduleDripCmd = &cobra.Command{
+	Use:   "drip <post>",
+	Short: "Queue a post's social drip campaign",
+	Long: `Composes the social posts of a drip campaign for a post and adds them to the
+schedule queue (.megafone/state/schedule.json). The default campaign is:
+
+  day 0  announcement  the post's social_blurb (or description) and link
+  day 3  quote         one of the post's highlights
+  day 7  stat          a sentence from the post with a number in it
+
+Day 0 is the post's date, or today if that has passed. Define other campaigns
+under campaigns: in megafone.yaml. Queuing a campaign again replaces its
+unsent posts. 'megafone schedule run' sends the posts once they're due.
+
+Examples:
+  megafone schedule drip content/posts/en/my-post.md
+  megafone schedule drip content/posts/en/my-post.md --campaign launch --platforms mastodon`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runScheduleDrip(args[0]); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+var scheduleListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Show queued social posts",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runScheduleList(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+var scheduleRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Send the queued social posts that are due",
+	Long: `Posts every queued item whose time has come to each of its platforms.
+A platform that fails is retried on the next run, up to 5 times. Run it from
+cron or an automation, e.g. every 15 minutes.
+
+Examples:
+  megafone schedule run -s ~/hugo
+  megafone schedule run -s ~/hugo --dry-run`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runScheduleRun(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(scheduleCmd)
+	scheduleCmd.AddCommand(scheduleDripCmd)
+	scheduleCmd.AddCommand(scheduleListCmd)
+	scheduleCmd.AddCommand(scheduleRunCmd)
+
+	for _, c := range []*cobra.Command{scheduleDripCmd, scheduleListCmd, scheduleRunCmd} {
+		c.Flags().StringVarP(&siteSource, "site-source", "s", "", "Path to local Hugo site repository (default: found from the post path)")
+	}
+	scheduleDripCmd.Flags().StringVar(&dripCampaign, "campaign", "default", "Campaign from megafone.yaml to queue")
+	scheduleDripCmd.Flags().StringVar(&dripStart, "start", "", "Day 0 of the campaign, 2006-01-02 (default: the post's date, or today if that has passed)")
+	scheduleDripCmd.Flags().StringSliceVar(&dripPlatforms, "platforms", nil, "Post to these platforms instead of the campaign's (mastodon, bluesky, x)")
+	scheduleDripCmd.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "Show the campaign without queuing it")
+	scheduleListCmd.Flags().BoolVar(&scheduleAll, "all", false, "Include sent and abandoned posts")
+	scheduleRunCmd.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "Show what is due without posting")
+}
+
+// campaignConfig is one entry under campaigns: in megafone.yaml
+type campaignConfig struct {
+	Platforms []string       `yaml:"platforms"`
+	Time      string         `yaml:"time"` // time of day posts go out, 15:04
+	Steps     []campaignStep `yaml:"steps"`
+}
+
+// campaignStep is one social post of a campaign. Text, if set, is a template
+// with .Title, .URL, .Blurb, .Quote, and .Stat and makes the step custom.
+type campaignStep struct {
+	Day       int      `yaml:"day"`
+	Kind      string   `yaml:"kind"` // announcement, quote, stat, or custom
+	Text      string   `yaml:"text"`
+	Platforms []string `yaml:"platforms"`
+}
+
+// scheduleQueue is the site's queue of social posts waiting to go out
+type scheduleQueue struct {
+	Items []scheduledPost `json:"items"`
+}
+
+// scheduledPost is one queued social post. Sent and Errors are keyed by
+// platform, so a post that failed on one platform is only retried there.
+type scheduledPost struct {
+	ID        string            `json:"id"`
+	Post      string            `json:"post"` // relative to the site root
+	Campaign  string            `json:"campaign"`
+	Kind      string            `json:"kind"`
+	Text      string            `json:"text"`
+	Link      string            `json:"link"`
+	Due       time.Time         `json:"due"`
+	Platforms []string          `json:"platforms"`
+	Sent      map[string]string `json:"sent,omitempty"` // platform → URL of the social post
+	Errors    map[string]string `json:"errors,omitempty"`
+	Attempts  map[string]int    `json:"attempts,omitempty"`
+}
+
+// pending returns the platforms the post still has to go out on
+func (p scheduledPost) pending() []string {
+	var out []string
+	for _, platform := range p.Platforms {
+		if _, sent := p.Sent[platform]; !sent && p.Attempts[platform] < scheduleMaxAttempts {
+			out = append(out, platform)
+		}
+	}
+	return out
+}
+
+func (p scheduledPost) status() string {
+	switch {
+	case len(p.Sent) == len(p.Platforms):
+		return "sent"
+	case len(p.pending()) == 0:
+		return "failed"
+	case len(p.Errors) > 0:
+		return "retrying"
+	default:
+		return "queued"
+	}
+}
+
+func scheduleQueuePath(basePath string) string {
+	return workspacePath(basePath, "state", "schedule.json")
+}
+
+func loadScheduleQueue(basePath string) (*scheduleQueue, error) {
+	var q scheduleQueue
+	data, err := os.ReadFile(scheduleQueuePath(basePath))
+	if os.IsNotExist(err) {
+		return &q, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &q); err != nil {
+		return nil, fmt.Errorf("invalid schedule queue %s: %w", scheduleQueuePath(basePath), err)
+	}
+	return &q, nil
+}
+
+func saveScheduleQueue(basePath string, q *scheduleQueue) error {
+	path := scheduleQueuePath(basePath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(q, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// scheduleSitePath is the site a schedule command works on: --site-source,
+// or the site containing the post or the working directory
+func scheduleSitePath(postPath string) (string, error) {
+	if siteSource != "" {
+		return siteSource, nil
+	}
+	dir := "."
+	if postPath != "" {
+		dir = filepath.Dir(postPath)
+	}
+	if root := findSiteRoot(dir); root != "" {
+		return root, nil
+	}
+	return "", fmt.Errorf("no Hugo site found; pass --site-source")
+}
+
+func runScheduleDrip(postPath string) error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	basePath, err := scheduleSitePath(postPath)
+	if err != nil {
+		return err
+	}
+
+	campaign, ok := appConfig.Campaigns[dripCampaign]
+	if !ok {
+		if dripCampaign != "default" {
+			return fmt.Errorf("no campaign %q under campaigns in %s", dripCampaign, configPath)
+		}
+		campaign = defaultDripCampaign
+	}
+	if len(campaign.Steps) == 0 {
+		return fmt.Errorf("campaign %q has no steps", dripCampaign)
+	}
+
+	data, err := os.ReadFile(postPath)
+	if err != nil {
+		return fmt.Errorf("failed to read post: %w", err)
+	}
+	content := string(data)
+
+	baseURL := siteBaseURL(basePath)
+	if baseURL == "" {
+		return fmt.Errorf("the Hugo config has no baseURL, so the post's link can't be built")
+	}
+	link := baseURL + postURL(basePath, postPath, content)
+
+	start, err := dripStartTime(content, campaign.Time)
+	if err != nil {
+		return err
+	}
+
+	absBase, _ := filepath.Abs(basePath)
+	absPost, _ := filepath.Abs(postPath)
+	rel := filepath.ToSlash(mustRel(absBase, absPost))
+	items, err := composeDrip(content, link, rel, campaign, start)
+	if err != nil {
+		return err
+	}
+	if e := experimentForPost(basePath, postPath); e != nil && e.Mode == experimentPlatforms {
+		if items, err = composeExperimentDrip(content, link, rel, campaign, start, e); err != nil {
+			return err
+		}
+	}
+	if len(items) == 0 {
+		return fmt.Errorf("nothing to queue: every step of campaign %q was skipped", dripCampaign)
+	}
+
+	printScheduledPosts(items)
+	if dryRun {
+		logInfo("Dry run mode - not queuing")
+		return nil
+	}
+
+	q, err := loadScheduleQueue(basePath)
+	if err != nil {
+		return err
+	}
+	// Queuing again replaces the campaign's unsent posts; sent ones stay as a record
+	kept := q.Items[:0]
+	for _, item := range q.Items {
+		if item.Post == rel && item.Campaign == dripCampaign && len(item.Sent) == 0 {
+			continue
+		}
+		kept = append(kept, item)
+	}
+	q.Items = append(kept, items...)
+	sort.SliceStable(q.Items, func(i, j int) bool { return q.Items[i].Due.Before(q.Items[j].Due) })
+	if err := saveScheduleQueue(basePath, q); err != nil {
+		return fmt.Errorf("failed to save schedule queue: %w", err)
+	}
+	logSuccess("✅ Queued %d social posts for %s", len(items), rel)
+	return nil
+}
+
+// dripStartTime is day 0 of a campaign at its time of day, in the post date timezone
+func dripStartTime(content, timeOfDay string) (time.Time, error) {
+	loc := postDate.time.Location()
+	now := time.Now().In(loc)
+
+	day := now
+	if dripStart != "" {
+		t, err := time.ParseInLocation("2006-01-02", dripStart, loc)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid --start value %q (use 2006-01-02)", dripStart)
+		}
+		day = t
+	} else if t, ok := parsePostDate(frontMatterString(content, "date")); ok && t.After(now) {
+		day = t.In(loc)
+	}
+
+	clock, err := time.Parse("15:04", firstNonEmpty(timeOfDay, "09:00"))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid campaign time %q (use 15:04)", timeOfDay)
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), clock.Hour(), clock.Minute(), 0, 0, loc), nil
+}
+
+// dripData is what a custom step's text template can reference
+type dripData struct {
+	Title, URL, Blurb, Quote, Stat string
+}
+
+// composeDrip writes the text of each campaign step. Steps whose material the
+// post lacks (a quote step without highlights) are skipped with a warning.
+func composeDrip(content, link, rel string, campaign campaignConfig, start time.Time) ([]scheduledPost, error) {
+	data := dripData{
+		Title: frontMatterString(content, "title"),
+		URL:   link,
+		Blurb: firstNonEmpty(frontMatterString(content, "social_blurb"), frontMatterString(content, "description"), frontMatterString(content, "summary"), frontMatterString(content, "title")),
+	}
+	highlights := frontMatterList(content, "highlights")
+	stats := statSentences(content, highlights)
+
+	var items []scheduledPost
+	quotes, statsUsed := 0, 0
+	for i, step := range campaign.Steps {
+		platforms := dripPlatforms
+		if len(platforms) == 0 {
+			platforms = firstNonEmptyList(step.Platforms, campaign.Platforms, defaultDripCampaign.Platforms)
+		}
+		if err := checkSocialPlatforms(platforms); err != nil {
+			return nil, fmt.Errorf("campaign %q step %d: %w", dripCampaign, i+1, err)
+		}
+
+		kind := step.Kind
+		if kind == "" && step.Text != "" {
+			kind = dripCustom
+		}
+		step.Kind = kind
+		data.Quote, data.Stat = "", ""
+		if quotes < len(highlights) {
+			data.Quote = highlights[quotes]
+		}
+		if statsUsed < len(stats) {
+			data.Stat = stats[statsUsed]
+		}
+
+		text := ""
+		switch {
+		case step.Text != "":
+			tmpl, err := template.New("step").Parse(step.Text)
+			if err != nil {
+				return nil, fmt.Errorf("campaign %q step %d: %w", dripCampaign, i+1, err)
+			}
+			var b bytes.Buffer
+			if err := tmpl.Execute(&b, data); err != nil {
+				return nil, fmt.Errorf("campaign %q step %d: %w", dripCampaign, i+1, err)
+			}
+			text = strings.TrimSpace(b.String())
+		case kind == dripAnnouncement:
+			text = data.Blurb
+		case kind == dripQuote:
+			if data.Quote == "" {
+				logInfo("⚠️  Skipping day %d quote: the post has no highlights left (run summarize --fields highlights)", step.Day)
+				continue
+			}
+			text = "“" + data.Quote + "” — " + data.Title
+		case kind == dripStat:
+			if data.Stat == "" {
+				logInfo("⚠️  Skipping day %d stat: no sentence in the post has a number in it", step.Day)
+				continue
+			}
+			text = data.Stat
+		default:
+			return nil, fmt.Errorf("campaign %q step %d: unknown kind %q (use announcement, quote, stat, or custom with text)", dripCampaign, i+1, kind)
+		}
+		switch kind {
+		case dripQuote:
+			quotes++
+		case dripStat:
+			statsUsed++
+		}
+
+		// A custom text that already carries the link doesn't get it twice
+		itemLink := link
+		if strings.Contains(text, link) {
+			itemLink = ""
+		}
+		items = append(items, scheduledPost{
+			ID:        fmt.Sprintf("%s-%s-%d", strings.TrimSuffix(filepath.Base(rel), ".md"), dripCampaign, i+1),
+			Post:      rel,
+			Campaign:  dripCampaign,
+			Kind:      kind,
+			Text:      text,
+			Link:      itemLink,
+			Due:       start.AddDate(0, 0, step.Day),
+			Platforms: platforms,
+		})
+	}
+	return items, nil
+}
+
+// composeExperimentDrip composes a drip campaign for a post in a platforms
+// experiment: each platform gets the title and description of its variant.
+// The announcement uses the description rather than social_blurb, so it
+// differs between the variants. Posts whose text doesn't change with the
+// variant still go out once.
+func composeExperimentDrip(content, link, rel string, campaign campaignConfig, start time.Time, e *experiment) ([]scheduledPost, error) {
+	content = removeFrontMatterField(content, "social_blurb")
+	items, err := composeDrip(withVariant(content, e.Variants[0]), link, rel, campaign, start)
+	if err != nil {
+		return nil, err
+	}
+	variantItems, err := composeDrip(withVariant(content, e.Variants[1]), link, rel, campaign, start)
+	if err != nil {
+		return nil, err
+	}
+	variantText := make(map[string]string)
+	for _, item := range variantItems {
+		variantText[item.ID] = item.Text
+	}
+
+	var out []scheduledPost
+	for _, item := range items {
+		text, ok := variantText[item.ID]
+		if !ok || text == item.Text {
+			out = append(out, item)
+			continue
+		}
+		a, b := item, item
+		a.Platforms, b.Platforms = nil, nil
+		for _, p := range item.Platforms {
+			if e.Platforms[p] == "b" {
+				b.Platforms = append(b.Platforms, p)
+			} else {
+				a.Platforms = append(a.Platforms, p)
+			}
+		}
+		b.ID, b.Text = item.ID+"-b", text
+		if len(a.Platforms) > 0 {
+			out = append(out, a)
+		}
+		if len(b.Platforms) > 0 {
+			out = append(out, b)
+		}
+	}
+	logInfo("🧪 Experiment %s: variant b goes to %s", e.ID, strings.Join(variantPlatforms(e, "b"), ","))
+	return out, nil
+}
+
+// variantPlatforms lists the platforms showing a variant, sorted
+func variantPlatforms(e *experiment, variant string) []string {
+	var out []string
+	for p, v := range e.Platforms {
+		if v == variant {
+			out = append(out, p)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// statSentences returns sentences from the post that carry a number, the
+// post's highlights first, for stat steps
+func statSentences(content string, highlights []string) []string {
+	hasDigit := func(s string) bool { return strings.IndexFunc(s, unicode.IsDigit) >= 0 }
+	var out []string
+	seen := make(map[string]bool)
+	for _, h := range highlights {
+		if hasDigit(h) && !seen[h] {
+			out = append(out, h)
+			seen[h] = true
+		}
+	}
+
+	text, err := exportPlain(content)
+	if err != nil {
+		return out
+	}
+	// Skip the title and its underline
+	if i := strings.Index(text, "\n\n"); i != -1 {
+		text = text[i+2:]
+	}
+	for _, para := range strings.Split(text, "\n\n") {
+		if strings.HasPrefix(para, "- ") {
+			continue
+		}
+		for _, sentence := range splitSentences(strings.Join(strings.Fields(para), " ")) {
+			if n := len([]rune(sentence)); n < 40 || n > 220 || !hasDigit(sentence) || seen[sentence] {
+				continue
+			}
+			out = append(out, sentence)
+			seen[sentence] = true
+		}
+	}
+	return out
+}
+
+// splitSentences breaks a paragraph after ., !, or ? followed by a space and a capital
+func splitSentences(para string) []string {
+	var out []string
+	runes := []rune(para)
+	start := 0
+	for i := 0; i+2 < len(runes); i++ {
+		if (runes[i] == '.' || runes[i] == '!' || runes[i] == '?') && runes[i+1] == ' ' && unicode.IsUpper(runes[i+2]) {
+			out = append(out, strings.TrimSpace(string(runes[start:i+1])))
+			start = i + 2
+		}
+	}
+	if rest := strings.TrimSpace(string(runes[start:])); rest != "" {
+		out = append(out, rest)
+	}
+	return out
+}
+
+func firstNonEmptyList(lists ...[]string) []string {
+	for _, l := range lists {
+		if len(l) > 0 {
+			return l
+		}
+	}
+	return nil
+}
+
+func printScheduledPosts(items []scheduledPost) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DUE\tSTATUS\tPLATFORMS\tPOST\tTEXT")
+	for _, item := range items {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", item.Due.Format("2006-01-02 15:04"), item.status(),
+			strings.Join(item.Platforms, ","), item.Post, trimToLength(item.Text, 60))
+	}
+	w.Flush()
+}
+
+func runScheduleList() error {
+	basePath, err := scheduleSitePath("")
+	if err != nil {
+		return err
+	}
+	q, err := loadScheduleQueue(basePath)
+	if err != nil {
+		return err
+	}
+	var items []scheduledPost
+	for _, item := range q.Items {
+		if scheduleAll || len(item.pending()) > 0 {
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		fmt.Println("Nothing queued")
+		return nil
+	}
+	printScheduledPosts(items)
+	return nil
+}
+
+func runScheduleRun() error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	basePath, err := scheduleSitePath("")
+	if err != nil {
+		return err
+	}
+	q, err := loadScheduleQueue(basePath)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	now := time.Now()
+	baseURL := siteBaseURL(basePath)
+	sent, failed := 0, 0
+	// Missing or rejected credentials don't use up attempts; the posts wait
+	// until they're fixed
+	authFailed := make(map[string]bool)
+	for i := range q.Items {
+		item := &q.Items[i]
+		if item.Due.After(now) {
+			continue
+		}
+		for _, platform := range item.pending() {
+			if authFailed[platform] {
+				failed++
+				continue
+			}
+			slug := strings.TrimSuffix(path.Base(item.Post), ".md")
+			link := trackLink(ctx, item.Link, platform, item.Campaign, slug)
+			text := trackTextLinks(ctx, item.Text, baseURL, platform, item.Campaign, slug)
+			text = fitSocialPost(text, link, socialPostLimits[platform])
+			if dryRun {
+				fmt.Printf("%s → %s:\n  %s\n\n", item.ID, platform, text)
+				continue
+			}
+
+			url, err := postToSocial(ctx, platform, text)
+			if item.Attempts == nil {
+				item.Attempts = make(map[string]int)
+			}
+			if item.Errors == nil {
+				item.Errors = make(map[string]string)
+			}
+			if item.Sent == nil {
+				item.Sent = make(map[string]string)
+			}
+			if err != nil {
+				failed++
+				item.Errors[platform] = err.Error()
+				if errors.Is(err, ErrAuth) {
+					authFailed[platform] = true
+					logError("Failed to post to %s: %v", platform, err)
+					continue
+				}
+				item.Attempts[platform]++
+				logError("Failed to post %s to %s (attempt %d of %d): %v", item.ID, platform, item.Attempts[platform], scheduleMaxAttempts, err)
+				continue
+			}
+			item.Attempts[platform]++
+			sent++
+			delete(item.Errors, platform)
+			item.Sent[platform] = url
+			logSuccess("📣 Posted %s to %s: %s", item.ID, platform, url)
+		}
+		if !dryRun {
+			if err := saveScheduleQueue(basePath, q); err != nil {
+				return fmt.Errorf("failed to save schedule queue: %w", err)
+			}
+		}
+	}
+
+	if dryRun {
+		return nil
+	}
+	logInfo("Sent %d social posts, %d failed", sent, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d social posts failed; they are retried on the next run", failed)
+	}
+	return nil
+}
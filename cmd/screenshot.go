@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/jpeg"
+	_ "image/png"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Screenshot heroes are captured at this viewport, which is already 16:9
+const (
+	screenshotWidth  = 1600
+	screenshotHeight = 900
+)
+
+// chromeBinaries are the names headless Chrome goes by on PATH
+var chromeBinaries = []string{"google-chrome", "google-chrome-stable", "chromium", "chromium-browser", "chrome", "msedge"}
+
+// findChrome locates a Chrome or Chromium binary: CHROME_PATH, then PATH,
+// then the standard macOS install
+func findChrome() (string, error) {
+	if p := os.Getenv("CHROME_PATH"); p != "" {
+		return p, nil
+	}
+	for _, name := range chromeBinaries {
+		if p, err := exec.LookPath(name); err == nil {
+			return p, nil
+		}
+	}
+	if runtime.GOOS == "darwin" {
+		p := "/Applications/Google Chrome.app/Contents/MacOS/Google Chrome"
+		if _, err := os.Stat(p); err == nil {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("Chrome or Chromium not found (install it or set CHROME_PATH)")
+}
+
+// captureScreenshotHero renders a page in headless Chrome and saves the top of
+// it, cropped to 16:9, as the post's hero
+func captureScreenshotHero(ctx context.Context, pageURL, baseName, basePath string) (string, error) {
+	// Chrome doesn't go through the HTTP client, so check the page itself
+	if err := checkFetchHost(pageURL); err != nil {
+		return "", err
+	}
+	chrome, err := findChrome()
+	if err != nil {
+		return "", err
+	}
+	tmpDir, err := os.MkdirTemp("", "megafone-screenshot")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	shot := filepath.Join(tmpDir, "shot.png")
+	args := []string{
+		"--headless=new", "--disable-gpu", "--hide-scrollbars", "--mute-audio",
+		"--no-first-run", "--no-default-browser-check",
+		// A fresh profile, so a running browser's lock doesn't get in the way
+		"--user-data-dir=" + filepath.Join(tmpDir, "profile"),
+		fmt.Sprintf("--window-size=%d,%d", screenshotWidth, screenshotHeight),
+		// Give scripts time to render the page before the capture
+		"--virtual-time-budget=10000",
+		"--screenshot=" + shot,
+	}
+	if runtime.GOOS == "linux" && os.Geteuid() == 0 {
+		// Chrome refuses to sandbox as root, which is common in containers
+		args = append(args, "--no-sandbox")
+	}
+	args = append(args, pageURL)
+
+	ctx, cancel := context.WithTimeout(ctx, 90*time.Second)
+	defer cancel()
+	logInfo("📸 Capturing a screenshot of %s...", pageURL)
+	if out, err := exec.CommandContext(ctx, chrome, args...).CombinedOutput(); err != nil {
+		return "", fmt.Errorf("headless Chrome failed: %v: %s", err, lastLine(string(out)))
+	}
+
+	data, err := os.ReadFile(shot)
+	if err != nil {
+		return "", fmt.Errorf("Chrome didn't write a screenshot: %w", err)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode screenshot: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, cropTo16x9(img), &jpeg.Options{Quality: 88}); err != nil {
+		return "", fmt.Errorf("failed to encode screenshot: %w", err)
+	}
+
+	imageName, err := writeSiteImage(basePath, baseName+".jpg", buf.Bytes())
+	if err != nil {
+		return "", err
+	}
+	logSuccess("📸 Saved screenshot hero: %s", imageName)
+	return imageName, nil
+}
+
+// cropTo16x9 keeps the top of a taller image (what a visitor sees first) or
+// the middle of a wider one
+func cropTo16x9(img image.Image) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	crop := b
+	if w*9 < h*16 {
+		crop.Max.Y = b.Min.Y + w*9/16
+	} else if w*9 > h*16 {
+		cw := h * 16 / 9
+		crop.Min.X = b.Min.X + (w-cw)/2
+		crop.Max.X = crop.Min.X + cw
+	}
+	if crop == b {
+		return img
+	}
+	if sub, ok := img.(interface {
+		SubImage(image.Rectangle) image.Image
+	}); ok {
+		return sub.SubImage(crop)
+	}
+	return img
+}
+
+// screenshotAttribution credits the captured site in the hero's caption
+func screenshotAttribution(meta pageMetadata, pageURL string) imageAttribution {
+	site := meta.SiteName
+	if site == "" {
+		if u, err := url.Parse(pageURL); err == nil {
+			site = strings.TrimPrefix(u.Hostname(), "www.")
+		}
+	}
+	return imageAttribution{
+		Caption:   "Screenshot of " + firstNonEmpty(meta.Title, site),
+		Credit:    site,
+		CreditURL: pageURL,
+	}
+}
+
+// lastLine returns the last non-empty line of command output
+func lastLine(out string) string {
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
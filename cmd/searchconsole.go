@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// searchConsoleLag is how long Search Console takes to report a day's data in full
+const searchConsoleLag = 3 * 24 * time.Hour
+
+// searchConsoleStats is a page's search performance over a date range
+type searchConsoleStats struct {
+	Clicks      int     `json:"clicks"`
+	Impressions int     `json:"impressions"`
+	CTR         float64 `json:"ctr"`
+}
+
+// searchConsoleToken signs in with the service account key in
+// GOOGLE_APPLICATION_CREDENTIALS, which needs read access to the property
+func searchConsoleToken(ctx context.Context) (string, error) {
+	keyPath := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	if keyPath == "" {
+		return "", classify(ErrAuth, fmt.Errorf("GOOGLE_APPLICATION_CREDENTIALS (a service account key file) is required to read Search Console"))
+	}
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		return "", classify(ErrAuth, fmt.Errorf("failed to read service account key: %w", err))
+	}
+	var key struct {
+		ClientEmail string `json:"client_email"`
+		PrivateKey  string `json:"private_key"`
+		TokenURI    string `json:"token_uri"`
+	}
+	if err := json.Unmarshal(data, &key); err != nil || key.ClientEmail == "" || key.PrivateKey == "" {
+		return "", classify(ErrAuth, fmt.Errorf("%s is not a service account key", keyPath))
+	}
+	block, _ := pem.Decode([]byte(key.PrivateKey))
+	if block == nil {
+		return "", classify(ErrAuth, fmt.Errorf("invalid private key in %s", keyPath))
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return "", classify(ErrAuth, fmt.Errorf("invalid private key in %s: %w", keyPath, err))
+	}
+	privateKey, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return "", classify(ErrAuth, fmt.Errorf("the private key in %s is not an RSA key", keyPath))
+	}
+
+	// A JWT signed by the service account is exchanged for an access token
+	tokenURI := firstNonEmpty(key.TokenURI, "https://oauth2.googleapis.com/token")
+	now := time.Now()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iss":   key.ClientEmail,
+		"scope": "https://www.googleapis.com/auth/webmasters.readonly",
+		"aud":   tokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, privateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {unsigned + "." + base64.RawURLEncoding.EncodeToString(signature)},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := socialClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("Google sign-in failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", classify(ErrAuth, fmt.Errorf("Google rejected the service account: %s: %s", resp.Status, strings.TrimSpace(string(body))))
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil || token.AccessToken == "" {
+		return "", fmt.Errorf("invalid Google token response")
+	}
+	return token.AccessToken, nil
+}
+
+// searchConsoleQuery returns the clicks, impressions, and CTR of one page in
+// a Search Console property (https://example.com/ or sc-domain:example.com)
+// from start to end, inclusive
+func searchConsoleQuery(ctx context.Context, token, property, page string, start, end time.Time) (searchConsoleStats, error) {
+	body := map[string]interface{}{
+		"startDate":  start.Format("2006-01-02"),
+		"endDate":    end.Format("2006-01-02"),
+		"dimensions": []string{"page"},
+		"dimensionFilterGroups": []map[string]interface{}{{
+			"filters": []map[string]string{{"dimension": "page", "operator": "equals", "expression": page}},
+		}},
+	}
+	var reply struct {
+		Rows []struct {
+			Clicks      float64 `json:"clicks"`
+			Impressions float64 `json:"impressions"`
+			CTR         float64 `json:"ctr"`
+		} `json:"rows"`
+	}
+	endpoint := "https://searchconsole.googleapis.com/webmasters/v3/sites/" + url.PathEscape(property) + "/searchAnalytics/query"
+	if err := postSocialJSON(ctx, "Search Console", endpoint, map[string]string{"Authorization": "Bearer " + token}, body, &reply); err != nil {
+		return searchConsoleStats{}, err
+	}
+	// A page without impressions has no row
+	if len(reply.Rows) == 0 {
+		return searchConsoleStats{}, nil
+	}
+	row := reply.Rows[0]
+	return searchConsoleStats{Clicks: int(row.Clicks), Impressions: int(row.Impressions), CTR: row.CTR}, nil
+}
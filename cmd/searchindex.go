@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	searchFormatFuse     = "fuse"
+	searchFormatLunr     = "lunr"
+	searchFormatPagefind = "pagefind"
+)
+
+var (
+	searchIndexFormat   string
+	searchIndexOut      string
+	searchIndexMaxWords int
+	searchIndexDrafts   bool
+)
+
+// searchFieldWeights ranks the indexed fields. The summaries megafone writes
+// (summarize, cached key points) rank above the body because they say what a
+// post is about in a few words.
+var searchFieldWeights = []struct {
+	Name   string
+	Weight float64
+}{
+	{"title", 10},
+	{"summary", 5},
+	{"key_points", 4},
+	{"tags", 3},
+	{"description", 3},
+	{"content", 1},
+}
+
+// searchDocument is one post in a Fuse or Lunr index
+type searchDocument struct {
+	URL         string   `json:"url"`
+	Title       string   `json:"title"`
+	Summary     string   `json:"summary,omitempty"`
+	KeyPoints   string   `json:"key_points,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Date        string   `json:"date,omitempty"`
+	Content     string   `json:"content"`
+}
+
+// pagefindRecord is the shape Pagefind's addCustomRecord takes
+type pagefindRecord struct {
+	URL      string              `json:"url"`
+	Content  string              `json:"content"`
+	Language string              `json:"language"`
+	Meta     map[string]string   `json:"meta"`
+	Filters  map[string][]string `json:"filters,omitempty"`
+}
+
+var searchIndexCmd = &cobra.Command{
+	Use:   "search-index",
+	Short: "Build a client-side search index of the site's posts into static/",
+	Long: `Writes a JSON search index of every published post for client-side search.
+Each entry carries the post's URL, title, tags, date, and plain-text body, plus
+the summaries megafone has produced: the summary and description front matter
+from "megafone summarize" and its cached key points. Those are weighted above
+the body so searches match what a post is about.
+
+Formats:
+  fuse      {"keys": [...weights], "documents": [...]}  new Fuse(idx.documents, {keys: idx.keys})
+  lunr      {"ref": "url", "fields": [...boosts], "documents": [...]}
+  pagefind  an array of records for Pagefind's index.addCustomRecord()
+
+Examples:
+  megafone search-index -s ~/hugo
+  megafone search-index -s ~/hugo --format lunr --max-words 0
+  megafone search-index -s ~/hugo --format pagefind --out static/search/records.json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runSearchIndex(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(searchIndexCmd)
+
+	searchIndexCmd.Flags().StringVarP(&siteSource, "site-source", "s", "", "Path to local Hugo site repository")
+	searchIndexCmd.Flags().StringVarP(&searchIndexFormat, "format", "f", searchFormatFuse, "Index format: fuse, lunr, or pagefind")
+	searchIndexCmd.Flags().StringVarP(&searchIndexOut, "out", "o", "", "Output file, relative to the site (default: static/search/<format>.json)")
+	searchIndexCmd.Flags().IntVar(&searchIndexMaxWords, "max-words", 300, "Words of each post's body to index (0 for all)")
+	searchIndexCmd.Flags().BoolVar(&searchIndexDrafts, "drafts", false, "Include drafts")
+}
+
+func runSearchIndex() error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	switch searchIndexFormat {
+	case searchFormatFuse, searchFormatLunr, searchFormatPagefind:
+	default:
+		return fmt.Errorf("invalid --format %q (use fuse, lunr, or pagefind)", searchIndexFormat)
+	}
+
+	basePath, err := resolveSitePath()
+	if err != nil {
+		return err
+	}
+	posts, err := loadSitePosts(basePath)
+	if err != nil {
+		return fmt.Errorf("failed to read posts: %w", err)
+	}
+
+	docs := []searchDocument{}
+	var languages []string
+	summarized := 0
+	for _, post := range posts {
+		if post.Draft && !searchIndexDrafts {
+			continue
+		}
+		doc, err := searchDocumentFor(basePath, post)
+		if err != nil {
+			return err
+		}
+		if doc.Summary != "" || doc.KeyPoints != "" {
+			summarized++
+		}
+		docs = append(docs, doc)
+		languages = append(languages, postLanguage(post.Path))
+	}
+
+	var index interface{}
+	switch searchIndexFormat {
+	case searchFormatFuse:
+		keys := make([]map[string]interface{}, len(searchFieldWeights))
+		for i, f := range searchFieldWeights {
+			keys[i] = map[string]interface{}{"name": f.Name, "weight": f.Weight}
+		}
+		index = map[string]interface{}{"keys": keys, "documents": docs}
+	case searchFormatLunr:
+		fields := make([]map[string]interface{}, len(searchFieldWeights))
+		for i, f := range searchFieldWeights {
+			fields[i] = map[string]interface{}{"name": f.Name, "boost": f.Weight}
+		}
+		index = map[string]interface{}{"ref": "url", "fields": fields, "documents": docs}
+	case searchFormatPagefind:
+		records := make([]pagefindRecord, len(docs))
+		for i, doc := range docs {
+			records[i] = pagefindRecordFor(doc, languages[i])
+		}
+		index = records
+	}
+
+	data, err := json.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("failed to encode search index: %w", err)
+	}
+	out := filepath.Join(basePath, firstNonEmpty(searchIndexOut, filepath.Join("static", "search", searchIndexFormat+".json")))
+	if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+		return fmt.Errorf("failed to create search index directory: %w", err)
+	}
+	if err := os.WriteFile(out, data, 0644); err != nil {
+		return fmt.Errorf("failed to write search index: %w", err)
+	}
+	recordSiteChange("modified", out, "", false)
+
+	logSuccess("✅ Indexed %d posts (%d with summaries, %.1f KB): %s", len(docs), summarized, float64(len(data))/1024, out)
+	if summarized < len(docs) {
+		logInfo("💡 Run \"megafone summarize\" on the remaining posts to give search a summary to match")
+	}
+	return nil
+}
+
+// searchDocumentFor builds a post's index entry
+func searchDocumentFor(basePath string, post sitePost) (searchDocument, error) {
+	data, err := os.ReadFile(post.Path)
+	if err != nil {
+		return searchDocument{}, fmt.Errorf("failed to read %s: %w", post.Path, err)
+	}
+	content := string(data)
+
+	rendered, err := markdownToHTML(expandShortcodesMarkdown(post.Body))
+	if err != nil {
+		return searchDocument{}, fmt.Errorf("failed to render %s: %w", post.Path, err)
+	}
+	text := strings.Join(strings.Fields(htmlToText(rendered)), " ")
+	if searchIndexMaxWords > 0 {
+		text = truncateWords(searchIndexMaxWords, text)
+	}
+
+	doc := searchDocument{
+		URL:         postURL(basePath, post.Path, content),
+		Title:       post.Title,
+		Summary:     frontMatterString(content, "summary"),
+		Description: post.Description,
+		Tags:        post.Tags,
+		Date:        post.Date,
+		Content:     text,
+	}
+	slug := strings.TrimSuffix(filepath.Base(post.Path), filepath.Ext(post.Path))
+	if pc := loadPostContext(basePath, slug, postContextHash(content)); pc != nil {
+		doc.KeyPoints = pc.KeyPoints
+	}
+	return doc, nil
+}
+
+// pagefindRecordFor puts the summaries ahead of the body, since Pagefind ranks
+// custom records by their content alone
+func pagefindRecordFor(doc searchDocument, language string) pagefindRecord {
+	var parts []string
+	for _, s := range []string{doc.Title, doc.Summary, doc.KeyPoints, doc.Description, doc.Content} {
+		if s != "" {
+			parts = append(parts, s)
+		}
+	}
+	record := pagefindRecord{
+		URL:      doc.URL,
+		Content:  strings.Join(parts, "\n\n"),
+		Language: language,
+		Meta:     map[string]string{"title": doc.Title},
+	}
+	if excerpt := firstNonEmpty(doc.Summary, doc.Description); excerpt != "" {
+		record.Meta["summary"] = excerpt
+	}
+	if doc.Date != "" {
+		record.Meta["date"] = doc.Date
+	}
+	if len(doc.Tags) > 0 {
+		record.Filters = map[string][]string{"tags": doc.Tags}
+	}
+	return record
+}
+
+// postLanguage reads the language from a content/posts/<lang>/ path, defaulting to en
+func postLanguage(postPath string) string {
+	parts := strings.Split(filepath.ToSlash(postPath), "/")
+	for i := 0; i+2 < len(parts); i++ {
+		if parts[i] == "content" && parts[i+1] == "posts" && len(parts[i+2]) == 2 {
+			return parts[i+2]
+		}
+	}
+	return "en"
+}
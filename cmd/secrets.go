@@ -0,0 +1,337 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Secret scan modes
+const (
+	secretScanBlock = "block" // refuse to write a post with findings
+	secretScanMask  = "mask"  // replace findings with [REDACTED]
+	secretScanWarn  = "warn"  // report findings and write the post as is
+	secretScanOff   = "off"
+)
+
+const secretMask = "[REDACTED]"
+
+var secretScanMode string
+
+// secretScanConfig is the secret_scan block of megafone.yaml
+type secretScanConfig struct {
+	Mode            string   `yaml:"mode"`             // block, mask, warn, or off (default mask)
+	InternalDomains []string `yaml:"internal_domains"` // hostnames under these are reported as internal
+	Allow           []string `yaml:"allow"`            // regexes; findings that match one are ignored
+	Skip            []string `yaml:"skip"`             // rule ids to turn off, such as private-ip
+	Enforce         []string `yaml:"enforce"`          // report-only rule ids to mask or block like credentials
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&secretScanMode, "secret-scan", "", "What to do with API keys and tokens found in generated posts: block, mask, warn, or off (default: secret_scan.mode in the config, or mask). Private IPs and internal hostnames are only reported unless secret_scan.enforce lists them")
+}
+
+// secretRule is a gitleaks-style detection rule. When Group is set, only that
+// submatch is the secret; the rest of the match is context.
+type secretRule struct {
+	ID          string
+	Description string
+	Regex       *regexp.Regexp
+	Group       int
+	MinEntropy  float64 // Shannon entropy, in bits per character, below which a match is ignored
+	Stopwords   bool    // ignore matches that look like placeholders
+	// ReportOnly rules match things posts often show on purpose, like a
+	// homelab's LAN addresses; their findings are logged but never masked or
+	// blocked unless listed in secret_scan.enforce
+	ReportOnly bool
+}
+
+// secretRules are checked in order; when two findings overlap, the one that
+// starts first wins, then the longer one.
+var secretRules = []secretRule{
+	{ID: "private-key", Description: "private key", Regex: regexp.MustCompile(`-----BEGIN[A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----[\s\S]*?(-----END[A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----|$)`)},
+	{ID: "aws-access-key", Description: "AWS access key ID", Regex: regexp.MustCompile(`\b((?:A3T[A-Z0-9]|AKIA|ASIA|ABIA|ACCA)[A-Z2-7]{16})\b`), Group: 1, Stopwords: true},
+	{ID: "github-token", Description: "GitHub token", Regex: regexp.MustCompile(`\b(gh[pousr]_[0-9A-Za-z]{36}|github_pat_[0-9A-Za-z_]{82})\b`), Group: 1},
+	{ID: "anthropic-key", Description: "Anthropic API key", Regex: regexp.MustCompile(`\b(sk-ant-(?:api|admin)\d{2}-[A-Za-z0-9_-]{80,})`), Group: 1},
+	{ID: "openai-key", Description: "OpenAI API key", Regex: regexp.MustCompile(`\b(sk-(?:proj-|svcacct-|admin-)?[A-Za-z0-9_-]{32,})`), Group: 1, MinEntropy: 3.5},
+	{ID: "stripe-key", Description: "Stripe key", Regex: regexp.MustCompile(`\b((?:sk|rk)_(?:live|test)_[0-9A-Za-z]{24,})\b`), Group: 1},
+	{ID: "google-api-key", Description: "Google API key", Regex: regexp.MustCompile(`\b(AIza[0-9A-Za-z_-]{35})`), Group: 1},
+	{ID: "slack-token", Description: "Slack token", Regex: regexp.MustCompile(`\b(xox[abposr]-[0-9A-Za-z-]{10,})`), Group: 1},
+	{ID: "slack-webhook", Description: "Slack webhook URL", Regex: regexp.MustCompile(`https://hooks\.slack\.com/(?:services|workflows)/[A-Za-z0-9+/]{20,}`)},
+	{ID: "jwt", Description: "JSON web token", Regex: regexp.MustCompile(`\b(eyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,})`), Group: 1},
+	{ID: "url-credentials", Description: "password in a URL", Regex: regexp.MustCompile(`\b[a-z][a-z0-9+.-]*://[^/\s:@]+:([^/\s:@]{3,})@`), Group: 1, Stopwords: true},
+	{
+		ID:          "generic-secret",
+		Description: "assigned secret",
+		Regex:       regexp.MustCompile(`(?i)\b(?:api[_-]?key|api[_-]?secret|client[_-]?secret|secret[_-]?key|access[_-]?key|auth[_-]?token|access[_-]?token|token|secret|passw(?:or)?d)\b["']?\s*[:=]\s*["']?([A-Za-z0-9_\-/+=.~]{16,})`),
+		Group:       1,
+		MinEntropy:  3.5,
+		Stopwords:   true,
+	},
+	{ID: "private-ip", Description: "private IP address", Regex: regexp.MustCompile(`\b(10\.\d{1,3}\.\d{1,3}\.\d{1,3}|172\.(?:1[6-9]|2\d|3[01])\.\d{1,3}\.\d{1,3}|192\.168\.\d{1,3}\.\d{1,3})\b`), Group: 1, ReportOnly: true},
+	{ID: "internal-host", Description: "internal hostname", Regex: regexp.MustCompile(`(?i)(?:^|[^a-z0-9.-])((?:[a-z0-9-]+\.)+(?:internal|corp|intranet|lan|home\.arpa))\b`), Group: 1, ReportOnly: true},
+}
+
+// secretStopwords mark documentation placeholders rather than real secrets
+var secretStopwords = []string{"example", "xxxx", "placeholder", "your", "dummy", "changeme", "redacted", "sample", "<", "$", "{{", "***", "..."}
+
+// secretFinding is one match, by byte offset into the scanned text
+type secretFinding struct {
+	Rule        string
+	Description string
+	Line        int
+	Start, End  int
+	Match       string
+	ReportOnly  bool
+}
+
+// preview shows enough of a finding to find it without repeating the secret.
+// Addresses and hostnames are shown in full.
+func (f secretFinding) preview() string {
+	switch f.Rule {
+	case "private-ip", "internal-host", "internal-domain":
+		return f.Match
+	}
+	if len(f.Match) <= 8 {
+		return f.Match
+	}
+	return f.Match[:4] + strings.Repeat("*", min(len(f.Match)-4, 12))
+}
+
+// effectiveSecretScanMode is --secret-scan, secret_scan.mode, or mask
+func effectiveSecretScanMode() (string, error) {
+	mode := firstNonEmpty(secretScanMode, appConfig.SecretScan.Mode, secretScanMask)
+	switch mode {
+	case secretScanBlock, secretScanMask, secretScanWarn, secretScanOff:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid --secret-scan value %q (use block, mask, warn, or off)", mode)
+	}
+}
+
+// activeSecretRules returns the built-in rules minus secret_scan.skip, with
+// the rules in secret_scan.enforce no longer report-only, plus a rule for
+// secret_scan.internal_domains
+func activeSecretRules() []secretRule {
+	cfg := appConfig.SecretScan
+	listed := func(ids []string, id string) bool {
+		for _, listedID := range ids {
+			if strings.EqualFold(strings.TrimSpace(listedID), id) {
+				return true
+			}
+		}
+		return false
+	}
+	var rules []secretRule
+	for _, r := range secretRules {
+		if listed(cfg.Skip, r.ID) {
+			continue
+		}
+		if listed(cfg.Enforce, r.ID) {
+			r.ReportOnly = false
+		}
+		rules = append(rules, r)
+	}
+
+	var domains []string
+	for _, d := range cfg.InternalDomains {
+		if d = strings.Trim(strings.TrimPrefix(strings.TrimSpace(d), "*."), "."); d != "" {
+			domains = append(domains, regexp.QuoteMeta(d))
+		}
+	}
+	if len(domains) > 0 {
+		rules = append(rules, secretRule{
+			ID:          "internal-domain",
+			Description: "host in secret_scan.internal_domains",
+			Regex:       regexp.MustCompile(`(?i)(?:^|[^a-z0-9.-])((?:[a-z0-9-]+\.)*(?:` + strings.Join(domains, "|") + `))\b`),
+			Group:       1,
+		})
+	}
+	return rules
+}
+
+// scanSecrets returns the findings in text, in order, without overlaps
+func scanSecrets(text string) ([]secretFinding, error) {
+	var allow []*regexp.Regexp
+	for _, pattern := range appConfig.SecretScan.Allow {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid secret_scan.allow pattern %q in %s: %w", pattern, configPath, err)
+		}
+		allow = append(allow, re)
+	}
+
+	var findings []secretFinding
+	for _, rule := range activeSecretRules() {
+		for _, m := range rule.Regex.FindAllStringSubmatchIndex(text, -1) {
+			start, end := m[0], m[1]
+			if rule.Group > 0 {
+				start, end = m[2*rule.Group], m[2*rule.Group+1]
+			}
+			if start < 0 {
+				continue
+			}
+			match := text[start:end]
+			if rule.MinEntropy > 0 && shannonEntropy(match) < rule.MinEntropy {
+				continue
+			}
+			if rule.Stopwords && hasSecretStopword(match) {
+				continue
+			}
+			if secretAllowed(text[m[0]:m[1]], allow) {
+				continue
+			}
+			findings = append(findings, secretFinding{
+				Rule:        rule.ID,
+				Description: rule.Description,
+				Line:        strings.Count(text[:start], "\n") + 1,
+				Start:       start,
+				End:         end,
+				Match:       match,
+				ReportOnly:  rule.ReportOnly,
+			})
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Start != findings[j].Start {
+			return findings[i].Start < findings[j].Start
+		}
+		return findings[i].End > findings[j].End
+	})
+	var kept []secretFinding
+	lastEnd := -1
+	for _, f := range findings {
+		if f.Start >= lastEnd {
+			kept = append(kept, f)
+			lastEnd = f.End
+		}
+	}
+	return kept, nil
+}
+
+func secretAllowed(match string, allow []*regexp.Regexp) bool {
+	for _, re := range allow {
+		if re.MatchString(match) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasSecretStopword(s string) bool {
+	lower := strings.ToLower(s)
+	for _, w := range secretStopwords {
+		if strings.Contains(lower, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// shannonEntropy is the average information per character of s, in bits
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := map[rune]int{}
+	for _, r := range s {
+		counts[r]++
+	}
+	n := float64(len([]rune(s)))
+	var h float64
+	for _, c := range counts {
+		p := float64(c) / n
+		h -= p * math.Log2(p)
+	}
+	return h
+}
+
+// maskSecrets replaces each finding with [REDACTED]
+func maskSecrets(text string, findings []secretFinding) string {
+	var b strings.Builder
+	last := 0
+	for _, f := range findings {
+		b.WriteString(text[last:f.Start])
+		b.WriteString(secretMask)
+		last = f.End
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// logSecretFindings logs each finding and returns the ones to act on: a
+// report-only finding is logged once per match and otherwise left alone
+func logSecretFindings(what string, findings []secretFinding) []secretFinding {
+	var enforced []secretFinding
+	var reported []string
+	seen := make(map[string]bool)
+	for _, f := range findings {
+		if !f.ReportOnly {
+			logError("Possible %s in %s, line %d: %s (%s)", f.Description, what, f.Line, f.preview(), f.Rule)
+			enforced = append(enforced, f)
+			continue
+		}
+		if !seen[f.Match] {
+			seen[f.Match] = true
+			reported = append(reported, f.preview())
+		}
+	}
+	if len(reported) > 0 {
+		logInfo("⚠️  Left %d private address(es) or internal hostname(s) in %s as is: %s (list private-ip or internal-host in secret_scan.enforce to mask them)", len(reported), what, strings.Join(reported, ", "))
+	}
+	return enforced
+}
+
+// maskSourceSecrets masks secrets in source material before it goes into a
+// prompt, so the model can't echo them into the post. Only warn and off leave
+// the source as is; blocking is decided on the finished post.
+func maskSourceSecrets(source, what string) (string, error) {
+	mode, err := effectiveSecretScanMode()
+	if err != nil || mode == secretScanOff {
+		return source, err
+	}
+	findings, err := scanSecrets(source)
+	if err != nil || len(findings) == 0 {
+		return source, err
+	}
+	if findings = logSecretFindings(what, findings); len(findings) == 0 {
+		return source, nil
+	}
+	if mode == secretScanWarn {
+		logInfo("⚠️  %d possible secrets in the %s were sent to the model (--secret-scan warn)", len(findings), what)
+		return source, nil
+	}
+	logInfo("🔒 Masked %d possible secrets in the %s before sending it to the model", len(findings), what)
+	return maskSecrets(source, findings), nil
+}
+
+// applySecretScan checks a generated post for API keys, tokens, internal
+// hostnames, and private IPs before it is written, and blocks, masks, or
+// reports them per --secret-scan. Report-only findings are just logged.
+func applySecretScan(content string) (string, error) {
+	mode, err := effectiveSecretScanMode()
+	if err != nil || mode == secretScanOff {
+		return content, err
+	}
+	findings, err := scanSecrets(content)
+	if err != nil || len(findings) == 0 {
+		return content, err
+	}
+	if findings = logSecretFindings("the post", findings); len(findings) == 0 {
+		return content, nil
+	}
+	switch mode {
+	case secretScanBlock:
+		return content, classify(ErrSecrets, fmt.Errorf("%d possible secrets in the generated post (first: %s on line %d); nothing was written. Remove them from the source, add an allow pattern to secret_scan in %s, or use --secret-scan mask",
+			len(findings), findings[0].Description, findings[0].Line, configPath))
+	case secretScanWarn:
+		logInfo("⚠️  Writing the post with %d possible secrets (--secret-scan warn)", len(findings))
+		return content, nil
+	default:
+		logInfo("🔒 Masked %d possible secrets in the post", len(findings))
+		return maskSecrets(content, findings), nil
+	}
+}
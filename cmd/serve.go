@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveAddr            string
+	serveAutomationsFile string
+	serveShutdownTimeout time.Duration
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run megafone as a long-lived service with health endpoints",
+	Long: `Runs the automations scheduler as a service suitable for containers. Every
+flag can also be set with a MEGAFONE_* environment variable (for example
+MEGAFONE_SITE_REPO or MEGAFONE_ADDR), and this applies to all commands.
+
+The Hugo site is either mounted as a volume (--site-source) or cloned at startup
+from --site-repo using an optional SSH deploy key.
+
+Endpoints:
+  /healthz  200 while the process is running
+  /readyz   200 once the site is available and automations are loaded
+
+SIGTERM stops accepting new work, lets a running automation pass finish, and
+exits within --shutdown-timeout.
+
+Examples:
+  megafone serve -s /site
+  MEGAFONE_SITE_REPO=git@github.com:me/blog.git MEGAFONE_DEPLOY_KEY=/secrets/id_ed25519 megafone serve`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runServe(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().StringVar(&serveAddr, "addr", ":8080", "Address for the health and readiness endpoints")
+	serveCmd.Flags().StringVarP(&serveAutomationsFile, "file", "f", "automations.yaml", "Path to the automations file")
+	serveCmd.Flags().StringVarP(&siteSource, "site-source", "s", "", "Path to a mounted Hugo site")
+	serveCmd.Flags().DurationVar(&serveShutdownTimeout, "shutdown-timeout", 30*time.Second, "How long to wait for in-flight work on shutdown")
+}
+
+func runServe() error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var ready atomic.Bool
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if !ready.Load() {
+			http.Error(w, "not ready", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ready")
+	})
+
+	server := &http.Server{Addr: serveAddr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	serverErr := make(chan error, 1)
+	go func() {
+		logInfo("🩺 Health endpoints listening on %s", serveAddr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	// Start serving /healthz before the clone so slow clones don't fail liveness probes
+	basePath, err := resolveSitePath()
+	if err != nil {
+		return err
+	}
+	// Actions run as child processes, which pick the site up from the environment
+	os.Setenv(envVarForFlag("site-source"), basePath)
+
+	cfg, err := loadAutomations(serveAutomationsFile)
+	if err != nil {
+		return err
+	}
+	ready.Store(true)
+	logSuccess("Ready: %d automations for %s", len(cfg.Automations), basePath)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		automationsLoop(ctx, cfg, serveAutomationsFile+".state.json", true)
+	}()
+
+	select {
+	case err := <-serverErr:
+		stop()
+		wg.Wait()
+		return fmt.Errorf("health server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	logInfo("🛑 Shutting down...")
+	ready.Store(false)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serveShutdownTimeout)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-shutdownCtx.Done():
+		logError("Timed out waiting for in-flight automations")
+	}
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down health server: %w", err)
+	}
+	logSuccess("Stopped")
+	return nil
+}
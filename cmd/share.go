@@ -0,0 +1,285 @@
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	shareTarget  string
+	shareExpires time.Duration
+	shareOutput  string
+)
+
+var shareCmd = &cobra.Command{
+	Use:   "share <post>",
+	Short: "Publish a draft as a standalone HTML preview and print its URL",
+	Long: `Renders a post to a single HTML page with the hero and site images inlined,
+uploads it, and prints a preview URL for reviewers who don't use git.
+
+Targets:
+  s3       S3-compatible bucket from --asset-bucket (supports --expires via a presigned URL)
+  gist     Secret GitHub gist viewed through gistpreview.github.io (needs GITHUB_TOKEN)
+  netlify  New Netlify site deployed from the page (needs NETLIFY_AUTH_TOKEN)
+
+Examples:
+  megafone share content/posts/en/my-draft.md --to gist
+  megafone share content/posts/en/my-draft.md --to s3 --asset-bucket previews --expires 72h
+  megafone share content/posts/en/my-draft.md --output /tmp/preview.html`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runShare(args[0]); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(shareCmd)
+
+	shareCmd.Flags().StringVar(&shareTarget, "to", "s3", "Where to upload the preview: s3, gist, or netlify")
+	shareCmd.Flags().DurationVar(&shareExpires, "expires", 0, "Expire the link after this long (s3 only, max 168h)")
+	shareCmd.Flags().StringVarP(&shareOutput, "output", "o", "", "Write the HTML to this file instead of uploading it")
+	shareCmd.Flags().StringVarP(&siteSource, "site-source", "s", "", "Path to local Hugo site repository (default: found from the post path)")
+	addAssetStoreFlags(shareCmd)
+}
+
+func runShare(postPath string) error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+
+	data, err := os.ReadFile(postPath)
+	if err != nil {
+		return fmt.Errorf("failed to read post: %w", err)
+	}
+	content := string(data)
+
+	basePath := siteSource
+	if basePath == "" {
+		basePath = findSiteRoot(filepath.Dir(postPath))
+	}
+	if basePath == "" {
+		logInfo("No Hugo site found above %s; site images will not be inlined", postPath)
+	}
+
+	page, err := renderStandalonePost(content, basePath)
+	if err != nil {
+		return err
+	}
+	logInfo("📄 Rendered %s (%d KB)", postPath, len(page)/1024)
+
+	if shareOutput != "" {
+		if err := os.WriteFile(shareOutput, []byte(page), 0644); err != nil {
+			return fmt.Errorf("failed to write preview: %w", err)
+		}
+		logSuccess("✅ Preview written to %s", shareOutput)
+		return nil
+	}
+
+	slug := strings.TrimSuffix(filepath.Base(postPath), filepath.Ext(postPath))
+	if shareExpires > 0 && shareTarget != "s3" {
+		logInfo("⚠️  --expires is only supported with --to s3; the %s link will not expire", shareTarget)
+	}
+
+	ctx := context.Background()
+	var previewURL string
+	switch shareTarget {
+	case "s3":
+		previewURL, err = shareToS3(ctx, slug, page)
+	case "gist":
+		previewURL, err = shareToGist(ctx, slug, page)
+	case "netlify":
+		previewURL, err = shareToNetlify(ctx, page)
+	default:
+		return fmt.Errorf("invalid --to value %q (use s3, gist, or netlify)", shareTarget)
+	}
+	if err != nil {
+		return err
+	}
+
+	logSuccess("🔗 Preview: %s", previewURL)
+	fmt.Println(previewURL)
+	return nil
+}
+
+func shareToS3(ctx context.Context, slug, page string) (string, error) {
+	store, err := newObjectStore()
+	if err != nil {
+		return "", err
+	}
+	if store == nil {
+		return "", fmt.Errorf("--to s3 requires --asset-bucket")
+	}
+	if shareExpires > 7*24*time.Hour {
+		return "", fmt.Errorf("--expires cannot exceed 168h (the S3 presigned URL limit)")
+	}
+
+	// An unguessable name keeps unexpiring links private enough for drafts
+	token := make([]byte, 8)
+	rand.Read(token)
+	store.prefix = "shares"
+	name := fmt.Sprintf("%s-%s.html", slug, hex.EncodeToString(token))
+
+	publicURL, err := store.put(ctx, name, []byte(page), map[string]string{
+		"Content-Type":  "text/html; charset=utf-8",
+		"Cache-Control": "no-store",
+	})
+	if err != nil {
+		return "", err
+	}
+	if shareExpires > 0 {
+		return store.presign(name, shareExpires, time.Now().UTC()), nil
+	}
+	return publicURL, nil
+}
+
+// presign returns a SigV4 query-signed GET URL for an object that stops
+// working after expires
+func (s *objectStore) presign(name string, expires time.Duration, now time.Time) string {
+	key := path.Join(s.prefix, name)
+	u, _ := url.Parse(fmt.Sprintf("%s/%s/%s", s.endpoint, s.bucket, key))
+
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", day, s.region)
+
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	query.Set("X-Amz-Credential", s.accessKey+"/"+scope)
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", fmt.Sprintf("%d", int(expires.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	// SigV4 wants %20 rather than + for spaces
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		parts = append(parts, url.QueryEscape(k)+"="+strings.ReplaceAll(url.QueryEscape(query.Get(k)), "+", "%20"))
+	}
+	canonicalQuery := strings.Join(parts, "&")
+
+	canonicalRequest := strings.Join([]string{
+		http.MethodGet,
+		u.EscapedPath(),
+		canonicalQuery,
+		"host:" + u.Host + "\n",
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+s.secretKey), day)
+	signingKey = hmacSHA256(signingKey, s.region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	u.RawQuery = canonicalQuery + "&X-Amz-Signature=" + signature
+	return u.String()
+}
+
+func shareToGist(ctx context.Context, slug, page string) (string, error) {
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		return "", fmt.Errorf("--to gist requires GITHUB_TOKEN with the gist scope")
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"description": "Draft preview: " + slug,
+		"public":      false,
+		"files": map[string]interface{}{
+			"index.html": map[string]string{"content": page},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.github.com/gists", bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	var result struct {
+		ID string `json:"id"`
+	}
+	if err := doJSON(req, &result); err != nil {
+		return "", fmt.Errorf("failed to create gist: %w", err)
+	}
+	return "https://gistpreview.github.io/?" + result.ID, nil
+}
+
+func shareToNetlify(ctx context.Context, page string) (string, error) {
+	token := os.Getenv("NETLIFY_AUTH_TOKEN")
+	if token == "" {
+		return "", fmt.Errorf("--to netlify requires NETLIFY_AUTH_TOKEN")
+	}
+
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	w, err := zw.Create("index.html")
+	if err != nil {
+		return "", err
+	}
+	io.WriteString(w, page)
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.netlify.com/api/v1/sites", &archive)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/zip")
+
+	var result struct {
+		URL    string `json:"url"`
+		SSLURL string `json:"ssl_url"`
+	}
+	if err := doJSON(req, &result); err != nil {
+		return "", fmt.Errorf("failed to deploy to Netlify: %w", err)
+	}
+	return firstNonEmpty(result.SSLURL, result.URL), nil
+}
+
+// doJSON sends req and decodes a 2xx JSON response into out
+func doJSON(req *http.Request, out interface{}) error {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
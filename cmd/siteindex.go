@@ -0,0 +1,298 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	indexRelatedK   int
+	indexMinScore   float64
+	indexRelatedOut string
+	indexFull       bool
+)
+
+// siteIndex is the embeddings index of the site's posts, kept in
+// .megafone/index/embeddings.json
+type siteIndex struct {
+	Model string           `json:"model"`
+	Built string           `json:"built"`
+	Posts []siteIndexEntry `json:"posts"`
+}
+
+// siteIndexEntry is one post's embedding
+type siteIndexEntry struct {
+	Path   string    `json:"path"` // relative to the site, slash-separated
+	Hash   string    `json:"hash"` // of the embedded text
+	Slug   string    `json:"slug"`
+	Title  string    `json:"title"`
+	URL    string    `json:"url"`
+	Date   string    `json:"date,omitempty"`
+	Vector []float32 `json:"vector"`
+}
+
+// relatedPost is one entry of data/related.yaml
+type relatedPost struct {
+	Slug  string  `yaml:"slug"`
+	Title string  `yaml:"title"`
+	URL   string  `yaml:"url"`
+	Score float64 `yaml:"score"`
+}
+
+var indexCmd = &cobra.Command{
+	Use:   "index",
+	Short: "Manage the site's embeddings index",
+}
+
+var indexRebuildCmd = &cobra.Command{
+	Use:   "rebuild",
+	Short: "Update the site's embeddings index and refresh data/related.yaml",
+	Long: `Embeds the site's published posts into .megafone/index/embeddings.json and
+writes data/related.yaml, which maps each post's slug to its most similar posts.
+Templates can render a related-posts section from it instead of relying on
+Hugo's keyword matching:
+
+  {{ with index site.Data.related (or .Slug .File.ContentBaseName) }}
+    <ul>{{ range . }}<li><a href="{{ .url }}">{{ .title }}</a></li>{{ end }}</ul>
+  {{ end }}
+
+The index is incremental: each post's embedded text is hashed, and only new
+or edited posts are sent for embedding. --full re-embeds everything.
+
+Examples:
+  megafone index rebuild -s ~/hugo
+  megafone index rebuild -s ~/hugo --related 3 --min-score 0.5`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runIndexRebuild(cmd); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(indexCmd)
+	indexCmd.AddCommand(indexRebuildCmd)
+
+	indexRebuildCmd.Flags().StringVarP(&siteSource, "site-source", "s", "", "Path to local Hugo site repository")
+	indexRebuildCmd.Flags().IntVar(&indexRelatedK, "related", 5, "Related posts to list per post in data/related.yaml (0 to skip writing it)")
+	indexRebuildCmd.Flags().Float64Var(&indexMinScore, "min-score", 0.35, "Minimum similarity (0-1) for a post to count as related")
+	indexRebuildCmd.Flags().BoolVar(&indexFull, "full", false, "Re-embed every post, even unchanged ones")
+	indexRebuildCmd.Flags().StringVar(&indexRelatedOut, "related-out", filepath.Join("data", "related.yaml"), "Related posts data file, relative to the site")
+}
+
+func runIndexRebuild(cmd *cobra.Command) error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+
+	basePath, err := resolveSitePath()
+	if err != nil {
+		return err
+	}
+	apiKey, err := getOpenAIKey(cmd)
+	if err != nil {
+		return err
+	}
+
+	posts, err := loadSitePosts(basePath)
+	if err != nil {
+		return fmt.Errorf("failed to load site posts: %w", err)
+	}
+	var published []sitePost
+	for _, p := range posts {
+		if !p.Draft {
+			published = append(published, p)
+		}
+	}
+	if len(published) == 0 {
+		return fmt.Errorf("no published posts found under %s", filepath.Join(basePath, "content", "posts"))
+	}
+
+	var previous *siteIndex
+	if !indexFull {
+		if previous, err = loadSiteIndex(basePath); err != nil {
+			logError("Ignoring unreadable index: %v", err)
+		}
+	}
+	idx, _, err := updateSiteIndex(context.Background(), openai.NewClient(apiKey), basePath, previous, published)
+	if err != nil {
+		return err
+	}
+	if err := saveSiteIndex(basePath, idx); err != nil {
+		return fmt.Errorf("failed to save index: %w", err)
+	}
+	logSuccess("✅ Indexed %d posts: %s", len(idx.Posts), siteIndexPath(basePath))
+
+	if indexRelatedK > 0 {
+		path := filepath.Join(basePath, indexRelatedOut)
+		if err := writeRelatedData(path, relatedPosts(idx, indexRelatedK, indexMinScore)); err != nil {
+			return fmt.Errorf("failed to write related posts: %w", err)
+		}
+		logSuccess("✅ Related posts written: %s", path)
+	}
+	return nil
+}
+
+func siteIndexPath(basePath string) string {
+	return workspacePath(basePath, "index", "embeddings.json")
+}
+
+// updateSiteIndex returns an index of posts, reusing the vectors in idx (which
+// may be nil) for posts whose text hasn't changed and embedding the rest. It
+// also reports how many posts were embedded.
+func updateSiteIndex(ctx context.Context, client *openai.Client, basePath string, idx *siteIndex, posts []sitePost) (*siteIndex, int, error) {
+	model := string(openai.SmallEmbedding3)
+	previous := make(map[string]siteIndexEntry)
+	if idx != nil && idx.Model == model {
+		for _, e := range idx.Posts {
+			previous[e.Path] = e
+		}
+	}
+
+	permalink := sitePermalink(basePath)
+	updated := &siteIndex{Model: model, Built: time.Now().UTC().Format(time.RFC3339)}
+	var texts []string
+	var stale []int
+	for _, p := range posts {
+		text := p.indexText()
+		sum := sha256.Sum256([]byte(text))
+		e := siteIndexEntry{
+			Path:  filepath.ToSlash(mustRel(basePath, p.Path)),
+			Hash:  hex.EncodeToString(sum[:]),
+			Slug:  p.Slug,
+			Title: p.Title,
+			URL:   firstNonEmpty(p.URL, permalinkFor(permalink, p.Path, p.Slug, p.Time())),
+			Date:  p.Date,
+		}
+		if prev, ok := previous[e.Path]; ok && prev.Hash == e.Hash {
+			e.Vector = prev.Vector
+		} else {
+			texts = append(texts, text)
+			stale = append(stale, len(updated.Posts))
+		}
+		updated.Posts = append(updated.Posts, e)
+	}
+
+	if len(texts) > 0 {
+		logInfo("🧮 Embedding %d new or changed posts (%d unchanged)...", len(texts), len(posts)-len(texts))
+		vectors, err := embedTexts(ctx, client, texts)
+		if err != nil {
+			return nil, 0, err
+		}
+		for i, v := range vectors {
+			updated.Posts[stale[i]].Vector = v
+		}
+	}
+	return updated, len(texts), nil
+}
+
+// indexText is the text a post is embedded from: its summary plus the opening of the body
+func (p sitePost) indexText() string {
+	body := anyShortcodeRegex.ReplaceAllString(p.Body, "")
+	return p.summaryText() + "\n" + truncateWords(200, body)
+}
+
+// loadSiteIndex reads the embeddings index, returning nil if it hasn't been built
+func loadSiteIndex(basePath string) (*siteIndex, error) {
+	data, err := os.ReadFile(siteIndexPath(basePath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var idx siteIndex
+	if err := json.Unmarshal(data, &idx); err != nil {
+		return nil, fmt.Errorf("invalid index %s: %w", siteIndexPath(basePath), err)
+	}
+	return &idx, nil
+}
+
+// ensureSiteIndex returns the embeddings of posts, bringing the saved index up
+// to date first. Only new and edited posts are embedded, so on an indexed site
+// this costs no API calls.
+func ensureSiteIndex(ctx context.Context, client *openai.Client, basePath string, posts []sitePost) (*siteIndex, error) {
+	idx, err := loadSiteIndex(basePath)
+	if err != nil {
+		return nil, err
+	}
+	updated, embedded, err := updateSiteIndex(ctx, client, basePath, idx, posts)
+	if err != nil {
+		return nil, err
+	}
+	if embedded > 0 || idx == nil || len(idx.Posts) != len(updated.Posts) {
+		if err := saveSiteIndex(basePath, updated); err != nil {
+			return nil, fmt.Errorf("failed to save index: %w", err)
+		}
+	}
+	return updated, nil
+}
+
+func saveSiteIndex(basePath string, idx *siteIndex) error {
+	path := siteIndexPath(basePath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// relatedPosts lists each post's k most similar posts scoring at least minScore
+func relatedPosts(idx *siteIndex, k int, minScore float64) map[string][]relatedPost {
+	related := make(map[string][]relatedPost)
+	for i, a := range idx.Posts {
+		var candidates []relatedPost
+		for j, b := range idx.Posts {
+			if i == j {
+				continue
+			}
+			if score := cosineSimilarity(a.Vector, b.Vector); score >= minScore {
+				candidates = append(candidates, relatedPost{Slug: b.Slug, Title: b.Title, URL: b.URL, Score: float64(int(score*1000)) / 1000})
+			}
+		}
+		sort.SliceStable(candidates, func(x, y int) bool {
+			return candidates[x].Score > candidates[y].Score
+		})
+		if len(candidates) > k {
+			candidates = candidates[:k]
+		}
+		if len(candidates) > 0 {
+			related[a.Slug] = candidates
+		}
+	}
+	return related
+}
+
+// writeRelatedData writes the related posts map as a Hugo data file
+func writeRelatedData(path string, related map[string][]relatedPost) error {
+	data, err := yaml.Marshal(related)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	header := "# Generated by megafone index rebuild; edits are overwritten.\n"
+	if len(related) == 0 {
+		data = []byte("{}\n")
+	}
+	if err := os.WriteFile(path, append([]byte(header), data...), 0644); err != nil {
+		return err
+	}
+	recordSiteChange("modified", path, "", false)
+	return nil
+}
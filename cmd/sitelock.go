@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// siteLockPoll is how often a run waiting for the site lock checks it again
+const siteLockPoll = 250 * time.Millisecond
+
+var (
+	siteLockTimeout time.Duration
+
+	// runStarted marks when this process began; posts written to the site
+	// after it by another run are that run's, not earlier output to replace
+	runStarted = time.Now()
+
+	// siteLockMutexes serializes goroutines of one process, which flock alone
+	// doesn't on platforms without it
+	siteLockMutexes sync.Map
+
+	// postsWrittenByRun holds the post paths this process wrote, so a run
+	// writing the same file twice (a retry, a translation) isn't renamed
+	postsWrittenByRun sync.Map
+)
+
+func init() {
+	rootCmd.PersistentFlags().DurationVar(&siteLockTimeout, "lock-timeout", 10*time.Minute, "How long to wait for another megafone run writing to the same site before giving up")
+}
+
+// siteLockPath returns the lock file for a site. Locks live in the user cache
+// directory rather than the site so they never show up in git, and so a
+// --site-repo clone can be locked before it exists.
+func siteLockPath(sitePath string) (string, error) {
+	abs, err := filepath.Abs(sitePath)
+	if err != nil {
+		return "", err
+	}
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate cache directory: %w", err)
+	}
+	dir := filepath.Join(cacheDir, "megafone", "locks")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(filepath.Clean(abs)))
+	return filepath.Join(dir, hex.EncodeToString(sum[:8])+".lock"), nil
+}
+
+// withSiteLock runs fn while holding the write lock for a site, queueing behind
+// any other megafone process or goroutine writing to it. what describes the
+// write for whoever is left waiting. Calls must not nest.
+func withSiteLock(sitePath, what string, fn func() error) error {
+	path, err := siteLockPath(sitePath)
+	if err != nil {
+		return fmt.Errorf("failed to lock %s: %w", sitePath, err)
+	}
+
+	mu, _ := siteLockMutexes.LoadOrStore(path, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	defer mu.(*sync.Mutex).Unlock()
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to lock %s: %w", sitePath, err)
+	}
+	defer f.Close()
+
+	deadline := time.Now().Add(siteLockTimeout)
+	waiting := false
+	for lockFile(f, true) != nil {
+		holder := siteLockHolder(path)
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out after %s waiting to write to %s (held by %s); raise --lock-timeout", siteLockTimeout, sitePath, holder)
+		}
+		if !waiting {
+			logInfo("⏳ Waiting for another megafone run writing to %s (%s)", sitePath, holder)
+			waiting = true
+		}
+		time.Sleep(siteLockPoll)
+	}
+	defer func() {
+		f.Truncate(0)
+		unlockFile(f)
+	}()
+
+	// Record the holder for anyone who queues behind this run
+	f.Truncate(0)
+	f.WriteAt([]byte(fmt.Sprintf("pid %d, %s: %s since %s\n", os.Getpid(), firstNonEmpty(journalCommand, "megafone"), what, time.Now().Format(time.RFC3339))), 0)
+
+	return fn()
+}
+
+// siteLockHolder describes the run holding a lock, as it recorded itself
+func siteLockHolder(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil || len(strings.TrimSpace(string(data))) == 0 {
+		return "unknown run"
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// claimPostPath picks where a new post goes; call it under the site lock. A
+// file at postPath written since this run started by another run is a
+// concurrent job that landed on the same slug, so this post gets the next free
+// numbered slug instead of overwriting it. content's slug follows the filename.
+func claimPostPath(postPath, content string) (string, string) {
+	claimed := postPath
+	if takenByOtherRun(postPath) {
+		ext := filepath.Ext(postPath)
+		base := strings.TrimSuffix(postPath, ext)
+		for n := 2; ; n++ {
+			claimed = fmt.Sprintf("%s-%d%s", base, n, ext)
+			if _, err := os.Stat(claimed); os.IsNotExist(err) {
+				break
+			}
+		}
+		slug := strings.TrimSuffix(filepath.Base(claimed), ext)
+		if frontMatterString(content, "slug") != "" {
+			content = upsertFrontMatterField(content, "slug", yamlQuote(slug))
+		}
+		logInfo("⚠️  Another run just wrote %s; writing this post as %s", filepath.Base(postPath), filepath.Base(claimed))
+	}
+	postsWrittenByRun.Store(claimed, true)
+	return claimed, content
+}
+
+func takenByOtherRun(postPath string) bool {
+	if _, ours := postsWrittenByRun.Load(postPath); ours {
+		return false
+	}
+	info, err := os.Stat(postPath)
+	return err == nil && info.ModTime().After(runStarted)
+}
+
+// writeNewPost writes a generated post under the site lock, moving it to a
+// free slug if a concurrent run took this one, and returns where it was written
+func writeNewPost(basePath, postPath, content string) (string, error) {
+	err := withSiteLock(basePath, "writing "+filepath.Base(postPath), func() error {
+		postPath, content = claimPostPath(postPath, content)
+		if err := os.MkdirAll(filepath.Dir(postPath), 0755); err != nil {
+			return fmt.Errorf("failed to create posts directory: %w", err)
+		}
+		return os.WriteFile(postPath, []byte(content), 0644)
+	})
+	return postPath, err
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	siteRepo        string
+	siteCacheDir    string
+	siteDeployKey   string
+	siteSparse      bool
+	siteSparsePaths []string
+)
+
+var repoDirRegex = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// cachedSitePath clones --site-repo into the cache (or pulls an existing clone)
+// and returns the checkout path
+func cachedSitePath() (string, error) {
+	dir := siteCacheDir
+	if dir == "" {
+		cache, err := os.UserCacheDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to locate cache directory (set --site-cache): %w", err)
+		}
+		dir = filepath.Join(cache, "megafone", "sites")
+	}
+
+	// git@github.com:me/site.git -> github.com_me_site
+	name := strings.TrimSuffix(siteRepo, ".git")
+	name = strings.TrimPrefix(strings.TrimPrefix(name, "https://"), "ssh://")
+	name = strings.TrimPrefix(name, "git@")
+	name = strings.Trim(repoDirRegex.ReplaceAllString(name, "_"), "_")
+
+	dest := filepath.Join(dir, name)
+	// Parallel runs share the clone; only one may pull or clone at a time
+	err := withSiteLock(dest, "syncing the site clone", func() error {
+		return syncSiteRepo(siteRepo, dest, siteDeployKey)
+	})
+	if err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+// syncSiteRepo clones repoURL into dest, or fast-forwards an existing clone.
+// deployKey is a path to an SSH private key; without one, SSH URLs use the
+// running SSH agent and HTTPS URLs use GITHUB_TOKEN when set. With --sparse the
+// clone is shallow, fetches blobs lazily, and checks out only --sparse-paths.
+func syncSiteRepo(repoURL, dest, deployKey string) error {
+	if _, err := os.Stat(filepath.Join(dest, ".git")); err == nil {
+		logInfo("🔄 Updating site clone in %s", dest)
+		if siteSparse {
+			if err := runGit(deployKey, append([]string{"-C", dest, "sparse-checkout", "set"}, siteSparsePaths...)...); err != nil {
+				return err
+			}
+			return runGit(deployKey, "-C", dest, "pull", "--ff-only", "--quiet", "--depth", "1")
+		}
+		return runGit(deployKey, "-C", dest, "pull", "--ff-only", "--quiet")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return fmt.Errorf("failed to create clone directory: %w", err)
+	}
+
+	if !siteSparse {
+		logInfo("📥 Cloning %s into %s", repoURL, dest)
+		return runGit(deployKey, "clone", "--quiet", repoURL, dest)
+	}
+
+	logInfo("📥 Sparse cloning %s (%s) into %s", repoURL, strings.Join(siteSparsePaths, ", "), dest)
+	if err := runGit(deployKey, "clone", "--quiet", "--depth", "1", "--filter=blob:none", "--no-checkout", repoURL, dest); err != nil {
+		return err
+	}
+	// Cone mode also keeps top-level files such as hugo.toml
+	if err := runGit(deployKey, append([]string{"-C", dest, "sparse-checkout", "set", "--cone"}, siteSparsePaths...)...); err != nil {
+		return err
+	}
+	return runGit(deployKey, "-C", dest, "checkout", "--quiet")
+}
+
+func runGit(deployKey string, args ...string) error {
+	// Pass the token as a header so it never lands in .git/config, scoped to
+	// github.com so it isn't sent to other hosts or redirect targets
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		basic := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + token))
+		args = append([]string{"-c", "http.https://github.com/.extraHeader=Authorization: Basic " + basic}, args...)
+	}
+
+	c := exec.Command("git", args...)
+	c.Env = os.Environ()
+	if deployKey != "" {
+		// Containers have no known_hosts; accept the host key on first use
+		c.Env = append(c.Env, fmt.Sprintf("GIT_SSH_COMMAND=ssh -i %s -o IdentitiesOnly=yes -o StrictHostKeyChecking=accept-new", deployKey))
+	}
+	out, err := c.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git %s failed: %v: %s", gitSubcommand(args), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// gitSubcommand returns the first argument that isn't a global option
+func gitSubcommand(args []string) string {
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "-c", "-C":
+			i++
+		default:
+			return args[i]
+		}
+	}
+	return "git"
+}
@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	slugStrategyLLM     = "llm"
+	slugStrategyTitle   = "title"
+	slugStrategyDate    = "date"
+	slugStrategyKeyword = "keyword"
+)
+
+var (
+	slugStrategy      string
+	slugKeyword       string
+	slugMaxLength     int
+	slugDropStopWords bool
+)
+
+// slugsConfig is the slugs block of megafone.yaml
+type slugsConfig struct {
+	Strategy        string `yaml:"strategy"`
+	Keyword         string `yaml:"keyword"`
+	MaxLength       int    `yaml:"max_length"`
+	RemoveStopWords bool   `yaml:"remove_stop_words"`
+}
+
+// slugStopWords are dropped from slugs with --slug-stop-words
+var slugStopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "but": true,
+	"by": true, "for": true, "from": true, "how": true, "in": true, "into": true, "is": true, "it": true,
+	"its": true, "of": true, "on": true, "or": true, "that": true, "the": true, "this": true, "to": true,
+	"was": true, "what": true, "when": true, "why": true, "with": true, "you": true, "your": true,
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&slugStrategy, "slug-strategy", "", "How generated posts are named: llm (model-suggested), title, date (2006-01-02-title), or keyword (default: slugs.strategy in config, then llm)")
+	rootCmd.PersistentFlags().StringVar(&slugKeyword, "slug-keyword", "", "Keyword to lead the slug with the keyword strategy (default: slugs.keyword in config, then the brief's target keyword)")
+	rootCmd.PersistentFlags().IntVar(&slugMaxLength, "slug-max-length", 0, "Maximum slug length in characters, cut at a word boundary (default: slugs.max_length in config, then 50)")
+	rootCmd.PersistentFlags().BoolVar(&slugDropStopWords, "slug-stop-words", false, "Remove stop words (the, a, of, ...) from slugs (default: slugs.remove_stop_words in config)")
+}
+
+// checkSlugStrategy rejects an unknown strategy before any generation work is done
+func checkSlugStrategy() error {
+	switch strategy := firstNonEmpty(slugStrategy, appConfig.Slugs.Strategy, slugStrategyLLM); strategy {
+	case slugStrategyLLM, slugStrategyTitle, slugStrategyDate, slugStrategyKeyword:
+		return nil
+	default:
+		return fmt.Errorf("invalid slug strategy %q (use llm, title, date, or keyword)", strategy)
+	}
+}
+
+// applySlugStrategy picks the post's slug under the configured strategy, sets
+// the slug front matter field to it, and returns the slug to use as the
+// filename. proposed is the name the command came up with on its own (the
+// model's suggestion for most commands); keyword is the brief's target keyword,
+// if any.
+func applySlugStrategy(content, proposed, keyword string) (string, string, error) {
+	slug, err := slugFor(content, proposed, keyword, postDate.time)
+	if err != nil {
+		return content, proposed, err
+	}
+	return upsertFrontMatterField(content, "slug", yamlQuote(slug)), slug, nil
+}
+
+// slugFor builds a post's slug under the configured strategy; date is used by the date strategy
+func slugFor(content, proposed, keyword string, date time.Time) (string, error) {
+	strategy := firstNonEmpty(slugStrategy, appConfig.Slugs.Strategy, slugStrategyLLM)
+	title := frontMatterString(content, "title")
+
+	maxLength := slugMaxLength
+	if maxLength == 0 {
+		maxLength = appConfig.Slugs.MaxLength
+	}
+	if maxLength == 0 {
+		maxLength = 50
+	}
+	dropStopWords := slugDropStopWords || appConfig.Slugs.RemoveStopWords
+
+	var words []string
+	switch strategy {
+	case slugStrategyLLM:
+		words = slugWords(proposed, dropStopWords)
+	case slugStrategyTitle, slugStrategyDate:
+		words = slugWords(title, dropStopWords)
+	case slugStrategyKeyword:
+		keyword = firstNonEmpty(slugKeyword, appConfig.Slugs.Keyword, keyword)
+		if keyword == "" {
+			logInfo("⚠️  No keyword for the keyword slug strategy (set --slug-keyword); using the title")
+		}
+		// The keyword leads, followed by whatever the title adds
+		words = slugWords(keyword, false)
+		inKeyword := make(map[string]bool)
+		for _, w := range words {
+			inKeyword[w] = true
+		}
+		for _, w := range slugWords(title, dropStopWords) {
+			if !inKeyword[w] {
+				words = append(words, w)
+			}
+		}
+	default:
+		return "", fmt.Errorf("invalid slug strategy %q (use llm, title, date, or keyword)", strategy)
+	}
+	if len(words) == 0 {
+		words = slugWords(firstNonEmpty(proposed, title), dropStopWords)
+	}
+
+	prefix := ""
+	if strategy == slugStrategyDate {
+		prefix = date.Format("2006-01-02") + "-"
+	}
+	slug := prefix + joinSlugWords(words, maxLength-len(prefix))
+	if slug == prefix {
+		slug = prefix + "untitled-post"
+	}
+	return slug, nil
+}
+
+// slugWords lowercases s and splits it into ASCII words, optionally without stop words
+func slugWords(s string, dropStopWords bool) []string {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return (r < 'a' || r > 'z') && (r < '0' || r > '9')
+	})
+	var words []string
+	for _, w := range fields {
+		if dropStopWords && slugStopWords[w] && len(fields) > 1 {
+			continue
+		}
+		words = append(words, w)
+	}
+	return words
+}
+
+// joinSlugWords hyphenates words, stopping before the one that would exceed
+// maxLength. A single overlong word is cut.
+func joinSlugWords(words []string, maxLength int) string {
+	var slug string
+	for _, w := range words {
+		next := w
+		if slug != "" {
+			next = slug + "-" + w
+		}
+		if len(next) > maxLength {
+			if slug == "" && maxLength > 0 {
+				slug = w[:maxLength]
+			}
+			break
+		}
+		slug = next
+	}
+	return slug
+}
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	slugsMigratePermalink string
+	slugsMigrateDrafts    bool
+)
+
+var slugsCmd = &cobra.Command{
+	Use:   "slugs",
+	Short: "Manage post slugs",
+}
+
+var slugsMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Rename existing posts under the current slug strategy, keeping old URLs as aliases",
+	Long: `Recomputes every post's slug under the slug strategy (--slug-strategy or
+slugs in megafone.yaml), renames posts whose slug changes, and adds the old URL to
+each renamed post's aliases so Hugo keeps redirecting it.
+
+Posts with an explicit url: in their front matter are left alone. Page bundles
+(index.md) keep their directory and only get the new slug field. Old URLs are
+built from --permalink, which defaults to the posts entry of the Hugo
+permalinks config, then /posts/:slug/.
+
+Every rename is staged first and shown as a diff; nothing changes until you
+confirm, and the whole migration can be reverted with 'megafone transactions
+undo'.
+
+Examples:
+  # Preview the renames
+  megafone slugs migrate -s ~/hugo --slug-strategy keyword --dry-run
+
+  megafone slugs migrate -s ~/hugo --slug-strategy title --slug-stop-words`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runSlugsMigrate(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(slugsCmd)
+	slugsCmd.AddCommand(slugsMigrateCmd)
+
+	slugsMigrateCmd.Flags().StringVarP(&siteSource, "site-source", "s", "", "Path to local Hugo site repository")
+	slugsMigrateCmd.Flags().StringVar(&slugsMigratePermalink, "permalink", "", "Permalink pattern posts are served under, e.g. /:year/:month/:slug/ (default: the Hugo config, then /posts/:slug/)")
+	slugsMigrateCmd.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "List the renames without changing any files")
+	slugsMigrateCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Apply without asking for confirmation")
+	slugsMigrateCmd.Flags().BoolVar(&slugsMigrateDrafts, "drafts", false, "Include drafts")
+}
+
+func runSlugsMigrate() error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+
+	basePath, err := resolveSitePath()
+	if err != nil {
+		return err
+	}
+	posts, err := loadSitePosts(basePath)
+	if err != nil {
+		return fmt.Errorf("failed to read posts: %w", err)
+	}
+
+	permalink := firstNonEmpty(slugsMigratePermalink, sitePermalink(basePath))
+	sources := journalSources(basePath)
+	logInfo("🔗 Migrating slugs to the %s strategy (permalink %s)", firstNonEmpty(slugStrategy, appConfig.Slugs.Strategy, slugStrategyLLM), permalink)
+
+	txn := newSiteTxn(basePath, "slugs migrate")
+	taken := make(map[string]bool)
+	renamed, updated, skipped := 0, 0, 0
+	for _, post := range posts {
+		if post.Draft && !slugsMigrateDrafts {
+			continue
+		}
+		data, err := os.ReadFile(post.Path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", post.Path, err)
+		}
+		content := string(data)
+		if frontMatterString(content, "url") != "" {
+			continue
+		}
+
+		keywords := frontMatterList(content, "keywords")
+		keywords = append(keywords, "")
+		slug, err := slugFor(content, post.Slug, keywords[0], post.Time())
+		if err != nil {
+			return err
+		}
+		if slug == post.Slug {
+			continue
+		}
+
+		bundle := filepath.Base(post.Path) == "index.md"
+		newPath := post.Path
+		if !bundle {
+			newPath = filepath.Join(filepath.Dir(post.Path), slug+".md")
+			if _, err := os.Stat(newPath); err == nil || taken[newPath] {
+				logError("Skipping %s: %s already exists", post.Path, newPath)
+				skipped++
+				continue
+			}
+			taken[newPath] = true
+		}
+
+		oldURL := permalinkFor(permalink, post.Path, post.Slug, post.Time())
+		newURL := permalinkFor(permalink, newPath, slug, post.Time())
+		fmt.Printf("  %s → %s\n", oldURL, newURL)
+
+		content = upsertFrontMatterField(content, "slug", yamlQuote(slug))
+		content = addAliases(content, newURL, oldURL)
+		// Carry the post's source over so regenerating it still finds the renamed file
+		source := sources[filepath.ToSlash(mustRel(basePath, post.Path))]
+		if err := txn.write(newPath, []byte(content), source, post.AIGenerated); err != nil {
+			return err
+		}
+		if bundle {
+			updated++
+			continue
+		}
+		if err := txn.remove(post.Path, source, post.AIGenerated); err != nil {
+			return err
+		}
+		renamed++
+	}
+
+	applied, err := applyTxn(txn)
+	if err != nil || !applied {
+		return err
+	}
+	logSuccess("✅ Renamed %d posts, updated %d bundles, skipped %d", renamed, updated, skipped)
+	return nil
+}
+
+// journalSources maps each post path in the journal (relative to the site) to
+// the source it was last created from
+func journalSources(basePath string) map[string]string {
+	sources := make(map[string]string)
+	f, err := os.Open(journalPath(basePath))
+	if err != nil {
+		return sources
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry journalEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) == nil && entry.Action == "created" && entry.Source != "" {
+			sources[entry.Path] = entry.Source
+		}
+	}
+	return sources
+}
+
+// mustRel returns path relative to base, or path itself if it isn't under base
+func mustRel(base, path string) string {
+	rel, err := filepath.Rel(base, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return rel
+}
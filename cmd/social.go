@@ -0,0 +1,303 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	platformMastodon = "mastodon"
+	platformBluesky  = "bluesky"
+	platformX        = "x"
+)
+
+var (
+	socialClient = &http.Client{Timeout: 30 * time.Second}
+	bareURLRegex = regexp.MustCompile(`https?://[^\s<>"]+`)
+)
+
+// socialPostLimits is the most characters each platform accepts in one post
+var socialPostLimits = map[string]int{
+	platformMastodon: 500,
+	platformBluesky:  300,
+	platformX:        280,
+}
+
+// socialPlatforms is the order platforms are listed and posted to
+var socialPlatforms = []string{platformMastodon, platformBluesky, platformX}
+
+// checkSocialPlatforms rejects platforms megafone can't post to
+func checkSocialPlatforms(platforms []string) error {
+	if len(platforms) == 0 {
+		return fmt.Errorf("no platforms to post to (use mastodon, bluesky, or x)")
+	}
+	for _, p := range platforms {
+		if _, ok := socialPostLimits[p]; !ok {
+			return fmt.Errorf("unknown platform %q (use mastodon, bluesky, or x)", p)
+		}
+	}
+	return nil
+}
+
+// fitSocialPost shortens text so it and the link fit within a platform's limit
+func fitSocialPost(text, link string, limit int) string {
+	if link == "" {
+		return trimToLength(text, limit)
+	}
+	room := limit - len([]rune(link)) - 1
+	if room < 20 {
+		return link
+	}
+	return trimToLength(text, room) + " " + link
+}
+
+// postToSocial publishes text on a platform and returns the URL of the new post
+func postToSocial(ctx context.Context, platform, text string) (string, error) {
+	switch platform {
+	case platformMastodon:
+		return postToMastodon(ctx, text)
+	case platformBluesky:
+		return postToBluesky(ctx, text)
+	case platformX:
+		return postToX(ctx, text)
+	default:
+		return "", fmt.Errorf("unknown platform %q", platform)
+	}
+}
+
+// socialCredentials are the environment variables each platform needs
+var socialCredentials = map[string][]string{
+	platformMastodon: {"MASTODON_SERVER", "MASTODON_ACCESS_TOKEN"},
+	platformBluesky:  {"BLUESKY_HANDLE", "BLUESKY_APP_PASSWORD"},
+	platformX:        {"X_API_KEY", "X_API_SECRET", "X_ACCESS_TOKEN", "X_ACCESS_TOKEN_SECRET"},
+}
+
+// socialConfigured reports whether the credentials for a platform are set
+func socialConfigured(platform string) bool {
+	for _, name := range socialCredentials[platform] {
+		if os.Getenv(name) == "" {
+			return false
+		}
+	}
+	return len(socialCredentials[platform]) > 0
+}
+
+// postToMastodon posts a public status with MASTODON_ACCESS_TOKEN on MASTODON_SERVER
+func postToMastodon(ctx context.Context, text string) (string, error) {
+	server := strings.TrimRight(os.Getenv("MASTODON_SERVER"), "/")
+	token := os.Getenv("MASTODON_ACCESS_TOKEN")
+	if server == "" || token == "" {
+		return "", classify(ErrAuth, fmt.Errorf("MASTODON_SERVER and MASTODON_ACCESS_TOKEN are required to post to Mastodon"))
+	}
+	if !strings.Contains(server, "://") {
+		server = "https://" + server
+	}
+
+	var status struct {
+		URL string `json:"url"`
+	}
+	body := map[string]string{"status": text, "visibility": "public"}
+	if err := postSocialJSON(ctx, "Mastodon", server+"/api/v1/statuses", map[string]string{"Authorization": "Bearer " + token}, body, &status); err != nil {
+		return "", err
+	}
+	return status.URL, nil
+}
+
+// postToBluesky signs in with BLUESKY_HANDLE and BLUESKY_APP_PASSWORD and
+// creates a post, turning URLs in the text into link facets so they're clickable
+func postToBluesky(ctx context.Context, text string) (string, error) {
+	handle := strings.TrimPrefix(os.Getenv("BLUESKY_HANDLE"), "@")
+	password := os.Getenv("BLUESKY_APP_PASSWORD")
+	if handle == "" || password == "" {
+		return "", classify(ErrAuth, fmt.Errorf("BLUESKY_HANDLE and BLUESKY_APP_PASSWORD are required to post to Bluesky"))
+	}
+	pds := strings.TrimRight(firstNonEmpty(os.Getenv("BLUESKY_PDS"), "https://bsky.social"), "/")
+
+	var session struct {
+		AccessJwt string `json:"accessJwt"`
+		DID       string `json:"did"`
+	}
+	login := map[string]string{"identifier": handle, "password": password}
+	if err := postSocialJSON(ctx, "Bluesky", pds+"/xrpc/com.atproto.server.createSession", nil, login, &session); err != nil {
+		return "", err
+	}
+
+	record := map[string]interface{}{
+		"$type":     "app.bsky.feed.post",
+		"text":      text,
+		"createdAt": time.Now().UTC().Format(time.RFC3339),
+	}
+	if facets := blueskyLinkFacets(text); len(facets) > 0 {
+		record["facets"] = facets
+	}
+	var created struct {
+		URI string `json:"uri"`
+	}
+	body := map[string]interface{}{"repo": session.DID, "collection": "app.bsky.feed.post", "record": record}
+	if err := postSocialJSON(ctx, "Bluesky", pds+"/xrpc/com.atproto.repo.createRecord", map[string]string{"Authorization": "Bearer " + session.AccessJwt}, body, &created); err != nil {
+		return "", err
+	}
+	// at://did/app.bsky.feed.post/<rkey> is shown at bsky.app/profile/<handle>/post/<rkey>
+	rkey := created.URI[strings.LastIndex(created.URI, "/")+1:]
+	return "https://bsky.app/profile/" + handle + "/post/" + rkey, nil
+}
+
+// postToX posts with the X API v2, signing the request with OAuth 1.0a user
+// credentials (X_API_KEY, X_API_SECRET, X_ACCESS_TOKEN, X_ACCESS_TOKEN_SECRET)
+func postToX(ctx context.Context, text string) (string, error) {
+	if !socialConfigured(platformX) {
+		return "", classify(ErrAuth, fmt.Errorf("X_API_KEY, X_API_SECRET, X_ACCESS_TOKEN, and X_ACCESS_TOKEN_SECRET are required to post to X"))
+	}
+	endpoint := strings.TrimRight(firstNonEmpty(os.Getenv("X_API_URL"), "https://api.x.com"), "/") + "/2/tweets"
+
+	var created struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	auth := oauth1Header(http.MethodPost, endpoint, os.Getenv("X_API_KEY"), os.Getenv("X_API_SECRET"), os.Getenv("X_ACCESS_TOKEN"), os.Getenv("X_ACCESS_TOKEN_SECRET"))
+	if err := postSocialJSON(ctx, "X", endpoint, map[string]string{"Authorization": auth}, map[string]string{"text": text}, &created); err != nil {
+		return "", err
+	}
+	if created.Data.ID == "" {
+		return "", fmt.Errorf("X returned no post ID")
+	}
+	return "https://x.com/i/web/status/" + created.Data.ID, nil
+}
+
+// oauth1Header signs a request with OAuth 1.0a HMAC-SHA1. JSON bodies aren't
+// part of the signature, so only the OAuth parameters and the query are.
+func oauth1Header(method, endpoint, consumerKey, consumerSecret, token, tokenSecret string) string {
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+	params := map[string]string{
+		"oauth_consumer_key":     consumerKey,
+		"oauth_nonce":            hex.EncodeToString(nonce),
+		"oauth_signature_method": "HMAC-SHA1",
+		"oauth_timestamp":        strconv.FormatInt(time.Now().Unix(), 10),
+		"oauth_token":            token,
+		"oauth_version":          "1.0",
+	}
+
+	base := endpoint
+	signed := make(map[string]string, len(params))
+	for k, v := range params {
+		signed[k] = v
+	}
+	if u, err := url.Parse(endpoint); err == nil {
+		for k, vs := range u.Query() {
+			signed[k] = vs[0]
+		}
+		u.RawQuery = ""
+		base = u.String()
+	}
+	keys := make([]string, 0, len(signed))
+	for k := range signed {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = oauthEscape(k) + "=" + oauthEscape(signed[k])
+	}
+	baseString := method + "&" + oauthEscape(base) + "&" + oauthEscape(strings.Join(pairs, "&"))
+	mac := hmac.New(sha1.New, []byte(oauthEscape(consumerSecret)+"&"+oauthEscape(tokenSecret)))
+	mac.Write([]byte(baseString))
+	params["oauth_signature"] = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	keys = keys[:0]
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	header := make([]string, len(keys))
+	for i, k := range keys {
+		header[i] = fmt.Sprintf(`%s="%s"`, oauthEscape(k), oauthEscape(params[k]))
+	}
+	return "OAuth " + strings.Join(header, ", ")
+}
+
+// oauthEscape percent-encodes as RFC 3986 requires, which url.QueryEscape
+// doesn't quite (it turns spaces into +)
+func oauthEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// blueskyLinkFacets marks each URL in text as a link. Bluesky counts facet
+// offsets in UTF-8 bytes.
+func blueskyLinkFacets(text string) []map[string]interface{} {
+	var facets []map[string]interface{}
+	for _, loc := range bareURLRegex.FindAllStringIndex(text, -1) {
+		uri := strings.TrimRight(text[loc[0]:loc[1]], ".,;:!?)")
+		facets = append(facets, map[string]interface{}{
+			"index": map[string]int{"byteStart": loc[0], "byteEnd": loc[0] + len(uri)},
+			"features": []map[string]string{
+				{"$type": "app.bsky.richtext.facet#link", "uri": uri},
+			},
+		})
+	}
+	return facets
+}
+
+// postSocialJSON posts a JSON request to a platform API and decodes the reply into v
+func postSocialJSON(ctx context.Context, service, endpoint string, headers map[string]string, body, v interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	return socialRequest(ctx, http.MethodPost, service, endpoint, headers, bytes.NewReader(payload), v)
+}
+
+// getSocialJSON fetches a platform API endpoint and decodes the reply into v
+func getSocialJSON(ctx context.Context, service, endpoint string, headers map[string]string, v interface{}) error {
+	return socialRequest(ctx, http.MethodGet, service, endpoint, headers, nil, v)
+}
+
+func socialRequest(ctx context.Context, method, service, endpoint string, headers map[string]string, body io.Reader, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := socialClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s request failed: %w", service, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return classify(ErrAuth, fmt.Errorf("%s rejected the credentials: %s", service, resp.Status))
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return fmt.Errorf("%s API error: %s: %s", service, resp.Status, strings.TrimSpace(string(data)))
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("invalid %s response: %w", service, err)
+	}
+	return nil
+}
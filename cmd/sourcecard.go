@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// sourceCard is the attribution card rendered by the theme for website-based posts
+type sourceCard struct {
+	SiteName  string
+	Favicon   string
+	Author    string
+	Published string
+	URL       string
+}
+
+// newSourceCard builds the attribution card from a page's extracted metadata
+func newSourceCard(meta pageMetadata) sourceCard {
+	return sourceCard{
+		SiteName:  meta.SiteName,
+		Favicon:   meta.Favicon,
+		Author:    meta.Author,
+		Published: meta.Published,
+		URL:       meta.URL,
+	}
+}
+
+// frontMatterValue renders the card as a YAML flow mapping for the front matter
+func (c sourceCard) frontMatterValue() string {
+	var fields []string
+	add := func(key, value string) {
+		if value != "" {
+			fields = append(fields, fmt.Sprintf("%s: %s", key, yamlQuote(value)))
+		}
+	}
+	add("site_name", c.SiteName)
+	add("favicon", c.Favicon)
+	add("author", c.Author)
+	add("published", c.Published)
+	add("url", c.URL)
+	return "{" + strings.Join(fields, ", ") + "}"
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+// sourceSettings is a megafone.yaml block of generate defaults for one kind of
+// source (github, website, podcast, research, chat, or feed)
+type sourceSettings struct {
+	Model              string   `yaml:"model"`
+	Temperature        *float32 `yaml:"temperature"`
+	Words              int      `yaml:"words"`
+	ImageSource        string   `yaml:"image_source"`
+	ImageLicensePolicy string   `yaml:"image_license_policy"`
+	Prompt             string   `yaml:"prompt"`
+	Truncation         string   `yaml:"truncation"`
+	ResearchBackend    string   `yaml:"research_backend"`
+}
+
+// applySourceSettings fills generate flags the user didn't set from the config
+// block for kind. Feed items fall back to the website block.
+func applySourceSettings(cmd *cobra.Command, kind string) error {
+	settings, ok := appConfig.Sources[kind]
+	if !ok && kind == "feed" {
+		settings, ok = appConfig.Sources["website"]
+	}
+	if !ok {
+		return nil
+	}
+
+	values := map[string]string{
+		"model":                settings.Model,
+		"image-source":         settings.ImageSource,
+		"image-license-policy": settings.ImageLicensePolicy,
+		"prompt":               settings.Prompt,
+		"truncation":           settings.Truncation,
+		"research-backend":     settings.ResearchBackend,
+	}
+	if settings.Temperature != nil {
+		values["temperature"] = strconv.FormatFloat(float64(*settings.Temperature), 'f', -1, 32)
+	}
+	if settings.Words > 0 {
+		values["words"] = strconv.Itoa(settings.Words)
+	}
+
+	for name, value := range values {
+		if value == "" || cmd.Flags().Changed(name) {
+			continue
+		}
+		if err := cmd.Flags().Set(name, value); err != nil {
+			return fmt.Errorf("invalid sources.%s.%s in config: %w", kind, name, err)
+		}
+	}
+	logInfo("⚙️  Applied %s settings from %s", kind, configPath)
+	return nil
+}
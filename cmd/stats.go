@@ -0,0 +1,274 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	statsFormat string
+	statsTop    int
+	statsDrafts bool
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Summarize the site's posts",
+	Long: `Summarizes the posts under content/posts: posts per month and per tag,
+average word count, how many have hero images and descriptions, and how many
+were AI-generated versus written by hand.
+
+A post counts as AI-generated when its front matter has ai_generated: true or
+megafone's journal (.megafone/journal.jsonl) recorded generating it.
+
+Examples:
+  megafone stats -s ~/hugo
+  megafone stats -s ~/hugo --top 30 --format json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runStats(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+
+	statsCmd.Flags().StringVarP(&siteSource, "site-source", "s", "", "Path to local Hugo site repository")
+	statsCmd.Flags().StringVar(&statsFormat, "format", "text", "Output format: text or json")
+	statsCmd.Flags().IntVar(&statsTop, "top", 15, "Number of tags to show")
+	statsCmd.Flags().BoolVar(&statsDrafts, "drafts", false, "Include drafts")
+}
+
+// siteStats is the JSON form of the stats report
+type siteStats struct {
+	Posts          int            `json:"posts"`
+	Drafts         int            `json:"drafts"`
+	PerMonth       []countEntry   `json:"per_month"`
+	PerTag         []countEntry   `json:"per_tag"`
+	AvgWords       int            `json:"avg_words"`
+	MedianWords    int            `json:"median_words"`
+	WithHero       int            `json:"with_hero"`
+	WithoutHero    int            `json:"without_hero"`
+	WithDesc       int            `json:"with_description"`
+	WithoutDesc    int            `json:"without_description"`
+	AIGenerated    int            `json:"ai_generated"`
+	Manual         int            `json:"manual"`
+	ModelsUsed     map[string]int `json:"models_used,omitempty"`
+	UndatedPosts   int            `json:"undated"`
+	NoTags         int            `json:"without_tags"`
+	FirstPostMonth string         `json:"first_month,omitempty"`
+	LastPostMonth  string         `json:"last_month,omitempty"`
+}
+
+type countEntry struct {
+	Key   string `json:"key"`
+	Count int    `json:"count"`
+}
+
+func runStats() error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	basePath, err := inspectSitePath()
+	if err != nil {
+		return err
+	}
+	all, err := loadSitePosts(basePath)
+	if err != nil {
+		return fmt.Errorf("failed to read posts: %w", err)
+	}
+	generated, models := journalGeneratedPosts(basePath)
+
+	stats := siteStats{ModelsUsed: models}
+	months := make(map[string]int)
+	tagCounts := make(map[string]int)
+	var words []int
+	for _, p := range all {
+		if p.Draft {
+			stats.Drafts++
+			if !statsDrafts {
+				continue
+			}
+		}
+		stats.Posts++
+
+		if t := p.Time(); t.IsZero() {
+			stats.UndatedPosts++
+		} else {
+			months[t.Format("2006-01")]++
+		}
+		for _, tag := range p.Tags {
+			tagCounts[strings.ToLower(tag)]++
+		}
+		if len(p.Tags) == 0 {
+			stats.NoTags++
+		}
+		words = append(words, p.WordCount())
+
+		if p.Hero != "" {
+			stats.WithHero++
+		} else {
+			stats.WithoutHero++
+		}
+		if p.Description != "" {
+			stats.WithDesc++
+		} else {
+			stats.WithoutDesc++
+		}
+		if rel, err := filepath.Rel(basePath, p.Path); p.AIGenerated || (err == nil && generated[filepath.ToSlash(rel)]) {
+			stats.AIGenerated++
+		} else {
+			stats.Manual++
+		}
+	}
+
+	stats.PerMonth = sortedCounts(months, false)
+	if len(stats.PerMonth) > 0 {
+		stats.FirstPostMonth = stats.PerMonth[0].Key
+		stats.LastPostMonth = stats.PerMonth[len(stats.PerMonth)-1].Key
+
+		// Show quiet months as zero rather than skipping them
+		first, _, _ := parsePeriod(stats.FirstPostMonth)
+		last, _, _ := parsePeriod(stats.LastPostMonth)
+		for m := first; !m.After(last); m = m.AddDate(0, 1, 0) {
+			months[m.Format("2006-01")] += 0
+		}
+		stats.PerMonth = sortedCounts(months, false)
+	}
+	stats.PerTag = sortedCounts(tagCounts, true)
+	if statsTop > 0 && len(stats.PerTag) > statsTop {
+		stats.PerTag = stats.PerTag[:statsTop]
+	}
+	if len(words) > 0 {
+		total := 0
+		for _, w := range words {
+			total += w
+		}
+		sort.Ints(words)
+		stats.AvgWords = total / len(words)
+		stats.MedianWords = words[len(words)/2]
+	}
+
+	switch statsFormat {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(stats)
+	case "text":
+		printStats(stats)
+		return nil
+	default:
+		return fmt.Errorf("invalid --format value %q (use text or json)", statsFormat)
+	}
+}
+
+// journalGeneratedPosts returns the site-relative paths megafone's journal
+// recorded as AI-generated, and how many posts each model wrote
+func journalGeneratedPosts(basePath string) (map[string]bool, map[string]int) {
+	generated := make(map[string]bool)
+	models := make(map[string]int)
+
+	f, err := os.Open(journalPath(basePath))
+	if err != nil {
+		return generated, models
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry journalEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) != nil {
+			continue
+		}
+		if entry.Action != "created" || !entry.AIGenerated || filepath.Ext(entry.Path) != ".md" {
+			continue
+		}
+		if !generated[entry.Path] && entry.Model != "" {
+			models[entry.Model]++
+		}
+		generated[entry.Path] = true
+	}
+	return generated, models
+}
+
+// sortedCounts orders a tally by key, or by count (highest first) when byCount is set
+func sortedCounts(counts map[string]int, byCount bool) []countEntry {
+	entries := make([]countEntry, 0, len(counts))
+	for k, n := range counts {
+		entries = append(entries, countEntry{Key: k, Count: n})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if byCount && entries[i].Count != entries[j].Count {
+			return entries[i].Count > entries[j].Count
+		}
+		return entries[i].Key < entries[j].Key
+	})
+	return entries
+}
+
+func printStats(s siteStats) {
+	fmt.Printf("📊 %d posts", s.Posts)
+	if s.Drafts > 0 && !statsDrafts {
+		fmt.Printf(" (+%d drafts)", s.Drafts)
+	}
+	if s.FirstPostMonth != "" {
+		fmt.Printf(", %s to %s", s.FirstPostMonth, s.LastPostMonth)
+	}
+	fmt.Println()
+	if s.Posts == 0 {
+		return
+	}
+
+	fmt.Printf("\nWords per post: %d average, %d median\n", s.AvgWords, s.MedianWords)
+	fmt.Printf("Hero images:    %s\n", ratio(s.WithHero, s.Posts))
+	fmt.Printf("Descriptions:   %s\n", ratio(s.WithDesc, s.Posts))
+	fmt.Printf("Tagged:         %s\n", ratio(s.Posts-s.NoTags, s.Posts))
+	fmt.Printf("AI-generated:   %s (%d manual)\n", ratio(s.AIGenerated, s.Posts), s.Manual)
+	if len(s.ModelsUsed) > 0 {
+		var parts []string
+		for _, e := range sortedCounts(s.ModelsUsed, true) {
+			parts = append(parts, fmt.Sprintf("%s %d", e.Key, e.Count))
+		}
+		fmt.Printf("Models:         %s\n", strings.Join(parts, ", "))
+	}
+
+	fmt.Println("\nPosts per month:")
+	printBars(s.PerMonth)
+	if s.UndatedPosts > 0 {
+		fmt.Printf("  %d posts have no date\n", s.UndatedPosts)
+	}
+
+	if len(s.PerTag) > 0 {
+		fmt.Println("\nTop tags:")
+		printBars(s.PerTag)
+	}
+}
+
+func ratio(n, total int) string {
+	return fmt.Sprintf("%d/%d (%d%%)", n, total, n*100/total)
+}
+
+// printBars draws a horizontal bar per entry, scaled to the largest count
+func printBars(entries []countEntry) {
+	maxCount, width := 0, 0
+	for _, e := range entries {
+		maxCount = max(maxCount, e.Count)
+		width = max(width, len(e.Key))
+	}
+	for _, e := range entries {
+		bar := ""
+		if e.Count > 0 {
+			bar = strings.Repeat("█", max(e.Count*40/maxCount, 1))
+		}
+		fmt.Printf("  %-*s %s %d\n", width, e.Key, bar, e.Count)
+	}
+}
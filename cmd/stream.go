@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+var quietStream bool
+
+// streamKey marks a context whose chat requests write the post itself, the
+// replies worth watching as they arrive
+type streamKey struct{}
+
+func init() {
+	rootCmd.PersistentFlags().BoolVarP(&quietStream, "quiet", "q", false, "Don't show the post as the model writes it")
+}
+
+// withStreaming marks ctx so the reply to a chat request sent with it is
+// streamed and shown as it's written
+func withStreaming(ctx context.Context) context.Context {
+	return context.WithValue(ctx, streamKey{}, true)
+}
+
+func stderrIsTerminal() bool {
+	info, err := os.Stderr.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// replyStream returns where to show a streamed reply, and a func to call when
+// it ends: the tail line of the live progress display, or else the text itself
+// on stderr. show is nil when the reply shouldn't be streamed: the request
+// isn't for a post, --quiet is set, or there's no terminal to watch it on.
+func replyStream(ctx context.Context) (show func(chunk string), done func()) {
+	if streaming, _ := ctx.Value(streamKey{}).(bool); !streaming || quietStream {
+		return nil, nil
+	}
+	if progressLive() {
+		progressStreamReset()
+		return progressStreamText, progressStreamReset
+	}
+	if !stderrIsTerminal() {
+		return nil, nil
+	}
+
+	endsInNewline := true
+	show = func(chunk string) {
+		fmt.Fprint(os.Stderr, chunk)
+		endsInNewline = strings.HasSuffix(chunk, "\n")
+	}
+	done = func() {
+		if !endsInNewline {
+			fmt.Fprintln(os.Stderr)
+		}
+	}
+	fmt.Fprintln(os.Stderr)
+	return show, done
+}
+
+// openaiStreamCompletion sends req as a streaming request, passing each piece
+// of the reply to show, and assembles the pieces into a regular response
+func openaiStreamCompletion(ctx context.Context, client *openai.Client, req openai.ChatCompletionRequest, show func(string)) (openai.ChatCompletionResponse, error) {
+	req.Stream = true
+	req.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+	stream, err := client.CreateChatCompletionStream(ctx, req)
+	if err != nil {
+		return openai.ChatCompletionResponse{}, err
+	}
+	defer stream.Close()
+
+	completion := openai.ChatCompletionResponse{Model: req.Model}
+	var text strings.Builder
+	var finish openai.FinishReason
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return openai.ChatCompletionResponse{}, err
+		}
+		if chunk.Model != "" {
+			completion.Model = chunk.Model
+		}
+		if chunk.Usage != nil {
+			completion.Usage = *chunk.Usage
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content != "" {
+				text.WriteString(choice.Delta.Content)
+				show(choice.Delta.Content)
+			}
+			if choice.FinishReason != "" {
+				finish = choice.FinishReason
+			}
+		}
+	}
+
+	completion.Choices = []openai.ChatCompletionChoice{{
+		Message:      openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: text.String()},
+		FinishReason: finish,
+	}}
+	completion.SetHeader(stream.Header())
+	return completion, nil
+}
+
+// anthropicStreamEvent is one server-sent event of a streamed Messages reply
+type anthropicStreamEvent struct {
+	Type    string `json:"type"`
+	Message struct {
+		Model string `json:"model"`
+		Usage struct {
+			InputTokens int `json:"input_tokens"`
+		} `json:"usage"`
+	} `json:"message"`
+	Delta struct {
+		Type       string `json:"type"`
+		Text       string `json:"text"`
+		StopReason string `json:"stop_reason"`
+	} `json:"delta"`
+	Usage struct {
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// anthropicStreamCompletion is anthropicChatCompletion over the streaming
+// Messages API, passing each piece of the reply to show
+func anthropicStreamCompletion(ctx context.Context, req openai.ChatCompletionRequest, show func(string)) (openai.ChatCompletionResponse, error) {
+	resp, err := anthropicPost(ctx, req, true)
+	if err != nil {
+		return openai.ChatCompletionResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	var model, stopReason string
+	var text strings.Builder
+	var inputTokens, outputTokens int
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var event anthropicStreamEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			continue
+		}
+		switch event.Type {
+		case "message_start":
+			model = event.Message.Model
+			inputTokens = event.Message.Usage.InputTokens
+		case "content_block_delta":
+			if event.Delta.Type == "text_delta" {
+				text.WriteString(event.Delta.Text)
+				show(event.Delta.Text)
+			}
+		case "message_delta":
+			stopReason = event.Delta.StopReason
+			outputTokens = event.Usage.OutputTokens
+		case "error":
+			return openai.ChatCompletionResponse{}, fmt.Errorf("Anthropic API error: %s: %s", event.Error.Type, event.Error.Message)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return openai.ChatCompletionResponse{}, fmt.Errorf("Anthropic stream interrupted: %w", err)
+	}
+
+	completion := anthropicCompletion(firstNonEmpty(model, req.Model), text.String(), stopReason, inputTokens, outputTokens)
+	completion.SetHeader(resp.Header)
+	return completion, nil
+}
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var fromStub string
+
+// postStub is an idea queued in the site repo as a markdown file whose front
+// matter carries the generation directives for that post
+type postStub struct {
+	Path        string
+	Topic       string
+	Sources     []string
+	Tags        []string
+	Tone        string
+	Length      string
+	Image       string
+	ImageSource string
+	Prompt      string
+	Model       string
+	Notes       string
+}
+
+// loadPostStub reads a stub file's front matter directives and body notes
+func loadPostStub(path string) (*postStub, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stub: %w", err)
+	}
+	content := string(data)
+
+	fm := frontMatterBlock(content)
+	if fm == "" {
+		return nil, fmt.Errorf("stub %s has no front matter", path)
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	stub := &postStub{
+		Path:        absPath,
+		Topic:       frontMatterString(content, "topic"),
+		Sources:     frontMatterList(content, "sources"),
+		Tags:        frontMatterList(content, "tags"),
+		Tone:        frontMatterString(content, "tone"),
+		Length:      frontMatterString(content, "length"),
+		Image:       frontMatterString(content, "image"),
+		ImageSource: frontMatterString(content, "image_source"),
+		Prompt:      frontMatterString(content, "prompt"),
+		Model:       frontMatterString(content, "model"),
+		Notes:       strings.TrimSpace(content[len(fm)+len("\n---"):]),
+	}
+
+	if stub.Topic == "" {
+		return nil, fmt.Errorf("stub %s is missing a topic field", path)
+	}
+	return stub, nil
+}
+
+// apply copies the stub's directives into the generate settings. Flags given
+// explicitly on the command line win over the stub.
+func (s *postStub) apply(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	if !flags.Changed("topic") {
+		topicURL = s.Topic
+	}
+	if !flags.Changed("tags") && len(s.Tags) > 0 {
+		tags = strings.Join(s.Tags, ",")
+	}
+	if !flags.Changed("image") && s.Image != "" {
+		imagePath = s.resolvePath(s.Image)
+	}
+	if !flags.Changed("image-source") && s.ImageSource != "" {
+		imageSource = s.ImageSource
+	}
+	if !flags.Changed("prompt") && s.Prompt != "" {
+		promptFile = s.Prompt
+	}
+	if !flags.Changed("model") && s.Model != "" {
+		model = s.Model
+	}
+	if siteSource == "" {
+		siteSource = findSiteRoot(filepath.Dir(s.Path))
+	}
+}
+
+// resolvePath resolves paths in the stub relative to the stub file itself
+func (s *postStub) resolvePath(p string) string {
+	if filepath.IsAbs(p) || strings.HasPrefix(p, "~") {
+		return p
+	}
+	return filepath.Join(filepath.Dir(s.Path), p)
+}
+
+// directives renders the stub's tone, length, notes, and extra sources as an
+// addition to the prompt template
+func (s *postStub) directives() string {
+	var b strings.Builder
+	b.WriteString("\n\n## Post Directives (from the author's stub - follow these over the defaults above)\n")
+	if s.Tone != "" {
+		b.WriteString(fmt.Sprintf("- Tone: %s\n", s.Tone))
+	}
+	if s.Length != "" {
+		b.WriteString(fmt.Sprintf("- Target length: %s\n", s.Length))
+	}
+	if s.Notes != "" {
+		b.WriteString("\n### Author's notes\n")
+		b.WriteString(s.Notes)
+		b.WriteString("\n")
+	}
+
+	for _, source := range s.Sources {
+		logInfo("🔗 Fetching stub source: %s", source)
+		text, meta, _, err := fetchWebsiteContent(source)
+		if err != nil {
+			logError("Failed to fetch stub source %s: %v", source, err)
+			continue
+		}
+		// Keep each additional source small so the primary topic dominates
+		if len(text) > 8000 {
+			text = text[:8000] + "... [source truncated]"
+		}
+		b.WriteString(fmt.Sprintf("\n### Additional source: %s (%s)\n%s\n", meta.Title, source, text))
+	}
+
+	return b.String()
+}
+
+// findSiteRoot walks up from dir to the first directory containing content/
+func findSiteRoot(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		if info, err := os.Stat(filepath.Join(dir, "content")); err == nil && info.IsDir() {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,307 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/spf13/cobra"
+)
+
+var (
+	summarizeLength  int
+	summarizeFields  []string
+	summarizeRefresh bool
+)
+
+// summaryFields maps each summary kind to the front matter field it's written to
+var summaryFields = map[string]string{
+	"meta":   "description",
+	"rss":    "summary",
+	"social": "social_blurb",
+}
+
+var summarizeCmd = &cobra.Command{
+	Use:   "summarize <post>",
+	Short: "Write meta description, RSS summary, and social blurb for a post",
+	Long: `Produces three summaries of a post in one model call and writes them to its
+front matter:
+
+  meta    description   search snippet, at most --length characters
+  rss     summary       two or three sentences for feed readers (Hugo's .Summary)
+  social  social_blurb  a hook for social posts, under 240 characters
+
+With --fields highlights it also picks 2-3 sentences from the post, quoted
+word for word, for pull quotes on social posts and share cards (highlights).
+Generated posts get these automatically.
+
+The model's condensed notes on the post are cached in .megafone/cache, so
+re-running with a different --length or --fields sends the notes instead of
+the whole post. Editing the post invalidates the cache; --refresh ignores it.
+
+Examples:
+  megafone summarize content/posts/en/my-post.md
+  megafone summarize content/posts/en/my-post.md --length 140 --fields meta,social`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runSummarize(cmd, args[0]); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(summarizeCmd)
+
+	summarizeCmd.Flags().IntVar(&summarizeLength, "length", 160, "Maximum meta description length in characters")
+	summarizeCmd.Flags().StringSliceVar(&summarizeFields, "fields", []string{"meta", "rss", "social"}, "Summaries to write: meta, rss, social, highlights")
+	summarizeCmd.Flags().BoolVar(&summarizeRefresh, "refresh", false, "Re-read the full post even if cached context is available")
+	summarizeCmd.Flags().StringVarP(&siteSource, "site-source", "s", "", "Path to local Hugo site repository (default: found from the post path)")
+	summarizeCmd.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "Print the summaries without writing the post")
+	summarizeCmd.Flags().StringVarP(&model, "model", "m", "gpt-4o-mini", "OpenAI model to use")
+}
+
+// postContext is the model's condensed reading of a post, cached so later
+// requests about the same post don't resend its full text
+type postContext struct {
+	Hash      string `json:"hash"`
+	Model     string `json:"model"`
+	KeyPoints string `json:"key_points"`
+	Created   string `json:"created"`
+}
+
+// postSummaries is the JSON the model returns
+type postSummaries struct {
+	KeyPoints string `json:"key_points,omitempty"`
+	Meta      string `json:"meta"`
+	RSS       string `json:"rss"`
+	Social    string `json:"social"`
+}
+
+func runSummarize(cmd *cobra.Command, postPath string) error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	for _, f := range summarizeFields {
+		if _, ok := summaryFields[f]; !ok && f != "highlights" {
+			return fmt.Errorf("invalid --fields value %q (use meta, rss, social, or highlights)", f)
+		}
+	}
+	if summarizeLength < 50 {
+		return fmt.Errorf("--length must be at least 50")
+	}
+
+	apiKey, err := getOpenAIKey(cmd)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(postPath)
+	if err != nil {
+		return fmt.Errorf("failed to read post: %w", err)
+	}
+	content := string(data)
+
+	basePath := siteSource
+	if basePath == "" {
+		basePath = findSiteRoot(filepath.Dir(postPath))
+	}
+	slug := strings.TrimSuffix(filepath.Base(postPath), filepath.Ext(postPath))
+	hash := postContextHash(content)
+
+	var cached *postContext
+	if basePath != "" && !summarizeRefresh {
+		cached = loadPostContext(basePath, slug, hash)
+	}
+
+	ctx := context.Background()
+	client := openai.NewClient(apiKey)
+	var summaries postSummaries
+	if wantSummaries() {
+		summaries, err = writePostSummaries(ctx, client, content, cached)
+		if err != nil {
+			return classify(ErrGeneration, err)
+		}
+	}
+	summaries.Meta = trimToLength(summaries.Meta, summarizeLength)
+	summaries.Social = trimToLength(summaries.Social, 240)
+
+	if cached == nil && basePath != "" && summaries.KeyPoints != "" {
+		if err := savePostContext(basePath, slug, postContext{
+			Hash:      hash,
+			Model:     effectiveModel(),
+			KeyPoints: summaries.KeyPoints,
+			Created:   time.Now().UTC().Format(time.RFC3339),
+		}); err != nil {
+			logError("Failed to cache post context: %v", err)
+		}
+	}
+
+	values := map[string]string{"meta": summaries.Meta, "rss": summaries.RSS, "social": summaries.Social}
+	for _, f := range summarizeFields {
+		if f == "highlights" {
+			// Quotes have to come from the post itself, not the cached notes
+			highlights, err := extractHighlights(ctx, client, content)
+			if err != nil {
+				return classify(ErrGeneration, fmt.Errorf("failed to extract highlights: %w", err))
+			}
+			if len(highlights) == 0 {
+				logInfo("⚠️  Model returned no highlights found in the post")
+			} else if dryRun {
+				fmt.Printf("highlights:\n  - %s\n\n", strings.Join(highlights, "\n  - "))
+			} else {
+				content = setFrontMatterList(content, "highlights", highlights)
+			}
+			continue
+		}
+		if values[f] == "" {
+			logInfo("⚠️  Model returned no %s summary", f)
+			continue
+		}
+		if dryRun {
+			fmt.Printf("%s (%d chars):\n  %s\n\n", summaryFields[f], len([]rune(values[f])), values[f])
+			continue
+		}
+		content = upsertFrontMatterField(content, summaryFields[f], yamlQuote(values[f]))
+	}
+	if dryRun {
+		return nil
+	}
+
+	if err := os.WriteFile(postPath, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write post: %w", err)
+	}
+	recordSiteChange("modified", postPath, "", true)
+	logSuccess("✅ Summaries written to %s", postPath)
+	return nil
+}
+
+// wantSummaries reports whether --fields asks for anything writePostSummaries produces
+func wantSummaries() bool {
+	for _, f := range summarizeFields {
+		if _, ok := summaryFields[f]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// writePostSummaries asks for every summary length at once. With cached context the
+// model works from its earlier notes rather than the full post.
+func writePostSummaries(ctx context.Context, client *openai.Client, content string, cached *postContext) (postSummaries, error) {
+	title := frontMatterString(content, "title")
+	var source, notesField string
+	if cached != nil {
+		logInfo("♻️  Reusing cached context for this post")
+		source = "Notes from an earlier reading of the post:\n" + cached.KeyPoints
+	} else {
+		body := anyShortcodeRegex.ReplaceAllString(postBody(content), "")
+		source = "Post:\n" + summarizeTokens(6000, body)
+		notesField = `  "key_points": "the post's main points, claims, and any specific names or numbers, as terse notes under 150 words",` + "\n"
+	}
+
+	prompt := fmt.Sprintf(`Title: %s
+
+%s
+
+Write summaries of this blog post and respond with only a JSON object:
+{
+%s  "meta": "meta description for search results, at most %d characters, plain and specific, no clickbait",
+  "rss": "two or three sentences for feed readers saying what the post covers and why it matters",
+  "social": "a hook for a social media post, under 240 characters, no hashtags, no emoji"
+}`, title, source, notesField, summarizeLength)
+
+	resp, err := createChatCompletion(ctx, client, openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: "You write concise, accurate summaries of technical blog posts in the author's voice. You never invent facts that aren't in the post. You output only JSON.",
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: prompt,
+			},
+		},
+		Temperature: 0.4,
+	})
+	if err != nil {
+		return postSummaries{}, fmt.Errorf("failed to summarize post: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return postSummaries{}, fmt.Errorf("no response from model")
+	}
+
+	var summaries postSummaries
+	if err := decodeModelJSON(resp.Choices[0].Message.Content, &summaries); err != nil {
+		return postSummaries{}, fmt.Errorf("failed to parse summaries: %w", err)
+	}
+	return summaries, nil
+}
+
+// decodeModelJSON parses a JSON object from a model reply, tolerating code fences
+// and text around the object
+func decodeModelJSON(reply string, v interface{}) error {
+	start := strings.Index(reply, "{")
+	end := strings.LastIndex(reply, "}")
+	if start == -1 || end < start {
+		return fmt.Errorf("no JSON object in reply")
+	}
+	return json.Unmarshal([]byte(reply[start:end+1]), v)
+}
+
+// trimToLength shortens s to at most n characters at a word boundary
+func trimToLength(s string, n int) string {
+	s = strings.TrimSpace(s)
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	cut := string(runes[:n-1])
+	if i := strings.LastIndex(cut, " "); i > n/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,;:-") + "…"
+}
+
+func postContextHash(content string) string {
+	sum := sha256.Sum256([]byte(frontMatterString(content, "title") + "\n" + postBody(content)))
+	return hex.EncodeToString(sum[:])
+}
+
+func postContextPath(basePath, slug string) string {
+	return workspacePath(basePath, "cache", "post-context", slug+".json")
+}
+
+// loadPostContext returns the cached context for a post, or nil if there is none
+// or the post has changed since it was cached
+func loadPostContext(basePath, slug, hash string) *postContext {
+	data, err := os.ReadFile(postContextPath(basePath, slug))
+	if err != nil {
+		return nil
+	}
+	var pc postContext
+	if json.Unmarshal(data, &pc) != nil || pc.Hash != hash || pc.KeyPoints == "" {
+		return nil
+	}
+	return &pc
+}
+
+func savePostContext(basePath, slug string, pc postContext) error {
+	path := postContextPath(basePath, slug)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(pc, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
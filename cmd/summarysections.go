@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	placementTop              = "top"
+	placementAfterIntro       = "after-intro"
+	placementBeforeConclusion = "before-conclusion"
+	placementBottom           = "bottom"
+)
+
+var (
+	addTLDR      bool
+	addTakeaways bool
+)
+
+// conclusionHeadingRegex matches the H2s models usually end a post with
+var conclusionHeadingRegex = regexp.MustCompile(`(?i)^##\s+.*\b(conclusion|wrapping up|wrap-up|final thoughts|summary|closing thoughts|next steps|takeaways?)\b`)
+
+// summarySectionsConfig is the summary_sections block of megafone.yaml. Each
+// section is added to generated posts whose content type is listed in types
+// ("all" for every type); --tldr and --takeaways override that for one run.
+type summarySectionsConfig struct {
+	TLDR      summarySectionConfig `yaml:"tldr"`
+	Takeaways summarySectionConfig `yaml:"takeaways"`
+}
+
+type summarySectionConfig struct {
+	Types     []string `yaml:"types"`
+	Placement string   `yaml:"placement"` // top, after-intro, before-conclusion, or bottom
+	Heading   string   `yaml:"heading"`
+	Count     int      `yaml:"count"` // takeaways only
+}
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&addTLDR, "tldr", false, "Add a one-paragraph TL;DR to generated posts (default: summary_sections.tldr.types in config)")
+	rootCmd.PersistentFlags().BoolVar(&addTakeaways, "takeaways", false, "Add a key takeaways list to generated posts (default: summary_sections.takeaways.types in config)")
+}
+
+// enabled reports whether the section is wanted for a content type. An
+// explicit flag wins over the config.
+func (c summarySectionConfig) enabled(flag, contentType string) bool {
+	if rootCmd.PersistentFlags().Changed(flag) {
+		value, _ := rootCmd.PersistentFlags().GetBool(flag)
+		return value
+	}
+	for _, t := range c.Types {
+		if t == "all" || t == contentType {
+			return true
+		}
+	}
+	return false
+}
+
+// applySummarySections adds the TL;DR and key takeaways configured for a
+// content type. Both are written from the finished post, not its source, in
+// one call. A failed call is logged and the post is kept as it is.
+func applySummarySections(ctx context.Context, client *openai.Client, content, contentType string) (string, error) {
+	cfg := appConfig.SummarySections
+	wantTLDR := cfg.TLDR.enabled("tldr", contentType)
+	wantTakeaways := cfg.Takeaways.enabled("takeaways", contentType)
+	if !wantTLDR && !wantTakeaways {
+		return content, nil
+	}
+
+	tldrPlacement := firstNonEmpty(cfg.TLDR.Placement, placementTop)
+	takeawaysPlacement := firstNonEmpty(cfg.Takeaways.Placement, placementBeforeConclusion)
+	for name, p := range map[string]string{"tldr": tldrPlacement, "takeaways": takeawaysPlacement} {
+		switch p {
+		case placementTop, placementAfterIntro, placementBeforeConclusion, placementBottom:
+		default:
+			return content, fmt.Errorf("invalid summary_sections.%s.placement %q (use top, after-intro, before-conclusion, or bottom)", name, p)
+		}
+	}
+
+	tldrHeading := firstNonEmpty(cfg.TLDR.Heading, "TL;DR")
+	takeawaysHeading := firstNonEmpty(cfg.Takeaways.Heading, "Key takeaways")
+	body := strings.ToLower(postBody(content))
+	// The model sometimes writes these itself
+	wantTLDR = wantTLDR && !strings.Contains(body, strings.ToLower(tldrHeading))
+	wantTakeaways = wantTakeaways && !strings.Contains(body, "## "+strings.ToLower(takeawaysHeading))
+	if !wantTLDR && !wantTakeaways {
+		return content, nil
+	}
+
+	count := cfg.Takeaways.Count
+	if count <= 0 {
+		count = 4
+	}
+	logInfo("📌 Writing summary sections...")
+	sections, err := writeSummarySections(ctx, client, content, count)
+	if err != nil {
+		logError("Failed to write summary sections: %v", err)
+		logInfo("Continuing without them...")
+		return content, nil
+	}
+
+	if wantTakeaways && len(sections.Takeaways) > 0 {
+		var list strings.Builder
+		fmt.Fprintf(&list, "## %s\n\n", takeawaysHeading)
+		for i, t := range sections.Takeaways {
+			if i == count {
+				break
+			}
+			fmt.Fprintf(&list, "- %s\n", strings.TrimSpace(strings.TrimPrefix(t, "- ")))
+		}
+		content = placeSection(content, strings.TrimRight(list.String(), "\n"), takeawaysPlacement)
+	}
+	if wantTLDR && sections.TLDR != "" {
+		content = placeSection(content, fmt.Sprintf("> **%s** %s", tldrHeading, strings.TrimSpace(sections.TLDR)), tldrPlacement)
+	}
+	return content, nil
+}
+
+type summarySections struct {
+	TLDR      string   `json:"tldr"`
+	Takeaways []string `json:"takeaways"`
+}
+
+func writeSummarySections(ctx context.Context, client *openai.Client, content string, count int) (summarySections, error) {
+	prompt := fmt.Sprintf(`Title: %s
+
+Post:
+%s
+
+Respond with only a JSON object:
+{
+  "tldr": "one paragraph of two or three sentences saying what the post covers and what the reader should take from it",
+  "takeaways": ["%d short bullet points, each a specific, self-contained takeaway from the post"]
+}`, frontMatterString(content, "title"), summarizeTokens(8000, postBody(content)), count)
+
+	resp, err := createChatCompletion(ctx, client, openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: "You summarize technical blog posts in the author's voice. Everything you write is stated in the post; you never add facts. You output only JSON.",
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: prompt,
+			},
+		},
+		Temperature: 0.3,
+	})
+	if err != nil {
+		return summarySections{}, err
+	}
+	if len(resp.Choices) == 0 {
+		return summarySections{}, fmt.Errorf("no response from model")
+	}
+
+	var sections summarySections
+	if err := decodeModelJSON(resp.Choices[0].Message.Content, &sections); err != nil {
+		return summarySections{}, fmt.Errorf("failed to parse summary sections: %w", err)
+	}
+	return sections, nil
+}
+
+// placeSection inserts a block into a post's body. after-intro goes before the
+// first H2 and before-conclusion before a closing H2 like "Conclusion"; each
+// falls back to the top or bottom when the post has no such heading.
+func placeSection(content, section, placement string) string {
+	fm := frontMatterBlock(content)
+	head := ""
+	if fm != "" {
+		head = fm + "\n---\n\n"
+	}
+	lines := strings.Split(strings.TrimRight(postBody(content), "\n"), "\n")
+
+	insertAt := -1
+	switch placement {
+	case placementTop:
+		insertAt = 0
+	case placementAfterIntro, placementBeforeConclusion:
+		fence := false
+		for i, line := range lines {
+			if strings.HasPrefix(strings.TrimSpace(line), "```") {
+				fence = !fence
+			}
+			if fence || !strings.HasPrefix(line, "## ") {
+				continue
+			}
+			if placement == placementAfterIntro {
+				insertAt = i
+				break
+			}
+			if conclusionHeadingRegex.MatchString(line) {
+				insertAt = i
+			}
+		}
+		if insertAt == -1 && placement == placementAfterIntro {
+			insertAt = 0
+		}
+	}
+
+	if insertAt == -1 {
+		return head + strings.Join(lines, "\n") + "\n\n" + section + "\n"
+	}
+	before := strings.TrimRight(strings.Join(lines[:insertAt], "\n"), "\n")
+	after := strings.Join(lines[insertAt:], "\n")
+	if before != "" {
+		before += "\n\n"
+	}
+	return head + before + section + "\n\n" + after + "\n"
+}
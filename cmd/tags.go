@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// tagsConfig is the tags section of megafone.yaml
+type tagsConfig struct {
+	// Aliases maps variant spellings to the tag to use instead, after case
+	// and separators are normalized, e.g. golang: go
+	Aliases map[string]string `yaml:"aliases"`
+}
+
+var (
+	tagsNormalizeFields []string
+	tagsNormalizeDrafts bool
+)
+
+var tagsCmd = &cobra.Command{
+	Use:   "tags",
+	Short: "Manage post tags",
+}
+
+var tagsNormalizeCmd = &cobra.Command{
+	Use:   "normalize",
+	Short: "Rewrite every post's tags in one consistent form",
+	Long: `Lowercases tags, turns spaces and underscores into hyphens, maps variant
+spellings through tags.aliases in megafone.yaml, and drops duplicates, across
+every post in the site.
+
+All edits are staged first and shown as a diff; nothing changes until you
+confirm, and the whole run can be reverted with 'megafone transactions undo'.
+
+Examples:
+  # Preview the changes
+  megafone tags normalize -s ~/hugo --dry-run
+
+  megafone tags normalize -s ~/hugo --field tags --field categories --yes`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runTagsNormalize(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(tagsCmd)
+	tagsCmd.AddCommand(tagsNormalizeCmd)
+
+	tagsNormalizeCmd.Flags().StringVarP(&siteSource, "site-source", "s", "", "Path to local Hugo site repository")
+	tagsNormalizeCmd.Flags().StringArrayVar(&tagsNormalizeFields, "field", []string{"tags"}, "Front matter list to normalize (repeatable)")
+	tagsNormalizeCmd.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "Show the changes without making them")
+	tagsNormalizeCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Apply without asking for confirmation")
+	tagsNormalizeCmd.Flags().BoolVar(&tagsNormalizeDrafts, "drafts", false, "Include drafts")
+}
+
+// canonicalTag returns the canonical form of a tag. Aliases apply once, so
+// they can't chain or loop.
+func canonicalTag(tag string) string {
+	tag = tagForm(tag)
+	for alias, canonical := range appConfig.Tags.Aliases {
+		if tagForm(alias) == tag {
+			return tagForm(canonical)
+		}
+	}
+	return tag
+}
+
+// tagForm lowercases a tag and joins its words with single hyphens
+func tagForm(tag string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(tag), func(r rune) bool {
+		return r == ' ' || r == '_' || r == '-'
+	}), "-")
+}
+
+// canonicalTags normalizes a list of tags, keeping the first of any duplicates
+func canonicalTags(tags []string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, tag := range tags {
+		tag = canonicalTag(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		out = append(out, tag)
+	}
+	return out
+}
+
+func runTagsNormalize() error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+
+	basePath, err := resolveSitePath()
+	if err != nil {
+		return err
+	}
+	posts, err := loadSitePosts(basePath)
+	if err != nil {
+		return fmt.Errorf("failed to read posts: %w", err)
+	}
+
+	txn := newSiteTxn(basePath, "tags normalize")
+	for _, post := range posts {
+		if post.Draft && !tagsNormalizeDrafts {
+			continue
+		}
+		data, err := os.ReadFile(post.Path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", post.Path, err)
+		}
+		content := string(data)
+		updated := content
+		for _, field := range tagsNormalizeFields {
+			tags := frontMatterList(updated, field)
+			if len(tags) == 0 {
+				continue
+			}
+			if normalized := canonicalTags(tags); !slices.Equal(tags, normalized) {
+				updated = setFrontMatterList(updated, field, normalized)
+			}
+		}
+		if updated != content {
+			if err := txn.write(post.Path, []byte(updated), "", post.AIGenerated); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = applyTxn(txn)
+	return err
+}
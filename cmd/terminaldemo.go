@@ -0,0 +1,290 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/sashabaranov/go-openai"
+)
+
+// Terminal demo modes for --terminal-demo
+const (
+	terminalDemoOff      = "off"
+	terminalDemoDetect   = "detect"   // embed an asciinema recording or VHS render from the repo
+	terminalDemoGenerate = "generate" // detect, else write a VHS tape and record it
+)
+
+var terminalDemo string
+
+var (
+	asciinemaRegex  = regexp.MustCompile(`https?://asciinema\.org/a/([A-Za-z0-9]+)`)
+	tapeOutputRegex = regexp.MustCompile(`(?m)^\s*Output\s+"?([^"\s]+)"?`)
+	// demoNameRegex picks README GIFs that are likely terminal recordings
+	demoNameRegex = regexp.MustCompile(`(?i)demo|vhs|asciicast|cast|terminal|screencast|usage|preview`)
+)
+
+const asciinemaShortcode = `{{/* Installed by megafone: embeds an asciinema recording */}}
+<figure class="asciinema">
+  <script src="https://asciinema.org/a/{{ .Get "id" }}.js" id="asciicast-{{ .Get "id" }}" async></script>
+  <noscript><a href="https://asciinema.org/a/{{ .Get "id" }}"><img src="https://asciinema.org/a/{{ .Get "id" }}.svg" alt="Terminal recording"></a></noscript>
+  {{ with .Get "caption" }}<figcaption>{{ . }}</figcaption>{{ end }}
+</figure>
+`
+
+// embedTerminalDemo adds a recording of the project's CLI to a repo post:
+// an asciinema recording or VHS render the repo already has, or with
+// --terminal-demo generate, one recorded from a VHS tape the model writes
+func embedTerminalDemo(ctx context.Context, apiKey, content, owner, repo, readme, heroURL, basePath string) (string, error) {
+	gh := newGitHubClient()
+	repoData, _, err := gh.Repositories.Get(ctx, owner, repo)
+	if err != nil {
+		return content, fmt.Errorf("failed to fetch repository: %w", err)
+	}
+	branch := firstNonEmpty(repoData.GetDefaultBranch(), "main")
+	caption := fmt.Sprintf("%s in the terminal", repo)
+	baseName := strings.ToLower(repo) + "-demo"
+
+	if m := asciinemaRegex.FindStringSubmatch(readme); m != nil {
+		logInfo("📼 Embedding asciinema recording %s", m[0])
+		if dryRun {
+			return content, nil
+		}
+		if err := ensureSiteShortcode(basePath, "asciinema", asciinemaShortcode); err != nil {
+			return content, err
+		}
+		return insertAfterFirstParagraph(content, fmt.Sprintf(`{{< asciinema id="%s" caption=%q >}}`, m[1], caption)), nil
+	}
+
+	for _, u := range repoDemoMedia(ctx, gh, owner, repo, branch, readme) {
+		if u == heroURL {
+			// The hero is the demo and is already embedded
+			logVerbose("The hero image is the repo's demo: %s", u)
+			return content, nil
+		}
+		logInfo("📼 Embedding terminal demo %s", u)
+		if dryRun {
+			return content, nil
+		}
+		data, err := fetchRepoFile(u)
+		if err != nil {
+			logError("Failed to download demo %s: %v", u, err)
+			continue
+		}
+		return embedDemoMedia(content, data, baseName, caption, basePath)
+	}
+
+	if terminalDemo != terminalDemoGenerate {
+		logInfo("No terminal demo found in the repository")
+		return content, nil
+	}
+	data, err := recordTerminalDemo(ctx, apiKey, repoData, readme)
+	if err != nil || data == nil {
+		return content, err
+	}
+	return embedDemoMedia(content, data, baseName, caption, basePath)
+}
+
+// repoDemoMedia lists the repo's terminal recordings: the outputs of its VHS
+// tapes that are committed, then README GIFs named like demos
+func repoDemoMedia(ctx context.Context, gh *github.Client, owner, repo, branch, readme string) []string {
+	raw := func(p string) string {
+		return fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s/%s", owner, repo, branch, p)
+	}
+	var media []string
+
+	tree, _, err := gh.Git.GetTree(ctx, owner, repo, branch, true)
+	if err != nil {
+		logVerbose("Failed to list repository files: %v", err)
+	} else {
+		files := make(map[string]bool)
+		for _, e := range tree.Entries {
+			files[e.GetPath()] = true
+		}
+		for _, e := range tree.Entries {
+			p := e.GetPath()
+			if !strings.HasSuffix(p, ".tape") {
+				continue
+			}
+			tape, err := fetchRepoFile(raw(p))
+			if err != nil {
+				continue
+			}
+			// VHS resolves Output against where it runs: the repo root or the tape's directory
+			for _, m := range tapeOutputRegex.FindAllStringSubmatch(string(tape), -1) {
+				for _, out := range []string{path.Join(path.Dir(p), m[1]), path.Clean(m[1])} {
+					ext := strings.ToLower(path.Ext(out))
+					if files[out] && (ext == ".gif" || ext == ".mp4") {
+						media = append(media, raw(out))
+						break
+					}
+				}
+			}
+		}
+	}
+
+	for _, u := range extractImageURLsFromMarkdown(readme, owner, repo) {
+		if strings.HasSuffix(strings.ToLower(u), ".gif") && demoNameRegex.MatchString(path.Base(u)) {
+			media = append(media, u)
+		}
+	}
+	return media
+}
+
+// embedDemoMedia saves a GIF or MP4 recording to the site and embeds it after
+// the opening paragraph. GIFs become MP4s when ffmpeg is available, since
+// they are several times smaller.
+func embedDemoMedia(content string, data []byte, baseName, caption, basePath string) (string, error) {
+	video := ""
+	if isGIF(data) {
+		name, err := convertGIFToMP4(data, baseName, basePath)
+		if err != nil {
+			logVerbose("Keeping the demo as a GIF: %v", err)
+			gifName, err := writeSiteImage(basePath, baseName+".gif", data)
+			if err != nil {
+				return content, err
+			}
+			return insertAfterFirstParagraph(content, fmt.Sprintf("![%s](/images/site/%s)", caption, gifName)), nil
+		}
+		video = name
+	} else {
+		name, err := writeSiteImage(basePath, baseName+".mp4", data)
+		if err != nil {
+			return content, err
+		}
+		video = name
+	}
+	if err := ensureGIFVideoShortcode(basePath); err != nil {
+		return content, err
+	}
+	logSuccess("📼 Embedded terminal demo: %s", video)
+	return insertAfterFirstParagraph(content, fmt.Sprintf(`{{< gif-video src="/images/site/%s" caption=%q >}}`, video, caption)), nil
+}
+
+// recordTerminalDemo has the model write a VHS tape of the CLI's basic usage
+// and records it. The tape runs real commands on this machine, so it is only
+// recorded after being reviewed in the terminal; otherwise it's saved for a
+// manual run. It returns nil when nothing was recorded.
+func recordTerminalDemo(ctx context.Context, apiKey string, repoData *github.Repository, readme string) ([]byte, error) {
+	tape, err := writeDemoTape(ctx, apiKey, repoData, readme)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write VHS tape: %w", err)
+	}
+
+	vhs, lookErr := exec.LookPath("vhs")
+	if dryRun || lookErr != nil || !stdinIsTerminal() {
+		saved := filepath.Join(os.TempDir(), strings.ToLower(repoData.GetName())+"-demo.tape")
+		if err := os.WriteFile(saved, []byte(tape), 0644); err != nil {
+			return nil, err
+		}
+		switch {
+		case dryRun:
+			logInfo("📼 VHS tape (not recorded in dry run): %s", saved)
+		case lookErr != nil:
+			logInfo("📼 vhs isn't in PATH; the demo tape is saved at %s (https://github.com/charmbracelet/vhs)", saved)
+		default:
+			logInfo("📼 The demo tape runs commands on this machine, so it's only recorded interactively; review and run it with: vhs %s", saved)
+		}
+		return nil, nil
+	}
+
+	for {
+		fmt.Println("\n" + strings.Repeat("=", 80))
+		fmt.Println("VHS tape for the terminal demo (these commands will run on this machine):")
+		fmt.Println(strings.Repeat("=", 80))
+		fmt.Println(tape)
+		fmt.Println(strings.Repeat("=", 80))
+		choice := askChoice("Record this demo?", []string{"record", "edit", "Skip"}, "s")
+		if choice == "s" {
+			logInfo("Skipping the terminal demo")
+			return nil, nil
+		}
+		if choice == "r" {
+			break
+		}
+		if edited, err := editText(tape, ".tape"); err != nil {
+			return nil, err
+		} else if strings.TrimSpace(edited) != "" {
+			tape = edited
+		}
+	}
+
+	tmpDir, err := os.MkdirTemp("", "megafone-vhs")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+	out := filepath.Join(tmpDir, "demo.mp4")
+	// Our Output replaces the tape's, so the recording lands where we expect
+	tape = tapeOutputRegex.ReplaceAllString(tape, "")
+	tapePath := filepath.Join(tmpDir, "demo.tape")
+	if err := os.WriteFile(tapePath, []byte(fmt.Sprintf("Output %q\n%s", out, tape)), 0644); err != nil {
+		return nil, err
+	}
+
+	logInfo("📼 Recording the terminal demo with vhs...")
+	rctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+	c := exec.CommandContext(rctx, vhs, tapePath)
+	c.Dir = tmpDir
+	if output, err := c.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("vhs failed: %v: %s", err, lastLine(string(output)))
+	}
+	return os.ReadFile(out)
+}
+
+// writeDemoTape asks the model for a short VHS tape showing the CLI's basic usage
+func writeDemoTape(ctx context.Context, apiKey string, repoData *github.Repository, readme string) (string, error) {
+	prompt := fmt.Sprintf(`Write a VHS tape (https://github.com/charmbracelet/vhs) that records a short
+terminal demo of %s: %s
+
+Show its basic usage in 3-6 commands taken from the README, with output a
+reader can follow. Assume the tool is already installed: start with
+"Require <binary>" for each program used. Use only read-only, harmless commands
+(no installs, deletes, network writes, sudo, or secrets). Set FontSize 22,
+Width 1280, Height 720, and TypingSpeed 60ms; Sleep long enough after each
+Enter to read the output. Don't include an Output line.
+
+Respond with only the tape.
+
+README:
+%s`, repoData.GetFullName(), repoData.GetDescription(), summarizeTokens(6000, readme))
+
+	resp, err := createChatCompletion(ctx, openai.NewClient(apiKey), openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleUser, Content: prompt},
+		},
+		Temperature: 0.2,
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("no response from model")
+	}
+	tape := strings.TrimSpace(resp.Choices[0].Message.Content)
+	tape = strings.TrimPrefix(strings.TrimPrefix(tape, "```tape"), "```")
+	return strings.TrimSpace(strings.TrimSuffix(tape, "```")) + "\n", nil
+}
+
+func fetchRepoFile(u string) ([]byte, error) {
+	resp, err := http.Get(u)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP error: %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 50<<20))
+}
@@ -0,0 +1,469 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/spf13/cobra"
+)
+
+var (
+	topicsClusters int
+	topicsMinSize  int
+	topicsJSON     bool
+	topicsGenerate bool
+)
+
+// topicCluster is a group of related posts and the pillar page proposed for it
+type topicCluster struct {
+	Name        string         `json:"name"`
+	PillarTitle string         `json:"pillar_title"`
+	Keyword     string         `json:"keyword"`
+	Summary     string         `json:"summary"`
+	Cohesion    float64        `json:"cohesion"`
+	Members     []topicsMember `json:"members"`
+}
+
+type topicsMember struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+	Path  string `json:"path"`
+}
+
+var topicsCmd = &cobra.Command{
+	Use:   "topics",
+	Short: "Organize the site's posts into topics",
+}
+
+var topicsClusterCmd = &cobra.Command{
+	Use:   "cluster",
+	Short: "Cluster posts into topic hubs and propose pillar pages",
+	Long: `Groups the site's published posts into topic clusters by their embeddings,
+names each cluster, and proposes a pillar page: a hub post that introduces the
+topic and links out to every post in the cluster. With --generate, the pillar
+pages are written into the site.
+
+Embeddings come from the site index (see "megafone index rebuild"), which is
+built first if it is missing or out of date.
+
+Examples:
+  megafone topics cluster -s ~/hugo
+  megafone topics cluster -s ~/hugo --clusters 8 --min-size 4 --json
+
+  # Write the pillar pages
+  megafone topics cluster -s ~/hugo --generate`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runTopicsCluster(cmd); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(topicsCmd)
+	topicsCmd.AddCommand(topicsClusterCmd)
+
+	topicsClusterCmd.Flags().StringVarP(&siteSource, "site-source", "s", "", "Path to local Hugo site repository")
+	topicsClusterCmd.Flags().IntVar(&topicsClusters, "clusters", 0, "Number of clusters (default: about the square root of half the post count)")
+	topicsClusterCmd.Flags().IntVar(&topicsMinSize, "min-size", 3, "Smallest cluster worth a pillar page")
+	topicsClusterCmd.Flags().BoolVar(&topicsJSON, "json", false, "Output clusters as JSON")
+	topicsClusterCmd.Flags().BoolVar(&topicsGenerate, "generate", false, "Write a pillar page for each cluster")
+	topicsClusterCmd.Flags().StringVarP(&promptFile, "prompt", "p", "prompts/technical-article.txt", "Style guide for pillar pages")
+	topicsClusterCmd.Flags().StringVarP(&model, "model", "m", "gpt-4o", "OpenAI model to use")
+	topicsClusterCmd.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "Print pillar pages instead of writing them")
+}
+
+func runTopicsCluster(cmd *cobra.Command) error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	ctx := context.Background()
+
+	basePath, err := resolveSitePath()
+	if err != nil {
+		return err
+	}
+	apiKey, err := getOpenAIKey(cmd)
+	if err != nil {
+		return err
+	}
+	client := openai.NewClient(apiKey)
+
+	posts, err := loadSitePosts(basePath)
+	if err != nil {
+		return fmt.Errorf("failed to load site posts: %w", err)
+	}
+	var published []sitePost
+	for _, p := range posts {
+		if !p.Draft {
+			published = append(published, p)
+		}
+	}
+	if len(published) < 2*topicsMinSize {
+		return fmt.Errorf("need at least %d published posts to cluster, found %d", 2*topicsMinSize, len(published))
+	}
+
+	idx, err := ensureSiteIndex(ctx, client, basePath, published)
+	if err != nil {
+		return err
+	}
+
+	k := topicsClusters
+	if k <= 0 {
+		k = int(math.Round(math.Sqrt(float64(len(idx.Posts)) / 2)))
+	}
+	k = max(2, min(k, len(idx.Posts)/topicsMinSize))
+	logInfo("🧩 Clustering %d posts into %d topics...", len(idx.Posts), k)
+
+	vectors := make([][]float32, len(idx.Posts))
+	for i, e := range idx.Posts {
+		vectors[i] = e.Vector
+	}
+	assignments, centroids := kMeans(vectors, k)
+
+	var clusters []topicCluster
+	unclustered := 0
+	for c := range centroids {
+		var members []topicsMember
+		var cohesion float64
+		for i, a := range assignments {
+			if a != c {
+				continue
+			}
+			e := idx.Posts[i]
+			members = append(members, topicsMember{Title: e.Title, URL: e.URL, Path: e.Path})
+			cohesion += cosineSimilarity(e.Vector, centroids[c])
+		}
+		if len(members) < topicsMinSize {
+			unclustered += len(members)
+			continue
+		}
+		clusters = append(clusters, topicCluster{
+			Cohesion: math.Round(cohesion/float64(len(members))*1000) / 1000,
+			Members:  members,
+		})
+	}
+	if len(clusters) == 0 {
+		return fmt.Errorf("no cluster reached --min-size %d; try fewer --clusters", topicsMinSize)
+	}
+	sort.SliceStable(clusters, func(i, j int) bool {
+		return len(clusters[i].Members) > len(clusters[j].Members)
+	})
+
+	logInfo("🏷️  Naming %d topics...", len(clusters))
+	if err := nameTopicClusters(ctx, client, clusters); err != nil {
+		return classify(ErrGeneration, err)
+	}
+
+	if topicsJSON {
+		data, err := json.MarshalIndent(clusters, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	} else {
+		for i, c := range clusters {
+			fmt.Printf("\n%d. %s (%d posts, cohesion %.2f)\n", i+1, c.Name, len(c.Members), c.Cohesion)
+			fmt.Printf("   Pillar page: %q (keyword: %s)\n", c.PillarTitle, c.Keyword)
+			if c.Summary != "" {
+				fmt.Printf("   %s\n", c.Summary)
+			}
+			for _, m := range c.Members {
+				fmt.Printf("     - %s  %s\n", m.Title, m.URL)
+			}
+		}
+		if unclustered > 0 {
+			fmt.Printf("\n%d posts fell in clusters smaller than %d and were left out.\n", unclustered, topicsMinSize)
+		}
+	}
+
+	if !topicsGenerate {
+		return nil
+	}
+	styleGuide, err := loadPrompt(promptFile, "topic", "")
+	if err != nil {
+		return err
+	}
+	for _, c := range clusters {
+		if err := writePillarPage(ctx, client, basePath, styleGuide, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// kMeans groups unit-normalized vectors into k clusters by cosine similarity,
+// seeding with k-means++ from a fixed seed so runs are repeatable. It returns
+// each vector's cluster and the cluster centroids.
+func kMeans(vectors [][]float32, k int) ([]int, [][]float32) {
+	points := make([][]float32, len(vectors))
+	for i, v := range vectors {
+		points[i] = normalizeVector(v)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	centroids := [][]float32{points[rng.Intn(len(points))]}
+	for len(centroids) < k {
+		weights := make([]float64, len(points))
+		var total float64
+		for i, p := range points {
+			nearest := math.Inf(1)
+			for _, c := range centroids {
+				nearest = math.Min(nearest, 1-cosineSimilarity(p, c))
+			}
+			weights[i] = nearest * nearest
+			total += weights[i]
+		}
+		if total == 0 {
+			break
+		}
+		target := rng.Float64() * total
+		for i, w := range weights {
+			if target -= w; target <= 0 {
+				centroids = append(centroids, points[i])
+				break
+			}
+		}
+	}
+
+	assignments := make([]int, len(points))
+	for iter := 0; iter < 50; iter++ {
+		changed := iter == 0
+		for i, p := range points {
+			best, bestSim := 0, -2.0
+			for c, centroid := range centroids {
+				if sim := cosineSimilarity(p, centroid); sim > bestSim {
+					best, bestSim = c, sim
+				}
+			}
+			if assignments[i] != best {
+				assignments[i] = best
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+
+		sums := make([][]float64, len(centroids))
+		for c := range sums {
+			sums[c] = make([]float64, len(points[0]))
+		}
+		for i, p := range points {
+			for d, x := range p {
+				sums[assignments[i]][d] += float64(x)
+			}
+		}
+		for c, sum := range sums {
+			centroid := make([]float32, len(sum))
+			for d, x := range sum {
+				centroid[d] = float32(x)
+			}
+			// An emptied cluster keeps its old centroid
+			if n := normalizeVector(centroid); n != nil {
+				centroids[c] = n
+			}
+		}
+	}
+	return assignments, centroids
+}
+
+// normalizeVector scales v to unit length, returning nil for a zero vector
+func normalizeVector(v []float32) []float32 {
+	var norm float64
+	for _, x := range v {
+		norm += float64(x) * float64(x)
+	}
+	if norm == 0 {
+		return nil
+	}
+	norm = math.Sqrt(norm)
+	out := make([]float32, len(v))
+	for i, x := range v {
+		out[i] = float32(float64(x) / norm)
+	}
+	return out
+}
+
+// nameTopicClusters asks the model to name every cluster and propose its pillar page in one call
+func nameTopicClusters(ctx context.Context, client *openai.Client, clusters []topicCluster) error {
+	var b strings.Builder
+	for i, c := range clusters {
+		fmt.Fprintf(&b, "Cluster %d:\n", i+1)
+		for _, m := range c.Members {
+			fmt.Fprintf(&b, "- %s\n", m.Title)
+		}
+		b.WriteString("\n")
+	}
+
+	resp, err := createChatCompletion(ctx, client, openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: "You are an SEO content strategist organizing a blog into topic hubs. Respond with JSON only.",
+			},
+			{
+				Role: openai.ChatMessageRoleUser,
+				Content: fmt.Sprintf(`These are clusters of related posts from one blog, listed by title.
+
+%s
+For each cluster, in order, give:
+- name: a short topic name (2-4 words)
+- pillar_title: the title of a pillar page that introduces the whole topic and links to every post in it
+- keyword: the search phrase the pillar page should rank for
+- summary: one sentence on what the cluster covers
+
+Respond with JSON: {"clusters": [{"name": "", "pillar_title": "", "keyword": "", "summary": ""}]}`, b.String()),
+			},
+		},
+		Temperature: 0.3,
+	})
+	if err != nil {
+		return fmt.Errorf("OpenAI API error: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return fmt.Errorf("no response from OpenAI")
+	}
+
+	var named struct {
+		Clusters []topicCluster `json:"clusters"`
+	}
+	if err := decodeModelJSON(resp.Choices[0].Message.Content, &named); err != nil {
+		return fmt.Errorf("failed to parse topic names: %w", err)
+	}
+	for i := range clusters {
+		if i < len(named.Clusters) {
+			clusters[i].Name = named.Clusters[i].Name
+			clusters[i].PillarTitle = named.Clusters[i].PillarTitle
+			clusters[i].Keyword = named.Clusters[i].Keyword
+			clusters[i].Summary = named.Clusters[i].Summary
+		}
+		if clusters[i].Name == "" {
+			clusters[i].Name = fmt.Sprintf("Topic %d", i+1)
+		}
+		clusters[i].PillarTitle = firstNonEmpty(clusters[i].PillarTitle, clusters[i].Name)
+	}
+	return nil
+}
+
+// writePillarPage generates a cluster's pillar page and writes it into the site.
+// Any member post the model didn't link is listed at the end.
+func writePillarPage(ctx context.Context, client *openai.Client, basePath, styleGuide string, c topicCluster) error {
+	logInfo("✍️  Writing pillar page %q...", c.PillarTitle)
+
+	var members strings.Builder
+	for _, m := range c.Members {
+		description := ""
+		if data, err := os.ReadFile(filepath.Join(basePath, filepath.FromSlash(m.Path))); err == nil {
+			description = frontMatterString(string(data), "description")
+		}
+		fmt.Fprintf(&members, "- [%s](%s)", m.Title, m.URL)
+		if description != "" {
+			fmt.Fprintf(&members, ": %s", description)
+		}
+		members.WriteString("\n")
+	}
+
+	resp, err := createChatCompletion(withStreaming(ctx), client, openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: "You are a technical writer building a topic hub for a blog. Follow the style guide precisely. Output ONLY the markdown content, no explanations.",
+			},
+			{
+				Role: openai.ChatMessageRoleUser,
+				Content: fmt.Sprintf(`%s
+
+Write a pillar page titled %q for the topic "%s" (target search phrase: %q).
+%s
+
+The site already has these posts on the topic:
+%s
+Instructions:
+- Introduce the topic and explain how its parts fit together, as a guide a newcomer would start from
+- Group the posts into sections and link every one of them with its exact URL, saying what the reader gets from each
+- Don't repeat the posts' content in depth; the page is a map to them
+- Include tags in the front matter
+
+IMPORTANT: Your response must be ONLY valid markdown. Do not include any explanatory text before or after the markdown.
+IMPORTANT: Use date: %s in the front matter.
+
+Generate a complete Hugo markdown post following the style guide above.`,
+					styleGuide, c.PillarTitle, c.Name, c.Keyword, c.Summary, members.String(), postDateString()),
+			},
+		},
+		Temperature: 0.6,
+	})
+	if err != nil {
+		return classify(ErrGeneration, fmt.Errorf("OpenAI API error: %w", err))
+	}
+	if len(resp.Choices) == 0 || resp.Choices[0].Message.Content == "" {
+		return classify(ErrGeneration, fmt.Errorf("no response from OpenAI"))
+	}
+	content := resp.Choices[0].Message.Content
+
+	var missing []string
+	for _, m := range c.Members {
+		if !strings.Contains(content, "("+m.URL+")") {
+			missing = append(missing, fmt.Sprintf("- [%s](%s)", m.Title, m.URL))
+		}
+	}
+	if len(missing) > 0 {
+		logInfo("⚠️  Pillar page skipped %d posts; listing them at the end", len(missing))
+		content = strings.TrimRight(content, "\n") + "\n\n## More on " + c.Name + "\n\n" + strings.Join(missing, "\n") + "\n"
+	}
+
+	content = applyDatePolicy(content, postDateString(), basePath)
+	content = upsertFrontMatterField(content, "pillar", "true")
+	content = applyHighlights(ctx, client, content)
+	if content, err = applySummarySections(ctx, client, content, "topic"); err != nil {
+		return err
+	}
+	content = applyAccessibility(content)
+	content, err = applyDisclosure(content, basePath)
+	if err != nil {
+		return err
+	}
+	source := "topic:" + c.Name
+	if content, err = applyFrontMatterDefaults(content, "topic", source); err != nil {
+		return err
+	}
+	if content, err = applySecretScan(content); err != nil {
+		return err
+	}
+	filename := sanitizeFilename(c.PillarTitle)
+	if content, filename, err = applySlugStrategy(content, filename, c.Keyword); err != nil {
+		return err
+	}
+
+	if dryRun {
+		fmt.Println("\n" + strings.Repeat("=", 80))
+		fmt.Println("DRY RUN - Pillar page:")
+		fmt.Println(strings.Repeat("=", 80))
+		fmt.Println(content)
+		fmt.Println(strings.Repeat("=", 80))
+		return nil
+	}
+
+	postPath := filepath.Join(postsDir(basePath), filename+".md")
+	if _, err := os.Stat(postPath); err == nil {
+		logInfo("⏭️  %s already exists; not overwriting", postPath)
+		return nil
+	}
+	if err := os.WriteFile(postPath, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write pillar page: %w", err)
+	}
+	logSuccess("✅ Pillar page created: %s", postPath)
+	logGeneration(source, postPath, "", frontMatterList(content, "tags"))
+	return runHooks("post_generate", appConfig.Hooks.PostGenerate, hookRun{Post: postPath, Source: source, Site: basePath})
+}
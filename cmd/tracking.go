@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// trackingConfig is the tracking block of megafone.yaml. Links to the site in
+// social posts and cross-posts get UTM parameters, and optionally a short URL
+// from a self-hosted Shlink server.
+type trackingConfig struct {
+	UTM       utmParams            `yaml:"utm"`
+	Platforms map[string]utmParams `yaml:"platforms"` // per-platform overrides
+	Shlink    shlinkConfig         `yaml:"shlink"`
+}
+
+// utmParams are UTM values; {platform}, {campaign}, and {slug} are filled in per link
+type utmParams struct {
+	Source   string `yaml:"source"`
+	Medium   string `yaml:"medium"`
+	Campaign string `yaml:"campaign"`
+	Content  string `yaml:"content"`
+	Term     string `yaml:"term"`
+}
+
+type shlinkConfig struct {
+	URL       string   `yaml:"url"`       // e.g. https://s.example.com; the API key is SHLINK_API_KEY
+	Domain    string   `yaml:"domain"`    // short domain, if the server has several
+	Platforms []string `yaml:"platforms"` // where to shorten (default: the social platforms)
+}
+
+// platformMediums is the default utm_medium for each place megafone puts links
+var platformMediums = map[string]string{
+	platformMastodon: "social",
+	platformBluesky:  "social",
+	"devto":          "crosspost",
+	"medium":         "crosspost",
+	"email-html":     "email",
+}
+
+var shortURLs = struct {
+	sync.Mutex
+	m map[string]string
+}{m: make(map[string]string)}
+
+// trackingEnabled reports whether megafone.yaml asks for UTM parameters
+func trackingEnabled() bool {
+	cfg := appConfig.Tracking
+	return cfg.UTM != (utmParams{}) || len(cfg.Platforms) > 0 || cfg.Shlink.URL != ""
+}
+
+// trackLink adds the UTM parameters for a platform and campaign to a link,
+// keeping any it already has, and shortens it if Shlink is set up for the
+// platform. A failed shortening is logged and the long link used.
+func trackLink(ctx context.Context, link, platform, campaign, slug string) string {
+	if !trackingEnabled() || link == "" {
+		return link
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+
+	cfg := appConfig.Tracking
+	override := cfg.Platforms[platform]
+	fill := strings.NewReplacer("{platform}", platform, "{campaign}", campaign, "{slug}", slug)
+	values := []struct{ key, value string }{
+		{"utm_source", firstNonEmpty(override.Source, cfg.UTM.Source, "{platform}")},
+		{"utm_medium", firstNonEmpty(override.Medium, cfg.UTM.Medium, platformMediums[platform], "referral")},
+		{"utm_campaign", firstNonEmpty(override.Campaign, cfg.UTM.Campaign, "{campaign}")},
+		{"utm_content", firstNonEmpty(override.Content, cfg.UTM.Content)},
+		{"utm_term", firstNonEmpty(override.Term, cfg.UTM.Term)},
+	}
+	query := u.Query()
+	for _, v := range values {
+		if value := fill.Replace(v.value); value != "" && query.Get(v.key) == "" {
+			query.Set(v.key, value)
+		}
+	}
+	u.RawQuery = query.Encode()
+	tracked := u.String()
+
+	if !shortenFor(platform) {
+		return tracked
+	}
+	short, err := shortenLink(ctx, tracked, platform)
+	if err != nil {
+		logError("Failed to shorten %s: %v", tracked, err)
+		return tracked
+	}
+	return short
+}
+
+func shortenFor(platform string) bool {
+	cfg := appConfig.Tracking.Shlink
+	// Creating short links is a side effect a dry run shouldn't have
+	if cfg.URL == "" || dryRun {
+		return false
+	}
+	platforms := cfg.Platforms
+	if len(platforms) == 0 {
+		platforms = []string{platformMastodon, platformBluesky}
+	}
+	for _, p := range platforms {
+		if p == platform {
+			return true
+		}
+	}
+	return false
+}
+
+// shortenLink creates (or reuses) a Shlink short URL for link
+func shortenLink(ctx context.Context, link, platform string) (string, error) {
+	shortURLs.Lock()
+	defer shortURLs.Unlock()
+	if short, ok := shortURLs.m[link]; ok {
+		return short, nil
+	}
+
+	apiKey := os.Getenv("SHLINK_API_KEY")
+	if apiKey == "" {
+		return "", classify(ErrAuth, fmt.Errorf("SHLINK_API_KEY is required to shorten links with Shlink"))
+	}
+	cfg := appConfig.Tracking.Shlink
+	body := map[string]interface{}{
+		"longUrl":      link,
+		"findIfExists": true,
+		"tags":         []string{"megafone", platform},
+	}
+	if cfg.Domain != "" {
+		body["domain"] = cfg.Domain
+	}
+
+	var created struct {
+		ShortURL string `json:"shortUrl"`
+	}
+	endpoint := strings.TrimRight(cfg.URL, "/") + "/rest/v3/short-urls"
+	if err := postSocialJSON(ctx, "Shlink", endpoint, map[string]string{"X-Api-Key": apiKey}, body, &created); err != nil {
+		return "", err
+	}
+	if created.ShortURL == "" {
+		return "", fmt.Errorf("Shlink returned no short URL")
+	}
+	shortURLs.m[link] = created.ShortURL
+	return created.ShortURL, nil
+}
+
+// onSite reports whether link points at the site
+func onSite(link, baseURL string) bool {
+	return link == baseURL || strings.HasPrefix(link, baseURL+"/")
+}
+
+// trackMarkdownLinks applies trackLink to the markdown links in a post body
+// that point at the site, making root-relative ones absolute. Images and
+// other sites' links are left alone.
+func trackMarkdownLinks(ctx context.Context, markdown, baseURL, platform, campaign, slug string) string {
+	if !trackingEnabled() || baseURL == "" {
+		return markdown
+	}
+	baseURL = strings.TrimRight(baseURL, "/")
+	return markdownLinkOrImageRegex.ReplaceAllStringFunc(markdown, func(m string) string {
+		parts := markdownLinkOrImageRegex.FindStringSubmatch(m)
+		link := parts[3]
+		if parts[1] != "" {
+			return m
+		}
+		if strings.HasPrefix(link, "/") && !strings.HasPrefix(link, "//") && !strings.HasPrefix(link, "/images/") {
+			link = baseURL + link
+		}
+		if !onSite(link, baseURL) {
+			return m
+		}
+		return strings.Replace(m, "("+parts[3], "("+trackLink(ctx, link, platform, campaign, slug), 1)
+	})
+}
+
+// trackTextLinks applies trackLink to the bare URLs in a social post that point at the site
+func trackTextLinks(ctx context.Context, text, baseURL, platform, campaign, slug string) string {
+	if !trackingEnabled() || baseURL == "" {
+		return text
+	}
+	baseURL = strings.TrimRight(baseURL, "/")
+	return bareURLRegex.ReplaceAllStringFunc(text, func(link string) string {
+		trimmed := strings.TrimRight(link, ".,;:!?)")
+		if !onSite(trimmed, baseURL) {
+			return link
+		}
+		return trackLink(ctx, trimmed, platform, campaign, slug) + link[len(trimmed):]
+	})
+}
@@ -0,0 +1,369 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Bulk commands stage their edits in a siteTxn, show what would change, and
+// apply everything at once after confirmation. The old content of every file
+// is saved first, so 'megafone transactions undo' can put it back.
+
+const (
+	// txnDiffFiles and txnDiffLines bound the diff shown before confirmation
+	txnDiffFiles = 20
+	txnDiffLines = 8
+	// txnMaxDiffLines is the longest file a line diff is computed for
+	txnMaxDiffLines = 3000
+)
+
+var assumeYes bool
+
+// siteTxn is a set of staged file changes to one site
+type siteTxn struct {
+	basePath string
+	name     string
+	// undoOf is the transaction this one reverts
+	undoOf  string
+	changes []*txnChange
+	byPath  map[string]*txnChange
+}
+
+// txnChange is one staged file: its content when staged (nil if it didn't
+// exist) and after (nil to delete it)
+type txnChange struct {
+	path        string
+	before      []byte
+	after       []byte
+	source      string
+	aiGenerated bool
+}
+
+// txnManifest is .megafone/transactions/<id>/manifest.json, the undo set of an
+// applied transaction
+type txnManifest struct {
+	ID      string      `json:"id"`
+	Name    string      `json:"name"`
+	Command string      `json:"command"`
+	Time    string      `json:"time"`
+	UndoOf  string      `json:"undo_of,omitempty"`
+	Undone  string      `json:"undone,omitempty"`
+	Changes []txnRecord `json:"changes"`
+}
+
+// txnRecord is one change in a manifest; Before names the saved copy of the
+// old content within the transaction's directory
+type txnRecord struct {
+	Path        string `json:"path"`
+	Action      string `json:"action"`
+	Before      string `json:"before,omitempty"`
+	AfterSHA256 string `json:"after_sha256,omitempty"`
+	Source      string `json:"source,omitempty"`
+	AIGenerated bool   `json:"ai_generated,omitempty"`
+}
+
+func newSiteTxn(basePath, name string) *siteTxn {
+	return &siteTxn{basePath: basePath, name: name, byPath: make(map[string]*txnChange)}
+}
+
+// stage records a change to path, remembering what the file held when first staged
+func (t *siteTxn) stage(path string, after []byte, source string, aiGenerated bool) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if c, ok := t.byPath[abs]; ok {
+		c.after, c.source, c.aiGenerated = after, firstNonEmpty(source, c.source), aiGenerated
+		return nil
+	}
+	c := &txnChange{path: abs, after: after, source: source, aiGenerated: aiGenerated}
+	if data, err := os.ReadFile(abs); err == nil {
+		c.before = data
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	t.changes = append(t.changes, c)
+	t.byPath[abs] = c
+	return nil
+}
+
+// write stages new content for path, creating the file if needed
+func (t *siteTxn) write(path string, data []byte, source string, aiGenerated bool) error {
+	return t.stage(path, data, source, aiGenerated)
+}
+
+// remove stages deleting path
+func (t *siteTxn) remove(path string, source string, aiGenerated bool) error {
+	return t.stage(path, nil, source, aiGenerated)
+}
+
+// effective returns the staged changes that change anything
+func (t *siteTxn) effective() []*txnChange {
+	var out []*txnChange
+	for _, c := range t.changes {
+		if c.before == nil && c.after == nil || c.before != nil && c.after != nil && bytes.Equal(c.before, c.after) {
+			continue
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+func (c *txnChange) action() string {
+	switch {
+	case c.before == nil:
+		return "created"
+	case c.after == nil:
+		return "deleted"
+	}
+	return "modified"
+}
+
+// printSummary lists the staged changes with a short line diff of each
+func (t *siteTxn) printSummary() {
+	changes := t.effective()
+	counts := map[string]int{}
+	for _, c := range changes {
+		counts[c.action()]++
+	}
+	fmt.Printf("\n%s: %d file(s) to change (%d modified, %d created, %d deleted)\n", t.name, len(changes), counts["modified"], counts["created"], counts["deleted"])
+
+	for i, c := range changes {
+		if i == txnDiffFiles {
+			fmt.Printf("\n… and %d more file(s)\n", len(changes)-txnDiffFiles)
+			break
+		}
+		marker := map[string]string{"created": "A", "deleted": "D", "modified": "M"}[c.action()]
+		fmt.Printf("\n%s %s\n", marker, mustRel(t.basePath, c.path))
+		lines := lineDiff(string(c.before), string(c.after))
+		for j, line := range lines {
+			if j == txnDiffLines {
+				fmt.Printf("    … %d more changed line(s)\n", len(lines)-txnDiffLines)
+				break
+			}
+			fmt.Printf("    %s\n", line)
+		}
+	}
+	fmt.Println()
+}
+
+// lineDiff lists the lines only a has ("- ") and only b has ("+ "), in order,
+// using a longest common subsequence of lines
+func lineDiff(a, b string) []string {
+	al, bl := fileLines(a), fileLines(b)
+	if len(al) > txnMaxDiffLines || len(bl) > txnMaxDiffLines {
+		return []string{fmt.Sprintf("(%d → %d lines)", len(al), len(bl))}
+	}
+
+	// lcs[i][j] is the LCS length of al[i:] and bl[j:]
+	lcs := make([][]int, len(al)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(bl)+1)
+	}
+	for i := len(al) - 1; i >= 0; i-- {
+		for j := len(bl) - 1; j >= 0; j-- {
+			if al[i] == bl[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var out []string
+	i, j := 0, 0
+	for i < len(al) || j < len(bl) {
+		switch {
+		case i < len(al) && j < len(bl) && al[i] == bl[j]:
+			i++
+			j++
+		case i < len(al) && (j == len(bl) || lcs[i+1][j] >= lcs[i][j+1]):
+			out = append(out, "- "+al[i])
+			i++
+		default:
+			out = append(out, "+ "+bl[j])
+			j++
+		}
+	}
+	return out
+}
+
+func fileLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// confirm shows the summary and asks whether to apply it: dry runs never do,
+// --yes always does, and without a terminal --yes is required
+func (t *siteTxn) confirm() (bool, error) {
+	if len(t.effective()) == 0 {
+		logInfo("Nothing to change")
+		return false, nil
+	}
+	t.printSummary()
+	switch {
+	case dryRun:
+		fmt.Println("Dry run - nothing changed.")
+		return false, nil
+	case assumeYes:
+		return true, nil
+	case !stdinIsTerminal():
+		return false, fmt.Errorf("not applying %d change(s) without confirmation; pass --yes to apply them", len(t.effective()))
+	}
+	return askChoice(fmt.Sprintf("Apply %d change(s)?", len(t.effective())), []string{"yes", "No"}, "n") == "y", nil
+}
+
+// commit applies the staged changes under the site lock. The old content of
+// every file is saved to an undo set first; files are replaced by rename, and
+// if any change fails the ones already made are reverted. It returns the
+// transaction ID to pass to 'transactions undo'.
+func (t *siteTxn) commit() (string, error) {
+	changes := t.effective()
+	var id string
+	err := withSiteLock(t.basePath, "applying "+t.name, func() error {
+		// Refuse to overwrite edits made since the changes were staged
+		for _, c := range changes {
+			current, err := os.ReadFile(c.path)
+			if os.IsNotExist(err) {
+				current, err = nil, nil
+			}
+			if err != nil {
+				return err
+			}
+			if !bytes.Equal(current, c.before) || (current == nil) != (c.before == nil) {
+				return fmt.Errorf("%s changed since the transaction was staged; run the command again", mustRel(t.basePath, c.path))
+			}
+		}
+
+		var err error
+		if id, err = t.saveUndoSet(changes); err != nil {
+			return fmt.Errorf("failed to save the undo set: %w", err)
+		}
+
+		for i, c := range changes {
+			if err := applyFileContent(c.path, c.after); err != nil {
+				for _, done := range changes[:i] {
+					if rerr := applyFileContent(done.path, done.before); rerr != nil {
+						logError("Failed to roll back %s: %v", done.path, rerr)
+					}
+				}
+				os.RemoveAll(workspacePath(t.basePath, "transactions", id))
+				return fmt.Errorf("failed to apply %s (every change was rolled back): %w", mustRel(t.basePath, c.path), err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	for _, c := range changes {
+		recordSiteChange(c.action(), c.path, c.source, c.aiGenerated)
+	}
+	return id, nil
+}
+
+// applyFileContent makes path hold data, or removes it when data is nil.
+// Content goes to a temporary file in the same directory first and is renamed
+// into place, so a file is never left half-written.
+func applyFileContent(path string, data []byte) error {
+	if data == nil {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".megafone-*")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	os.Chmod(f.Name(), 0644)
+	if err := os.Rename(f.Name(), path); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
+
+// saveUndoSet writes the manifest and the old content of every changed file
+func (t *siteTxn) saveUndoSet(changes []*txnChange) (string, error) {
+	id := journalRunID
+	for n := 2; ; n++ {
+		if _, err := os.Stat(workspacePath(t.basePath, "transactions", id)); os.IsNotExist(err) {
+			break
+		}
+		id = fmt.Sprintf("%s-%d", journalRunID, n)
+	}
+	dir := workspacePath(t.basePath, "transactions", id)
+	if err := os.MkdirAll(filepath.Join(dir, "before"), 0755); err != nil {
+		return "", err
+	}
+
+	manifest := txnManifest{ID: id, Name: t.name, Command: journalCommand, Time: time.Now().Format(time.RFC3339), UndoOf: t.undoOf}
+	for i, c := range changes {
+		rec := txnRecord{
+			Path:        filepath.ToSlash(mustRel(t.basePath, c.path)),
+			Action:      c.action(),
+			Source:      c.source,
+			AIGenerated: c.aiGenerated,
+		}
+		if c.before != nil {
+			rec.Before = filepath.ToSlash(filepath.Join("before", fmt.Sprintf("%05d", i)))
+			if err := os.WriteFile(filepath.Join(dir, rec.Before), c.before, 0644); err != nil {
+				return "", err
+			}
+		}
+		if c.after != nil {
+			sum := sha256.Sum256(c.after)
+			rec.AfterSHA256 = hex.EncodeToString(sum[:])
+		}
+		manifest.Changes = append(manifest.Changes, rec)
+	}
+	if err := writeTxnManifest(t.basePath, manifest); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+func writeTxnManifest(basePath string, m txnManifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(workspacePath(basePath, "transactions", m.ID, "manifest.json"), append(data, '\n'), 0644)
+}
+
+// applyTxn confirms and commits a transaction, logging how to undo it
+func applyTxn(t *siteTxn) (bool, error) {
+	ok, err := t.confirm()
+	if err != nil || !ok {
+		return false, err
+	}
+	id, err := t.commit()
+	if err != nil {
+		return false, err
+	}
+	logSuccess("✅ Applied %d change(s) as transaction %s; undo with: megafone transactions undo %s", len(t.effective()), id, id)
+	return true, nil
+}
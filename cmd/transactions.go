@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var txnUndoForce bool
+
+var transactionsCmd = &cobra.Command{
+	Use:     "transactions",
+	Aliases: []string{"txn"},
+	Short:   "List and undo bulk changes to the site",
+	Long: `Bulk commands (slugs migrate, tags normalize, meta set on a directory) apply
+their changes as one transaction and save the old content of every file they
+touch to .megafone/transactions/<id>/. These commands list the transactions and
+put a transaction's files back the way they were.`,
+}
+
+var transactionsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List applied transactions, newest first",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runTransactionsList(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+var transactionsUndoCmd = &cobra.Command{
+	Use:   "undo [id]",
+	Short: "Revert a transaction (default: the latest one not yet undone)",
+	Long: `Restores every file a transaction changed: modified and deleted files get
+their old content back and created files are removed. The undo is itself a
+transaction, shown and confirmed the same way, so it can be undone too.
+
+Files edited since the transaction are left alone and the undo is refused;
+--force reverts them anyway, losing those edits.
+
+Examples:
+  megafone transactions undo -s ~/hugo --dry-run
+  megafone transactions undo 20250302T101502Z-9f2c1a7b -s ~/hugo`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		id := ""
+		if len(args) > 0 {
+			id = args[0]
+		}
+		if err := runTransactionsUndo(id); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(transactionsCmd)
+	transactionsCmd.AddCommand(transactionsListCmd)
+	transactionsCmd.AddCommand(transactionsUndoCmd)
+
+	transactionsListCmd.Flags().StringVarP(&siteSource, "site-source", "s", "", "Path to local Hugo site repository")
+	transactionsUndoCmd.Flags().StringVarP(&siteSource, "site-source", "s", "", "Path to local Hugo site repository")
+	transactionsUndoCmd.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "Show what the undo would change without changing it")
+	transactionsUndoCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Apply without asking for confirmation")
+	transactionsUndoCmd.Flags().BoolVar(&txnUndoForce, "force", false, "Revert files even if they were edited after the transaction")
+}
+
+// loadTxnManifests reads every transaction of a site, newest first
+func loadTxnManifests(basePath string) ([]txnManifest, error) {
+	entries, err := os.ReadDir(workspacePath(basePath, "transactions"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var manifests []txnManifest
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(workspacePath(basePath, "transactions", e.Name(), "manifest.json"))
+		if err != nil {
+			continue
+		}
+		var m txnManifest
+		if err := json.Unmarshal(data, &m); err != nil {
+			logError("Skipping unreadable transaction %s: %v", e.Name(), err)
+			continue
+		}
+		manifests = append(manifests, m)
+	}
+	// IDs start with a UTC timestamp
+	sort.Slice(manifests, func(i, j int) bool { return manifests[i].ID > manifests[j].ID })
+	return manifests, nil
+}
+
+func runTransactionsList() error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	basePath, err := resolveSitePath()
+	if err != nil {
+		return err
+	}
+	manifests, err := loadTxnManifests(basePath)
+	if err != nil {
+		return err
+	}
+	if len(manifests) == 0 {
+		fmt.Println("No transactions.")
+		return nil
+	}
+	for _, m := range manifests {
+		status := ""
+		switch {
+		case m.Undone != "":
+			status = " (undone " + m.Undone + ")"
+		case m.UndoOf != "":
+			status = " (undo of " + m.UndoOf + ")"
+		}
+		fmt.Printf("%s  %s  %-28s %d file(s)%s\n", m.ID, m.Time, m.Name, len(m.Changes), status)
+	}
+	return nil
+}
+
+func runTransactionsUndo(id string) error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	basePath, err := resolveSitePath()
+	if err != nil {
+		return err
+	}
+	manifests, err := loadTxnManifests(basePath)
+	if err != nil {
+		return err
+	}
+
+	var target *txnManifest
+	for i, m := range manifests {
+		if id == "" && m.Undone == "" && m.UndoOf == "" || id != "" && m.ID == id {
+			target = &manifests[i]
+			break
+		}
+	}
+	switch {
+	case target == nil && id == "":
+		return fmt.Errorf("no transaction left to undo")
+	case target == nil:
+		return fmt.Errorf("no transaction %s in %s", id, workspacePath(basePath, "transactions"))
+	case target.Undone != "" && !txnUndoForce:
+		return fmt.Errorf("transaction %s was already undone at %s", target.ID, target.Undone)
+	}
+
+	dir := workspacePath(basePath, "transactions", target.ID)
+	txn := newSiteTxn(basePath, "undo "+target.Name)
+	txn.undoOf = target.ID
+	var conflicts []string
+	for _, rec := range target.Changes {
+		path := filepath.Join(basePath, filepath.FromSlash(rec.Path))
+		if !txnUnchangedSince(path, rec) {
+			conflicts = append(conflicts, rec.Path)
+			if !txnUndoForce {
+				continue
+			}
+		}
+		if rec.Before == "" {
+			if err := txn.remove(path, rec.Source, rec.AIGenerated); err != nil {
+				return err
+			}
+			continue
+		}
+		before, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(rec.Before)))
+		if err != nil {
+			return fmt.Errorf("the undo set of %s is incomplete: %w", target.ID, err)
+		}
+		if err := txn.write(path, before, rec.Source, rec.AIGenerated); err != nil {
+			return err
+		}
+	}
+	if len(conflicts) > 0 && !txnUndoForce {
+		return fmt.Errorf("%d file(s) changed after transaction %s, so it can't be undone cleanly: %s (--force reverts them anyway)", len(conflicts), target.ID, strings.Join(conflicts, ", "))
+	}
+
+	ok, err := txn.confirm()
+	if err != nil || !ok {
+		return err
+	}
+	undoID, err := txn.commit()
+	if err != nil {
+		return err
+	}
+	target.Undone = time.Now().Format(time.RFC3339)
+	if err := writeTxnManifest(basePath, *target); err != nil {
+		logError("Failed to mark %s as undone: %v", target.ID, err)
+	}
+	logSuccess("✅ Undid transaction %s (%d file(s)); the undo is transaction %s", target.ID, len(txn.effective()), undoID)
+	return nil
+}
+
+// txnUnchangedSince reports whether a file is still as the transaction left it
+func txnUnchangedSince(path string, rec txnRecord) bool {
+	data, err := os.ReadFile(path)
+	if rec.AfterSHA256 == "" {
+		return os.IsNotExist(err)
+	}
+	if err != nil {
+		return false
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]) == rec.AfterSHA256
+}
@@ -0,0 +1,490 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/spf13/cobra"
+)
+
+var (
+	translateTo        []string
+	translateFrom      string
+	translateOverwrite bool
+	translateJobs      int
+
+	translateBackend    string
+	translateNoPostEdit bool
+)
+
+// translatedFields are the front matter fields translated along with the body
+var translatedFields = []string{"title", "description", "summary", "social_blurb"}
+
+// languageNames spells out common language codes for prompts
+var languageNames = map[string]string{
+	"ar": "Arabic", "cs": "Czech", "da": "Danish", "de": "German", "en": "English", "es": "Spanish",
+	"fi": "Finnish", "fr": "French", "he": "Hebrew", "hi": "Hindi", "id": "Indonesian", "it": "Italian",
+	"ja": "Japanese", "ko": "Korean", "nl": "Dutch", "no": "Norwegian", "pl": "Polish", "pt": "Portuguese",
+	"ro": "Romanian", "ru": "Russian", "sv": "Swedish", "th": "Thai", "tr": "Turkish", "uk": "Ukrainian",
+	"vi": "Vietnamese", "zh": "Chinese",
+}
+
+// translateBatchChars caps the source text sent in one translation request
+const translateBatchChars = 12000
+
+var translateCmd = &cobra.Command{
+	Use:   "translate <post>",
+	Short: "Translate a post into other languages using a translation memory",
+	Long: `Translates an existing post's body and its title, description, summary, and
+social_blurb into each --to language, all languages at once.
+
+Translations go where Hugo looks for them: content/posts/<lang>/ when posts
+are organized by language directory, otherwise beside the post as
+<name>.<lang>.md. Both the post and its translations get a translationKey so
+Hugo links them.
+
+Every translated paragraph and the terms chosen for it are kept in a
+translation memory per language pair (.megafone/translation-memory/en-fr.json).
+Paragraphs translated before are reused as-is, without a model call, and the
+glossary and similar past translations are sent with new ones so wording stays
+consistent across posts. Edit the memory file to correct a term or a segment.
+
+DeepL (DEEPL_API_KEY) or Google Translate (GOOGLE_TRANSLATE_API_KEY) can do
+the translating instead of the model, for every language with --backend or per
+language under translation in megafone.yaml. The model then post-edits their
+output with the glossary and memory unless post_edit is false or
+--no-post-edit is set.
+
+Examples:
+  megafone translate content/posts/en/my-post.md --to fr,ja
+  megafone translate content/posts/en/my-post.md --to de --overwrite -m gpt-4o
+  megafone translate content/posts/en/my-post.md --to ja --backend deepl`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runTranslate(cmd, args[0]); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(translateCmd)
+
+	translateCmd.Flags().StringSliceVar(&translateTo, "to", nil, "Languages to translate into, as codes (fr,ja,de) (required)")
+	translateCmd.Flags().StringVar(&translateFrom, "from", "", "Language of the post (default: its content/posts/<lang> directory, then en)")
+	translateCmd.Flags().BoolVar(&translateOverwrite, "overwrite", false, "Replace existing translations")
+	translateCmd.Flags().StringVar(&translateBackend, "backend", "", "Translation backend for every language: llm, deepl, or google (default: translation in config, then llm)")
+	translateCmd.Flags().BoolVar(&translateNoPostEdit, "no-post-edit", false, "Use DeepL or Google output as-is instead of having the model post-edit it")
+	translateCmd.Flags().IntVar(&translateJobs, "jobs", 3, "Languages to translate at the same time")
+	translateCmd.Flags().StringVarP(&siteSource, "site-source", "s", "", "Path to local Hugo site repository (default: found from the post path)")
+	translateCmd.Flags().StringVarP(&model, "model", "m", "gpt-4o-mini", "OpenAI model to use")
+	translateCmd.Flags().BoolVarP(&dryRun, "dry-run", "d", false, "Print the translations without writing files")
+
+	translateCmd.MarkFlagRequired("to")
+}
+
+// postSegment is a piece of a post body; code blocks and shortcodes are kept as-is
+type postSegment struct {
+	Text      string
+	Translate bool
+}
+
+func runTranslate(cmd *cobra.Command, postPath string) error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	ctx := context.Background()
+
+	data, err := os.ReadFile(postPath)
+	if err != nil {
+		return fmt.Errorf("failed to read post: %w", err)
+	}
+	content := string(data)
+	if frontMatterBlock(content) == "" {
+		return fmt.Errorf("%s has no front matter", postPath)
+	}
+
+	basePath := siteSource
+	if basePath == "" {
+		basePath = findSiteRoot(filepath.Dir(postPath))
+	}
+	if basePath == "" {
+		return fmt.Errorf("could not find the Hugo site for %s (set --site-source)", postPath)
+	}
+
+	from := firstNonEmpty(translateFrom, postLanguage(postPath))
+	var langs []string
+	for _, lang := range translateTo {
+		lang = strings.ToLower(strings.TrimSpace(lang))
+		if lang == "" || lang == from {
+			continue
+		}
+		langs = append(langs, lang)
+	}
+	if len(langs) == 0 {
+		return fmt.Errorf("no target languages other than %s", from)
+	}
+
+	// The model is only needed if some language translates or post-edits with it
+	var client *openai.Client
+	for _, lang := range langs {
+		backend, postEdit, err := translationBackendFor(lang)
+		if err != nil {
+			return err
+		}
+		if (backend == translateBackendLLM || postEdit) && client == nil {
+			apiKey, err := getOpenAIKey(cmd)
+			if err != nil {
+				return err
+			}
+			client = openai.NewClient(apiKey)
+		}
+	}
+
+	// The translationKey ties the post to its translations
+	key := frontMatterString(content, "translationKey")
+	if key == "" {
+		key = parseSitePost(postPath, content).Slug
+		content = upsertFrontMatterField(content, "translationKey", yamlQuote(key))
+		if !dryRun {
+			if err := os.WriteFile(postPath, []byte(content), 0644); err != nil {
+				return fmt.Errorf("failed to write post: %w", err)
+			}
+			recordSiteChange("modified", postPath, "", false)
+		}
+	}
+
+	var (
+		failed []string
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+	)
+	slots := make(chan struct{}, max(translateJobs, 1))
+	for _, lang := range langs {
+		wg.Add(1)
+		go func(lang string) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			if err := translatePostTo(ctx, client, basePath, postPath, content, from, lang); err != nil {
+				logError("Translation to %s failed: %v", lang, err)
+				mu.Lock()
+				failed = append(failed, lang)
+				mu.Unlock()
+			}
+		}(lang)
+	}
+	wg.Wait()
+
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return classify(ErrGeneration, fmt.Errorf("translation failed for %s", strings.Join(failed, ", ")))
+	}
+	return nil
+}
+
+// translatePostTo writes one translation of a post, reusing and extending the
+// language pair's translation memory
+func translatePostTo(ctx context.Context, client *openai.Client, basePath, postPath, content, from, to string) error {
+	target := translatedPostPath(postPath, from, to)
+	if _, err := os.Stat(target); err == nil && !translateOverwrite && !dryRun {
+		logInfo("⏭️  %s already exists (use --overwrite to replace it)", target)
+		return nil
+	}
+
+	tm, err := loadTranslationMemory(basePath, from, to)
+	if err != nil {
+		return err
+	}
+	backend, postEdit, err := translationBackendFor(to)
+	if err != nil {
+		return err
+	}
+
+	// Front matter values and body paragraphs are translated as one list of segments
+	var texts []string
+	var fields []string
+	for _, field := range translatedFields {
+		if v := frontMatterString(content, field); v != "" {
+			fields = append(fields, field)
+			texts = append(texts, v)
+		}
+	}
+	segments := splitPostSegments(postBody(content))
+	for _, seg := range segments {
+		if seg.Translate {
+			texts = append(texts, seg.Text)
+		}
+	}
+
+	translated := make([]string, len(texts))
+	var pending []int
+	for i, text := range texts {
+		if t, ok := tm.lookup(text); ok {
+			translated[i] = t
+		} else {
+			pending = append(pending, i)
+		}
+	}
+	via := backend
+	if postEdit {
+		via += " + model post-edit"
+	}
+	logInfo("🌐 %s → %s via %s: %d segments, %d from translation memory", from, to, via, len(texts), len(texts)-len(pending))
+
+	for start := 0; start < len(pending); {
+		end, size := start, 0
+		for end < len(pending) && (end == start || size+len(texts[pending[end]]) <= translateBatchChars) {
+			size += len(texts[pending[end]])
+			end++
+		}
+		batch := make([]string, end-start)
+		for i, idx := range pending[start:end] {
+			batch[i] = texts[idx]
+		}
+		var out []string
+		var terms map[string]string
+		if backend == translateBackendLLM {
+			out, terms, err = translateSegments(ctx, client, tm, batch, from, to)
+		} else if out, err = machineTranslate(ctx, backend, batch, from, to); err == nil && postEdit {
+			out, terms, err = postEditSegments(ctx, client, tm, batch, out, from, to)
+		}
+		if err != nil {
+			return err
+		}
+		for i, idx := range pending[start:end] {
+			translated[idx] = out[i]
+			tm.remember(texts[idx], out[i])
+		}
+		tm.addTerms(terms)
+		start = end
+	}
+
+	result := content
+	for i, field := range fields {
+		result = upsertFrontMatterField(result, field, yamlQuote(translated[i]))
+	}
+	next := len(fields)
+	var body []string
+	for _, seg := range segments {
+		if seg.Translate {
+			body = append(body, translated[next])
+			next++
+		} else {
+			body = append(body, seg.Text)
+		}
+	}
+	result = frontMatterBlock(result) + "\n---\n\n" + strings.Join(body, "\n\n") + "\n"
+
+	if dryRun {
+		fmt.Println("\n" + strings.Repeat("=", 80))
+		fmt.Printf("DRY RUN - %s translation (%s):\n", languageName(to), target)
+		fmt.Println(strings.Repeat("=", 80))
+		fmt.Println(result)
+		fmt.Println(strings.Repeat("=", 80))
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(target), err)
+	}
+	if err := os.WriteFile(target, []byte(result), 0644); err != nil {
+		return fmt.Errorf("failed to write translation: %w", err)
+	}
+	if len(pending) > 0 {
+		if err := saveTranslationMemory(basePath, tm); err != nil {
+			logError("Failed to save translation memory: %v", err)
+		}
+	}
+	logSuccess("✅ %s translation: %s", languageName(to), target)
+	logGeneration(postPath, target, "", frontMatterList(result, "tags"))
+	return nil
+}
+
+// translateSegments translates a batch of segments in one request, sending the
+// glossary terms they use and similar past translations along. It returns the
+// translations in order plus any new terms the model settled on.
+func translateSegments(ctx context.Context, client *openai.Client, tm *translationMemory, segments []string, from, to string) ([]string, map[string]string, error) {
+	input, err := json.Marshal(segments)
+	if err != nil {
+		return nil, nil, err
+	}
+	prompt := fmt.Sprintf(`Translate each segment of this blog post from %s to %s.
+%s
+Rules:
+%s
+Segments (a JSON array):
+%s
+
+Respond with JSON: {"translations": ["one translated string per segment, in order"], "terms": {"source term": "translation"}}
+List in "terms" the technical terms you translated (not kept as-is) so later posts can use the same wording.`,
+		languageName(from), languageName(to), translationMemoryPrompt(tm, segments), translationRules, input)
+	return requestTranslations(ctx, client, prompt, len(segments), from, to)
+}
+
+// postEditSegments has the model correct machine translations against the
+// source, the glossary, and the site's earlier translations
+func postEditSegments(ctx context.Context, client *openai.Client, tm *translationMemory, segments, drafts []string, from, to string) ([]string, map[string]string, error) {
+	type pair struct {
+		Source string `json:"source"`
+		Draft  string `json:"draft"`
+	}
+	pairs := make([]pair, len(segments))
+	for i := range segments {
+		pairs[i] = pair{segments[i], drafts[i]}
+	}
+	input, err := json.Marshal(pairs)
+	if err != nil {
+		return nil, nil, err
+	}
+	prompt := fmt.Sprintf(`Post-edit these machine translations of a blog post from %s to %s. Fix mistranslations,
+broken markdown, and unnatural phrasing, and apply the glossary; keep drafts that are already right.
+%s
+Rules:
+%s
+Segments (a JSON array of source and machine-translated draft):
+%s
+
+Respond with JSON: {"translations": ["one final translation per segment, in order"], "terms": {"source term": "translation"}}
+List in "terms" the technical terms you translated (not kept as-is) so later posts can use the same wording.`,
+		languageName(from), languageName(to), translationMemoryPrompt(tm, segments), translationRules, input)
+	return requestTranslations(ctx, client, prompt, len(segments), from, to)
+}
+
+// translationRules are the instructions every translation prompt shares
+const translationRules = `- Keep markdown syntax, links (translate only the link text), inline code, HTML, and Hugo shortcodes exactly as they are
+- Keep product names, APIs, commands, and code identifiers in their original form
+- Keep the author's tone; write naturally for native readers rather than word for word
+`
+
+// translationMemoryPrompt lists the glossary terms used in segments and similar past translations
+func translationMemoryPrompt(tm *translationMemory, segments []string) string {
+	var memory strings.Builder
+	if terms := tm.termsIn(segments); len(terms) > 0 {
+		memory.WriteString("\nGlossary (always translate these terms this way):\n")
+		keys := make([]string, 0, len(terms))
+		for k := range terms {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(&memory, "- %s → %s\n", k, terms[k])
+		}
+	}
+	if examples := tm.similarSegments(segments, 5); len(examples) > 0 {
+		memory.WriteString("\nEarlier translations from this site (match their terminology and tone):\n")
+		for _, ex := range examples {
+			fmt.Fprintf(&memory, "- %q → %q\n", ex.Source, ex.Target)
+		}
+	}
+	return memory.String()
+}
+
+// requestTranslations sends a translation prompt and checks the model returned one translation per segment
+func requestTranslations(ctx context.Context, client *openai.Client, prompt string, n int, from, to string) ([]string, map[string]string, error) {
+	resp, err := createChatCompletion(ctx, client, openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: fmt.Sprintf("You are a professional technical translator from %s to %s. Respond with JSON only.", languageName(from), languageName(to)),
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: prompt,
+			},
+		},
+		Temperature: 0.2,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("OpenAI API error: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return nil, nil, fmt.Errorf("no response from OpenAI")
+	}
+
+	var reply struct {
+		Translations []string          `json:"translations"`
+		Terms        map[string]string `json:"terms"`
+	}
+	if err := decodeModelJSON(resp.Choices[0].Message.Content, &reply); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse translation: %w", err)
+	}
+	if len(reply.Translations) != n {
+		return nil, nil, fmt.Errorf("model returned %d translations for %d segments", len(reply.Translations), n)
+	}
+	return reply.Translations, reply.Terms, nil
+}
+
+// splitPostSegments splits a body into blank-line-separated blocks. Fenced
+// code blocks and lines holding only a shortcode aren't translated.
+func splitPostSegments(body string) []postSegment {
+	var segments []postSegment
+	var block []string
+	inFence := false
+	fence := ""
+
+	flush := func(translate bool) {
+		text := strings.TrimRight(strings.Join(block, "\n"), "\n ")
+		if strings.TrimSpace(text) != "" {
+			segments = append(segments, postSegment{Text: text, Translate: translate})
+		}
+		block = nil
+	}
+
+	for _, line := range strings.Split(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case inFence:
+			block = append(block, line)
+			if strings.HasPrefix(trimmed, fence) {
+				inFence = false
+				flush(false)
+			}
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			flush(true)
+			inFence, fence = true, trimmed[:3]
+			block = append(block, line)
+		case trimmed == "":
+			flush(true)
+		case anyShortcodeRegex.MatchString(trimmed) && anyShortcodeRegex.ReplaceAllString(trimmed, "") == "":
+			flush(true)
+			block = append(block, line)
+			flush(false)
+		default:
+			block = append(block, line)
+		}
+	}
+	flush(!inFence)
+	return segments
+}
+
+// translatedPostPath is where Hugo expects a translation: the same place under
+// content/posts/<lang>/ when the post sits in a language directory, otherwise
+// beside it as <name>.<lang>.md
+func translatedPostPath(postPath, from, to string) string {
+	dir, name := filepath.Split(postPath)
+	dir = filepath.Clean(dir)
+	if filepath.Base(dir) == from {
+		return filepath.Join(filepath.Dir(dir), to, name)
+	}
+	if name == "index.md" && filepath.Base(filepath.Dir(dir)) == from {
+		return filepath.Join(filepath.Dir(filepath.Dir(dir)), to, filepath.Base(dir), name)
+	}
+	stem := strings.TrimSuffix(strings.TrimSuffix(name, ".md"), "."+from)
+	return filepath.Join(dir, stem+"."+to+".md")
+}
+
+func languageName(code string) string {
+	if name, ok := languageNames[code]; ok {
+		return name
+	}
+	return code
+}
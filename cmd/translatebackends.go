@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	translateBackendLLM    = "llm"
+	translateBackendDeepL  = "deepl"
+	translateBackendGoogle = "google"
+)
+
+var translateClient = &http.Client{Timeout: 2 * time.Minute}
+
+// translationConfig is the translation block of megafone.yaml. Backend and
+// post_edit apply to every language unless overridden under languages.
+type translationConfig struct {
+	Backend   string                             `yaml:"backend"`
+	PostEdit  *bool                              `yaml:"post_edit"`
+	Languages map[string]translationLanguageConf `yaml:"languages"`
+}
+
+type translationLanguageConf struct {
+	Backend  string `yaml:"backend"`
+	PostEdit *bool  `yaml:"post_edit"`
+}
+
+// translationBackendFor returns the backend for a target language and whether
+// the LLM post-edits its output. --backend and --no-post-edit override the config.
+func translationBackendFor(lang string) (string, bool, error) {
+	cfg := appConfig.Translation
+	langCfg := cfg.Languages[lang]
+	backend := firstNonEmpty(translateBackend, langCfg.Backend, cfg.Backend, translateBackendLLM)
+
+	postEdit := true
+	if cfg.PostEdit != nil {
+		postEdit = *cfg.PostEdit
+	}
+	if langCfg.PostEdit != nil {
+		postEdit = *langCfg.PostEdit
+	}
+	if translateNoPostEdit {
+		postEdit = false
+	}
+
+	switch backend {
+	case translateBackendLLM:
+		return backend, false, nil
+	case translateBackendDeepL, translateBackendGoogle:
+		return backend, postEdit, nil
+	default:
+		return "", false, fmt.Errorf("invalid translation backend %q for %s (use llm, deepl, or google)", backend, lang)
+	}
+}
+
+// machineTranslate translates segments with a dedicated MT service
+func machineTranslate(ctx context.Context, backend string, segments []string, from, to string) ([]string, error) {
+	switch backend {
+	case translateBackendDeepL:
+		return deeplTranslate(ctx, segments, from, to)
+	case translateBackendGoogle:
+		return googleTranslate(ctx, segments, from, to)
+	default:
+		return nil, fmt.Errorf("%s is not a machine translation backend", backend)
+	}
+}
+
+// deeplTranslate calls the DeepL API, 50 segments per request (its limit).
+// Free-plan keys (ending in :fx) use the free endpoint.
+func deeplTranslate(ctx context.Context, segments []string, from, to string) ([]string, error) {
+	apiKey := os.Getenv("DEEPL_API_KEY")
+	if apiKey == "" {
+		return nil, classify(ErrAuth, fmt.Errorf("DEEPL_API_KEY is required for the deepl translation backend"))
+	}
+	endpoint := "https://api.deepl.com/v2/translate"
+	if strings.HasSuffix(apiKey, ":fx") {
+		endpoint = "https://api-free.deepl.com/v2/translate"
+	}
+
+	var out []string
+	for start := 0; start < len(segments); start += 50 {
+		end := min(start+50, len(segments))
+		body := map[string]interface{}{
+			"text":        segments[start:end],
+			"source_lang": strings.ToUpper(from),
+			"target_lang": deeplTargetLang(to),
+		}
+		var result struct {
+			Translations []struct {
+				Text string `json:"text"`
+			} `json:"translations"`
+		}
+		if err := postTranslationJSON(ctx, "DeepL", endpoint, "DeepL-Auth-Key "+apiKey, body, &result); err != nil {
+			return nil, err
+		}
+		if len(result.Translations) != end-start {
+			return nil, fmt.Errorf("DeepL returned %d translations for %d segments", len(result.Translations), end-start)
+		}
+		for _, t := range result.Translations {
+			out = append(out, t.Text)
+		}
+	}
+	return out, nil
+}
+
+// deeplTargetLang maps a language code to DeepL's, which needs a variant for English and Portuguese
+func deeplTargetLang(lang string) string {
+	switch lang {
+	case "en":
+		return "EN-US"
+	case "pt":
+		return "PT-PT"
+	}
+	return strings.ToUpper(lang)
+}
+
+// googleTranslate calls the Cloud Translation v2 API, 128 segments per request (its limit)
+func googleTranslate(ctx context.Context, segments []string, from, to string) ([]string, error) {
+	apiKey := os.Getenv("GOOGLE_TRANSLATE_API_KEY")
+	if apiKey == "" {
+		return nil, classify(ErrAuth, fmt.Errorf("GOOGLE_TRANSLATE_API_KEY is required for the google translation backend"))
+	}
+	endpoint := "https://translation.googleapis.com/language/translate/v2?key=" + url.QueryEscape(apiKey)
+
+	var out []string
+	for start := 0; start < len(segments); start += 128 {
+		end := min(start+128, len(segments))
+		body := map[string]interface{}{
+			"q":      segments[start:end],
+			"source": from,
+			"target": to,
+			"format": "text",
+		}
+		var result struct {
+			Data struct {
+				Translations []struct {
+					TranslatedText string `json:"translatedText"`
+				} `json:"translations"`
+			} `json:"data"`
+		}
+		if err := postTranslationJSON(ctx, "Google Translate", endpoint, "", body, &result); err != nil {
+			return nil, err
+		}
+		if len(result.Data.Translations) != end-start {
+			return nil, fmt.Errorf("Google Translate returned %d translations for %d segments", len(result.Data.Translations), end-start)
+		}
+		for _, t := range result.Data.Translations {
+			out = append(out, t.TranslatedText)
+		}
+	}
+	return out, nil
+}
+
+// postTranslationJSON posts a JSON request to an MT API and decodes the reply into v
+func postTranslationJSON(ctx context.Context, service, endpoint, auth string, body, v interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+
+	resp, err := translateClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s request failed: %w", service, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return classify(ErrAuth, fmt.Errorf("%s rejected the API key: %s", service, resp.Status))
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("%s API error: %s: %s", service, resp.Status, strings.TrimSpace(string(data)))
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("invalid %s response: %w", service, err)
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// translationMemory is what megafone has translated before for one language
+// pair: a glossary of terms and every translated segment. It lives in
+// .megafone/translation-memory/<from>-<to>.json and can be edited by hand;
+// corrected terms and segments are used from then on.
+type translationMemory struct {
+	From     string                        `json:"from"`
+	To       string                        `json:"to"`
+	Terms    map[string]string             `json:"terms"`
+	Segments map[string]translationSegment `json:"segments"` // keyed by segmentKey
+}
+
+// translationSegment is one translated paragraph, heading, or front matter value
+type translationSegment struct {
+	Source  string `json:"source"`
+	Target  string `json:"target"`
+	Updated string `json:"updated"`
+}
+
+func translationMemoryPath(basePath, from, to string) string {
+	return workspacePath(basePath, "translation-memory", from+"-"+to+".json")
+}
+
+// loadTranslationMemory reads the memory for a language pair, starting an empty one if there is none
+func loadTranslationMemory(basePath, from, to string) (*translationMemory, error) {
+	tm := &translationMemory{From: from, To: to}
+	data, err := os.ReadFile(translationMemoryPath(basePath, from, to))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, tm); err != nil {
+			return nil, fmt.Errorf("invalid translation memory %s: %w", translationMemoryPath(basePath, from, to), err)
+		}
+	}
+	if tm.Terms == nil {
+		tm.Terms = make(map[string]string)
+	}
+	if tm.Segments == nil {
+		tm.Segments = make(map[string]translationSegment)
+	}
+	return tm, nil
+}
+
+func saveTranslationMemory(basePath string, tm *translationMemory) error {
+	path := translationMemoryPath(basePath, tm.From, tm.To)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(tm, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// segmentKey identifies a segment by its text, ignoring differences in whitespace
+func segmentKey(source string) string {
+	sum := sha256.Sum256([]byte(strings.Join(strings.Fields(source), " ")))
+	return hex.EncodeToString(sum[:12])
+}
+
+// lookup returns the remembered translation of source, if any
+func (tm *translationMemory) lookup(source string) (string, bool) {
+	seg, ok := tm.Segments[segmentKey(source)]
+	return seg.Target, ok
+}
+
+func (tm *translationMemory) remember(source, target string) {
+	tm.Segments[segmentKey(source)] = translationSegment{
+		Source:  source,
+		Target:  target,
+		Updated: time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+// addTerms records new glossary terms without overriding existing ones, which
+// may have been corrected by hand
+func (tm *translationMemory) addTerms(terms map[string]string) {
+	for source, target := range terms {
+		source, target = strings.TrimSpace(source), strings.TrimSpace(target)
+		if source == "" || target == "" {
+			continue
+		}
+		if _, ok := tm.Terms[source]; !ok {
+			tm.Terms[source] = target
+		}
+	}
+}
+
+// termsIn returns the glossary entries that occur in texts
+func (tm *translationMemory) termsIn(texts []string) map[string]string {
+	joined := strings.ToLower(strings.Join(texts, "\n"))
+	found := make(map[string]string)
+	for source, target := range tm.Terms {
+		if strings.Contains(joined, strings.ToLower(source)) {
+			found[source] = target
+		}
+	}
+	return found
+}
+
+// similarSegments returns up to n remembered segments sharing the most words
+// with texts, as examples of earlier translations to stay consistent with
+func (tm *translationMemory) similarSegments(texts []string, n int) []translationSegment {
+	words := make(map[string]bool)
+	for _, w := range strings.Fields(strings.ToLower(strings.Join(texts, " "))) {
+		if len(w) > 3 {
+			words[w] = true
+		}
+	}
+
+	type scored struct {
+		seg   translationSegment
+		score float64
+	}
+	var candidates []scored
+	for _, seg := range tm.Segments {
+		fields := strings.Fields(strings.ToLower(seg.Source))
+		if len(fields) == 0 {
+			continue
+		}
+		shared := 0
+		for _, w := range fields {
+			if words[w] {
+				shared++
+			}
+		}
+		if score := float64(shared) / float64(len(fields)); score >= 0.5 {
+			candidates = append(candidates, scored{seg, score})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].seg.Source < candidates[j].seg.Source
+	})
+
+	var out []translationSegment
+	for i := 0; i < len(candidates) && i < n; i++ {
+		out = append(out, candidates[i].seg)
+	}
+	return out
+}
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Truncation strategies for sources too long for the prompt
+const (
+	truncateHead     = "head"      // keep the beginning
+	truncateHeadTail = "head-tail" // keep the beginning and the end
+	truncateRelevant = "relevant"  // keep the chunks most similar to the topic
+)
+
+var truncationStrategy string
+
+// truncateChunkChars is the size of the chunks the relevant strategy ranks
+const truncateChunkChars = 1500
+
+// fitSource cuts a source to maxChars with --truncation, logging what was
+// kept. topic is what the relevant strategy ranks chunks against; when
+// embeddings fail it falls back to head-tail.
+func fitSource(ctx context.Context, apiKey, name, text, topic string, maxChars int) string {
+	if len(text) <= maxChars {
+		return text
+	}
+	budgetTruncated(name, len(text), maxChars)
+	strategy := firstNonEmpty(truncationStrategy, truncateHead)
+	logInfo("✂️  The %s is %s chars; keeping %s with the %s strategy", name, formatCount(len(text)), formatCount(maxChars), strategy)
+
+	switch strategy {
+	case truncateHeadTail:
+		return headTail(text, maxChars)
+	case truncateRelevant:
+		fitted, err := relevantChunks(ctx, openai.NewClient(apiKey), text, topic, maxChars)
+		if err != nil {
+			logError("Relevance ranking failed, keeping the head and tail instead: %v", err)
+			return headTail(text, maxChars)
+		}
+		return fitted
+	default:
+		return cutAtBoundary(text, maxChars) + "\n\n[... content truncated]"
+	}
+}
+
+// checkTruncationStrategy rejects an unknown --truncation before any work is done
+func checkTruncationStrategy() error {
+	switch truncationStrategy {
+	case "", truncateHead, truncateHeadTail, truncateRelevant:
+		return nil
+	default:
+		return fmt.Errorf("invalid --truncation value %q (use head, head-tail, or relevant)", truncationStrategy)
+	}
+}
+
+// headTail keeps the first two thirds of the budget from the start of text
+// and the rest from its end, where READMEs keep configuration, FAQs, and
+// caveats
+func headTail(text string, maxChars int) string {
+	headChars := maxChars * 2 / 3
+	head := cutAtBoundary(text, headChars)
+	tail := text[len(text)-(maxChars-len(head)):]
+	// Start the tail at a line, not mid-sentence
+	if i := strings.IndexByte(tail, '\n'); i >= 0 && i < len(tail)/4 {
+		tail = strings.TrimLeft(tail[i:], "\n")
+	}
+	omitted := len(text) - len(head) - len(tail)
+	return fmt.Sprintf("%s\n\n[... %s characters omitted ...]\n\n%s", head, formatCount(omitted), tail)
+}
+
+// cutAtBoundary shortens text to at most n characters, at the last paragraph
+// or sentence break in the second half of that span
+func cutAtBoundary(text string, n int) string {
+	if len(text) <= n {
+		return text
+	}
+	cut := text[:n]
+	if i := strings.LastIndex(cut, "\n\n"); i > n/2 {
+		return cut[:i]
+	}
+	if i := strings.LastIndexAny(cut, ".\n"); i > n/2 {
+		return cut[:i+1]
+	}
+	return cut
+}
+
+// chunkText splits text into chunks of about size characters at paragraph
+// breaks; a paragraph longer than size is split on its own
+func chunkText(text string, size int) []string {
+	var chunks []string
+	var current strings.Builder
+	for _, para := range strings.Split(text, "\n\n") {
+		for len(para) > size {
+			piece := cutAtBoundary(para, size)
+			if piece == "" {
+				piece = para[:size]
+			}
+			if current.Len() > 0 {
+				chunks = append(chunks, current.String())
+				current.Reset()
+			}
+			chunks = append(chunks, piece)
+			para = strings.TrimLeft(para[len(piece):], " \n")
+		}
+		if current.Len() > 0 && current.Len()+len(para) > size {
+			chunks = append(chunks, current.String())
+			current.Reset()
+		}
+		if current.Len() > 0 {
+			current.WriteString("\n\n")
+		}
+		current.WriteString(para)
+	}
+	if strings.TrimSpace(current.String()) != "" {
+		chunks = append(chunks, current.String())
+	}
+	return chunks
+}
+
+// relevantChunks keeps the chunks of text most similar to topic by embedding,
+// in their original order. The first chunk, which introduces the subject, is
+// always kept.
+func relevantChunks(ctx context.Context, client *openai.Client, text, topic string, maxChars int) (string, error) {
+	chunks := chunkText(text, truncateChunkChars)
+	if len(chunks) < 2 {
+		return cutAtBoundary(text, maxChars), nil
+	}
+	vectors, err := embedTexts(ctx, client, append([]string{topic}, chunks...))
+	if err != nil {
+		return "", err
+	}
+
+	order := make([]int, len(chunks)-1)
+	for i := range order {
+		order[i] = i + 1
+	}
+	score := func(i int) float64 { return cosineSimilarity(vectors[0], vectors[i+1]) }
+	sort.SliceStable(order, func(a, b int) bool { return score(order[a]) > score(order[b]) })
+
+	keep := map[int]bool{0: true}
+	used := len(chunks[0])
+	for _, i := range order {
+		if used+len(chunks[i]) > maxChars {
+			continue
+		}
+		keep[i] = true
+		used += len(chunks[i])
+	}
+
+	var b strings.Builder
+	kept := 0
+	for i, chunk := range chunks {
+		if !keep[i] {
+			continue
+		}
+		if b.Len() > 0 {
+			if keep[i-1] {
+				b.WriteString("\n\n")
+			} else {
+				b.WriteString("\n\n[...]\n\n")
+			}
+		}
+		b.WriteString(chunk)
+		kept++
+	}
+	logVerbose("Kept %d of %d chunks by relevance to %q", kept, len(chunks), topic)
+	return b.String(), nil
+}
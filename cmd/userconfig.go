@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+var (
+	userConfigPath string
+	promptDir      string
+	outputLanguage string
+)
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&userConfigPath, "user-config", "", "Personal config file of flag defaults (default: ~/.megafone.yaml)")
+	rootCmd.PersistentFlags().StringVar(&promptDir, "prompt-dir", "prompts", "Directory of prompt templates; default template paths (prompts/...) resolve here")
+	rootCmd.PersistentFlags().StringVar(&outputLanguage, "language", "en", "Language to write posts in, as a code; posts go to content/posts/<language>")
+}
+
+// applyUserConfig sets every flag not passed on the command line from the
+// personal config file, so site-source, model, keys, and the like needn't be
+// repeated on every invocation. Values under a command's name apply only to
+// that command (generate: {model: gpt-4o-mini}) and win over top-level ones.
+// It runs before applyEnvFlags, so a flag wins over the config file, which
+// wins over the environment. The env block sets environment variables for
+// keys that aren't flags (GITHUB_TOKEN and the like), and the credentials
+// block sets the social platforms' variables by platform.
+func applyUserConfig(cmd *cobra.Command) error {
+	path := firstNonEmpty(userConfigPath, os.Getenv(envVarForFlag("user-config")))
+	file := path
+	if file == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil
+		}
+		file = filepath.Join(home, ".megafone.yaml")
+	}
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		if path != "" {
+			return fmt.Errorf("config file %s not found", path)
+		}
+		return nil
+	}
+	v := viper.New()
+	v.SetConfigFile(file)
+	if err := v.ReadInConfig(); err != nil {
+		return fmt.Errorf("failed to read %s: %w", file, err)
+	}
+
+	var errs []string
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if f.Changed || f.Name == "help" || f.Name == "user-config" {
+			return
+		}
+		key := ""
+		for _, candidate := range []string{cmd.Name() + "." + f.Name, f.Name} {
+			if v.IsSet(candidate) {
+				key = candidate
+				break
+			}
+		}
+		if key == "" {
+			return
+		}
+		if err := cmd.Flags().Set(f.Name, configFlagValue(v.Get(key))); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", key, err))
+		}
+	})
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid setting in %s: %s", v.ConfigFileUsed(), strings.Join(errs, "; "))
+	}
+
+	env := v.GetStringMapString("env")
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		// viper lowercases keys; environment variables are conventionally upper case
+		os.Setenv(strings.ToUpper(name), env[name])
+	}
+	// credentials: {mastodon: {access_token: ...}} sets MASTODON_ACCESS_TOKEN
+	for platform := range v.GetStringMap("credentials") {
+		for key, value := range v.GetStringMapString("credentials." + platform) {
+			os.Setenv(strings.ToUpper(platform+"_"+key), value)
+		}
+	}
+	logVerbose("Loaded settings from %s", v.ConfigFileUsed())
+	return nil
+}
+
+// configFlagValue renders a config value the way it would be typed as a flag;
+// lists become comma-separated
+func configFlagValue(value interface{}) string {
+	if list, ok := value.([]interface{}); ok {
+		items := make([]string, len(list))
+		for i, item := range list {
+			items[i] = fmt.Sprint(item)
+		}
+		return strings.Join(items, ",")
+	}
+	if s, ok := value.(string); ok && strings.HasPrefix(s, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, s[2:])
+		}
+	}
+	return fmt.Sprint(value)
+}
+
+// resolvePromptPath points the built-in prompts/ template paths at --prompt-dir
+func resolvePromptPath(path string) string {
+	if rest, ok := strings.CutPrefix(filepath.ToSlash(path), "prompts/"); ok && promptDir != "prompts" {
+		return filepath.Join(promptDir, filepath.FromSlash(rest))
+	}
+	return path
+}
+
+// postsDir is the directory new posts are written to: content/posts/<language>
+func postsDir(basePath string) string {
+	return filepath.Join(basePath, "content", "posts", outputLanguage)
+}
+
+// languagePromptSection asks for the post in --language when it isn't English
+func languagePromptSection() string {
+	if outputLanguage == "" || outputLanguage == "en" {
+		return ""
+	}
+	return fmt.Sprintf("\n\nIMPORTANT: Write the post, including its title, description, and headings, in %s. Keep front matter keys, code, and URLs as they are.\n", languageName(outputLanguage))
+}
@@ -0,0 +1,677 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	validateFix      bool
+	validateExternal bool
+	validateStrict   bool
+	validateFormat   string
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate [path]",
+	Short: "Check existing site content for broken front matter, links, images, and shortcodes",
+	Long: `Runs megafone's validation checks across the site's content, or a single file
+or directory under it:
+
+  frontmatter  front matter parses, has a title and a valid date, tags is a list, draft is a boolean
+  markdown     unclosed code fences, body H1s, stray whitespace
+  links        internal links and ref/relref shortcodes point at existing content
+               (external links too with --external)
+  images       hero and body images exist in assets, static, or the page bundle
+  shortcodes   every shortcode exists in layouts/, a theme, or Hugo, and paired ones are closed
+  a11y         images have alt text, heading levels don't skip, link text isn't "click
+               here", tables have headers, and nothing is described by color alone
+
+Prints every issue and a summary, and exits non-zero when there are errors
+(or warnings with --strict), so it works as a pre-commit or CI gate. --fix
+repairs whitespace issues, skipped heading levels, and alt text a caption or
+title already supplies, in place.
+
+Examples:
+  # Check the whole site
+  megafone validate -s ~/hugo
+
+  # Check one post and fix what can be fixed
+  megafone validate ~/hugo/content/posts/en/my-post.md --fix
+
+  # CI gate including external links, as JSON
+  megafone validate -s ~/hugo --external --strict --format json`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runValidate(args); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+
+	validateCmd.Flags().StringVarP(&siteSource, "site-source", "s", "", "Path to local Hugo site repository (default: the site containing [path])")
+	validateCmd.Flags().BoolVar(&validateFix, "fix", false, "Fix whitespace, skipped heading levels, and alt text that a caption or title supplies, in place")
+	validateCmd.Flags().BoolVar(&validateExternal, "external", false, "Also check that external links respond (slower)")
+	validateCmd.Flags().BoolVar(&validateStrict, "strict", false, "Exit non-zero on warnings as well as errors")
+	validateCmd.Flags().StringVar(&validateFormat, "format", "text", "Report format: text or json")
+}
+
+// validationIssue is one problem found in a content file
+type validationIssue struct {
+	Path     string `json:"path"`
+	Line     int    `json:"line,omitempty"`
+	Check    string `json:"check"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	Fixed    bool   `json:"fixed,omitempty"`
+}
+
+const (
+	severityError   = "error"
+	severityWarning = "warning"
+)
+
+var validationChecks = []string{"frontmatter", "markdown", "links", "images", "shortcodes", "a11y"}
+
+// hugoBuiltinShortcodes are always available without a layout file
+var hugoBuiltinShortcodes = map[string]bool{
+	"comment": true, "details": true, "figure": true, "gist": true, "highlight": true,
+	"instagram": true, "param": true, "qr": true, "ref": true, "relref": true,
+	"tweet": true, "vimeo": true, "x": true, "youtube": true,
+}
+
+var (
+	markdownLinkOrImageRegex = regexp.MustCompile(`(!?)\[([^\]]*)\]\(([^)\s]+)(?:\s+"[^"]*")?\)`)
+	refShortcodeRegex        = regexp.MustCompile(`\{\{[<%]\s*(?:rel)?ref\s+"([^"]+)"\s*[>%]\}\}`)
+	shortcodeNameRegex       = regexp.MustCompile(`\{\{[<%]\s*(/?)\s*([\w./-]+)`)
+	shortcodeCommentRegex    = regexp.MustCompile(`\{\{[<%]/\*.*?\*/[>%]\}\}`)
+	headingRegex             = regexp.MustCompile(`^(#{1,6})\s`)
+)
+
+// contentValidator holds what the checks need to know about the whole site
+type contentValidator struct {
+	basePath   string
+	slugs      map[string]bool
+	shortcodes map[string]bool
+
+	mu       sync.Mutex
+	external map[string]string // URL -> problem, "" when fine
+}
+
+func runValidate(args []string) error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+
+	target := ""
+	if len(args) > 0 {
+		target = args[0]
+	}
+	var basePath string
+	if siteSource == "" && target != "" {
+		basePath = findSiteRoot(target)
+		if basePath == "" {
+			return classify(ErrSiteLayout, fmt.Errorf("%s is not inside a Hugo site (no content/ directory above it)", target))
+		}
+	} else {
+		var err error
+		if basePath, err = resolveSitePath(); err != nil {
+			return err
+		}
+	}
+	if target == "" {
+		target = filepath.Join(basePath, "content")
+	}
+
+	files, err := contentFiles(target)
+	if err != nil {
+		return err
+	}
+	v, err := newContentValidator(basePath)
+	if err != nil {
+		return err
+	}
+
+	var issues []validationIssue
+	for _, path := range files {
+		fileIssues, err := v.validateFile(path)
+		if err != nil {
+			return err
+		}
+		issues = append(issues, fileIssues...)
+	}
+
+	errorCount, warningCount, fixedCount := 0, 0, 0
+	for _, issue := range issues {
+		switch {
+		case issue.Fixed:
+			fixedCount++
+		case issue.Severity == severityError:
+			errorCount++
+		default:
+			warningCount++
+		}
+	}
+
+	if validateFormat == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(map[string]interface{}{
+			"files":    len(files),
+			"errors":   errorCount,
+			"warnings": warningCount,
+			"fixed":    fixedCount,
+			"issues":   issues,
+		}); err != nil {
+			return err
+		}
+	} else {
+		printValidationReport(basePath, files, issues)
+		fmt.Printf("\n%d files checked: %d errors, %d warnings, %d fixed\n", len(files), errorCount, warningCount, fixedCount)
+	}
+
+	if errorCount > 0 || (validateStrict && warningCount > 0) {
+		return fmt.Errorf("validation failed: %d errors, %d warnings", errorCount, warningCount)
+	}
+	return nil
+}
+
+// contentFiles lists the markdown files at path, which may be a file or directory
+func contentFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	var files []string
+	err = filepath.WalkDir(path, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && filepath.Ext(p) == ".md" {
+			files = append(files, p)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+func newContentValidator(basePath string) (*contentValidator, error) {
+	v := &contentValidator{
+		basePath:   basePath,
+		slugs:      make(map[string]bool),
+		shortcodes: make(map[string]bool),
+		external:   make(map[string]string),
+	}
+
+	// Every page and section is addressable by its file name, bundle directory, or slug
+	err := filepath.WalkDir(filepath.Join(basePath, "content"), func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			v.slugs[strings.ToLower(d.Name())] = true
+			return nil
+		}
+		if filepath.Ext(p) != ".md" {
+			return nil
+		}
+		name := strings.TrimSuffix(d.Name(), ".md")
+		if name != "index" && name != "_index" {
+			v.slugs[strings.ToLower(name)] = true
+		}
+		if data, err := os.ReadFile(p); err == nil {
+			if slug := frontMatterString(string(data), "slug"); slug != "" {
+				v.slugs[strings.ToLower(slug)] = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to index content: %w", err)
+	}
+
+	layoutDirs := []string{filepath.Join(basePath, "layouts", "shortcodes")}
+	themes, _ := filepath.Glob(filepath.Join(basePath, "themes", "*", "layouts", "shortcodes"))
+	layoutDirs = append(layoutDirs, themes...)
+	for _, dir := range layoutDirs {
+		filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			rel, _ := filepath.Rel(dir, p)
+			name := filepath.ToSlash(rel)
+			name = strings.TrimSuffix(name, filepath.Ext(name))
+			// Output-format variants like name.amp.html
+			name, _, _ = strings.Cut(name, ".")
+			v.shortcodes[name] = true
+			return nil
+		})
+	}
+	return v, nil
+}
+
+// validateFile runs every check on one file, applying fixes when --fix is set
+func (v *contentValidator) validateFile(path string) ([]validationIssue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	content := string(data)
+
+	var issues []validationIssue
+	add := func(line int, check, severity, format string, args ...interface{}) {
+		issues = append(issues, validationIssue{Path: path, Line: line, Check: check, Severity: severity, Message: fmt.Sprintf(format, args...)})
+	}
+
+	v.checkFrontMatter(path, content, add)
+	fixed, fixedIssues := v.checkMarkdown(path, content, add)
+	v.checkLinks(path, content, add)
+	v.checkImages(path, content, add)
+	v.checkShortcodes(content, add)
+	fixed, a11yFixes := v.checkAccessibility(path, content, fixed, add)
+	fixedIssues = append(fixedIssues, a11yFixes...)
+
+	if validateFix && fixed != content {
+		if err := os.WriteFile(path, []byte(fixed), 0644); err != nil {
+			return nil, fmt.Errorf("failed to write fixes to %s: %w", path, err)
+		}
+		recordSiteChange("modified", path, "", false)
+		issues = append(issues, fixedIssues...)
+	} else {
+		for i := range fixedIssues {
+			fixedIssues[i].Fixed = false
+			fixedIssues[i].Message += " (fixable with --fix)"
+		}
+		issues = append(issues, fixedIssues...)
+	}
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Line < issues[j].Line })
+	return issues, nil
+}
+
+type issueFunc func(line int, check, severity, format string, args ...interface{})
+
+func (v *contentValidator) checkFrontMatter(path, content string, add issueFunc) {
+	fm := frontMatterBlock(content)
+	if fm == "" {
+		add(1, "frontmatter", severityError, "missing YAML front matter")
+		return
+	}
+
+	var fields map[string]interface{}
+	raw := strings.TrimPrefix(strings.TrimLeft(fm, "\n"), "---")
+	if err := yaml.Unmarshal([]byte(raw), &fields); err != nil {
+		add(1, "frontmatter", severityError, "front matter is not valid YAML: %v", err)
+		return
+	}
+
+	isSection := filepath.Base(path) == "_index.md"
+	if title, _ := fields["title"].(string); strings.TrimSpace(title) == "" {
+		add(1, "frontmatter", severityError, "missing title")
+	}
+	switch date := fields["date"].(type) {
+	case nil:
+		if !isSection {
+			add(1, "frontmatter", severityError, "missing date")
+		}
+	case time.Time:
+	case string:
+		if _, ok := parsePostDate(date); !ok {
+			add(1, "frontmatter", severityError, "date %q is not a valid date", date)
+		}
+	default:
+		add(1, "frontmatter", severityError, "date %v is not a valid date", date)
+	}
+	if t, ok := fields["tags"]; ok && t != nil {
+		if _, isList := t.([]interface{}); !isList {
+			add(1, "frontmatter", severityError, "tags must be a list")
+		}
+	}
+	if d, ok := fields["draft"]; ok {
+		if _, isBool := d.(bool); !isBool {
+			add(1, "frontmatter", severityError, "draft must be true or false, got %v", d)
+		}
+	}
+	if !isSection {
+		if desc, _ := fields["description"].(string); strings.TrimSpace(desc) == "" {
+			add(1, "frontmatter", severityWarning, "missing description")
+		}
+	}
+}
+
+// checkMarkdown lints the body. Whitespace problems are returned separately,
+// already marked fixed, along with the content as --fix would write it.
+func (v *contentValidator) checkMarkdown(path, content string, add issueFunc) (string, []validationIssue) {
+	var fixes []validationIssue
+	fix := func(line int, message string) {
+		fixes = append(fixes, validationIssue{Path: path, Line: line, Check: "markdown", Severity: severityWarning, Message: message, Fixed: true})
+	}
+
+	lines := strings.Split(content, "\n")
+	bodyStart := 0
+	if fm := frontMatterBlock(content); fm != "" {
+		bodyStart = strings.Count(fm, "\n") + 1
+	}
+
+	var out []string
+	fence, fenceLine := "", 0
+	blankRun := 0
+	for i, line := range lines {
+		n := i + 1
+		trimmed := strings.TrimRight(line, " \t")
+		if trimmed != line && line != trimmed+"  " && fence == "" {
+			fix(n, "trailing whitespace")
+			line = trimmed
+		}
+
+		if i < bodyStart {
+			out = append(out, line)
+			continue
+		}
+
+		stripped := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(stripped, fence) {
+				fence = ""
+			}
+			out = append(out, line)
+			continue
+		}
+		if strings.HasPrefix(stripped, "```") || strings.HasPrefix(stripped, "~~~") {
+			fence, fenceLine = stripped[:3], n
+			blankRun = 0
+			out = append(out, line)
+			continue
+		}
+
+		if stripped == "" {
+			blankRun++
+			if blankRun > 1 && i < len(lines)-1 {
+				fix(n, "repeated blank line")
+				continue
+			}
+		} else {
+			blankRun = 0
+		}
+
+		if m := headingRegex.FindStringSubmatch(line); m != nil {
+			level := len(m[1])
+			if level == 1 {
+				add(n, "markdown", severityWarning, "H1 in body; the title is already rendered as the page heading")
+			}
+		}
+		for _, m := range markdownLinkOrImageRegex.FindAllStringSubmatch(line, -1) {
+			if m[1] == "" && strings.TrimSpace(m[2]) == "" {
+				add(n, "markdown", severityWarning, "link to %s has no text", m[3])
+			}
+		}
+		out = append(out, line)
+	}
+	if fence != "" {
+		add(fenceLine, "markdown", severityError, "code fence opened here is never closed")
+	}
+
+	fixed := strings.Join(out, "\n")
+	if !strings.HasSuffix(fixed, "\n") {
+		fix(len(lines), "missing final newline")
+		fixed += "\n"
+	}
+	return fixed, fixes
+}
+
+// checkAccessibility reports a11y findings against the original content and
+// applies the deterministic fixes to fixed, the content as --fix would write it
+func (v *contentValidator) checkAccessibility(path, content, fixed string, add issueFunc) (string, []validationIssue) {
+	var fixes []validationIssue
+	_, findings := auditAccessibility(content)
+	for _, f := range findings {
+		if f.Fixed {
+			fixes = append(fixes, validationIssue{Path: path, Line: f.Line, Check: "a11y", Severity: severityWarning, Message: f.Message, Fixed: true})
+		} else {
+			add(f.Line, "a11y", severityWarning, "%s", f.Message)
+		}
+	}
+	fixed, _ = auditAccessibility(fixed)
+	return fixed, fixes
+}
+
+func (v *contentValidator) checkLinks(path, content string, add issueFunc) {
+	body := postBody(content)
+	offset := strings.Count(content[:len(content)-len(body)], "\n")
+
+	for i, line := range strings.Split(body, "\n") {
+		n := offset + i + 1
+		for _, m := range markdownLinkOrImageRegex.FindAllStringSubmatch(line, -1) {
+			if m[1] == "!" {
+				continue // images are checked separately
+			}
+			target := m[3]
+			switch {
+			case strings.HasPrefix(target, "http://"), strings.HasPrefix(target, "https://"):
+				if validateExternal {
+					if problem := v.checkExternal(target); problem != "" {
+						add(n, "links", severityWarning, "%s: %s", target, problem)
+					}
+				}
+			case strings.HasPrefix(target, "#"), strings.HasPrefix(target, "mailto:"), strings.Contains(target, "{{"):
+			default:
+				if !v.internalTargetExists(path, target) {
+					add(n, "links", severityError, "broken internal link %s", target)
+				}
+			}
+		}
+		for _, m := range refShortcodeRegex.FindAllStringSubmatch(line, -1) {
+			if !v.internalTargetExists(path, m[1]) {
+				add(n, "links", severityError, "ref target %q not found", m[1])
+			}
+		}
+	}
+}
+
+// internalTargetExists resolves a site-relative or page-relative link against
+// static files, the page's directory, and known page slugs
+func (v *contentValidator) internalTargetExists(pagePath, target string) bool {
+	target, _, _ = strings.Cut(target, "#")
+	target, _, _ = strings.Cut(target, "?")
+	if target == "" || target == "/" {
+		return true
+	}
+
+	var candidates []string
+	if strings.HasPrefix(target, "/") {
+		candidates = append(candidates,
+			filepath.Join(v.basePath, "static", target),
+			filepath.Join(v.basePath, "content", target),
+			filepath.Join(v.basePath, "content", strings.TrimSuffix(target, "/")+".md"))
+	} else {
+		candidates = append(candidates,
+			filepath.Join(filepath.Dir(pagePath), target),
+			filepath.Join(v.basePath, "content", target))
+	}
+	for _, c := range candidates {
+		if _, err := os.Stat(c); err == nil {
+			return true
+		}
+	}
+
+	last := strings.TrimSuffix(strings.TrimSuffix(target, "/"), ".md")
+	return v.slugs[strings.ToLower(filepath.Base(last))]
+}
+
+// checkExternal requests a URL once per run, falling back to GET for servers that reject HEAD
+func (v *contentValidator) checkExternal(url string) string {
+	v.mu.Lock()
+	if problem, ok := v.external[url]; ok {
+		v.mu.Unlock()
+		return problem
+	}
+	v.mu.Unlock()
+
+	client := &http.Client{Timeout: 15 * time.Second}
+	problem := ""
+	resp, err := client.Head(url)
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusForbidden) {
+		resp.Body.Close()
+		resp, err = client.Get(url)
+	}
+	if err != nil {
+		problem = err.Error()
+	} else {
+		resp.Body.Close()
+		if resp.StatusCode >= 400 {
+			problem = resp.Status
+		}
+	}
+
+	v.mu.Lock()
+	v.external[url] = problem
+	v.mu.Unlock()
+	return problem
+}
+
+func (v *contentValidator) checkImages(path, content string, add issueFunc) {
+	if hero := frontMatterString(content, "hero"); hero != "" && !v.imageExists(path, hero) {
+		add(1, "images", severityError, "hero image %s not found", hero)
+	}
+
+	body := postBody(content)
+	offset := strings.Count(content[:len(content)-len(body)], "\n")
+	for i, line := range strings.Split(body, "\n") {
+		var srcs []string
+		for _, m := range markdownLinkOrImageRegex.FindAllStringSubmatch(line, -1) {
+			if m[1] == "!" {
+				srcs = append(srcs, m[3])
+			}
+		}
+		for _, sc := range figureShortcodeRegex.FindAllString(line, -1) {
+			if src := shortcodeArgs(sc)["src"]; src != "" {
+				srcs = append(srcs, src)
+			}
+		}
+		for _, src := range srcs {
+			if !v.imageExists(path, src) {
+				add(offset+i+1, "images", severityError, "image %s not found", src)
+			}
+		}
+	}
+}
+
+func (v *contentValidator) imageExists(pagePath, src string) bool {
+	if strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://") || strings.HasPrefix(src, "data:") {
+		if validateExternal && !strings.HasPrefix(src, "data:") {
+			return v.checkExternal(src) == ""
+		}
+		return true
+	}
+	var candidates []string
+	if strings.HasPrefix(src, "/") {
+		candidates = []string{
+			filepath.Join(v.basePath, "assets", src),
+			filepath.Join(v.basePath, "static", src),
+		}
+	} else {
+		candidates = []string{
+			filepath.Join(filepath.Dir(pagePath), src),
+			filepath.Join(v.basePath, "assets", src),
+		}
+	}
+	for _, c := range candidates {
+		if _, err := os.Stat(c); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *contentValidator) checkShortcodes(content string, add issueFunc) {
+	content = shortcodeCommentRegex.ReplaceAllString(content, "")
+	lines := strings.Split(content, "\n")
+
+	type open struct{ line, count int }
+	opens := make(map[string]*open)
+	closes := make(map[string]int)
+	for i, line := range lines {
+		for _, m := range shortcodeNameRegex.FindAllStringSubmatch(line, -1) {
+			closing, name := m[1] == "/", m[2]
+			if closing {
+				closes[name]++
+				continue
+			}
+			if opens[name] == nil {
+				opens[name] = &open{line: i + 1}
+			}
+			opens[name].count++
+			if !hugoBuiltinShortcodes[name] && !v.shortcodes[name] {
+				add(i+1, "shortcodes", severityError, "unknown shortcode %q (no layouts/shortcodes/%s.html)", name, name)
+			}
+		}
+	}
+	for name, n := range closes {
+		if o := opens[name]; o == nil || o.count < n {
+			add(0, "shortcodes", severityError, "closing {{< /%s >}} without an opening shortcode", name)
+		} else if o.count > n {
+			add(o.line, "shortcodes", severityError, "%d %s shortcodes opened but only %d closed", o.count, name, n)
+		}
+	}
+}
+
+func printValidationReport(basePath string, files []string, issues []validationIssue) {
+	byCheck := make(map[string][2]int)
+	current := ""
+	for _, issue := range issues {
+		rel, err := filepath.Rel(basePath, issue.Path)
+		if err != nil {
+			rel = issue.Path
+		}
+		if rel != current {
+			fmt.Printf("\n%s\n", rel)
+			current = rel
+		}
+		status := issue.Severity
+		if issue.Fixed {
+			status = "fixed"
+		}
+		if issue.Line > 0 {
+			fmt.Printf("  %4d  %-7s [%s] %s\n", issue.Line, status, issue.Check, issue.Message)
+		} else {
+			fmt.Printf("        %-7s [%s] %s\n", status, issue.Check, issue.Message)
+		}
+
+		counts := byCheck[issue.Check]
+		if issue.Severity == severityError && !issue.Fixed {
+			counts[0]++
+		} else if !issue.Fixed {
+			counts[1]++
+		}
+		byCheck[issue.Check] = counts
+	}
+
+	fmt.Println("\nSummary:")
+	for _, check := range validationChecks {
+		counts := byCheck[check]
+		mark := "✅"
+		if counts[0] > 0 {
+			mark = "❌"
+		} else if counts[1] > 0 {
+			mark = "⚠️ "
+		}
+		fmt.Printf("  %s %-12s %d errors, %d warnings\n", mark, check, counts[0], counts[1])
+	}
+}
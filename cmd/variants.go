@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const (
+	variantSimple = "simple"
+
+	variantPlacementCompanion = "companion"
+	variantPlacementSection   = "section"
+)
+
+var (
+	postVariants     []string
+	variantPlacement string
+)
+
+// checkVariantFlags rejects unknown --variants and --variant-placement values
+// before anything is generated
+func checkVariantFlags() error {
+	for _, v := range postVariants {
+		if v != variantSimple {
+			return fmt.Errorf("unknown variant %q (supported: simple)", v)
+		}
+	}
+	if variantPlacement != variantPlacementCompanion && variantPlacement != variantPlacementSection {
+		return fmt.Errorf("invalid --variant-placement %q (use companion or section)", variantPlacement)
+	}
+	return nil
+}
+
+func wantVariant(name string) bool {
+	for _, v := range postVariants {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
+
+// writeSimpleVariant rewrites a post for readers new to its subject. As a
+// section it is a short plain-language overview without headings; as a
+// companion it is a full beginner's version of the post.
+func writeSimpleVariant(ctx context.Context, client *openai.Client, content string, section bool) (string, error) {
+	shape := `Write a complete beginner's version of the post in Markdown. Keep its structure where it helps,
+using ## and ### headings, but cut detail a newcomer doesn't need. Keep code only when it is
+essential, and explain it line by line.`
+	if section {
+		shape = `Write a plain-language overview of at most 200 words in Markdown, without headings or code,
+for a reader who would otherwise be lost in the post.`
+	}
+
+	prompt := fmt.Sprintf(`Title: %s
+
+Post:
+%s
+
+Rewrite this blog post for readers who are new to its subject. %s
+
+Define each technical term the first time it is used, prefer short sentences and concrete
+examples, and keep every fact consistent with the post. Don't add claims, numbers, or
+recommendations the post doesn't make. Respond with only the Markdown, without front matter
+or a title.`, frontMatterString(content, "title"), summarizeTokens(8000, postBody(content)), shape)
+
+	resp, err := createChatCompletion(ctx, client, openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: "You explain technical writing to beginners in the author's voice, without talking down to them and without inventing anything.",
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: prompt,
+			},
+		},
+		Temperature: 0.5,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to write the simple variant: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("no response from model")
+	}
+
+	simple := strings.TrimSpace(resp.Choices[0].Message.Content)
+	simple = strings.TrimSuffix(strings.TrimPrefix(simple, "```markdown\n"), "\n```")
+	if simple == "" {
+		return "", fmt.Errorf("model returned an empty simple variant")
+	}
+	return simple, nil
+}
+
+// prependBody puts text at the start of a post's body, after the front matter
+func prependBody(content, text string) string {
+	fm := frontMatterBlock(content)
+	if fm == "" {
+		return text + "\n\n" + strings.TrimLeft(content, "\n")
+	}
+	return fm + "\n---\n\n" + text + "\n\n" + postBody(content)
+}
+
+// addSimpleSection puts the simple variant at the top of the post, collapsed
+// in Hugo's details shortcode so experienced readers can skip it
+func addSimpleSection(content, simple string) string {
+	section := `{{< details summary="New to this? Start with the short version" >}}` + "\n" + simple + "\n" + `{{< /details >}}`
+	return prependBody(content, section)
+}
+
+// simpleCompanionPath is where the companion of a post is written: beside it, with a -simple suffix
+func simpleCompanionPath(postPath string) string {
+	return strings.TrimSuffix(postPath, ".md") + "-" + variantSimple + ".md"
+}
+
+// simpleCompanion builds the beginner's companion of a post. It shares the
+// post's front matter except for the title, slug, and URL history, is marked
+// with variant and variant_of, and links back to the post.
+func simpleCompanion(content, simple, postPath string) string {
+	fm := frontMatterBlock(content)
+	companion := fm + "\n---\n\n"
+	if fm == "" {
+		companion = "---\n---\n\n"
+	}
+	companion = removeFrontMatterField(companion, "aliases")
+	companion = removeFrontMatterField(companion, "translationKey")
+	title := frontMatterString(content, "title")
+	companion = upsertFrontMatterField(companion, "title", yamlQuote(title+" (for beginners)"))
+	if slug := frontMatterString(content, "slug"); slug != "" {
+		companion = upsertFrontMatterField(companion, "slug", yamlQuote(slug+"-"+variantSimple))
+	}
+	companion = upsertFrontMatterField(companion, "variant", yamlQuote(variantSimple))
+	companion = upsertFrontMatterField(companion, "variant_of", yamlQuote(filepath.Base(postPath)))
+
+	backlink := fmt.Sprintf(`*This is the beginner-friendly version of [%s]({{< relref %q >}}).*`, title, filepath.Base(postPath))
+	return companion + backlink + "\n\n" + applyAccessibility(simple) + "\n"
+}
+
+// linkSimpleCompanion points readers of a post to its beginner's companion
+func linkSimpleCompanion(content, companionPath string) string {
+	note := fmt.Sprintf(`*New to this? Read the [beginner-friendly version]({{< relref %q >}}).*`, filepath.Base(companionPath))
+	if strings.Contains(content, note) {
+		return content
+	}
+	return prependBody(content, note)
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+var notesAudioPath string
+
+// whisperMaxBytes is the largest file the transcription API accepts (25 MB),
+// less some room for the multipart encoding
+const whisperMaxBytes = 24 << 20
+
+// whisperFormats are the audio formats the transcription API reads directly
+var whisperFormats = map[string]bool{
+	".flac": true, ".m4a": true, ".mp3": true, ".mp4": true, ".mpeg": true,
+	".mpga": true, ".oga": true, ".ogg": true, ".wav": true, ".webm": true,
+}
+
+// transcribeVoiceMemo transcribes a voice memo with Whisper. Transcripts are
+// cached by the audio's hash, so regenerating a post doesn't pay for the same
+// memo twice. Memos too large for the API, or in other formats, are converted
+// to small mono MP3s with ffmpeg, split into 20-minute parts if needed.
+func transcribeVoiceMemo(ctx context.Context, apiKey, path, topic string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read voice memo: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	cachePath := ""
+	if cacheDir, err := os.UserCacheDir(); err == nil {
+		cachePath = filepath.Join(cacheDir, "megafone", "transcripts", hex.EncodeToString(sum[:16])+".txt")
+		if cached, err := os.ReadFile(cachePath); err == nil {
+			logInfo("🎙️  Using the cached transcript of %s", path)
+			return string(cached), nil
+		}
+	}
+
+	parts := []string{path}
+	if len(data) > whisperMaxBytes || !whisperFormats[strings.ToLower(filepath.Ext(path))] {
+		tmpDir, err := os.MkdirTemp("", "megafone-memo")
+		if err != nil {
+			return "", err
+		}
+		defer os.RemoveAll(tmpDir)
+		if parts, err = splitVoiceMemo(path, tmpDir); err != nil {
+			return "", err
+		}
+	}
+
+	logInfo("🎙️  Transcribing %s (%d KB)...", path, len(data)/1024)
+	client := openai.NewClient(apiKey)
+	var transcript []string
+	for i, part := range parts {
+		resp, err := client.CreateTranscription(ctx, openai.AudioRequest{
+			Model:    openai.Whisper1,
+			FilePath: part,
+			// The topic helps with the spelling of names and jargon
+			Prompt: topic,
+			Format: openai.AudioResponseFormatText,
+		})
+		if err != nil {
+			return "", fmt.Errorf("transcription API error (part %d of %d): %w", i+1, len(parts), err)
+		}
+		transcript = append(transcript, strings.TrimSpace(resp.Text))
+	}
+	text := strings.TrimSpace(strings.Join(transcript, "\n\n"))
+	if text == "" {
+		return "", fmt.Errorf("no speech found in %s", path)
+	}
+	logVerbose("Transcript: %s", text)
+
+	if cachePath != "" {
+		if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err == nil {
+			os.WriteFile(cachePath, []byte(text), 0644)
+		}
+	}
+	return text, nil
+}
+
+// splitVoiceMemo converts a memo to 32 kbps mono MP3 parts of at most 20
+// minutes (about 5 MB each) in dir, and returns their paths in order
+func splitVoiceMemo(path, dir string) ([]string, error) {
+	ffmpeg, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return nil, fmt.Errorf("%s is too large or not a format the transcription API reads, and ffmpeg isn't in PATH to convert it", path)
+	}
+	cmd := exec.Command(ffmpeg, "-y", "-loglevel", "error", "-i", path,
+		"-vn", "-ac", "1", "-ar", "16000", "-b:a", "32k",
+		"-f", "segment", "-segment_time", "1200", filepath.Join(dir, "part%03d.mp3"))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("ffmpeg failed: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	parts, err := filepath.Glob(filepath.Join(dir, "part*.mp3"))
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("ffmpeg found no audio in %s", path)
+	}
+	sort.Strings(parts)
+	return parts, nil
+}
+
+// voiceMemoNotes frames a transcript as the author's notes. Speech rambles,
+// so the model is told to pull the views out of it and keep the author's
+// phrasing, while building the structure itself.
+func voiceMemoNotes(transcript string) string {
+	return `Transcript of the author talking through the topic (spoken and unedited:
+pull out their views, examples, and turns of phrase, and quote or closely
+paraphrase the memorable ones; ignore false starts and filler; the structure of
+the post is yours to build, the opinions are theirs):
+
+` + transcript
+}
@@ -0,0 +1,268 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// workspaceSchema is the .megafone layout this version reads and writes.
+// Bump it with a new entry in workspaceMigrations whenever state files move.
+const workspaceSchema = 2
+
+var workspaceMigrateDryRun bool
+
+// workspaceMeta is .megafone/workspace.json, which records the layout version
+type workspaceMeta struct {
+	Schema   int    `json:"schema"`
+	Migrated string `json:"migrated,omitempty"`
+}
+
+// workspaceMove is a state file or directory that moves between two layouts,
+// relative to .megafone
+type workspaceMove struct {
+	From, To string
+}
+
+// workspaceMigrations lists the moves that upgrade a workspace from schema n
+// (the key) to n+1
+var workspaceMigrations = map[int][]workspaceMove{
+	// Schema 1 kept every state file at the top of .megafone
+	1: {
+		{"schedule.json", "state/schedule.json"},
+		{"crossposts.json", "state/crossposts.json"},
+		{"experiments.json", "state/experiments.json"},
+		{"monitor.json", "state/monitor.json"},
+		{"metrics.jsonl", "history/metrics.jsonl"},
+		{"context.jsonl", "history/context.jsonl"},
+	},
+}
+
+var workspaceCmd = &cobra.Command{
+	Use:   "workspace",
+	Short: "Manage the site's .megafone state directory",
+	Long: `megafone keeps everything it knows about a site in .megafone/ in the site
+repository, so the state is committed and travels with the site:
+
+  workspace.json        layout version
+  journal.jsonl         every file megafone created, changed, or deleted
+  state/                publishing queue, cross-posts, experiments, source monitor
+  history/              metrics snapshots and prompt context budgets
+  index/                post embeddings
+  translation-memory/   translated paragraphs and glossaries per language pair
+  notices/              suggested correction notices
+  transactions/         undo sets of bulk changes
+  cache/                parsed posts and fetched sources, safe to delete and
+                        kept out of git by .megafone/.gitignore
+
+When an upgrade changes the layout, commands stop with a pointer to
+workspace migrate.`,
+}
+
+var workspaceMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Move the site's .megafone state to the current layout",
+	Long: `Moves state files written by an older megafone to where this version expects
+them and records the new layout version in .megafone/workspace.json. Commit the
+result so every checkout of the site picks it up.
+
+Examples:
+  # Preview the moves
+  megafone workspace migrate -s ~/hugo --dry-run
+
+  megafone workspace migrate -s ~/hugo`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runWorkspaceMigrate(); err != nil {
+			exitWithError(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(workspaceCmd)
+	workspaceCmd.AddCommand(workspaceMigrateCmd)
+
+	workspaceMigrateCmd.Flags().StringVarP(&siteSource, "site-source", "s", "", "Path to local Hugo site repository")
+	workspaceMigrateCmd.Flags().BoolVarP(&workspaceMigrateDryRun, "dry-run", "d", false, "List the moves without changing any files")
+}
+
+// workspacePath returns a path inside the site's .megafone directory
+func workspacePath(basePath string, elem ...string) string {
+	return filepath.Join(append([]string{basePath, ".megafone"}, elem...)...)
+}
+
+// workspaceSchemaOf returns the layout version of a site's workspace. Without
+// workspace.json, a workspace holding schema 1 files is schema 1, and anything
+// else (including no .megafone at all) is already current.
+func workspaceSchemaOf(basePath string) (int, error) {
+	data, err := os.ReadFile(workspacePath(basePath, "workspace.json"))
+	if err == nil {
+		var meta workspaceMeta
+		if err := json.Unmarshal(data, &meta); err != nil || meta.Schema < 1 {
+			return 0, fmt.Errorf("unreadable %s", workspacePath(basePath, "workspace.json"))
+		}
+		return meta.Schema, nil
+	}
+	if !os.IsNotExist(err) {
+		return 0, err
+	}
+	for _, move := range workspaceMigrations[1] {
+		if _, err := os.Stat(workspacePath(basePath, move.From)); err == nil {
+			return 1, nil
+		}
+	}
+	return workspaceSchema, nil
+}
+
+// checkWorkspace refuses to run against a workspace in another layout, where
+// state would be silently missed or clobbered
+func checkWorkspace(basePath string) error {
+	if err := checkWorkspaceSchema(basePath); err != nil {
+		return err
+	}
+
+	if info, err := os.Stat(workspacePath(basePath)); err != nil || !info.IsDir() {
+		return nil
+	}
+	// Stamp workspaces started before workspace.json existed, so later
+	// migrations know where they begin
+	if _, err := os.Stat(workspacePath(basePath, "workspace.json")); os.IsNotExist(err) {
+		if err := writeWorkspaceMeta(basePath); err != nil {
+			logError("Failed to record the workspace version: %v", err)
+		}
+	}
+	if err := ensureWorkspaceGitignore(basePath); err != nil {
+		logError("Failed to write %s: %v", workspacePath(basePath, ".gitignore"), err)
+	}
+	return nil
+}
+
+// checkWorkspaceSchema is the part of checkWorkspace that only reads, for
+// commands that must leave the workspace as they found it
+func checkWorkspaceSchema(basePath string) error {
+	schema, err := workspaceSchemaOf(basePath)
+	if err != nil {
+		return classify(ErrSiteLayout, err)
+	}
+	switch {
+	case schema < workspaceSchema:
+		return classify(ErrSiteLayout, fmt.Errorf("%s uses the layout of an older megafone (schema %d, current %d); run: megafone workspace migrate -s %s", workspacePath(basePath), schema, workspaceSchema, basePath))
+	case schema > workspaceSchema:
+		return classify(ErrSiteLayout, fmt.Errorf("%s was written by a newer megafone (schema %d; this version reads up to %d); upgrade megafone", workspacePath(basePath), schema, workspaceSchema))
+	}
+	return nil
+}
+
+// ensureWorkspaceGitignore makes .megafone/.gitignore exclude cache/, which
+// holds copies of every post and fetched source and shouldn't be committed
+func ensureWorkspaceGitignore(basePath string) error {
+	path := workspacePath(basePath, ".gitignore")
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if gitignoreHasCache(data) {
+		return nil
+	}
+	if len(data) > 0 && !strings.HasSuffix(string(data), "\n") {
+		data = append(data, '\n')
+	}
+	if err := os.MkdirAll(workspacePath(basePath), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, "cache/\n"...), 0644)
+}
+
+// workspaceIgnoresCache reports whether .megafone/.gitignore already excludes cache/
+func workspaceIgnoresCache(basePath string) bool {
+	data, err := os.ReadFile(workspacePath(basePath, ".gitignore"))
+	return err == nil && gitignoreHasCache(data)
+}
+
+func gitignoreHasCache(data []byte) bool {
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line == "cache/" || line == "cache" || line == "/cache/" {
+			return true
+		}
+	}
+	return false
+}
+
+func runWorkspaceMigrate() error {
+	if err := initLogger(); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+
+	basePath, err := locateSitePath()
+	if err != nil {
+		return err
+	}
+	schema, err := workspaceSchemaOf(basePath)
+	if err != nil {
+		return classify(ErrSiteLayout, err)
+	}
+	if schema > workspaceSchema {
+		return checkWorkspace(basePath)
+	}
+	if _, err := os.Stat(workspacePath(basePath, "workspace.json")); err == nil && schema == workspaceSchema {
+		logInfo("✅ %s is already at schema %d", workspacePath(basePath), schema)
+		return nil
+	}
+
+	return withSiteLock(basePath, "migrating the workspace", func() error {
+		moved := 0
+		for from := schema; from < workspaceSchema; from++ {
+			for _, move := range workspaceMigrations[from] {
+				src, dst := workspacePath(basePath, move.From), workspacePath(basePath, move.To)
+				if _, err := os.Stat(src); os.IsNotExist(err) {
+					continue
+				}
+				if _, err := os.Stat(dst); err == nil {
+					return fmt.Errorf("can't move %s: %s already exists; merge or remove one of them and run again", move.From, move.To)
+				}
+				if workspaceMigrateDryRun {
+					logInfo("Would move .megafone/%s → .megafone/%s", move.From, move.To)
+					moved++
+					continue
+				}
+				if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+					return err
+				}
+				if err := os.Rename(src, dst); err != nil {
+					return fmt.Errorf("failed to move %s: %w", move.From, err)
+				}
+				logInfo("📦 Moved .megafone/%s → .megafone/%s", move.From, move.To)
+				moved++
+			}
+		}
+
+		if workspaceMigrateDryRun {
+			logInfo("Dry run: %d file(s) would move from schema %d to %d", moved, schema, workspaceSchema)
+			return nil
+		}
+		if err := writeWorkspaceMeta(basePath); err != nil {
+			return err
+		}
+		logSuccess("✅ Migrated %s from schema %d to %d (%d file(s) moved); commit .megafone to share it", workspacePath(basePath), schema, workspaceSchema, moved)
+		return nil
+	})
+}
+
+func writeWorkspaceMeta(basePath string) error {
+	data, err := json.MarshalIndent(workspaceMeta{Schema: workspaceSchema, Migrated: time.Now().Format(time.RFC3339)}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(workspacePath(basePath), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(workspacePath(basePath, "workspace.json"), append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return ensureWorkspaceGitignore(basePath)
+}